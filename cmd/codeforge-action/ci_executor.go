@@ -13,6 +13,7 @@ import (
 
 	"github.com/freema/codeforge/internal/prompt"
 	"github.com/freema/codeforge/internal/review"
+	"github.com/freema/codeforge/internal/runas"
 	"github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/runner"
 )
@@ -80,6 +81,9 @@ func (e *CIExecutor) Execute(ctx context.Context) int {
 		"has_system_context", systemContext != "",
 	)
 
+	// As root, drop to the "codeforge" user like the server does.
+	runAs, _ := runas.Default().Identity("")
+
 	startTime := time.Now()
 
 	// Run CLI
@@ -92,6 +96,7 @@ func (e *CIExecutor) Execute(ctx context.Context) int {
 		MCPConfigPath:      mcpConfigPath,
 		AppendSystemPrompt: systemContext,
 		AllowedTools:       e.cfg.AllowedTools,
+		RunAs:              runAs,
 		OnEvent: func(event json.RawMessage) {
 			// Log only event type for progress visibility, not full payload.
 			// Writing full events to stderr causes deadlock when pipe buffer fills.
@@ -9,22 +9,38 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/freema/codeforge/api"
 	"github.com/freema/codeforge/internal/ai"
+	"github.com/freema/codeforge/internal/apispec"
+	"github.com/freema/codeforge/internal/audit"
+	"github.com/freema/codeforge/internal/blobstore"
+	"github.com/freema/codeforge/internal/cabundle"
+	"github.com/freema/codeforge/internal/chaos"
 	"github.com/freema/codeforge/internal/config"
 	"github.com/freema/codeforge/internal/crypto"
 	"github.com/freema/codeforge/internal/database"
+	"github.com/freema/codeforge/internal/hooks"
+	"github.com/freema/codeforge/internal/httpclient"
 	"github.com/freema/codeforge/internal/keys"
 	"github.com/freema/codeforge/internal/logger"
 	"github.com/freema/codeforge/internal/notify"
+	"github.com/freema/codeforge/internal/policy"
+	"github.com/freema/codeforge/internal/proxy"
+	"github.com/freema/codeforge/internal/redact"
 	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/runas"
 	"github.com/freema/codeforge/internal/schedule"
 	"github.com/freema/codeforge/internal/server"
 	"github.com/freema/codeforge/internal/server/handlers"
 	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/stats"
 	"github.com/freema/codeforge/internal/tenant"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/mcp"
 	"github.com/freema/codeforge/internal/tool/runner"
 	"github.com/freema/codeforge/internal/tools"
@@ -42,14 +58,26 @@ func main() {
 		fmt.Println("codeforge", version)
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		// Print the OpenAPI document with schemas generated from the Go types.
+		spec, err := apispec.Build(api.OpenAPISpec)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		_, _ = os.Stdout.Write(spec)
+		return
+	}
 
-	if err := run(); err != nil {
+	// `codeforge agent` runs workers only, without the HTTP server.
+	agent := len(os.Args) > 1 && os.Args[1] == "agent"
+	if err := run(agent); err != nil {
 		slog.Error("fatal error", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
+func run(agent bool) error {
 	// Load config
 	configPath := os.Getenv("CODEFORGE_CONFIG")
 	cfg, err := config.Load(configPath)
@@ -59,7 +87,30 @@ func run() error {
 
 	// Setup logger
 	logger.Setup(cfg.Logging.Level, cfg.Logging.Format)
-	slog.Info("starting codeforge", "version", version)
+	slog.Info("starting codeforge", "version", version, "agent", agent)
+	if agent && cfg.Workers.Concurrency < 1 {
+		return fmt.Errorf("runner agent needs workers.concurrency >= 1")
+	}
+
+	// Trust extra CAs before any outbound request
+	if cfg.TLS.CAFile != "" || cfg.TLS.CAPEM != "" {
+		caPEM, err := cabundle.Load(cfg.TLS.CAFile, cfg.TLS.CAPEM)
+		if err != nil {
+			return fmt.Errorf("loading CA bundle: %w", err)
+		}
+		if err := cabundle.Install(caPEM, filepath.Join(os.TempDir(), "codeforge-ca")); err != nil {
+			return fmt.Errorf("installing CA bundle: %w", err)
+		}
+		slog.Info("custom CA bundle installed", "ca_file", cfg.TLS.CAFile, "inline", cfg.TLS.CAPEM != "")
+	}
+
+	// Export proxy settings before any outbound request (net/http caches them)
+	if err := applyProxy(cfg); err != nil {
+		return fmt.Errorf("applying proxy settings: %w", err)
+	}
+
+	// Shared outbound HTTP pool; cloned from the transport configured above
+	configureHTTPClients(cfg.HTTPClient, cfg.PromptPolicy)
 
 	// Initialize tracing
 	tracingShutdown, err := tracing.Setup(context.Background(), tracing.Config{
@@ -75,7 +126,16 @@ func run() error {
 	defer func() { _ = tracingShutdown(context.Background()) }()
 
 	// Connect to Redis
-	rdb, err := redisclient.New(cfg.Redis.URL, cfg.Redis.Prefix)
+	rdb, err := redisclient.NewWithOptions(cfg.Redis.URL, cfg.Redis.Prefix, redisclient.Options{
+		Username:           cfg.Redis.Username,
+		Password:           cfg.Redis.Password,
+		TLS:                cfg.Redis.TLS.Enabled,
+		CAFile:             cfg.Redis.TLS.CAFile,
+		CertFile:           cfg.Redis.TLS.CertFile,
+		KeyFile:            cfg.Redis.TLS.KeyFile,
+		ServerName:         cfg.Redis.TLS.ServerName,
+		InsecureSkipVerify: cfg.Redis.TLS.InsecureSkipVerify,
+	})
 	if err != nil {
 		return fmt.Errorf("connecting to redis: %w", err)
 	}
@@ -86,7 +146,26 @@ func run() error {
 	if err := rdb.Ping(ctx); err != nil {
 		return fmt.Errorf("redis ping failed: %w", err)
 	}
-	slog.Info("redis connected", "url", cfg.Redis.URL)
+	slog.Info("redis connected", "url", redisclient.RedactURL(cfg.Redis.URL))
+
+	// Fault injection for recovery drills (nil when disabled)
+	var faults *chaos.Injector
+	if cfg.Chaos.Enabled {
+		faults = chaos.New(chaos.Config{
+			RedisDelayRate:  cfg.Chaos.RedisDelayRate,
+			RedisDelayMax:   cfg.Chaos.RedisDelayMax,
+			CLIKillRate:     cfg.Chaos.CLIKillRate,
+			CLIKillAfterMax: cfg.Chaos.CLIKillAfterMax,
+			WebhookDropRate: cfg.Chaos.WebhookDropRate,
+			CloneFailRate:   cfg.Chaos.CloneFailRate,
+		}, cfg.Chaos.Seed)
+		rdb.Unwrap().AddHook(faults.RedisHook())
+		slog.Warn("chaos fault injection enabled — not for production",
+			"redis_delay_rate", cfg.Chaos.RedisDelayRate,
+			"cli_kill_rate", cfg.Chaos.CLIKillRate,
+			"webhook_drop_rate", cfg.Chaos.WebhookDropRate,
+			"clone_fail_rate", cfg.Chaos.CloneFailRate)
+	}
 
 	// Open SQLite database
 	sqliteDB, err := database.Open(cfg.SQLite.Path)
@@ -106,15 +185,104 @@ func run() error {
 		return fmt.Errorf("initializing crypto: %w", err)
 	}
 
-	// Initialize session service
+	// Initialize session service. Agents keep a local SQLite for the
+	// registries but forward session mirror writes to the server.
+	mirrorDB := sqliteDB.Unwrap()
+	if agent {
+		mirrorDB = nil
+	}
 	sessionService := session.NewService(
 		rdb,
 		cryptoSvc,
-		sqliteDB.Unwrap(),
+		mirrorDB,
 		cfg.Workers.QueueName,
 		time.Duration(cfg.Sessions.StateTTL)*time.Second,
 		time.Duration(cfg.Sessions.ResultTTL)*time.Second,
 	)
+	if agent {
+		sessionService.SetRemoteMirror()
+	}
+	sessionService.SetMaxIterations(cfg.Sessions.MaxIterations)
+	sessionService.SetQueueLimit(cfg.Workers.MaxQueueDepth, time.Duration(cfg.Workers.QueueRetryAfter)*time.Second)
+	sessionService.SetTranscriptTTL(time.Duration(cfg.Sessions.TranscriptTTL) * time.Second)
+	sessionService.SetMaxRetainTTL(time.Duration(cfg.Sessions.MaxRetainTTL) * time.Second)
+	sessionService.SetAwaitTTL(time.Duration(cfg.Sessions.AwaitTTL)*time.Second, time.Duration(cfg.Sessions.MaxAwaitTTL)*time.Second)
+	sessionService.SetDeleteGracePeriod(time.Duration(cfg.Sessions.DeleteGracePeriod) * time.Second)
+	sessionService.SetProjectStore(session.NewProjectStore(rdb))
+	sessionService.SetPromptStore(session.NewPromptStore(rdb,
+		int64(cfg.Sessions.PromptUploadMaxBytes),
+		time.Duration(cfg.Sessions.PromptUploadTTL)*time.Second))
+	sessionService.SetDefaults(session.Defaults{
+		MaxTurns:     cfg.Sessions.Defaults.MaxTurns,
+		MaxBudgetUSD: cfg.Sessions.Defaults.MaxBudgetUSD,
+		TargetBranch: cfg.Sessions.Defaults.TargetBranch,
+		AllowedTools: cfg.Sessions.Defaults.AllowedTools,
+	})
+	if n, err := sessionService.MigrateRepoIndex(context.Background()); err != nil {
+		slog.Warn("repo index migration failed", "error", err)
+	} else if n > 0 {
+		slog.Info("repo index migrated", "sessions", n)
+	}
+	if n, err := sessionService.MigrateRepoURLs(context.Background()); err != nil {
+		slog.Warn("repo URL migration failed", "error", err)
+	} else if n > 0 {
+		slog.Info("repo URLs normalized", "sessions", n)
+	}
+	if n, err := sessionService.BackfillRepoNames(context.Background()); err != nil {
+		slog.Warn("repo name backfill failed", "error", err)
+	} else if n > 0 {
+		slog.Info("repo names backfilled", "sessions", n)
+	}
+
+	repoPolicy, err := policy.NewRepoPolicy(cfg.RepoPolicy.Allow, cfg.RepoPolicy.Deny)
+	if err != nil {
+		return fmt.Errorf("loading repo policy: %w", err)
+	}
+	sessionService.SetRepoPolicy(repoPolicy)
+
+	repoURLPolicy, err := policy.NewRepoURLPolicy(cfg.RepoPolicy.Schemes)
+	if err != nil {
+		return fmt.Errorf("loading repo URL policy: %w", err)
+	}
+	sessionService.SetRepoURLPolicy(repoURLPolicy)
+
+	workspaceTemplates, err := workspace.NewTemplates(cfg.Sessions.WorkspaceTemplates)
+	if err != nil {
+		return fmt.Errorf("loading workspace templates: %w", err)
+	}
+	sessionService.SetWorkspaceTemplates(workspaceTemplates.Names())
+
+	callbackPolicy, err := policy.NewCallbackPolicy(cfg.Webhooks.AllowPrivate, cfg.Webhooks.AllowHosts, cfg.Webhooks.AllowCIDRs, cfg.Webhooks.DenyCIDRs)
+	if err != nil {
+		return fmt.Errorf("loading callback policy: %w", err)
+	}
+	sessionService.SetCallbackPolicy(callbackPolicy)
+
+	promptPolicy, err := buildPromptPolicy(cfg.PromptPolicy)
+	if err != nil {
+		return fmt.Errorf("loading prompt policy: %w", err)
+	}
+	if promptPolicy != nil {
+		sessionService.SetPromptPolicy(promptPolicy, audit.NewStore(sqliteDB.Unwrap()))
+	}
+
+	// Offload large results and transcripts to object storage when configured
+	if cfg.BlobStore.Endpoint != "" && cfg.BlobStore.Bucket != "" {
+		blobs, err := blobstore.NewS3(blobstore.S3Config{
+			Endpoint:  cfg.BlobStore.Endpoint,
+			Bucket:    cfg.BlobStore.Bucket,
+			Region:    cfg.BlobStore.Region,
+			AccessKey: cfg.BlobStore.AccessKey,
+			SecretKey: cfg.BlobStore.SecretKey,
+			Prefix:    cfg.BlobStore.Prefix,
+			PathStyle: cfg.BlobStore.PathStyle,
+		})
+		if err != nil {
+			return fmt.Errorf("initializing blob store: %w", err)
+		}
+		sessionService.SetBlobStore(blobs, cfg.BlobStore.OffloadThreshold)
+		slog.Info("blob store enabled", "endpoint", cfg.BlobStore.Endpoint, "bucket", cfg.BlobStore.Bucket)
+	}
 
 	// Initialize webhook sender
 	var webhookSender *webhook.Sender
@@ -124,15 +292,21 @@ func run() error {
 			cfg.Webhooks.RetryCount,
 			cfg.Webhooks.RetryDelay,
 		)
+		webhookSender.SetMaxRetryDelay(cfg.Webhooks.RetryMaxDelay)
+		webhookSender.SetChaos(faults)
+		webhookSender.SetPolicy(callbackPolicy)
 	}
 
 	// Auto-populate provider domains from GITLAB_URL / GITHUB_URL env vars
 	// so self-hosted instances are recognized for PR creation without manual config.
 	cfg.Git.ProviderDomains = keys.MergeEnvProviderDomains(cfg.Git.ProviderDomains)
+	providerAPI := gitpkg.APIConfig{BaseURLs: cfg.Git.APIBaseURLs, ErrorMaxBytes: cfg.Sessions.ProviderErrorMaxBytes}
 
 	// Initialize key registry and resolver
 	sqliteKeyRegistry := keys.NewSQLiteRegistry(sqliteDB.Unwrap(), cryptoSvc)
+	sqliteKeyRegistry.SetProviderAPI(providerAPI)
 	keyRegistry := keys.NewEnvAwareRegistry(sqliteKeyRegistry)
+	keyRegistry.SetProviderAPI(providerAPI)
 	keyResolver := keys.NewResolver(keyRegistry, cfg.Git.ProviderDomains)
 
 	// Initialize MCP registry and installer
@@ -152,6 +326,18 @@ func run() error {
 		rdb,
 		time.Duration(cfg.Sessions.WorkspaceTTL)*time.Second,
 	)
+	if cfg.Sessions.Cache.Dir != "" {
+		sharedCache, err := workspace.NewSharedCache(cfg.Sessions.Cache.Dir, int64(cfg.Sessions.Cache.MaxGB)*1024*1024*1024)
+		if err != nil {
+			return fmt.Errorf("initializing shared dependency cache: %w", err)
+		}
+		workspaceMgr.SetSharedCache(sharedCache)
+	}
+	if n, err := workspaceMgr.MigrateIndex(context.Background()); err != nil {
+		slog.Warn("workspace index migration failed", "error", err)
+	} else if n > 0 {
+		slog.Info("workspace index migrated", "workspaces", n)
+	}
 
 	// Initialize CLI registry
 	cliRegistry := runner.NewRegistry(cfg.CLI.Default)
@@ -189,6 +375,27 @@ func run() error {
 
 	// Initialize streamer
 	streamer := worker.NewStreamer(rdb, time.Duration(cfg.Sessions.WorkspaceTTL)*time.Second)
+	streamer.SetHistoryArchiver(sessionService)
+	if cfg.Redaction.Enabled {
+		redactor, err := redact.New(true, cfg.Redaction.Patterns)
+		if err != nil {
+			return fmt.Errorf("loading redaction patterns: %w", err)
+		}
+		streamer.SetRedactor(redactor)
+	}
+
+	runAs, err := runas.New(runas.Config{
+		Mode: cfg.CLI.RunAs.Mode,
+		User: cfg.CLI.RunAs.User,
+		UID:  cfg.CLI.RunAs.UID,
+		GID:  cfg.CLI.RunAs.GID,
+
+		UIDMin: cfg.CLI.RunAs.EphemeralUIDMin,
+		UIDMax: cfg.CLI.RunAs.EphemeralUIDMax,
+	})
+	if err != nil {
+		return fmt.Errorf("configuring cli.run_as: %w", err)
+	}
 
 	// Initialize executor
 	executor := worker.NewExecutor(
@@ -201,10 +408,20 @@ func run() error {
 		toolResolver,
 		workspaceMgr,
 		worker.ExecutorConfig{
-			WorkspaceBase:   cfg.Sessions.WorkspaceBase,
-			DefaultTimeout:  cfg.Sessions.DefaultTimeout,
-			MaxTimeout:      cfg.Sessions.MaxTimeout,
-			ProviderDomains: cfg.Git.ProviderDomains,
+			WorkspaceBase:      cfg.Sessions.WorkspaceBase,
+			DefaultTimeout:     cfg.Sessions.DefaultTimeout,
+			MaxTimeout:         cfg.Sessions.MaxTimeout,
+			ProviderDomains:    cfg.Git.ProviderDomains,
+			ProviderAPI:        providerAPI,
+			TranscriptMaxBytes: cfg.Sessions.TranscriptMaxBytes,
+			ResultMaxChars:     cfg.Sessions.ResultMaxChars,
+			MaxContextChars:    cfg.Sessions.MaxContextChars,
+			ClaudeBackend:      claudeBackend(cfg.CLI.ClaudeCode),
+			RunAs:              runAs,
+			ProgressInterval:   time.Duration(cfg.Sessions.ProgressInterval) * time.Second,
+			HeartbeatInterval:  time.Duration(cfg.Sessions.HeartbeatInterval) * time.Second,
+			CrashLoopThreshold: cfg.Sessions.CrashLoopThreshold,
+			WorkspaceTemplates: workspaceTemplates,
 			DefaultModels: map[string]string{
 				"claude-code":  cfg.CLI.ClaudeCode.DefaultModel,
 				"codex":        cfg.CLI.Codex.DefaultModel,
@@ -222,6 +439,15 @@ func run() error {
 		cfg.Workers.QueueName,
 		cfg.Workers.Concurrency,
 	)
+	role := worker.RoleServer
+	if agent {
+		role = worker.RoleAgent
+	}
+	pool.SetNode(cfg.Workers.NodeID, role, version)
+
+	// Circuit breaker: pause the pool while Redis is unreachable.
+	redisMonitor := redisclient.NewMonitor(rdb, cfg.Redis.HealthCheckInterval, cfg.Redis.FailureThreshold)
+	redisMonitor.OnChange(pool.SetRedisAvailable)
 
 	// Initialize AI helper client (for PR metadata, commit messages)
 	aiClient := ai.NewClientFromRegistry(context.Background(), keyResolver)
@@ -230,16 +456,52 @@ func run() error {
 	analyzer := runner.NewAnalyzer(aiClient)
 
 	// Initialize PR service
-	prService := session.NewPRService(sessionService, analyzer, workspaceMgr, keyResolver, session.PRServiceConfig{
+	prServiceCfg := session.PRServiceConfig{
 		WorkspaceBase:   cfg.Sessions.WorkspaceBase,
 		BranchPrefix:    cfg.Git.BranchPrefix,
 		CommitAuthor:    cfg.Git.CommitAuthor,
 		CommitEmail:     cfg.Git.CommitEmail,
 		ProviderDomains: cfg.Git.ProviderDomains,
-	}, aiClient)
+		ProviderAPI:     providerAPI,
+		GitLabMR:        gitpkg.GitLabMROptions{ApprovalRuleReviewers: cfg.Git.GitLabMR.ApprovalRuleReviewers},
+	}
+	if cfg.Git.GitLabMR.Squash {
+		prServiceCfg.GitLabMR.Squash = &cfg.Git.GitLabMR.Squash
+	}
+	if cfg.Git.GitLabMR.RemoveSourceBranch {
+		prServiceCfg.GitLabMR.RemoveSourceBranch = &cfg.Git.GitLabMR.RemoveSourceBranch
+	}
+	if dc := cfg.Git.DirectCommit; dc.Enabled {
+		prServiceCfg.DirectCommit = &gitpkg.DirectCommitPolicy{Paths: dc.Paths, MaxLines: dc.MaxLines}
+	}
+	prService := session.NewPRService(sessionService, analyzer, workspaceMgr, keyResolver, prServiceCfg, aiClient)
+
+	// Conflicts found while updating a PR branch show up in the session stream.
+	prService.SetEventEmitter(streamer)
+
+	// Lifecycle hooks. Compiled-in plugins register here too, e.g.
+	// hookRunner.Register(hooks.PostClone, hooks.Func("audit", auditClone)).
+	hookRunner := hooks.NewRunner()
+	for _, h := range cfg.Hooks {
+		stage, err := hooks.ParseStage(h.Stage)
+		if err != nil {
+			return fmt.Errorf("loading hook %s: %w", h.Name, err)
+		}
+		if h.Optional {
+			hookRunner.RegisterOptional(stage, hooks.NewCommand(h.Name, h.Command, h.Timeout))
+		} else {
+			hookRunner.Register(stage, hooks.NewCommand(h.Name, h.Command, h.Timeout))
+		}
+	}
+	executor.SetHooks(hookRunner)
+	prService.SetHooks(hookRunner, runAs)
 
 	// Wire the PR service into the executor for auto-PR-enabled sessions (workflows).
 	executor.SetPRCreator(prService)
+	executor.SetBranchStarter(prService)
+
+	// Summarizes older iterations for the summary context strategy.
+	executor.SetAIClient(aiClient)
 
 	// Initialize workspace cleaner
 	wsCleaner := workspace.NewCleaner(workspaceMgr, sessionService, workspace.CleanerConfig{
@@ -248,6 +510,11 @@ func run() error {
 		DiskCriticalThreshold: int64(cfg.Sessions.DiskCriticalThresholdGB) * 1024 * 1024 * 1024,
 	})
 
+	wsSizer := workspace.NewSizer(workspaceMgr, workspace.SizerConfig{
+		Interval:  time.Duration(cfg.Sessions.WorkspaceSizeInterval) * time.Second,
+		Staleness: time.Duration(cfg.Sessions.WorkspaceSizeStaleness) * time.Second,
+	})
+
 	// Initialize workflow subsystem
 	workflowRegistry := workflow.NewSQLiteRegistry(sqliteDB.Unwrap())
 	workflowConfigStore := workflow.NewSQLiteConfigStore(sqliteDB.Unwrap())
@@ -269,10 +536,16 @@ func run() error {
 
 	// Wire per-tenant usage tracking into the executor — only when the subscription
 	// model is enabled, so a stray client-supplied tenant_id can never trigger logging.
-	if cfg.Subscription.Enabled {
+	// Agents skip it: their tenant store is a local database nobody reads.
+	if cfg.Subscription.Enabled && !agent {
 		executor.SetUsageLogger(tenantStore)
+		executor.SetTenantBudget(tenantService)
 	}
 
+	// Rolling aggregates behind GET /api/v1/stats
+	executor.SetStatsRecorder(stats.NewRecorder(rdb))
+	executor.SetChaos(faults)
+
 	// Scheduled (cron) sessions
 	scheduleStore := schedule.NewStore(sqliteDB.Unwrap())
 	scheduler := schedule.NewScheduler(scheduleStore, sessionService, time.Minute)
@@ -286,20 +559,62 @@ func run() error {
 			"discord", cfg.Notifications.DiscordWebhookURL != "")
 	}
 
-	srv := server.New(cfg, rdb, sqliteDB, sessionService, prService, pool, keyRegistry, mcpRegistry, workspaceMgr, workflowRegistry, workflowConfigStore, cliRegistry, cliConfigs, webhookReceiverHandler, tenantHandler, tenantService, scheduleHandler, version)
-
 	// Start background services
 	appCtx, appCancel := context.WithCancel(context.Background())
 	defer appCancel()
 
 	pool.Start(appCtx)
+	go redisMonitor.Start(appCtx)
 	go wsCleaner.Start(appCtx)
+	go wsSizer.Start(appCtx)
+
+	if agent {
+		slog.Info("runner agent started", "node", pool.NodeID(), "concurrency", cfg.Workers.Concurrency)
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-quit
+		slog.Info("shutdown signal received", "signal", sig.String())
+		appCancel()
+		pool.Stop()
+		slog.Info("shutdown complete")
+		return nil
+	}
+
+	srv := server.New(cfg, rdb, sqliteDB, sessionService, prService, pool, keyRegistry, mcpRegistry, workspaceMgr, workflowRegistry, workflowConfigStore, cliRegistry, cliConfigs, webhookReceiverHandler, tenantHandler, tenantService, scheduleHandler, version)
+	srv.SetRedisMonitor(redisMonitor)
+
+	// Copy sessions run by runner agents into the SQLite mirror.
+	go worker.NewMirrorSyncer(sessionService, 5*time.Second).Start(appCtx)
+
+	// Re-enqueue sessions that wait for a worker but fell out of the queue
+	go worker.NewOutboxReconciler(sessionService, pool, time.Minute, 2*time.Minute).Start(appCtx)
+
+	// Queue sessions created with schedule_at / delay_seconds once they are due.
+	go worker.NewScheduledPromoter(sessionService, 5*time.Second).Start(appCtx)
 
 	// Fail sessions stuck in running/cloning far past any possible timeout
 	// (lost worker: crash, failed requeue, pre-reliability leftovers).
 	stuckAge := time.Duration(cfg.Sessions.MaxTimeout)*time.Second + 30*time.Minute
 	go worker.NewStuckSweeper(sessionService, 10*time.Minute, stuckAge).Start(appCtx)
 
+	// Fail sessions abandoned in pending/awaiting_instruction and release
+	// their workspaces.
+	if cfg.Sessions.StaleSessionAge > 0 {
+		staleAge := time.Duration(cfg.Sessions.StaleSessionAge) * time.Second
+		go worker.NewStaleExpirer(sessionService, workspaceMgr, webhookSender, 30*time.Minute, staleAge).Start(appCtx)
+	}
+
+	// Reconcile per-session keys and workspace hashes left behind by crashes.
+	orphanSweeper := worker.NewOrphanSweeper(rdb, workspaceMgr, 30*time.Minute)
+	orphanSweeper.SetBlobDeleter(sessionService)
+	go orphanSweeper.Start(appCtx)
+
+	// Purge soft-deleted sessions whose grace period is over.
+	go worker.NewDeletedPurger(sessionService, workspaceMgr, 5*time.Minute).Start(appCtx)
+
+	// Follow auto-merge PRs until merged (or closed / 24h old).
+	go worker.NewAutoMerger(prService, time.Minute, 24*time.Hour).Start(appCtx)
+
 	// Fire recurring (cron) sessions.
 	go scheduler.Start(appCtx)
 
@@ -337,3 +652,84 @@ func run() error {
 	slog.Info("shutdown complete")
 	return nil
 }
+
+// applyProxy exports the outbound proxy configuration into the process
+// environment, inherited by git and the AI CLIs and read by net/http.
+func applyProxy(cfg *config.Config) error {
+	settings := proxy.Settings{
+		HTTPProxy:  cfg.Proxy.HTTPProxy,
+		HTTPSProxy: cfg.Proxy.HTTPSProxy,
+		NoProxy:    proxy.SplitList(cfg.Proxy.NoProxy),
+	}
+	if settings.HTTPProxy == "" && settings.HTTPSProxy == "" {
+		return nil
+	}
+	if cfg.Proxy.BypassProviderDomains {
+		for domain := range keys.MergeEnvProviderDomains(cfg.Git.ProviderDomains) {
+			settings.NoProxy = append(settings.NoProxy, domain)
+		}
+	}
+	slog.Info("outbound proxy enabled",
+		"http_proxy", settings.HTTPProxy != "",
+		"https_proxy", settings.HTTPSProxy != "",
+		"no_proxy", strings.Join(settings.NoProxy, ","))
+	return proxy.Apply(settings)
+}
+
+// claudeBackend translates the Claude Code backend config for the executor.
+// Settings for every backend are kept so sessions can override the default.
+func claudeBackend(c config.ClaudeCodeConfig) runner.ClaudeBackend {
+	return runner.ClaudeBackend{
+		Name:            c.Backend,
+		AWSRegion:       c.Bedrock.Region,
+		AWSProfile:      c.Bedrock.Profile,
+		VertexRegion:    c.Vertex.Region,
+		VertexProjectID: c.Vertex.ProjectID,
+		CredentialsFile: c.Vertex.CredentialsFile,
+	}
+}
+
+// buildPromptPolicy assembles the prompt moderation chain. Returns nil when
+// neither regex patterns nor an external policy service are configured.
+func buildPromptPolicy(c config.PromptPolicyConfig) (policy.PromptChecker, error) {
+	var chain policy.PromptChain
+	regex, err := policy.NewRegexPromptPolicy(c.RejectPatterns, c.FlagPatterns)
+	if err != nil {
+		return nil, err
+	}
+	if !regex.Empty() {
+		chain = append(chain, regex)
+	}
+	if c.WebhookURL != "" {
+		chain = append(chain, policy.NewHTTPPromptPolicy(c.WebhookURL, c.Timeout, c.FailOpen))
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	slog.Info("prompt policy enabled",
+		"reject_patterns", len(c.RejectPatterns),
+		"flag_patterns", len(c.FlagPatterns),
+		"external", c.WebhookURL != "")
+	return chain, nil
+}
+
+// configureHTTPClients sets up the shared outbound HTTP pool and the
+// per-component timeouts. The policy timeout is prompt_policy.timeout, next to
+// the other prompt policy settings.
+func configureHTTPClients(c config.HTTPClientConfig, promptPolicy config.PromptPolicyConfig) {
+	httpclient.Configure(httpclient.Config{
+		MaxIdleConns:        c.MaxIdleConns,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		IdleConnTimeout:     c.IdleConnTimeout,
+		Timeouts: map[string]time.Duration{
+			httpclient.Webhook:   c.Timeouts.Webhook,
+			httpclient.Provider:  c.Timeouts.Provider,
+			httpclient.Review:    c.Timeouts.Review,
+			httpclient.AI:        c.Timeouts.AI,
+			httpclient.Notify:    c.Timeouts.Notify,
+			httpclient.Policy:    promptPolicy.Timeout,
+			httpclient.Sentry:    c.Timeouts.Sentry,
+			httpclient.BlobStore: c.Timeouts.BlobStore,
+		},
+	})
+}
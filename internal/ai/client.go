@@ -8,7 +8,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
 )
 
 // Client generates short text completions via AI API.
@@ -28,7 +29,7 @@ func NewAnthropicClient(apiKey string) Client {
 	return &anthropicClient{
 		apiKey: apiKey,
 		model:  "claude-haiku-4-5-20251001",
-		client: &http.Client{Timeout: 15 * time.Second},
+		client: httpclient.New(httpclient.AI),
 	}
 }
 
@@ -94,7 +95,7 @@ func NewOpenAIClient(apiKey string) Client {
 	return &openaiClient{
 		apiKey: apiKey,
 		model:  "gpt-4.1-mini",
-		client: &http.Client{Timeout: 15 * time.Second},
+		client: httpclient.New(httpclient.AI),
 	}
 }
 
@@ -103,6 +103,73 @@ func GenerateCommitMessage(ctx context.Context, client Client, diff, taskPrompt
 	return msg
 }
 
+// IterationResult is the generated summary of one session iteration.
+type IterationResult struct {
+	Changed    string   `json:"changed"`
+	OpenIssues []string `json:"open_issues"`
+}
+
+// SummarizeIteration summarizes what an iteration changed and left open from
+// its instruction and the CLI output. Returns nil if AI is not available or
+// fails (caller should use fallback).
+func SummarizeIteration(ctx context.Context, client Client, instruction, output string) *IterationResult {
+	if client == nil {
+		return nil
+	}
+
+	system, err := prompt.LoadRaw("iteration_result")
+	if err != nil {
+		slog.Warn("failed to load iteration_result prompt", "error", err)
+		return nil
+	}
+
+	if len(output) > 8000 {
+		output = output[len(output)-8000:] // the conclusion is at the end
+	}
+
+	response, err := client.Generate(ctx, system, "## Instruction\n"+instruction+"\n\n## Agent output\n"+output)
+	if err != nil {
+		slog.Warn("AI iteration summary failed", "error", err)
+		return nil
+	}
+
+	var res IterationResult
+	if err := json.Unmarshal([]byte(stripJSONFences(response)), &res); err != nil {
+		slog.Warn("failed to parse AI iteration summary", "error", err, "response", truncate(response, 200))
+		return nil
+	}
+	if res.Changed == "" {
+		return nil
+	}
+	return &res
+}
+
+// SummarizeIterations condenses the formatted history of earlier session
+// iterations for a follow-up prompt. Returns empty string if AI is not
+// available or fails.
+func SummarizeIterations(ctx context.Context, client Client, history string) string {
+	if client == nil {
+		return ""
+	}
+
+	system, err := prompt.LoadRaw("iteration_summary")
+	if err != nil {
+		slog.Warn("failed to load iteration_summary prompt", "error", err)
+		return ""
+	}
+
+	if len(history) > 20000 {
+		history = history[len(history)-20000:] // keep the newest part
+	}
+
+	response, err := client.Generate(ctx, system, history)
+	if err != nil {
+		slog.Warn("AI iteration summary failed", "error", err)
+		return ""
+	}
+	return strings.TrimSpace(response)
+}
+
 func stripJSONFences(s string) string {
 	s = strings.TrimSpace(s)
 	if strings.HasPrefix(s, "```json") {
@@ -0,0 +1,162 @@
+// Package apispec derives the OpenAPI document's schemas from the Go request
+// and response structs the handlers actually use, and validates incoming
+// request bodies against them.
+package apispec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema (OpenAPI 3.0 dialect) object.
+type Schema = map[string]interface{}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator builds schemas, emitting $refs for types with a component name.
+type generator struct {
+	names map[reflect.Type]string
+}
+
+// schemaFor returns the schema of t. When inline is false and t is a named
+// component, a $ref is returned instead.
+func (g *generator) schemaFor(t reflect.Type, inline bool) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !inline {
+		if name, ok := g.names[t]; ok {
+			return Schema{"$ref": "#/components/schemas/" + name}
+		}
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem(), false)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem(), false)}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default: // interface{} and anything else: any value
+		return Schema{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) Schema {
+	props := Schema{}
+	var required []string
+	g.collectFields(t, props, &required)
+
+	s := Schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// collectFields adds t's JSON-visible fields to props, flattening embedded structs.
+func (g *generator) collectFields(t reflect.Type, props Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts := jsonName(f)
+		if name == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				g.collectFields(f.Type, props, required)
+			}
+			continue
+		}
+
+		fs := g.schemaFor(f.Type, false)
+		if opts["writeOnly"] {
+			fs["writeOnly"] = true
+		}
+		applyValidateTag(fs, f.Tag.Get("validate"), required, name)
+		props[name] = fs
+	}
+}
+
+// jsonName resolves a field's wire name. Fields hidden with json:"-" can be
+// documented with an `openapi:"name,writeOnly"` tag when a custom
+// UnmarshalJSON accepts them on input.
+func jsonName(f reflect.StructField) (string, map[string]bool) {
+	opts := map[string]bool{}
+	if tag, ok := f.Tag.Lookup("openapi"); ok {
+		parts := strings.Split(tag, ",")
+		for _, o := range parts[1:] {
+			opts[o] = true
+		}
+		return parts[0], opts
+	}
+
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", opts
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		if f.Anonymous {
+			return "", opts
+		}
+		name = f.Name
+	}
+	return name, opts
+}
+
+// applyValidateTag maps the go-playground/validator rules the handlers rely on.
+func applyValidateTag(s Schema, tag string, required *[]string, name string) {
+	if tag == "" {
+		return
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			*required = append(*required, name)
+		case "url":
+			s["format"] = "uri"
+		case "oneof":
+			s["enum"] = strings.Fields(arg)
+		case "max", "lte":
+			if n, err := strconv.Atoi(arg); err == nil {
+				switch s["type"] {
+				case "string":
+					s["maxLength"] = n
+				case "integer", "number":
+					s["maximum"] = n
+				}
+			}
+		case "gte", "min":
+			if n, err := strconv.Atoi(arg); err == nil && (s["type"] == "integer" || s["type"] == "number") {
+				s["minimum"] = n
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package apispec
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/freema/codeforge/internal/audit"
+	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/review"
+	"github.com/freema/codeforge/internal/schedule"
+	"github.com/freema/codeforge/internal/server/middleware"
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/stats"
+	"github.com/freema/codeforge/internal/tenant"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/runner"
+	"github.com/freema/codeforge/internal/tool/verify"
+	"github.com/freema/codeforge/internal/worker"
+)
+
+// component binds an OpenAPI component name to the Go type behind it.
+type component struct {
+	name string
+	typ  reflect.Type
+}
+
+func typeOf(v interface{}) reflect.Type { return reflect.TypeOf(v) }
+
+// components lists every schema generated from code. Names match the
+// existing spec so hand-written descriptions and examples are preserved.
+var components = []component{
+	{"CreateSessionRequest", typeOf(session.CreateSessionRequest{})},
+	{"InstructRequest", typeOf(session.InstructRequest{})},
+	{"PromptUpload", typeOf(session.PromptUpload{})},
+	{"Attachment", typeOf(session.Attachment{})},
+	{"AttachmentInfo", typeOf(session.AttachmentInfo{})},
+	{"SessionConfig", typeOf(session.Config{})},
+	{"VerifyConfig", typeOf(session.Verify{})},
+	{"VerifyResult", typeOf(verify.Result{})},
+	{"Reasoning", typeOf(session.Reasoning{})},
+	{"SessionMCPServer", typeOf(session.MCPServer{})},
+	{"Session", typeOf(session.Session{})},
+	{"Iteration", typeOf(session.Iteration{})},
+	{"IterationSummary", typeOf(session.IterationSummary{})},
+	{"ToolsSummary", typeOf(runner.ToolsSummary{})},
+	{"UsageInfo", typeOf(session.UsageInfo{})},
+	{"ChangesSummary", typeOf(gitpkg.ChangesSummary{})},
+	{"CreatePRRequest", typeOf(session.CreatePRRequest{})},
+	{"CreatePRResponse", typeOf(session.CreatePRResponse{})},
+	{"GitLabMROptions", typeOf(gitpkg.GitLabMROptions{})},
+	{"PushToPRResponse", typeOf(session.PushToPRResponse{})},
+	{"RebaseRequest", typeOf(session.RebaseRequest{})},
+	{"RebaseResponse", typeOf(session.RebaseResponse{})},
+	{"CreatePRsRequest", typeOf(session.CreatePRsRequest{})},
+	{"CreatePRsResponse", typeOf(session.CreatePRsResponse{})},
+	{"FanOutPR", typeOf(session.FanOutPR{})},
+	{"ReviewResult", typeOf(review.ReviewResult{})},
+	{"ReviewIssue", typeOf(review.ReviewIssue{})},
+	{"Transcript", typeOf(session.Transcript{})},
+	{"ProjectSettings", typeOf(session.ProjectSettings{})},
+	{"Schedule", typeOf(schedule.Schedule{})},
+	{"Tenant", typeOf(tenant.Tenant{})},
+	{"KeyPoolEntry", typeOf(tenant.KeyPoolEntry{})},
+	{"UsageSummary", typeOf(tenant.UsageSummary{})},
+	{"AuditEntry", typeOf(audit.Entry{})},
+	{"StatsSummary", typeOf(stats.Summary{})},
+	{"StatsWindow", typeOf(stats.Window{})},
+	{"StatsRepoCount", typeOf(stats.RepoCount{})},
+	{"StuckSession", typeOf(session.StuckSession{})},
+	{"PoolSnapshot", typeOf(worker.PoolSnapshot{})},
+	{"WorkerState", typeOf(worker.WorkerState{})},
+	{"QueueStats", typeOf(worker.QueueStats{})},
+	{"Node", typeOf(worker.Node{})},
+	{"FamilyUsage", typeOf(redisclient.FamilyUsage{})},
+	{"EvictResult", typeOf(session.EvictResult{})},
+	{"Limits", typeOf(middleware.Limits{})},
+	{"RateLimitStatus", typeOf(middleware.RateLimitStatus{})},
+	{"TenantLimits", typeOf(tenant.Limits{})},
+	{"Quota", typeOf(tenant.Quota{})},
+	{"Budget", typeOf(tenant.Budget{})},
+}
+
+// requestBodies maps "METHOD /path" to the component decoded by the handler.
+// Used by the strict validation middleware.
+var requestBodies = map[string]string{
+	"POST /api/v1/sessions":                       "CreateSessionRequest",
+	"POST /api/v1/sessions/{sessionID}/instruct":  "InstructRequest",
+	"POST /api/v1/sessions/{sessionID}/create-pr": "CreatePRRequest",
+	"PUT /api/v1/projects/{owner}/{repo}":         "ProjectSettings",
+	"POST /api/v1/sessions/create-prs":            "CreatePRsRequest",
+	"POST /api/v1/sessions/{sessionID}/rebase":    "RebaseRequest",
+}
+
+func newGenerator() *generator {
+	g := &generator{names: make(map[reflect.Type]string, len(components))}
+	for _, c := range components {
+		g.names[c.typ] = c.name
+	}
+	return g
+}
+
+// Schemas returns the generated component schemas keyed by name.
+func Schemas() map[string]Schema {
+	g := newGenerator()
+	out := make(map[string]Schema, len(components))
+	for _, c := range components {
+		out[c.name] = g.schemaFor(c.typ, true)
+	}
+	return out
+}
+
+// Build merges the generated schemas into the hand-written spec. Field sets,
+// types and required lists come from the Go structs; descriptions, examples,
+// enums and other annotations are kept from the static document.
+func Build(static []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(static, &doc); err != nil {
+		return nil, fmt.Errorf("parsing spec: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("parsing spec: empty document")
+	}
+	schemas := mappingChild(mappingChild(doc.Content[0], "components"), "schemas")
+	if schemas == nil {
+		return nil, fmt.Errorf("spec has no components.schemas")
+	}
+
+	generated := Schemas()
+	for _, c := range components {
+		merged := generated[c.name]
+		existing := mappingChild(schemas, c.name)
+		if existing != nil {
+			var static Schema
+			if err := existing.Decode(&static); err != nil {
+				return nil, fmt.Errorf("decoding schema %s: %w", c.name, err)
+			}
+			merged = mergeSchema(merged, static)
+		}
+
+		var node yaml.Node
+		if err := node.Encode(merged); err != nil {
+			return nil, fmt.Errorf("encoding schema %s: %w", c.name, err)
+		}
+		if existing != nil {
+			*existing = node
+		} else {
+			schemas.Content = append(schemas.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: c.name}, &node)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encoding spec: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// structural keys always come from the generated schema.
+var structural = map[string]bool{
+	"type": true, "$ref": true, "properties": true, "items": true,
+	"additionalProperties": true, "required": true,
+}
+
+// mergeSchema overlays static annotations onto a generated schema.
+func mergeSchema(gen, static Schema) Schema {
+	if len(gen) == 0 {
+		return static // free-form in Go (json.RawMessage, interface{}): keep the documented shape
+	}
+	if _, isRef := gen["$ref"]; isRef {
+		return gen
+	}
+	for k, v := range static {
+		if _, set := gen[k]; !set && !structural[k] {
+			gen[k] = v
+		}
+	}
+
+	genProps, _ := gen["properties"].(Schema)
+	staticProps, _ := static["properties"].(Schema)
+	for name, gp := range genProps {
+		sp, ok := staticProps[name].(Schema)
+		if !ok {
+			continue
+		}
+		genProps[name] = mergeSchema(gp.(Schema), sp)
+	}
+
+	if items, ok := gen["items"].(Schema); ok {
+		if sItems, ok := static["items"].(Schema); ok {
+			gen["items"] = mergeSchema(items, sItems)
+		}
+	}
+	return gen
+}
+
+// mappingChild returns the value node for key in a YAML mapping node.
+func mappingChild(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
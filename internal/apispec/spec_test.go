@@ -0,0 +1,101 @@
+package apispec
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/freema/codeforge/api"
+)
+
+func TestBuild(t *testing.T) {
+	out, err := Build(api.OpenAPISpec)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	var doc struct {
+		Paths      map[string]interface{} `yaml:"paths"`
+		Components struct {
+			Schemas map[string]Schema `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("generated spec is not valid YAML: %v", err)
+	}
+	if len(doc.Paths) == 0 {
+		t.Error("paths were lost")
+	}
+
+	create := doc.Components.Schemas["CreateSessionRequest"]
+	props := create["properties"].(Schema)
+	repoURL := props["repo_url"].(Schema)
+	if desc, _ := repoURL["description"].(string); !strings.HasPrefix(desc, "Git repository URL.") || repoURL["format"] != "uri" {
+		t.Errorf("repo_url lost annotations: %v", repoURL)
+	}
+	if req, _ := create["required"].([]interface{}); len(req) != 1 || req[0] != "repo_url" {
+		t.Errorf("required = %v, want [repo_url]", create["required"])
+	}
+	if _, ok := props["tenant_id"]; ok {
+		t.Error("server-only tenant_id must not be documented on the request")
+	}
+
+	cfgProps := doc.Components.Schemas["SessionConfig"]["properties"].(Schema)
+	key, ok := cfgProps["ai_api_key"].(Schema)
+	if !ok || key["writeOnly"] != true {
+		t.Errorf("ai_api_key should be a writeOnly property, got %v", cfgProps["ai_api_key"])
+	}
+	if _, ok := doc.Components.Schemas["InstructRequest"]; !ok {
+		t.Error("InstructRequest component missing")
+	}
+}
+
+// TestStaticSpecInSync fails when a struct gains or loses a JSON field the
+// hand-written spec does not reflect, so descriptions are added alongside.
+func TestStaticSpecInSync(t *testing.T) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]Schema `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(api.OpenAPISpec, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, gen := range Schemas() {
+		static, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("component %s is generated but missing from api/openapi.yaml", name)
+			continue
+		}
+		want := propertyNames(gen)
+		got := propertyNames(static)
+		if !equal(want, got) {
+			t.Errorf("%s properties drifted:\n  code: %v\n  spec: %v", name, want, got)
+		}
+	}
+}
+
+func propertyNames(s Schema) []string {
+	props, _ := s["properties"].(Schema)
+	out := make([]string, 0, len(props))
+	for k := range props {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
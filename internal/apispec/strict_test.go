@@ -0,0 +1,89 @@
+package apispec
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidator_Middleware(t *testing.T) {
+	v := NewValidator()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{"documented create", "POST", "/api/v1/sessions", `{"repo_url":"https://x/y","prompt":"p","config":{"cli":"codex","ai_api_key":"k","reasoning":{"effort":"low"}},"metadata":{"any":"thing"}}`, http.StatusOK, ""},
+		{"unknown top-level field", "POST", "/api/v1/sessions", `{"repo_url":"https://x/y","promt":"typo"}`, http.StatusBadRequest, "promt"},
+		{"unknown nested field", "POST", "/api/v1/sessions", `{"repo_url":"https://x/y","config":{"model":"x"}}`, http.StatusBadRequest, "config.model"},
+		{"unknown field in array item", "POST", "/api/v1/sessions", `{"repo_url":"https://x/y","config":{"mcp_servers":[{"name":"a","bogus":1}]}}`, http.StatusBadRequest, "config.mcp_servers[].bogus"},
+		{"instruct with path param", "POST", "/api/v1/sessions/abc/instruct", `{"prompt":"p","extra":true}`, http.StatusBadRequest, "extra"},
+		{"undocumented route passes", "POST", "/api/v1/keys", `{"whatever":1}`, http.StatusOK, ""},
+		{"malformed JSON left to handler", "POST", "/api/v1/sessions", `{`, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody string
+			h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+				w.WriteHeader(http.StatusOK)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantField != "" && !strings.Contains(rec.Body.String(), `"`+tt.wantField+`"`) {
+				t.Errorf("response %s does not name %s", rec.Body.String(), tt.wantField)
+			}
+			if rec.Code == http.StatusOK && gotBody != tt.body {
+				t.Errorf("handler got body %q, want %q", gotBody, tt.body)
+			}
+		})
+	}
+}
+
+func TestValidator_MiddlewareBodyLimit(t *testing.T) {
+	v := NewValidator()
+	v.maxBody = 64
+	body := func(n int) string {
+		const prefix, suffix = `{"repo_url":"https://x/y","prompt":"`, `"}`
+		return prefix + strings.Repeat("p", n-len(prefix)-len(suffix)) + suffix
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"at the limit", body(64), http.StatusOK},
+		{"over the limit", body(65), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody string
+			h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+				w.WriteHeader(http.StatusOK)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/sessions", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK && gotBody != tt.body {
+				t.Errorf("handler got a truncated body: %q", gotBody)
+			}
+		})
+	}
+}
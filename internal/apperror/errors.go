@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Sentinel errors for common conditions.
@@ -12,7 +13,10 @@ var (
 	ErrValidation        = errors.New("validation error")
 	ErrUnauthorized      = errors.New("unauthorized")
 	ErrConflict          = errors.New("conflict")
+	ErrForbidden         = errors.New("forbidden")
+	ErrGone              = errors.New("gone")
 	ErrInvalidTransition = errors.New("invalid state transition")
+	ErrUnavailable       = errors.New("service unavailable")
 )
 
 // AppError is a structured error with an HTTP status code and optional fields.
@@ -21,6 +25,9 @@ type AppError struct {
 	Message string
 	Status  int
 	Fields  map[string]string
+	Code    string // machine-readable error code; defaults to the status text
+	// RetryAfter is sent as the Retry-After header (0 = none).
+	RetryAfter time.Duration
 }
 
 func (e *AppError) Error() string {
@@ -61,6 +68,33 @@ func Conflict(format string, args ...interface{}) *AppError {
 	}
 }
 
+// Forbidden creates a 403 error.
+func Forbidden(format string, args ...interface{}) *AppError {
+	return &AppError{
+		Err:     ErrForbidden,
+		Message: fmt.Sprintf(format, args...),
+		Status:  http.StatusForbidden,
+	}
+}
+
+// Gone creates a 410 error.
+func Gone(format string, args ...interface{}) *AppError {
+	return &AppError{
+		Err:     ErrGone,
+		Message: fmt.Sprintf(format, args...),
+		Status:  http.StatusGone,
+	}
+}
+
+// Unavailable creates a 503 error.
+func Unavailable(format string, args ...interface{}) *AppError {
+	return &AppError{
+		Err:     ErrUnavailable,
+		Message: fmt.Sprintf(format, args...),
+		Status:  http.StatusServiceUnavailable,
+	}
+}
+
 // HTTPStatus extracts the HTTP status code from an error, defaulting to 500.
 func HTTPStatus(err error) int {
 	var appErr *AppError
@@ -79,5 +113,14 @@ func HTTPStatus(err error) int {
 	if errors.Is(err, ErrConflict) {
 		return http.StatusConflict
 	}
+	if errors.Is(err, ErrForbidden) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, ErrGone) {
+		return http.StatusGone
+	}
+	if errors.Is(err, ErrUnavailable) {
+		return http.StatusServiceUnavailable
+	}
 	return http.StatusInternalServerError
 }
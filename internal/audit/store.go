@@ -0,0 +1,119 @@
+// Package audit records security-relevant decisions in SQLite so operators
+// can review why work was allowed, flagged or rejected.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Entry is a single audit trail record.
+type Entry struct {
+	ID        int64             `json:"id"`
+	SessionID string            `json:"session_id,omitempty"`
+	TenantID  string            `json:"tenant_id,omitempty"`
+	Action    string            `json:"action"`
+	Decision  string            `json:"decision"`
+	Reason    string            `json:"reason,omitempty"`
+	Detail    map[string]string `json:"detail,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Store persists audit entries in SQLite.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates an audit store.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Record appends an entry and assigns its ID/timestamp.
+func (s *Store) Record(ctx context.Context, e *Entry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	detail := ""
+	if len(e.Detail) > 0 {
+		data, err := json.Marshal(e.Detail)
+		if err != nil {
+			return fmt.Errorf("marshaling audit detail: %w", err)
+		}
+		detail = string(data)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (session_id, tenant_id, action, decision, reason, detail, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.SessionID, e.TenantID, e.Action, e.Decision, e.Reason, detail,
+		e.CreatedAt.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting audit entry: %w", err)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		e.ID = id
+	}
+	return nil
+}
+
+// Filter narrows List results. Zero values match everything.
+type Filter struct {
+	SessionID string
+	TenantID  string
+	Decision  string
+	Limit     int // default 100
+}
+
+// List returns matching entries, newest first.
+func (s *Store) List(ctx context.Context, f Filter) ([]Entry, error) {
+	query := `SELECT id, session_id, tenant_id, action, decision, reason, detail, created_at
+		FROM audit_log WHERE 1=1`
+	var args []interface{}
+	if f.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, f.SessionID)
+	}
+	if f.TenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, f.TenantID)
+	}
+	if f.Decision != "" {
+		query += " AND decision = ?"
+		args = append(args, f.Decision)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing audit entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []Entry{}
+	for rows.Next() {
+		var (
+			e         Entry
+			detail    string
+			createdAt string
+		)
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.TenantID, &e.Action, &e.Decision, &e.Reason, &detail, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning audit entry: %w", err)
+		}
+		if detail != "" {
+			_ = json.Unmarshal([]byte(detail), &e.Detail)
+		}
+		e.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
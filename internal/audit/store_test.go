@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/freema/codeforge/internal/database"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := database.Migrate(context.Background(), db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewStore(db)
+}
+
+func TestStore_RecordAndList(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	entries := []*Entry{
+		{SessionID: "s1", Action: "prompt.create", Decision: "allow"},
+		{SessionID: "s1", TenantID: "t1", Action: "prompt.instruct", Decision: "flag", Reason: "matched", Detail: map[string]string{"rule": "delete all"}},
+		{SessionID: "s2", Action: "prompt.create", Decision: "reject"},
+	}
+	for _, e := range entries {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		if e.ID == 0 {
+			t.Error("expected ID to be assigned")
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 3},
+		{"by session", Filter{SessionID: "s1"}, 2},
+		{"by tenant", Filter{TenantID: "t1"}, 1},
+		{"by decision", Filter{Decision: "reject"}, 1},
+		{"limit", Filter{Limit: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %d entries, want %d", len(got), tt.want)
+			}
+		})
+	}
+
+	got, _ := store.List(ctx, Filter{Decision: "flag"})
+	if got[0].Detail["rule"] != "delete all" || got[0].Reason != "matched" {
+		t.Errorf("unexpected entry: %+v", got[0])
+	}
+}
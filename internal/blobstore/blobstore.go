@@ -0,0 +1,20 @@
+// Package blobstore offloads large payloads (results, transcripts, event
+// histories, iteration patches) from Redis to S3-compatible object storage.
+// GCS works through its S3 interoperability endpoint
+// (https://storage.googleapis.com) with HMAC keys.
+package blobstore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by Get when the object does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a minimal object store.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,84 @@
+// Package cabundle installs additional trusted CA certificates for enterprise
+// git hosts with private PKI. The certificates are added to the default Go
+// HTTP transport (provider APIs, webhooks) and exported to subprocesses via
+// GIT_SSL_CAINFO (git) and NODE_EXTRA_CA_CERTS (Node-based AI CLIs).
+package cabundle
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// systemBundles are well-known system CA bundle locations (Debian/Alpine,
+// RHEL, macOS/BSD). GIT_SSL_CAINFO replaces git's trust store, so the system
+// roots are copied into the combined bundle.
+var systemBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/cert.pem",
+}
+
+// Load returns the PEM data from file and/or inline pem, concatenated.
+func Load(file, pem string) ([]byte, error) {
+	var data []byte
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		data = append(data, b...)
+		data = append(data, '\n')
+	}
+	data = append(data, pem...)
+	return data, nil
+}
+
+// Install trusts extraPEM in addition to the system roots. Bundle files are
+// written to dir. It must run before the first outbound HTTPS request.
+func Install(extraPEM []byte, dir string) error {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(extraPEM) {
+		return fmt.Errorf("no valid PEM certificates in CA bundle")
+	}
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating CA bundle dir: %w", err)
+	}
+	extraPath := filepath.Join(dir, "extra-ca.pem")
+	if err := os.WriteFile(extraPath, extraPEM, 0644); err != nil {
+		return fmt.Errorf("writing CA bundle: %w", err)
+	}
+	combinedPath := filepath.Join(dir, "ca-bundle.pem")
+	if err := os.WriteFile(combinedPath, combine(extraPEM), 0644); err != nil {
+		return fmt.Errorf("writing CA bundle: %w", err)
+	}
+
+	if err := os.Setenv("GIT_SSL_CAINFO", combinedPath); err != nil {
+		return err
+	}
+	return os.Setenv("NODE_EXTRA_CA_CERTS", extraPath)
+}
+
+// combine prepends the first readable system bundle to extraPEM.
+func combine(extraPEM []byte) []byte {
+	for _, path := range systemBundles {
+		if system, err := os.ReadFile(path); err == nil {
+			return append(append(system, '\n'), extraPEM...)
+		}
+	}
+	return extraPEM
+}
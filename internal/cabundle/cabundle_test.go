@@ -0,0 +1,83 @@
+package cabundle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCAPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Corp CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(file, []byte("FILE"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := Load(file, "INLINE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "FILE") || !strings.Contains(string(data), "INLINE") {
+		t.Errorf("Load = %q", data)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.pem"), ""); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	t.Setenv("GIT_SSL_CAINFO", "")
+	t.Setenv("NODE_EXTRA_CA_CERTS", "")
+	dir := t.TempDir()
+	ca := testCAPEM(t)
+
+	if err := Install(ca, dir); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	combined, err := os.ReadFile(os.Getenv("GIT_SSL_CAINFO"))
+	if err != nil {
+		t.Fatalf("GIT_SSL_CAINFO not readable: %v", err)
+	}
+	if !strings.Contains(string(combined), string(ca)) {
+		t.Error("combined bundle must contain the extra CA")
+	}
+	if os.Getenv("NODE_EXTRA_CA_CERTS") != filepath.Join(dir, "extra-ca.pem") {
+		t.Errorf("NODE_EXTRA_CA_CERTS = %q", os.Getenv("NODE_EXTRA_CA_CERTS"))
+	}
+}
+
+func TestInstall_InvalidPEM(t *testing.T) {
+	if err := Install([]byte("not a cert"), t.TempDir()); err == nil {
+		t.Error("expected error for invalid PEM")
+	}
+}
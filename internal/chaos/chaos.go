@@ -0,0 +1,151 @@
+// Package chaos injects faults (slow Redis, killed CLI runs, dropped webhooks,
+// failed clones) at configurable rates so recovery paths — retries, orphan
+// recovery, failure webhooks — can be exercised in staging. Never enable it
+// in production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/metrics"
+)
+
+// ErrInjected is returned (wrapped) by every injected failure.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Config sets the probability (0..1) of each fault.
+type Config struct {
+	RedisDelayRate  float64
+	RedisDelayMax   time.Duration
+	CLIKillRate     float64
+	CLIKillAfterMax time.Duration // the CLI is killed a random time within this window
+	WebhookDropRate float64
+	CloneFailRate   float64
+}
+
+// Injector decides, per operation, whether to inject a fault.
+// A nil Injector is valid and never injects anything.
+type Injector struct {
+	cfg Config
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New creates an injector. The seed makes a run reproducible; 0 seeds from the clock.
+func New(cfg Config, seed int64) *Injector {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{cfg: cfg, rnd: rand.New(rand.NewSource(seed))}
+}
+
+// hit rolls the dice for a fault with the given rate and counts injected faults.
+func (i *Injector) hit(fault string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	ok := i.rnd.Float64() < rate
+	i.mu.Unlock()
+	if ok {
+		metrics.ChaosFaults.WithLabelValues(fault).Inc()
+	}
+	return ok
+}
+
+// upTo returns a random duration in [0, max).
+func (i *Injector) upTo(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return time.Duration(i.rnd.Int63n(int64(max)))
+}
+
+// CloneError returns an injected error for a clone attempt, or nil.
+func (i *Injector) CloneError() error {
+	if i == nil || !i.hit("clone_fail", i.cfg.CloneFailRate) {
+		return nil
+	}
+	slog.Warn("chaos: failing clone attempt")
+	return fmt.Errorf("%w: clone failed", ErrInjected)
+}
+
+// DropWebhook reports whether a webhook delivery attempt should be dropped.
+func (i *Injector) DropWebhook() bool {
+	if i == nil || !i.hit("webhook_drop", i.cfg.WebhookDropRate) {
+		return false
+	}
+	slog.Warn("chaos: dropping webhook delivery attempt")
+	return true
+}
+
+// WithCLIKill derives a context for a CLI run that may be cancelled a random
+// time into the run, killing the process as a crash would. The parent context
+// is untouched, so the run fails instead of being treated as a timeout.
+func (i *Injector) WithCLIKill(ctx context.Context) (context.Context, context.CancelFunc) {
+	if i == nil || !i.hit("cli_kill", i.cfg.CLIKillRate) {
+		return ctx, func() {}
+	}
+	after := i.upTo(i.cfg.CLIKillAfterMax)
+	slog.Warn("chaos: CLI run will be killed", "after", after)
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(after, cancel)
+	return ctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// RedisHook returns a go-redis hook delaying commands and pipelines.
+// Only call it on a non-nil Injector.
+func (i *Injector) RedisHook() redis.Hook {
+	return redisHook{i}
+}
+
+// delay sleeps for a random time when the Redis delay fault fires.
+func (i *Injector) delay(ctx context.Context) error {
+	if !i.hit("redis_delay", i.cfg.RedisDelayRate) {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(i.upTo(i.cfg.RedisDelayMax)):
+		return nil
+	}
+}
+
+type redisHook struct{ i *Injector }
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.i.delay(ctx); err != nil {
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.i.delay(ctx); err != nil {
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
@@ -0,0 +1,96 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNilInjector(t *testing.T) {
+	var i *Injector
+	if err := i.CloneError(); err != nil {
+		t.Errorf("CloneError = %v", err)
+	}
+	if i.DropWebhook() {
+		t.Error("DropWebhook = true")
+	}
+	ctx, cancel := i.WithCLIKill(context.Background())
+	defer cancel()
+	if ctx != context.Background() {
+		t.Error("WithCLIKill should return the parent context")
+	}
+}
+
+func TestRates(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+		want bool
+	}{
+		{"never", 0, false},
+		{"always", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New(Config{CloneFailRate: tt.rate, WebhookDropRate: tt.rate, CLIKillRate: tt.rate}, 1)
+			for n := 0; n < 20; n++ {
+				if err := i.CloneError(); (err != nil) != tt.want || (err != nil && !errors.Is(err, ErrInjected)) {
+					t.Fatalf("CloneError = %v", err)
+				}
+				if i.DropWebhook() != tt.want {
+					t.Fatal("DropWebhook mismatch")
+				}
+			}
+		})
+	}
+}
+
+func TestRates_Partial(t *testing.T) {
+	i := New(Config{CloneFailRate: 0.3}, 42)
+	failed := 0
+	for n := 0; n < 1000; n++ {
+		if i.CloneError() != nil {
+			failed++
+		}
+	}
+	if failed < 200 || failed > 400 {
+		t.Errorf("failed %d of 1000 at rate 0.3", failed)
+	}
+}
+
+func TestWithCLIKill(t *testing.T) {
+	parent := context.Background()
+	i := New(Config{CLIKillRate: 1, CLIKillAfterMax: 10 * time.Millisecond}, 1)
+	ctx, cancel := i.WithCLIKill(parent)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("run context was not cancelled")
+	}
+	if parent.Err() != nil {
+		t.Error("parent context must stay alive")
+	}
+}
+
+func TestRedisDelay(t *testing.T) {
+	i := New(Config{RedisDelayRate: 1, RedisDelayMax: 20 * time.Millisecond}, 1)
+	start := time.Now()
+	for n := 0; n < 5; n++ {
+		if err := i.delay(context.Background()); err != nil {
+			t.Fatalf("delay = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("5 delays took %v, want < 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	slow := New(Config{RedisDelayRate: 1, RedisDelayMax: time.Hour}, 1)
+	if err := slow.delay(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("delay past deadline = %v", err)
+	}
+}
@@ -27,6 +27,118 @@ type Config struct {
 	Logging       LoggingConfig       `koanf:"logging"`
 	Subscription  SubscriptionConfig  `koanf:"subscription"`
 	Notifications NotificationsConfig `koanf:"notifications"`
+	BlobStore     BlobStoreConfig     `koanf:"blob_store"`
+	Proxy         ProxyConfig         `koanf:"proxy"`
+	HTTPClient    HTTPClientConfig    `koanf:"http_client"`
+	TLS           TLSConfig           `koanf:"tls"`
+	RepoPolicy    RepoPolicyConfig    `koanf:"repo_policy"`
+	PromptPolicy  PromptPolicyConfig  `koanf:"prompt_policy"`
+	Redaction     RedactionConfig     `koanf:"redaction"`
+	Chaos         ChaosConfig         `koanf:"chaos"`
+	Hooks         []HookConfig        `koanf:"hooks"`
+}
+
+// HookConfig runs an external command at one stage of a session run:
+// pre_clone, post_clone, pre_run, post_run or pre_pr. A failing hook fails the
+// session (or rejects the PR for pre_pr) unless Optional is set.
+type HookConfig struct {
+	Name     string        `koanf:"name"`
+	Stage    string        `koanf:"stage"`
+	Command  string        `koanf:"command"` // run with sh -c in the workspace
+	Timeout  time.Duration `koanf:"timeout"` // 0 = 5m
+	Optional bool          `koanf:"optional"`
+}
+
+// ChaosConfig enables fault injection for recovery drills in staging: Redis
+// commands are delayed, CLI runs killed, webhook attempts dropped and clone
+// attempts failed, each at its own rate (0..1). Never enable in production.
+type ChaosConfig struct {
+	Enabled         bool          `koanf:"enabled"`
+	Seed            int64         `koanf:"seed"` // fixed seed for a reproducible run; 0 = random
+	RedisDelayRate  float64       `koanf:"redis_delay_rate"`
+	RedisDelayMax   time.Duration `koanf:"redis_delay_max"`
+	CLIKillRate     float64       `koanf:"cli_kill_rate"`
+	CLIKillAfterMax time.Duration `koanf:"cli_kill_after_max"` // kill within this long after the CLI starts
+	WebhookDropRate float64       `koanf:"webhook_drop_rate"`
+	CloneFailRate   float64       `koanf:"clone_fail_rate"`
+}
+
+// RedactionConfig masks secrets in streamed events, transcripts and stored
+// results. Built-in rules cover common token formats and .env assignments;
+// each session's own access token and AI key are always masked when enabled.
+type RedactionConfig struct {
+	Enabled  bool     `koanf:"enabled"`
+	Patterns []string `koanf:"patterns"` // extra regexes; a (?P<keep>...) group is preserved
+}
+
+// PromptPolicyConfig configures prompt moderation on session create and
+// instruct: a built-in regex denylist plus an optional external policy service.
+// Every decision is written to the audit log.
+type PromptPolicyConfig struct {
+	RejectPatterns []string      `koanf:"reject_patterns"` // case-insensitive regexes that reject the prompt
+	FlagPatterns   []string      `koanf:"flag_patterns"`   // case-insensitive regexes that only flag it
+	WebhookURL     string        `koanf:"webhook_url"`     // external policy service (POST JSON, answers {"action","reason"})
+	Timeout        time.Duration `koanf:"timeout"`
+	FailOpen       bool          `koanf:"fail_open"` // flag instead of reject when the service is unavailable
+}
+
+// RepoPolicyConfig restricts which repositories sessions may target. Rules are
+// globs on "host/owner/repo" ("**" spans subgroups) or "re:"-prefixed regexes.
+// Deny wins; a non-empty allow list rejects everything it does not match.
+// Schemes lists the URL schemes repo_url may use (https, http, ssh, file).
+type RepoPolicyConfig struct {
+	Allow   []string `koanf:"allow"`
+	Deny    []string `koanf:"deny"`
+	Schemes []string `koanf:"schemes"`
+}
+
+// TLSConfig adds trusted CA certificates for hosts signed by a private CA
+// (e.g. self-hosted GitLab). Both sources may be combined.
+type TLSConfig struct {
+	CAFile string `koanf:"ca_file"` // path to a PEM bundle
+	CAPEM  string `koanf:"ca_pem"`  // inline PEM certificates
+}
+
+// ProxyConfig routes outbound traffic (git, AI CLIs, provider APIs, webhooks)
+// through a corporate proxy. Empty values leave existing *_PROXY env untouched.
+type ProxyConfig struct {
+	HTTPProxy             string `koanf:"http_proxy"`
+	HTTPSProxy            string `koanf:"https_proxy"`
+	NoProxy               string `koanf:"no_proxy"`                // comma-separated hosts/domains/CIDRs reached directly
+	BypassProviderDomains bool   `koanf:"bypass_provider_domains"` // add git.provider_domains (self-hosted GitLab/GitHub) to no_proxy
+}
+
+// HTTPClientConfig tunes outbound HTTP calls: one shared connection pool and a
+// timeout per component. The prompt policy service keeps prompt_policy.timeout.
+type HTTPClientConfig struct {
+	MaxIdleConns        int                `koanf:"max_idle_conns"`
+	MaxIdleConnsPerHost int                `koanf:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration      `koanf:"idle_conn_timeout"`
+	Timeouts            HTTPTimeoutsConfig `koanf:"timeouts"`
+}
+
+// HTTPTimeoutsConfig holds the per-component request timeouts.
+type HTTPTimeoutsConfig struct {
+	Webhook   time.Duration `koanf:"webhook"`    // session callbacks
+	Provider  time.Duration `koanf:"provider"`   // GitHub/GitLab API, key verification
+	Review    time.Duration `koanf:"review"`     // PR review comments and diffs
+	AI        time.Duration `koanf:"ai"`         // analyzer LLM APIs
+	Notify    time.Duration `koanf:"notify"`     // Slack/Discord/Teams
+	Sentry    time.Duration `koanf:"sentry"`     // Sentry proxy
+	BlobStore time.Duration `koanf:"blob_store"` // S3-compatible offload
+}
+
+// BlobStoreConfig configures optional S3-compatible object storage for large
+// results and transcripts. Disabled unless endpoint and bucket are set.
+type BlobStoreConfig struct {
+	Endpoint         string `koanf:"endpoint"` // e.g. https://s3.eu-central-1.amazonaws.com, https://storage.googleapis.com
+	Bucket           string `koanf:"bucket"`
+	Region           string `koanf:"region"`
+	AccessKey        string `koanf:"access_key"`
+	SecretKey        string `koanf:"secret_key"`
+	Prefix           string `koanf:"prefix"`            // key prefix inside the bucket
+	PathStyle        bool   `koanf:"path_style"`        // bucket in the URL path (MinIO)
+	OffloadThreshold int    `koanf:"offload_threshold"` // payloads >= this many bytes leave Redis
 }
 
 // NotificationsConfig controls outbound chat notifications for terminal session
@@ -54,27 +166,101 @@ type SQLiteConfig struct {
 type ServerConfig struct {
 	Port      int    `koanf:"port"`
 	AuthToken string `koanf:"auth_token"`
+	// StrictAPI rejects request bodies with fields the OpenAPI spec does not document.
+	StrictAPI bool `koanf:"strict_api"`
+	// H2C serves HTTP/2 over cleartext (prior knowledge) next to HTTP/1.1,
+	// for reverse proxies that speak h2c to the backend.
+	H2C bool `koanf:"h2c"`
+	// TLSCertFile/TLSKeyFile serve HTTPS directly; browsers then negotiate
+	// HTTP/2 and multiplex SSE streams over a single connection.
+	TLSCertFile string `koanf:"tls_cert_file"`
+	TLSKeyFile  string `koanf:"tls_key_file"`
+	// SSEMaxDuration closes live event streams after this long (0 = until the session is done).
+	SSEMaxDuration time.Duration `koanf:"sse_max_duration"`
+	// SSEKeepalive is the interval of keepalive comments on idle streams.
+	SSEKeepalive time.Duration `koanf:"sse_keepalive"`
 }
 
 type RedisConfig struct {
-	URL    string `koanf:"url"`
-	Prefix string `koanf:"prefix"`
+	URL                 string         `koanf:"url"` // redis:// or rediss:// (TLS)
+	Prefix              string         `koanf:"prefix"`
+	Username            string         `koanf:"username"` // ACL user; overrides the URL's
+	Password            string         `koanf:"password"` // overrides the URL's
+	TLS                 RedisTLSConfig `koanf:"tls"`
+	HealthCheckInterval time.Duration  `koanf:"health_check_interval"` // circuit breaker ping interval
+	FailureThreshold    int            `koanf:"failure_threshold"`     // failed pings before degraded mode
+}
+
+// RedisTLSConfig configures TLS for managed Redis. It applies to rediss://
+// URLs; Enabled turns TLS on for a redis:// URL.
+type RedisTLSConfig struct {
+	Enabled            bool   `koanf:"enabled"`
+	CAFile             string `koanf:"ca_file"`   // PEM bundle replacing the system roots
+	CertFile           string `koanf:"cert_file"` // client certificate (mutual TLS)
+	KeyFile            string `koanf:"key_file"`
+	ServerName         string `koanf:"server_name"` // when the certificate name differs from the host
+	InsecureSkipVerify bool   `koanf:"insecure_skip_verify"`
 }
 
 type WorkersConfig struct {
 	Concurrency int    `koanf:"concurrency"`
 	QueueName   string `koanf:"queue_name"`
+	NodeID      string `koanf:"node_id"` // registry and lease ID; empty = hostname
+	// MaxQueueDepth rejects new sessions with 503 while this many wait in the queue (0 = unlimited).
+	MaxQueueDepth int `koanf:"max_queue_depth"`
+	// QueueRetryAfter is the Retry-After (seconds) sent with those rejections.
+	QueueRetryAfter int `koanf:"queue_retry_after"`
 }
 
 type SessionsConfig struct {
-	DefaultTimeout          int    `koanf:"default_timeout"`
-	MaxTimeout              int    `koanf:"max_timeout"`
-	WorkspaceTTL            int    `koanf:"workspace_ttl"`
-	WorkspaceBase           string `koanf:"workspace_base"`
-	StateTTL                int    `koanf:"state_ttl"`
-	ResultTTL               int    `koanf:"result_ttl"`
-	DiskWarningThresholdGB  int    `koanf:"disk_warning_threshold_gb"`
-	DiskCriticalThresholdGB int    `koanf:"disk_critical_threshold_gb"`
+	DefaultTimeout          int                   `koanf:"default_timeout"`
+	MaxTimeout              int                   `koanf:"max_timeout"`
+	WorkspaceTTL            int                   `koanf:"workspace_ttl"`
+	WorkspaceBase           string                `koanf:"workspace_base"`
+	StateTTL                int                   `koanf:"state_ttl"`
+	ResultTTL               int                   `koanf:"result_ttl"`
+	DiskWarningThresholdGB  int                   `koanf:"disk_warning_threshold_gb"`
+	DiskCriticalThresholdGB int                   `koanf:"disk_critical_threshold_gb"`
+	MaxIterations           int                   `koanf:"max_iterations"`           // default cap on iterations per session (0 = unlimited)
+	TranscriptTTL           int                   `koanf:"transcript_ttl"`           // seconds to keep full CLI transcripts (0 = no expiry)
+	TranscriptMaxBytes      int                   `koanf:"transcript_max_bytes"`     // per-iteration transcript cap before compression (0 = unlimited)
+	ResultMaxChars          int                   `koanf:"result_max_chars"`         // result kept in iteration history and webhook SSE events
+	MaxContextChars         int                   `koanf:"max_context_chars"`        // previous-iteration context injected into follow-up prompts
+	ProviderErrorMaxBytes   int                   `koanf:"provider_error_max_bytes"` // provider API error body kept in error messages
+	WorkspaceSizeInterval   int                   `koanf:"workspace_size_interval"`  // seconds between background workspace sizing passes
+	WorkspaceSizeStaleness  int                   `koanf:"workspace_size_staleness"` // seconds before a cached workspace size is recomputed
+	MaxRetainTTL            int                   `koanf:"max_retain_ttl"`           // upper bound in seconds for POST /sessions/{id}/retain (0 = unbounded)
+	AwaitTTL                int                   `koanf:"await_ttl"`                // default seconds POST /sessions/{id}/await keeps the workspace
+	MaxAwaitTTL             int                   `koanf:"max_await_ttl"`            // upper bound in seconds for POST /sessions/{id}/await (0 = unbounded)
+	StaleSessionAge         int                   `koanf:"stale_session_age"`        // seconds in pending/awaiting_instruction before a session is expired (0 = never)
+	DeleteGracePeriod       int                   `koanf:"delete_grace_period"`      // seconds a deleted session stays restorable before it is purged
+	PromptUploadMaxBytes    int                   `koanf:"prompt_upload_max_bytes"`  // size limit of a prompt uploaded via POST /prompts
+	PromptUploadTTL         int                   `koanf:"prompt_upload_ttl"`        // seconds an uploaded prompt can be referenced
+	ProgressInterval        int                   `koanf:"progress_interval"`        // seconds between cli_progress events during a CLI run (0 = none)
+	HeartbeatInterval       int                   `koanf:"heartbeat_interval"`       // seconds of CLI silence before (and between) cli_heartbeat events (0 = none)
+	CrashLoopThreshold      int                   `koanf:"crash_loop_threshold"`     // automatic runs failing the same way in a row before the session is failed (0 = no limit)
+	Defaults                SessionDefaultsConfig `koanf:"defaults"`
+	Cache                   SharedCacheConfig     `koanf:"cache"`
+	// WorkspaceTemplates maps template names to directories of dependency
+	// caches copied into fresh workspaces; "default" applies to sessions
+	// that pick none.
+	WorkspaceTemplates map[string]string `koanf:"workspace_templates"`
+}
+
+// SessionDefaultsConfig fills session config fields a create request (and its
+// project settings) leave empty. Zero values apply no default.
+type SessionDefaultsConfig struct {
+	MaxTurns     int     `koanf:"max_turns"`
+	MaxBudgetUSD float64 `koanf:"max_budget_usd"`
+	TargetBranch string  `koanf:"target_branch"`
+	AllowedTools string  `koanf:"allowed_tools"` // comma-separated Claude Code tool allowlist
+}
+
+// SharedCacheConfig enables dependency caches (Go modules, npm, pip, …)
+// shared by the sessions of a tenant on a node. An empty Dir disables it.
+type SharedCacheConfig struct {
+	Dir   string `koanf:"dir"`
+	MaxGB int    `koanf:"max_gb"` // size above which the cleaner evicts caches (0 = unlimited)
 }
 
 type CLIConfig struct {
@@ -82,6 +268,21 @@ type CLIConfig struct {
 	ClaudeCode ClaudeCodeConfig `koanf:"claude_code"`
 	Codex      CodexConfig      `koanf:"codex"`
 	Cursor     CursorConfig     `koanf:"cursor"`
+	RunAs      RunAsConfig      `koanf:"run_as"`
+}
+
+// RunAsConfig picks the Unix user the CLI runs as and hands workspaces to.
+// auto drops to the "codeforge" user when the server runs as root; current
+// never switches users or chowns (rootless containers, K8s securityContext);
+// fixed switches to User, or UID/GID (needs root); ephemeral gives every
+// session a throwaway uid from EphemeralUIDMin..EphemeralUIDMax (needs root).
+type RunAsConfig struct {
+	Mode            string `koanf:"mode"`              // auto (default), current, fixed, ephemeral
+	User            string `koanf:"user"`              // fixed: user name or numeric uid
+	UID             int    `koanf:"uid"`               // fixed: uid when user is empty
+	GID             int    `koanf:"gid"`               // fixed: gid; 0 = the user's primary group
+	EphemeralUIDMin int    `koanf:"ephemeral_uid_min"` // ephemeral: first uid handed out
+	EphemeralUIDMax int    `koanf:"ephemeral_uid_max"` // ephemeral: last uid handed out
 }
 
 type CursorConfig struct {
@@ -97,9 +298,27 @@ type CodexConfig struct {
 }
 
 type ClaudeCodeConfig struct {
-	Path         string   `koanf:"path"`
-	DefaultModel string   `koanf:"default_model"`
-	Models       []string `koanf:"models"`
+	Path         string        `koanf:"path"`
+	DefaultModel string        `koanf:"default_model"`
+	Models       []string      `koanf:"models"`
+	Backend      string        `koanf:"backend"` // anthropic (default), bedrock, vertex
+	Bedrock      BedrockConfig `koanf:"bedrock"`
+	Vertex       VertexConfig  `koanf:"vertex"`
+}
+
+// BedrockConfig routes Claude Code through AWS Bedrock. Credentials come from
+// the standard AWS chain (env vars, shared profile, instance/task role).
+type BedrockConfig struct {
+	Region  string `koanf:"region"`
+	Profile string `koanf:"profile"`
+}
+
+// VertexConfig routes Claude Code through Google Vertex AI. Credentials come
+// from Application Default Credentials or CredentialsFile.
+type VertexConfig struct {
+	Region          string `koanf:"region"`
+	ProjectID       string `koanf:"project_id"`
+	CredentialsFile string `koanf:"credentials_file"`
 }
 
 type GitConfig struct {
@@ -107,6 +326,26 @@ type GitConfig struct {
 	CommitAuthor    string            `koanf:"commit_author"`
 	CommitEmail     string            `koanf:"commit_email"`
 	ProviderDomains map[string]string `koanf:"provider_domains"`
+	APIBaseURLs     map[string]string `koanf:"api_base_urls"` // host → explicit provider API base URL
+
+	DirectCommit DirectCommitConfig `koanf:"direct_commit"`
+	GitLabMR     GitLabMRConfig     `koanf:"gitlab_mr"`
+}
+
+// GitLabMRConfig sets merge request options on every MR CodeForge opens;
+// false leaves the project default. create-pr can override them per MR.
+type GitLabMRConfig struct {
+	Squash                bool `koanf:"squash"`
+	RemoveSourceBranch    bool `koanf:"remove_source_branch"`
+	ApprovalRuleReviewers bool `koanf:"approval_rule_reviewers"` // request review from approval rule approvers
+}
+
+// DirectCommitConfig allows create-pr with direct=true to commit trivial
+// changes straight to the target branch instead of opening a PR.
+type DirectCommitConfig struct {
+	Enabled  bool     `koanf:"enabled"`
+	Paths    []string `koanf:"paths"`     // globs any change may touch; other files may change whitespace only
+	MaxLines int      `koanf:"max_lines"` // ceiling on added plus deleted lines (0 = no ceiling)
 }
 
 type EncryptionConfig struct {
@@ -114,9 +353,17 @@ type EncryptionConfig struct {
 }
 
 type WebhookConfig struct {
-	HMACSecret string        `koanf:"hmac_secret"`
-	RetryCount int           `koanf:"retry_count"`
-	RetryDelay time.Duration `koanf:"retry_delay"`
+	HMACSecret    string        `koanf:"hmac_secret"`
+	RetryCount    int           `koanf:"retry_count"`
+	RetryDelay    time.Duration `koanf:"retry_delay"`     // base delay, doubled per retry
+	RetryMaxDelay time.Duration `koanf:"retry_max_delay"` // cap for one retry delay, incl. Retry-After
+
+	// SSRF guard for callback_url: loopback, private and link-local targets
+	// are blocked unless allowed below.
+	AllowPrivate bool     `koanf:"allow_private"` // disable the default block
+	AllowHosts   []string `koanf:"allow_hosts"`   // host names exempt from all checks
+	AllowCIDRs   []string `koanf:"allow_cidrs"`   // networks exempt from the default block
+	DenyCIDRs    []string `koanf:"deny_cidrs"`    // extra blocked networks, win over allow_cidrs
 }
 
 type CodeReviewConfig struct {
@@ -152,17 +399,23 @@ type LoggingConfig struct {
 func Defaults() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: 8080,
+			Port:           8080,
+			H2C:            true,
+			SSEMaxDuration: 10 * time.Minute,
+			SSEKeepalive:   15 * time.Second,
 		},
 		Redis: RedisConfig{
-			Prefix: "codeforge:",
+			Prefix:              "codeforge:",
+			HealthCheckInterval: 2 * time.Second,
+			FailureThreshold:    3,
 		},
 		SQLite: SQLiteConfig{
 			Path: "/data/codeforge.db",
 		},
 		Workers: WorkersConfig{
-			Concurrency: 3,
-			QueueName:   "queue:sessions",
+			Concurrency:     3,
+			QueueName:       "queue:sessions",
+			QueueRetryAfter: 30,
 		},
 		Sessions: SessionsConfig{
 			DefaultTimeout:          300,
@@ -173,6 +426,27 @@ func Defaults() *Config {
 			ResultTTL:               604800,
 			DiskWarningThresholdGB:  10,
 			DiskCriticalThresholdGB: 20,
+			TranscriptTTL:           2592000,
+			TranscriptMaxBytes:      10485760,
+			ResultMaxChars:          2000,
+			MaxContextChars:         50000,
+			ProviderErrorMaxBytes:   500,
+			WorkspaceSizeInterval:   60,
+			WorkspaceSizeStaleness:  900,
+			MaxRetainTTL:            7776000,
+			AwaitTTL:                86400,
+			MaxAwaitTTL:             604800,
+			StaleSessionAge:         1209600,
+			DeleteGracePeriod:       86400,
+			PromptUploadMaxBytes:    1048576,
+			PromptUploadTTL:         86400,
+			ProgressInterval:        10,
+			HeartbeatInterval:       30,
+			CrashLoopThreshold:      3,
+			WorkspaceTemplates:      map[string]string{},
+			Cache: SharedCacheConfig{
+				MaxGB: 20,
+			},
 		},
 		CLI: CLIConfig{
 			Default: "claude-code",
@@ -200,16 +474,41 @@ func Defaults() *Config {
 					"composer-2",
 				},
 			},
+			RunAs: RunAsConfig{
+				Mode:            "auto",
+				EphemeralUIDMin: 200000,
+				EphemeralUIDMax: 299999,
+			},
 		},
 		Git: GitConfig{
 			BranchPrefix:    "codeforge/",
 			CommitAuthor:    "CodeForge Bot",
 			CommitEmail:     "codeforge@noreply",
 			ProviderDomains: map[string]string{},
+			APIBaseURLs:     map[string]string{},
+			DirectCommit: DirectCommitConfig{
+				Paths:    []string{"*.md", "*.rst", "docs", "LICENSE", "AUTHORS"},
+				MaxLines: 50,
+			},
 		},
 		Webhooks: WebhookConfig{
-			RetryCount: 3,
-			RetryDelay: 5 * time.Second,
+			RetryCount:    3,
+			RetryDelay:    5 * time.Second,
+			RetryMaxDelay: 5 * time.Minute,
+		},
+		HTTPClient: HTTPClientConfig{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			Timeouts: HTTPTimeoutsConfig{
+				Webhook:   10 * time.Second,
+				Provider:  15 * time.Second,
+				Review:    30 * time.Second,
+				AI:        15 * time.Second,
+				Notify:    10 * time.Second,
+				Sentry:    30 * time.Second,
+				BlobStore: 60 * time.Second,
+			},
 		},
 		RateLimit: RateLimitConfig{
 			Enabled:           true,
@@ -227,6 +526,25 @@ func Defaults() *Config {
 			Level:  "info",
 			Format: "json",
 		},
+		BlobStore: BlobStoreConfig{
+			Region:           "us-east-1",
+			Prefix:           "codeforge",
+			OffloadThreshold: 65536,
+		},
+		RepoPolicy: RepoPolicyConfig{
+			Schemes: []string{"https", "ssh"},
+		},
+		PromptPolicy: PromptPolicyConfig{
+			Timeout: 5 * time.Second,
+		},
+		Redaction: RedactionConfig{
+			Enabled: true,
+		},
+		Chaos: ChaosConfig{
+			RedisDelayMax:   500 * time.Millisecond,
+			CLIKillAfterMax: 30 * time.Second,
+		},
+		Hooks: []HookConfig{},
 	}
 }
 
@@ -279,11 +597,58 @@ func validate(cfg *Config) error {
 	if cfg.Redis.URL == "" {
 		return fmt.Errorf("config: redis.url is required (set CODEFORGE_REDIS__URL)")
 	}
+	if (cfg.Redis.TLS.CertFile == "") != (cfg.Redis.TLS.KeyFile == "") {
+		return fmt.Errorf("config: redis.tls.cert_file and redis.tls.key_file must be set together")
+	}
+	if cfg.Redis.HealthCheckInterval <= 0 || cfg.Redis.FailureThreshold <= 0 {
+		return fmt.Errorf("config: redis.health_check_interval and redis.failure_threshold must be positive")
+	}
+	if cfg.Workers.MaxQueueDepth < 0 || cfg.Workers.QueueRetryAfter < 0 {
+		return fmt.Errorf("config: workers.max_queue_depth and workers.queue_retry_after must not be negative")
+	}
 	if cfg.Server.AuthToken == "" {
 		return fmt.Errorf("config: server.auth_token is required (set CODEFORGE_SERVER__AUTH_TOKEN)")
 	}
 	if cfg.Encryption.Key == "" {
 		return fmt.Errorf("config: encryption.key is required (set CODEFORGE_ENCRYPTION__KEY)")
 	}
+	switch cfg.CLI.ClaudeCode.Backend {
+	case "", "anthropic", "bedrock", "vertex":
+	default:
+		return fmt.Errorf("config: cli.claude_code.backend must be anthropic, bedrock or vertex (got %q)", cfg.CLI.ClaudeCode.Backend)
+	}
+	switch cfg.CLI.RunAs.Mode {
+	case "", "auto", "current", "fixed":
+	case "ephemeral":
+		if cfg.CLI.RunAs.EphemeralUIDMin <= 0 || cfg.CLI.RunAs.EphemeralUIDMax < cfg.CLI.RunAs.EphemeralUIDMin {
+			return fmt.Errorf("config: cli.run_as.ephemeral_uid_min must be positive and not above ephemeral_uid_max")
+		}
+	default:
+		return fmt.Errorf("config: cli.run_as.mode must be auto, current, fixed or ephemeral (got %q)", cfg.CLI.RunAs.Mode)
+	}
+	if (cfg.Server.TLSCertFile == "") != (cfg.Server.TLSKeyFile == "") {
+		return fmt.Errorf("config: server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	if cfg.Git.DirectCommit.MaxLines < 0 {
+		return fmt.Errorf("config: git.direct_commit.max_lines must not be negative")
+	}
+	for name, rate := range map[string]float64{
+		"redis_delay_rate":  cfg.Chaos.RedisDelayRate,
+		"cli_kill_rate":     cfg.Chaos.CLIKillRate,
+		"webhook_drop_rate": cfg.Chaos.WebhookDropRate,
+		"clone_fail_rate":   cfg.Chaos.CloneFailRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("config: chaos.%s must be between 0 and 1 (got %g)", name, rate)
+		}
+	}
+	for i, h := range cfg.Hooks {
+		if h.Name == "" || h.Command == "" {
+			return fmt.Errorf("config: hooks[%d] needs a name and a command", i)
+		}
+		if h.Timeout < 0 {
+			return fmt.Errorf("config: hooks[%d].timeout must not be negative", i)
+		}
+	}
 	return nil
 }
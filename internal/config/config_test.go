@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaults(t *testing.T) {
@@ -15,21 +17,69 @@ func TestDefaults(t *testing.T) {
 		want interface{}
 	}{
 		{"server.port", cfg.Server.Port, 8080},
+		{"server.h2c", cfg.Server.H2C, true},
+		{"server.sse_max_duration", cfg.Server.SSEMaxDuration, 10 * time.Minute},
+		{"server.sse_keepalive", cfg.Server.SSEKeepalive, 15 * time.Second},
 		{"redis.prefix", cfg.Redis.Prefix, "codeforge:"},
+		{"redis.health_check_interval", cfg.Redis.HealthCheckInterval, 2 * time.Second},
+		{"redis.failure_threshold", cfg.Redis.FailureThreshold, 3},
 		{"sqlite.path", cfg.SQLite.Path, "/data/codeforge.db"},
 		{"workers.concurrency", cfg.Workers.Concurrency, 3},
 		{"workers.queue_name", cfg.Workers.QueueName, "queue:sessions"},
+		{"workers.node_id", cfg.Workers.NodeID, ""},
+		{"workers.max_queue_depth", cfg.Workers.MaxQueueDepth, 0},
+		{"workers.queue_retry_after", cfg.Workers.QueueRetryAfter, 30},
 		{"sessions.default_timeout", cfg.Sessions.DefaultTimeout, 300},
 		{"sessions.max_timeout", cfg.Sessions.MaxTimeout, 1800},
+		{"sessions.result_max_chars", cfg.Sessions.ResultMaxChars, 2000},
+		{"sessions.max_context_chars", cfg.Sessions.MaxContextChars, 50000},
+		{"sessions.provider_error_max_bytes", cfg.Sessions.ProviderErrorMaxBytes, 500},
+		{"sessions.workspace_size_interval", cfg.Sessions.WorkspaceSizeInterval, 60},
+		{"sessions.workspace_size_staleness", cfg.Sessions.WorkspaceSizeStaleness, 900},
+		{"sessions.max_retain_ttl", cfg.Sessions.MaxRetainTTL, 7776000},
+		{"sessions.await_ttl", cfg.Sessions.AwaitTTL, 86400},
+		{"sessions.max_await_ttl", cfg.Sessions.MaxAwaitTTL, 604800},
+		{"sessions.stale_session_age", cfg.Sessions.StaleSessionAge, 1209600},
+		{"sessions.delete_grace_period", cfg.Sessions.DeleteGracePeriod, 86400},
+		{"sessions.prompt_upload_max_bytes", cfg.Sessions.PromptUploadMaxBytes, 1048576},
+		{"sessions.prompt_upload_ttl", cfg.Sessions.PromptUploadTTL, 86400},
+		{"sessions.progress_interval", cfg.Sessions.ProgressInterval, 10},
+		{"sessions.heartbeat_interval", cfg.Sessions.HeartbeatInterval, 30},
+		{"sessions.crash_loop_threshold", cfg.Sessions.CrashLoopThreshold, 3},
+		{"sessions.workspace_templates", len(cfg.Sessions.WorkspaceTemplates), 0},
+		{"sessions.cache.dir", cfg.Sessions.Cache.Dir, ""},
+		{"sessions.cache.max_gb", cfg.Sessions.Cache.MaxGB, 20},
+		{"sessions.defaults.max_turns", cfg.Sessions.Defaults.MaxTurns, 0},
+		{"sessions.defaults.target_branch", cfg.Sessions.Defaults.TargetBranch, ""},
 		{"cli.default", cfg.CLI.Default, "claude-code"},
 		{"cli.claude_code.path", cfg.CLI.ClaudeCode.Path, "claude"},
 		{"cli.codex.path", cfg.CLI.Codex.Path, "codex"},
+		{"cli.run_as.mode", cfg.CLI.RunAs.Mode, "auto"},
+		{"cli.run_as.ephemeral_uid_min", cfg.CLI.RunAs.EphemeralUIDMin, 200000},
+		{"cli.run_as.ephemeral_uid_max", cfg.CLI.RunAs.EphemeralUIDMax, 299999},
 		{"git.branch_prefix", cfg.Git.BranchPrefix, "codeforge/"},
+		{"git.api_base_urls", len(cfg.Git.APIBaseURLs), 0},
+		{"git.direct_commit.enabled", cfg.Git.DirectCommit.Enabled, false},
+		{"git.direct_commit.max_lines", cfg.Git.DirectCommit.MaxLines, 50},
+		{"git.direct_commit.paths", strings.Join(cfg.Git.DirectCommit.Paths, ","), "*.md,*.rst,docs,LICENSE,AUTHORS"},
+		{"git.gitlab_mr.squash", cfg.Git.GitLabMR.Squash, false},
+		{"webhooks.allow_private", cfg.Webhooks.AllowPrivate, false},
+		{"webhooks.retry_max_delay", cfg.Webhooks.RetryMaxDelay, 5 * time.Minute},
+		{"http_client.max_idle_conns_per_host", cfg.HTTPClient.MaxIdleConnsPerHost, 10},
+		{"http_client.timeouts.webhook", cfg.HTTPClient.Timeouts.Webhook, 10 * time.Second},
+		{"http_client.timeouts.provider", cfg.HTTPClient.Timeouts.Provider, 15 * time.Second},
 		{"rate_limit.enabled", cfg.RateLimit.Enabled, true},
 		{"rate_limit.sessions_per_minute", cfg.RateLimit.SessionsPerMinute, 10},
 		{"logging.level", cfg.Logging.Level, "info"},
 		{"logging.format", cfg.Logging.Format, "json"},
 		{"code_review.webhook_dedup_ttl", cfg.CodeReview.WebhookDedupTTL, 3600},
+		{"repo_policy.schemes", strings.Join(cfg.RepoPolicy.Schemes, ","), "https,ssh"},
+		{"prompt_policy.timeout", cfg.PromptPolicy.Timeout, 5 * time.Second},
+		{"redaction.enabled", cfg.Redaction.Enabled, true},
+		{"chaos.enabled", cfg.Chaos.Enabled, false},
+		{"chaos.redis_delay_max", cfg.Chaos.RedisDelayMax, 500 * time.Millisecond},
+		{"chaos.cli_kill_after_max", cfg.Chaos.CLIKillAfterMax, 30 * time.Second},
+		{"hooks", len(cfg.Hooks), 0},
 	}
 
 	for _, tt := range tests {
@@ -177,6 +227,18 @@ redis:
 	}
 }
 
+func TestLoad_Validation_ChaosRate(t *testing.T) {
+	t.Setenv("CODEFORGE_REDIS__URL", "redis://localhost:6379")
+	t.Setenv("CODEFORGE_SERVER__AUTH_TOKEN", "test-token")
+	t.Setenv("CODEFORGE_ENCRYPTION__KEY", "0123456789abcdef0123456789abcdef")
+	t.Setenv("CODEFORGE_CHAOS__CLONE_FAIL_RATE", "1.5")
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("expected error for chaos.clone_fail_rate > 1")
+	}
+}
+
 func TestLoad_InvalidConfigPath(t *testing.T) {
 	_, err := Load("/nonexistent/path.yaml")
 	if err == nil {
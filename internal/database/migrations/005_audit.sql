@@ -0,0 +1,14 @@
+-- Audit trail: security-relevant decisions (e.g. prompt policy verdicts).
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id  TEXT NOT NULL DEFAULT '',
+    tenant_id   TEXT NOT NULL DEFAULT '',
+    action      TEXT NOT NULL,            -- what was attempted, e.g. prompt.create, prompt.instruct
+    decision    TEXT NOT NULL,            -- allow, flag, reject
+    reason      TEXT NOT NULL DEFAULT '',
+    detail      TEXT NOT NULL DEFAULT '', -- JSON context (source, rule, repo)
+    created_at  TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_session ON audit_log(session_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
@@ -0,0 +1,4 @@
+-- Inbound X-Request-ID of the API call that created (or last instructed) a
+-- session, so a customer request can be traced across logs, SSE and webhooks.
+ALTER TABLE sessions ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_sessions_request_id ON sessions(request_id);
@@ -0,0 +1,4 @@
+-- Soft delete: a deleted session is hidden from lists and answers 410 until
+-- it is restored or purged after the grace period.
+ALTER TABLE sessions ADD COLUMN deleted_at TEXT;
+CREATE INDEX IF NOT EXISTS idx_sessions_deleted_at ON sessions(deleted_at);
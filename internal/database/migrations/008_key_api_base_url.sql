@@ -0,0 +1,3 @@
+-- Explicit provider API base URL per key, for GitHub Enterprise / GitLab
+-- installs where the conventional API location is wrong.
+ALTER TABLE keys ADD COLUMN api_base_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Structured summary of an iteration (what changed, files, open issues),
+-- used instead of the raw result when building follow-up prompts.
+ALTER TABLE session_iterations ADD COLUMN summary_json TEXT NOT NULL DEFAULT '';
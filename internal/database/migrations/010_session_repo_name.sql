@@ -0,0 +1,5 @@
+-- Lower-cased owner/repo of repo_url, so a repository's sessions are listed
+-- from SQLite whatever spelling of the URL they were created with. Rows
+-- written before this column are filled in at startup.
+ALTER TABLE sessions ADD COLUMN repo_name TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_sessions_repo_name ON sessions(repo_name, created_at);
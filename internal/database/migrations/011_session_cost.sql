@@ -0,0 +1,3 @@
+-- Spend of all CLI runs of a session, checked against config.max_budget_usd.
+-- Kept here as well as in Redis so the budget survives expired state.
+ALTER TABLE sessions ADD COLUMN cost_usd REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Cap on a tenant's total spend per calendar month (UTC); 0 = no cap.
+ALTER TABLE tenants ADD COLUMN max_budget_usd_per_month REAL NOT NULL DEFAULT 0;
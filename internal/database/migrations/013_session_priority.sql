@@ -0,0 +1,3 @@
+-- Queue priority of a session, applied to every iteration. Kept here as well
+-- as in Redis so requeues after expired state keep the priority.
+ALTER TABLE sessions ADD COLUMN priority TEXT NOT NULL DEFAULT '';
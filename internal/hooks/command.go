@@ -0,0 +1,122 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+// maxOutputChars is how much of a failed command's output ends up in the error.
+const maxOutputChars = 2000
+
+// defaultCommandTimeout bounds a command hook without a timeout of its own.
+const defaultCommandTimeout = 5 * time.Minute
+
+// Command is a hook that runs a shell command (sh -c) in the workspace, as
+// the session's CLI user (Event.RunAs), so agent-written files are never run
+// with the server's privileges. Without a workspace (pre_clone) it runs in a
+// temporary directory removed afterwards. The session is described in
+// CODEFORGE_HOOK_STAGE, CODEFORGE_SESSION_ID, CODEFORGE_REPO_URL,
+// CODEFORGE_ITERATION and CODEFORGE_WORK_DIR; a non-zero exit fails the hook.
+type Command struct {
+	name    string
+	command string
+	timeout time.Duration
+}
+
+// NewCommand creates a command hook (timeout 0 = 5 minutes).
+func NewCommand(name, command string, timeout time.Duration) *Command {
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	return &Command{name: name, command: command, timeout: timeout}
+}
+
+// Name returns the hook's name from the config.
+func (c *Command) Name() string {
+	return c.name
+}
+
+// Run executes the command and returns its exit status and output tail as
+// the error when it fails.
+func (c *Command) Run(ctx context.Context, ev Event) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	dir := ev.WorkDir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "codeforge-hook-")
+		if err != nil {
+			return fmt.Errorf("creating hook directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		if err := runas.Chown(tmp, ev.RunAs); err != nil {
+			return fmt.Errorf("handing hook directory to %s: %w", ev.RunAs.Spec(), err)
+		}
+		dir = tmp
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command)
+	cmd.Dir = dir
+	cmd = runas.Command(ctx, cmd, ev.RunAs)
+	cmd.Env = append(runas.Env(environ(), ev.RunAs),
+		"CODEFORGE_HOOK_STAGE="+string(ev.Stage),
+		"CODEFORGE_SESSION_ID="+ev.SessionID,
+		"CODEFORGE_REPO_URL="+ev.RepoURL,
+		"CODEFORGE_ITERATION="+strconv.Itoa(ev.Iteration),
+		"CODEFORGE_WORK_DIR="+ev.WorkDir,
+	)
+	// Kill the whole process group on timeout, like verification commands.
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+	if out := tail(buf.String(), maxOutputChars); out != "" {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return err
+}
+
+// environ returns the server environment without CodeForge's own settings,
+// which hold secrets a hook has no business reading.
+func environ() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "CODEFORGE_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
@@ -0,0 +1,136 @@
+// Package hooks runs team-specific steps at fixed points of a session —
+// before and after the clone, before and after the CLI run, before a PR is
+// created — so compliance checks, formatters or notifications can be added
+// without changing the executor. Hooks are external commands from the config
+// or Go plugins registered in main.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/metrics"
+	"github.com/freema/codeforge/internal/runas"
+)
+
+// Stage is a point in the session lifecycle where hooks run.
+type Stage string
+
+const (
+	PreClone  Stage = "pre_clone"  // before the repository is cloned (no workspace yet)
+	PostClone Stage = "post_clone" // after the clone, before the first run
+	PreRun    Stage = "pre_run"    // before each CLI run
+	PostRun   Stage = "post_run"   // after a successful CLI run, before the changes are diffed
+	PrePR     Stage = "pre_pr"     // before create-pr commits and pushes
+)
+
+// Stages lists every stage in lifecycle order.
+var Stages = []Stage{PreClone, PostClone, PreRun, PostRun, PrePR}
+
+// ParseStage validates a stage name from the config.
+func ParseStage(s string) (Stage, error) {
+	for _, st := range Stages {
+		if string(st) == s {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hook stage %q (stages: pre_clone, post_clone, pre_run, post_run, pre_pr)", s)
+}
+
+// Event describes the session a hook runs for.
+type Event struct {
+	Stage     Stage
+	SessionID string
+	RepoURL   string
+	Iteration int
+	WorkDir   string // the repository checkout; empty at pre_clone
+	// RunAs is the session's CLI user, which command hooks run as in the
+	// workspace; nil runs them as the server's user.
+	RunAs *runas.Identity
+}
+
+// Hook is one step run at a stage. An error stops the stage: the session run
+// fails (or create-pr is refused) with the error as the reason.
+type Hook interface {
+	Name() string
+	Run(ctx context.Context, ev Event) error
+}
+
+// funcHook adapts a function to Hook.
+type funcHook struct {
+	name string
+	fn   func(ctx context.Context, ev Event) error
+}
+
+// Func returns a Hook that calls fn, for plugins compiled into the binary.
+func Func(name string, fn func(ctx context.Context, ev Event) error) Hook {
+	return &funcHook{name: name, fn: fn}
+}
+
+func (h *funcHook) Name() string                            { return h.name }
+func (h *funcHook) Run(ctx context.Context, ev Event) error { return h.fn(ctx, ev) }
+
+// Error is a failed required hook.
+type Error struct {
+	Stage Stage
+	Hook  string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s hook %s failed: %v", e.Stage, e.Hook, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type registered struct {
+	hook     Hook
+	optional bool
+}
+
+// Runner holds the hooks of every stage. A nil Runner is valid and runs
+// nothing.
+type Runner struct {
+	stages map[Stage][]registered
+}
+
+// NewRunner creates a runner without hooks.
+func NewRunner() *Runner {
+	return &Runner{stages: make(map[Stage][]registered)}
+}
+
+// Register adds a hook to a stage. Hooks of a stage run in registration order
+// and the first failure stops the stage.
+func (r *Runner) Register(stage Stage, h Hook) {
+	r.stages[stage] = append(r.stages[stage], registered{hook: h})
+}
+
+// RegisterOptional adds a hook whose failure is only logged.
+func (r *Runner) RegisterOptional(stage Stage, h Hook) {
+	r.stages[stage] = append(r.stages[stage], registered{hook: h, optional: true})
+}
+
+// Run runs the hooks of ev.Stage and returns an *Error for the first required
+// hook that fails.
+func (r *Runner) Run(ctx context.Context, ev Event) error {
+	if r == nil {
+		return nil
+	}
+	for _, reg := range r.stages[ev.Stage] {
+		err := reg.hook.Run(ctx, ev)
+		if err == nil {
+			metrics.HookRuns.WithLabelValues(string(ev.Stage), "ok").Inc()
+			continue
+		}
+		metrics.HookRuns.WithLabelValues(string(ev.Stage), "failed").Inc()
+		if reg.optional {
+			slog.Warn("optional hook failed", "stage", ev.Stage, "hook", reg.hook.Name(), "session_id", ev.SessionID, "error", err)
+			continue
+		}
+		return &Error{Stage: ev.Stage, Hook: reg.hook.Name(), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+func TestParseStage(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Stage
+		wantErr bool
+	}{
+		{"pre_clone", PreClone, false},
+		{"post_clone", PostClone, false},
+		{"pre_run", PreRun, false},
+		{"post_run", PostRun, false},
+		{"pre_pr", PrePR, false},
+		{"PRE_PR", "", true},
+		{"post_pr", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseStage(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name      string
+		register  func(r *Runner, record func(string, error) Hook)
+		stage     Stage
+		wantCalls string
+		wantHook  string // name of the failing hook; empty = no error
+	}{
+		{
+			name: "runs in registration order",
+			register: func(r *Runner, h func(string, error) Hook) {
+				r.Register(PreRun, h("a", nil))
+				r.Register(PreRun, h("b", nil))
+			},
+			stage:     PreRun,
+			wantCalls: "a,b",
+		},
+		{
+			name: "only the event's stage",
+			register: func(r *Runner, h func(string, error) Hook) {
+				r.Register(PreRun, h("a", nil))
+				r.Register(PostRun, h("b", nil))
+			},
+			stage:     PostRun,
+			wantCalls: "b",
+		},
+		{
+			name: "required failure stops the stage",
+			register: func(r *Runner, h func(string, error) Hook) {
+				r.Register(PrePR, h("lint", errBoom))
+				r.Register(PrePR, h("after", nil))
+			},
+			stage:     PrePR,
+			wantCalls: "lint",
+			wantHook:  "lint",
+		},
+		{
+			name: "optional failure is skipped",
+			register: func(r *Runner, h func(string, error) Hook) {
+				r.RegisterOptional(PostClone, h("notify", errBoom))
+				r.Register(PostClone, h("after", nil))
+			},
+			stage:     PostClone,
+			wantCalls: "notify,after",
+		},
+		{
+			name:     "no hooks",
+			register: func(r *Runner, h func(string, error) Hook) {},
+			stage:    PreClone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			record := func(name string, err error) Hook {
+				return Func(name, func(ctx context.Context, ev Event) error {
+					calls = append(calls, name)
+					return err
+				})
+			}
+			r := NewRunner()
+			tt.register(r, record)
+
+			err := r.Run(context.Background(), Event{Stage: tt.stage, SessionID: "s1"})
+			if got := strings.Join(calls, ","); got != tt.wantCalls {
+				t.Errorf("calls = %q, want %q", got, tt.wantCalls)
+			}
+			if tt.wantHook == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var hookErr *Error
+			if !errors.As(err, &hookErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if hookErr.Hook != tt.wantHook || hookErr.Stage != tt.stage || !errors.Is(err, errBoom) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunner_Nil(t *testing.T) {
+	var r *Runner
+	if err := r.Run(context.Background(), Event{Stage: PreRun}); err != nil {
+		t.Fatalf("nil runner: %v", err)
+	}
+}
+
+func TestCommand_Run(t *testing.T) {
+	t.Setenv("CODEFORGE_ENCRYPTION__KEY", "secret")
+	dir := t.TempDir()
+	ev := Event{Stage: PostClone, SessionID: "s1", RepoURL: "https://github.com/o/r.git", Iteration: 2, WorkDir: dir}
+
+	out := filepath.Join(dir, "env.txt")
+	cmd := NewCommand("env", `echo "$CODEFORGE_HOOK_STAGE $CODEFORGE_SESSION_ID $CODEFORGE_ITERATION $CODEFORGE_REPO_URL $(pwd) [$CODEFORGE_ENCRYPTION__KEY]" > env.txt`, 0)
+	if err := cmd.Run(context.Background(), ev); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "post_clone s1 2 https://github.com/o/r.git " + dir + " []"
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCommand_RunPreClone(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "pwd.txt")
+	cmd := NewCommand("pwd", `pwd > "`+out+`"`, 0)
+	if err := cmd.Run(context.Background(), Event{Stage: PreClone, SessionID: "s1"}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := strings.TrimSpace(string(data))
+	if cwd, _ := os.Getwd(); dir == cwd {
+		t.Errorf("pre_clone hook ran in the server's working directory %s", cwd)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temporary hook directory %s not removed", dir)
+	}
+}
+
+func TestCommand_RunAs(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("needs root to switch users")
+	}
+	// t.TempDir's parent is private to root; the hook user must reach dir.
+	dir, err := os.MkdirTemp("", "hook-runas-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	id := &runas.Identity{UID: 65534, GID: 65534, HomeDir: dir}
+	cmd := NewCommand("whoami", `id -u > uid.txt`, 0)
+	if err := cmd.Run(context.Background(), Event{Stage: PostRun, WorkDir: dir, RunAs: id}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "uid.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "65534" {
+		t.Errorf("hook ran as uid %s, want 65534", got)
+	}
+}
+
+func TestCommand_RunFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		want    string
+	}{
+		{"exit status with output", "echo lint failed >&2; exit 3", 0, "exit status 3: lint failed"},
+		{"timeout", "sleep 10", 100 * time.Millisecond, "timed out after 100ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewCommand("check", tt.command, tt.timeout).Run(context.Background(), Event{Stage: PrePR, WorkDir: t.TempDir()})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
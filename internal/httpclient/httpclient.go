@@ -0,0 +1,136 @@
+// Package httpclient builds the HTTP clients used for outbound calls. All
+// clients share one pooled transport, get their timeout from per-component
+// configuration and report every request to Prometheus.
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/freema/codeforge/internal/metrics"
+)
+
+// Components, used as the metrics label and to look up the timeout.
+const (
+	Webhook   = "webhook"   // session callbacks
+	Provider  = "provider"  // GitHub/GitLab API calls and key verification
+	Review    = "review"    // PR review comments and diffs (larger payloads)
+	AI        = "ai"        // analyzer / prompt-helper LLM APIs
+	Notify    = "notify"    // Slack, Discord and Teams notifications
+	Policy    = "policy"    // external prompt policy service
+	Sentry    = "sentry"    // Sentry proxy
+	BlobStore = "blobstore" // S3-compatible payload offload
+)
+
+// Config configures the shared connection pool and component timeouts.
+type Config struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	Timeouts            map[string]time.Duration // component → timeout; missing = default
+}
+
+// DefaultTimeouts are the timeouts used for components not configured.
+var DefaultTimeouts = map[string]time.Duration{
+	Webhook:   10 * time.Second,
+	Provider:  15 * time.Second,
+	Review:    30 * time.Second,
+	AI:        15 * time.Second,
+	Notify:    10 * time.Second,
+	Policy:    5 * time.Second,
+	Sentry:    30 * time.Second,
+	BlobStore: 60 * time.Second,
+}
+
+var (
+	mu        sync.RWMutex
+	cfg       Config
+	transport *http.Transport
+)
+
+// Configure sets the pool and timeouts. Call it once at startup, after the
+// proxy and CA settings are installed on http.DefaultTransport — the shared
+// transport is cloned from it.
+func Configure(c Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	cfg = c
+	transport = newTransport(c)
+}
+
+func newTransport(c Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if c.MaxIdleConns > 0 {
+		t.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = c.IdleConnTimeout
+	}
+	return t
+}
+
+// sharedTransport returns the pooled transport, creating it with defaults
+// when Configure was not called (tests, tools).
+func sharedTransport() *http.Transport {
+	mu.RLock()
+	t := transport
+	mu.RUnlock()
+	if t != nil {
+		return t
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if transport == nil {
+		transport = newTransport(cfg)
+	}
+	return transport
+}
+
+// Timeout returns the configured timeout for component.
+func Timeout(component string) time.Duration {
+	mu.RLock()
+	d := cfg.Timeouts[component]
+	mu.RUnlock()
+	if d > 0 {
+		return d
+	}
+	return DefaultTimeouts[component]
+}
+
+// New returns an instrumented client on the shared pool with the component's
+// timeout.
+func New(component string) *http.Client {
+	return NewWithTransport(component, sharedTransport())
+}
+
+// NewWithTransport is New with a dedicated transport, for components that
+// need their own dialing rules (e.g. the webhook SSRF guard).
+func NewWithTransport(component string, rt http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   Timeout(component),
+		Transport: &instrumented{component: component, next: rt},
+	}
+}
+
+// instrumented records outbound request counts and latency per component.
+type instrumented struct {
+	component string
+	next      http.RoundTripper
+}
+
+func (i *instrumented) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := i.next.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.OutboundRequests.WithLabelValues(i.component, code).Inc()
+	metrics.OutboundDuration.WithLabelValues(i.component).Observe(time.Since(start).Seconds())
+	return resp, err
+}
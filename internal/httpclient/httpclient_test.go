@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTimeout(t *testing.T) {
+	Configure(Config{Timeouts: map[string]time.Duration{Webhook: 3 * time.Second}})
+	t.Cleanup(func() { Configure(Config{}) })
+
+	tests := []struct {
+		component string
+		want      time.Duration
+	}{
+		{Webhook, 3 * time.Second},
+		{Provider, 15 * time.Second},
+		{"unknown", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.component, func(t *testing.T) {
+			if got := Timeout(tt.component); got != tt.want {
+				t.Errorf("Timeout(%q) = %v, want %v", tt.component, got, tt.want)
+			}
+			if got := New(tt.component).Timeout; got != tt.want {
+				t.Errorf("New(%q).Timeout = %v, want %v", tt.component, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigure_Pool(t *testing.T) {
+	Configure(Config{MaxIdleConns: 7, MaxIdleConnsPerHost: 3, IdleConnTimeout: time.Second})
+	t.Cleanup(func() { Configure(Config{}) })
+
+	tr := sharedTransport()
+	if tr.MaxIdleConns != 7 || tr.MaxIdleConnsPerHost != 3 || tr.IdleConnTimeout != time.Second {
+		t.Errorf("pool = %d/%d/%v", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+	if New(Notify).Transport.(*instrumented).next != New(AI).Transport.(*instrumented).next {
+		t.Error("components should share one transport")
+	}
+}
+
+func TestInstrumented(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	before := outboundCount(t, "418")
+	resp, err := NewWithTransport("test", srv.Client().Transport).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := outboundCount(t, "418") - before; got != 1 {
+		t.Errorf("requests counted = %v, want 1", got)
+	}
+
+	srv.Close()
+	beforeErr := outboundCount(t, "error")
+	if _, err := NewWithTransport("test", http.DefaultTransport).Get(srv.URL); err == nil {
+		t.Fatal("expected a transport error")
+	}
+	if got := outboundCount(t, "error") - beforeErr; got != 1 {
+		t.Errorf("errors counted = %v, want 1", got)
+	}
+}
+
+// outboundCount reads codeforge_outbound_requests_total{component="test",code=code}.
+func outboundCount(t *testing.T, code string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "codeforge_outbound_requests_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["component"] == "test" && labels["code"] == code {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -8,29 +8,34 @@ import (
 	"time"
 
 	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 )
 
 // envKeyMapping maps an environment variable to a provider key.
 type envKeyMapping struct {
-	EnvVar    string
-	URLEnvVar string // optional: env var for custom base URL
-	Provider  string
-	Name      string
+	EnvVar       string
+	URLEnvVar    string // optional: env var for custom base URL
+	APIURLEnvVar string // optional: env var for an explicit API base URL
+	Provider     string
+	Name         string
 }
 
 // knownEnvKeys lists all recognized environment variable → provider mappings.
 var knownEnvKeys = []envKeyMapping{
-	{"GITHUB_TOKEN", "GITHUB_URL", "github", "github-env"},
-	{"GITLAB_TOKEN", "GITLAB_URL", "gitlab", "gitlab-env"},
-	{"SENTRY_AUTH_TOKEN", "SENTRY_URL", "sentry", "sentry-env"},
-	{"ANTHROPIC_API_KEY", "", "anthropic", "anthropic-env"},
-	{"OPENAI_API_KEY", "", "openai", "openai-env"},
+	{"GITHUB_TOKEN", "GITHUB_URL", "GITHUB_API_URL", "github", "github-env"},
+	{"GITLAB_TOKEN", "GITLAB_URL", "GITLAB_API_URL", "gitlab", "gitlab-env"},
+	{"AZURE_DEVOPS_TOKEN", "AZURE_DEVOPS_URL", "", "azure_devops", "azure-devops-env"},
+	{"GITEA_TOKEN", "GITEA_URL", "GITEA_API_URL", "gitea", "gitea-env"},
+	{"SENTRY_AUTH_TOKEN", "SENTRY_URL", "", "sentry", "sentry-env"},
+	{"ANTHROPIC_API_KEY", "", "", "anthropic", "anthropic-env"},
+	{"OPENAI_API_KEY", "", "", "openai", "openai-env"},
 }
 
 // EnvAwareRegistry wraps a Registry and surfaces environment-variable-sourced
 // keys alongside database keys. Env keys are read-only (cannot be deleted).
 type EnvAwareRegistry struct {
-	inner Registry
+	inner       Registry
+	providerAPI gitpkg.APIConfig
 }
 
 // NewEnvAwareRegistry wraps the given registry to also expose env-var keys.
@@ -38,6 +43,12 @@ func NewEnvAwareRegistry(inner Registry) *EnvAwareRegistry {
 	return &EnvAwareRegistry{inner: inner}
 }
 
+// SetProviderAPI sets the per-host API base URLs used when verifying env
+// keys that have no API URL variable set.
+func (r *EnvAwareRegistry) SetProviderAPI(cfg gitpkg.APIConfig) {
+	r.providerAPI = cfg
+}
+
 func (r *EnvAwareRegistry) Create(ctx context.Context, key Key) error {
 	return r.inner.Create(ctx, key)
 }
@@ -66,6 +77,9 @@ func (r *EnvAwareRegistry) List(ctx context.Context) ([]Key, error) {
 			if m.URLEnvVar != "" {
 				k.BaseURL = os.Getenv(m.URLEnvVar)
 			}
+			if m.APIURLEnvVar != "" {
+				k.APIBaseURL = os.Getenv(m.APIURLEnvVar)
+			}
 			dbKeys = append(dbKeys, k)
 		}
 	}
@@ -124,13 +138,29 @@ func (r *EnvAwareRegistry) Verify(ctx context.Context, name string) (*VerifyResu
 			if m.URLEnvVar != "" {
 				baseURL = os.Getenv(m.URLEnvVar)
 			}
-			result := verifyToken(ctx, m.Provider, token, baseURL)
+			apiBaseURL := ""
+			if m.APIURLEnvVar != "" {
+				apiBaseURL = os.Getenv(m.APIURLEnvVar)
+			}
+			result := verifyToken(ctx, m.Provider, token, baseURL, r.providerAPI.BaseURL(baseURL, apiBaseURL))
 			return result, m.Provider, nil
 		}
 	}
 	return r.inner.Verify(ctx, name)
 }
 
+func (r *EnvAwareRegistry) APIBaseURL(ctx context.Context, name string) string {
+	for _, m := range knownEnvKeys {
+		if m.Name == name && os.Getenv(m.EnvVar) != "" {
+			if m.APIURLEnvVar == "" {
+				return ""
+			}
+			return os.Getenv(m.APIURLEnvVar)
+		}
+	}
+	return r.inner.APIBaseURL(ctx, name)
+}
+
 func (r *EnvAwareRegistry) isEnvKey(name string) bool {
 	for _, m := range knownEnvKeys {
 		if m.Name == name {
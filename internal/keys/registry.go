@@ -8,17 +8,23 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 )
 
 // Key represents a stored access token.
 type Key struct {
-	Name      string    `json:"name"`
-	Provider  string    `json:"provider"`
-	Token     string    `json:"token,omitempty"` // only in create request, never in responses
-	Scope     string    `json:"scope,omitempty"`
-	BaseURL   string    `json:"base_url,omitempty"`
-	Source    string    `json:"source,omitempty"` // "db" or "env"
-	CreatedAt time.Time `json:"created_at"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	Token    string `json:"token,omitempty"` // only in create request, never in responses
+	Scope    string `json:"scope,omitempty"`
+	BaseURL  string `json:"base_url,omitempty"`
+	// APIBaseURL overrides the API location derived from BaseURL (GitHub
+	// Enterprise / GitLab behind a proxy), see gitpkg.APIBase.
+	APIBaseURL string    `json:"api_base_url,omitempty"`
+	Source     string    `json:"source,omitempty"` // "db" or "env"
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // VerifyResult contains the result of a provider token verification.
@@ -42,14 +48,21 @@ type Registry interface {
 	ResolveByName(ctx context.Context, name string) (token, provider string, err error)
 	// ResolveFullByName looks up a key by name and returns token, provider, and base URL.
 	ResolveFullByName(ctx context.Context, name string) (token, provider, baseURL string, err error)
+	// APIBaseURL returns the explicit API base URL of a key, "" when the key
+	// sets none or does not exist.
+	APIBaseURL(ctx context.Context, name string) string
 }
 
-func verifyToken(ctx context.Context, provider, token, baseURL string) *VerifyResult {
+func verifyToken(ctx context.Context, provider, token, baseURL, apiBaseURL string) *VerifyResult {
 	switch provider {
 	case "github":
-		return verifyGitHub(ctx, token, baseURL)
+		return verifyGitHub(ctx, token, gitpkg.APIBase(gitpkg.ProviderGitHub, baseURL, apiBaseURL))
 	case "gitlab":
-		return verifyGitLab(ctx, token, baseURL)
+		return verifyGitLab(ctx, token, gitpkg.APIBase(gitpkg.ProviderGitLab, baseURL, apiBaseURL))
+	case "azure_devops":
+		return verifyAzureDevOps(ctx, token, baseURL)
+	case "gitea":
+		return verifyGitea(ctx, token, gitpkg.APIBase(gitpkg.ProviderGitea, baseURL, apiBaseURL))
 	case "sentry":
 		return verifySentry(ctx, token, baseURL)
 	case "anthropic":
@@ -61,19 +74,15 @@ func verifyToken(ctx context.Context, provider, token, baseURL string) *VerifyRe
 	}
 }
 
-func verifyGitHub(ctx context.Context, token, baseURL string) *VerifyResult {
-	apiURL := "https://api.github.com/user"
-	if baseURL != "" {
-		apiURL = strings.TrimRight(baseURL, "/") + "/api/v3/user"
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+func verifyGitHub(ctx context.Context, token, apiBase string) *VerifyResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/user", nil)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: "failed to create request"}
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.New(httpclient.Provider).Do(req)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: "connection failed"}
 	}
@@ -105,6 +114,44 @@ func verifyGitHub(ctx context.Context, token, baseURL string) *VerifyResult {
 	}
 }
 
+func verifyGitea(ctx context.Context, token, apiBase string) *VerifyResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/user", nil)
+	if err != nil {
+		return &VerifyResult{Valid: false, Error: "failed to create request"}
+	}
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := httpclient.New(httpclient.Provider).Do(req)
+	if err != nil {
+		return &VerifyResult{Valid: false, Error: "connection failed"}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return &VerifyResult{Valid: false, Error: "invalid or expired token"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &VerifyResult{Valid: false, Error: fmt.Sprintf("unexpected status %d", resp.StatusCode)}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return &VerifyResult{Valid: true}
+	}
+
+	var user struct {
+		Login string `json:"login"`
+		Email string `json:"email"`
+	}
+	_ = json.Unmarshal(body, &user)
+
+	return &VerifyResult{
+		Valid:    true,
+		Username: user.Login,
+		Email:    user.Email,
+	}
+}
+
 func verifySentry(ctx context.Context, token, baseURL string) *VerifyResult {
 	type sentryOrg struct {
 		Slug string `json:"slug"`
@@ -133,7 +180,7 @@ func verifySentry(ctx context.Context, token, baseURL string) *VerifyResult {
 		}
 		req.Header.Set("Authorization", "Bearer "+token)
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := httpclient.New(httpclient.Provider).Do(req)
 		if err != nil {
 			continue
 		}
@@ -186,7 +233,7 @@ func verifyAnthropic(ctx context.Context, token, _ string) *VerifyResult {
 	req.Header.Set("x-api-key", token)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.New(httpclient.Provider).Do(req)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: "connection failed"}
 	}
@@ -212,7 +259,7 @@ func verifyOpenAI(ctx context.Context, token, _ string) *VerifyResult {
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.New(httpclient.Provider).Do(req)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: "connection failed"}
 	}
@@ -231,18 +278,68 @@ func verifyOpenAI(ctx context.Context, token, _ string) *VerifyResult {
 	}
 }
 
-func verifyGitLab(ctx context.Context, token, baseURL string) *VerifyResult {
-	apiURL := "https://gitlab.com/api/v4/user"
+// verifyAzureDevOps checks a personal access token against the user profile
+// service, or the connection data of baseURL (an organization or Azure
+// DevOps Server collection) when set.
+func verifyAzureDevOps(ctx context.Context, token, baseURL string) *VerifyResult {
+	endpoint := "https://app.vssps.visualstudio.com/_apis/profile/profiles/me?api-version=7.1"
 	if baseURL != "" {
-		apiURL = strings.TrimRight(baseURL, "/") + "/api/v4/user"
+		endpoint = strings.TrimRight(baseURL, "/") + "/_apis/connectionData"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return &VerifyResult{Valid: false, Error: "failed to create request"}
+	}
+	req.SetBasicAuth("", token)
+
+	resp, err := httpclient.New(httpclient.Provider).Do(req)
+	if err != nil {
+		return &VerifyResult{Valid: false, Error: "connection failed"}
+	}
+	defer resp.Body.Close()
+
+	// Rejected tokens are redirected to the sign-in page (203/302).
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusNonAuthoritativeInfo || resp.StatusCode == http.StatusFound {
+		return &VerifyResult{Valid: false, Error: "invalid or expired token"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &VerifyResult{Valid: false, Error: fmt.Sprintf("unexpected status %d", resp.StatusCode)}
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return &VerifyResult{Valid: true}
+	}
+
+	var user struct {
+		DisplayName       string `json:"displayName"`
+		EmailAddress      string `json:"emailAddress"`
+		AuthenticatedUser struct {
+			ProviderDisplayName string `json:"providerDisplayName"`
+		} `json:"authenticatedUser"`
+	}
+	_ = json.Unmarshal(body, &user)
+
+	name := user.DisplayName
+	if name == "" {
+		name = user.AuthenticatedUser.ProviderDisplayName
+	}
+	return &VerifyResult{
+		Valid:    true,
+		Username: name,
+		Email:    user.EmailAddress,
+	}
+}
+
+func verifyGitLab(ctx context.Context, token, apiBase string) *VerifyResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/api/v4/user", nil)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: "failed to create request"}
 	}
 	req.Header.Set("PRIVATE-TOKEN", token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.New(httpclient.Provider).Do(req)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: "connection failed"}
 	}
@@ -11,7 +11,7 @@ import (
 // Resolver resolves access tokens using a priority chain:
 // 1. Inline token on session (access_token field)
 // 2. Registered key by provider_key name
-// 3. Environment variable fallback (GITHUB_TOKEN / GITLAB_TOKEN)
+// 3. Environment variable fallback (GITHUB_TOKEN / GITLAB_TOKEN / AZURE_DEVOPS_TOKEN / GITEA_TOKEN)
 type Resolver struct {
 	registry        Registry
 	providerDomains map[string]string
@@ -54,6 +54,14 @@ func (r *Resolver) ResolveToken(ctx context.Context, repoURL, accessToken, provi
 		if t := os.Getenv("GITLAB_TOKEN"); t != "" {
 			return t, nil
 		}
+	case gitpkg.ProviderAzureDevOps:
+		if t := os.Getenv("AZURE_DEVOPS_TOKEN"); t != "" {
+			return t, nil
+		}
+	case gitpkg.ProviderGitea:
+		if t := os.Getenv("GITEA_TOKEN"); t != "" {
+			return t, nil
+		}
 	case gitpkg.ProviderUnknown:
 		// Self-hosted instances with unrecognized domains: try both env vars.
 		// GITLAB_TOKEN first — self-hosted GitLab is far more common than GitHub Enterprise.
@@ -69,6 +77,15 @@ func (r *Resolver) ResolveToken(ctx context.Context, repoURL, accessToken, provi
 		repoURL, envHint(repo.Provider))
 }
 
+// APIBaseURL returns the explicit API base URL of the named provider key,
+// "" when unset.
+func (r *Resolver) APIBaseURL(ctx context.Context, providerKey string) string {
+	if providerKey == "" {
+		return ""
+	}
+	return r.registry.APIBaseURL(ctx, providerKey)
+}
+
 // ResolveAIKey tries to resolve an AI provider API key from the registry.
 // It looks up keys by the well-known env-sourced name ("<provider>-env") first,
 // then falls back to any key matching the given provider.
@@ -95,6 +112,10 @@ func envHint(p gitpkg.Provider) string {
 		return "GITHUB_TOKEN"
 	case gitpkg.ProviderGitLab:
 		return "GITLAB_TOKEN"
+	case gitpkg.ProviderAzureDevOps:
+		return "AZURE_DEVOPS_TOKEN"
+	case gitpkg.ProviderGitea:
+		return "GITEA_TOKEN"
 	default:
 		return "GITLAB_TOKEN or GITHUB_TOKEN"
 	}
@@ -23,6 +23,7 @@ func (s *stubRegistry) ResolveByName(_ context.Context, _ string) (string, strin
 func (s *stubRegistry) ResolveFullByName(_ context.Context, _ string) (string, string, string, error) {
 	return "", "", "", fmt.Errorf("not found")
 }
+func (s *stubRegistry) APIBaseURL(_ context.Context, _ string) string { return "" }
 
 func (s *stubRegistry) Resolve(_ context.Context, provider, name string) (string, error) {
 	key := provider + ":" + name
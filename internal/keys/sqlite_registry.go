@@ -9,12 +9,14 @@ import (
 
 	"github.com/freema/codeforge/internal/apperror"
 	"github.com/freema/codeforge/internal/crypto"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 )
 
 // SQLiteRegistry implements Registry backed by SQLite.
 type SQLiteRegistry struct {
-	db     *sql.DB
-	crypto *crypto.Service
+	db          *sql.DB
+	crypto      *crypto.Service
+	providerAPI gitpkg.APIConfig
 }
 
 // NewSQLiteRegistry creates a new SQLite-backed key registry.
@@ -22,12 +24,18 @@ func NewSQLiteRegistry(db *sql.DB, cryptoSvc *crypto.Service) *SQLiteRegistry {
 	return &SQLiteRegistry{db: db, crypto: cryptoSvc}
 }
 
+// SetProviderAPI sets the per-host API base URLs used when verifying keys
+// that do not set api_base_url themselves.
+func (r *SQLiteRegistry) SetProviderAPI(cfg gitpkg.APIConfig) {
+	r.providerAPI = cfg
+}
+
 func (r *SQLiteRegistry) Create(ctx context.Context, key Key) error {
 	switch key.Provider {
-	case "github", "gitlab", "sentry", "anthropic", "openai":
+	case "github", "gitlab", "azure_devops", "gitea", "sentry", "anthropic", "openai":
 		// valid
 	default:
-		return apperror.Validation("provider must be 'github', 'gitlab', 'sentry', 'anthropic', or 'openai'")
+		return apperror.Validation("provider must be 'github', 'gitlab', 'azure_devops', 'gitea', 'sentry', 'anthropic', or 'openai'")
 	}
 
 	encrypted, err := r.crypto.Encrypt(key.Token)
@@ -36,8 +44,8 @@ func (r *SQLiteRegistry) Create(ctx context.Context, key Key) error {
 	}
 
 	_, err = r.db.ExecContext(ctx,
-		"INSERT INTO keys (name, provider, encrypted_token, scope, base_url) VALUES (?, ?, ?, ?, ?)",
-		key.Name, key.Provider, encrypted, key.Scope, key.BaseURL,
+		"INSERT INTO keys (name, provider, encrypted_token, scope, base_url, api_base_url) VALUES (?, ?, ?, ?, ?, ?)",
+		key.Name, key.Provider, encrypted, key.Scope, key.BaseURL, key.APIBaseURL,
 	)
 	if err != nil {
 		// SQLite UNIQUE constraint violation
@@ -52,7 +60,7 @@ func (r *SQLiteRegistry) Create(ctx context.Context, key Key) error {
 
 func (r *SQLiteRegistry) List(ctx context.Context) ([]Key, error) {
 	rows, err := r.db.QueryContext(ctx,
-		"SELECT name, provider, scope, base_url, created_at FROM keys ORDER BY created_at",
+		"SELECT name, provider, scope, base_url, api_base_url, created_at FROM keys ORDER BY created_at",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("listing keys: %w", err)
@@ -63,7 +71,7 @@ func (r *SQLiteRegistry) List(ctx context.Context) ([]Key, error) {
 	for rows.Next() {
 		var k Key
 		var createdAt string
-		if err := rows.Scan(&k.Name, &k.Provider, &k.Scope, &k.BaseURL, &createdAt); err != nil {
+		if err := rows.Scan(&k.Name, &k.Provider, &k.Scope, &k.BaseURL, &k.APIBaseURL, &createdAt); err != nil {
 			return nil, fmt.Errorf("scanning key: %w", err)
 		}
 		k.CreatedAt, _ = time.Parse("2006-01-02T15:04:05.000", createdAt)
@@ -107,11 +115,11 @@ func (r *SQLiteRegistry) Resolve(ctx context.Context, provider, name string) (st
 }
 
 func (r *SQLiteRegistry) Verify(ctx context.Context, name string) (*VerifyResult, string, error) {
-	var provider, encrypted, baseURL string
+	var provider, encrypted, baseURL, apiBaseURL string
 	err := r.db.QueryRowContext(ctx,
-		"SELECT provider, encrypted_token, base_url FROM keys WHERE name = ?",
+		"SELECT provider, encrypted_token, base_url, api_base_url FROM keys WHERE name = ?",
 		name,
-	).Scan(&provider, &encrypted, &baseURL)
+	).Scan(&provider, &encrypted, &baseURL, &apiBaseURL)
 	if err == sql.ErrNoRows {
 		return nil, "", apperror.NotFound("key '%s' not found", name)
 	}
@@ -124,10 +132,16 @@ func (r *SQLiteRegistry) Verify(ctx context.Context, name string) (*VerifyResult
 		return nil, "", fmt.Errorf("decrypting token: %w", err)
 	}
 
-	result := verifyToken(ctx, provider, token, baseURL)
+	result := verifyToken(ctx, provider, token, baseURL, r.providerAPI.BaseURL(baseURL, apiBaseURL))
 	return result, provider, nil
 }
 
+func (r *SQLiteRegistry) APIBaseURL(ctx context.Context, name string) string {
+	var apiBaseURL string
+	_ = r.db.QueryRowContext(ctx, "SELECT api_base_url FROM keys WHERE name = ?", name).Scan(&apiBaseURL)
+	return apiBaseURL
+}
+
 func (r *SQLiteRegistry) ResolveByName(ctx context.Context, name string) (string, string, error) {
 	token, provider, _, err := r.ResolveFullByName(ctx, name)
 	return token, provider, err
@@ -30,6 +30,7 @@ func setupTestDB(t *testing.T) (*sql.DB, *crypto.Service) {
 			encrypted_token TEXT NOT NULL,
 			scope           TEXT NOT NULL DEFAULT '',
 			base_url        TEXT NOT NULL DEFAULT '',
+			api_base_url    TEXT NOT NULL DEFAULT '',
 			created_at      TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now')),
 			UNIQUE(provider, name)
 		)
@@ -276,3 +277,24 @@ func TestSQLiteRegistry_EncryptionRoundtrip(t *testing.T) {
 		t.Errorf("decrypted token: got %q, want %q", token, originalToken)
 	}
 }
+
+func TestSQLiteRegistry_APIBaseURL(t *testing.T) {
+	db, cryptoSvc := setupTestDB(t)
+	reg := NewSQLiteRegistry(db, cryptoSvc)
+	ctx := context.Background()
+
+	if err := reg.Create(ctx, Key{Name: "ghe", Provider: "github", Token: "tok", BaseURL: "https://ghe.example.com", APIBaseURL: "https://ghe-api.example.com/api/v3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reg.APIBaseURL(ctx, "ghe"); got != "https://ghe-api.example.com/api/v3" {
+		t.Errorf("APIBaseURL = %q", got)
+	}
+	if got := reg.APIBaseURL(ctx, "missing"); got != "" {
+		t.Errorf("APIBaseURL of unknown key = %q, want empty", got)
+	}
+	keys, _ := reg.List(ctx)
+	if len(keys) != 1 || keys[0].APIBaseURL != "https://ghe-api.example.com/api/v3" {
+		t.Errorf("List = %+v", keys)
+	}
+}
@@ -25,6 +25,17 @@ var (
 		[]string{"status"},
 	)
 
+	// PhaseDuration tracks how long sessions spend in each phase (queue_wait,
+	// clone, run, diff, pr), to tell slow queues, clones and models apart.
+	PhaseDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "codeforge_session_phase_duration_seconds",
+			Help:    "Time sessions spend per phase in seconds",
+			Buckets: []float64{0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800},
+		},
+		[]string{"phase"},
+	)
+
 	// TasksInProgress tracks the number of currently executing tasks.
 	TasksInProgress = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -85,6 +96,24 @@ var (
 		[]string{"method", "path"},
 	)
 
+	// TokensTotal counts AI tokens consumed per run.
+	TokensTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "codeforge_tokens_total",
+			Help: "Total number of AI tokens consumed",
+		},
+		[]string{"direction", "model", "cli"},
+	)
+
+	// CostUSDTotal accumulates the spend reported by the CLIs.
+	CostUSDTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "codeforge_cost_usd_total",
+			Help: "Total AI spend in USD as reported by the CLIs",
+		},
+		[]string{"model", "cli"},
+	)
+
 	// ReviewParseFailures counts review output parse failures.
 	ReviewParseFailures = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -92,4 +121,42 @@ var (
 			Help: "Total number of review output parse failures",
 		},
 	)
+
+	// OutboundRequests counts outbound HTTP requests by component and status
+	// code ("error" for transport failures).
+	OutboundRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "codeforge_outbound_requests_total",
+			Help: "Total number of outbound HTTP requests",
+		},
+		[]string{"component", "code"},
+	)
+
+	// OutboundDuration tracks outbound HTTP request latency in seconds.
+	OutboundDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "codeforge_outbound_request_duration_seconds",
+			Help:    "Outbound HTTP request duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"component"},
+	)
+
+	// HookRuns counts executor hook runs per stage and result (ok, failed).
+	HookRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "codeforge_hook_runs_total",
+			Help: "Total number of executor hook runs",
+		},
+		[]string{"stage", "result"},
+	)
+
+	// ChaosFaults counts faults injected by the chaos injector.
+	ChaosFaults = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "codeforge_chaos_faults_total",
+			Help: "Total number of injected faults",
+		},
+		[]string{"fault"},
+	)
 )
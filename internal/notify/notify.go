@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/freema/codeforge/internal/config"
+	"github.com/freema/codeforge/internal/httpclient"
 )
 
 // Event types emitted by the executor.
@@ -69,7 +70,7 @@ func New(cfg config.NotificationsConfig) *Notifier {
 		teamsURL:   cfg.TeamsWebhookURL,
 		uiBaseURL:  strings.TrimRight(cfg.UIBaseURL, "/"),
 		events:     events,
-		client:     &http.Client{Timeout: 10 * time.Second},
+		client:     httpclient.New(httpclient.Notify),
 	}
 }
 
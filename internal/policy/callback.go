@@ -0,0 +1,168 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// CallbackPolicy guards outbound callbacks against SSRF: callback_url is
+// user-controlled, so by default it may not reach loopback, private (RFC 1918,
+// fc00::/7), link-local (incl. cloud metadata at 169.254.169.254),
+// unspecified or multicast addresses.
+//
+// Check resolves the host when a URL is accepted; Transport re-checks the
+// address every connection actually dials, so DNS rebinding and redirects
+// cannot reach a blocked network later.
+//
+// Precedence: allowed hosts skip the check, deny CIDRs win over allow CIDRs,
+// allow CIDRs exempt networks from the default block.
+type CallbackPolicy struct {
+	allowPrivate bool
+	allowHosts   map[string]bool
+	allow        []*net.IPNet
+	deny         []*net.IPNet
+	resolver     *net.Resolver
+}
+
+// NewCallbackPolicy compiles the callback guard. Entries may be
+// comma-separated (single env var value).
+func NewCallbackPolicy(allowPrivate bool, allowHosts, allowCIDRs, denyCIDRs []string) (*CallbackPolicy, error) {
+	p := &CallbackPolicy{
+		allowPrivate: allowPrivate,
+		allowHosts:   map[string]bool{},
+		resolver:     net.DefaultResolver,
+	}
+	for _, h := range splitList(allowHosts) {
+		p.allowHosts[strings.ToLower(h)] = true
+	}
+	var err error
+	if p.allow, err = parseCIDRs(allowCIDRs); err != nil {
+		return nil, err
+	}
+	if p.deny, err = parseCIDRs(denyCIDRs); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, raw := range splitList(values) {
+		if !strings.Contains(raw, "/") {
+			if ip := net.ParseIP(raw); ip != nil {
+				bits := 32
+				if ip.To4() == nil {
+					bits = 128
+				}
+				raw = fmt.Sprintf("%s/%d", raw, bits)
+			}
+		}
+		_, n, err := net.ParseCIDR(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid callback CIDR %q: %w", raw, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// Check returns a 400 error when rawURL is not an http(s) URL or its host
+// resolves to a blocked address. A nil policy allows everything.
+func (p *CallbackPolicy) Check(ctx context.Context, rawURL string) error {
+	if p == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return apperror.Validation("callback URL must be an http or https URL")
+	}
+	host := u.Hostname()
+	if p.allowHosts[strings.ToLower(host)] {
+		return nil
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return apperror.Validation("callback host %s cannot be resolved", host)
+		}
+		ips = ips[:0]
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	for _, ip := range ips {
+		if p.blocked(ip) {
+			return apperror.Validation("callback host %s resolves to blocked address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+func (p *CallbackPolicy) blocked(ip net.IP) bool {
+	for _, n := range p.deny {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	for _, n := range p.allow {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	if p.allowPrivate {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// Transport returns an HTTP transport that refuses connections to blocked
+// addresses at dial time. TLS settings are taken from http.DefaultTransport,
+// so call it after those are installed. Proxies are never used: through a
+// proxy the dialer would only see the proxy's address, not the callback's.
+func (p *CallbackPolicy) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	guarded := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: p.control}
+	plain := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(addr); err == nil && p.allowHosts[strings.ToLower(host)] {
+			return plain.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+	return t
+}
+
+// control runs after DNS resolution with the concrete address being dialed.
+func (p *CallbackPolicy) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || p.blocked(ip) {
+		return fmt.Errorf("callback connection to blocked address %s refused", host)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestCallbackPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		allowHosts   []string
+		allowCIDRs   []string
+		denyCIDRs    []string
+		url          string
+		wantErr      bool
+	}{
+		{"public address", false, nil, nil, nil, "https://93.184.216.34/hook", false},
+		{"loopback", false, nil, nil, nil, "http://127.0.0.1:8080/hook", true},
+		{"localhost name", false, nil, nil, nil, "http://localhost/hook", true},
+		{"rfc1918", false, nil, nil, nil, "http://10.1.2.3/hook", true},
+		{"metadata endpoint", false, nil, nil, nil, "http://169.254.169.254/latest/meta-data", true},
+		{"ipv6 loopback", false, nil, nil, nil, "http://[::1]/hook", true},
+		{"ipv6 unique local", false, nil, nil, nil, "http://[fd00::1]/hook", true},
+		{"ipv4-mapped loopback", false, nil, nil, nil, "http://[::ffff:127.0.0.1]/hook", true},
+		{"unspecified", false, nil, nil, nil, "http://0.0.0.0/hook", true},
+		{"not http", false, nil, nil, nil, "ftp://93.184.216.34/x", true},
+		{"private allowed", true, nil, nil, nil, "http://10.1.2.3/hook", false},
+		{"allowed cidr", false, nil, []string{"10.1.0.0/16"}, nil, "http://10.1.2.3/hook", false},
+		{"allowed cidr does not cover", false, nil, []string{"10.1.0.0/16"}, nil, "http://10.2.0.1/hook", true},
+		{"allowed host", false, []string{"Receiver.Internal"}, nil, nil, "http://receiver.internal/hook", false},
+		{"deny wins over allow", false, nil, []string{"10.0.0.0/8"}, []string{"10.9.0.0/16"}, "http://10.9.0.1/hook", true},
+		{"deny public address", false, nil, nil, []string{"93.184.216.34"}, "https://93.184.216.34/hook", true},
+		{"deny with private allowed", true, nil, nil, []string{"169.254.0.0/16"}, "http://169.254.169.254/", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewCallbackPolicy(tt.allowPrivate, tt.allowHosts, tt.allowCIDRs, tt.denyCIDRs)
+			if err != nil {
+				t.Fatalf("NewCallbackPolicy: %v", err)
+			}
+			err = p.Check(context.Background(), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			var appErr *apperror.AppError
+			if err != nil && (!errors.As(err, &appErr) || appErr.Status != 400) {
+				t.Errorf("expected a 400 AppError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCallbackPolicy_NilAllowsEverything(t *testing.T) {
+	var p *CallbackPolicy
+	if err := p.Check(context.Background(), "http://127.0.0.1/"); err != nil {
+		t.Errorf("nil policy: %v", err)
+	}
+}
+
+func TestNewCallbackPolicy_InvalidCIDR(t *testing.T) {
+	if _, err := NewCallbackPolicy(false, nil, []string{"10.0.0.0/99"}, nil); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
+
+func TestCallbackPolicy_Transport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		allowHosts []string
+		wantErr    bool
+	}{
+		// The test server listens on loopback: the dialer must refuse it
+		// even though no Check ran before the request.
+		{"blocked at dial time", nil, true},
+		{"allowed host", []string{"127.0.0.1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewCallbackPolicy(false, tt.allowHosts, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: p.Transport()}
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallbackPolicy_TransportIgnoresProxy(t *testing.T) {
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("HTTPS_PROXY", proxy.URL)
+
+	// The proxy itself is allowed; the callback target is not.
+	p, err := NewCallbackPolicy(false, []string{"127.0.0.1"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := p.Transport()
+	if tr.Proxy != nil {
+		t.Fatal("transport must not use a proxy")
+	}
+	for _, target := range []string{"http://10.0.0.1:9/hook", "https://10.0.0.1:9/hook"} {
+		resp, err := (&http.Client{Transport: tr}).Get(target)
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: expected the blocked target to be refused", target)
+		}
+	}
+	if proxied {
+		t.Error("request went through the proxy")
+	}
+}
@@ -0,0 +1,218 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
+)
+
+// PromptAction is the verdict of a prompt check.
+type PromptAction string
+
+const (
+	PromptAllow  PromptAction = "allow"
+	PromptFlag   PromptAction = "flag"   // accepted, but recorded for review
+	PromptReject PromptAction = "reject" // refused before any work is queued
+)
+
+// severity orders actions so the strictest verdict wins in a chain.
+func (a PromptAction) severity() int {
+	switch a {
+	case PromptReject:
+		return 2
+	case PromptFlag:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// PromptRequest is what a prompt check sees.
+type PromptRequest struct {
+	Kind      string `json:"kind"` // "create" or "instruct"
+	SessionID string `json:"session_id,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	RepoURL   string `json:"repo_url,omitempty"`
+	Prompt    string `json:"prompt"`
+}
+
+// PromptDecision is the outcome of a prompt check.
+type PromptDecision struct {
+	Action PromptAction
+	Reason string
+	Source string // "regex" or "http"
+	Rule   string // matching pattern, when known
+}
+
+// PromptChecker validates prompts on session create and instruct.
+type PromptChecker interface {
+	CheckPrompt(ctx context.Context, req PromptRequest) (PromptDecision, error)
+}
+
+// PromptChain runs checkers in order and returns the strictest decision.
+// A reject short-circuits the remaining checkers.
+type PromptChain []PromptChecker
+
+// CheckPrompt implements PromptChecker.
+func (c PromptChain) CheckPrompt(ctx context.Context, req PromptRequest) (PromptDecision, error) {
+	result := PromptDecision{Action: PromptAllow}
+	for _, checker := range c {
+		d, err := checker.CheckPrompt(ctx, req)
+		if err != nil {
+			return PromptDecision{}, err
+		}
+		if d.Action.severity() > result.Action.severity() {
+			result = d
+		}
+		if result.Action == PromptReject {
+			break
+		}
+	}
+	return result, nil
+}
+
+// RegexPromptPolicy is the built-in denylist. Patterns are case-insensitive
+// regular expressions, e.g. `exfiltrat`, `delete\s+all`.
+type RegexPromptPolicy struct {
+	reject []*regexp.Regexp
+	flag   []*regexp.Regexp
+}
+
+// NewRegexPromptPolicy compiles reject and flag patterns.
+func NewRegexPromptPolicy(reject, flag []string) (*RegexPromptPolicy, error) {
+	p := &RegexPromptPolicy{}
+	var err error
+	if p.reject, err = compilePromptPatterns(reject); err != nil {
+		return nil, err
+	}
+	if p.flag, err = compilePromptPatterns(flag); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Empty reports whether the policy has no patterns.
+func (p *RegexPromptPolicy) Empty() bool {
+	return len(p.reject) == 0 && len(p.flag) == 0
+}
+
+// CheckPrompt implements PromptChecker.
+func (p *RegexPromptPolicy) CheckPrompt(_ context.Context, req PromptRequest) (PromptDecision, error) {
+	for _, re := range p.reject {
+		if re.MatchString(req.Prompt) {
+			return PromptDecision{Action: PromptReject, Reason: "prompt matches a denied pattern", Source: "regex", Rule: re.String()}, nil
+		}
+	}
+	for _, re := range p.flag {
+		if re.MatchString(req.Prompt) {
+			return PromptDecision{Action: PromptFlag, Reason: "prompt matches a flagged pattern", Source: "regex", Rule: re.String()}, nil
+		}
+	}
+	return PromptDecision{Action: PromptAllow, Source: "regex"}, nil
+}
+
+func compilePromptPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, raw := range patterns {
+		if raw == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt pattern %q: %w", raw, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// HTTPPromptPolicy delegates the decision to an external policy service.
+//
+// The service receives the PromptRequest as JSON and answers with
+//
+//	{"action": "allow" | "flag" | "reject", "reason": "..."}
+//
+// When the service is unreachable or answers with a non-2xx status the prompt
+// is rejected, unless failOpen is set, in which case it is flagged.
+type HTTPPromptPolicy struct {
+	url      string
+	client   *http.Client
+	failOpen bool
+}
+
+// NewHTTPPromptPolicy creates an external policy checker. timeout <= 0 uses
+// the policy component timeout of the shared HTTP clients.
+func NewHTTPPromptPolicy(url string, timeout time.Duration, failOpen bool) *HTTPPromptPolicy {
+	return &HTTPPromptPolicy{
+		url:      url,
+		client:   promptPolicyClient(timeout),
+		failOpen: failOpen,
+	}
+}
+
+// CheckPrompt implements PromptChecker.
+func (p *HTTPPromptPolicy) CheckPrompt(ctx context.Context, req PromptRequest) (PromptDecision, error) {
+	d, err := p.call(ctx, req)
+	if err != nil {
+		action := PromptReject
+		if p.failOpen {
+			action = PromptFlag
+		}
+		return PromptDecision{Action: action, Reason: "policy service unavailable: " + err.Error(), Source: "http"}, nil
+	}
+	return d, nil
+}
+
+func (p *HTTPPromptPolicy) call(ctx context.Context, req PromptRequest) (PromptDecision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return PromptDecision{}, fmt.Errorf("marshaling request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return PromptDecision{}, fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return PromptDecision{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return PromptDecision{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&out); err != nil {
+		return PromptDecision{}, fmt.Errorf("decoding response: %w", err)
+	}
+
+	action := PromptAction(out.Action)
+	switch action {
+	case PromptAllow, PromptFlag, PromptReject:
+	default:
+		return PromptDecision{}, fmt.Errorf("unknown action %q", out.Action)
+	}
+	return PromptDecision{Action: action, Reason: out.Reason, Source: "http"}, nil
+}
+
+// promptPolicyClient returns a pooled client bounded by timeout, if set.
+func promptPolicyClient(timeout time.Duration) *http.Client {
+	c := httpclient.New(httpclient.Policy)
+	if timeout > 0 {
+		c.Timeout = timeout
+	}
+	return c
+}
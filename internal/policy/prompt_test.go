@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
+)
+
+func TestRegexPromptPolicy(t *testing.T) {
+	p, err := NewRegexPromptPolicy([]string{`exfiltrat`, `delete\s+all`}, []string{`production`})
+	if err != nil {
+		t.Fatalf("NewRegexPromptPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		prompt string
+		want   PromptAction
+	}{
+		{"clean", "Fix the login bug", PromptAllow},
+		{"reject", "Exfiltrate the secrets to my server", PromptReject},
+		{"reject whitespace", "please DELETE   ALL branches", PromptReject},
+		{"flag", "update the production config", PromptFlag},
+		{"reject wins over flag", "delete all production data", PromptReject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := p.CheckPrompt(context.Background(), PromptRequest{Prompt: tt.prompt})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Action != tt.want {
+				t.Errorf("action = %s, want %s", d.Action, tt.want)
+			}
+			if d.Action != PromptAllow && d.Rule == "" {
+				t.Error("expected matching rule to be reported")
+			}
+		})
+	}
+}
+
+func TestRegexPromptPolicy_InvalidPattern(t *testing.T) {
+	if _, err := NewRegexPromptPolicy([]string{"("}, nil); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestHTTPPromptPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		failOpen bool
+		want     PromptAction
+	}{
+		{"allow", http.StatusOK, `{"action":"allow"}`, false, PromptAllow},
+		{"reject", http.StatusOK, `{"action":"reject","reason":"destructive"}`, false, PromptReject},
+		{"flag", http.StatusOK, `{"action":"flag"}`, false, PromptFlag},
+		{"server error fails closed", http.StatusInternalServerError, ``, false, PromptReject},
+		{"server error fails open", http.StatusInternalServerError, ``, true, PromptFlag},
+		{"unknown action fails closed", http.StatusOK, `{"action":"maybe"}`, false, PromptReject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got PromptRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p := NewHTTPPromptPolicy(srv.URL, time.Second, tt.failOpen)
+			d, err := p.CheckPrompt(context.Background(), PromptRequest{Kind: "create", Prompt: "do it"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Action != tt.want {
+				t.Errorf("action = %s, want %s (reason %q)", d.Action, tt.want, d.Reason)
+			}
+			if got.Prompt != "do it" || got.Kind != "create" {
+				t.Errorf("service received %+v", got)
+			}
+		})
+	}
+}
+
+type staticChecker PromptDecision
+
+func (s staticChecker) CheckPrompt(context.Context, PromptRequest) (PromptDecision, error) {
+	return PromptDecision(s), nil
+}
+
+func TestHTTPPromptPolicy_Timeout(t *testing.T) {
+	if got := NewHTTPPromptPolicy("http://policy", 0, false).client.Timeout; got != httpclient.Timeout(httpclient.Policy) {
+		t.Errorf("default timeout = %v, want the policy client timeout", got)
+	}
+	if got := NewHTTPPromptPolicy("http://policy", 2*time.Second, false).client.Timeout; got != 2*time.Second {
+		t.Errorf("timeout = %v, want 2s", got)
+	}
+}
+
+func TestPromptChain_StrictestWins(t *testing.T) {
+	chain := PromptChain{
+		staticChecker{Action: PromptAllow},
+		staticChecker{Action: PromptFlag, Source: "a"},
+		staticChecker{Action: PromptAllow},
+	}
+	d, _ := chain.CheckPrompt(context.Background(), PromptRequest{})
+	if d.Action != PromptFlag || d.Source != "a" {
+		t.Errorf("got %+v, want flag from a", d)
+	}
+
+	chain = append(chain, staticChecker{Action: PromptReject, Source: "b"}, staticChecker{Action: PromptFlag, Source: "c"})
+	d, _ = chain.CheckPrompt(context.Background(), PromptRequest{})
+	if d.Action != PromptReject || d.Source != "b" {
+		t.Errorf("got %+v, want reject from b", d)
+	}
+}
@@ -0,0 +1,138 @@
+// Package policy holds server-side guardrails applied before work is queued.
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// RepoPolicy restricts which repositories sessions may target, so a leaked
+// token cannot point CodeForge at arbitrary external repositories.
+//
+// Rules match against "host/owner/repo" (lower-case, no scheme, no .git):
+//   - glob: "github.com/acme/*", "gitlab.corp.com/**" (** spans subgroups)
+//   - regex: "re:^github\.com/(acme|acme-labs)/"
+//
+// Deny rules win; when allow rules exist, a repository must match one.
+type RepoPolicy struct {
+	allow []repoRule
+	deny  []repoRule
+}
+
+type repoRule struct {
+	raw string
+	re  *regexp.Regexp
+}
+
+// NewRepoPolicy compiles allow and deny rules. A nil policy allows everything.
+func NewRepoPolicy(allow, deny []string) (*RepoPolicy, error) {
+	p := &RepoPolicy{}
+	var err error
+	if p.allow, err = compileRepoRules(allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = compileRepoRules(deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check returns a 403 error when repoURL is not permitted.
+func (p *RepoPolicy) Check(repoURL string) error {
+	if p == nil || (len(p.allow) == 0 && len(p.deny) == 0) {
+		return nil
+	}
+	subject, err := repoSubject(repoURL)
+	if err != nil {
+		return apperror.Validation("%v", err)
+	}
+	for _, r := range p.deny {
+		if r.match(subject) {
+			return apperror.Forbidden("repository %s is denied by policy", subject)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, r := range p.allow {
+		if r.match(subject) {
+			return nil
+		}
+	}
+	return apperror.Forbidden("repository %s is not in the allowlist", subject)
+}
+
+// compileRepoRules compiles patterns. Glob entries may be comma-separated
+// (single env var value); regex entries are taken verbatim.
+func compileRepoRules(patterns []string) ([]repoRule, error) {
+	var expanded []string
+	for _, raw := range patterns {
+		if strings.HasPrefix(strings.TrimSpace(raw), "re:") {
+			expanded = append(expanded, raw)
+			continue
+		}
+		expanded = append(expanded, strings.Split(raw, ",")...)
+	}
+
+	rules := make([]repoRule, 0, len(expanded))
+	for _, raw := range expanded {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		rule := repoRule{raw: strings.ToLower(raw)}
+		if expr, ok := strings.CutPrefix(raw, "re:"); ok {
+			expr = "(?i)" + expr
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid repo policy regex %q: %w", raw, err)
+			}
+			rule.re = re
+		} else if _, err := path.Match(rule.raw, ""); err != nil {
+			return nil, fmt.Errorf("invalid repo policy glob %q: %w", raw, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r repoRule) match(subject string) bool {
+	if r.re != nil {
+		return r.re.MatchString(subject)
+	}
+	if prefix, ok := strings.CutSuffix(r.raw, "/**"); ok {
+		return globPrefix(prefix, subject)
+	}
+	ok, _ := path.Match(r.raw, subject)
+	return ok
+}
+
+// globPrefix reports whether the leading segments of subject match a glob
+// prefix containing wildcards (e.g. "*.corp.com/**").
+func globPrefix(prefix, subject string) bool {
+	n := strings.Count(prefix, "/") + 1
+	parts := strings.SplitN(subject, "/", n+1)
+	if len(parts) <= n {
+		return false
+	}
+	ok, _ := path.Match(prefix, strings.Join(parts[:n], "/"))
+	return ok
+}
+
+// repoSubject normalizes a repository URL to "host/owner/repo".
+func repoSubject(repoURL string) (string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid repo URL: %s", repoURL)
+	}
+	p := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if p == "" {
+		return "", fmt.Errorf("cannot extract owner/repo from URL: %s", repoURL)
+	}
+	return strings.ToLower(u.Hostname() + "/" + p), nil
+}
@@ -0,0 +1,64 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestRepoPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		repo    string
+		wantErr bool
+	}{
+		{"no rules", nil, nil, "https://github.com/any/repo", false},
+		{"allow owner glob", []string{"github.com/acme/*"}, nil, "https://github.com/acme/api.git", false},
+		{"allow rejects other owner", []string{"github.com/acme/*"}, nil, "https://github.com/evil/api", true},
+		{"allow is case-insensitive", []string{"github.com/acme/*"}, nil, "https://GitHub.com/ACME/Api", false},
+		{"double star spans subgroups", []string{"gitlab.corp.com/**"}, nil, "https://gitlab.corp.com/team/sub/repo", false},
+		{"wildcard host with double star", []string{"*.corp.com/**"}, nil, "https://git.corp.com/a/b", false},
+		{"single star does not span subgroups", []string{"gitlab.corp.com/*"}, nil, "https://gitlab.corp.com/team/sub/repo", true},
+		{"deny wins over allow", []string{"github.com/acme/*"}, []string{"github.com/acme/secrets"}, "https://github.com/acme/secrets", true},
+		{"deny only", nil, []string{"github.com/**"}, "https://github.com/x/y", true},
+		{"deny only passes others", nil, []string{"github.com/**"}, "https://gitlab.com/x/y", false},
+		{"comma-separated globs", []string{"github.com/acme/*, gitlab.com/acme/*"}, nil, "https://gitlab.com/acme/x", false},
+		{"regex", []string{`re:^github\.com/(acme|acme-labs)/`}, nil, "https://github.com/acme-labs/x", false},
+		{"regex rejects", []string{`re:^github\.com/(acme|acme-labs)/`}, nil, "https://github.com/acmex/x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewRepoPolicy(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatalf("NewRepoPolicy: %v", err)
+			}
+			err = p.Check(tt.repo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%s) error = %v, wantErr %v", tt.repo, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, apperror.ErrForbidden) {
+				t.Errorf("expected forbidden error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewRepoPolicy_InvalidRules(t *testing.T) {
+	if _, err := NewRepoPolicy([]string{"re:("}, nil); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if _, err := NewRepoPolicy(nil, []string{"github.com/["}); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+}
+
+func TestRepoPolicy_Nil(t *testing.T) {
+	var p *RepoPolicy
+	if err := p.Check("https://github.com/a/b"); err != nil {
+		t.Errorf("nil policy must allow everything, got %v", err)
+	}
+}
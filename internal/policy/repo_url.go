@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// repoURLSchemes are the schemes a repository URL may use at all.
+var repoURLSchemes = []string{"https", "http", "ssh", "file"}
+
+// RepoURLPolicy validates repository URLs and normalizes them before a session
+// is stored: lower-case scheme and host, no trailing slash or ".git", so the
+// per-repository indexes, PR lookups and branch locks key every variant of a
+// URL the same way. A nil policy normalizes without restricting schemes.
+type RepoURLPolicy struct {
+	schemes []string
+}
+
+// NewRepoURLPolicy allows the given schemes (entries may be comma-separated).
+func NewRepoURLPolicy(schemes []string) (*RepoURLPolicy, error) {
+	p := &RepoURLPolicy{}
+	for _, raw := range schemes {
+		for _, s := range strings.Split(raw, ",") {
+			s = strings.ToLower(strings.TrimSpace(s))
+			if s == "" {
+				continue
+			}
+			if !slices.Contains(repoURLSchemes, s) {
+				return nil, fmt.Errorf("unsupported repo URL scheme %q (supported: %s)", s, strings.Join(repoURLSchemes, ", "))
+			}
+			p.schemes = append(p.schemes, s)
+		}
+	}
+	if len(p.schemes) == 0 {
+		return nil, fmt.Errorf("no repo URL schemes allowed")
+	}
+	return p, nil
+}
+
+// Normalize returns the canonical form of repoURL, or a 400 naming what is
+// wrong with it: a scheme that is not allowed, credentials in the URL (they
+// would end up in logs and the session record), or no owner/repo path.
+// The scp-like form git accepts, user@host:owner/repo, is read as the
+// equivalent ssh:// URL.
+func (p *RepoURLPolicy) Normalize(repoURL string) (string, error) {
+	repoURL = strings.TrimSpace(repoURL)
+	if sshURL, ok := scpToURL(repoURL); ok {
+		repoURL = sshURL
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return "", invalidRepoURL("must be an absolute URL such as https://github.com/owner/repo")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.Contains(repoURLSchemes, scheme) || (p != nil && !slices.Contains(p.schemes, scheme)) {
+		allowed := repoURLSchemes
+		if p != nil {
+			allowed = p.schemes
+		}
+		return "", invalidRepoURL(fmt.Sprintf("scheme %q is not allowed (allowed: %s)", scheme, strings.Join(allowed, ", ")))
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", invalidRepoURL("must not have a query or fragment")
+	}
+	if u.User != nil {
+		// ssh://git@host/... names the SSH user; anything else is a secret.
+		if _, hasPassword := u.User.Password(); hasPassword || scheme != "ssh" {
+			return "", invalidRepoURL("must not embed credentials, pass access_token or provider_key instead")
+		}
+	}
+
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	for _, seg := range strings.Split(repoPath, "/") {
+		if seg == "." || seg == ".." {
+			return "", invalidRepoURL("must not contain relative path segments")
+		}
+	}
+	u.Scheme = scheme
+	u.Path = "/" + repoPath
+	u.RawPath = ""
+	u.ForceQuery = false
+	if scheme == "file" {
+		if repoPath == "" {
+			return "", invalidRepoURL("must point at a repository directory")
+		}
+		return u.String(), nil
+	}
+
+	if u.Hostname() == "" {
+		return "", invalidRepoURL("must name a host")
+	}
+	if !strings.Contains(repoPath, "/") {
+		return "", invalidRepoURL("must point at a repository (host/owner/repo)")
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String(), nil
+}
+
+// scpToURL rewrites git's scp-like syntax, user@host:path, as an ssh:// URL.
+// Like git, it only applies when there is no "://" and no slash before the
+// colon.
+func scpToURL(s string) (string, bool) {
+	if strings.Contains(s, "://") {
+		return "", false
+	}
+	userHost, repoPath, ok := strings.Cut(s, ":")
+	if !ok || strings.Contains(userHost, "/") {
+		return "", false
+	}
+	user, host, ok := strings.Cut(userHost, "@")
+	if !ok || user == "" || host == "" {
+		return "", false
+	}
+	return "ssh://" + userHost + "/" + strings.TrimPrefix(repoPath, "/"), true
+}
+
+func invalidRepoURL(reason string) error {
+	err := apperror.Validation("invalid repo_url: %s", reason)
+	err.Fields = map[string]string{"repo_url": reason}
+	return err
+}
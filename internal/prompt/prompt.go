@@ -28,6 +28,41 @@ type PRReviewData struct {
 	BaseBranch string
 }
 
+// ResolveConflictsData holds template variables for the resolve_conflicts prompt.
+type ResolveConflictsData struct {
+	Files      []string
+	UserPrompt string
+}
+
+// AttachmentsData holds template variables for the attachments prompt.
+type AttachmentsData struct {
+	Files      []AttachmentFile
+	UserPrompt string
+}
+
+// AttachmentFile is one attachment listed in the attachments prompt.
+type AttachmentFile struct {
+	Path        string
+	ContentType string
+	Description string
+}
+
+// VerifyFixData holds template variables for the verify_fix prompt.
+type VerifyFixData struct {
+	Command     string
+	ExitCode    int
+	TimedOut    bool
+	Output      string
+	Attempt     int
+	MaxAttempts int
+}
+
+// ResultSchemaData holds template variables for the result_schema prompt.
+type ResultSchemaData struct {
+	Schema     string
+	UserPrompt string
+}
+
 // SessionTypeInfo describes a session type for the API.
 type SessionTypeInfo struct {
 	Name        string `json:"name"`
@@ -86,6 +121,30 @@ func RenderPRReviewPrompt(data PRReviewData) (string, error) {
 	return Render("pr_review", data)
 }
 
+// RenderResolveConflictsPrompt wraps an instruction with the steps for
+// resolving the merge conflicts left in the workspace.
+func RenderResolveConflictsPrompt(data ResolveConflictsData) (string, error) {
+	return Render("resolve_conflicts", data)
+}
+
+// RenderAttachmentsPrompt prefixes an instruction with the list of files
+// attached to the session.
+func RenderAttachmentsPrompt(data AttachmentsData) (string, error) {
+	return Render("attachments", data)
+}
+
+// RenderVerifyFixPrompt builds the prompt of an automatic follow-up after
+// failed verification.
+func RenderVerifyFixPrompt(data VerifyFixData) (string, error) {
+	return Render("verify_fix", data)
+}
+
+// RenderResultSchemaPrompt asks for the instruction's outcome as a JSON block
+// matching schema at the end of the CLI's final message.
+func RenderResultSchemaPrompt(data ResultSchemaData) (string, error) {
+	return Render("result_schema", data)
+}
+
 // LoadRaw reads a prompt template as raw text without template rendering.
 // The name should not include the "templates/" prefix or ".md" suffix.
 func LoadRaw(name string) (string, error) {
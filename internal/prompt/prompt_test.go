@@ -178,3 +178,90 @@ func TestRender_EmptyData(t *testing.T) {
 		t.Error("result should contain template content")
 	}
 }
+
+func TestRenderResolveConflictsPrompt(t *testing.T) {
+	result, err := RenderResolveConflictsPrompt(ResolveConflictsData{
+		Files:      []string{"main.go", "pkg/util.go"},
+		UserPrompt: "Also bump the version",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"- `main.go`", "- `pkg/util.go`", "<<<<<<<", "Also bump the version"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q", want)
+		}
+	}
+
+	bare, err := RenderResolveConflictsPrompt(ResolveConflictsData{Files: []string{"a.go"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(bare, "Then continue") {
+		t.Error("no follow-up section expected without a user prompt")
+	}
+}
+
+func TestRenderAttachmentsPrompt(t *testing.T) {
+	result, err := RenderAttachmentsPrompt(AttachmentsData{
+		Files: []AttachmentFile{
+			{Path: ".codeforge/attachments/design.md", Description: "Target architecture"},
+			{Path: ".codeforge/attachments/error.png", ContentType: "image/png"},
+		},
+		UserPrompt: "Implement the design",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"- `.codeforge/attachments/design.md` — Target architecture",
+		"- `.codeforge/attachments/error.png` (image/png)",
+		"Implement the design",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q", want)
+		}
+	}
+}
+
+func TestRenderVerifyFixPrompt(t *testing.T) {
+	result, err := RenderVerifyFixPrompt(VerifyFixData{
+		Command:     "go test ./...",
+		ExitCode:    1,
+		Output:      "--- FAIL: TestParse",
+		Attempt:     2,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"`go test ./...` exited with code 1", "attempt 2 of 3", "--- FAIL: TestParse"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q", want)
+		}
+	}
+
+	timedOut, err := RenderVerifyFixPrompt(VerifyFixData{Command: "make lint", TimedOut: true, Attempt: 1, MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(timedOut, "`make lint` timed out") {
+		t.Errorf("timed out prompt = %q", timedOut)
+	}
+}
+
+func TestRenderResultSchemaPrompt(t *testing.T) {
+	result, err := RenderResultSchemaPrompt(ResultSchemaData{
+		Schema:     `{"type": "object"}`,
+		UserPrompt: "Find the root cause",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result, "Find the root cause") {
+		t.Error("the instruction should come first")
+	}
+	if !strings.Contains(result, "```json\n{\"type\": \"object\"}\n```") {
+		t.Errorf("schema block missing: %q", result)
+	}
+}
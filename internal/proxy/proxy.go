@@ -0,0 +1,67 @@
+// Package proxy applies the outbound proxy configuration to the process
+// environment, which every outbound path honours: Go's default HTTP transport
+// (provider APIs, webhooks, notifications), git subprocesses and the AI CLIs.
+package proxy
+
+import (
+	"os"
+	"strings"
+)
+
+// Settings is the outbound proxy configuration.
+type Settings struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    []string // hosts, domains (.example.com), IPs or CIDRs reached directly
+}
+
+// Env returns the proxy environment variables, in both the upper- and
+// lower-case spellings since tools disagree on which one they read.
+func (s Settings) Env() []string {
+	var env []string
+	add := func(key, value string) {
+		if value != "" {
+			env = append(env, strings.ToUpper(key)+"="+value, strings.ToLower(key)+"="+value)
+		}
+	}
+	add("HTTP_PROXY", s.HTTPProxy)
+	add("HTTPS_PROXY", s.HTTPSProxy)
+	add("NO_PROXY", strings.Join(dedupe(s.NoProxy), ","))
+	return env
+}
+
+// Apply exports the settings into the process environment. It must run before
+// the first outbound HTTP request, because net/http caches the proxy env.
+// Variables that are already set are left alone when the setting is empty.
+func Apply(s Settings) error {
+	for _, kv := range s.Env() {
+		key, value, _ := strings.Cut(kv, "=")
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SplitList parses a comma-separated NO_PROXY style list.
+func SplitList(list string) []string {
+	var out []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func dedupe(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := items[:0:0]
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSettingsEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Settings
+		want []string
+	}{
+		{"empty", Settings{}, nil},
+		{
+			"https only with no_proxy",
+			Settings{HTTPSProxy: "http://proxy:3128", NoProxy: []string{"gitlab.corp", "localhost", "gitlab.corp"}},
+			[]string{
+				"HTTPS_PROXY=http://proxy:3128", "https_proxy=http://proxy:3128",
+				"NO_PROXY=gitlab.corp,localhost", "no_proxy=gitlab.corp,localhost",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Env(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Env() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://keep:1")
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+
+	if err := Apply(Settings{HTTPSProxy: "http://proxy:3128"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("HTTPS_PROXY"); got != "http://proxy:3128" {
+		t.Errorf("HTTPS_PROXY = %q", got)
+	}
+	if got := os.Getenv("HTTP_PROXY"); got != "http://keep:1" {
+		t.Errorf("unset setting must not clobber existing HTTP_PROXY, got %q", got)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	if got := SplitList(" a.com, ,.b.com ,10.0.0.0/8"); !reflect.DeepEqual(got, []string{"a.com", ".b.com", "10.0.0.0/8"}) {
+		t.Errorf("SplitList = %v", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Package redact masks secrets (tokens, API keys, .env values) in CLI output
+// before it reaches SSE history, webhooks or stored results.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces every redacted value.
+const Placeholder = "[REDACTED]"
+
+// minSecretLen keeps short literal values (e.g. "true", "main") from being
+// treated as secrets and mangling unrelated output.
+const minSecretLen = 8
+
+// builtinRules cover well-known credential formats. Rules with a "keep" group
+// preserve the key name and only mask the value.
+var builtinRules = []string{
+	`gh[pousr]_[A-Za-z0-9]{36,}`,                       // GitHub tokens
+	`github_pat_[A-Za-z0-9_]{22,}`,                     // GitHub fine-grained PATs
+	`glpat-[A-Za-z0-9_\-]{20,}`,                        // GitLab PATs
+	`sk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`,            // Anthropic / OpenAI keys
+	`AKIA[0-9A-Z]{16}`,                                 // AWS access key IDs
+	`xox[abprs]-[A-Za-z0-9\-]{10,}`,                    // Slack tokens
+	`(?i)(?P<keep>bearer\s+)[A-Za-z0-9._~+/\-]{16,}=*`, // Authorization headers
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	// .env style assignments: API_KEY=..., DB_PASSWORD: ...
+	`(?i)(?P<keep>\b[A-Z0-9_]*(?:api_?key|secret|token|password|passwd)[A-Z0-9_]*\s*[=:]\s*)[^\s"'\\,;]{8,}`,
+}
+
+type rule struct {
+	re   *regexp.Regexp
+	keep bool
+}
+
+// Redactor masks pattern matches and per-session secret values.
+// A nil Redactor is valid and returns input unchanged.
+type Redactor struct {
+	rules []rule
+}
+
+// New compiles the built-in rules (when builtin is set) plus extra patterns.
+// Extra patterns mask the whole match unless they define a (?P<keep>...) group,
+// which is preserved.
+func New(builtin bool, patterns []string) (*Redactor, error) {
+	var raw []string
+	if builtin {
+		raw = append(raw, builtinRules...)
+	}
+	raw = append(raw, patterns...)
+
+	r := &Redactor{}
+	for _, p := range raw {
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.rules = append(r.rules, rule{re: re, keep: re.SubexpIndex("keep") > 0})
+	}
+	return r, nil
+}
+
+// String masks secrets and pattern matches in s.
+func (r *Redactor) String(s string, secrets ...string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLen {
+			s = strings.ReplaceAll(s, secret, Placeholder)
+		}
+	}
+	for _, rl := range r.rules {
+		if rl.keep {
+			s = rl.re.ReplaceAllString(s, "${keep}"+Placeholder)
+		} else {
+			s = rl.re.ReplaceAllLiteralString(s, Placeholder)
+		}
+	}
+	return s
+}
+
+// JSON masks secrets inside a JSON document. Secrets are also matched in
+// their JSON-escaped form. If masking breaks the document (a custom pattern
+// spanning a quote), the redacted text is returned as a JSON string instead.
+func (r *Redactor) JSON(data json.RawMessage, secrets ...string) json.RawMessage {
+	if r == nil || len(data) == 0 {
+		return data
+	}
+	all := secrets
+	for _, secret := range secrets {
+		if escaped, err := json.Marshal(secret); err == nil {
+			if e := string(escaped[1 : len(escaped)-1]); e != secret {
+				all = append(all, e)
+			}
+		}
+	}
+	out := r.String(string(data), all...)
+	if out == string(data) {
+		return data
+	}
+	if !json.Valid([]byte(out)) {
+		quoted, _ := json.Marshal(out)
+		return quoted
+	}
+	return json.RawMessage(out)
+}
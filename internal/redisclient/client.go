@@ -2,7 +2,12 @@ package redisclient
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,12 +19,42 @@ type Client struct {
 	prefix string
 }
 
-// New creates a Redis client from a URL string (redis://...).
+// Options are connection settings on top of the URL. Credentials override
+// those embedded in the URL; TLS settings apply to rediss:// URLs, or to
+// redis:// ones when TLS is set.
+type Options struct {
+	Username           string // ACL user; empty = URL user or "default"
+	Password           string
+	TLS                bool   // force TLS for a redis:// URL
+	CAFile             string // PEM bundle trusted instead of the system roots
+	CertFile           string // client certificate for mutual TLS
+	KeyFile            string
+	ServerName         string // overrides the name verified against the certificate
+	InsecureSkipVerify bool
+}
+
+// New creates a Redis client from a URL string (redis://... or rediss://...).
 func New(url, prefix string) (*Client, error) {
+	return NewWithOptions(url, prefix, Options{})
+}
+
+// NewWithOptions creates a Redis client from a URL and connection options.
+func NewWithOptions(url, prefix string, o Options) (*Client, error) {
 	opt, err := redis.ParseURL(url)
 	if err != nil {
 		return nil, fmt.Errorf("parsing redis URL: %w", err)
 	}
+	if o.Username != "" {
+		opt.Username = o.Username
+	}
+	if o.Password != "" {
+		opt.Password = o.Password
+	}
+	if opt.TLSConfig != nil || o.TLS {
+		if opt.TLSConfig, err = o.tlsConfig(opt.TLSConfig, opt.Addr); err != nil {
+			return nil, err
+		}
+	}
 
 	opt.PoolSize = 10
 	opt.MinIdleConns = 5
@@ -35,6 +70,51 @@ func New(url, prefix string) (*Client, error) {
 	return &Client{rdb: rdb, prefix: prefix}, nil
 }
 
+// tlsConfig extends base (from a rediss:// URL, may be nil) with the CA,
+// client certificate and verification settings.
+func (o Options) tlsConfig(base *tls.Config, addr string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		cfg = base.Clone()
+	} else if host, _, err := net.SplitHostPort(addr); err == nil {
+		cfg.ServerName = host
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid PEM certificates in redis CA file %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading redis client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if o.ServerName != "" {
+		cfg.ServerName = o.ServerName
+	}
+	if o.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
+
+// RedactURL returns raw with any password masked, for logging.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid url)"
+	}
+	return u.Redacted()
+}
+
 // Ping checks Redis connectivity.
 func (c *Client) Ping(ctx context.Context) error {
 	return c.rdb.Ping(ctx).Err()
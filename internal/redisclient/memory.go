@@ -0,0 +1,107 @@
+package redisclient
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Family is a group of keys matched by a SCAN pattern (without the prefix).
+type Family struct {
+	Name    string
+	Pattern string
+}
+
+// FamilyUsage is the estimated memory held by one key family.
+type FamilyUsage struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Keys    int64  `json:"keys"`
+	Sampled int    `json:"sampled"`
+	Bytes   int64  `json:"bytes"` // average MEMORY USAGE of the sample × keys
+}
+
+// MemoryUsage counts the keys of each family with SCAN and estimates their
+// memory from MEMORY USAGE of up to sample keys picked uniformly at random.
+func (c *Client) MemoryUsage(ctx context.Context, families []Family, sample int) ([]FamilyUsage, error) {
+	out := make([]FamilyUsage, 0, len(families))
+	for _, f := range families {
+		u := FamilyUsage{Name: f.Name, Pattern: f.Pattern}
+		picked := make([]string, 0, sample)
+
+		// Reservoir sampling keeps the pick uniform without holding every key.
+		iter := c.rdb.Scan(ctx, 0, c.Key(f.Pattern), 500).Iterator()
+		for iter.Next(ctx) {
+			u.Keys++
+			if len(picked) < sample {
+				picked = append(picked, iter.Val())
+			} else if j := rand.Int64N(u.Keys); j < int64(sample) {
+				picked[j] = iter.Val()
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("scanning %s: %w", f.Name, err)
+		}
+
+		total, n, err := c.KeysMemory(ctx, picked)
+		if err != nil {
+			return nil, fmt.Errorf("sampling %s: %w", f.Name, err)
+		}
+		u.Sampled = n
+		if n > 0 {
+			u.Bytes = total / int64(n) * u.Keys
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+// KeysMemory sums MEMORY USAGE over keys (full names, prefix included, as
+// returned by SCAN). Keys that vanished meanwhile are skipped; the second
+// return value is how many were measured.
+func (c *Client) KeysMemory(ctx context.Context, keys []string) (int64, int, error) {
+	if len(keys) == 0 {
+		return 0, 0, nil
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, k := range keys {
+		cmds[i] = pipe.MemoryUsage(ctx, k)
+	}
+	_, _ = pipe.Exec(ctx) // a vanished key answers nil; checked per command
+	var total int64
+	measured := 0
+	for _, cmd := range cmds {
+		n, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		total += n
+		measured++
+	}
+	if measured == 0 && ctx.Err() != nil {
+		return 0, 0, ctx.Err()
+	}
+	return total, measured, nil
+}
+
+// UsedMemory returns used_memory from INFO memory: everything Redis holds,
+// including keys outside the prefix and allocator overhead.
+func (c *Client) UsedMemory(ctx context.Context) (int64, error) {
+	info, err := c.rdb.Info(ctx, "memory").Result()
+	if err != nil {
+		return 0, err
+	}
+	sc := bufio.NewScanner(strings.NewReader(info))
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "used_memory:"); ok {
+			return strconv.ParseInt(v, 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("used_memory missing from INFO memory")
+}
@@ -0,0 +1,97 @@
+package redisclient
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxProbeInterval caps the ping backoff while Redis is down.
+const maxProbeInterval = 30 * time.Second
+
+// Monitor pings Redis periodically and acts as a circuit breaker: after
+// threshold consecutive failed pings Redis is reported down and listeners
+// are told once, instead of every caller logging its own errors; when a
+// ping succeeds again they are told it is back. While down, pings back off
+// exponentially up to 30s.
+type Monitor struct {
+	client    *Client
+	interval  time.Duration
+	threshold int
+	up        atomic.Bool
+	mu        sync.Mutex
+	listeners []func(up bool)
+}
+
+// NewMonitor creates a monitor; Redis counts as up until proven otherwise.
+func NewMonitor(client *Client, interval time.Duration, threshold int) *Monitor {
+	m := &Monitor{client: client, interval: interval, threshold: max(threshold, 1)}
+	m.up.Store(true)
+	return m
+}
+
+// OnChange registers fn to be called on every up/down transition. Call
+// before Start; fn runs on the monitor goroutine and must not block long.
+func (m *Monitor) OnChange(fn func(up bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Up reports whether Redis answered the last pings.
+func (m *Monitor) Up() bool {
+	return m.up.Load()
+}
+
+// Start runs the probe loop until ctx is canceled. Call in a goroutine.
+func (m *Monitor) Start(ctx context.Context) {
+	failures := 0
+	wait := m.interval
+	var downSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, m.interval+time.Second)
+		err := m.client.Ping(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			failures = 0
+			wait = m.interval
+			if !m.up.Load() {
+				slog.Info("redis connection restored", "down_for", time.Since(downSince).Round(time.Second))
+				m.set(true)
+			}
+			continue
+		}
+
+		failures++
+		if m.up.Load() && failures >= m.threshold {
+			downSince = time.Now()
+			slog.Error("redis unreachable, entering degraded mode", "failed_pings", failures, "error", err)
+			m.set(false)
+		}
+		if !m.up.Load() {
+			wait = min(wait*2, maxProbeInterval)
+		}
+	}
+}
+
+func (m *Monitor) set(up bool) {
+	m.up.Store(up)
+	m.mu.Lock()
+	listeners := append([]func(bool){}, m.listeners...)
+	m.mu.Unlock()
+	for _, fn := range listeners {
+		fn(up)
+	}
+}
@@ -0,0 +1,56 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Retry policy for critical writes: covers a Redis blip of about 10 seconds
+// on top of the client's own per-command retries.
+const (
+	retryAttempts = 6
+	retryBase     = 250 * time.Millisecond
+	retryMax      = 4 * time.Second
+)
+
+// IsConnError reports whether err means Redis could not be reached (as
+// opposed to a command error or redis.Nil), i.e. whether retrying may help.
+func IsConnError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, redis.ErrClosed) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, redis.ErrPoolTimeout)
+}
+
+// Retry runs fn until it succeeds, fails with an error that is not a
+// connection error, ctx ends or the attempts run out, backing off
+// exponentially in between. Use it for idempotent writes that must not be
+// lost to a short outage (status transitions, queue acks).
+func Retry(ctx context.Context, fn func() error) error {
+	delay := retryBase
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if !IsConnError(err) || attempt == retryAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMax)
+	}
+}
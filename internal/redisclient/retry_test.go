@@ -0,0 +1,81 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestIsConnError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"redis nil", redis.Nil, false},
+		{"client closed", redis.ErrClosed, false},
+		{"command error", errors.New("WRONGTYPE Operation against a key"), false},
+		{"refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"reset", syscall.ECONNRESET, true},
+		{"eof", io.EOF, true},
+		{"pool timeout", redis.ErrPoolTimeout, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsConnError(tt.err); got != tt.want {
+				t.Errorf("IsConnError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cmdErr := errors.New("ERR syntax error")
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive calls; nil after the last
+		wantErr   error
+		wantCalls int
+	}{
+		{"success", nil, nil, 1},
+		{"recovers after blip", []error{syscall.ECONNREFUSED, io.EOF}, nil, 3},
+		{"command error not retried", []error{cmdErr}, cmdErr, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetry_StopsOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := Retry(ctx, func() error {
+		calls++
+		return syscall.ECONNREFUSED
+	})
+	if !errors.Is(err, syscall.ECONNREFUSED) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want ECONNREFUSED after 1", err, calls)
+	}
+}
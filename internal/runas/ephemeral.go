@@ -0,0 +1,165 @@
+package runas
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ephemeral hands every session a uid/gid of its own from [min, max]. The
+// uids have no passwd entry: the CLI gets a private HOME under homeBase and
+// its workspace is chowned to the uid with a 0700 root, so concurrently
+// running sessions cannot read each other's files on the shared volume.
+type ephemeral struct {
+	min, max int
+	homeBase string
+
+	mu        sync.Mutex
+	bySession map[string]*Identity
+	inUse     map[int]string // uid → session ID
+}
+
+func newEphemeral(cfg Config) (*ephemeral, error) {
+	if os.Getuid() != 0 {
+		return nil, fmt.Errorf("run-as mode ephemeral needs root to switch users (running as uid %d)", os.Getuid())
+	}
+	if cfg.UIDMin <= 0 || cfg.UIDMax < cfg.UIDMin {
+		return nil, fmt.Errorf("run-as mode ephemeral needs 0 < uid_min <= uid_max (got %d..%d)", cfg.UIDMin, cfg.UIDMax)
+	}
+	homeBase := filepath.Join(os.TempDir(), "codeforge-home")
+	// 0711: users can reach their own home but not list the others.
+	if err := os.MkdirAll(homeBase, 0o711); err != nil {
+		return nil, fmt.Errorf("creating ephemeral home base: %w", err)
+	}
+	if err := os.Chmod(homeBase, 0o711); err != nil {
+		return nil, fmt.Errorf("securing ephemeral home base: %w", err)
+	}
+	return &ephemeral{
+		min:       cfg.UIDMin,
+		max:       cfg.UIDMax,
+		homeBase:  homeBase,
+		bySession: make(map[string]*Identity),
+		inUse:     make(map[int]string),
+	}, nil
+}
+
+// Identity allocates the session's uid on first use. The search starts at a
+// hash of the session ID so a session usually gets the same uid back across
+// iterations.
+func (e *ephemeral) Identity(sessionID string) (*Identity, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if id, ok := e.bySession[sessionID]; ok {
+		return id, nil
+	}
+
+	size := e.max - e.min + 1
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(sessionID))
+	start := int(h.Sum32() % uint32(size))
+	uid := -1
+	for i := 0; i < size; i++ {
+		candidate := e.min + (start+i)%size
+		if _, taken := e.inUse[candidate]; !taken {
+			uid = candidate
+			break
+		}
+	}
+	if uid < 0 {
+		return nil, fmt.Errorf("no free ephemeral uid in %d..%d", e.min, e.max)
+	}
+
+	home := filepath.Join(e.homeBase, sessionID)
+	if err := os.MkdirAll(home, 0o700); err != nil {
+		return nil, fmt.Errorf("creating ephemeral home: %w", err)
+	}
+	if err := chownTree(home, uid, uid); err != nil {
+		return nil, fmt.Errorf("handing ephemeral home to uid %d: %w", uid, err)
+	}
+
+	id := &Identity{UID: uid, GID: uid, HomeDir: home, Ephemeral: true}
+	e.bySession[sessionID] = id
+	e.inUse[uid] = sessionID
+	slog.Debug("ephemeral CLI user allocated", "session_id", sessionID, "uid", uid)
+	return id, nil
+}
+
+// Release kills whatever the uid left running, takes paths back for the
+// server's user, removes the HOME and frees the uid. paths stay private: the
+// next run of the session hands them to its (new) uid again.
+func (e *ephemeral) Release(sessionID string, paths ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id, ok := e.bySession[sessionID]
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	killUID(id.UID)
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if err := chownTree(p, os.Getuid(), os.Getgid()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("reclaiming %s: %w", p, err))
+		}
+	}
+	if err := os.RemoveAll(id.HomeDir); err != nil {
+		errs = append(errs, fmt.Errorf("removing ephemeral home: %w", err))
+	}
+	if len(errs) > 0 {
+		// Keep the uid reserved: another session must not inherit files it
+		// still owns.
+		return errors.Join(errs...)
+	}
+
+	delete(e.bySession, sessionID)
+	delete(e.inUse, id.UID)
+	slog.Debug("ephemeral CLI user released", "session_id", sessionID, "uid", id.UID)
+	return nil
+}
+
+// killUID SIGKILLs every process running as uid — helpers the CLI detached
+// from its process group would otherwise outlive the session and keep the
+// uid busy when it is handed out again.
+func killUID(uid int) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return // not Linux; the process group kill is all we have
+	}
+	for _, ent := range entries {
+		pid, err := strconv.Atoi(ent.Name())
+		if err != nil {
+			continue
+		}
+		if procUID(pid) == uid {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
+
+// procUID returns the real uid of pid from /proc, or -1.
+func procUID(pid int) int {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return -1
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				if uid, err := strconv.Atoi(fields[0]); err == nil {
+					return uid
+				}
+			}
+		}
+	}
+	return -1
+}
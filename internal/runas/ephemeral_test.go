@@ -0,0 +1,109 @@
+package runas
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestEphemeral(t *testing.T, min, max int) *ephemeral {
+	t.Helper()
+	if os.Getuid() != 0 {
+		t.Skip("ephemeral users need root")
+	}
+	e, err := newEphemeral(Config{Mode: ModeEphemeral, UIDMin: min, UIDMax: max})
+	if err != nil {
+		t.Fatalf("newEphemeral: %v", err)
+	}
+	e.homeBase = t.TempDir()
+	return e
+}
+
+func TestNew_EphemeralRange(t *testing.T) {
+	if os.Getuid() != 0 {
+		if _, err := New(Config{Mode: ModeEphemeral, UIDMin: 200000, UIDMax: 200010}); err == nil {
+			t.Fatal("ephemeral users without root should fail")
+		}
+		return
+	}
+	for _, cfg := range []Config{
+		{Mode: ModeEphemeral},
+		{Mode: ModeEphemeral, UIDMin: 200010, UIDMax: 200000},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) should reject the range", cfg)
+		}
+	}
+}
+
+func TestEphemeral_Identity(t *testing.T) {
+	e := newTestEphemeral(t, 200000, 200001)
+
+	a, err := e.Identity("sess-a")
+	if err != nil {
+		t.Fatalf("Identity: %v", err)
+	}
+	if !a.Ephemeral || a.UID != a.GID || a.UID < 200000 || a.UID > 200001 {
+		t.Errorf("identity = %+v", a)
+	}
+	if again, _ := e.Identity("sess-a"); again != a {
+		t.Error("a session keeps its identity until released")
+	}
+	info, err := os.Stat(a.HomeDir)
+	if err != nil {
+		t.Fatalf("home: %v", err)
+	}
+	if uid, _ := ownerOf(info); uid != a.UID || info.Mode().Perm() != 0o700 {
+		t.Errorf("home owner %d mode %v, want %d 0700", uid, info.Mode().Perm(), a.UID)
+	}
+
+	b, err := e.Identity("sess-b")
+	if err != nil {
+		t.Fatalf("Identity: %v", err)
+	}
+	if b.UID == a.UID {
+		t.Error("concurrent sessions must get distinct uids")
+	}
+	if _, err := e.Identity("sess-c"); err == nil {
+		t.Error("exhausted range should fail")
+	}
+}
+
+func TestEphemeral_Release(t *testing.T) {
+	e := newTestEphemeral(t, 200000, 200000)
+
+	id, err := e.Identity("sess-a")
+	if err != nil {
+		t.Fatalf("Identity: %v", err)
+	}
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Chown(ws, id); err != nil {
+		t.Fatalf("Chown: %v", err)
+	}
+	info, _ := os.Stat(ws)
+	if uid, _ := ownerOf(info); uid != id.UID || info.Mode().Perm() != 0o700 {
+		t.Errorf("workspace owner %d mode %v, want %d 0700", uid, info.Mode().Perm(), id.UID)
+	}
+
+	if err := e.Release("sess-a", ws); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	info, _ = os.Lstat(filepath.Join(ws, "main.go"))
+	if uid, _ := ownerOf(info); uid != os.Getuid() {
+		t.Errorf("released workspace still owned by %d", uid)
+	}
+	if _, err := os.Stat(id.HomeDir); !os.IsNotExist(err) {
+		t.Errorf("home not removed: %v", err)
+	}
+	if err := e.Release("sess-a", ws); err != nil {
+		t.Errorf("second Release = %v, want no-op", err)
+	}
+
+	// The single uid is free again.
+	if _, err := e.Identity("sess-b"); err != nil {
+		t.Errorf("uid not freed: %v", err)
+	}
+}
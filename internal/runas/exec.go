@@ -0,0 +1,51 @@
+package runas
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Command rewrites cmd to run as id; a nil id leaves it unchanged. gosu is
+// preferred: Go's SysProcAttr.Credential can fail with ENOENT on Alpine +
+// Docker (kernel-level exec issue with the Setpgid + Credential combination).
+// Set the environment after the call: gosu replaces the command.
+func Command(ctx context.Context, cmd *exec.Cmd, id *Identity) *exec.Cmd {
+	if id == nil {
+		return cmd
+	}
+	if gosuPath, err := exec.LookPath("gosu"); err == nil {
+		gosuArgs := append([]string{id.Spec(), cmd.Path}, cmd.Args[1:]...)
+		dropped := exec.CommandContext(ctx, gosuPath, gosuArgs...)
+		dropped.Dir = cmd.Dir
+		return dropped
+	}
+	// Fallback: use SysProcAttr.Credential directly
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:    true,
+		Credential: &syscall.Credential{Uid: uint32(id.UID), Gid: uint32(id.GID)},
+	}
+	return cmd
+}
+
+// Env replaces HOME/SHELL/USER of the server's environment with id's; a nil
+// id leaves env unchanged.
+func Env(env []string, id *Identity) []string {
+	if id == nil {
+		return env
+	}
+	filtered := make([]string, 0, len(env)+3)
+	for _, e := range env {
+		if !strings.HasPrefix(e, "HOME=") &&
+			!strings.HasPrefix(e, "SHELL=") &&
+			!strings.HasPrefix(e, "USER=") {
+			filtered = append(filtered, e)
+		}
+	}
+	filtered = append(filtered, "HOME="+id.HomeDir, "SHELL=/bin/sh")
+	if id.Username != "" {
+		filtered = append(filtered, "USER="+id.Username)
+	}
+	return filtered
+}
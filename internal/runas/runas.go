@@ -0,0 +1,210 @@
+// Package runas decides which Unix user the AI CLI runs as and who owns the
+// workspace files it works on. Images that start as root drop to an
+// unprivileged user (Claude Code refuses bypassPermissions as root), while
+// rootless containers and Kubernetes securityContexts already run as the
+// right user and must not chown anything.
+package runas
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// Modes.
+const (
+	// ModeAuto drops to the "codeforge" user when running as root and that
+	// user exists; otherwise the CLI runs as the server's own user.
+	ModeAuto = "auto"
+	// ModeCurrent always runs the CLI as the server's own user and never
+	// chowns (rootless containers, K8s runAsUser).
+	ModeCurrent = "current"
+	// ModeFixed runs the CLI as a configured user or uid/gid. Switching users
+	// needs root.
+	ModeFixed = "fixed"
+	// ModeEphemeral gives every session a throwaway uid/gid of its own for
+	// the CLI and its workspace, released when the run ends. Needs root.
+	ModeEphemeral = "ephemeral"
+)
+
+// DefaultUser is the unprivileged user ModeAuto drops to.
+const DefaultUser = "codeforge"
+
+// Config selects the strategy.
+type Config struct {
+	Mode string // auto (default), current, fixed
+	User string // fixed: user name, or a numeric uid
+	UID  int    // fixed: uid when User is empty
+	GID  int    // fixed: gid; 0 = the user's primary group (or UID)
+
+	UIDMin int // ephemeral: first uid handed out
+	UIDMax int // ephemeral: last uid handed out
+}
+
+// Identity is the Unix user a CLI process runs as.
+type Identity struct {
+	Username string // empty for a uid without a passwd entry
+	UID      int
+	GID      int
+	HomeDir  string // HOME for the CLI
+	// Ephemeral identities are recycled: files handed to them are private
+	// (0700 workspace root) and taken back on Release.
+	Ephemeral bool
+}
+
+// Spec is the user argument for gosu: the name when known, else uid:gid.
+func (id *Identity) Spec() string {
+	if id.Username != "" {
+		return id.Username
+	}
+	return fmt.Sprintf("%d:%d", id.UID, id.GID)
+}
+
+// Strategy resolves the user a session's CLI runs as.
+type Strategy interface {
+	// Identity returns the user the CLI of sessionID runs as; nil runs it as
+	// the server's own user, with no privilege drop and no chown.
+	Identity(sessionID string) (*Identity, error)
+	// Release ends the session's run: paths handed to its identity are
+	// taken back by the server's user and the identity is freed. No-op for
+	// identities shared between sessions.
+	Release(sessionID string, paths ...string) error
+}
+
+// New builds the strategy for cfg. Users are looked up once, here, so a
+// misconfiguration fails at startup instead of on the first session.
+func New(cfg Config) (Strategy, error) {
+	switch cfg.Mode {
+	case "", ModeAuto:
+		return static{id: auto()}, nil
+	case ModeCurrent:
+		return static{current: true}, nil
+	case ModeFixed:
+		id, err := fixed(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return static{id: id}, nil
+	case ModeEphemeral:
+		return newEphemeral(cfg)
+	default:
+		return nil, fmt.Errorf("unknown run-as mode %q (want auto, current, fixed or ephemeral)", cfg.Mode)
+	}
+}
+
+// Default is the ModeAuto strategy.
+func Default() Strategy {
+	return static{id: auto()}
+}
+
+// static runs every session as the same identity.
+type static struct {
+	id      *Identity
+	current bool // ModeCurrent: the server's user was chosen explicitly
+}
+
+func (s static) Identity(string) (*Identity, error) { return s.id, nil }
+
+// IsCurrent reports whether s is ModeCurrent, where running commands as the
+// server's own user — even root — is the operator's explicit choice rather
+// than a fallback for a missing user.
+func IsCurrent(s Strategy) bool {
+	st, ok := s.(static)
+	return ok && st.current
+}
+
+func (s static) Release(string, ...string) error { return nil }
+
+func auto() *Identity {
+	if os.Getuid() != 0 {
+		return nil
+	}
+	u, err := user.Lookup(DefaultUser)
+	if err != nil {
+		return nil
+	}
+	return fromUser(u)
+}
+
+func fixed(cfg Config) (*Identity, error) {
+	var id *Identity
+	switch {
+	case cfg.User != "":
+		u, err := user.Lookup(cfg.User)
+		if err == nil {
+			id = fromUser(u)
+		} else if uid, numErr := strconv.Atoi(cfg.User); numErr == nil {
+			id = byUID(uid)
+		} else {
+			return nil, fmt.Errorf("run-as user %q: %w", cfg.User, err)
+		}
+	case cfg.UID > 0:
+		id = byUID(cfg.UID)
+	default:
+		return nil, fmt.Errorf("run-as mode fixed needs a user or uid")
+	}
+	if cfg.GID > 0 {
+		id.GID = cfg.GID
+	}
+
+	if id.UID == os.Getuid() && id.GID == os.Getgid() {
+		return nil, nil // already that user: nothing to switch
+	}
+	if os.Getuid() != 0 {
+		return nil, fmt.Errorf("run-as mode fixed needs root to switch to uid %d (running as uid %d); use mode current", id.UID, os.Getuid())
+	}
+	return id, nil
+}
+
+func fromUser(u *user.User) *Identity {
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	home := u.HomeDir
+	if home == "" {
+		home = os.TempDir()
+	}
+	return &Identity{Username: u.Username, UID: uid, GID: gid, HomeDir: home}
+}
+
+// byUID looks uid up, falling back to a bare uid without a passwd entry.
+func byUID(uid int) *Identity {
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		return fromUser(u)
+	}
+	return bareUID(uid)
+}
+
+// bareUID is a uid without a passwd entry (e.g. an arbitrary K8s uid). Its
+// group defaults to the uid and HOME to the temp dir, which anyone can write.
+func bareUID(uid int) *Identity {
+	return &Identity{UID: uid, GID: uid, HomeDir: os.TempDir()}
+}
+
+// Chown hands path (recursively) over to id so the CLI can write to it. It is
+// a no-op when id is nil or the server is not root — a non-root server can
+// only create files its own user already owns. An ephemeral identity's path
+// is made private (0700) so other sessions' users cannot read it.
+func Chown(path string, id *Identity) error {
+	if id == nil || os.Getuid() != 0 {
+		return nil
+	}
+	if err := chownTree(path, id.UID, id.GID); err != nil {
+		return err
+	}
+	if id.Ephemeral {
+		return os.Chmod(path, 0o700)
+	}
+	return nil
+}
+
+func chownTree(root string, uid, gid int) error {
+	return filepath.WalkDir(root, func(p string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(p, uid, gid)
+	})
+}
@@ -0,0 +1,112 @@
+package runas
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	self := strconv.Itoa(os.Getuid())
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+		wantNil bool
+	}{
+		{name: "current never switches", cfg: Config{Mode: ModeCurrent}, wantNil: true},
+		{name: "unknown mode", cfg: Config{Mode: "sudo"}, wantErr: true},
+		{name: "fixed needs a user", cfg: Config{Mode: ModeFixed}, wantErr: true},
+		{name: "fixed unknown user name", cfg: Config{Mode: ModeFixed, User: "no-such-user-cf"}, wantErr: true},
+		{name: "fixed as self is a no-op", cfg: Config{Mode: ModeFixed, User: self, GID: os.Getgid()}, wantNil: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			id, err := s.Identity("sess-1")
+			if err != nil {
+				t.Fatalf("Identity: %v", err)
+			}
+			if (id == nil) != tt.wantNil {
+				t.Errorf("Identity = %+v, wantNil %v", id, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestNew_FixedUID(t *testing.T) {
+	s, err := New(Config{Mode: ModeFixed, UID: 64123})
+	if os.Getuid() != 0 {
+		if err == nil {
+			t.Fatal("switching users without root should fail")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	id, _ := s.Identity("sess-1")
+	if id.UID != 64123 || id.GID != 64123 || id.Username != "" || id.HomeDir == "" {
+		t.Errorf("identity = %+v, want bare uid 64123 with a home", id)
+	}
+	if got := id.Spec(); got != "64123:64123" {
+		t.Errorf("Spec = %q", got)
+	}
+}
+
+func TestChown(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "f"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Chown(dir, nil); err != nil {
+		t.Fatalf("Chown(nil) = %v", err)
+	}
+	if os.Getuid() != 0 {
+		t.Skip("chowning to another user needs root")
+	}
+	if err := Chown(dir, &Identity{UID: 64123, GID: 64124}); err != nil {
+		t.Fatalf("Chown: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(dir, "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, gid := ownerOf(info); uid != 64123 || gid != 64124 {
+		t.Errorf("owner = %d:%d, want 64123:64124", uid, gid)
+	}
+}
+
+func ownerOf(info os.FileInfo) (int, int) {
+	st := info.Sys().(*syscall.Stat_t)
+	return int(st.Uid), int(st.Gid)
+}
+
+func TestEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "HOME=/root", "USER=root", "SHELL=/bin/bash"}
+
+	named := Env(env, &Identity{Username: "codeforge", UID: 1000, GID: 1000, HomeDir: "/home/codeforge"})
+	want := []string{"PATH=/usr/bin", "HOME=/home/codeforge", "SHELL=/bin/sh", "USER=codeforge"}
+	if strings.Join(named, ",") != strings.Join(want, ",") {
+		t.Errorf("env = %v, want %v", named, want)
+	}
+
+	bare := Env(env, &Identity{UID: 64123, GID: 64123, HomeDir: "/tmp"})
+	want = []string{"PATH=/usr/bin", "HOME=/tmp", "SHELL=/bin/sh"}
+	if strings.Join(bare, ",") != strings.Join(want, ",") {
+		t.Errorf("env = %v, want %v", bare, want)
+	}
+
+	if got := Env(env, nil); strings.Join(got, ",") != strings.Join(env, ",") {
+		t.Errorf("nil identity changed env: %v", got)
+	}
+}
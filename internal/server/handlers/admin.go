@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/freema/codeforge/internal/worker"
+)
+
+// WorkerPool is the worker pool as seen by the HTTP layer: session cancel,
+// maintenance-mode control and introspection. Implemented by *worker.Pool.
+type WorkerPool interface {
+	Canceller
+	Pause()
+	Resume()
+	Paused() bool
+	ActiveCount() int
+	Snapshot(ctx context.Context) worker.PoolSnapshot
+	Nodes(ctx context.Context) ([]worker.Node, error)
+}
+
+// AdminHandler serves operator-only worker pool management endpoints.
+type AdminHandler struct {
+	pool WorkerPool
+}
+
+// NewAdminHandler creates an admin handler.
+func NewAdminHandler(pool WorkerPool) *AdminHandler {
+	return &AdminHandler{pool: pool}
+}
+
+// ListWorkers handles GET /api/v1/admin/workers.
+// Reports what each worker on this node is doing, the cancel map and the queue lengths.
+func (h *AdminHandler) ListWorkers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.pool.Snapshot(r.Context()))
+}
+
+// ListNodes handles GET /api/v1/admin/nodes.
+// Lists the live servers and runner agents consuming the session queue.
+func (h *AdminHandler) ListNodes(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.pool.Nodes(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"nodes": nodes})
+}
+
+// PauseWorkers handles POST /api/v1/admin/workers/pause.
+// Workers stop dequeuing; in-flight sessions finish and the queue is kept.
+func (h *AdminHandler) PauseWorkers(w http.ResponseWriter, r *http.Request) {
+	h.pool.Pause()
+	h.writePoolState(w)
+}
+
+// ResumeWorkers handles POST /api/v1/admin/workers/resume.
+func (h *AdminHandler) ResumeWorkers(w http.ResponseWriter, r *http.Request) {
+	h.pool.Resume()
+	h.writePoolState(w)
+}
+
+func (h *AdminHandler) writePoolState(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"paused":          h.pool.Paused(),
+		"active_sessions": h.pool.ActiveCount(),
+	})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/freema/codeforge/internal/worker"
+)
+
+type fakePool struct {
+	paused bool
+	active int
+}
+
+func (f *fakePool) Cancel(string) error { return nil }
+func (f *fakePool) Pause()              { f.paused = true }
+func (f *fakePool) Resume()             { f.paused = false }
+func (f *fakePool) Paused() bool        { return f.paused }
+func (f *fakePool) ActiveCount() int    { return f.active }
+func (f *fakePool) Snapshot(context.Context) worker.PoolSnapshot {
+	return worker.PoolSnapshot{
+		Paused:         f.paused,
+		Concurrency:    2,
+		ActiveSessions: f.active,
+		Workers: []worker.WorkerState{
+			{ID: 0, State: "busy", SessionID: "sess-1"},
+			{ID: 1, State: "idle"},
+		},
+		Cancellable: []string{"sess-1"},
+		Queue:       &worker.QueueStats{Pending: 4, Processing: 1},
+	}
+}
+
+func (f *fakePool) Nodes(context.Context) ([]worker.Node, error) {
+	return []worker.Node{
+		{ID: "api-1", Role: worker.RoleServer, Concurrency: 2, Active: f.active},
+		{ID: "vm-7", Role: worker.RoleAgent, Concurrency: 8, Processing: 3},
+	}, nil
+}
+
+func TestAdminHandler_PauseResume(t *testing.T) {
+	pool := &fakePool{active: 2}
+	h := NewAdminHandler(pool)
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantPaused bool
+	}{
+		{"pause", h.PauseWorkers, true},
+		{"pause is idempotent", h.PauseWorkers, true},
+		{"resume", h.ResumeWorkers, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			var body struct {
+				Paused         bool `json:"paused"`
+				ActiveSessions int  `json:"active_sessions"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Paused != tt.wantPaused || pool.paused != tt.wantPaused {
+				t.Errorf("paused = %v (pool %v), want %v", body.Paused, pool.paused, tt.wantPaused)
+			}
+			if body.ActiveSessions != 2 {
+				t.Errorf("active_sessions = %d, want 2", body.ActiveSessions)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_ListWorkers(t *testing.T) {
+	h := NewAdminHandler(&fakePool{active: 1})
+
+	rec := httptest.NewRecorder()
+	h.ListWorkers(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var body worker.PoolSnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Workers) != 2 || body.Workers[0].SessionID != "sess-1" || body.Workers[1].State != "idle" {
+		t.Errorf("workers = %+v", body.Workers)
+	}
+	if body.Queue == nil || body.Queue.Pending != 4 || len(body.Cancellable) != 1 {
+		t.Errorf("snapshot = %+v", body)
+	}
+}
+
+func TestAdminHandler_ListNodes(t *testing.T) {
+	h := NewAdminHandler(&fakePool{active: 1})
+
+	rec := httptest.NewRecorder()
+	h.ListNodes(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var body struct {
+		Nodes []worker.Node `json:"nodes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Nodes) != 2 || body.Nodes[1].Role != worker.RoleAgent || body.Nodes[1].Processing != 3 {
+		t.Errorf("nodes = %+v", body.Nodes)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/freema/codeforge/internal/audit"
+)
+
+// AuditHandler exposes the audit trail (prompt policy decisions). Operator-only.
+type AuditHandler struct {
+	store *audit.Store
+}
+
+// NewAuditHandler creates an audit handler.
+func NewAuditHandler(store *audit.Store) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// List handles GET /api/v1/admin/audit?session_id=&tenant_id=&decision=&limit=.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit > 1000 {
+		limit = 1000
+	}
+	entries, err := h.store.List(r.Context(), audit.Filter{
+		SessionID: q.Get("session_id"),
+		TenantID:  q.Get("tenant_id"),
+		Decision:  q.Get("decision"),
+		Limit:     limit,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+}
@@ -20,6 +20,8 @@ type HealthHandler struct {
 	startTime    time.Time
 	version      string
 	ready        *atomic.Bool
+	pool         interface{ Paused() bool } // optional, reports maintenance mode
+	redisMonitor interface{ Up() bool }     // optional, Redis circuit breaker
 }
 
 // NewHealthHandler creates a health handler.
@@ -41,6 +43,17 @@ func (h *HealthHandler) SetReady(v bool) {
 	h.ready.Store(v)
 }
 
+// SetPool wires the worker pool so /health reports maintenance mode.
+func (h *HealthHandler) SetPool(pool interface{ Paused() bool }) {
+	h.pool = pool
+}
+
+// SetRedisMonitor wires the Redis circuit breaker so /ready fails while
+// Redis is unreachable.
+func (h *HealthHandler) SetRedisMonitor(m interface{ Up() bool }) {
+	h.redisMonitor = m
+}
+
 type healthResponse struct {
 	Status               string  `json:"status"`
 	Redis                string  `json:"redis"`
@@ -48,6 +61,7 @@ type healthResponse struct {
 	Version              string  `json:"version"`
 	Uptime               string  `json:"uptime"`
 	WorkspaceDiskUsageMB float64 `json:"workspace_disk_usage_mb"`
+	WorkersPaused        bool    `json:"workers_paused"`
 }
 
 // Health checks Redis and SQLite connectivity and returns system health.
@@ -80,6 +94,10 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 		resp.WorkspaceDiskUsageMB = float64(totalBytes) / (1024 * 1024)
 	}
 
+	if h.pool != nil {
+		resp.WorkersPaused = h.pool.Paused()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	_ = json.NewEncoder(w).Encode(resp)
@@ -123,7 +141,8 @@ func (h *HealthHandler) AuthVerify(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// Ready returns 200 if the server is accepting traffic, 503 during shutdown.
+// Ready returns 200 if the server is accepting traffic, 503 during shutdown
+// and while the circuit breaker reports Redis down.
 func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
 	if !h.ready.Load() {
 		w.Header().Set("Content-Type", "application/json")
@@ -131,6 +150,12 @@ func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(map[string]string{"status": "shutting_down"})
 		return
 	}
+	if h.redisMonitor != nil && !h.redisMonitor.Up() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "redis_unavailable"})
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// IterationHandler serves per-iteration views of a session.
+type IterationHandler struct {
+	service *session.IterationService
+}
+
+// NewIterationHandler creates an iteration handler.
+func NewIterationHandler(service *session.IterationService) *IterationHandler {
+	return &IterationHandler{service: service}
+}
+
+// List handles GET /api/v1/sessions/{sessionID}/iterations?limit=&offset=.
+func (h *IterationHandler) List(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	limit, ok := countParam(w, r, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := countParam(w, r, "offset")
+	if !ok {
+		return
+	}
+
+	page, err := h.service.List(r.Context(), sessionID, limit, offset)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// Get handles GET /api/v1/sessions/{sessionID}/iterations/{n}.
+func (h *IterationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	n, ok := iterationParam(w, r)
+	if !ok {
+		return
+	}
+
+	iter, err := h.service.Get(r.Context(), sessionID, n)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, iter)
+}
+
+// Diff handles GET /api/v1/sessions/{sessionID}/iterations/{n}/diff.
+// Returns JSON by default, or the raw patch with ?format=patch.
+func (h *IterationHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	n, ok := iterationParam(w, r)
+	if !ok {
+		return
+	}
+
+	diff, err := h.service.Diff(r.Context(), sessionID, n)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "patch" {
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(diff.Diff))
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
+
+// Revert handles POST /api/v1/sessions/{sessionID}/iterations/{n}/revert.
+func (h *IterationHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	n, ok := iterationParam(w, r)
+	if !ok {
+		return
+	}
+
+	iter, err := h.service.Revert(r.Context(), sessionID, n)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, iter)
+}
+
+// iterationParam parses the {n} path parameter, writing a 400 on failure.
+func iterationParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, "iteration must be a positive integer")
+		return 0, false
+	}
+	return n, true
+}
+
+// countParam parses an optional non-negative integer query parameter (empty =
+// 0), writing a 400 on failure.
+func countParam(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		writeError(w, http.StatusBadRequest, name+" must be a non-negative integer")
+		return 0, false
+	}
+	return n, true
+}
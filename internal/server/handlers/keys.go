@@ -6,12 +6,15 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/freema/codeforge/internal/apperror"
 	"github.com/freema/codeforge/internal/keys"
+	"github.com/freema/codeforge/internal/policy"
 )
 
 // KeyHandler handles key-related HTTP endpoints.
 type KeyHandler struct {
-	registry keys.Registry
+	registry  keys.Registry
+	urlPolicy *policy.CallbackPolicy // optional, nil = api_base_url may point anywhere
 }
 
 // NewKeyHandler creates a new key handler.
@@ -19,21 +22,28 @@ func NewKeyHandler(registry keys.Registry) *KeyHandler {
 	return &KeyHandler{registry: registry}
 }
 
+// SetURLPolicy guards api_base_url with the callback SSRF policy: the server
+// sends the key's token there.
+func (h *KeyHandler) SetURLPolicy(p *policy.CallbackPolicy) {
+	h.urlPolicy = p
+}
+
 // Create handles POST /api/v1/keys.
 func (h *KeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name     string `json:"name" validate:"required"`
-		Provider string `json:"provider" validate:"required"`
-		Token    string `json:"token" validate:"required"`
-		Scope    string `json:"scope,omitempty"`
-		BaseURL  string `json:"base_url,omitempty"`
+		Name       string `json:"name" validate:"required"`
+		Provider   string `json:"provider" validate:"required"`
+		Token      string `json:"token" validate:"required"`
+		Scope      string `json:"scope,omitempty"`
+		BaseURL    string `json:"base_url,omitempty"`
+		APIBaseURL string `json:"api_base_url,omitempty" validate:"omitempty,url"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 	if err := validate.Struct(req); err != nil {
-		writeError(w, http.StatusBadRequest, "name, provider, and token are required")
+		writeError(w, http.StatusBadRequest, "name, provider, and token are required; api_base_url must be a URL")
 		return
 	}
 	if !validName.MatchString(req.Name) {
@@ -41,12 +51,22 @@ func (h *KeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.APIBaseURL != "" {
+		if err := h.urlPolicy.Check(r.Context(), req.APIBaseURL); err != nil {
+			appErr := apperror.Validation("api_base_url is not allowed: %v", err)
+			appErr.Fields = map[string]string{"api_base_url": err.Error()}
+			writeAppError(w, appErr)
+			return
+		}
+	}
+
 	key := keys.Key{
-		Name:     req.Name,
-		Provider: req.Provider,
-		Token:    req.Token,
-		Scope:    req.Scope,
-		BaseURL:  req.BaseURL,
+		Name:       req.Name,
+		Provider:   req.Provider,
+		Token:      req.Token,
+		Scope:      req.Scope,
+		BaseURL:    req.BaseURL,
+		APIBaseURL: req.APIBaseURL,
 	}
 
 	if err := h.registry.Create(r.Context(), key); err != nil {
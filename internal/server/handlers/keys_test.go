@@ -12,6 +12,7 @@ import (
 
 	"github.com/freema/codeforge/internal/apperror"
 	"github.com/freema/codeforge/internal/keys"
+	"github.com/freema/codeforge/internal/policy"
 )
 
 // mockRegistry implements keys.Registry for testing.
@@ -74,6 +75,15 @@ func (m *mockRegistry) ResolveFullByName(_ context.Context, name string) (string
 	return "", "", "", nil
 }
 
+func (m *mockRegistry) APIBaseURL(_ context.Context, name string) string {
+	for _, k := range m.keys {
+		if k.Name == name {
+			return k.APIBaseURL
+		}
+	}
+	return ""
+}
+
 func TestKeyHandler_Create(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -144,6 +154,30 @@ func TestKeyHandler_Create_Duplicate(t *testing.T) {
 	}
 }
 
+func TestKeyHandler_Create_APIBaseURLPolicy(t *testing.T) {
+	pol, err := policy.NewCallbackPolicy(false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := &mockRegistry{}
+	h := NewKeyHandler(reg)
+	h.SetURLPolicy(pol)
+
+	body := `{"name":"gh","provider":"github","token":"tok","api_base_url":"http://169.254.169.254/latest"}`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/keys", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.Create(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status: got %d, want 400, body: %s", w.Code, w.Body.String())
+	}
+	if len(reg.created) != 0 {
+		t.Errorf("key should not be stored, got %d", len(reg.created))
+	}
+}
+
 func TestKeyHandler_List(t *testing.T) {
 	reg := &mockRegistry{
 		keys: []keys.Key{
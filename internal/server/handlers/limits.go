@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/freema/codeforge/internal/server/middleware"
+	"github.com/freema/codeforge/internal/tenant"
+)
+
+// LimitsHandler reports the caller's rate-limit and quota usage so clients can
+// throttle themselves instead of running into 429s.
+type LimitsHandler struct {
+	limiter        *middleware.RateLimiter // nil when disabled
+	tenantService  *tenant.Service         // nil without the subscription model
+	sessionCounter tenantSessionCounter
+}
+
+// NewLimitsHandler creates a limits handler. limiter and tenantService may be nil.
+func NewLimitsHandler(limiter *middleware.RateLimiter, tenantService *tenant.Service, counter tenantSessionCounter) *LimitsHandler {
+	return &LimitsHandler{limiter: limiter, tenantService: tenantService, sessionCounter: counter}
+}
+
+// Get handles GET /api/v1/limits.
+func (h *LimitsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	out := middleware.Limits{Role: "operator"}
+
+	if h.limiter != nil {
+		st, err := h.limiter.Status(r)
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, "could not read rate limit usage, try again")
+			return
+		}
+		out.RateLimit = &st
+	}
+
+	if t := middleware.TenantFromContext(ctx); t != nil && h.tenantService != nil {
+		out.Role = "tenant"
+		daily, err := h.tenantService.Store().CountDailySessions(ctx, t.ID)
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, "could not read session quota, try again")
+			return
+		}
+		active := 0
+		if h.sessionCounter != nil {
+			if active, err = h.sessionCounter.CountActiveByTenant(ctx, t.ID); err != nil {
+				writeError(w, http.StatusServiceUnavailable, "could not read concurrency usage, try again")
+				return
+			}
+		}
+		spent, err := h.tenantService.Store().MonthlyCost(ctx, t.ID, time.Now())
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, "could not read monthly spend, try again")
+			return
+		}
+		// Same semantics as the checks on session creation: a negative daily
+		// limit and a zero concurrency limit are unlimited.
+		out.Tenant = &tenant.Limits{
+			Tier:                   t.Tier,
+			SessionsPerDay:         tenant.NewQuota(t.MaxSessionsPerDay, daily, t.MaxSessionsPerDay < 0),
+			ConcurrentSessions:     tenant.NewQuota(t.MaxConcurrentSessions, active, t.MaxConcurrentSessions <= 0),
+			MonthlyBudget:          tenant.NewBudget(t.MaxBudgetUSDPerMonth, spent),
+			MaxBudgetUSDPerSession: t.MaxBudgetUSDPerSession,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/freema/codeforge/internal/server/middleware"
+	"github.com/freema/codeforge/internal/tenant"
+)
+
+func getLimits(t *testing.T, h *LimitsHandler, tnt *tenant.Tenant) middleware.Limits {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/limits", nil)
+	if tnt != nil {
+		req = req.WithContext(middleware.ContextWithTenant(req.Context(), tnt))
+	}
+	rec := httptest.NewRecorder()
+	h.Get(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var body middleware.Limits
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return body
+}
+
+func TestLimits_Operator(t *testing.T) {
+	body := getLimits(t, NewLimitsHandler(nil, nil, nil), nil)
+	if body.Role != "operator" || body.RateLimit != nil || body.Tenant != nil {
+		t.Errorf("limits = %+v, want a bare operator view", body)
+	}
+}
+
+func TestLimits_Tenant(t *testing.T) {
+	th, store := newMeTestHandler(t)
+	tnt := seedMeTenant(t, store)
+
+	tests := []struct {
+		name           string
+		perDay, concur int
+		active         int
+		wantDaily      tenant.Quota
+		wantConcurrent tenant.Quota
+	}{
+		{
+			name: "limited", perDay: 50, concur: 2, active: 1,
+			wantDaily:      tenant.Quota{Limit: 50, Remaining: 50},
+			wantConcurrent: tenant.Quota{Limit: 2, Used: 1, Remaining: 1},
+		},
+		{
+			name: "over the limit", perDay: 0, concur: 2, active: 3,
+			wantDaily:      tenant.Quota{Limit: 0, Remaining: 0},
+			wantConcurrent: tenant.Quota{Limit: 2, Used: 3, Remaining: 0},
+		},
+		{
+			name: "unlimited", perDay: -1, concur: 0, active: 4,
+			wantDaily:      tenant.Quota{Unlimited: true},
+			wantConcurrent: tenant.Quota{Used: 4, Unlimited: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tnt.MaxSessionsPerDay, tnt.MaxConcurrentSessions = tt.perDay, tt.concur
+			h := NewLimitsHandler(nil, th.service, fakeCounter{active: tt.active})
+
+			body := getLimits(t, h, tnt)
+			if body.Role != "tenant" || body.Tenant == nil || body.Tenant.Tier != tnt.Tier {
+				t.Fatalf("limits = %+v", body)
+			}
+			if body.Tenant.SessionsPerDay != tt.wantDaily {
+				t.Errorf("sessions_per_day = %+v, want %+v", body.Tenant.SessionsPerDay, tt.wantDaily)
+			}
+			if body.Tenant.ConcurrentSessions != tt.wantConcurrent {
+				t.Errorf("concurrent_sessions = %+v, want %+v", body.Tenant.ConcurrentSessions, tt.wantConcurrent)
+			}
+		})
+	}
+}
+
+func TestLimits_TenantBudget(t *testing.T) {
+	th, store := newMeTestHandler(t)
+	tnt := seedMeTenant(t, store)
+	if err := store.LogUsage(context.Background(), &tenant.UsageLog{TenantID: tnt.ID, SessionID: "s1", CLI: "claude-code", EstimatedCostUSD: 3}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewLimitsHandler(nil, th.service, nil)
+
+	tnt.MaxBudgetUSDPerMonth = 10
+	if got := getLimits(t, h, tnt).Tenant.MonthlyBudget; got != (tenant.Budget{LimitUSD: 10, SpentUSD: 3, RemainingUSD: 7}) {
+		t.Errorf("monthly_budget = %+v, want 7 of 10 remaining", got)
+	}
+
+	tnt.MaxBudgetUSDPerMonth = 0
+	if got := getLimits(t, h, tnt).Tenant.MonthlyBudget; got != (tenant.Budget{SpentUSD: 3, Unlimited: true}) {
+		t.Errorf("monthly_budget = %+v, want spend only", got)
+	}
+}
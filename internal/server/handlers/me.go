@@ -60,6 +60,7 @@ func (h *TenantHandler) MeUsage(w http.ResponseWriter, r *http.Request) {
 			"max_sessions_per_day":       t.MaxSessionsPerDay,
 			"max_concurrent_sessions":    t.MaxConcurrentSessions,
 			"max_budget_usd_per_session": t.MaxBudgetUSDPerSession,
+			"max_budget_usd_per_month":   t.MaxBudgetUSDPerMonth,
 			"allowed_clis":               t.AllowedCLIs,
 			"allowed_models":             t.AllowedModels,
 		},
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/tool/runner"
+)
+
+// ProjectHandler manages per-repository session defaults. Operator-only.
+type ProjectHandler struct {
+	store       *session.ProjectStore
+	cliRegistry *runner.Registry
+}
+
+// NewProjectHandler creates a project settings handler.
+func NewProjectHandler(store *session.ProjectStore, cliRegistry *runner.Registry) *ProjectHandler {
+	return &ProjectHandler{store: store, cliRegistry: cliRegistry}
+}
+
+// projectRepo resolves the {owner}/{repo} path parameters to the store key.
+// Nested GitLab groups arrive URL-encoded in {owner}.
+func projectRepo(r *http.Request) (string, error) {
+	owner, err := url.PathUnescape(chi.URLParam(r, "owner"))
+	if err != nil || owner == "" {
+		return "", errors.New("invalid owner")
+	}
+	repo := strings.TrimSuffix(chi.URLParam(r, "repo"), ".git")
+	return strings.ToLower(owner + "/" + repo), nil
+}
+
+// List handles GET /api/v1/projects.
+func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.store.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"projects": projects,
+		"total":    len(projects),
+	})
+}
+
+// Get handles GET /api/v1/projects/{owner}/{repo}.
+func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
+	repo, err := projectRepo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ps, err := h.store.Get(r.Context(), repo)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ps)
+}
+
+// Put handles PUT /api/v1/projects/{owner}/{repo}, replacing the settings.
+func (h *ProjectHandler) Put(w http.ResponseWriter, r *http.Request) {
+	repo, err := projectRepo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var ps session.ProjectSettings
+	if err := json.NewDecoder(r.Body).Decode(&ps); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := validate.Struct(ps); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			fields := make(map[string]string)
+			for _, e := range validationErrs {
+				fields[e.Field()] = formatValidationError(e)
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":  "validation_error",
+				"fields": fields,
+			})
+			return
+		}
+		writeError(w, http.StatusBadRequest, "validation failed")
+		return
+	}
+	if ps.CLI != "" {
+		if _, err := h.cliRegistry.Get(ps.CLI); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":  "validation_error",
+				"fields": map[string]string{"cli": fmt.Sprintf("unknown CLI: %s", ps.CLI)},
+			})
+			return
+		}
+	}
+
+	ps.Repo = repo
+	if err := h.store.Put(r.Context(), &ps); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, ps)
+}
+
+// Delete handles DELETE /api/v1/projects/{owner}/{repo}.
+func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	repo, err := projectRepo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.store.Delete(r.Context(), repo); err != nil {
+		writeAppError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/freema/codeforge/internal/server/middleware"
+	"github.com/freema/codeforge/internal/session"
+)
+
+// PromptHandler accepts prompts too large to inline in a session request.
+// The body is the raw prompt text; ?partial=true keeps the upload open for
+// further chunks, which makes large uploads resumable.
+type PromptHandler struct {
+	store *session.PromptStore
+}
+
+// NewPromptHandler creates a prompt upload handler.
+func NewPromptHandler(store *session.PromptStore) *PromptHandler {
+	return &PromptHandler{store: store}
+}
+
+// Upload handles POST /api/v1/prompts[?partial=true].
+func (h *PromptHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.readChunk(w, r)
+	if !ok {
+		return
+	}
+	partial := r.URL.Query().Get("partial") == "true"
+	if len(data) == 0 && !partial {
+		writeError(w, http.StatusBadRequest, "prompt body is empty")
+		return
+	}
+
+	up, err := h.store.Create(r.Context(), tenantIDFrom(r), data, !partial)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, up)
+}
+
+// Append handles PATCH /api/v1/prompts/{promptID}[?partial=true].
+// The Upload-Offset header must equal the bytes received so far.
+func (h *PromptHandler) Append(w http.ResponseWriter, r *http.Request) {
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "Upload-Offset header is required")
+		return
+	}
+	data, ok := h.readChunk(w, r)
+	if !ok {
+		return
+	}
+
+	up, err := h.store.Append(r.Context(), chi.URLParam(r, "promptID"), tenantIDFrom(r), offset, data, r.URL.Query().Get("partial") != "true")
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, up)
+}
+
+// Get handles GET /api/v1/prompts/{promptID} — the upload state, whose size is
+// the offset to resume from.
+func (h *PromptHandler) Get(w http.ResponseWriter, r *http.Request) {
+	up, err := h.store.Get(r.Context(), chi.URLParam(r, "promptID"), tenantIDFrom(r))
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, up)
+}
+
+// readChunk reads the request body, rejecting anything over the upload limit.
+func (h *PromptHandler) readChunk(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.store.MaxBytes()))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "prompt exceeds the upload limit")
+			return nil, false
+		}
+		writeError(w, http.StatusBadRequest, "reading request body failed")
+		return nil, false
+	}
+	return data, true
+}
+
+// tenantIDFrom returns the authenticated subscription tenant, or "" for the operator.
+func tenantIDFrom(r *http.Request) string {
+	if tnt := middleware.TenantFromContext(r.Context()); tnt != nil {
+		return tnt.ID
+	}
+	return ""
+}
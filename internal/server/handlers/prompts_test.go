@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// The cases below are rejected before the store is touched, so no Redis is needed.
+func TestPromptHandler_RejectsBadRequests(t *testing.T) {
+	h := NewPromptHandler(session.NewPromptStore(nil, 8, 0))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     *http.Request
+		want    int
+	}{
+		{"empty body", h.Upload, httptest.NewRequest(http.MethodPost, "/", nil), http.StatusBadRequest},
+		{"too large", h.Upload, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")), http.StatusRequestEntityTooLarge},
+		{"missing offset", h.Append, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader("x")), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, tt.req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
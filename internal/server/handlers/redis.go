@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/session"
+)
+
+const (
+	defaultMemorySample = 50
+	maxMemorySample     = 1000
+)
+
+// memoryFamilies are the key families reported by GET /admin/redis/memory.
+var memoryFamilies = []redisclient.Family{
+	{Name: "state", Pattern: "session:*:state"},
+	{Name: "history", Pattern: "session:*:history"},
+	{Name: "results", Pattern: "session:*:result"},
+	{Name: "iterations", Pattern: "session:*:iterations"},
+	{Name: "transcripts", Pattern: "session:*:transcript"},
+	{Name: "workspaces", Pattern: "workspace:*"},
+	{Name: "ratelimit", Pattern: "ratelimit:*"},
+	{Name: "queues", Pattern: "queue:*"},
+}
+
+// RedisHandler reports Redis memory per key family and evicts old finished
+// sessions for capacity planning. Operator-only.
+type RedisHandler struct {
+	rdb            *redisclient.Client
+	sessionService *session.Service
+}
+
+// NewRedisHandler creates a Redis maintenance handler.
+func NewRedisHandler(rdb *redisclient.Client, sessionService *session.Service) *RedisHandler {
+	return &RedisHandler{rdb: rdb, sessionService: sessionService}
+}
+
+// Memory handles GET /api/v1/admin/redis/memory?sample=50.
+// Counts each family's keys and extrapolates MEMORY USAGE of a random sample.
+func (h *RedisHandler) Memory(w http.ResponseWriter, r *http.Request) {
+	sample := defaultMemorySample
+	if raw := r.URL.Query().Get("sample"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxMemorySample {
+			writeError(w, http.StatusBadRequest, "sample must be between 1 and 1000")
+			return
+		}
+		sample = n
+	}
+
+	used, err := h.rdb.UsedMemory(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	families, err := h.rdb.MemoryUsage(r.Context(), memoryFamilies, sample)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"used_memory": used,
+		"sample":      sample,
+		"families":    families,
+	})
+}
+
+// Purge handles POST /api/v1/admin/redis/purge?older_than=720h&dry_run=true.
+// Drops the Redis keys of sessions finished longer ago than older_than; the
+// sessions stay readable from SQLite.
+func (h *RedisHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("older_than")
+	age, err := time.ParseDuration(raw)
+	if err != nil || age < time.Hour {
+		writeError(w, http.StatusBadRequest, "older_than must be a duration of at least 1h, e.g. 72h")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	res, err := h.sessionService.EvictFinished(r.Context(), time.Now().Add(-age), dryRun)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		OlderThan string `json:"older_than"`
+		*session.EvictResult
+	}{age.String(), res})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedisHandler_InvalidParams(t *testing.T) {
+	h := NewRedisHandler(nil, nil)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+	}{
+		{"sample zero", h.Memory, http.MethodGet, "/?sample=0"},
+		{"sample too large", h.Memory, http.MethodGet, "/?sample=5000"},
+		{"sample not a number", h.Memory, http.MethodGet, "/?sample=all"},
+		{"older_than missing", h.Purge, http.MethodPost, "/"},
+		{"older_than too short", h.Purge, http.MethodPost, "/?older_than=30m"},
+		{"older_than invalid", h.Purge, http.MethodPost, "/?older_than=week"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", rec.Code)
+			}
+		})
+	}
+}
@@ -11,11 +11,12 @@ import (
 // RepoHandler handles repository listing endpoints.
 type RepoHandler struct {
 	keyRegistry keys.Registry
+	providerAPI gitpkg.APIConfig
 }
 
 // NewRepoHandler creates a new repository handler.
-func NewRepoHandler(keyRegistry keys.Registry) *RepoHandler {
-	return &RepoHandler{keyRegistry: keyRegistry}
+func NewRepoHandler(keyRegistry keys.Registry, providerAPI gitpkg.APIConfig) *RepoHandler {
+	return &RepoHandler{keyRegistry: keyRegistry, providerAPI: providerAPI}
 }
 
 // List handles GET /api/v1/repositories.
@@ -36,7 +37,7 @@ func (h *RepoHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	var token string
 	var provider gitpkg.Provider
-	var baseURL string
+	var baseURL, apiBaseURL string
 
 	providerKey := r.URL.Query().Get("provider_key")
 	providerParam := r.URL.Query().Get("provider")
@@ -53,6 +54,7 @@ func (h *RepoHandler) List(w http.ResponseWriter, r *http.Request) {
 		token = t
 		provider = gitpkg.Provider(p)
 		baseURL = u
+		apiBaseURL = h.keyRegistry.APIBaseURL(ctx, providerKey)
 
 	case providerParam != "" && inlineToken != "":
 		// Mode 2: inline token
@@ -69,7 +71,7 @@ func (h *RepoHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repos, err := gitpkg.ListRepos(ctx, provider, token, baseURL, page, perPage)
+	repos, err := gitpkg.ListRepos(ctx, provider, token, gitpkg.APIBase(provider, baseURL, h.providerAPI.BaseURL(baseURL, apiBaseURL)), page, perPage, h.providerAPI.ErrorMaxBytes)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, err.Error())
 		return
@@ -108,7 +110,8 @@ func (h *RepoHandler) ListPullRequests(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := gitpkg.ListPullRequests(ctx, provider, token, baseURL, repo)
+	apiBase := gitpkg.APIBase(provider, baseURL, h.providerAPI.BaseURL(baseURL, h.keyRegistry.APIBaseURL(ctx, providerKey)))
+	prs, err := gitpkg.ListPullRequests(ctx, provider, token, apiBase, repo, h.providerAPI.ErrorMaxBytes)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, err.Error())
 		return
@@ -143,7 +146,8 @@ func (h *RepoHandler) ListBranches(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	branches, err := gitpkg.ListBranches(ctx, provider, token, baseURL, repo)
+	apiBase := gitpkg.APIBase(provider, baseURL, h.providerAPI.BaseURL(baseURL, h.keyRegistry.APIBaseURL(ctx, providerKey)))
+	branches, err := gitpkg.ListBranches(ctx, provider, token, apiBase, repo, h.providerAPI.ErrorMaxBytes)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, err.Error())
 		return
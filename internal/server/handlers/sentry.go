@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/freema/codeforge/internal/httpclient"
 	"github.com/freema/codeforge/internal/keys"
 )
 
@@ -29,9 +29,7 @@ type SentryHandler struct {
 func NewSentryHandler(keyRegistry keys.Registry) *SentryHandler {
 	return &SentryHandler{
 		keyRegistry: keyRegistry,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:      httpclient.New(httpclient.Sentry),
 	}
 }
 
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
@@ -44,13 +46,14 @@ type SessionHandler struct {
 	cliRegistry     *runner.Registry
 	keyRegistry     keys.Registry
 	providerDomains map[string]string
+	providerAPI     gitpkg.APIConfig
 	tenantService   *tenant.Service      // optional, nil = subscription disabled
 	sessionCounter  tenantSessionCounter // optional, nil = concurrency limit not enforced
 }
 
 // NewSessionHandler creates a new session handler.
-func NewSessionHandler(service *session.Service, prService *session.PRService, canceller Canceller, cliRegistry *runner.Registry, keyRegistry keys.Registry, providerDomains map[string]string, tenantService *tenant.Service) *SessionHandler {
-	h := &SessionHandler{service: service, prService: prService, canceller: canceller, cliRegistry: cliRegistry, keyRegistry: keyRegistry, providerDomains: providerDomains, tenantService: tenantService}
+func NewSessionHandler(service *session.Service, prService *session.PRService, canceller Canceller, cliRegistry *runner.Registry, keyRegistry keys.Registry, providerDomains map[string]string, providerAPI gitpkg.APIConfig, tenantService *tenant.Service) *SessionHandler {
+	h := &SessionHandler{service: service, prService: prService, canceller: canceller, cliRegistry: cliRegistry, keyRegistry: keyRegistry, providerDomains: providerDomains, providerAPI: providerAPI, tenantService: tenantService}
 	if service != nil {
 		h.sessionCounter = service
 	}
@@ -90,6 +93,46 @@ func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ListByRepo handles GET /api/v1/repos/{owner}/{repo}/sessions?status=&limit=&offset=.
+// GitLab subgroups are passed URL-encoded in {owner} (group%2Fsubgroup).
+func (h *SessionHandler) ListByRepo(w http.ResponseWriter, r *http.Request) {
+	owner, err := url.PathUnescape(chi.URLParam(r, "owner"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid owner")
+		return
+	}
+	repo := strings.TrimSuffix(chi.URLParam(r, "repo"), ".git")
+
+	opts := session.ListOptions{
+		Status: r.URL.Query().Get("status"),
+	}
+	if tnt := middleware.TenantFromContext(r.Context()); tnt != nil {
+		opts.TenantID = tnt.ID
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			opts.Limit = n
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			opts.Offset = n
+		}
+	}
+
+	sessions, total, err := h.service.ListByRepo(r.Context(), owner, repo, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"repository": strings.ToLower(owner + "/" + repo),
+		"sessions":   sessions,
+		"total":      total,
+	})
+}
+
 // Create handles POST /api/v1/sessions.
 func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req session.CreateSessionRequest
@@ -137,6 +180,9 @@ func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Fill from project defaults first so the effective config is validated.
+	h.service.ApplyDefaults(r.Context(), &req)
+
 	// Validate CLI name against registry
 	if req.Config != nil && req.Config.CLI != "" {
 		if _, err := h.cliRegistry.Get(req.Config.CLI); err != nil {
@@ -148,6 +194,14 @@ func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.Config != nil && !runner.ValidClaudeBackend(req.Config.AIBackend) {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":  "validation_error",
+			"fields": map[string]string{"ai_backend": fmt.Sprintf("unknown AI backend: %s", req.Config.AIBackend)},
+		})
+		return
+	}
+
 	// Subscription tenants: enforce tier limits + assign a managed key from the pool.
 	// req.TenantID is json:"-" so it can only be set server-side by applyTenant.
 	if tnt := middleware.TenantFromContext(r.Context()); tnt != nil {
@@ -155,6 +209,14 @@ func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
 			writeError(w, status, msg)
 			return
 		}
+		if !h.checkTenantBudget(w, r, tnt) {
+			return
+		}
+	}
+
+	if err := h.service.CheckQueue(r.Context()); err != nil {
+		writeAppError(w, err)
+		return
 	}
 
 	t, err := h.service.Create(r.Context(), req)
@@ -163,11 +225,15 @@ func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
+	resp := map[string]interface{}{
 		"id":         t.ID,
 		"status":     t.Status,
 		"created_at": t.CreatedAt,
-	})
+	}
+	if t.ScheduledAt != nil {
+		resp["scheduled_at"] = t.ScheduledAt
+	}
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 // OwnershipMiddleware enforces tenant ownership of a session for any route with a
@@ -184,7 +250,7 @@ func (h *SessionHandler) OwnershipMiddleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
-		t, err := h.service.Get(r.Context(), sessionID)
+		t, err := h.service.Get(r.Context(), sessionID, session.IncludeDeleted())
 		if err != nil {
 			writeAppError(w, err)
 			return
@@ -197,6 +263,25 @@ func (h *SessionHandler) OwnershipMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// checkTenantBudget refuses new work with 409 budget_exceeded once a tenant
+// spent its monthly budget. Like the other tier checks it fails closed when
+// the spend cannot be read. Reports whether the request may proceed.
+func (h *SessionHandler) checkTenantBudget(w http.ResponseWriter, r *http.Request, tnt *tenant.Tenant) bool {
+	if h.tenantService == nil {
+		return true
+	}
+	err := h.tenantService.CheckBudget(r.Context(), tnt.ID)
+	switch {
+	case err == nil:
+		return true
+	case apperror.HTTPStatus(err) == http.StatusConflict:
+		writeAppError(w, err)
+	default:
+		writeError(w, http.StatusServiceUnavailable, "could not verify monthly budget, try again")
+	}
+	return false
+}
+
 // applyTenant enforces a subscription tenant's tier limits and assigns a managed
 // API key from the operator pool when the request brings no BYOK key. Returns a
 // non-zero HTTP status + message on rejection, or (0, "") to proceed.
@@ -300,8 +385,12 @@ func stringInJSONList(list, target string) bool {
 	return false
 }
 
+// maxWait caps ?wait= so long-poll requests finish inside the 60s route timeout.
+const maxWait = 55 * time.Second
+
 // Get handles GET /api/v1/sessions/{sessionID}.
-// Supports ?include=iterations to load full iteration history.
+// Supports ?include=iterations to load full iteration history and ?wait=30s
+// to long-poll until the session reaches a terminal state.
 func (h *SessionHandler) Get(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "sessionID")
 	if sessionID == "" {
@@ -309,23 +398,96 @@ func (h *SessionHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, err := h.service.Get(r.Context(), sessionID)
+	wait, err := parseWait(r.URL.Query().Get("wait"))
 	if err != nil {
-		writeAppError(w, err)
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Load iterations if requested
-	if r.URL.Query().Get("include") == "iterations" {
-		iterations, err := h.service.GetIterations(r.Context(), sessionID)
-		if err == nil {
-			t.Iterations = iterations
+	includeIterations := r.URL.Query().Get("include") == "iterations"
+
+	var t *session.Session
+	switch {
+	case wait > 0:
+		t, err = h.service.WaitForTerminal(r.Context(), sessionID, wait)
+		if err == nil && includeIterations {
+			t.Iterations, err = h.service.GetIterations(r.Context(), sessionID)
 		}
+	case includeIterations:
+		t, err = h.service.Get(r.Context(), sessionID, session.WithIterations())
+	default:
+		t, err = h.service.Get(r.Context(), sessionID)
+	}
+	if err != nil {
+		writeAppError(w, err)
+		return
 	}
 
 	writeJSON(w, http.StatusOK, t)
 }
 
+// parseWait parses the ?wait= long-poll duration; values above maxWait are clamped.
+func parseWait(v string) (time.Duration, error) {
+	d, err := parseDurationParam("wait", v)
+	return min(d, maxWait), err
+}
+
+// parseDurationParam parses a query parameter given as a Go duration ("30s")
+// or a bare number of seconds. Empty means zero.
+func parseDurationParam(name, v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		n, aerr := strconv.Atoi(v)
+		if aerr != nil {
+			return 0, fmt.Errorf("%s must be a duration like 30s", name)
+		}
+		d = time.Duration(n) * time.Second
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+	return d, nil
+}
+
+// Transcript handles GET /api/v1/sessions/{sessionID}/transcript.
+// Supports ?iteration=N to return a single iteration.
+func (h *SessionHandler) Transcript(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	iteration := 0
+	if v := r.URL.Query().Get("iteration"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "iteration must be a positive integer")
+			return
+		}
+		iteration = n
+	}
+
+	if _, err := h.service.Get(r.Context(), sessionID); err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	transcripts, err := h.service.GetTranscripts(r.Context(), sessionID, iteration)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"session_id":  sessionID,
+		"transcripts": transcripts,
+	})
+}
+
 // Instruct handles POST /api/v1/sessions/{sessionID}/instruct.
 func (h *SessionHandler) Instruct(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "sessionID")
@@ -334,24 +496,52 @@ func (h *SessionHandler) Instruct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Prompt string `json:"prompt" validate:"required,max=102400"`
-	}
+	var req session.InstructRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 	if err := validate.Struct(req); err != nil {
-		writeError(w, http.StatusBadRequest, "prompt is required and must be under 100KB")
+		writeError(w, http.StatusBadRequest, "prompt (or prompt_ref) is required and must be under 100KB; config overrides must not be negative")
+		return
+	}
+
+	prompt, err := h.service.ResolvePrompt(r.Context(), req.Prompt, req.PromptRef, tenantIDFrom(r))
+	if err != nil {
+		writeAppError(w, err)
 		return
 	}
 
-	t, err := h.service.Instruct(r.Context(), sessionID, req.Prompt)
+	if tnt := middleware.TenantFromContext(r.Context()); tnt != nil {
+		if req.Config != nil {
+			if status, msg := applyTenantOverrides(req.Config, tnt); status != 0 {
+				writeError(w, status, msg)
+				return
+			}
+		}
+		if !h.checkTenantBudget(w, r, tnt) {
+			return
+		}
+	}
+
+	t, err := h.service.InstructWithConfig(r.Context(), sessionID, prompt, req.Config)
 	if err != nil {
 		writeAppError(w, err)
 		return
 	}
 
+	// Another instruction is in flight: the follow-up runs after it.
+	if t.QueuePosition > 0 {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"id":             t.ID,
+			"status":         t.Status,
+			"iteration":      t.Iteration,
+			"queued":         true,
+			"queue_position": t.QueuePosition,
+		})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"id":        t.ID,
 		"status":    t.Status,
@@ -359,6 +549,143 @@ func (h *SessionHandler) Instruct(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Await handles POST /api/v1/sessions/{sessionID}/await?ttl=24h.
+// Parks a completed session in awaiting_instruction, keeping its workspace
+// until the hold expires (ttl defaults to sessions.await_ttl).
+func (h *SessionHandler) Await(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	ttl, err := parseDurationParam("ttl", r.URL.Query().Get("ttl"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t, err := h.service.Await(r.Context(), sessionID, ttl)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":               t.ID,
+		"status":           t.Status,
+		"iteration":        t.Iteration,
+		"await_expires_at": t.AwaitExpiresAt,
+	})
+}
+
+// applyTenantOverrides holds instruct overrides to the tenant's tier: the
+// model must be allowed and the budget stays within the per-session cap.
+func applyTenantOverrides(cfg *session.IterationConfig, tnt *tenant.Tenant) (int, string) {
+	if tnt.AllowedModels != nil && cfg.AIModel != "" && !stringInJSONList(*tnt.AllowedModels, cfg.AIModel) {
+		return http.StatusForbidden, fmt.Sprintf("model %q is not allowed for the %q subscription tier", cfg.AIModel, tnt.Tier)
+	}
+	if tnt.MaxBudgetUSDPerSession > 0 && cfg.MaxBudgetUSD > tnt.MaxBudgetUSDPerSession {
+		cfg.MaxBudgetUSD = tnt.MaxBudgetUSDPerSession
+	}
+	return 0, ""
+}
+
+// Prioritize handles POST /api/v1/sessions/{sessionID}/prioritize.
+func (h *SessionHandler) Prioritize(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	previous, err := h.service.Prioritize(r.Context(), sessionID)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                sessionID,
+		"position":          1,
+		"previous_position": previous,
+	})
+}
+
+// Retain handles POST /api/v1/sessions/{sessionID}/retain?ttl=720h.
+// Extends the expiry of the session's stored state, result and history.
+func (h *SessionHandler) Retain(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	ttl, err := parseDurationParam("ttl", r.URL.Query().Get("ttl"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ttl == 0 {
+		writeError(w, http.StatusBadRequest, "ttl is required")
+		return
+	}
+
+	expiresAt, err := h.service.Retain(r.Context(), sessionID, ttl)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"id":          sessionID,
+		"ttl_seconds": int(ttl.Seconds()),
+	}
+	if !expiresAt.IsZero() {
+		resp["expires_at"] = expiresAt
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Delete handles DELETE /api/v1/sessions/{sessionID}. The session is only
+// hidden (lists skip it, GET answers 410) and can be restored until it is
+// purged after the grace period.
+func (h *SessionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	purgeAfter, err := h.service.Delete(r.Context(), sessionID)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":          sessionID,
+		"deleted":     true,
+		"purge_after": purgeAfter,
+	})
+}
+
+// Restore handles POST /api/v1/sessions/{sessionID}/restore.
+func (h *SessionHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	t, err := h.service.Restore(r.Context(), sessionID)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
 // Cancel handles POST /api/v1/sessions/{sessionID}/cancel.
 func (h *SessionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "sessionID")
@@ -374,17 +701,21 @@ func (h *SessionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Queued but not yet picked up — cancel directly; the stale queue entry
-	// is skipped by the worker's shouldProcess guard.
-	if t.Status == session.StatusPending {
-		if err := h.service.UpdateStatus(r.Context(), sessionID, session.StatusCanceled); err != nil {
+	// Queued or scheduled but not yet picked up — drop it from the queue
+	// and cancel directly.
+	if t.Status == session.StatusPending || t.Status == session.StatusScheduled {
+		if err := h.service.CancelPending(r.Context(), sessionID); err != nil {
 			writeAppError(w, err)
 			return
 		}
+		message := "queued session canceled"
+		if t.Status == session.StatusScheduled {
+			message = "scheduled session canceled"
+		}
 		writeJSON(w, http.StatusOK, map[string]string{
 			"id":      sessionID,
 			"status":  string(session.StatusCanceled),
-			"message": "queued session canceled",
+			"message": message,
 		})
 		return
 	}
@@ -424,6 +755,11 @@ func (h *SessionHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.prService.CreatePR(r.Context(), sessionID, req)
 	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			writeAppError(w, err)
+			return
+		}
 		// Determine status code from error message
 		errMsg := err.Error()
 		switch {
@@ -446,7 +782,56 @@ func (h *SessionHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
-// PushToPR handles POST /api/v1/sessions/{sessionID}/push.
+// CreatePRs handles POST /api/v1/sessions/create-prs: one cross-linked PR per
+// session for a change spanning several repositories.
+func (h *SessionHandler) CreatePRs(w http.ResponseWriter, r *http.Request) {
+	var req session.CreatePRsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			fields := make(map[string]string)
+			for _, e := range validationErrs {
+				fields[e.Field()] = formatValidationError(e)
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":  "validation_error",
+				"fields": fields,
+			})
+			return
+		}
+		writeError(w, http.StatusBadRequest, "validation failed")
+		return
+	}
+
+	// OwnershipMiddleware only sees {sessionID} routes; check each ID here.
+	if tnt := middleware.TenantFromContext(r.Context()); tnt != nil {
+		for _, id := range req.SessionIDs {
+			t, err := h.service.Get(r.Context(), id)
+			if err != nil {
+				writeAppError(w, err)
+				return
+			}
+			if t.TenantID != tnt.ID {
+				writeError(w, http.StatusNotFound, "session not found")
+				return
+			}
+		}
+	}
+
+	result, err := h.prService.CreatePRs(r.Context(), req)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// PushToPR handles POST /api/v1/sessions/{sessionID}/push: to the existing PR,
+// or to the session branch when no PR was created.
 func (h *SessionHandler) PushToPR(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "sessionID")
 	if sessionID == "" {
@@ -456,6 +841,11 @@ func (h *SessionHandler) PushToPR(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.prService.PushToPR(r.Context(), sessionID)
 	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			writeAppError(w, err)
+			return
+		}
 		errMsg := err.Error()
 		switch {
 		case strings.Contains(errMsg, "not found"):
@@ -464,8 +854,6 @@ func (h *SessionHandler) PushToPR(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusConflict, errMsg)
 		case strings.Contains(errMsg, "no new changes to push"):
 			writeError(w, http.StatusBadRequest, errMsg)
-		case strings.Contains(errMsg, "no existing PR"):
-			writeError(w, http.StatusBadRequest, errMsg)
 		default:
 			writeError(w, http.StatusInternalServerError, errMsg)
 		}
@@ -536,7 +924,9 @@ func (h *SessionHandler) PostReviewComments(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	t, err := h.service.Get(r.Context(), sessionID)
+	// Posting comments talks to the git provider, so this is the one read
+	// path that needs the session's decrypted access token.
+	t, err := h.service.Get(r.Context(), sessionID, session.WithSecrets())
 	if err != nil {
 		writeAppError(w, err)
 		return
@@ -580,6 +970,11 @@ func (h *SessionHandler) PostReviewComments(w http.ResponseWriter, r *http.Reque
 		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse repo URL: %v", err))
 		return
 	}
+	var explicit string
+	if t.ProviderKey != "" && h.keyRegistry != nil {
+		explicit = h.keyRegistry.APIBaseURL(r.Context(), t.ProviderKey)
+	}
+	h.providerAPI.Apply(repo, explicit)
 
 	result, err := gitpkg.PostReviewComments(
 		r.Context(), repo, token, prNumber, t.ReviewResult,
@@ -597,6 +992,56 @@ func (h *SessionHandler) PostReviewComments(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// Rebase handles POST /api/v1/sessions/{sessionID}/rebase.
+func (h *SessionHandler) Rebase(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, "session ID is required")
+		return
+	}
+
+	var req session.RebaseRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			fields := make(map[string]string)
+			for _, e := range validationErrs {
+				fields[e.Field()] = formatValidationError(e)
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":  "validation_error",
+				"fields": fields,
+			})
+			return
+		}
+		writeError(w, http.StatusBadRequest, "validation failed")
+		return
+	}
+
+	result, err := h.prService.Rebase(r.Context(), sessionID, req)
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			writeAppError(w, err)
+			return
+		}
+		if strings.Contains(err.Error(), "uncommitted changes") {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
 // GetPRStatus handles GET /api/v1/sessions/{sessionID}/pr-status.
 func (h *SessionHandler) GetPRStatus(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "sessionID")
@@ -645,8 +1090,15 @@ func writeAppError(w http.ResponseWriter, err error) {
 	status := apperror.HTTPStatus(err)
 	var appErr *apperror.AppError
 	if errors.As(err, &appErr) {
+		code := appErr.Code
+		if code == "" {
+			code = http.StatusText(status)
+		}
+		if appErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(appErr.RetryAfter.Seconds())))
+		}
 		writeJSON(w, status, map[string]interface{}{
-			"error":   http.StatusText(status),
+			"error":   code,
 			"message": appErr.Message,
 			"fields":  appErr.Fields,
 		})
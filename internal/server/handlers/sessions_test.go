@@ -7,10 +7,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/freema/codeforge/internal/apperror"
 	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/tenant"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/runner"
 )
 
@@ -121,7 +125,7 @@ func TestReview_InvalidCLI(t *testing.T) {
 	cliRegistry := runner.NewRegistry("claude-code")
 	cliRegistry.Register("claude-code", runner.NewClaudeRunner("claude"), runner.RunnerMeta{AIProvider: "anthropic"})
 
-	h := NewSessionHandler(nil, nil, nil, cliRegistry, nil, nil, nil)
+	h := NewSessionHandler(nil, nil, nil, cliRegistry, nil, nil, gitpkg.APIConfig{}, nil)
 
 	r := chi.NewRouter()
 	r.Post("/api/v1/sessions/{sessionID}/review", h.Review)
@@ -171,3 +175,94 @@ func TestCancel_StatusCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWait(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"30s", 30 * time.Second, false},
+		{"10", 10 * time.Second, false},
+		{"5m", maxWait, false},
+		{"-1s", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseWait(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseWait(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTenantOverrides(t *testing.T) {
+	models := `["claude-sonnet-4-6"]`
+	tnt := &tenant.Tenant{Tier: "pro", AllowedModels: &models, MaxBudgetUSDPerSession: 5}
+
+	tests := []struct {
+		name       string
+		cfg        session.IterationConfig
+		wantStatus int
+		wantBudget float64
+	}{
+		{"allowed model", session.IterationConfig{AIModel: "claude-sonnet-4-6", MaxBudgetUSD: 2}, 0, 2},
+		{"model not in tier", session.IterationConfig{AIModel: "claude-opus-4-6"}, http.StatusForbidden, 0},
+		{"budget capped", session.IterationConfig{MaxBudgetUSD: 50}, 0, 5},
+		{"budget unset", session.IterationConfig{MaxTurns: 3}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			status, _ := applyTenantOverrides(&cfg, tnt)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if status == 0 && cfg.MaxBudgetUSD != tt.wantBudget {
+				t.Errorf("budget = %v, want %v", cfg.MaxBudgetUSD, tt.wantBudget)
+			}
+		})
+	}
+}
+
+func TestWriteAppError_RetryAfter(t *testing.T) {
+	unavailable := apperror.Unavailable("session queue is full")
+	unavailable.Code = "queue_full"
+	unavailable.RetryAfter = 30 * time.Second
+
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantCode       string
+		wantRetryAfter string
+	}{
+		{"queue full", unavailable, http.StatusServiceUnavailable, "queue_full", "30"},
+		{"no retry hint", apperror.Conflict("busy"), http.StatusConflict, "Conflict", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeAppError(rec, tt.err)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["error"] != tt.wantCode {
+				t.Errorf("error = %v, want %q", body["error"], tt.wantCode)
+			}
+		})
+	}
+}
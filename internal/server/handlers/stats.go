@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/freema/codeforge/internal/stats"
+)
+
+// StatsHandler serves rolling session aggregates. Operator-only.
+type StatsHandler struct {
+	recorder *stats.Recorder
+}
+
+// NewStatsHandler creates a stats handler.
+func NewStatsHandler(recorder *stats.Recorder) *StatsHandler {
+	return &StatsHandler{recorder: recorder}
+}
+
+// Summary handles GET /api/v1/stats.
+func (h *StatsHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.recorder.Summary(r.Context(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
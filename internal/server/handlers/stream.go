@@ -15,15 +15,25 @@ import (
 	"github.com/freema/codeforge/internal/session"
 )
 
+// StreamConfig bounds live SSE streams.
+type StreamConfig struct {
+	MaxDuration time.Duration // 0 = stream until the session is done or the client leaves
+	Keepalive   time.Duration // interval of keepalive comments; <= 0 uses 15s
+}
+
 // StreamHandler handles SSE streaming for session events.
 type StreamHandler struct {
 	service *session.Service
 	redis   *redisclient.Client
+	cfg     StreamConfig
 }
 
 // NewStreamHandler creates a new stream handler.
-func NewStreamHandler(service *session.Service, redis *redisclient.Client) *StreamHandler {
-	return &StreamHandler{service: service, redis: redis}
+func NewStreamHandler(service *session.Service, redis *redisclient.Client, cfg StreamConfig) *StreamHandler {
+	if cfg.Keepalive <= 0 {
+		cfg.Keepalive = 15 * time.Second
+	}
+	return &StreamHandler{service: service, redis: redis, cfg: cfg}
 }
 
 // Stream handles GET /api/v1/sessions/{sessionID}/stream.
@@ -52,7 +62,9 @@ func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
 	// SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+	if r.ProtoMajor == 1 {
+		w.Header().Set("Connection", "keep-alive") // connection-specific headers are invalid in HTTP/2
+	}
 	w.Header().Set("X-Accel-Buffering", "no")
 	w.WriteHeader(http.StatusOK)
 
@@ -61,10 +73,7 @@ func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
 
 	flush := func() { flusher.Flush() }
 
-	isTerminal := t.Status == session.StatusCompleted ||
-		t.Status == session.StatusFailed ||
-		t.Status == session.StatusPRCreated ||
-		t.Status == session.StatusCanceled
+	isTerminal := t.Status.IsTerminal()
 
 	// Subscribe to live channels BEFORE reading history to avoid missing events.
 	// For terminal tasks we skip subscription entirely.
@@ -85,13 +94,16 @@ func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
 	writeSSE(w, "connected", map[string]interface{}{
 		"session_id": t.ID,
 		"status":     t.Status,
+		"request_id": t.RequestID,
 	})
 	flush()
 
-	// Replay history
-	historyKey := h.redis.Key("session", sessionID, "history")
-	history, err := h.redis.Unwrap().LRange(r.Context(), historyKey, 0, -1).Result()
-	if err == nil && len(history) > 0 {
+	// Replay history, including chunks archived to the blob store
+	history, err := h.service.HistoryEvents(r.Context(), sessionID)
+	if err != nil {
+		slog.Warn("SSE history replay failed", "session_id", sessionID, "error", err)
+	}
+	if len(history) > 0 {
 		for _, msg := range history {
 			fmt.Fprintf(w, "data: %s\n\n", msg)
 		}
@@ -108,16 +120,20 @@ func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Stream live events
-	maxDuration := 10 * time.Minute
-	deadline := time.After(maxDuration)
-	keepalive := time.NewTicker(15 * time.Second)
+	// Stream live events. A nil deadline channel never fires (no cutoff).
+	var deadline <-chan time.Time
+	if h.cfg.MaxDuration > 0 {
+		timer := time.NewTimer(h.cfg.MaxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	keepalive := time.NewTicker(h.cfg.Keepalive)
 	defer keepalive.Stop()
 
 	slog.Debug("SSE stream started", "session_id", sessionID)
 
 	for {
-		_ = rc.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		_ = rc.SetWriteDeadline(time.Now().Add(h.cfg.Keepalive + 15*time.Second))
 
 		select {
 		case <-r.Context().Done():
@@ -126,7 +142,7 @@ func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
 
 		case <-deadline:
 			writeSSE(w, "timeout", map[string]string{
-				"message": "stream closed after 10 minutes",
+				"message": fmt.Sprintf("stream closed after %s", h.cfg.MaxDuration),
 			})
 			flush()
 			slog.Debug("SSE stream timed out", "session_id", sessionID)
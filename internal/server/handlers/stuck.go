@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// defaultStuckAge is the quiet period after which an in-flight session is
+// reported as stuck when ?age is not given.
+const defaultStuckAge = 30 * time.Minute
+
+// StuckHandler reports in-flight sessions that stopped making progress and
+// lets operators fail them. Operator-only.
+type StuckHandler struct {
+	sessionService *session.Service
+	canceller      Canceller
+}
+
+// NewStuckHandler creates a stuck session handler. canceller stops the
+// worker of a force-failed session, wherever it runs.
+func NewStuckHandler(sessionService *session.Service, canceller Canceller) *StuckHandler {
+	return &StuckHandler{sessionService: sessionService, canceller: canceller}
+}
+
+// FailStuckRequest optionally limits a force-fail to specific sessions.
+type FailStuckRequest struct {
+	SessionIDs []string `json:"session_ids,omitempty"`
+}
+
+// List handles GET /api/v1/admin/stuck?age=30m.
+func (h *StuckHandler) List(w http.ResponseWriter, r *http.Request) {
+	age, ok := parseStuckAge(w, r)
+	if !ok {
+		return
+	}
+	stuck, err := h.sessionService.FindStuck(r.Context(), age)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"age":      age.String(),
+		"sessions": stuck,
+	})
+}
+
+// Fail handles POST /api/v1/admin/stuck/fail?age=30m.
+// Marks every stuck session (or the listed subset, if still stuck) failed and
+// cancels its worker.
+func (h *StuckHandler) Fail(w http.ResponseWriter, r *http.Request) {
+	age, ok := parseStuckAge(w, r)
+	if !ok {
+		return
+	}
+	var req FailStuckRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	}
+
+	stuck, err := h.sessionService.FindStuck(r.Context(), age)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	stuck = filterStuck(stuck, req.SessionIDs)
+
+	for i := range stuck {
+		st := &stuck[i]
+		reason := session.StuckReason(time.Duration(st.IdleSeconds) * time.Second)
+		if err := h.sessionService.FailStuck(r.Context(), st.ID, reason); err != nil {
+			st.FailError = err.Error()
+			continue
+		}
+		st.Failed = true
+		st.Status = session.StatusFailed
+		slog.Warn("stuck session force-failed", "session_id", st.ID, "idle_seconds", st.IdleSeconds)
+		// A hung worker must not finish the session later; one that is
+		// gone (crashed node) leaves nothing to cancel.
+		if err := h.canceller.Cancel(st.ID); err != nil {
+			slog.Debug("no worker to cancel for stuck session", "session_id", st.ID, "error", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"age":      age.String(),
+		"sessions": stuck,
+	})
+}
+
+// filterStuck keeps the sessions named in ids; no ids keeps all of them.
+func filterStuck(stuck []session.StuckSession, ids []string) []session.StuckSession {
+	if len(ids) == 0 {
+		return stuck
+	}
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	out := []session.StuckSession{}
+	for _, st := range stuck {
+		if want[st.ID] {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+func parseStuckAge(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	raw := r.URL.Query().Get("age")
+	if raw == "" {
+		return defaultStuckAge, true
+	}
+	age, err := time.ParseDuration(raw)
+	if err != nil || age < time.Minute {
+		writeError(w, http.StatusBadRequest, "age must be a duration of at least 1m, e.g. 30m or 2h")
+		return 0, false
+	}
+	return age, true
+}
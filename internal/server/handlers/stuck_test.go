@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+func TestParseStuckAge(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", defaultStuckAge, true},
+		{"?age=2h", 2 * time.Hour, true},
+		{"?age=1m", time.Minute, true},
+		{"?age=30s", 0, false},
+		{"?age=soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			age, ok := parseStuckAge(rec, httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+			if ok != tt.wantOK || age != tt.want {
+				t.Errorf("parseStuckAge = %v, %v; want %v, %v", age, ok, tt.want, tt.wantOK)
+			}
+			if !ok && rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", rec.Code)
+			}
+		})
+	}
+}
+
+func TestFilterStuck(t *testing.T) {
+	stuck := []session.StuckSession{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	if got := filterStuck(stuck, nil); len(got) != 3 {
+		t.Errorf("no ids: len = %d, want 3", len(got))
+	}
+	got := filterStuck(stuck, []string{"c", "a", "gone"})
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Errorf("filtered = %+v, want a, c", got)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -12,6 +15,7 @@ import (
 	"github.com/freema/codeforge/internal/database"
 	"github.com/freema/codeforge/internal/session"
 	"github.com/freema/codeforge/internal/tenant"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/runner"
 )
 
@@ -72,7 +76,7 @@ func TestApplyTenant_ConcurrencyLimit(t *testing.T) {
 	res, _ := svc.CreateTenant(ctx, "c", "c", tenant.TierFree) // free: MaxConcurrentSessions = 2
 	tnt, _ := store.GetTenant(ctx, res.Tenant.ID)
 
-	h := NewSessionHandler(nil, nil, nil, testCLIRegistry(), nil, nil, svc)
+	h := NewSessionHandler(nil, nil, nil, testCLIRegistry(), nil, nil, gitpkg.APIConfig{}, svc)
 
 	h.sessionCounter = fakeCounter{active: tnt.MaxConcurrentSessions}
 	if status, _ := h.applyTenant(ctx, &session.CreateSessionRequest{}, tnt); status != 429 {
@@ -108,7 +112,7 @@ func TestApplyTenant(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	h := NewSessionHandler(nil, nil, nil, testCLIRegistry(), nil, nil, svc)
+	h := NewSessionHandler(nil, nil, nil, testCLIRegistry(), nil, nil, gitpkg.APIConfig{}, svc)
 
 	t.Run("disallowed CLI -> 403", func(t *testing.T) {
 		req := &session.CreateSessionRequest{Config: &session.Config{CLI: "cursor"}}
@@ -159,3 +163,27 @@ func TestApplyTenant(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckTenantBudget(t *testing.T) {
+	ctx := context.Background()
+	svc, store, _ := newTenantService(t)
+	res, _ := svc.CreateTenant(ctx, "b", "b", tenant.TierFree)
+	tnt, _ := store.GetTenant(ctx, res.Tenant.ID)
+	tnt.MaxBudgetUSDPerMonth = 3
+	if err := store.UpdateTenant(ctx, tnt); err != nil {
+		t.Fatal(err)
+	}
+	h := NewSessionHandler(nil, nil, nil, testCLIRegistry(), nil, nil, gitpkg.APIConfig{}, svc)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions", nil)
+
+	w := httptest.NewRecorder()
+	if !h.checkTenantBudget(w, r, tnt) {
+		t.Fatalf("under budget refused: %d %s", w.Code, w.Body)
+	}
+
+	_ = store.LogUsage(ctx, &tenant.UsageLog{TenantID: tnt.ID, SessionID: "s1", CLI: "claude-code", EstimatedCostUSD: 3})
+	w = httptest.NewRecorder()
+	if h.checkTenantBudget(w, r, tnt) || w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "budget_exceeded") {
+		t.Fatalf("over budget: %d %s, want 409 budget_exceeded", w.Code, w.Body)
+	}
+}
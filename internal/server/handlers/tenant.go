@@ -88,6 +88,7 @@ func (h *TenantHandler) Update(w http.ResponseWriter, r *http.Request) {
 		MaxSessionsPerDay      *int     `json:"max_sessions_per_day"`
 		MaxConcurrentSessions  *int     `json:"max_concurrent_sessions"`
 		MaxBudgetUSDPerSession *float64 `json:"max_budget_usd_per_session"`
+		MaxBudgetUSDPerMonth   *float64 `json:"max_budget_usd_per_month"`
 		AllowedCLIs            *string  `json:"allowed_clis"`
 		AllowedModels          *string  `json:"allowed_models"`
 	}
@@ -111,6 +112,9 @@ func (h *TenantHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if req.MaxBudgetUSDPerSession != nil {
 		t.MaxBudgetUSDPerSession = *req.MaxBudgetUSDPerSession
 	}
+	if req.MaxBudgetUSDPerMonth != nil {
+		t.MaxBudgetUSDPerMonth = *req.MaxBudgetUSDPerMonth
+	}
 	if req.AllowedCLIs != nil {
 		t.AllowedCLIs = *req.AllowedCLIs
 	}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/freema/codeforge/internal/webhook"
+)
+
+// WebhookHandler lets integrators check their callback receiver.
+type WebhookHandler struct {
+	sender *webhook.Sender
+}
+
+// NewWebhookHandler creates a webhook handler. sender is nil when callbacks
+// are disabled (no HMAC secret configured).
+func NewWebhookHandler(sender *webhook.Sender) *WebhookHandler {
+	return &WebhookHandler{sender: sender}
+}
+
+// Test handles POST /api/v1/webhooks/test. It delivers one signed sample
+// payload and reports the receiver's status code, latency and the signature
+// it should have computed. Receiver failures are reported with 200 and
+// delivered=false; only a bad request or disabled callbacks are errors.
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	if h.sender == nil {
+		writeError(w, http.StatusConflict, "webhook callbacks are disabled: set webhooks.hmac_secret")
+		return
+	}
+
+	var req struct {
+		URL string `json:"url" validate:"required,url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "url must be a valid URL")
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		writeError(w, http.StatusBadRequest, "url must use http or https")
+		return
+	}
+	if err := h.sender.CheckURL(r.Context(), req.URL); err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res, err := h.sender.Test(r.Context(), req.URL)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
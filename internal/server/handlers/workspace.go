@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -37,14 +38,23 @@ func (h *WorkspaceHandler) List(w http.ResponseWriter, r *http.Request) {
 		SessionStatus string  `json:"session_status"`
 	}
 
+	ids := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		ids[i] = ws.TaskID
+	}
+	statuses, err := h.sessionService.GetStatusBatch(r.Context(), ids)
+	if err != nil {
+		slog.Warn("failed to load session statuses for workspaces", "error", err)
+	}
+
 	var totalSize int64
 	items := make([]wsInfo, 0, len(workspaces))
 	for _, ws := range workspaces {
 		totalSize += ws.SizeBytes
 
 		status := "unknown"
-		if t, err := h.sessionService.Get(r.Context(), ws.TaskID); err == nil {
-			status = string(t.Status)
+		if st, ok := statuses[ws.TaskID]; ok {
+			status = string(st)
 		}
 
 		items = append(items, wsInfo{
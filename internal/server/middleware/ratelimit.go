@@ -13,6 +13,7 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/tenant"
 )
 
 // RateLimiter implements Redis-based sliding window rate limiting.
@@ -81,6 +82,50 @@ func (rl *RateLimiter) allow(r *http.Request, clientID string) (bool, time.Durat
 	return true, 0
 }
 
+// RateLimitStatus is a caller's usage of the current rate limit window.
+type RateLimitStatus struct {
+	Limit         int        `json:"limit"`
+	WindowSeconds int        `json:"window_seconds"`
+	Used          int        `json:"used"`
+	Remaining     int        `json:"remaining"`
+	ResetAt       *time.Time `json:"reset_at,omitempty"` // when the oldest counted request leaves the window
+}
+
+// Limits is the caller's view of every limit that can reject its requests.
+type Limits struct {
+	Role      string           `json:"role"`                 // operator or tenant
+	RateLimit *RateLimitStatus `json:"rate_limit,omitempty"` // nil when rate limiting is disabled
+	Tenant    *tenant.Limits   `json:"tenant,omitempty"`
+}
+
+// Status reports the window usage of the caller's Bearer token without
+// counting the request itself.
+func (rl *RateLimiter) Status(r *http.Request) (RateLimitStatus, error) {
+	st := RateLimitStatus{Limit: rl.limit, WindowSeconds: int(rl.window.Seconds()), Remaining: rl.limit}
+	clientID := extractClientID(r)
+	if clientID == "" {
+		return st, nil
+	}
+	ctx := r.Context()
+	key := rl.redis.Key("ratelimit", hashToken(clientID))
+	windowStart := time.Now().UnixMilli() - rl.window.Milliseconds()
+
+	hits, err := rl.redis.Unwrap().ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(windowStart, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return st, err
+	}
+	st.Used = len(hits)
+	st.Remaining = max(rl.limit-st.Used, 0)
+	if len(hits) > 0 {
+		reset := time.UnixMilli(int64(hits[0].Score)).Add(rl.window).UTC()
+		st.ResetAt = &reset
+	}
+	return st, nil
+}
+
 func extractClientID(r *http.Request) string {
 	auth := r.Header.Get("Authorization")
 	token := strings.TrimPrefix(auth, "Bearer ")
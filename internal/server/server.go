@@ -14,6 +14,8 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/freema/codeforge/api"
+	"github.com/freema/codeforge/internal/apispec"
+	"github.com/freema/codeforge/internal/audit"
 	"github.com/freema/codeforge/internal/config"
 	"github.com/freema/codeforge/internal/database"
 	"github.com/freema/codeforge/internal/keys"
@@ -21,9 +23,12 @@ import (
 	"github.com/freema/codeforge/internal/server/handlers"
 	"github.com/freema/codeforge/internal/server/middleware"
 	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/stats"
 	"github.com/freema/codeforge/internal/tenant"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/mcp"
 	"github.com/freema/codeforge/internal/tool/runner"
+	"github.com/freema/codeforge/internal/webhook"
 	"github.com/freema/codeforge/internal/workflow"
 	"github.com/freema/codeforge/internal/workspace"
 )
@@ -32,10 +37,12 @@ import (
 type Server struct {
 	httpServer *http.Server
 	health     *handlers.HealthHandler
+	tlsCert    string
+	tlsKey     string
 }
 
 // New creates and configures the HTTP server with all routes and middleware.
-func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, sessionService *session.Service, prService *session.PRService, canceller handlers.Canceller, keyRegistry keys.Registry, mcpRegistry mcp.Registry, workspaceMgr *workspace.Manager, workflowRegistry workflow.Registry, workflowConfigStore workflow.ConfigStore, cliRegistry *runner.Registry, cliConfigs map[string]handlers.CLIInfo, webhookReceiverHandler *handlers.WebhookReceiverHandler, tenantHandler *handlers.TenantHandler, tenantService *tenant.Service, scheduleHandler *handlers.ScheduleHandler, version string) *Server {
+func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, sessionService *session.Service, prService *session.PRService, pool handlers.WorkerPool, keyRegistry keys.Registry, mcpRegistry mcp.Registry, workspaceMgr *workspace.Manager, workflowRegistry workflow.Registry, workflowConfigStore workflow.ConfigStore, cliRegistry *runner.Registry, cliConfigs map[string]handlers.CLIInfo, webhookReceiverHandler *handlers.WebhookReceiverHandler, tenantHandler *handlers.TenantHandler, tenantService *tenant.Service, scheduleHandler *handlers.ScheduleHandler, version string) *Server {
 	r := chi.NewRouter()
 
 	// Global middleware (timeout applied per-route-group, not globally, for SSE support)
@@ -46,14 +53,16 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 	r.Use(chimw.Recoverer)
 
 	// Rate limiter
+	var rateLimiter *middleware.RateLimiter
 	var rateLimitMw func(http.Handler) http.Handler
 	if cfg.RateLimit.Enabled && cfg.RateLimit.SessionsPerMinute > 0 {
-		rl := middleware.NewRateLimiter(redis, cfg.RateLimit.SessionsPerMinute, time.Minute)
-		rateLimitMw = rl.Middleware()
+		rateLimiter = middleware.NewRateLimiter(redis, cfg.RateLimit.SessionsPerMinute, time.Minute)
+		rateLimitMw = rateLimiter.Middleware()
 	}
 
 	// Health endpoints (no auth)
 	healthHandler := handlers.NewHealthHandler(redis, sqliteDB, workspaceMgr, version)
+	healthHandler.SetPool(pool)
 	r.Get("/", healthHandler.Info)
 	r.Get("/health", healthHandler.Health)
 	r.Get("/ready", healthHandler.Ready)
@@ -62,7 +71,12 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 	r.Handle("/metrics", promhttp.Handler())
 
 	// API docs (no auth)
-	docsHandler := handlers.NewDocsHandler(api.OpenAPISpec)
+	spec, err := apispec.Build(api.OpenAPISpec)
+	if err != nil {
+		slog.Warn("generating OpenAPI schemas failed, serving static spec", "error", err)
+		spec = api.OpenAPISpec
+	}
+	docsHandler := handlers.NewDocsHandler(spec)
 	r.Get("/api/docs", docsHandler.SwaggerUI)
 	r.Get("/api/docs/openapi.yaml", docsHandler.OpenAPISpec)
 
@@ -73,17 +87,39 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 	}
 
 	// Handlers
-	sessionHandler := handlers.NewSessionHandler(sessionService, prService, canceller, cliRegistry, keyRegistry, cfg.Git.ProviderDomains, tenantService)
+	providerAPI := gitpkg.APIConfig{BaseURLs: cfg.Git.APIBaseURLs, ErrorMaxBytes: cfg.Sessions.ProviderErrorMaxBytes}
+	sessionHandler := handlers.NewSessionHandler(sessionService, prService, pool, cliRegistry, keyRegistry, cfg.Git.ProviderDomains, providerAPI, tenantService)
 	cliHandler := handlers.NewCLIHandler(cliRegistry, cliConfigs)
-	streamHandler := handlers.NewStreamHandler(sessionService, redis)
+	streamHandler := handlers.NewStreamHandler(sessionService, redis, handlers.StreamConfig{
+		MaxDuration: cfg.Server.SSEMaxDuration,
+		Keepalive:   cfg.Server.SSEKeepalive,
+	})
 	keyHandler := handlers.NewKeyHandler(keyRegistry)
+	keyHandler.SetURLPolicy(sessionService.CallbackPolicy())
 	mcpHandler := handlers.NewMCPHandler(mcpRegistry)
 	toolHandler := handlers.NewToolHandler()
 	wsHandler := handlers.NewWorkspaceHandler(workspaceMgr, sessionService)
-	repoHandler := handlers.NewRepoHandler(keyRegistry)
+	repoHandler := handlers.NewRepoHandler(keyRegistry, providerAPI)
 	sentryHandler := handlers.NewSentryHandler(keyRegistry)
 	workflowHandler := handlers.NewWorkflowHandler(workflowRegistry, sessionService, keyRegistry)
 	workflowConfigHandler := handlers.NewWorkflowConfigHandler(workflowConfigStore, workflowRegistry, sessionService, keyRegistry)
+	adminHandler := handlers.NewAdminHandler(pool)
+	stuckHandler := handlers.NewStuckHandler(sessionService, pool)
+	redisHandler := handlers.NewRedisHandler(redis, sessionService)
+	limitsHandler := handlers.NewLimitsHandler(rateLimiter, tenantService, sessionService)
+	statsHandler := handlers.NewStatsHandler(stats.NewRecorder(redis))
+	projectHandler := handlers.NewProjectHandler(session.NewProjectStore(redis), cliRegistry)
+	promptHandler := handlers.NewPromptHandler(session.NewPromptStore(redis,
+		int64(cfg.Sessions.PromptUploadMaxBytes),
+		time.Duration(cfg.Sessions.PromptUploadTTL)*time.Second))
+	auditHandler := handlers.NewAuditHandler(audit.NewStore(sqliteDB.Unwrap()))
+	var webhookSender *webhook.Sender
+	if cfg.Webhooks.HMACSecret != "" {
+		webhookSender = webhook.NewSender(cfg.Webhooks.HMACSecret, 0, 0)
+		webhookSender.SetPolicy(sessionService.CallbackPolicy())
+	}
+	webhookHandler := handlers.NewWebhookHandler(webhookSender)
+	iterationHandler := handlers.NewIterationHandler(session.NewIterationService(sessionService, workspaceMgr, cfg.Sessions.WorkspaceBase))
 
 	// Protected API routes.
 	// Dual-auth when the subscription model is enabled: operator token OR tenant
@@ -95,6 +131,10 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 			r.Use(middleware.BearerAuth(cfg.Server.AuthToken))
 		}
 
+		if cfg.Server.StrictAPI {
+			r.Use(apispec.NewValidator().Middleware)
+		}
+
 		// Auth verification endpoint
 		r.Get("/auth/verify", healthHandler.AuthVerify)
 
@@ -117,18 +157,46 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 				} else {
 					r.Post("/", sessionHandler.Create)
 				}
+				r.Post("/create-prs", sessionHandler.CreatePRs)
 				r.Get("/{sessionID}", sessionHandler.Get)
+				r.Delete("/{sessionID}", sessionHandler.Delete)
+				r.Post("/{sessionID}/restore", sessionHandler.Restore)
 				r.Post("/{sessionID}/instruct", sessionHandler.Instruct)
+				r.Post("/{sessionID}/await", sessionHandler.Await)
 				r.Post("/{sessionID}/cancel", sessionHandler.Cancel)
+				r.Post("/{sessionID}/retain", sessionHandler.Retain)
+				r.With(middleware.OperatorOnly).Post("/{sessionID}/prioritize", sessionHandler.Prioritize)
 				r.Post("/{sessionID}/review", sessionHandler.Review)
 				r.Post("/{sessionID}/post-review", sessionHandler.PostReviewComments)
 				r.Post("/{sessionID}/create-pr", sessionHandler.CreatePR)
 				r.Post("/{sessionID}/push", sessionHandler.PushToPR)
+				r.Post("/{sessionID}/rebase", sessionHandler.Rebase)
 				r.Get("/{sessionID}/pr-status", sessionHandler.GetPRStatus)
+				r.Get("/{sessionID}/transcript", sessionHandler.Transcript)
+				r.Get("/{sessionID}/iterations", iterationHandler.List)
+				r.Get("/{sessionID}/iterations/{n}", iterationHandler.Get)
+				r.Get("/{sessionID}/iterations/{n}/diff", iterationHandler.Diff)
+				r.Post("/{sessionID}/iterations/{n}/revert", iterationHandler.Revert)
 			})
 
+			// Prompts over the inline limit, referenced as prompt_ref (tenant-scoped).
+			r.Route("/prompts", func(r chi.Router) {
+				r.Post("/", promptHandler.Upload)
+				r.Get("/{promptID}", promptHandler.Get)
+				r.Patch("/{promptID}", promptHandler.Append)
+			})
+
+			// Previous runs for a repository (tenants see only their own).
+			r.Get("/repos/{owner}/{repo}/sessions", sessionHandler.ListByRepo)
+
 			r.Get("/session-types", sessionHandler.ListSessionTypes)
 
+			// Rate-limit window and quota usage, for client-side throttling.
+			r.Get("/limits", limitsHandler.Get)
+
+			// Callback receiver check — tenants set callback URLs too.
+			r.Post("/webhooks/test", webhookHandler.Test)
+
 			// Caller identity + self-service usage — available to both roles
 			// (tenants get their own scope, operators are directed to /admin).
 			if tenantHandler != nil {
@@ -180,6 +248,13 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 					r.Get("/issues/{issueID}/latest-event", sentryHandler.GetLatestEvent)
 				})
 
+				r.Route("/projects", func(r chi.Router) {
+					r.Get("/", projectHandler.List)
+					r.Get("/{owner}/{repo}", projectHandler.Get)
+					r.Put("/{owner}/{repo}", projectHandler.Put)
+					r.Delete("/{owner}/{repo}", projectHandler.Delete)
+				})
+
 				r.Route("/workflows", func(r chi.Router) {
 					r.Post("/", workflowHandler.CreateWorkflow)
 					r.Get("/", workflowHandler.ListWorkflows)
@@ -208,6 +283,38 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 				}
 			})
 
+			// Pool introspection and maintenance mode (stop dequeuing while
+			// in-flight sessions drain).
+			r.Route("/admin/workers", func(r chi.Router) {
+				r.Use(middleware.OperatorOnly)
+				r.Get("/", adminHandler.ListWorkers)
+				r.Post("/pause", adminHandler.PauseWorkers)
+				r.Post("/resume", adminHandler.ResumeWorkers)
+			})
+
+			// Servers and runner agents registered on the shared queue.
+			r.With(middleware.OperatorOnly).Get("/admin/nodes", adminHandler.ListNodes)
+
+			// Rolling aggregates across all tenants.
+			r.With(middleware.OperatorOnly).Get("/stats", statsHandler.Summary)
+
+			// Audit trail of policy decisions (e.g. rejected or flagged prompts).
+			r.With(middleware.OperatorOnly).Get("/admin/audit", auditHandler.List)
+
+			// In-flight sessions without recent activity, with a force-fail action.
+			r.Route("/admin/stuck", func(r chi.Router) {
+				r.Use(middleware.OperatorOnly)
+				r.Get("/", stuckHandler.List)
+				r.Post("/fail", stuckHandler.Fail)
+			})
+
+			// Redis memory per key family and early eviction of old finished sessions.
+			r.Route("/admin/redis", func(r chi.Router) {
+				r.Use(middleware.OperatorOnly)
+				r.Get("/memory", redisHandler.Memory)
+				r.Post("/purge", redisHandler.Purge)
+			})
+
 			if tenantHandler != nil {
 				// Admin routes are operator-only — tenant tokens are rejected.
 				r.Route("/admin/tenants", func(r chi.Router) {
@@ -246,23 +353,46 @@ func New(cfg *config.Config, redis *redisclient.Client, sqliteDB *database.DB, s
 		otelHandler.ServeHTTP(w, req)
 	})
 
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+	return &Server{
+		httpServer: newHTTPServer(cfg.Server, handler),
+		health:     healthHandler,
+		tlsCert:    cfg.Server.TLSCertFile,
+		tlsKey:     cfg.Server.TLSKeyFile,
+	}
+}
+
+// newHTTPServer builds the listener-facing server. HTTP/2 lets a dashboard
+// multiplex many SSE streams over one connection instead of hitting the
+// browser's six-connections-per-host HTTP/1.1 limit: over TLS it is negotiated
+// via ALPN, in cleartext (h2c) it is used by proxies with prior knowledge.
+func newHTTPServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(cfg.H2C)
+
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      handler,
+		Protocols:    protocols,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 0, // Disabled — SSE handler manages deadlines via ResponseController
 		IdleTimeout:  60 * time.Second,
 	}
+}
 
-	return &Server{
-		httpServer: srv,
-		health:     healthHandler,
-	}
+// SetRedisMonitor makes /ready fail while the Redis circuit breaker is open.
+func (s *Server) SetRedisMonitor(m interface{ Up() bool }) {
+	s.health.SetRedisMonitor(m)
 }
 
 // Start begins listening for HTTP requests.
 func (s *Server) Start() error {
-	slog.Info("http server starting", "addr", s.httpServer.Addr)
+	slog.Info("http server starting", "addr", s.httpServer.Addr,
+		"tls", s.tlsCert != "", "h2c", s.httpServer.Protocols.UnencryptedHTTP2())
+	if s.tlsCert != "" {
+		return s.httpServer.ListenAndServeTLS(s.tlsCert, s.tlsKey)
+	}
 	return s.httpServer.ListenAndServe()
 }
 
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/freema/codeforge/internal/config"
+)
+
+func TestNewHTTPServer_H2C(t *testing.T) {
+	tests := []struct {
+		name      string
+		h2c       bool
+		wantProto string
+	}{
+		{"h2c enabled", true, "HTTP/2.0"},
+		{"h2c disabled", false, "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newHTTPServer(config.ServerConfig{H2C: tt.h2c}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, r.Proto)
+			}))
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			go func() { _ = srv.Serve(ln) }()
+			defer srv.Close()
+
+			// With h2c the client speaks HTTP/2 with prior knowledge only.
+			protocols := new(http.Protocols)
+			protocols.SetHTTP1(!tt.h2c)
+			protocols.SetUnencryptedHTTP2(tt.h2c)
+			client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+			resp, err := client.Get("http://" + ln.Addr().String())
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.wantProto {
+				t.Errorf("proto = %q, want %q", body, tt.wantProto)
+			}
+		})
+	}
+}
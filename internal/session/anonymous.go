@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// validateAnonymous checks a session that opted into config.anonymous: it
+// clones a public repository over HTTPS without credentials, so anything
+// that needs a token before create-pr is refused up front.
+func validateAnonymous(req CreateSessionRequest) error {
+	if req.Config == nil || !req.Config.Anonymous {
+		return nil
+	}
+	var reason, field string
+	switch {
+	case req.AccessToken != "" || req.ProviderKey != "":
+		field, reason = "access_token", "anonymous sessions take no credentials, drop access_token and provider_key"
+	case !strings.HasPrefix(strings.ToLower(req.RepoURL), "https://"):
+		field, reason = "repo_url", "anonymous sessions need a public https:// repository URL"
+	case req.Config.AutoCreatePR:
+		field, reason = "config.auto_create_pr", "opening a PR needs a token, pass access_token to create-pr instead"
+	case req.Config.AutoPostReview || req.Config.OutputMode == "post_comments":
+		field, reason = "config.output_mode", "posting review comments needs a token"
+	default:
+		return nil
+	}
+	appErr := apperror.Validation("invalid anonymous session: %s", reason)
+	appErr.Fields = map[string]string{field: reason}
+	return appErr
+}
+
+// SetAccessToken stores a token supplied after creation (create-pr of an
+// anonymous session), encrypted like the one given at creation.
+func (s *Service) SetAccessToken(ctx context.Context, sessionID, token string) error {
+	enc, err := s.crypto.Encrypt(token)
+	if err != nil {
+		return fmt.Errorf("encrypting access token: %w", err)
+	}
+	stateKey := s.redis.Key("session", sessionID, "state")
+	if err := s.redis.Unwrap().HSet(ctx, stateKey, "encrypted_access_token", enc).Err(); err != nil {
+		return fmt.Errorf("setting access token: %w", err)
+	}
+	return nil
+}
+
+// requireToken makes sure t has the token a push needs: the session's own,
+// one passed with the request (kept for later pushes), or the usual
+// provider_key / environment fallback. Anonymous sessions get no fallback:
+// the server's token is never sent to a repository the caller did not
+// authenticate against. Without a token the caller gets a 400 naming what to
+// supply instead of a git authentication error.
+func (s *PRService) requireToken(ctx context.Context, t *Session, requestToken, op string) error {
+	if requestToken != "" && t.AccessToken == "" {
+		if err := s.sessionService.SetAccessToken(ctx, t.ID, requestToken); err != nil {
+			return err
+		}
+		t.AccessToken = requestToken
+	}
+	if t.AccessToken != "" {
+		return nil
+	}
+	reason := "the session has no access token"
+	if t.IsAnonymous() {
+		reason = "anonymous sessions only use a token passed as access_token"
+	} else if s.tokenResolver != nil {
+		token, err := s.tokenResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
+		if err == nil {
+			t.AccessToken = token
+			return nil
+		}
+		reason = err.Error()
+	}
+	appErr := apperror.Validation("%s needs an access token: %s", op, reason)
+	appErr.Fields = map[string]string{"access_token": "required to " + op}
+	return appErr
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestValidateAnonymous(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       CreateSessionRequest
+		wantField string // "" = valid
+	}{
+		{
+			name: "not anonymous",
+			req:  CreateSessionRequest{RepoURL: "git@github.com:acme/api.git", AccessToken: "tok", Config: &Config{AutoCreatePR: true}},
+		},
+		{
+			name: "public https repository",
+			req:  CreateSessionRequest{RepoURL: "https://github.com/acme/api", Config: &Config{Anonymous: true}},
+		},
+		{
+			name:      "with a token",
+			req:       CreateSessionRequest{RepoURL: "https://github.com/acme/api", AccessToken: "tok", Config: &Config{Anonymous: true}},
+			wantField: "access_token",
+		},
+		{
+			name:      "with a provider key",
+			req:       CreateSessionRequest{RepoURL: "https://github.com/acme/api", ProviderKey: "gh", Config: &Config{Anonymous: true}},
+			wantField: "access_token",
+		},
+		{
+			name:      "ssh url",
+			req:       CreateSessionRequest{RepoURL: "ssh://git@github.com/acme/api.git", Config: &Config{Anonymous: true}},
+			wantField: "repo_url",
+		},
+		{
+			name:      "auto PR",
+			req:       CreateSessionRequest{RepoURL: "https://github.com/acme/api", Config: &Config{Anonymous: true, AutoCreatePR: true}},
+			wantField: "config.auto_create_pr",
+		},
+		{
+			name:      "review comments",
+			req:       CreateSessionRequest{RepoURL: "https://github.com/acme/api", SessionType: "pr_review", Config: &Config{Anonymous: true, PRNumber: 7, OutputMode: "post_comments"}},
+			wantField: "config.output_mode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAnonymous(tt.req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var appErr *apperror.AppError
+			if !errors.As(err, &appErr) || appErr.Status != 400 || appErr.Fields[tt.wantField] == "" {
+				t.Fatalf("err = %#v, want 400 on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+// serverToken resolves every repository to the server's own token.
+type serverToken struct{}
+
+func (serverToken) ResolveToken(context.Context, string, string, string) (string, error) {
+	return "server-token", nil
+}
+
+func (serverToken) APIBaseURL(context.Context, string) string { return "" }
+
+func TestRequireToken_Fallback(t *testing.T) {
+	s := &PRService{tokenResolver: serverToken{}}
+	tests := []struct {
+		name      string
+		config    *Config
+		wantToken string
+		wantErr   bool
+	}{
+		{"regular session uses the server token", nil, "server-token", false},
+		{"anonymous session never does", &Config{Anonymous: true}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := &Session{ID: "s1", RepoURL: "https://github.com/o/r", Config: tt.config}
+			err := s.requireToken(context.Background(), sess, "", "create-pr")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, apperror.ErrValidation) {
+				t.Errorf("err = %v, want validation error", err)
+			}
+			if sess.AccessToken != tt.wantToken {
+				t.Errorf("token = %q, want %q", sess.AccessToken, tt.wantToken)
+			}
+		})
+	}
+}
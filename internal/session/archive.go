@@ -0,0 +1,173 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/blobstore"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+func (s *Service) historyKey(sessionID string) string {
+	return s.redis.Key("session", sessionID, "history")
+}
+
+// ArchiveHistory moves the inline events of a session's SSE history to the
+// blob store once they add up to the offload threshold; the Redis list then
+// holds a pointer in their place. It runs when a run finishes, so events of
+// later iterations are appended after the pointer and archived in turn.
+// A no-op without a blob store.
+func (s *Service) ArchiveHistory(ctx context.Context, sessionID string) error {
+	if s.blobs == nil {
+		return nil
+	}
+	key := s.historyKey(sessionID)
+	var uploaded string
+	err := s.redis.Unwrap().Watch(ctx, func(tx *redis.Tx) error {
+		entries, err := tx.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return fmt.Errorf("reading history: %w", err)
+		}
+		var pointers []interface{}
+		var jsonl bytes.Buffer
+		for _, e := range entries {
+			if strings.HasPrefix(e, blobPointerPrefix) {
+				pointers = append(pointers, e)
+				continue
+			}
+			jsonl.WriteString(e)
+			jsonl.WriteByte('\n')
+		}
+		if jsonl.Len() == 0 || jsonl.Len() < s.blobThreshold {
+			return nil
+		}
+
+		data, err := encodeTranscript(jsonl.Bytes())
+		if err != nil {
+			return err
+		}
+		blob := blobKey(sessionID, "history", strconv.Itoa(len(pointers))+".jsonl.gz")
+		if err := s.blobs.Put(ctx, blob, data); err != nil {
+			return fmt.Errorf("offloading %s: %w", blob, err)
+		}
+		uploaded = blob
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Del(ctx, key)
+			p.RPush(ctx, key, append(pointers, blobPointerPrefix+blob)...)
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		// Events were appended meanwhile; they are archived with the next run.
+		if err := s.blobs.Delete(ctx, uploaded); err != nil {
+			slog.Warn("deleting unused history blob failed", "session_id", sessionID, "key", uploaded, "error", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("archiving history: %w", err)
+	}
+	return nil
+}
+
+// HistoryEvents returns the SSE event history of a session, oldest first,
+// with archived events read back from the blob store.
+func (s *Service) HistoryEvents(ctx context.Context, sessionID string) ([]string, error) {
+	entries, err := s.redis.Unwrap().LRange(ctx, s.historyKey(sessionID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	events := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !strings.HasPrefix(e, blobPointerPrefix) {
+			events = append(events, e)
+			continue
+		}
+		data, err := s.resolveBlob(ctx, []byte(e))
+		if err != nil {
+			return nil, fmt.Errorf("loading archived history: %w", err)
+		}
+		archived, err := decodeTranscript(data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding archived history: %w", err)
+		}
+		for _, evt := range archived {
+			events = append(events, string(evt))
+		}
+	}
+	return events, nil
+}
+
+// historyBlobs returns the keys of the archived chunks of a session's history.
+func historyBlobs(entries []string) []string {
+	var keys []string
+	for _, e := range entries {
+		if key, ok := strings.CutPrefix(e, blobPointerPrefix); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// patchKey is the blob holding the diff of iteration n.
+func patchKey(sessionID string, n int) string {
+	return blobKey(sessionID, "patch", strconv.Itoa(n)+".diff.gz")
+}
+
+// ArchivePatch uploads the diff between the checkpoints of iteration n to the
+// blob store, so it can still be served once the workspace is cleaned up.
+// A no-op without a blob store.
+func (s *Service) ArchivePatch(ctx context.Context, sessionID string, n int, workDir string) error {
+	if s.blobs == nil {
+		return nil
+	}
+	diff, err := gitpkg.DiffCheckpoints(ctx, workDir,
+		gitpkg.CheckpointRef(n, gitpkg.CheckpointBefore), gitpkg.CheckpointRef(n, gitpkg.CheckpointAfter))
+	if err != nil {
+		return err
+	}
+	data, err := encodeTranscript([]byte(diff))
+	if err != nil {
+		return err
+	}
+	if err := s.blobs.Put(ctx, patchKey(sessionID, n), data); err != nil {
+		return fmt.Errorf("offloading patch of iteration %d: %w", n, err)
+	}
+	return nil
+}
+
+// archivedPatch returns the diff of iteration n uploaded by ArchivePatch.
+func (s *Service) archivedPatch(ctx context.Context, sessionID string, n int) (string, error) {
+	if s.blobs == nil {
+		return "", apperror.NotFound("no checkpoint for iteration %d (still running, failed, or workspace cleaned up)", n)
+	}
+	data, err := s.blobs.Get(ctx, patchKey(sessionID, n))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return "", apperror.NotFound("no checkpoint for iteration %d (still running, failed, or workspace cleaned up)", n)
+	}
+	if err != nil {
+		return "", fmt.Errorf("loading patch of iteration %d: %w", n, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decoding patch of iteration %d: %w", n, err)
+	}
+	defer zr.Close()
+	diff, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("decoding patch of iteration %d: %w", n, err)
+	}
+	return string(diff), nil
+}
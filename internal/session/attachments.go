@@ -0,0 +1,173 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// AttachmentsDir is where attachments are written in the workspace, relative
+// to the repository root. It is excluded from commits.
+const AttachmentsDir = ".codeforge/attachments"
+
+const (
+	maxAttachments         = 20
+	maxAttachmentBytes     = 5 << 20
+	maxAttachmentTotalSize = 20 << 20
+)
+
+// Attachment is a file supplied with a session request — a design doc, a
+// failing test log, a screenshot. Exactly one of Content (text), Data
+// (base64) or BlobKey (an object in the configured blob store) is set.
+type Attachment struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	ContentType string `json:"content_type,omitempty" validate:"max=255"`
+	Description string `json:"description,omitempty" validate:"max=1000"`
+	Content     string `json:"content,omitempty"`
+	Data        string `json:"data,omitempty"`
+	BlobKey     string `json:"blob_key,omitempty"`
+}
+
+// AttachmentInfo describes a stored attachment. The payload itself is kept
+// apart from the session state and only loaded by the executor.
+type AttachmentInfo struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Size        int64  `json:"size,omitempty"` // 0 for blob store references
+	Path        string `json:"path"`           // relative to the repository root
+}
+
+// uploadPrefix is where callers put blobs they reference by blob_key. Keys are
+// scoped per tenant, so a session can never pull in another tenant's uploads
+// or the artifacts of another session.
+func uploadPrefix(tenantID string) string {
+	if tenantID == "" {
+		return "uploads/"
+	}
+	return "uploads/" + tenantID + "/"
+}
+
+// validBlobKey accepts keys below the caller's upload prefix only.
+func validBlobKey(key, tenantID string) error {
+	prefix := uploadPrefix(tenantID)
+	if !strings.HasPrefix(key, prefix) || path.Clean(key) != key || strings.Contains(key, "..") {
+		appErr := apperror.Validation("blob_key %q must be an object below %s", key, prefix)
+		appErr.Fields = map[string]string{"attachments": "blob_key must be below " + prefix}
+		return appErr
+	}
+	return nil
+}
+
+// prepareAttachments validates attachments and returns their metadata and the
+// values to store per name: the payload, or a blob pointer.
+func (s *Service) prepareAttachments(ctx context.Context, sessionID, tenantID string, in []Attachment) ([]AttachmentInfo, map[string]interface{}, error) {
+	if len(in) == 0 {
+		return nil, nil, nil
+	}
+	if len(in) > maxAttachments {
+		return nil, nil, apperror.Validation("at most %d attachments are allowed", maxAttachments)
+	}
+
+	infos := make([]AttachmentInfo, 0, len(in))
+	values := make(map[string]interface{}, len(in))
+	var total int64
+	for _, a := range in {
+		if err := validAttachmentName(a.Name); err != nil {
+			return nil, nil, err
+		}
+		if _, dup := values[a.Name]; dup {
+			return nil, nil, apperror.Validation("duplicate attachment name %q", a.Name)
+		}
+
+		info := AttachmentInfo{
+			Name:        a.Name,
+			ContentType: a.ContentType,
+			Description: a.Description,
+			Path:        AttachmentsDir + "/" + a.Name,
+		}
+		if attachmentSources(a) != 1 {
+			return nil, nil, apperror.Validation("attachment %q must set exactly one of content, data or blob_key", a.Name)
+		}
+		var payload []byte
+		switch {
+		case a.BlobKey != "":
+			if s.blobs == nil {
+				return nil, nil, apperror.Validation("attachment %q references blob_key, but no blob store is configured", a.Name)
+			}
+			if err := validBlobKey(a.BlobKey, tenantID); err != nil {
+				return nil, nil, err
+			}
+			values[a.Name] = blobPointerPrefix + a.BlobKey
+			infos = append(infos, info)
+			continue
+		case a.Data != "":
+			decoded, err := base64.StdEncoding.DecodeString(a.Data)
+			if err != nil {
+				return nil, nil, apperror.Validation("attachment %q: data is not valid base64", a.Name)
+			}
+			payload = decoded
+		default:
+			payload = []byte(a.Content)
+		}
+
+		if len(payload) > maxAttachmentBytes {
+			return nil, nil, apperror.Validation("attachment %q exceeds %d bytes", a.Name, maxAttachmentBytes)
+		}
+		total += int64(len(payload))
+		if total > maxAttachmentTotalSize {
+			return nil, nil, apperror.Validation("attachments exceed %d bytes in total", maxAttachmentTotalSize)
+		}
+		stored, err := s.offload(ctx, blobKey(sessionID, "attachments", a.Name), payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[a.Name] = stored
+		info.Size = int64(len(payload))
+		infos = append(infos, info)
+	}
+	return infos, values, nil
+}
+
+func attachmentSources(a Attachment) int {
+	n := 0
+	for _, v := range []string{a.Content, a.Data, a.BlobKey} {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// validAttachmentName accepts plain file names only, so an attachment can
+// never be written outside AttachmentsDir.
+func validAttachmentName(name string) error {
+	if name == "" || name == "." || name == ".." || path.Base(name) != name || strings.ContainsAny(name, `/\`+"\x00") {
+		return apperror.Validation("invalid attachment name %q: must be a plain file name", name)
+	}
+	return nil
+}
+
+// LoadAttachments returns the attachment payloads of a session by name,
+// resolving blob pointers.
+func (s *Service) LoadAttachments(ctx context.Context, sessionID string) (map[string][]byte, error) {
+	raw, err := s.redis.Unwrap().HGetAll(ctx, s.redis.Key("session", sessionID, "attachments")).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("loading attachments: %w", err)
+	}
+	out := make(map[string][]byte, len(raw))
+	for name, v := range raw {
+		data, err := s.resolveBlob(ctx, []byte(v))
+		if err != nil {
+			return nil, fmt.Errorf("loading attachment %s: %w", name, err)
+		}
+		out[name] = data
+	}
+	return out, nil
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestPrepareAttachments(t *testing.T) {
+	ctx := context.Background()
+	store := memBlobStore{}
+	s := &Service{}
+	s.SetBlobStore(store, 16)
+
+	infos, values, err := s.prepareAttachments(ctx, "sess-1", "", []Attachment{
+		{Name: "design.md", Content: "# Design", Description: "target layout"},
+		{Name: "shot.png", ContentType: "image/png", Data: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("p", 32)))},
+		{Name: "log.txt", BlobKey: "uploads/ci.log"},
+	})
+	if err != nil {
+		t.Fatalf("prepareAttachments: %v", err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("got %d infos, want 3", len(infos))
+	}
+	if infos[0].Path != AttachmentsDir+"/design.md" || infos[0].Size != 8 {
+		t.Errorf("infos[0] = %+v", infos[0])
+	}
+	if got := string(values["design.md"].([]byte)); got != "# Design" {
+		t.Errorf("small payload should stay inline, got %q", got)
+	}
+	if got := string(values["shot.png"].([]byte)); got != blobPointerPrefix+"sessions/sess-1/attachments/shot.png" {
+		t.Errorf("large payload should be offloaded, got %q", got)
+	}
+	if values["log.txt"] != blobPointerPrefix+"uploads/ci.log" {
+		t.Errorf("blob reference = %v", values["log.txt"])
+	}
+}
+
+func TestPrepareAttachments_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []Attachment
+	}{
+		{"path traversal", []Attachment{{Name: "../secret", Content: "x"}}},
+		{"nested path", []Attachment{{Name: "a/b.txt", Content: "x"}}},
+		{"dot dot", []Attachment{{Name: "..", Content: "x"}}},
+		{"no source", []Attachment{{Name: "a.txt"}}},
+		{"two sources", []Attachment{{Name: "a.txt", Content: "x", Data: "eA=="}}},
+		{"bad base64", []Attachment{{Name: "a.bin", Data: "not base64!"}}},
+		{"duplicate", []Attachment{{Name: "a.txt", Content: "x"}, {Name: "a.txt", Content: "y"}}},
+		{"blob without store", []Attachment{{Name: "a.txt", BlobKey: "k"}}},
+		{"too large", []Attachment{{Name: "a.txt", Content: strings.Repeat("x", maxAttachmentBytes+1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := (&Service{}).prepareAttachments(context.Background(), "s", "", tt.in)
+			if !errors.Is(err, apperror.ErrValidation) {
+				t.Errorf("err = %v, want validation error", err)
+			}
+		})
+	}
+}
+
+func TestValidBlobKey(t *testing.T) {
+	tests := []struct {
+		key, tenant string
+		wantErr     bool
+	}{
+		{"uploads/ci.log", "", false},
+		{"uploads/acme/ci.log", "acme", false},
+		{"uploads/other/ci.log", "acme", true},
+		{"uploads/ci.log", "acme", true},
+		{"sessions/sess-2/attachments/secret.txt", "", true},
+		{"uploads/../sessions/sess-2/result", "", true},
+		{"uploads/acme/../other/ci.log", "acme", true},
+		{"uploads//ci.log", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenant+":"+tt.key, func(t *testing.T) {
+			err := validBlobKey(tt.key, tt.tenant)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, apperror.ErrValidation) {
+				t.Errorf("err = %v, want validation error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// Auto-merge modes recorded on the session.
+const (
+	AutoMergeProvider = "provider" // provider merges on green (GitHub auto-merge, GitLab MWPS)
+	AutoMergePoll     = "poll"     // CodeForge polls checks and merges on green
+)
+
+// autoMergeKey is the sorted set of sessions whose PR awaits auto-merge,
+// scored by when auto-merge was requested.
+func (s *Service) autoMergeKey() string {
+	return s.redis.Key("sessions:automerge")
+}
+
+// enableAutoMerge turns on provider auto-merge, falling back to polling when
+// the repository does not allow it, and queues the session for SyncAutoMerges.
+func (s *PRService) enableAutoMerge(ctx context.Context, sessionID string, repo *gitpkg.RepoInfo, token string, prNumber int) string {
+	mode := AutoMergeProvider
+	if err := gitpkg.EnableAutoMerge(ctx, repo, token, prNumber); err != nil {
+		slog.Info("provider auto-merge unavailable, polling checks instead", "session_id", sessionID, "error", err)
+		mode = AutoMergePoll
+	}
+
+	rdb := s.sessionService.redis.Unwrap()
+	pipe := rdb.TxPipeline()
+	pipe.HSet(ctx, s.sessionService.redis.Key("session", sessionID, "state"), "auto_merge", mode)
+	pipe.ZAdd(ctx, s.sessionService.autoMergeKey(), redis.Z{Score: float64(time.Now().Unix()), Member: sessionID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("recording auto-merge failed", "session_id", sessionID, "error", err)
+	}
+	return mode
+}
+
+// SyncAutoMerges advances every pending auto-merge: sessions whose PR was
+// merged move to pr_merged, poll-mode PRs are merged once their checks pass.
+// Entries older than maxAge, or whose PR was closed or failed its checks,
+// are dropped. Returns the number of PRs seen merged.
+func (s *PRService) SyncAutoMerges(ctx context.Context, now time.Time, maxAge time.Duration) (int, error) {
+	key := s.sessionService.autoMergeKey()
+	entries, err := s.sessionService.redis.Unwrap().ZRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	for _, e := range entries {
+		sessionID, _ := e.Member.(string)
+		since := time.Unix(int64(e.Score), 0)
+		done, ok := s.syncAutoMerge(ctx, sessionID)
+		if ok {
+			merged++
+		}
+		if !done && now.Sub(since) > maxAge {
+			slog.Info("auto-merge gave up waiting", "session_id", sessionID, "since", since)
+			done = true
+		}
+		if done {
+			s.sessionService.redis.Unwrap().ZRem(ctx, key, sessionID)
+		}
+	}
+	return merged, nil
+}
+
+// syncAutoMerge reports whether the session can leave the queue and whether
+// its PR is now merged.
+func (s *PRService) syncAutoMerge(ctx context.Context, sessionID string) (done, merged bool) {
+	t, err := s.sessionService.Get(ctx, sessionID)
+	if err != nil {
+		return apperror.HTTPStatus(err) < 500, false // not found / deleted
+	}
+	switch {
+	case t.Status == StatusPRMerged:
+		return true, false
+	case IsFinished(t.Status) || t.Status == StatusCompleted:
+		return true, false
+	case t.Status != StatusPRCreated:
+		return false, false // follow-up in progress; check again later
+	}
+
+	status, err := s.GetPRStatus(ctx, sessionID) // syncs merged → pr_merged, closed → completed
+	if err != nil {
+		slog.Warn("auto-merge: PR status check failed", "session_id", sessionID, "error", err)
+		return false, false
+	}
+	switch status.State {
+	case "merged":
+		return true, true
+	case "closed":
+		return true, false
+	}
+	if t.AutoMerge != AutoMergePoll {
+		return false, false // provider merges; we only watch
+	}
+
+	repo, token, err := s.providerAccess(ctx, sessionID)
+	if err != nil {
+		slog.Warn("auto-merge: resolving provider access failed", "session_id", sessionID, "error", err)
+		return false, false
+	}
+	checks, err := gitpkg.ChecksState(ctx, repo, token, t.PRNumber)
+	if err != nil {
+		slog.Warn("auto-merge: checks lookup failed", "session_id", sessionID, "error", err)
+		return false, false
+	}
+	switch checks {
+	case gitpkg.ChecksFailure:
+		slog.Info("auto-merge: checks failed, leaving PR open", "session_id", sessionID, "pr_url", t.PRURL)
+		return true, false
+	case gitpkg.ChecksPending:
+		return false, false
+	}
+
+	if err := gitpkg.MergePR(ctx, repo, token, t.PRNumber); err != nil {
+		slog.Warn("auto-merge: merge failed", "session_id", sessionID, "pr_url", t.PRURL, "error", err)
+		return false, false
+	}
+	if err := s.sessionService.UpdateStatus(ctx, sessionID, StatusPRMerged); err != nil {
+		slog.Warn("auto-merge: status update failed", "session_id", sessionID, "error", err)
+	}
+	slog.Info("auto-merge: PR merged", "session_id", sessionID, "pr_url", t.PRURL)
+	return true, true
+}
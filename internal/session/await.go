@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// SetAwaitTTL sets the default and maximum hold of Await (0 max = unbounded).
+func (s *Service) SetAwaitTTL(def, max time.Duration) {
+	s.awaitTTL = def
+	s.maxAwaitTTL = max
+}
+
+// Await parks a completed session in awaiting_instruction: the conversation
+// stays open and the workspace is kept for the next instruction until the
+// hold expires, after which the workspace cleaner releases it (a later
+// instruction re-clones). Nothing is queued. ttl 0 uses the server default.
+func (s *Service) Await(ctx context.Context, sessionID string, ttl time.Duration) (*Session, error) {
+	if ttl < 0 {
+		return nil, apperror.Validation("ttl must not be negative")
+	}
+	if ttl == 0 {
+		ttl = s.awaitTTL
+	}
+	if ttl <= 0 {
+		return nil, apperror.Validation("ttl is required")
+	}
+	if s.maxAwaitTTL > 0 && ttl > s.maxAwaitTTL {
+		return nil, apperror.Validation("ttl must not exceed %s", s.maxAwaitTTL)
+	}
+
+	t, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	// The state outlives the hold by the usual idle retention, so a hold
+	// nobody picks up does not keep the session in Redis forever.
+	keepUntil := expiresAt.Add(s.idleTTL())
+	stateKey := s.redis.Key("session", sessionID, "state")
+
+	// WATCH the state so an instruction or cancel landing between the
+	// status check and the write is not overwritten.
+	err = s.redis.Unwrap().Watch(ctx, func(tx *redis.Tx) error {
+		status, err := tx.HGet(ctx, stateKey, "status").Result()
+		if errors.Is(err, redis.Nil) {
+			return apperror.Conflict("session %s is no longer live, send an instruction instead", sessionID)
+		}
+		if err != nil {
+			return fmt.Errorf("reading session status: %w", err)
+		}
+		if err := ValidateTransition(Status(status), StatusAwaitingInstruction); err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, stateKey, map[string]interface{}{
+				"status":           string(StatusAwaitingInstruction),
+				"await_expires_at": expiresAt.Format(time.RFC3339Nano),
+				"updated_at":       now.Format(time.RFC3339Nano),
+			})
+			pipe.ExpireAt(ctx, stateKey, keepUntil)
+			pipe.ExpireAt(ctx, s.redis.Key("session", sessionID, "attachments"), keepUntil)
+			return nil
+		})
+		return err
+	}, stateKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return nil, apperror.Conflict("session %s changed meanwhile, try again", sessionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.Status = StatusAwaitingInstruction
+	t.AwaitExpiresAt = &expiresAt
+
+	slog.Info("session awaiting instruction", "session_id", sessionID, "expires_at", expiresAt)
+
+	s.persistToSQLite(sessionID, func() error {
+		return s.sqlite.UpdateStatus(ctx, sessionID, StatusAwaitingInstruction, nil, nil)
+	})
+
+	return t, nil
+}
+
+// AwaitExpired reports whether t is parked by Await and its hold is over.
+func AwaitExpired(t *Session, now time.Time) bool {
+	return t.Status == StatusAwaitingInstruction && t.AwaitExpiresAt != nil && !now.Before(*t.AwaitExpiresAt)
+}
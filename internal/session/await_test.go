@@ -0,0 +1,32 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAwaitExpired(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		status Status
+		expiry *time.Time
+		want   bool
+	}{
+		{"hold over", StatusAwaitingInstruction, &past, true},
+		{"hold ends now", StatusAwaitingInstruction, &now, true},
+		{"hold running", StatusAwaitingInstruction, &future, false},
+		{"queued instruction, no hold", StatusAwaitingInstruction, nil, false},
+		{"instructed since", StatusRunning, &past, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{Status: tt.status, AwaitExpiresAt: tt.expiry}
+			if got := AwaitExpired(s, now); got != tt.want {
+				t.Errorf("AwaitExpired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
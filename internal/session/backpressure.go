@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// SetQueueLimit makes CheckQueue reject new sessions while maxDepth sessions
+// wait in the queue (0 = unlimited), asking clients to retry after retryAfter.
+func (s *Service) SetQueueLimit(maxDepth int, retryAfter time.Duration) {
+	s.maxQueueDepth = maxDepth
+	s.queueRetryAfter = retryAfter
+}
+
+// CheckQueue sheds load before a session is created: once the queue holds
+// workers.max_queue_depth sessions it returns a 503 queue_full error with a
+// Retry-After, instead of growing a backlog nobody sees.
+func (s *Service) CheckQueue(ctx context.Context) error {
+	if s.maxQueueDepth <= 0 {
+		return nil
+	}
+	depth, err := s.QueueDepth(ctx)
+	if err != nil {
+		return err
+	}
+	if depth < int64(s.maxQueueDepth) {
+		return nil
+	}
+	appErr := apperror.Unavailable("session queue is full (%d waiting), retry later", depth)
+	appErr.Code = "queue_full"
+	appErr.RetryAfter = s.queueRetryAfter
+	return appErr
+}
+
+// QueueDepth returns the number of sessions waiting in all priority queues.
+func (s *Service) QueueDepth(ctx context.Context) (int64, error) {
+	pipe := s.redis.Unwrap().Pipeline()
+	var lens []*redis.IntCmd
+	for _, key := range s.queueKeys() {
+		lens = append(lens, pipe.LLen(ctx, key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("reading queue depth: %w", err)
+	}
+	var depth int64
+	for _, n := range lens {
+		depth += n.Val()
+	}
+	return depth, nil
+}
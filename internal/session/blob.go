@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/freema/codeforge/internal/blobstore"
+)
+
+// blobPointerPrefix marks a Redis value that only points to an object in the
+// blob store.
+const blobPointerPrefix = "blob://"
+
+// SetBlobStore enables offloading of results, transcripts and event
+// histories larger than threshold bytes to store; Redis then keeps only a
+// pointer. Iteration patches are archived there regardless of size.
+func (s *Service) SetBlobStore(store blobstore.Store, threshold int) {
+	s.blobs = store
+	s.blobThreshold = threshold
+}
+
+// offload returns the value to store in Redis: data itself, or a pointer to
+// the uploaded blob when data is over the threshold.
+func (s *Service) offload(ctx context.Context, key string, data []byte) ([]byte, error) {
+	if s.blobs == nil || len(data) < s.blobThreshold {
+		return data, nil
+	}
+	if err := s.blobs.Put(ctx, key, data); err != nil {
+		return nil, fmt.Errorf("offloading %s: %w", key, err)
+	}
+	return []byte(blobPointerPrefix + key), nil
+}
+
+// resolveBlob returns the payload behind a Redis value, following a blob
+// pointer if there is one.
+func (s *Service) resolveBlob(ctx context.Context, value []byte) ([]byte, error) {
+	key, ok := strings.CutPrefix(string(value), blobPointerPrefix)
+	if !ok {
+		return value, nil
+	}
+	if s.blobs == nil {
+		return nil, fmt.Errorf("value %s lives in the blob store, but none is configured", key)
+	}
+	return s.blobs.Get(ctx, key)
+}
+
+// DeleteBlobs deletes the blobs that values — read from sessionID's Redis
+// keys before they were dropped — point to. Other values are skipped, as are
+// pointers outside the session's prefix. A no-op without a blob store.
+func (s *Service) DeleteBlobs(ctx context.Context, sessionID string, values []string) {
+	if s.blobs == nil {
+		return
+	}
+	for _, v := range values {
+		key, ok := strings.CutPrefix(v, blobPointerPrefix)
+		if !ok || !strings.HasPrefix(key, blobKey(sessionID)) {
+			continue
+		}
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			slog.Warn("deleting blob failed", "session_id", sessionID, "key", key, "error", err)
+		}
+	}
+}
+
+// blobKey builds the object key for a session artifact.
+func blobKey(sessionID string, parts ...string) string {
+	return "sessions/" + sessionID + "/" + strings.Join(parts, "/")
+}
@@ -0,0 +1,154 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/blobstore"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+type memBlobStore map[string][]byte
+
+func (m memBlobStore) Put(_ context.Context, key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func (m memBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m memBlobStore) Delete(_ context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestOffload(t *testing.T) {
+	ctx := context.Background()
+	store := memBlobStore{}
+	s := &Service{}
+	s.SetBlobStore(store, 10)
+
+	small, err := s.offload(ctx, "k1", []byte("tiny"))
+	if err != nil || string(small) != "tiny" {
+		t.Fatalf("small payload should stay inline, got %q, %v", small, err)
+	}
+
+	big := []byte(strings.Repeat("x", 32))
+	ptr, err := s.offload(ctx, "k2", big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ptr) != blobPointerPrefix+"k2" {
+		t.Fatalf("expected pointer, got %q", ptr)
+	}
+
+	for _, v := range [][]byte{small, ptr} {
+		got, err := s.resolveBlob(ctx, v)
+		if err != nil {
+			t.Fatalf("resolveBlob: %v", err)
+		}
+		if string(got) != "tiny" && string(got) != string(big) {
+			t.Errorf("unexpected payload %q", got)
+		}
+	}
+}
+
+func TestOffload_Disabled(t *testing.T) {
+	s := &Service{}
+	big := []byte(strings.Repeat("x", 1<<20))
+	out, err := s.offload(context.Background(), "k", big)
+	if err != nil || len(out) != len(big) {
+		t.Fatal("without a blob store payloads must stay inline")
+	}
+	if _, err := s.resolveBlob(context.Background(), []byte(blobPointerPrefix+"k")); err == nil {
+		t.Error("expected error resolving a pointer without a blob store")
+	}
+}
+
+func TestArchivePatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if _, err := gitpkg.Checkpoint(ctx, dir, gitpkg.CheckpointRef(1, gitpkg.CheckpointBefore)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitpkg.Checkpoint(ctx, dir, gitpkg.CheckpointRef(1, gitpkg.CheckpointAfter)); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memBlobStore{}
+	s := &Service{}
+	s.SetBlobStore(store, 10)
+	if err := s.ArchivePatch(ctx, "s1", 1, dir); err != nil {
+		t.Fatalf("ArchivePatch: %v", err)
+	}
+	if _, ok := store[patchKey("s1", 1)]; !ok {
+		t.Fatalf("patch not uploaded, store has %v", store)
+	}
+
+	diff, err := s.archivedPatch(ctx, "s1", 1)
+	if err != nil {
+		t.Fatalf("archivedPatch: %v", err)
+	}
+	if !strings.Contains(diff, "+package main") {
+		t.Errorf("unexpected diff %q", diff)
+	}
+	if _, err := s.archivedPatch(ctx, "s1", 2); apperror.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("missing patch: err = %v, want not found", err)
+	}
+}
+
+func TestArchivePatch_Disabled(t *testing.T) {
+	s := &Service{}
+	if err := s.ArchivePatch(context.Background(), "s1", 1, t.TempDir()); err != nil {
+		t.Fatalf("without a blob store ArchivePatch must be a no-op: %v", err)
+	}
+	if _, err := s.archivedPatch(context.Background(), "s1", 1); apperror.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("err = %v, want not found", err)
+	}
+}
+
+func TestDeleteBlobs(t *testing.T) {
+	store := memBlobStore{
+		"sessions/s1/result":                []byte("r"),
+		"sessions/s1/history/0.jsonl.gz":    []byte("h"),
+		"sessions/other/history/0.jsonl.gz": []byte("o"),
+	}
+	s := &Service{blobs: store}
+	s.DeleteBlobs(context.Background(), "s1", []string{
+		"blob://sessions/s1/result",
+		"blob://sessions/s1/history/0.jsonl.gz",
+		"blob://sessions/other/history/0.jsonl.gz", // not this session's
+		`{"event":"inline"}`,
+	})
+	if len(store) != 1 || store["sessions/other/history/0.jsonl.gz"] == nil {
+		t.Errorf("remaining blobs = %v, want only the other session's", store)
+	}
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// What Rebase does when updating the branch stops on conflicts.
+const (
+	OnConflictAbort   = "abort"   // restore the workspace and answer 409 (default)
+	OnConflictPause   = "pause"   // keep the conflicts and wait for an instruction
+	OnConflictResolve = "resolve" // keep the conflicts and start a resolution iteration
+)
+
+// EventEmitter publishes git events to a session's stream.
+// Implemented by *worker.Streamer; injected via SetEventEmitter.
+type EventEmitter interface {
+	EmitGit(ctx context.Context, sessionID, event string, data interface{}) error
+}
+
+// SetEventEmitter wires the stream that receives conflicts_detected events.
+func (s *PRService) SetEventEmitter(e EventEmitter) {
+	s.events = e
+}
+
+// handleConflicts reports a conflicting branch update. Kept conflicts move
+// the session to awaiting_instruction; with OnConflictResolve a follow-up
+// iteration is queued right away — the executor spots the unfinished merge
+// and asks the CLI to resolve it first.
+func (s *PRService) handleConflicts(ctx context.Context, t *Session, ce *gitpkg.ConflictError, mode string, resp *RebaseResponse) (*RebaseResponse, error) {
+	slog.Info("branch update stopped on conflicts", "session_id", t.ID, "base", ce.Base, "files", ce.Files, "kept", ce.Kept)
+	if s.events != nil {
+		if err := s.events.EmitGit(ctx, t.ID, "conflicts_detected", map[string]interface{}{
+			"files":         ce.Files,
+			"target_branch": ce.Base,
+			"kept":          ce.Kept,
+		}); err != nil {
+			slog.Warn("failed to emit conflicts_detected", "session_id", t.ID, "error", err)
+		}
+	}
+
+	if !ce.Kept {
+		appErr := apperror.Conflict("%s", ce.Error())
+		appErr.Fields = map[string]string{"conflicts": strings.Join(ce.Files, ", ")}
+		return nil, appErr
+	}
+	resp.Conflicts = ce.Files
+
+	if mode == OnConflictResolve {
+		instruction := fmt.Sprintf("Resolve the merge conflicts from updating branch %s with %s.", t.Branch, ce.Base)
+		// Rebase holds the instruct lock; the iteration takes it over.
+		instructed, err := s.sessionService.instruct(ctx, t.ID, instruction, instructOptions{lockHeld: true})
+		if err == nil {
+			resp.Status = string(instructed.Status)
+			resp.Iteration = instructed.Iteration
+			resp.Message = "Conflicts with " + ce.Base + " are being resolved in a new iteration"
+			return resp, nil
+		}
+		slog.Warn("starting conflict resolution iteration failed, waiting for instruction", "session_id", t.ID, "error", err)
+	}
+
+	if err := s.sessionService.UpdateStatus(ctx, t.ID, StatusAwaitingInstruction); err != nil {
+		return nil, fmt.Errorf("marking session awaiting instruction: %w", err)
+	}
+	resp.Status = string(StatusAwaitingInstruction)
+	resp.Message = "Conflicts with " + ce.Base + " left in the workspace; send an instruction to resolve them"
+	return resp, nil
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recordFailure extends the session's failure streak when ARGV[1] is the
+// class of the previous failure, otherwise starts a new one. Returns the
+// streak length.
+var recordFailure = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "failure_class") == ARGV[1] then
+	return redis.call("HINCRBY", KEYS[1], "failure_streak", 1)
+end
+redis.call("HSET", KEYS[1], "failure_class", ARGV[1], "failure_streak", 1)
+return 1`)
+
+// RecordFailure counts a failure of an automatic run (a crash recovered on
+// restart, a verification that failed again) by its error class and returns
+// how many times in a row the session has now failed with it.
+func (s *Service) RecordFailure(ctx context.Context, sessionID, class string) (int, error) {
+	stateKey := s.redis.Key("session", sessionID, "state")
+	streak, err := recordFailure.Run(ctx, s.redis.Unwrap(), []string{stateKey}, class).Int()
+	if err != nil {
+		return 0, fmt.Errorf("recording failure: %w", err)
+	}
+	return streak, nil
+}
+
+// ClearFailures ends the session's failure streak after a run that did not
+// fail.
+func (s *Service) ClearFailures(ctx context.Context, sessionID string) error {
+	stateKey := s.redis.Key("session", sessionID, "state")
+	if err := s.redis.Unwrap().HDel(ctx, stateKey, "failure_class", "failure_streak").Err(); err != nil {
+		return fmt.Errorf("clearing failures: %w", err)
+	}
+	return nil
+}
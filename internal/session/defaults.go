@@ -0,0 +1,54 @@
+package session
+
+import "context"
+
+// Defaults are server-wide session config values used when neither the
+// request nor the repository's project settings set them.
+type Defaults struct {
+	MaxTurns     int
+	MaxBudgetUSD float64
+	TargetBranch string
+	AllowedTools string
+}
+
+// SetDefaults configures the server-wide session config defaults.
+func (s *Service) SetDefaults(d Defaults) {
+	s.defaults = d
+}
+
+// ApplyDefaults fills what req leaves unset: first from the settings of its
+// repository, then from the server defaults. Create calls it for every entry
+// point; handlers that validate the effective config (CLI, tenant tiers) call
+// it first. Applying twice is harmless.
+func (s *Service) ApplyDefaults(ctx context.Context, req *CreateSessionRequest) {
+	if s.projects != nil {
+		if repo := RepoFullName(req.RepoURL); repo != "" {
+			if ps, err := s.projects.Get(ctx, repo); err == nil {
+				ps.applyTo(req)
+			}
+		}
+	}
+	s.defaults.applyTo(req)
+}
+
+func (d Defaults) applyTo(req *CreateSessionRequest) {
+	if d == (Defaults{}) {
+		return
+	}
+	if req.Config == nil {
+		req.Config = &Config{}
+	}
+	cfg := req.Config
+	if cfg.MaxTurns == 0 {
+		cfg.MaxTurns = d.MaxTurns
+	}
+	if cfg.MaxBudgetUSD == 0 {
+		cfg.MaxBudgetUSD = d.MaxBudgetUSD
+	}
+	if cfg.TargetBranch == "" {
+		cfg.TargetBranch = d.TargetBranch
+	}
+	if cfg.AllowedTools == "" {
+		cfg.AllowedTools = d.AllowedTools
+	}
+}
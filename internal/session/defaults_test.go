@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDefaults_ApplyTo(t *testing.T) {
+	d := Defaults{MaxTurns: 30, MaxBudgetUSD: 2.5, TargetBranch: "develop", AllowedTools: "Read,Edit"}
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		want Config
+	}{
+		{"no config", nil, Config{MaxTurns: 30, MaxBudgetUSD: 2.5, TargetBranch: "develop", AllowedTools: "Read,Edit"}},
+		{"request wins", &Config{MaxTurns: 5, TargetBranch: "main"}, Config{MaxTurns: 5, MaxBudgetUSD: 2.5, TargetBranch: "main", AllowedTools: "Read,Edit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CreateSessionRequest{Config: tt.cfg}
+			d.applyTo(&req)
+			got := *req.Config
+			if got.MaxTurns != tt.want.MaxTurns || got.MaxBudgetUSD != tt.want.MaxBudgetUSD ||
+				got.TargetBranch != tt.want.TargetBranch || got.AllowedTools != tt.want.AllowedTools {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("zero defaults leave config nil", func(t *testing.T) {
+		req := CreateSessionRequest{}
+		Defaults{}.applyTo(&req)
+		if req.Config != nil {
+			t.Errorf("config = %+v, want nil", req.Config)
+		}
+	})
+}
+
+func TestApplyDefaults_ProjectBeforeServer(t *testing.T) {
+	svc := &Service{defaults: Defaults{MaxTurns: 30, TargetBranch: "develop"}}
+	req := CreateSessionRequest{RepoURL: "https://github.com/acme/api"}
+	(&ProjectSettings{MaxTurns: 12}).applyTo(&req)
+	svc.ApplyDefaults(context.Background(), &req)
+	if req.Config.MaxTurns != 12 || req.Config.TargetBranch != "develop" {
+		t.Errorf("config = %+v, want project max_turns and server target_branch", req.Config)
+	}
+}
@@ -0,0 +1,196 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// SetDeleteGracePeriod sets how long a soft-deleted session can be restored
+// before PurgeDeleted removes it for good.
+func (s *Service) SetDeleteGracePeriod(d time.Duration) {
+	s.deleteGrace = d
+}
+
+// deletedKey is the sorted set of soft-deleted session IDs, scored by the
+// unix time of deletion.
+func (s *Service) deletedKey() string {
+	return s.redis.Key("sessions:deleted")
+}
+
+// Delete soft-deletes a session: it disappears from lists and Get answers 410
+// until it is restored or purged after the grace period. Sessions still in
+// flight must be canceled first. Deleting twice is a no-op. Returns the time
+// after which the session will be purged.
+func (s *Service) Delete(ctx context.Context, sessionID string) (time.Time, error) {
+	t, err := s.Get(ctx, sessionID, IncludeDeleted())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.DeletedAt != nil {
+		return t.DeletedAt.Add(s.deleteGrace), nil
+	}
+	if !t.Status.IsTerminal() && t.Status != StatusAwaitingInstruction {
+		return time.Time{}, apperror.Conflict("session is %s; cancel it before deleting", t.Status)
+	}
+
+	now := time.Now().UTC()
+	stateKey := s.redis.Key("session", sessionID, "state")
+	pipe := s.redis.Unwrap().TxPipeline()
+	// HSet on an expired state would resurrect it as a husk; only mark live state.
+	if n, _ := s.redis.Unwrap().Exists(ctx, stateKey).Result(); n > 0 {
+		pipe.HSet(ctx, stateKey, "deleted_at", now.Format(time.RFC3339Nano))
+	}
+	pipe.ZAdd(ctx, s.deletedKey(), redis.Z{Score: float64(now.Unix()), Member: sessionID})
+	if name := RepoFullName(t.RepoURL); name != "" {
+		pipe.ZRem(ctx, s.repoIndexKey(name), sessionID) // keeps repo listing totals exact
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("deleting session: %w", err)
+	}
+
+	s.persistToSQLite(sessionID, func() error {
+		return s.sqlite.SetDeleted(ctx, sessionID, &now)
+	})
+
+	slog.Info("session deleted", "session_id", sessionID, "purge_after", now.Add(s.deleteGrace))
+	return now.Add(s.deleteGrace), nil
+}
+
+// Restore undoes Delete while the session has not been purged yet.
+func (s *Service) Restore(ctx context.Context, sessionID string) (*Session, error) {
+	t, err := s.Get(ctx, sessionID, IncludeDeleted())
+	if err != nil {
+		return nil, err
+	}
+	if t.DeletedAt == nil {
+		return nil, apperror.Conflict("session is not deleted")
+	}
+
+	pipe := s.redis.Unwrap().TxPipeline()
+	pipe.HDel(ctx, s.redis.Key("session", sessionID, "state"), "deleted_at")
+	pipe.ZRem(ctx, s.deletedKey(), sessionID)
+	if name := RepoFullName(t.RepoURL); name != "" {
+		pipe.ZAdd(ctx, s.repoIndexKey(name), redis.Z{Score: float64(t.CreatedAt.Unix()), Member: sessionID})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("restoring session: %w", err)
+	}
+
+	s.persistToSQLite(sessionID, func() error {
+		return s.sqlite.SetDeleted(ctx, sessionID, nil)
+	})
+
+	slog.Info("session restored", "session_id", sessionID)
+	t.DeletedAt = nil
+	return t, nil
+}
+
+// PurgeDeleted permanently removes sessions soft-deleted more than the grace
+// period before now: their Redis keys, offloaded blobs, index entries and
+// SQLite rows. Returns the purged IDs so the caller can drop their workspaces.
+func (s *Service) PurgeDeleted(ctx context.Context, now time.Time) ([]string, error) {
+	cutoff := now.Add(-s.deleteGrace).Unix()
+	ids, err := s.redis.Unwrap().ZRangeByScore(ctx, s.deletedKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(cutoff, 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing deleted sessions: %w", err)
+	}
+
+	purged := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if err := s.purge(ctx, id); err != nil {
+			slog.Warn("failed to purge session", "session_id", id, "error", err)
+			continue
+		}
+		purged = append(purged, id)
+	}
+	return purged, nil
+}
+
+func (s *Service) purge(ctx context.Context, sessionID string) error {
+	rdb := s.redis.Unwrap()
+	stateKey := s.redis.Key("session", sessionID, "state")
+	resultKey := s.redis.Key("session", sessionID, "result")
+	transcriptKey := s.redis.Key("session", sessionID, "transcript")
+	attachmentsKey := s.redis.Key("session", sessionID, "attachments")
+
+	pipe := rdb.Pipeline()
+	repoCmd := pipe.HGet(ctx, stateKey, "repo_url")
+	resultCmd := pipe.Get(ctx, resultKey)
+	transcriptCmd := pipe.HVals(ctx, transcriptKey)
+	attachmentsCmd := pipe.HVals(ctx, attachmentsKey)
+	historyCmd := pipe.LRange(ctx, s.historyKey(sessionID), 0, -1)
+	iterationCmd := pipe.HGet(ctx, stateKey, "iteration")
+	_, _ = pipe.Exec(ctx) // missing keys are redis.Nil; checked per command
+
+	repoURL := repoCmd.Val()
+	iterations, _ := strconv.Atoi(iterationCmd.Val())
+	if repoURL == "" && s.sqlite != nil {
+		if t, err := s.sqlite.Get(ctx, sessionID); err == nil {
+			repoURL = t.RepoURL
+			iterations = t.Iteration
+		}
+	}
+
+	if s.blobs != nil {
+		values := append([]string{resultCmd.Val()}, transcriptCmd.Val()...)
+		for _, v := range values {
+			if key, ok := strings.CutPrefix(v, blobPointerPrefix); ok {
+				if err := s.blobs.Delete(ctx, key); err != nil {
+					return fmt.Errorf("deleting blob %s: %w", key, err)
+				}
+			}
+		}
+		// Patches are archived under fixed keys, one per iteration.
+		blobs := historyBlobs(historyCmd.Val())
+		for n := 1; n <= iterations; n++ {
+			blobs = append(blobs, patchKey(sessionID, n))
+		}
+		for _, key := range blobs {
+			if err := s.blobs.Delete(ctx, key); err != nil {
+				return fmt.Errorf("deleting blob %s: %w", key, err)
+			}
+		}
+		// Attachments may reference objects the client owns; only the
+		// session's own uploads are removed.
+		for _, v := range attachmentsCmd.Val() {
+			if key, ok := strings.CutPrefix(v, blobPointerPrefix); ok && strings.HasPrefix(key, blobKey(sessionID)) {
+				if err := s.blobs.Delete(ctx, key); err != nil {
+					return fmt.Errorf("deleting blob %s: %w", key, err)
+				}
+			}
+		}
+	}
+
+	tx := rdb.TxPipeline()
+	tx.Del(ctx, stateKey, resultKey, transcriptKey, attachmentsKey,
+		s.redis.Key("session", sessionID, "history"),
+		s.redis.Key("session", sessionID, "iterations"))
+	tx.SRem(ctx, s.redis.Key("sessions:index"), sessionID)
+	if name := RepoFullName(repoURL); name != "" {
+		tx.ZRem(ctx, s.repoIndexKey(name), sessionID)
+	}
+	tx.ZRem(ctx, s.deletedKey(), sessionID)
+	tx.ZRem(ctx, s.autoMergeKey(), sessionID)
+	if _, err := tx.Exec(ctx); err != nil {
+		return fmt.Errorf("deleting session keys: %w", err)
+	}
+
+	if s.sqlite != nil {
+		if err := s.sqlite.Delete(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+	slog.Info("session purged", "session_id", sessionID)
+	return nil
+}
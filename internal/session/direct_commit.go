@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/ai"
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// commitDirect handles create-pr with direct=true: trivial changes (see
+// git.direct_commit) are committed straight to the target branch and no PR is
+// opened. The session is creating_pr while it pushes and completed again
+// afterwards; anything not trivial is refused with 400 so the caller can fall
+// back to a regular PR.
+func (s *PRService) commitDirect(ctx context.Context, t *Session, req CreatePRRequest, workDir string, ignoreGlobs []string) (*CreatePRResponse, error) {
+	if s.cfg.DirectCommit == nil {
+		return nil, apperror.Validation("direct commits are disabled (git.direct_commit.enabled)")
+	}
+	if t.HasPR() {
+		return nil, apperror.Validation("session already has a PR — push to it instead")
+	}
+	if t.Config != nil && t.Config.ForkURL != "" {
+		return nil, apperror.Validation("direct commits need push access to the repository, not a fork")
+	}
+
+	repoInfo, err := s.parseRepo(ctx, t)
+	if err != nil {
+		return nil, fmt.Errorf("parsing repo URL: %w", err)
+	}
+	target := req.TargetBranch
+	if target == "" && t.Config != nil {
+		target = t.Config.TargetBranch
+	}
+	if target == "" {
+		target = gitpkg.ResolveDefaultBranch(ctx, workDir, repoInfo, t.AccessToken)
+	}
+
+	reason, err := gitpkg.CheckTrivialChange(ctx, workDir, "refs/remotes/origin/"+target, ignoreGlobs, *s.cfg.DirectCommit)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		appErr := apperror.Validation("change is not trivial enough for a direct commit: %s", reason)
+		appErr.Fields = map[string]string{"direct": reason}
+		return nil, appErr
+	}
+	if err := gitpkg.CheckAccess(ctx, repoInfo, t.AccessToken, gitpkg.AccessPush); err != nil {
+		return nil, accessAppError(err)
+	}
+	if err := gitpkg.ValidatePushTarget(ctx, repoInfo, nil, t.AccessToken, target, target); err != nil {
+		return nil, pushTargetAppError(err)
+	}
+
+	// Busy while committing and pushing, so instruct and another create-pr
+	// are refused meanwhile; the session returns to its status either way.
+	previousStatus := t.Status
+	if err := s.sessionService.UpdateStatus(ctx, t.ID, StatusCreatingPR); err != nil {
+		return nil, fmt.Errorf("transitioning to creating_pr: %w", err)
+	}
+	defer func() { _ = s.sessionService.UpdateStatus(ctx, t.ID, previousStatus) }()
+
+	title := req.Title
+	if title == "" {
+		changedPaths, _ := gitpkg.ChangedFiles(ctx, workDir, ignoreGlobs...)
+		title = s.analyzer.Analyze(ctx, t.Prompt, t.ID, changedPaths).PRTitle
+	}
+	commitMsg := gitpkg.FormatCommitMessage(title, t.ID, s.cfg.CommitAuthor, s.cfg.CommitEmail)
+	if s.ai != nil {
+		if diffOut, diffErr := gitpkg.GetUnstagedDiff(ctx, workDir, ignoreGlobs...); diffErr == nil && diffOut != "" {
+			if generated := ai.GenerateCommitMessage(ctx, s.ai, diffOut, t.Prompt); generated != "" {
+				commitMsg = generated
+			}
+		}
+	}
+
+	sha, err := gitpkg.CommitAndPushDirect(ctx, gitpkg.PushExistingOptions{
+		WorkDir:     workDir,
+		BranchName:  target,
+		CommitMsg:   commitMsg,
+		AuthorName:  s.cfg.CommitAuthor,
+		AuthorEmail: s.cfg.CommitEmail,
+		Token:       t.AccessToken,
+		IgnoreGlobs: ignoreGlobs,
+	})
+	if err != nil {
+		return nil, pushTargetAppError(divergedAppError(err))
+	}
+
+	slog.Info("changes committed to target branch", "session_id", t.ID, "branch", target, "commit", sha)
+	if s.events != nil {
+		if err := s.events.EmitGit(ctx, t.ID, "direct_commit", map[string]string{
+			"branch": target,
+			"commit": sha,
+		}); err != nil {
+			slog.Warn("failed to emit direct_commit", "session_id", t.ID, "error", err)
+		}
+	}
+	return &CreatePRResponse{Branch: target, Commit: sha}, nil
+}
@@ -0,0 +1,154 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// evictBatch is how many session states are read per Redis round-trip.
+const evictBatch = 200
+
+var errSessionMoved = errors.New("session changed while being evicted")
+
+// EvictResult reports an EvictFinished pass.
+type EvictResult struct {
+	DryRun     bool     `json:"dry_run"`
+	Sessions   []string `json:"sessions"`         // evicted, or evictable on a dry run
+	FreedBytes int64    `json:"freed_bytes"`      // MEMORY USAGE of their keys before removal
+	Failed     int      `json:"failed,omitempty"` // candidates skipped after an error
+}
+
+// evictKeys are the Redis keys an eviction drops. State, result and
+// iterations live on in SQLite; the event history, archived chunks included,
+// is gone for good. Archived patches are kept for the diff endpoint.
+func (s *Service) evictKeys(sessionID string) []string {
+	return []string{
+		s.redis.Key("session", sessionID, "state"),
+		s.redis.Key("session", sessionID, "result"),
+		s.redis.Key("session", sessionID, "iterations"),
+		s.redis.Key("session", sessionID, "history"),
+	}
+}
+
+// EvictFinished frees Redis memory held by sessions that finished before
+// before: their keys are removed ahead of the state TTL and reads fall back
+// to the SQLite mirror, which is refreshed first. Soft-deleted sessions are
+// left to the purger. Transcripts and attachments keep their own TTLs.
+// A dry run only reports what would go.
+func (s *Service) EvictFinished(ctx context.Context, before time.Time, dryRun bool) (*EvictResult, error) {
+	if s.sqlite == nil {
+		return nil, apperror.Conflict("evicting finished sessions needs the SQLite mirror")
+	}
+	rdb := s.redis.Unwrap()
+	ids, err := rdb.SMembers(ctx, s.redis.Key("sessions:index")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing session index: %w", err)
+	}
+
+	res := &EvictResult{DryRun: dryRun, Sessions: []string{}}
+	for start := 0; start < len(ids); start += evictBatch {
+		batch := ids[start:min(start+evictBatch, len(ids))]
+		pipe := rdb.Pipeline()
+		cmds := make([]*redis.SliceCmd, len(batch))
+		for i, id := range batch {
+			cmds[i] = pipe.HMGet(ctx, s.redis.Key("session", id, "state"), "status", "finished_at", "deleted_at")
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("reading session states: %w", err)
+		}
+
+		for i, id := range batch {
+			vals := cmds[i].Val()
+			if len(vals) != 3 || !evictable(vals, before) {
+				continue
+			}
+			bytes, _, _ := s.redis.KeysMemory(ctx, s.evictKeys(id))
+			if !dryRun {
+				if err := s.evict(ctx, id, vals[0].(string), vals[1].(string)); err != nil {
+					if !errors.Is(err, errSessionMoved) {
+						slog.Warn("session eviction failed", "session_id", id, "error", err)
+						res.Failed++
+					}
+					continue
+				}
+			}
+			res.Sessions = append(res.Sessions, id)
+			res.FreedBytes += bytes
+		}
+	}
+	if !dryRun && len(res.Sessions) > 0 {
+		slog.Info("finished sessions evicted from redis", "count", len(res.Sessions), "freed_bytes", res.FreedBytes, "before", before)
+	}
+	return res, nil
+}
+
+// evictable reports whether an HMGET of status, finished_at and deleted_at
+// describes a live, finished session older than before. Completed and
+// pr_created sessions still take instructions and PRs, which need the Redis
+// state, so only truly terminal ones qualify.
+func evictable(vals []interface{}, before time.Time) bool {
+	status, _ := vals[0].(string)
+	finished, _ := vals[1].(string)
+	deleted, _ := vals[2].(string)
+	if !IsFinished(Status(status)) || finished == "" || deleted != "" {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339Nano, finished)
+	return err == nil && at.Before(before)
+}
+
+// evict mirrors one session into SQLite and drops its keys, unless its
+// status or finish time changed meanwhile (e.g. a follow-up instruction).
+func (s *Service) evict(ctx context.Context, sessionID, status, finishedAt string) error {
+	if err := s.syncSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("refreshing sqlite mirror: %w", err)
+	}
+	rdb := s.redis.Unwrap()
+	keys := s.evictKeys(sessionID)
+	stateKey, resultKey := keys[0], keys[1]
+	result, _ := rdb.Get(ctx, resultKey).Result()
+	history, _ := rdb.LRange(ctx, s.historyKey(sessionID), 0, -1).Result()
+
+	err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+		cur, err := tx.HMGet(ctx, stateKey, "status", "finished_at").Result()
+		if err != nil || len(cur) != 2 || cur[0] != status || cur[1] != finishedAt {
+			return errSessionMoved
+		}
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Del(ctx, keys...)
+			p.SRem(ctx, s.redis.Key("sessions:index"), sessionID)
+			return nil
+		})
+		return err
+	}, stateKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return errSessionMoved
+	}
+	if err != nil {
+		return err
+	}
+
+	// The mirror holds the resolved result now; the offloaded copy is unused.
+	if key, ok := strings.CutPrefix(result, blobPointerPrefix); ok && s.blobs != nil {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			slog.Warn("deleting evicted result blob failed", "session_id", sessionID, "key", key, "error", err)
+		}
+	}
+	for _, key := range historyBlobs(history) {
+		if s.blobs == nil {
+			break
+		}
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			slog.Warn("deleting evicted history blob failed", "session_id", sessionID, "key", key, "error", err)
+		}
+	}
+	return nil
+}
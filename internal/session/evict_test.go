@@ -0,0 +1,37 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvictable(t *testing.T) {
+	before := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	old := before.Add(-time.Hour).Format(time.RFC3339Nano)
+	recent := before.Add(time.Hour).Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name string
+		vals []interface{}
+		want bool
+	}{
+		{"failed long ago", []interface{}{"failed", old, nil}, true},
+		{"canceled long ago", []interface{}{"canceled", old, nil}, true},
+		{"merged long ago", []interface{}{"pr_merged", old, nil}, true},
+		{"finished recently", []interface{}{"failed", recent, nil}, false},
+		{"completed still takes instructions", []interface{}{"completed", old, nil}, false},
+		{"pr_created still takes instructions", []interface{}{"pr_created", old, nil}, false},
+		{"still running", []interface{}{"running", old, nil}, false},
+		{"awaiting instruction", []interface{}{"awaiting_instruction", old, nil}, false},
+		{"no finish time", []interface{}{"failed", nil, nil}, false},
+		{"soft-deleted", []interface{}{"failed", old, old}, false},
+		{"state gone", []interface{}{nil, nil, nil}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evictable(tt.vals, before); got != tt.want {
+				t.Errorf("evictable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// ListStale returns IDs of pending and awaiting_instruction sessions not
+// updated since before (SQLite-backed; nil without SQLite).
+func (s *Service) ListStale(ctx context.Context, before time.Time) ([]string, error) {
+	if s.sqlite == nil {
+		return nil, nil
+	}
+	return s.sqlite.ListStaleSessions(ctx, before)
+}
+
+// ExpireStale fails a pending or awaiting_instruction session abandoned for
+// longer than age and returns it. The live Redis state is re-checked: a session that
+// moved on since it was listed, or is still inside a hold set by Await, is
+// left alone and a 409 returned.
+func (s *Service) ExpireStale(ctx context.Context, sessionID string, age time.Duration) (*Session, error) {
+	t, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusPending && t.Status != StatusAwaitingInstruction {
+		return nil, apperror.Conflict("session is %s, no longer stale", t.Status)
+	}
+	if t.Status == StatusAwaitingInstruction && t.AwaitExpiresAt != nil && !AwaitExpired(t, time.Now()) {
+		return nil, apperror.Conflict("session is held until %s", t.AwaitExpiresAt.Format(time.RFC3339))
+	}
+	reason := StaleReason(t.Status, age)
+	if err := s.FailStuck(ctx, sessionID, reason); err != nil {
+		return nil, err
+	}
+	t.Status = StatusFailed
+	t.Error = reason
+	return t, nil
+}
+
+// StaleReason is the error stored on a session expired as abandoned.
+func StaleReason(status Status, age time.Duration) string {
+	return fmt.Sprintf("session expired after %s in %s without activity", age.Round(time.Second), status)
+}
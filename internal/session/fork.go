@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// checkForkURL runs config.fork_url through the same URL and repository
+// policies as repo_url — the session pushes to and fetches from it — and
+// returns its normalized form.
+func (s *Service) checkForkURL(repoURL, forkURL string) (string, error) {
+	normalized, err := s.repoURLPolicy.Normalize(forkURL)
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) && appErr.Fields["repo_url"] != "" {
+			reason := appErr.Fields["repo_url"]
+			appErr = apperror.Validation("invalid fork_url: %s", reason)
+			appErr.Fields = map[string]string{"config.fork_url": reason}
+			return "", appErr
+		}
+		return "", err
+	}
+	if err := s.repoPolicy.Check(normalized); err != nil {
+		return "", err
+	}
+	if err := validateForkURL(repoURL, normalized); err != nil {
+		appErr := apperror.Validation("invalid fork_url: %v", err)
+		appErr.Fields = map[string]string{"config.fork_url": err.Error()}
+		return "", appErr
+	}
+	return normalized, nil
+}
+
+// validateForkURL checks that config.fork_url names another repository on the
+// same host as repoURL — PRs can only be opened across repositories of one
+// provider instance.
+func validateForkURL(repoURL, forkURL string) error {
+	repo, err := gitpkg.ParseRepoURL(repoURL, nil)
+	if err != nil {
+		return err
+	}
+	fork, err := gitpkg.ParseRepoURL(forkURL, nil)
+	if err != nil {
+		return err
+	}
+	if fork.Host != repo.Host {
+		return fmt.Errorf("fork must be hosted on %s, got %s", repo.Host, fork.Host)
+	}
+	if strings.EqualFold(fork.FullName(), repo.FullName()) {
+		return fmt.Errorf("fork is the repository itself")
+	}
+	return nil
+}
+
+// pushTarget prepares the remote the session branch is pushed to: origin, or
+// the fork from config.fork_url, which is added to the workspace and returned
+// as headRepo for PR creation.
+func (s *PRService) pushTarget(ctx context.Context, t *Session, workDir string, repo *gitpkg.RepoInfo) (remote string, headRepo *gitpkg.RepoInfo, err error) {
+	if t.Config == nil || t.Config.ForkURL == "" {
+		return "", nil, nil
+	}
+	fork, err := gitpkg.ParseRepoURL(t.Config.ForkURL, s.cfg.ProviderDomains)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing fork URL: %w", err)
+	}
+	if repo != nil {
+		fork.Provider, fork.APIBaseURL, fork.ErrorMaxBytes = repo.Provider, repo.APIBaseURL, repo.ErrorMaxBytes
+	}
+	if err := gitpkg.SetRemote(ctx, workDir, gitpkg.ForkRemote, t.Config.ForkURL); err != nil {
+		return "", nil, fmt.Errorf("configuring fork remote: %w", err)
+	}
+	return gitpkg.ForkRemote, fork, nil
+}
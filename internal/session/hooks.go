@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/hooks"
+	"github.com/freema/codeforge/internal/runas"
+)
+
+// SetHooks wires the pre_pr hooks run before create-pr and push commit
+// anything, as the session's CLI user from runAs (nil = runas.Default).
+// Optional — when unset, no hooks run.
+func (s *PRService) SetHooks(h *hooks.Runner, runAs runas.Strategy) {
+	if runAs == nil {
+		runAs = runas.Default()
+	}
+	s.hooks = h
+	s.runAs = runAs
+}
+
+// runPrePRHooks runs the pre_pr hooks in the workspace. A failed hook refuses
+// the request with 409 hook_failed.
+func (s *PRService) runPrePRHooks(ctx context.Context, t *Session, workDir string) error {
+	if s.hooks == nil {
+		return nil
+	}
+	id, err := s.runAs.Identity(t.ID)
+	if err != nil {
+		return fmt.Errorf("resolving hook user: %w", err)
+	}
+	if id != nil && id.Ephemeral {
+		// Between runs the workspace belongs to the server; lend it to the
+		// session's user for the hooks and take it back afterwards.
+		if err := runas.Chown(workDir, id); err != nil {
+			return fmt.Errorf("handing workspace to hook user: %w", err)
+		}
+		defer func() {
+			if err := s.runAs.Release(t.ID, workDir); err != nil {
+				slog.Warn("releasing hook user failed", "session_id", t.ID, "error", err)
+			}
+		}()
+	}
+
+	err = s.hooks.Run(ctx, hooks.Event{
+		Stage:     hooks.PrePR,
+		SessionID: t.ID,
+		RepoURL:   t.RepoURL,
+		Iteration: t.Iteration,
+		WorkDir:   workDir,
+		RunAs:     id,
+	})
+	var he *hooks.Error
+	if !errors.As(err, &he) {
+		return err
+	}
+	appErr := apperror.Conflict("%s", he.Error())
+	appErr.Code = "hook_failed"
+	return appErr
+}
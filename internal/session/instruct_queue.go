@@ -0,0 +1,187 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// Instructions are serialized per session: the instruct lock is held from
+// the moment a follow-up is accepted until the worker has finished its
+// iteration. Follow-ups arriving meanwhile — or while the first run is still
+// going — are queued and started in order by FinishIteration.
+const (
+	// instructLockTTL bounds how long a lock outlives a worker that never
+	// reports back (crash); queued follow-ups expire with it.
+	instructLockTTL = 24 * time.Hour
+	// instructLockDraining marks the lock while an instruction is being
+	// accepted, before it is handed to its iteration number.
+	instructLockDraining = "accepting"
+	// instructLockBranchUpdate marks the lock while a rebase rewrites the
+	// session's branch.
+	instructLockBranchUpdate = "updating_branch"
+)
+
+// releaseInstructLock deletes the lock only while it still holds ARGV[1], so
+// a finished iteration never releases a lock its successor took over.
+var releaseInstructLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// QueuedInstruction is a follow-up waiting for the in-flight run.
+type QueuedInstruction struct {
+	Prompt   string           `json:"prompt"`
+	Config   *IterationConfig `json:"config,omitempty"`
+	QueuedAt time.Time        `json:"queued_at"`
+}
+
+func (s *Service) instructLockKey(sessionID string) string {
+	return s.redis.Key("session", sessionID, "instruct_lock")
+}
+
+func (s *Service) instructQueueKey(sessionID string) string {
+	return s.redis.Key("session", sessionID, "instruct_queue")
+}
+
+// queueInstruction appends a follow-up to the session's queue and sets
+// t.QueuePosition.
+func (s *Service) queueInstruction(ctx context.Context, t *Session, prompt string, cfg *IterationConfig) (*Session, error) {
+	if cfg.IsZero() {
+		cfg = nil
+	}
+	item := QueuedInstruction{Prompt: prompt, Config: cfg, QueuedAt: time.Now().UTC()}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling queued instruction: %w", err)
+	}
+
+	key := s.instructQueueKey(t.ID)
+	pipe := s.redis.Unwrap().TxPipeline()
+	push := pipe.RPush(ctx, key, string(data))
+	pipe.Expire(ctx, key, instructLockTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("queueing instruction: %w", err)
+	}
+
+	t.QueuedInstructions = append(t.QueuedInstructions, item)
+	t.QueuePosition = int(push.Val())
+	slog.Info("instruction queued", "session_id", t.ID, "position", t.QueuePosition, "status", t.Status)
+
+	// The run holding the lock may have finished between our checks and the
+	// push; start the queue here then, or the follow-up would wait forever.
+	s.startQueued(ctx, t.ID)
+	return t, nil
+}
+
+// FinishIteration releases the instruct lock held by iteration once the
+// worker is done with it and starts the next queued follow-up, if any.
+func (s *Service) FinishIteration(ctx context.Context, sessionID string, iteration int) {
+	lockKey := s.instructLockKey(sessionID)
+	if err := releaseInstructLock.Run(ctx, s.redis.Unwrap(), []string{lockKey}, strconv.Itoa(iteration)).Err(); err != nil {
+		slog.Warn("releasing instruct lock failed", "session_id", sessionID, "error", err)
+		return
+	}
+	s.startQueued(ctx, sessionID)
+}
+
+// lockBranchUpdate takes the instruct lock for a rebase, so the branch is
+// never rewritten while an iteration works on it.
+func (s *Service) lockBranchUpdate(ctx context.Context, sessionID string) error {
+	acquired, err := s.redis.Unwrap().SetNX(ctx, s.instructLockKey(sessionID), instructLockBranchUpdate, instructLockTTL).Result()
+	if err != nil {
+		return fmt.Errorf("acquiring instruct lock: %w", err)
+	}
+	if !acquired {
+		return apperror.Conflict("an instruction is in progress, try again once it has finished")
+	}
+	return nil
+}
+
+// unlockBranchUpdate releases the lock taken by lockBranchUpdate, unless a
+// conflict resolution iteration took it over, and starts a queued follow-up.
+func (s *Service) unlockBranchUpdate(ctx context.Context, sessionID string) {
+	lockKey := s.instructLockKey(sessionID)
+	if err := releaseInstructLock.Run(ctx, s.redis.Unwrap(), []string{lockKey}, instructLockBranchUpdate).Err(); err != nil {
+		slog.Warn("releasing instruct lock failed", "session_id", sessionID, "error", err)
+		return
+	}
+	s.startQueued(ctx, sessionID)
+}
+
+// startQueued starts the oldest queued follow-up when the session is idle and
+// no instruction holds the lock. Follow-ups that can no longer run (session
+// failed, iteration limit) are dropped with a warning.
+func (s *Service) startQueued(ctx context.Context, sessionID string) {
+	rdb := s.redis.Unwrap()
+	lockKey := s.instructLockKey(sessionID)
+	queueKey := s.instructQueueKey(sessionID)
+
+	acquired, err := rdb.SetNX(ctx, lockKey, instructLockDraining, instructLockTTL).Result()
+	if err != nil || !acquired {
+		return // someone else's instruction is in flight; it drains the queue when done
+	}
+	release := func() {
+		_ = releaseInstructLock.Run(ctx, rdb, []string{lockKey}, instructLockDraining).Err()
+	}
+
+	for {
+		t, err := s.Get(ctx, sessionID)
+		if err != nil {
+			release()
+			return
+		}
+		switch {
+		case IsFinished(t.Status):
+			if len(t.QueuedInstructions) > 0 {
+				slog.Warn("dropping queued instructions of finished session", "session_id", sessionID, "status", t.Status, "count", len(t.QueuedInstructions))
+			}
+			rdb.Del(ctx, queueKey)
+			release()
+			return
+		case t.Status != StatusCompleted && t.Status != StatusPRCreated && t.Status != StatusAwaitingInstruction:
+			release() // still busy; the worker drains the queue when it finishes
+			return
+		}
+
+		raw, err := rdb.LPop(ctx, queueKey).Result()
+		if err != nil {
+			release() // empty (redis.Nil) or unreadable
+			return
+		}
+		var next QueuedInstruction
+		if err := json.Unmarshal([]byte(raw), &next); err != nil {
+			slog.Warn("dropping malformed queued instruction", "session_id", sessionID, "error", err)
+			continue
+		}
+		instructed, err := s.instruct(ctx, sessionID, next.Prompt, instructOptions{overrides: next.Config, lockHeld: true})
+		if err != nil {
+			slog.Warn("dropping queued instruction", "session_id", sessionID, "error", err)
+			continue
+		}
+		slog.Info("queued instruction started", "session_id", sessionID, "iteration", instructed.Iteration)
+		return
+	}
+}
+
+func decodeQueuedInstructions(items []string) []QueuedInstruction {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]QueuedInstruction, 0, len(items))
+	for _, raw := range items {
+		var q QueuedInstruction
+		if err := json.Unmarshal([]byte(raw), &q); err == nil {
+			out = append(out, q)
+		}
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package session
+
+import "testing"
+
+func TestDecodeQueuedInstructions(t *testing.T) {
+	items := []string{
+		`{"prompt":"first","queued_at":"2026-10-15T10:00:00Z"}`,
+		`not json`,
+		`{"prompt":"second","config":{"ai_model":"haiku"},"queued_at":"2026-10-15T10:01:00Z"}`,
+	}
+	got := decodeQueuedInstructions(items)
+	if len(got) != 2 || got[0].Prompt != "first" || got[1].Prompt != "second" {
+		t.Fatalf("decoded %+v", got)
+	}
+	if got[1].Config == nil || got[1].Config.AIModel != "haiku" {
+		t.Errorf("config not decoded: %+v", got[1].Config)
+	}
+	if decodeQueuedInstructions(nil) != nil {
+		t.Error("empty queue should decode to nil")
+	}
+}
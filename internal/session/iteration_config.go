@@ -0,0 +1,60 @@
+package session
+
+import "encoding/json"
+
+// IterationConfig overrides session config for a single iteration, set by an
+// instruction — e.g. a cheap model drafts and an expensive one finishes.
+// Zero fields keep the session value. It is recorded on the iteration.
+type IterationConfig struct {
+	AIModel        string  `json:"ai_model,omitempty"`
+	MaxTurns       int     `json:"max_turns,omitempty" validate:"gte=0"`
+	MaxBudgetUSD   float64 `json:"max_budget_usd,omitempty" validate:"gte=0"`
+	TimeoutSeconds int     `json:"timeout_seconds,omitempty" validate:"gte=0"`
+}
+
+// IsZero reports whether c overrides nothing.
+func (c *IterationConfig) IsZero() bool {
+	return c == nil || *c == IterationConfig{}
+}
+
+// EffectiveConfig returns the session config with the current iteration's
+// overrides applied. The session's own Config is left untouched.
+func (t *Session) EffectiveConfig() *Config {
+	o := t.IterationConfig
+	if o.IsZero() {
+		return t.Config
+	}
+	var cfg Config
+	if t.Config != nil {
+		cfg = *t.Config
+	}
+	if o.AIModel != "" {
+		cfg.AIModel = o.AIModel
+	}
+	if o.MaxTurns > 0 {
+		cfg.MaxTurns = o.MaxTurns
+	}
+	if o.MaxBudgetUSD > 0 {
+		cfg.MaxBudgetUSD = o.MaxBudgetUSD
+	}
+	if o.TimeoutSeconds > 0 {
+		cfg.TimeoutSeconds = o.TimeoutSeconds
+	}
+	return &cfg
+}
+
+func marshalIterationConfig(c *IterationConfig) string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func unmarshalIterationConfig(data string) *IterationConfig {
+	if data == "" {
+		return nil
+	}
+	var c IterationConfig
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,48 @@
+package session
+
+import "testing"
+
+func TestEffectiveConfig(t *testing.T) {
+	base := &Config{AIModel: "opus", MaxTurns: 50, MaxBudgetUSD: 10, TimeoutSeconds: 900, AllowedTools: "Read"}
+
+	tests := []struct {
+		name      string
+		overrides *IterationConfig
+		want      Config
+	}{
+		{"no overrides", nil, *base},
+		{"empty overrides", &IterationConfig{}, *base},
+		{"cheap draft", &IterationConfig{AIModel: "haiku", MaxTurns: 5, MaxBudgetUSD: 0.5, TimeoutSeconds: 120},
+			Config{AIModel: "haiku", MaxTurns: 5, MaxBudgetUSD: 0.5, TimeoutSeconds: 120, AllowedTools: "Read"}},
+		{"model only", &IterationConfig{AIModel: "sonnet"},
+			Config{AIModel: "sonnet", MaxTurns: 50, MaxBudgetUSD: 10, TimeoutSeconds: 900, AllowedTools: "Read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{Config: base, IterationConfig: tt.overrides}
+			got := s.EffectiveConfig()
+			if got.AIModel != tt.want.AIModel || got.MaxTurns != tt.want.MaxTurns || got.MaxBudgetUSD != tt.want.MaxBudgetUSD ||
+				got.TimeoutSeconds != tt.want.TimeoutSeconds || got.AllowedTools != tt.want.AllowedTools {
+				t.Errorf("EffectiveConfig = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+	if base.AIModel != "opus" {
+		t.Error("session config must not be modified")
+	}
+
+	s := &Session{IterationConfig: &IterationConfig{MaxTurns: 3}}
+	if got := s.EffectiveConfig(); got == nil || got.MaxTurns != 3 {
+		t.Errorf("overrides without session config = %+v", got)
+	}
+}
+
+func TestIterationConfigRoundTrip(t *testing.T) {
+	in := &IterationConfig{AIModel: "haiku", MaxTurns: 5, MaxBudgetUSD: 0.25, TimeoutSeconds: 60}
+	if got := unmarshalIterationConfig(marshalIterationConfig(in)); got == nil || *got != *in {
+		t.Errorf("round trip = %+v, want %+v", got, in)
+	}
+	if unmarshalIterationConfig("") != nil {
+		t.Error("empty field should decode to nil")
+	}
+}
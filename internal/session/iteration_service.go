@@ -0,0 +1,212 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// IterationService exposes per-iteration workspace history built from the
+// checkpoints the executor records around every CLI run.
+type IterationService struct {
+	sessionService    *Service
+	workspaceResolver WorkspacePathResolver
+	workspaceBase     string
+}
+
+// NewIterationService creates an iteration service.
+func NewIterationService(sessionService *Service, workspaceResolver WorkspacePathResolver, workspaceBase string) *IterationService {
+	return &IterationService{
+		sessionService:    sessionService,
+		workspaceResolver: workspaceResolver,
+		workspaceBase:     workspaceBase,
+	}
+}
+
+// Page sizes of the iteration list.
+const (
+	defaultIterationLimit = 20
+	maxIterationLimit     = 100
+)
+
+// IterationPage is one page of a session's iteration history, oldest first.
+type IterationPage struct {
+	SessionID  string      `json:"session_id"`
+	Iterations []Iteration `json:"iterations"`
+	Total      int         `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+}
+
+// List returns the iteration records of a session, limit at a time starting
+// at offset (limit 0 = 20, at most 100). Unlike ?include=iterations on the
+// session, a failure to load them is an error, not an empty list.
+func (s *IterationService) List(ctx context.Context, sessionID string, limit, offset int) (*IterationPage, error) {
+	if limit < 0 || offset < 0 {
+		return nil, apperror.Validation("limit and offset must not be negative")
+	}
+	if _, err := s.sessionService.Get(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	if limit == 0 {
+		limit = defaultIterationLimit
+	}
+	limit = min(limit, maxIterationLimit)
+	iterations, total, err := s.sessionService.GetIterationPage(ctx, sessionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &IterationPage{
+		SessionID:  sessionID,
+		Iterations: iterations,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+	}, nil
+}
+
+// Get returns the record of iteration n of a session.
+func (s *IterationService) Get(ctx context.Context, sessionID string, n int) (*Iteration, error) {
+	t, err := s.sessionService.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	iterations, err := s.sessionService.GetIterations(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range iterations {
+		if iterations[i].Number == n {
+			return &iterations[i], nil
+		}
+	}
+	if n <= t.Iteration {
+		return nil, apperror.NotFound("iteration %d has no record yet (still running, or not started)", n)
+	}
+	return nil, apperror.NotFound("iteration %d not found (session has %d)", n, t.Iteration)
+}
+
+// IterationDiff is the change a single iteration made to the workspace.
+type IterationDiff struct {
+	SessionID string `json:"session_id"`
+	Iteration int    `json:"iteration"`
+	Diff      string `json:"diff"`
+}
+
+// Diff returns the unified diff produced by iteration n of a session, from
+// the workspace checkpoints or, once the workspace is gone, the archived patch.
+func (s *IterationService) Diff(ctx context.Context, sessionID string, n int) (*IterationDiff, error) {
+	t, err := s.sessionService.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > t.Iteration {
+		return nil, apperror.NotFound("iteration %d not found (session has %d)", n, t.Iteration)
+	}
+
+	workDir := s.workDir(ctx, sessionID)
+	before := gitpkg.CheckpointRef(n, gitpkg.CheckpointBefore)
+	after := gitpkg.CheckpointRef(n, gitpkg.CheckpointAfter)
+	var diff string
+	if gitpkg.CheckpointExists(ctx, workDir, before) && gitpkg.CheckpointExists(ctx, workDir, after) {
+		diff, err = gitpkg.DiffCheckpoints(ctx, workDir, before, after)
+	} else {
+		// The workspace is gone; the blob store may still have the patch.
+		diff, err = s.sessionService.archivedPatch(ctx, sessionID, n)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &IterationDiff{SessionID: sessionID, Iteration: n, Diff: diff}, nil
+}
+
+// Revert undoes iteration n in the workspace and records the undo as a new
+// iteration, so the history stays append-only and the revert itself can be
+// diffed or reverted. Only idle sessions (completed, pr_created) qualify.
+func (s *IterationService) Revert(ctx context.Context, sessionID string, n int) (*Iteration, error) {
+	t, err := s.sessionService.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !IsIdle(t.Status) {
+		return nil, apperror.Conflict("session is %s, only completed sessions can be reverted", t.Status)
+	}
+	if n < 1 || n > t.Iteration {
+		return nil, apperror.NotFound("iteration %d not found (session has %d)", n, t.Iteration)
+	}
+
+	workDir := s.workDir(ctx, sessionID)
+	before := gitpkg.CheckpointRef(n, gitpkg.CheckpointBefore)
+	after := gitpkg.CheckpointRef(n, gitpkg.CheckpointAfter)
+	if !gitpkg.CheckpointExists(ctx, workDir, before) || !gitpkg.CheckpointExists(ctx, workDir, after) {
+		return nil, apperror.NotFound("no checkpoint for iteration %d (still running, failed, or workspace cleaned up)", n)
+	}
+
+	iter := Iteration{
+		Number:    t.Iteration + 1,
+		Prompt:    fmt.Sprintf("Revert iteration %d", n),
+		Status:    StatusCompleted,
+		StartedAt: time.Now().UTC(),
+	}
+
+	revertBefore := gitpkg.CheckpointRef(iter.Number, gitpkg.CheckpointBefore)
+	revertAfter := gitpkg.CheckpointRef(iter.Number, gitpkg.CheckpointAfter)
+	recorded := false
+	defer func() {
+		if recorded {
+			return
+		}
+		// A failed revert leaves no checkpoints behind for later diffs and
+		// reverts to pick up.
+		for _, ref := range []string{revertBefore, revertAfter} {
+			if err := gitpkg.DeleteCheckpoint(context.WithoutCancel(ctx), workDir, ref); err != nil {
+				slog.Warn("removing revert checkpoint failed", "session_id", sessionID, "ref", ref, "error", err)
+			}
+		}
+	}()
+
+	if _, err := gitpkg.Checkpoint(ctx, workDir, revertBefore); err != nil {
+		return nil, fmt.Errorf("checkpointing before revert: %w", err)
+	}
+	if err := gitpkg.RevertCheckpoint(ctx, workDir, before, after); err != nil {
+		return nil, apperror.Conflict("iteration %d cannot be reverted cleanly, later changes overlap: %v", n, err)
+	}
+	if _, err := gitpkg.Checkpoint(ctx, workDir, revertAfter); err != nil {
+		return nil, fmt.Errorf("checkpointing after revert: %w", err)
+	}
+	if err := s.sessionService.ArchivePatch(ctx, sessionID, iter.Number, workDir); err != nil {
+		slog.Warn("archiving revert patch failed", "session_id", sessionID, "error", err)
+	}
+
+	changes, err := gitpkg.CalculateChanges(ctx, workDir, t.IgnoreGlobs(workDir)...)
+	if err != nil {
+		slog.Warn("failed to calculate changes after revert", "session_id", sessionID, "error", err)
+	}
+	ended := time.Now().UTC()
+	iter.Changes = changes
+	iter.Result = fmt.Sprintf("Reverted the changes made by iteration %d.", n)
+	iter.EndedAt = &ended
+
+	if err := s.sessionService.RecordIteration(ctx, sessionID, t.Iteration, iter); err != nil {
+		return nil, err
+	}
+	recorded = true
+
+	slog.Info("iteration reverted", "session_id", sessionID, "reverted", n, "iteration", iter.Number)
+	return &iter, nil
+}
+
+func (s *IterationService) workDir(ctx context.Context, sessionID string) string {
+	if s.workspaceResolver != nil {
+		if resolved := s.workspaceResolver.WorkspacePath(ctx, sessionID); resolved != "" {
+			return resolved
+		}
+	}
+	return filepath.Join(s.workspaceBase, sessionID)
+}
@@ -0,0 +1,72 @@
+//go:build integration
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestIterationService_ListAndGet(t *testing.T) {
+	svc, _ := setupTestService(t)
+	ctx := context.Background()
+	iters := NewIterationService(svc, nil, t.TempDir())
+
+	sess := createTestSession(t, svc, StatusCompleted)
+	for n := 1; n <= 3; n++ {
+		iter := Iteration{Number: n, Prompt: "step", Status: StatusCompleted, Usage: &UsageInfo{InputTokens: n * 100}}
+		if err := svc.SaveIteration(ctx, sess.ID, iter); err != nil {
+			t.Fatalf("SaveIteration: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		want          []int
+	}{
+		{"default page", 0, 0, []int{1, 2, 3}},
+		{"first page", 2, 0, []int{1, 2}},
+		{"second page", 2, 2, []int{3}},
+		{"past the end", 2, 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := iters.List(ctx, sess.ID, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if page.Total != 3 {
+				t.Errorf("total = %d, want 3", page.Total)
+			}
+			var got []int
+			for _, it := range page.Iterations {
+				got = append(got, it.Number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("iterations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	iter, err := iters.Get(ctx, sess.ID, 2)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if iter.Usage == nil || iter.Usage.InputTokens != 200 {
+		t.Errorf("iteration 2 usage = %+v, want 200 input tokens", iter.Usage)
+	}
+	if _, err := iters.Get(ctx, sess.ID, 9); apperror.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("Get of a missing iteration = %v, want 404", err)
+	}
+	if _, err := iters.List(ctx, "missing", 0, 0); apperror.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("List of an unknown session = %v, want 404", err)
+	}
+	if _, err := iters.List(ctx, sess.ID, -1, 0); apperror.HTTPStatus(err) != http.StatusBadRequest {
+		t.Errorf("List with a negative limit = %v, want 400", err)
+	}
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// Runner agents have no access to the server's SQLite database. Instead of
+// writing the mirror themselves they mark the session in a Redis set; the
+// server drains it with SyncMirror and re-reads each session from Redis.
+
+// SetRemoteMirror makes the service forward SQLite mirror writes to the
+// server. For services created without a database (runner agents).
+func (s *Service) SetRemoteMirror() {
+	s.remoteMirror = true
+}
+
+func (s *Service) mirrorPendingKey() string {
+	return s.redis.Key("sessions", "mirror_pending")
+}
+
+// forwardMirror marks sessionID for the server's next mirror sync.
+func (s *Service) forwardMirror(sessionID string) {
+	if !s.remoteMirror || sessionID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.redis.Unwrap().SAdd(ctx, s.mirrorPendingKey(), sessionID).Err(); err != nil {
+		slog.Warn("forwarding sqlite mirror write failed", "session_id", sessionID, "error", err)
+	}
+}
+
+// SyncMirror copies up to limit sessions marked by runner agents from Redis
+// into SQLite, with their iterations. Returns how many were synced.
+func (s *Service) SyncMirror(ctx context.Context, limit int64) (int, error) {
+	if s.sqlite == nil {
+		return 0, nil
+	}
+	ids, err := s.redis.Unwrap().SPopN(ctx, s.mirrorPendingKey(), limit).Result()
+	if err != nil {
+		return 0, fmt.Errorf("reading mirror queue: %w", err)
+	}
+	synced := 0
+	for _, id := range ids {
+		if err := s.syncSession(ctx, id); err != nil {
+			if errors.Is(err, apperror.ErrNotFound) {
+				continue // expired from Redis before the server got to it
+			}
+			slog.Warn("sqlite mirror sync failed, retrying later", "session_id", id, "error", err)
+			s.redis.Unwrap().SAdd(ctx, s.mirrorPendingKey(), id)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+func (s *Service) syncSession(ctx context.Context, sessionID string) error {
+	t, err := s.Get(ctx, sessionID, WithIterations(), IncludeDeleted())
+	if err != nil {
+		return err
+	}
+	if err := s.sqlite.Save(ctx, t); err != nil {
+		return err
+	}
+	for _, iter := range t.Iterations {
+		if err := s.sqlite.SaveIteration(ctx, sessionID, iter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/freema/codeforge/internal/review"
 	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/runner"
+	"github.com/freema/codeforge/internal/tool/verify"
 	"github.com/freema/codeforge/internal/tools"
 )
 
@@ -13,6 +15,7 @@ import (
 type Status string
 
 const (
+	StatusScheduled           Status = "scheduled" // waits for schedule_at before it is queued
 	StatusPending             Status = "pending"
 	StatusCloning             Status = "cloning"
 	StatusRunning             Status = "running"
@@ -22,6 +25,7 @@ const (
 	StatusReviewing           Status = "reviewing"
 	StatusCreatingPR          Status = "creating_pr"
 	StatusPRCreated           Status = "pr_created"
+	StatusPRMerged            Status = "pr_merged"
 	StatusCanceled            Status = "canceled"
 )
 
@@ -36,24 +40,52 @@ type Session struct {
 	SessionType string  `json:"session_type,omitempty"`
 	CallbackURL string  `json:"callback_url,omitempty"`
 	Config      *Config `json:"config,omitempty"`
+	// Priority is the queue the session waits in, for every iteration.
+	Priority Priority `json:"priority,omitempty"`
 
-	// Result fields
-	Result         string                 `json:"result,omitempty"`
-	Error          string                 `json:"error,omitempty"`
-	ChangesSummary *gitpkg.ChangesSummary `json:"changes_summary,omitempty"`
-	Usage          *UsageInfo             `json:"usage,omitempty"`
-	ReviewResult   *review.ReviewResult   `json:"review_result,omitempty"`
+	// Result fields — ResultStructured is the JSON block extracted from the
+	// result when config.result_schema is set; ResultStructuredError says why
+	// it is missing.
+	Result                string                 `json:"result,omitempty"`
+	ResultStructured      json.RawMessage        `json:"result_structured,omitempty"`
+	ResultStructuredError string                 `json:"result_structured_error,omitempty"`
+	Error                 string                 `json:"error,omitempty"`
+	ChangesSummary        *gitpkg.ChangesSummary `json:"changes_summary,omitempty"`
+	Usage                 *UsageInfo             `json:"usage,omitempty"`
+	ReviewResult          *review.ReviewResult   `json:"review_result,omitempty"`
+	Verification          *verify.Result         `json:"verification,omitempty"` // outcome of config.verify for the latest iteration
+	CostUSD               float64                `json:"cost_usd,omitempty"`     // spend of all CLI runs so far, checked against config.max_budget_usd
+	Timings               *Timings               `json:"timings,omitempty"`      // time spent per phase, summed over all iterations and create-pr
 
 	// Iteration tracking
-	Iteration     int         `json:"iteration"`
-	CurrentPrompt string      `json:"current_prompt,omitempty"` // follow-up prompt for current iteration (set by Instruct)
-	Iterations    []Iteration `json:"iterations,omitempty"`     // populated on demand via ?include=iterations
+	Iteration     int    `json:"iteration"`
+	CurrentPrompt string `json:"current_prompt,omitempty"` // follow-up prompt for current iteration (set by Instruct)
+	// IterationConfig holds the current iteration's config overrides (set by Instruct).
+	IterationConfig *IterationConfig `json:"iteration_config,omitempty"`
+	// QueuedInstructions are follow-ups waiting for the in-flight run, in order.
+	QueuedInstructions []QueuedInstruction `json:"queued_instructions,omitempty"`
+	// QueuePosition is set by Instruct when the follow-up was queued (1-based).
+	QueuePosition int         `json:"-"`
+	Iterations    []Iteration `json:"iterations,omitempty"` // populated on demand via ?include=iterations
+	// VerifyAttempts counts the automatic fix iterations queued in a row after
+	// failed verification; a user instruction resets it.
+	VerifyAttempts int `json:"-"`
+	// FailureStreak counts the automatic runs in a row that failed with the
+	// same FailureClass; CheckCrashLoop stops retrying once it is too long.
+	FailureClass  string `json:"-"`
+	FailureStreak int    `json:"-"`
+	// RunTimings is the phase breakdown of the run in flight, filled in by
+	// the worker and stored with its iteration.
+	RunTimings *Timings `json:"-"`
 
 	// Git integration — PRNumber is the PR created by CodeForge (via create-pr).
 	// For the input PR number on pr_review sessions, see Config.PRNumber.
 	Branch   string `json:"branch,omitempty"`
 	PRNumber int    `json:"pr_number,omitempty"`
 	PRURL    string `json:"pr_url,omitempty"`
+	// AutoMerge is how the PR gets merged once checks pass: "provider"
+	// (native auto-merge / MWPS) or "poll" (CodeForge merges when green).
+	AutoMerge string `json:"auto_merge,omitempty"`
 
 	// Review params (set by StartReviewAsync, consumed by executor)
 	ReviewCLI   string `json:"-"`
@@ -62,6 +94,9 @@ type Session struct {
 	// Metadata — optional key-value data (sentry URL, ticket link, etc.)
 	Metadata map[string]string `json:"metadata,omitempty"`
 
+	// Files supplied with the request, written to AttachmentsDir in the workspace.
+	Attachments []AttachmentInfo `json:"attachments,omitempty"`
+
 	// Workflow linkage
 	WorkflowRunID string `json:"workflow_run_id,omitempty"`
 
@@ -70,19 +105,102 @@ type Session struct {
 	TenantID string `json:"tenant_id,omitempty"`
 
 	// Observability
-	TraceID string `json:"trace_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"` // inbound X-Request-ID that created or last instructed the session
 
 	// Timestamps
-	CreatedAt  time.Time  `json:"created_at"`
-	StartedAt  *time.Time `json:"started_at,omitempty"`
-	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"` // when a scheduled session is queued
+	EnqueuedAt  *time.Time `json:"enqueued_at,omitempty"`  // last hand-over to the worker queue (create, instruct, review)
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // soft-deleted; purged after the grace period
+	// AwaitExpiresAt is when a session parked by Await releases its workspace.
+	AwaitExpiresAt *time.Time `json:"await_expires_at,omitempty"`
+}
+
+// IgnoreGlobs returns the patterns kept out of commits and change counts for
+// this session: the repository's .codeforgeignore plus config.ignore_globs.
+func (t *Session) IgnoreGlobs(workDir string) []string {
+	var configured []string
+	if t.Config != nil {
+		configured = t.Config.IgnoreGlobs
+	}
+	return gitpkg.LoadIgnoreGlobs(workDir, configured)
+}
+
+// HasPR reports whether create-pr opened a PR/MR for this session. Branch
+// alone does not count: with config.work_on_branch it is set at clone time.
+func (t *Session) HasPR() bool {
+	return t.PRNumber != 0 || t.PRURL != ""
+}
+
+// IsAnonymous reports whether the session opted into config.anonymous. Such a
+// session only ever uses a token its caller supplied, never the server's.
+func (t *Session) IsAnonymous() bool {
+	return t.Config != nil && t.Config.Anonymous
 }
 
 // UsageInfo tracks token usage and duration.
 type UsageInfo struct {
-	InputTokens     int `json:"input_tokens"`
-	OutputTokens    int `json:"output_tokens"`
-	DurationSeconds int `json:"duration_seconds"`
+	InputTokens     int     `json:"input_tokens"`
+	OutputTokens    int     `json:"output_tokens"`
+	DurationSeconds int     `json:"duration_seconds"`
+	CostUSD         float64 `json:"cost_usd,omitempty"` // reported by the CLI; 0 when it does not report spend
+}
+
+// Session phases, as timed in Timings and labelled in the
+// codeforge_session_phase_duration_seconds histogram.
+const (
+	PhaseQueueWait = "queue_wait" // enqueue until a worker picks the session up
+	PhaseClone     = "clone"      // cloning or re-cloning the repository
+	PhaseRun       = "run"        // the CLI run
+	PhaseDiff      = "diff"       // checkpointing and computing the change summary
+	PhasePR        = "pr"         // create-pr: branch, commit, push and the provider API
+)
+
+// Timings is how long a run spent in each phase, in seconds. A phase that
+// did not happen (a reused workspace is not cloned) stays zero.
+type Timings struct {
+	QueueWait float64 `json:"queue_wait_seconds"`
+	Clone     float64 `json:"clone_seconds"`
+	Run       float64 `json:"run_seconds"`
+	Diff      float64 `json:"diff_seconds"`
+	PR        float64 `json:"pr_seconds,omitempty"`
+}
+
+// Add adds d to phase; unknown phases are ignored.
+func (t *Timings) Add(phase string, d time.Duration) {
+	if p := t.phase(phase); p != nil {
+		*p += d.Seconds()
+	}
+}
+
+// Phases returns the seconds of every phase by name.
+func (t *Timings) Phases() map[string]float64 {
+	return map[string]float64{
+		PhaseQueueWait: t.QueueWait,
+		PhaseClone:     t.Clone,
+		PhaseRun:       t.Run,
+		PhaseDiff:      t.Diff,
+		PhasePR:        t.PR,
+	}
+}
+
+func (t *Timings) phase(name string) *float64 {
+	switch name {
+	case PhaseQueueWait:
+		return &t.QueueWait
+	case PhaseClone:
+		return &t.Clone
+	case PhaseRun:
+		return &t.Run
+	case PhaseDiff:
+		return &t.Diff
+	case PhasePR:
+		return &t.PR
+	}
+	return nil
 }
 
 // Config holds per-session configuration overrides.
@@ -90,20 +208,70 @@ type Config struct {
 	TimeoutSeconds     int                 `json:"timeout_seconds,omitempty"`
 	CLI                string              `json:"cli,omitempty"`
 	AIModel            string              `json:"ai_model,omitempty"`
-	AIApiKey           string              `json:"-"` // NEVER in responses (custom UnmarshalJSON accepts it)
+	AIApiKey           string              `json:"-" openapi:"ai_api_key,writeOnly"` // NEVER in responses (custom UnmarshalJSON accepts it)
 	MaxTurns           int                 `json:"max_turns,omitempty"`
 	SourceBranch       string              `json:"source_branch,omitempty"` // branch to clone/checkout
 	TargetBranch       string              `json:"target_branch,omitempty"`
 	MaxBudgetUSD       float64             `json:"max_budget_usd,omitempty"`
 	MCPServers         []MCPServer         `json:"mcp_servers,omitempty"`
 	Tools              []tools.SessionTool `json:"tools,omitempty"`
-	WorkspaceSessionID string              `json:"workspace_session_id,omitempty"`  // reuse workspace from another session
-	PRNumber           int                 `json:"pr_number,omitempty"`             // input PR/MR number to review (for pr_review sessions)
-	OutputMode         string              `json:"output_mode,omitempty"`           // "post_comments" or "api_only" (for pr_review sessions)
-	AutoReviewAfterFix bool                `json:"auto_review_after_fix,omitempty"` // auto-start review after each fix iteration
-	AutoPostReview     bool                `json:"auto_post_review,omitempty"`      // auto-post review result to MR comments
-	AutoCreatePR       bool                `json:"auto_create_pr,omitempty"`        // auto-create a PR/MR when the session completes with changes (used by workflows)
-	PRTitle            string              `json:"pr_title,omitempty"`              // explicit PR title for auto-created PRs (empty = AI-generated)
+	WorkspaceSessionID string              `json:"workspace_session_id,omitempty"`                       // reuse workspace from another session
+	PRNumber           int                 `json:"pr_number,omitempty"`                                  // input PR/MR number to review (for pr_review sessions)
+	OutputMode         string              `json:"output_mode,omitempty"`                                // "post_comments" or "api_only" (for pr_review sessions)
+	AutoReviewAfterFix bool                `json:"auto_review_after_fix,omitempty"`                      // auto-start review after each fix iteration
+	AutoPostReview     bool                `json:"auto_post_review,omitempty"`                           // auto-post review result to MR comments
+	AutoCreatePR       bool                `json:"auto_create_pr,omitempty"`                             // auto-create a PR/MR when the session completes with changes (used by workflows)
+	AutoMerge          bool                `json:"auto_merge,omitempty"`                                 // merge the auto-created PR once provider checks pass
+	PRTitle            string              `json:"pr_title,omitempty"`                                   // explicit PR title for auto-created PRs (empty = AI-generated)
+	MaxIterations      int                 `json:"max_iterations,omitempty"`                             // cap on total iterations incl. the first run (0 = server default)
+	Reasoning          *Reasoning          `json:"reasoning,omitempty"`                                  // extended thinking / reasoning effort for the CLI
+	AIBackend          string              `json:"ai_backend,omitempty"`                                 // Claude backend override: anthropic, bedrock, vertex (empty = server default)
+	AllowedTools       string              `json:"allowed_tools,omitempty"`                              // comma-separated tool allowlist (Claude Code --allowedTools)
+	IgnoreGlobs        []string            `json:"ignore_globs,omitempty"`                               // extra .codeforgeignore patterns kept out of commits and change counts
+	Verify             *Verify             `json:"verify,omitempty"`                                     // commands run after each iteration; failures can trigger fix iterations
+	ResultSchema       json.RawMessage     `json:"result_schema,omitempty"`                              // JSON schema the CLI's final JSON block must match; parsed into result_structured
+	ForkURL            string              `json:"fork_url,omitempty" validate:"omitempty,url,max=2000"` // push the branch to this fork instead of origin; PRs use fork-owner:branch as head
+	// How earlier iterations are put into follow-up prompts (empty = full).
+	ContextStrategy string `json:"context_strategy,omitempty" validate:"omitempty,oneof=full recent summary none"`
+	// Create and check out the session branch right after the clone, so every
+	// iteration commits to it; create-pr then pushes that branch.
+	WorkOnBranch bool `json:"work_on_branch,omitempty"`
+	// Terminal webhooks carry the full iteration history.
+	IncludeIterations bool `json:"include_iterations,omitempty"`
+	// Clone a public HTTPS repository without credentials; no token is
+	// resolved until create-pr supplies one.
+	Anonymous bool `json:"anonymous,omitempty"`
+	// Workspace template (sessions.workspace_templates) whose dependency
+	// caches are copied into the workspace (empty = the "default" template).
+	WorkspaceTemplate string `json:"workspace_template,omitempty" validate:"max=100"`
+}
+
+// Iteration context strategies: how the prompt of a follow-up iteration
+// carries the earlier iterations.
+const (
+	ContextFull    = "full"    // every iteration oldest-first until the context cap (default)
+	ContextRecent  = "recent"  // the newest iterations that fit the cap
+	ContextSummary = "summary" // the last iterations verbatim, older ones summarized by the AI helper
+	ContextNone    = "none"    // the current instruction only
+)
+
+// Verify configures the verification step: Commands (tests, linters) run in
+// the workspace after each iteration of a code session, in order, stopping at
+// the first failure. With MaxAttempts > 0 a failure queues a follow-up
+// iteration with the failure output as its prompt, up to MaxAttempts times
+// in a row.
+type Verify struct {
+	Commands       []string `json:"commands" validate:"min=1,max=10,dive,required,max=2000"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" validate:"gte=0,lte=1800"` // all commands together (0 = 300)
+	MaxAttempts    int      `json:"max_attempts,omitempty" validate:"gte=0,lte=10"`
+}
+
+// Reasoning requests deeper reasoning from the CLI. Effort maps to the Codex
+// reasoning effort and to a Claude Code thinking budget; BudgetTokens sets the
+// Claude Code thinking budget explicitly.
+type Reasoning struct {
+	Effort       string `json:"effort,omitempty" validate:"omitempty,oneof=low medium high"`
+	BudgetTokens int    `json:"budget_tokens,omitempty" validate:"gte=0,lte=128000"`
 }
 
 // UnmarshalJSON accepts ai_api_key from JSON input while json:"-" keeps it hidden in output.
@@ -138,15 +306,32 @@ type MCPServer struct {
 
 // Iteration stores result data for a single iteration.
 type Iteration struct {
-	Number    int                    `json:"number"`
-	Prompt    string                 `json:"prompt"`
-	Result    string                 `json:"result,omitempty"`
-	Error     string                 `json:"error,omitempty"`
-	Status    Status                 `json:"status"`
-	Changes   *gitpkg.ChangesSummary `json:"changes,omitempty"`
-	Usage     *UsageInfo             `json:"usage,omitempty"`
-	StartedAt time.Time              `json:"started_at"`
-	EndedAt   *time.Time             `json:"ended_at,omitempty"`
+	Number       int                    `json:"number"`
+	Prompt       string                 `json:"prompt"`
+	Result       string                 `json:"result,omitempty"`
+	Structured   json.RawMessage        `json:"result_structured,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	Status       Status                 `json:"status"`
+	Changes      *gitpkg.ChangesSummary `json:"changes,omitempty"`
+	Usage        *UsageInfo             `json:"usage,omitempty"`
+	Verification *verify.Result         `json:"verification,omitempty"` // config.verify outcome of this iteration
+	Config       *IterationConfig       `json:"config,omitempty"`       // overrides the instruction set for this iteration
+	Summary      *IterationSummary      `json:"summary,omitempty"`      // condensed outcome, used in follow-up prompts
+	Timings      *Timings               `json:"timings,omitempty"`      // queue wait, clone, run and diff of this iteration
+	StartedAt    time.Time              `json:"started_at"`
+	EndedAt      *time.Time             `json:"ended_at,omitempty"`
+
+	// ToolsSummary is the CLI run's tool calls, files read/written and commands.
+	ToolsSummary *runner.ToolsSummary `json:"tools_summary,omitempty"`
+}
+
+// IterationSummary is a short structured account of a completed iteration,
+// generated by the AI helper (or heuristically without one). Follow-up
+// prompts carry it instead of the raw result.
+type IterationSummary struct {
+	Changed    string   `json:"changed"`               // what was done, a few sentences
+	Files      []string `json:"files,omitempty"`       // files touched in the workspace
+	OpenIssues []string `json:"open_issues,omitempty"` // problems left for later iterations
 }
 
 // MarshalConfig serializes Config to JSON string for Redis storage.
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"fmt"
+
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// Next-step actions suggested to API clients after an iteration.
+const (
+	NextInstruct = "instruct"  // send a follow-up instruction
+	NextCreatePR = "create_pr" // open a PR/MR with the changes
+	NextPush     = "push"      // push the new changes to the existing PR/MR
+	NextReview   = "review"    // review the changes before merging
+	NextNone     = "none"      // nothing left to do in this session
+)
+
+// NextStep is a hint for orchestrators driving a session purely off webhooks:
+// what would usually come next, why, and the API call that does it.
+type NextStep struct {
+	Action   string `json:"action"`
+	Reason   string `json:"reason"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// SuggestNext derives the next step after an iteration of t ended in status
+// with the given changes. Failed and canceled sessions get none — they
+// cannot continue.
+func (s *Service) SuggestNext(t *Session, status Status, changes *gitpkg.ChangesSummary) *NextStep {
+	base := "/api/v1/sessions/" + t.ID
+	switch {
+	case IsFinished(status):
+		return &NextStep{Action: NextNone, Reason: "the session is " + string(status) + " and accepts no further work"}
+	case status == StatusPRCreated:
+		return &NextStep{Action: NextReview, Reason: "a PR is open; review it or instruct further changes", Endpoint: "POST " + base + "/review"}
+	case t.Verification != nil && !t.Verification.Passed:
+		return s.instructStep(t, "verification failed: "+t.Verification.Command+" did not pass")
+	case t.SessionType == "plan":
+		return s.instructStep(t, "the plan is ready; instruct the session to implement it")
+	case t.SessionType == "review" || t.SessionType == "pr_review":
+		return s.instructStep(t, "the review is done; instruct the session to address its findings")
+	case !hasChanges(changes):
+		return s.instructStep(t, "the iteration made no file changes; refine the instruction")
+	case t.PRURL != "":
+		return &NextStep{Action: NextPush, Reason: "the iteration changed files; push them to the open PR", Endpoint: "POST " + base + "/push"}
+	default:
+		return &NextStep{Action: NextCreatePR, Reason: "the iteration changed files; open a PR or instruct further changes", Endpoint: "POST " + base + "/create-pr"}
+	}
+}
+
+// SuggestAfterReview derives the next step after a review of t that reported
+// the given number of findings.
+func (s *Service) SuggestAfterReview(t *Session, findings int) *NextStep {
+	if findings == 0 {
+		return &NextStep{Action: NextNone, Reason: "the review found no issues"}
+	}
+	return s.instructStep(t, fmt.Sprintf("the review found %d issue(s); instruct the session to fix them", findings))
+}
+
+// instructStep suggests a follow-up instruction, or none once the session has
+// used up its iteration budget.
+func (s *Service) instructStep(t *Session, reason string) *NextStep {
+	if CheckIterationLimit(t, s.maxIterations) != nil {
+		return &NextStep{Action: NextNone, Reason: "the session reached max_iterations; create a new session to continue"}
+	}
+	return &NextStep{Action: NextInstruct, Reason: reason, Endpoint: "POST /api/v1/sessions/" + t.ID + "/instruct"}
+}
+
+func hasChanges(c *gitpkg.ChangesSummary) bool {
+	return c != nil && c.FilesModified+c.FilesCreated+c.FilesDeleted > 0
+}
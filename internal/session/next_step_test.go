@@ -0,0 +1,59 @@
+package session
+
+import (
+	"testing"
+
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/verify"
+)
+
+func TestSuggestNext(t *testing.T) {
+	changed := &gitpkg.ChangesSummary{FilesModified: 2}
+	tests := []struct {
+		name    string
+		session Session
+		status  Status
+		changes *gitpkg.ChangesSummary
+		want    string
+	}{
+		{"changes without PR", Session{SessionType: "code", Iteration: 1}, StatusCompleted, changed, NextCreatePR},
+		{"changes with PR", Session{SessionType: "code", Iteration: 2, PRURL: "https://x/pr/1"}, StatusCompleted, changed, NextPush},
+		{"no changes", Session{SessionType: "code", Iteration: 1}, StatusCompleted, &gitpkg.ChangesSummary{}, NextInstruct},
+		{"nil changes", Session{SessionType: "code", Iteration: 1}, StatusCompleted, nil, NextInstruct},
+		{"plan", Session{SessionType: "plan", Iteration: 1}, StatusCompleted, nil, NextInstruct},
+		{"pr_review", Session{SessionType: "pr_review", Iteration: 1}, StatusCompleted, nil, NextInstruct},
+		{"verification failed", Session{SessionType: "code", Iteration: 1, Verification: &verify.Result{Command: "go test ./..."}}, StatusCompleted, changed, NextInstruct},
+		{"verification passed", Session{SessionType: "code", Iteration: 1, Verification: &verify.Result{Passed: true}}, StatusCompleted, changed, NextCreatePR},
+		{"pr created", Session{SessionType: "code", Iteration: 1}, StatusPRCreated, nil, NextReview},
+		{"failed", Session{SessionType: "code", Iteration: 1}, StatusFailed, changed, NextNone},
+		{"iteration limit", Session{SessionType: "code", Iteration: 3, Config: &Config{MaxIterations: 3}}, StatusCompleted, nil, NextNone},
+	}
+	s := &Service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.session.ID = "abc"
+			got := s.SuggestNext(&tt.session, tt.status, tt.changes)
+			if got.Action != tt.want {
+				t.Errorf("action = %q, want %q (%s)", got.Action, tt.want, got.Reason)
+			}
+			if got.Reason == "" {
+				t.Error("reason must be set")
+			}
+			if got.Action != NextNone && got.Endpoint == "" {
+				t.Error("endpoint must be set for actionable steps")
+			}
+		})
+	}
+}
+
+func TestSuggestAfterReview(t *testing.T) {
+	s := &Service{}
+	sess := &Session{ID: "abc", Iteration: 1}
+	if got := s.SuggestAfterReview(sess, 0); got.Action != NextNone {
+		t.Errorf("clean review: action = %q", got.Action)
+	}
+	got := s.SuggestAfterReview(sess, 3)
+	if got.Action != NextInstruct || got.Endpoint != "POST /api/v1/sessions/abc/instruct" {
+		t.Errorf("review with findings = %+v", got)
+	}
+}
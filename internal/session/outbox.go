@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Every enqueue (create, instruct, review) records the session in the
+// sessions:outbox sorted set, scored by enqueue time, in the same MULTI/EXEC
+// as its state change and queue push. Workers ack the entry when they
+// dequeue the session. Entries nobody acked are checked by ReconcileOutbox,
+// which re-enqueues sessions that wait for a worker but sit in no queue —
+// e.g. after a failed requeue or a restored Redis snapshot.
+
+func (s *Service) outboxKey() string {
+	return s.redis.Key("sessions", "outbox")
+}
+
+// addOutbox records sessionID in the outbox within pipe, and at as the
+// session's enqueued_at, from which the worker measures the queue wait.
+func (s *Service) addOutbox(ctx context.Context, pipe redis.Pipeliner, sessionID string, at time.Time) {
+	pipe.ZAdd(ctx, s.outboxKey(), redis.Z{Score: float64(at.Unix()), Member: sessionID})
+	pipe.HSet(ctx, s.redis.Key("session", sessionID, "state"), "enqueued_at", at.UTC().Format(time.RFC3339Nano))
+}
+
+// AckOutbox removes the outbox entry of a session a worker has dequeued.
+func (s *Service) AckOutbox(ctx context.Context, sessionID string) {
+	if err := s.redis.Unwrap().ZRem(ctx, s.outboxKey(), sessionID).Err(); err != nil {
+		slog.Warn("outbox ack failed", "session_id", sessionID, "error", err)
+	}
+}
+
+// OutboxDue returns up to limit unacked sessions enqueued before before,
+// oldest first.
+func (s *Service) OutboxDue(ctx context.Context, before time.Time, limit int64) ([]string, error) {
+	ids, err := s.redis.Unwrap().ZRangeByScore(ctx, s.outboxKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(before.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading outbox: %w", err)
+	}
+	return ids, nil
+}
+
+// awaitsWorker reports whether a session in status st belongs in the queue.
+// awaiting_instruction is also a parking state (Await, merge conflicts), but
+// only enqueued sessions have an outbox entry.
+func awaitsWorker(st Status) bool {
+	return st == StatusPending || st == StatusAwaitingInstruction || st == StatusReviewing
+}
+
+// ReconcileOutbox repairs the outbox entry of sessionID. queued reports
+// whether the session is in the queue or a worker's lease list; it must
+// check the queue first, as dequeuing moves a session from there to a lease.
+// An entry whose session moved on is dropped; a session that still awaits a
+// worker but is queued nowhere is pushed onto the queue again. Reports
+// whether it was re-enqueued.
+func (s *Service) ReconcileOutbox(ctx context.Context, sessionID string, queued func(context.Context, string) (bool, error)) (bool, error) {
+	rdb := s.redis.Unwrap()
+	stateKey := s.redis.Key("session", sessionID, "state")
+	requeued := false
+
+	err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+		status, err := tx.HGet(ctx, stateKey, "status").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("reading session status: %w", err)
+		}
+		if !awaitsWorker(Status(status)) {
+			return tx.ZRem(ctx, s.outboxKey(), sessionID).Err()
+		}
+		inQueue, err := queued(ctx, sessionID)
+		if err != nil || inQueue {
+			return err // delivered; the worker acks it
+		}
+		queueKey, err := s.sessionQueueKey(ctx, tx, sessionID)
+		if err != nil {
+			return err
+		}
+		// WATCH aborts the push if a worker changed the status meanwhile.
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.RPush(ctx, queueKey, sessionID)
+			s.addOutbox(ctx, pipe, sessionID, time.Now())
+			return nil
+		})
+		requeued = err == nil
+		return err
+	}, stateKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil // status changed: a worker has it
+	}
+	return requeued, err
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// maxFanOutSessions bounds how many PRs a single CreatePRs call opens.
+const maxFanOutSessions = 10
+
+// CreatePRsRequest is the request body for POST /sessions/create-prs: one PR
+// per session, for a change that spans several repositories.
+type CreatePRsRequest struct {
+	SessionIDs   []string `json:"session_ids" validate:"required,min=2,max=10"`
+	Title        string   `json:"title,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	TargetBranch string   `json:"target_branch,omitempty"`
+}
+
+// CreatePRsResponse lists the outcome for every requested session.
+type CreatePRsResponse struct {
+	PullRequests []FanOutPR `json:"pull_requests"`
+	Created      int        `json:"created"`
+}
+
+// FanOutPR is the outcome of one session in a CreatePRs call. Error is set
+// instead of the PR fields when that session's PR could not be created.
+type FanOutPR struct {
+	SessionID  string `json:"session_id"`
+	Repository string `json:"repository"`
+	PRURL      string `json:"pr_url,omitempty"`
+	PRNumber   int    `json:"pr_number,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CreatePRs opens one PR per session in parallel and, once all are created,
+// links every PR to its siblings in its description. Sessions must belong to
+// distinct repositories. A failure on one repository does not undo the
+// others; it is reported on that session's entry.
+func (s *PRService) CreatePRs(ctx context.Context, req CreatePRsRequest) (*CreatePRsResponse, error) {
+	if len(req.SessionIDs) > maxFanOutSessions {
+		return nil, apperror.Validation("at most %d sessions per call", maxFanOutSessions)
+	}
+
+	results := make([]FanOutPR, len(req.SessionIDs))
+	seen := make(map[string]string, len(req.SessionIDs))
+	for i, id := range req.SessionIDs {
+		t, err := s.sessionService.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		repo := RepoFullName(t.RepoURL)
+		if other, dup := seen[repo]; dup {
+			return nil, apperror.Validation("sessions %s and %s target the same repository %s", other, id, repo)
+		}
+		seen[repo] = id
+		results[i] = FanOutPR{SessionID: id, Repository: repo}
+	}
+
+	descriptions := make([]string, len(results))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := s.CreatePR(ctx, results[i].SessionID, CreatePRRequest{
+				Title:        req.Title,
+				Description:  req.Description,
+				TargetBranch: req.TargetBranch,
+			})
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].PRURL = resp.PRURL
+			results[i].PRNumber = resp.PRNumber
+			results[i].Branch = resp.Branch
+			descriptions[i] = resp.description
+		}(i)
+	}
+	wg.Wait()
+
+	out := &CreatePRsResponse{PullRequests: results}
+	for _, r := range results {
+		if r.PRURL != "" {
+			out.Created++
+		}
+	}
+	if out.Created < 2 {
+		return out, nil
+	}
+
+	for i, r := range results {
+		if r.PRURL == "" {
+			continue
+		}
+		body := descriptions[i] + relatedPRsSection(results, i)
+		if err := s.updateDescription(ctx, r.SessionID, r.PRNumber, body); err != nil {
+			slog.Warn("linking related PRs failed", "session_id", r.SessionID, "pr_url", r.PRURL, "error", err)
+		}
+	}
+	return out, nil
+}
+
+// relatedPRsSection renders the links to every created PR except results[self].
+func relatedPRsSection(results []FanOutPR, self int) string {
+	var b strings.Builder
+	b.WriteString("\n\n---\n**Related pull requests**\n\n")
+	for i, r := range results {
+		if i == self || r.PRURL == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", r.Repository, r.PRURL)
+	}
+	return b.String()
+}
+
+func (s *PRService) updateDescription(ctx context.Context, sessionID string, prNumber int, description string) error {
+	repo, token, err := s.providerAccess(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return gitpkg.UpdatePRDescription(ctx, repo, token, prNumber, description)
+}
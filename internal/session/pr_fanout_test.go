@@ -0,0 +1,25 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRelatedPRsSection(t *testing.T) {
+	results := []FanOutPR{
+		{Repository: "acme/api", PRURL: "https://github.com/acme/api/pull/1"},
+		{Repository: "acme/web", Error: "no changes to create PR for"},
+		{Repository: "acme/worker", PRURL: "https://github.com/acme/worker/pull/7"},
+	}
+
+	got := relatedPRsSection(results, 0)
+	if strings.Contains(got, "acme/api") {
+		t.Errorf("section links the PR to itself:\n%s", got)
+	}
+	if strings.Contains(got, "acme/web") {
+		t.Errorf("section links a failed repository:\n%s", got)
+	}
+	if !strings.Contains(got, "- acme/worker: https://github.com/acme/worker/pull/7") {
+		t.Errorf("sibling link missing:\n%s", got)
+	}
+}
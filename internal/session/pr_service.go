@@ -2,12 +2,17 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/freema/codeforge/internal/ai"
-	"github.com/freema/codeforge/internal/slug"
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/hooks"
+	"github.com/freema/codeforge/internal/runas"
 	gitpkg "github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/runner"
 )
@@ -24,11 +29,18 @@ type PRServiceConfig struct {
 	CommitAuthor    string
 	CommitEmail     string
 	ProviderDomains map[string]string
+	ProviderAPI     gitpkg.APIConfig // provider API calls (git.api_base_urls)
+
+	// DirectCommit bounds create-pr with direct=true (nil = direct commits disabled).
+	DirectCommit *gitpkg.DirectCommitPolicy
+	// GitLabMR holds the server-wide MR options; CreatePRRequest.GitLab overrides them.
+	GitLabMR gitpkg.GitLabMROptions
 }
 
 // TokenResolver resolves access tokens for sessions.
 type TokenResolver interface {
 	ResolveToken(ctx context.Context, repoURL, accessToken, providerKey string) (string, error)
+	APIBaseURL(ctx context.Context, providerKey string) string
 }
 
 // PRService orchestrates the PR/MR creation workflow.
@@ -38,7 +50,10 @@ type PRService struct {
 	workspaceResolver WorkspacePathResolver
 	tokenResolver     TokenResolver
 	cfg               PRServiceConfig
-	ai                ai.Client // optional, nil = no AI commit messages
+	ai                ai.Client      // optional, nil = no AI commit messages
+	events            EventEmitter   // optional, nil = no conflict events
+	hooks             *hooks.Runner  // optional, nil = no pre_pr hooks
+	runAs             runas.Strategy // user pre_pr hooks run as; set with hooks
 }
 
 // NewPRService creates a PR service.
@@ -56,24 +71,83 @@ func NewPRService(sessionService *Service, analyzer *runner.Analyzer, workspaceR
 	return svc
 }
 
+// branchLockTTL bounds how long a generated branch name stays reserved —
+// long enough to commit and push, after which ls-remote sees the branch.
+const branchLockTTL = 10 * time.Minute
+
+// workBranchLockTTL keeps a work_on_branch name reserved from the clone until
+// create-pr pushes it, possibly many iterations later.
+const workBranchLockTTL = 7 * 24 * time.Hour
+
+// branchReserver returns a GenerateBranchName reserve func backed by a Redis
+// lock per repository (host included, see branchLockRepo) and branch name.
+// The session holding a lock may claim the name again (a retried create-pr);
+// Redis errors do not block PRs.
+func (s *PRService) branchReserver(ctx context.Context, repoURL, sessionID string, ttl time.Duration) func(string) bool {
+	rdb := s.sessionService.redis
+	if rdb == nil {
+		return nil
+	}
+	repo := branchLockRepo(repoURL)
+	return func(name string) bool {
+		key := rdb.Key("lock", "branch", repo, name)
+		ok, err := rdb.Unwrap().SetNX(ctx, key, sessionID, ttl).Result()
+		if err != nil {
+			slog.Warn("branch name lock unavailable", "branch", name, "error", err)
+			return true
+		}
+		if ok {
+			return true
+		}
+		holder, _ := rdb.Unwrap().Get(ctx, key).Result()
+		return holder == sessionID
+	}
+}
+
+// branchLockRepo returns the lower-cased "host/owner/repo" branch locks are
+// keyed by, so the same path on different providers does not share locks
+// while URL variants (casing, ".git") of one repository do.
+func branchLockRepo(repoURL string) string {
+	info, err := gitpkg.ParseRepoURL(repoURL, nil)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(info.Host + "/" + info.FullName())
+}
+
 // CreatePRRequest is the request body for POST /sessions/:id/create-pr.
 type CreatePRRequest struct {
 	Title        string `json:"title,omitempty"`
 	Description  string `json:"description,omitempty"`
 	TargetBranch string `json:"target_branch,omitempty"`
+	AutoMerge    bool   `json:"auto_merge,omitempty"` // merge once provider checks pass
+	Direct       bool   `json:"direct,omitempty"`     // commit trivial changes straight to the target branch, no PR
+	// GitLab MR options (squash, milestone, assignees, reviewers); ignored for GitHub.
+	GitLab *gitpkg.GitLabMROptions `json:"gitlab,omitempty"`
+	// Token for a session created without one (config.anonymous); stored on
+	// the session for later pushes.
+	AccessToken string `json:"access_token,omitempty"`
 }
 
 // CreatePRResponse is the response for a successful PR creation.
 type CreatePRResponse struct {
-	PRURL    string `json:"pr_url"`
-	PRNumber int    `json:"pr_number"`
-	Branch   string `json:"branch"`
+	PRURL     string `json:"pr_url"`
+	PRNumber  int    `json:"pr_number"`
+	Branch    string `json:"branch"`
+	AutoMerge string `json:"auto_merge,omitempty"` // "provider" or "poll" when requested
+	Commit    string `json:"commit,omitempty"`     // the commit pushed to the target branch by a direct commit
+
+	// GitLab approval rules the MR is subject to (Premium instances only).
+	ApprovalsRequired int      `json:"approvals_required,omitempty"`
+	ApprovalRules     []string `json:"approval_rules,omitempty"`
+
+	description string // final PR body, extended with sibling links by CreatePRs
 }
 
 // CreatePR orchestrates the full PR creation: analyze → branch → commit → push → create PR.
 func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePRRequest) (*CreatePRResponse, error) {
 	// Load session
-	t, err := s.sessionService.Get(ctx, sessionID)
+	t, err := s.sessionService.Get(ctx, sessionID, WithSecrets())
 	if err != nil {
 		return nil, err
 	}
@@ -91,36 +165,52 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 		}
 	}
 
+	ignoreGlobs := t.IgnoreGlobs(workDir)
+
+	// A branch the session already worked on or pushed (work_on_branch, push)
+	// may hold every change as commits; without uncommitted changes it needs
+	// commits ahead of the base branch, checked once that is known.
+	reuseBranch := t.Branch != "" && !t.HasPR()
+	checkAhead := false
+
 	// Check for changes — lazy recalculation if summary is nil but workspace exists.
-	if t.ChangesSummary == nil || (t.ChangesSummary.FilesModified == 0 && t.ChangesSummary.FilesCreated == 0 && t.ChangesSummary.FilesDeleted == 0) {
-		recalc, err := gitpkg.CalculateChanges(ctx, workDir)
-		if err == nil && recalc != nil && (recalc.FilesModified > 0 || recalc.FilesCreated > 0 || recalc.FilesDeleted > 0) {
+	if reuseBranch || t.ChangesSummary == nil || (t.ChangesSummary.FilesModified == 0 && t.ChangesSummary.FilesCreated == 0 && t.ChangesSummary.FilesDeleted == 0) {
+		recalc, err := gitpkg.CalculateChanges(ctx, workDir, ignoreGlobs...)
+		switch {
+		case err == nil && recalc != nil && (recalc.FilesModified > 0 || recalc.FilesCreated > 0 || recalc.FilesDeleted > 0):
 			slog.Info("recalculated changes for PR", "session_id", sessionID, "modified", recalc.FilesModified, "created", recalc.FilesCreated, "deleted", recalc.FilesDeleted)
 			t.ChangesSummary = recalc
-		} else {
+		case reuseBranch:
+			checkAhead = true
+		default:
 			return nil, fmt.Errorf("no changes to create PR for")
 		}
 	}
 
-	// Resolve access token (inline → registry → env) if not already set.
-	if s.tokenResolver != nil && t.AccessToken == "" {
-		token, err := s.tokenResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
-		if err != nil {
-			return nil, fmt.Errorf("resolving access token for PR: %w", err)
-		}
-		t.AccessToken = token
+	// Resolve access token (request → session → registry → env).
+	if err := s.requireToken(ctx, t, req.AccessToken, "create-pr"); err != nil {
+		return nil, err
+	}
+
+	if err := s.runPrePRHooks(ctx, t, workDir); err != nil {
+		return nil, err
+	}
+
+	if req.Direct {
+		return s.commitDirect(ctx, t, req, workDir, ignoreGlobs)
 	}
 
 	// Remember previous status so we can revert on non-fatal errors
 	previousStatus := t.Status
 
 	// Transition to CREATING_PR
+	prStarted := time.Now()
 	if err := s.sessionService.UpdateStatus(ctx, sessionID, StatusCreatingPR); err != nil {
 		return nil, fmt.Errorf("transitioning to creating_pr: %w", err)
 	}
 
 	// Parse repo URL to detect provider
-	repoInfo, err := gitpkg.ParseRepoURL(t.RepoURL, s.cfg.ProviderDomains)
+	repoInfo, err := s.parseRepo(ctx, t)
 	if err != nil {
 		s.failPR(ctx, sessionID, err)
 		return nil, fmt.Errorf("parsing repo URL: %w", err)
@@ -137,8 +227,14 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 	description := req.Description
 	var branchSlug string
 
+	// Changed paths feed the offline heuristic and the branch slug.
+	changedPaths, err := gitpkg.ChangedFiles(ctx, workDir, ignoreGlobs...)
+	if err != nil {
+		slog.Warn("listing changed files for PR metadata failed", "session_id", sessionID, "error", err)
+	}
+
 	if title == "" || description == "" {
-		analysis := s.analyzer.Analyze(ctx, t.Prompt, sessionID)
+		analysis := s.analyzer.Analyze(ctx, t.Prompt, sessionID, changedPaths)
 		if title == "" {
 			title = analysis.PRTitle
 		}
@@ -147,7 +243,7 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 		}
 		branchSlug = analysis.BranchSlug
 	} else {
-		branchSlug = slug.Generate(t.Prompt, sessionID)
+		branchSlug = runner.BranchSlug(t.Prompt, sessionID, changedPaths)
 	}
 
 	baseBranch := req.TargetBranch
@@ -155,22 +251,64 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 		if t.Config != nil && t.Config.TargetBranch != "" {
 			baseBranch = t.Config.TargetBranch
 		} else {
-			// Detect default branch from the cloned repo
-			if detected, err := gitpkg.DefaultBranch(ctx, workDir); err == nil && detected != "" {
-				baseBranch = detected
-			} else {
-				baseBranch = "main"
-			}
+			// The repository's actual default branch — older repos use master
+			baseBranch = gitpkg.ResolveDefaultBranch(ctx, workDir, repoInfo, t.AccessToken)
 		}
 	}
 
-	// Generate branch name
-	branchName := gitpkg.GenerateBranchName(ctx, workDir, s.cfg.BranchPrefix, branchSlug)
+	if checkAhead {
+		ahead, err := gitpkg.HasCommitsAhead(ctx, workDir, baseBranch, t.AccessToken)
+		if err != nil {
+			slog.Warn("checking branch for commits ahead of base failed", "session_id", sessionID, "error", err)
+		} else if !ahead {
+			// Nothing for the provider to open a PR for: not a failed session.
+			_ = s.sessionService.UpdateStatus(ctx, sessionID, previousStatus)
+			return nil, apperror.Validation("no changes to create PR for: branch %s has no commits ahead of %s", t.Branch, baseBranch)
+		}
+	}
+
+	remote, headRepo, err := s.pushTarget(ctx, t, workDir, repoInfo)
+	if err != nil {
+		_ = s.sessionService.UpdateStatus(ctx, sessionID, previousStatus)
+		return nil, err
+	}
+	accessRepo := repoInfo
+	if headRepo != nil {
+		accessRepo = headRepo
+	}
+	if err := gitpkg.CheckAccess(ctx, accessRepo, t.AccessToken, gitpkg.AccessPullRequest); err != nil {
+		_ = s.sessionService.UpdateStatus(ctx, sessionID, previousStatus)
+		return nil, accessAppError(err)
+	}
+
+	// Generate a branch name no concurrent session can pick as well
+	pushURL := t.RepoURL
+	if t.Config != nil && t.Config.ForkURL != "" {
+		pushURL = t.Config.ForkURL
+	}
+	branchName := t.Branch
+	if !reuseBranch {
+		branchName = gitpkg.GenerateBranchName(ctx, gitpkg.BranchNameOptions{
+			WorkDir: workDir,
+			Prefix:  s.cfg.BranchPrefix,
+			Slug:    branchSlug,
+			Remote:  remote,
+			Token:   t.AccessToken,
+			Reserve: s.branchReserver(ctx, pushURL, sessionID, branchLockTTL),
+		})
+	}
+
+	// Fail fast on a missing base branch or a protected head branch instead of
+	// a cryptic push or API error once the commit is made.
+	if err := gitpkg.ValidatePushTarget(ctx, repoInfo, headRepo, t.AccessToken, baseBranch, branchName); err != nil {
+		_ = s.sessionService.UpdateStatus(ctx, sessionID, previousStatus)
+		return nil, pushTargetAppError(err)
+	}
 
 	// Create commit message — try AI, fall back to formatted message
 	commitMsg := gitpkg.FormatCommitMessage(title, sessionID, s.cfg.CommitAuthor, s.cfg.CommitEmail)
 	if s.ai != nil {
-		if diffOut, diffErr := gitpkg.GetUnstagedDiff(ctx, workDir); diffErr == nil && diffOut != "" {
+		if diffOut, diffErr := gitpkg.GetUnstagedDiff(ctx, workDir, ignoreGlobs...); diffErr == nil && diffOut != "" {
 			if generated := ai.GenerateCommitMessage(ctx, s.ai, diffOut, t.Prompt); generated != "" {
 				commitMsg = generated
 			}
@@ -186,19 +324,24 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 		AuthorName:  s.cfg.CommitAuthor,
 		AuthorEmail: s.cfg.CommitEmail,
 		Token:       t.AccessToken,
+		IgnoreGlobs: ignoreGlobs,
+		Remote:      remote,
 	})
 	if err != nil {
 		// Revert status back instead of failing the session — user can retry or send new instructions
 		_ = s.sessionService.UpdateStatus(ctx, sessionID, previousStatus)
-		return nil, fmt.Errorf("creating branch and pushing: %w", err)
+		return nil, divergedAppError(fmt.Errorf("creating branch and pushing: %w", err))
 	}
 
 	// Create PR/MR on provider
+	mrOpts := s.cfg.GitLabMR.Merge(req.GitLab)
 	prResult, err := gitpkg.CreatePR(ctx, repoInfo, t.AccessToken, gitpkg.PRCreateOptions{
 		Title:       title,
 		Description: description,
 		Branch:      branchName,
 		BaseBranch:  baseBranch,
+		HeadRepo:    headRepo,
+		GitLab:      &mrOpts,
 	})
 	if err != nil {
 		s.failPR(ctx, sessionID, err)
@@ -213,7 +356,7 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 		"pr_number": prResult.Number,
 	})
 
-	s.sessionService.persistToSQLite(func() error {
+	s.sessionService.persistToSQLite(sessionID, func() error {
 		return s.sessionService.sqlite.UpdatePR(ctx, sessionID, branchName, prResult.URL, prResult.Number)
 	})
 
@@ -222,27 +365,40 @@ func (s *PRService) CreatePR(ctx context.Context, sessionID string, req CreatePR
 		slog.Error("failed to transition to pr_created", "session_id", sessionID, "error", err)
 	}
 
+	if err := s.sessionService.AddTimings(ctx, sessionID, &Timings{PR: time.Since(prStarted).Seconds()}); err != nil {
+		slog.Warn("failed to record PR timing", "session_id", sessionID, "error", err)
+	}
+
 	slog.Info("PR created", "session_id", sessionID, "pr_url", prResult.URL, "branch", branchName)
 
-	return &CreatePRResponse{
-		PRURL:    prResult.URL,
-		PRNumber: prResult.Number,
-		Branch:   branchName,
-	}, nil
+	resp := &CreatePRResponse{
+		PRURL:             prResult.URL,
+		PRNumber:          prResult.Number,
+		Branch:            branchName,
+		ApprovalsRequired: prResult.ApprovalsRequired,
+		ApprovalRules:     prResult.ApprovalRules,
+		description:       description,
+	}
+	if req.AutoMerge {
+		resp.AutoMerge = s.enableAutoMerge(ctx, sessionID, repoInfo, t.AccessToken, prResult.Number)
+	}
+	return resp, nil
 }
 
-// PushToPRResponse is the response for a successful push to an existing PR.
+// PushToPRResponse is the response for a successful push to the session branch.
 type PushToPRResponse struct {
-	PRURL   string `json:"pr_url"`
+	PRURL   string `json:"pr_url,omitempty"` // empty when the session has no PR
 	Branch  string `json:"branch"`
 	Message string `json:"message"`
 }
 
-// PushToPR pushes new changes to an existing PR branch without creating a new PR/MR.
-// The existing MR/PR on GitLab/GitHub auto-updates when the branch gets new commits.
+// PushToPR pushes new changes to the session branch without creating a PR/MR.
+// The existing MR/PR on GitLab/GitHub auto-updates when the branch gets new
+// commits. A session without a PR is pushed to its branch, created and
+// recorded on the first push, for review automation that opens PRs itself.
 func (s *PRService) PushToPR(ctx context.Context, sessionID string) (*PushToPRResponse, error) {
 	// Load session
-	t, err := s.sessionService.Get(ctx, sessionID)
+	t, err := s.sessionService.Get(ctx, sessionID, WithSecrets())
 	if err != nil {
 		return nil, err
 	}
@@ -252,11 +408,6 @@ func (s *PRService) PushToPR(ctx context.Context, sessionID string) (*PushToPRRe
 		return nil, fmt.Errorf("session must be in completed or pr_created status, currently: %s", t.Status)
 	}
 
-	// Validate that a PR was previously created
-	if t.Branch == "" {
-		return nil, fmt.Errorf("no existing PR — use create-pr first")
-	}
-
 	// Resolve workspace dir
 	workDir := filepath.Join(s.cfg.WorkspaceBase, sessionID)
 	if s.workspaceResolver != nil {
@@ -266,18 +417,39 @@ func (s *PRService) PushToPR(ctx context.Context, sessionID string) (*PushToPRRe
 	}
 
 	// Resolve access token if not already set
-	if s.tokenResolver != nil && t.AccessToken == "" {
-		token, err := s.tokenResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
-		if err != nil {
-			return nil, fmt.Errorf("resolving access token for push: %w", err)
+	if err := s.requireToken(ctx, t, "", "push"); err != nil {
+		return nil, err
+	}
+	if err := s.runPrePRHooks(ctx, t, workDir); err != nil {
+		return nil, err
+	}
+
+	ignoreGlobs := t.IgnoreGlobs(workDir)
+
+	remote, _, err := s.pushTarget(ctx, t, workDir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// No branch yet: branch off the workspace HEAD, as create-pr would.
+	if t.Branch == "" {
+		changedPaths, _ := gitpkg.ChangedFiles(ctx, workDir, ignoreGlobs...)
+		if len(changedPaths) == 0 {
+			return nil, fmt.Errorf("no new changes to push")
+		}
+		branch := s.sessionBranchName(ctx, t, workDir, remote, changedPaths, branchLockTTL)
+		if err := gitpkg.CheckoutWorkBranch(ctx, workDir, remote, branch, t.AccessToken); err != nil {
+			return nil, fmt.Errorf("creating branch: %w", err)
+		}
+		if err := s.recordBranch(ctx, t, branch); err != nil {
+			return nil, err
 		}
-		t.AccessToken = token
 	}
 
 	// Generate commit message — try AI, fall back to generic
 	commitMsg := "follow-up changes"
 	if s.ai != nil {
-		if diffOut, diffErr := gitpkg.GetUnstagedDiff(ctx, workDir); diffErr == nil && diffOut != "" {
+		if diffOut, diffErr := gitpkg.GetUnstagedDiff(ctx, workDir, ignoreGlobs...); diffErr == nil && diffOut != "" {
 			if generated := ai.GenerateCommitMessage(ctx, s.ai, diffOut, t.Prompt); generated != "" {
 				commitMsg = generated
 			}
@@ -292,20 +464,29 @@ func (s *PRService) PushToPR(ctx context.Context, sessionID string) (*PushToPRRe
 		AuthorName:  s.cfg.CommitAuthor,
 		AuthorEmail: s.cfg.CommitEmail,
 		Token:       t.AccessToken,
+		IgnoreGlobs: ignoreGlobs,
+		Remote:      remote,
 	}); err != nil {
-		return nil, err
+		return nil, divergedAppError(err)
 	}
 
-	slog.Info("pushed to existing PR", "session_id", sessionID, "branch", t.Branch)
+	slog.Info("pushed session branch", "session_id", sessionID, "branch", t.Branch, "pr_url", t.PRURL)
 
 	// Recalculate changes summary
-	recalc, err := gitpkg.CalculateChanges(ctx, workDir)
+	recalc, err := gitpkg.CalculateChanges(ctx, workDir, ignoreGlobs...)
 	if err == nil && recalc != nil {
 		t.ChangesSummary = recalc
 		stateKey := s.sessionService.redis.Key("session", sessionID, "state")
 		s.sessionService.redis.Unwrap().HSet(ctx, stateKey, "changes_summary", MarshalChangesSummary(recalc))
 	}
 
+	if !t.HasPR() {
+		return &PushToPRResponse{
+			Branch:  t.Branch,
+			Message: "Changes pushed to branch",
+		}, nil
+	}
+
 	// Ensure status is pr_created
 	if t.Status != StatusPRCreated {
 		if err := s.sessionService.UpdateStatus(ctx, sessionID, StatusPRCreated); err != nil {
@@ -320,9 +501,130 @@ func (s *PRService) PushToPR(ctx context.Context, sessionID string) (*PushToPRRe
 	}, nil
 }
 
+// RebaseRequest is the request body for POST /sessions/:id/rebase.
+type RebaseRequest struct {
+	TargetBranch string `json:"target_branch,omitempty"`                                              // base to update onto (default: session target branch, then repo default)
+	Strategy     string `json:"strategy,omitempty" validate:"omitempty,oneof=rebase merge"`           // default rebase
+	OnConflict   string `json:"on_conflict,omitempty" validate:"omitempty,oneof=abort pause resolve"` // default abort
+}
+
+// RebaseResponse is the response for a branch update.
+type RebaseResponse struct {
+	Branch       string   `json:"branch"`
+	TargetBranch string   `json:"target_branch"`
+	Strategy     string   `json:"strategy"`
+	HeadSHA      string   `json:"head_sha"`
+	UpToDate     bool     `json:"up_to_date"`
+	Conflicts    []string `json:"conflicts,omitempty"` // files left conflicted in the workspace
+	Status       string   `json:"status,omitempty"`    // new session status when conflicts were kept
+	Iteration    int      `json:"iteration,omitempty"` // resolution iteration started by on_conflict=resolve
+	Message      string   `json:"message"`
+}
+
+// Rebase brings the session's PR branch up to date with its target branch
+// after the target moved: fetch, rebase (or merge) in the workspace and
+// force-push with lease. Conflicts abort the update and leave the workspace
+// as it was, unless req.OnConflict keeps them for the CLI to resolve.
+func (s *PRService) Rebase(ctx context.Context, sessionID string, req RebaseRequest) (*RebaseResponse, error) {
+	t, err := s.sessionService.Get(ctx, sessionID, WithSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusCompleted && t.Status != StatusPRCreated {
+		return nil, apperror.Conflict("session must be in completed or pr_created status, currently: %s", t.Status)
+	}
+	if !t.HasPR() {
+		return nil, apperror.Validation("no existing PR — use create-pr first")
+	}
+
+	workDir := filepath.Join(s.cfg.WorkspaceBase, sessionID)
+	if s.workspaceResolver != nil {
+		if resolved := s.workspaceResolver.WorkspacePath(ctx, sessionID); resolved != "" {
+			workDir = resolved
+		}
+	}
+
+	if s.tokenResolver != nil && t.AccessToken == "" && !t.IsAnonymous() {
+		token, err := s.tokenResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
+		if err != nil {
+			return nil, fmt.Errorf("resolving access token for rebase: %w", err)
+		}
+		t.AccessToken = token
+	}
+
+	baseBranch := req.TargetBranch
+	if baseBranch == "" && t.Config != nil {
+		baseBranch = t.Config.TargetBranch
+	}
+	if baseBranch == "" {
+		repo, _ := s.parseRepo(ctx, t) // nil = no provider fallback
+		baseBranch = gitpkg.ResolveDefaultBranch(ctx, workDir, repo, t.AccessToken)
+	}
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = gitpkg.UpdateRebase
+	}
+	onConflict := req.OnConflict
+	if onConflict == "" {
+		onConflict = OnConflictAbort
+	}
+	remote, _, err := s.pushTarget(ctx, t, workDir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like an instruction, a rebase holds the instruct lock and a busy status
+	// while it rewrites the branch, so no iteration runs on the workspace.
+	if err := s.sessionService.lockBranchUpdate(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	defer s.sessionService.unlockBranchUpdate(context.WithoutCancel(ctx), sessionID)
+	previousStatus := t.Status
+	if err := s.sessionService.UpdateStatus(ctx, sessionID, StatusCreatingPR); err != nil {
+		return nil, fmt.Errorf("transitioning to creating_pr: %w", err)
+	}
+
+	result, err := gitpkg.UpdateBranch(ctx, gitpkg.UpdateBranchOptions{
+		WorkDir:       workDir,
+		BranchName:    t.Branch,
+		BaseBranch:    baseBranch,
+		Strategy:      strategy,
+		AuthorName:    s.cfg.CommitAuthor,
+		AuthorEmail:   s.cfg.CommitEmail,
+		Token:         t.AccessToken,
+		Remote:        remote,
+		KeepConflicts: onConflict != OnConflictAbort,
+	})
+	if statusErr := s.sessionService.UpdateStatus(context.WithoutCancel(ctx), sessionID, previousStatus); statusErr != nil {
+		slog.Warn("restoring session status after rebase failed", "session_id", sessionID, "status", previousStatus, "error", statusErr)
+	}
+	resp := &RebaseResponse{
+		Branch:       t.Branch,
+		TargetBranch: baseBranch,
+		Strategy:     strategy,
+	}
+	if err != nil {
+		var ce *gitpkg.ConflictError
+		if errors.As(err, &ce) {
+			return s.handleConflicts(ctx, t, ce, onConflict, resp)
+		}
+		return nil, divergedAppError(fmt.Errorf("updating branch: %w", err))
+	}
+
+	resp.HeadSHA = result.HeadSHA
+	resp.UpToDate = result.UpToDate
+	resp.Message = "Branch updated with " + baseBranch
+	if result.UpToDate {
+		resp.Message = "Branch already up to date with " + baseBranch
+		return resp, nil
+	}
+	slog.Info("PR branch updated", "session_id", sessionID, "branch", t.Branch, "base", baseBranch, "strategy", strategy)
+	return resp, nil
+}
+
 // GetPRStatus checks the current status of a session's PR/MR on the provider.
 func (s *PRService) GetPRStatus(ctx context.Context, sessionID string) (*gitpkg.PRStatus, error) {
-	t, err := s.sessionService.Get(ctx, sessionID)
+	t, err := s.sessionService.Get(ctx, sessionID, WithSecrets())
 	if err != nil {
 		return nil, err
 	}
@@ -330,13 +632,13 @@ func (s *PRService) GetPRStatus(ctx context.Context, sessionID string) (*gitpkg.
 		return nil, fmt.Errorf("session has no PR")
 	}
 
-	repoInfo, err := gitpkg.ParseRepoURL(t.RepoURL, s.cfg.ProviderDomains)
+	repoInfo, err := s.parseRepo(ctx, t)
 	if err != nil {
 		return nil, fmt.Errorf("parsing repo URL: %w", err)
 	}
 
 	// Resolve token
-	if s.tokenResolver != nil && t.AccessToken == "" {
+	if s.tokenResolver != nil && t.AccessToken == "" && !t.IsAnonymous() {
 		token, resolveErr := s.tokenResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
 		if resolveErr != nil {
 			return nil, fmt.Errorf("resolving token: %w", resolveErr)
@@ -349,10 +651,15 @@ func (s *PRService) GetPRStatus(ctx context.Context, sessionID string) (*gitpkg.
 		return nil, err
 	}
 
-	// Sync session status with PR state:
-	// PR merged or closed → transition session to completed (work is done)
+	// Sync session status with PR state: merged or closed → completed (work
+	// is done). Only an auto-merge session ends in the terminal pr_merged;
+	// any other can still take follow-up instructions.
 	if (status.State == "merged" || status.State == "closed") && t.Status == StatusPRCreated {
-		if err := s.sessionService.UpdateStatus(ctx, sessionID, StatusCompleted); err != nil {
+		next := StatusCompleted
+		if status.State == "merged" && t.AutoMerge != "" {
+			next = StatusPRMerged
+		}
+		if err := s.sessionService.UpdateStatus(ctx, sessionID, next); err != nil {
 			slog.Warn("failed to sync session status from PR", "session_id", sessionID, "pr_state", status.State, "error", err)
 		} else {
 			slog.Info("session status synced from PR", "session_id", sessionID, "pr_state", status.State)
@@ -362,6 +669,95 @@ func (s *PRService) GetPRStatus(ctx context.Context, sessionID string) (*gitpkg.
 	return status, nil
 }
 
+// pushTargetAppError maps a rejected push target to a 400 (missing base
+// branch, with suggestions) or 403 (protected head branch).
+func pushTargetAppError(err error) error {
+	var pte *gitpkg.PushTargetError
+	if !errors.As(err, &pte) {
+		return err
+	}
+	fields := map[string]string{pte.Field: pte.Reason}
+	msg := pte.Error()
+	if len(pte.Suggestions) > 0 {
+		list := strings.Join(pte.Suggestions, ", ")
+		fields["available_branches"] = list
+		msg += "; available branches: " + list
+	}
+	appErr := apperror.Validation("%s", msg)
+	if pte.Field == "branch" {
+		appErr = apperror.Forbidden("%s", msg)
+	}
+	appErr.Fields = fields
+	return appErr
+}
+
+// accessAppError maps a token the provider says cannot do the operation to a
+// 403 with the token_access code; other errors pass through unchanged.
+func accessAppError(err error) error {
+	var ae *gitpkg.AccessError
+	if !errors.As(err, &ae) {
+		return err
+	}
+	appErr := apperror.Forbidden("%s", ae.Error())
+	appErr.Code = "token_access"
+	appErr.Fields = map[string]string{"access_token": ae.Reason}
+	return appErr
+}
+
+// divergedAppError maps a push refused because the remote branch moved to a
+// 409 with the branch_diverged code; other errors pass through unchanged.
+func divergedAppError(err error) error {
+	var de *gitpkg.DivergedError
+	if !errors.As(err, &de) {
+		return err
+	}
+	appErr := apperror.Conflict("%s; integrate or discard the remote commits before pushing again", de.Error())
+	appErr.Code = "branch_diverged"
+	appErr.Fields = map[string]string{"branch": de.Branch}
+	if de.RemoteSHA != "" {
+		appErr.Fields["remote_sha"] = de.RemoteSHA
+	}
+	if de.LocalSHA != "" {
+		appErr.Fields["local_sha"] = de.LocalSHA
+	}
+	return appErr
+}
+
+// parseRepo parses the session's repository URL, applying the API base URL
+// of its provider key when the key sets one.
+func (s *PRService) parseRepo(ctx context.Context, t *Session) (*gitpkg.RepoInfo, error) {
+	repo, err := gitpkg.ParseRepoURL(t.RepoURL, s.cfg.ProviderDomains)
+	if err != nil {
+		return nil, err
+	}
+	var explicit string
+	if s.tokenResolver != nil {
+		explicit = s.tokenResolver.APIBaseURL(ctx, t.ProviderKey)
+	}
+	s.cfg.ProviderAPI.Apply(repo, explicit)
+	return repo, nil
+}
+
+// providerAccess resolves the provider repository and access token of a session.
+func (s *PRService) providerAccess(ctx context.Context, sessionID string) (*gitpkg.RepoInfo, string, error) {
+	t, err := s.sessionService.Get(ctx, sessionID, WithSecrets())
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := s.parseRepo(ctx, t)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing repo URL: %w", err)
+	}
+	if s.tokenResolver != nil && t.AccessToken == "" && !t.IsAnonymous() {
+		token, err := s.tokenResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("resolving access token: %w", err)
+		}
+		t.AccessToken = token
+	}
+	return repo, t.AccessToken, nil
+}
+
 func (s *PRService) failPR(ctx context.Context, sessionID string, err error) {
 	slog.Error("PR creation failed", "session_id", sessionID, "error", err)
 	_ = s.sessionService.SetError(ctx, sessionID, fmt.Sprintf("PR creation failed: %v", err))
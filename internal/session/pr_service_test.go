@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+func TestPushTargetAppError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantFields map[string]string
+	}{
+		{
+			name:       "missing base",
+			err:        &gitpkg.PushTargetError{Field: "target_branch", Branch: "main", Reason: "does not exist in acme/api", Suggestions: []string{"master", "develop"}},
+			wantStatus: http.StatusBadRequest,
+			wantFields: map[string]string{"target_branch": "does not exist in acme/api", "available_branches": "master, develop"},
+		},
+		{
+			name:       "protected head",
+			err:        &gitpkg.PushTargetError{Field: "branch", Branch: "codeforge/x", Reason: "a repository ruleset restricts creating this branch"},
+			wantStatus: http.StatusForbidden,
+			wantFields: map[string]string{"branch": "a repository ruleset restricts creating this branch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pushTargetAppError(tt.err)
+			if got := apperror.HTTPStatus(err); got != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got, tt.wantStatus)
+			}
+			var appErr *apperror.AppError
+			if !errors.As(err, &appErr) {
+				t.Fatalf("not an AppError: %v", err)
+			}
+			for k, v := range tt.wantFields {
+				if appErr.Fields[k] != v {
+					t.Errorf("fields[%s] = %q, want %q", k, appErr.Fields[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessAppError(t *testing.T) {
+	err := accessAppError(&gitpkg.AccessError{Repo: "acme/api", Reason: "token lacks write access to acme/api"})
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("not an AppError: %v", err)
+	}
+	if appErr.Status != http.StatusForbidden || appErr.Code != "token_access" || appErr.Message != "token lacks write access to acme/api" {
+		t.Errorf("err = %d %s %q", appErr.Status, appErr.Code, appErr.Message)
+	}
+
+	other := errors.New("boom")
+	if got := accessAppError(other); got != other {
+		t.Errorf("other errors must pass through, got %v", got)
+	}
+}
+
+func TestHandleConflicts_NotKept(t *testing.T) {
+	svc := &PRService{}
+	ce := &gitpkg.ConflictError{Strategy: "rebase", Base: "main", Files: []string{"a.go", "b.go"}}
+	_, err := svc.handleConflicts(context.Background(), &Session{ID: "s1", Branch: "codeforge/x"}, ce, OnConflictAbort, &RebaseResponse{})
+
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected AppError, got %v", err)
+	}
+	if apperror.HTTPStatus(err) != http.StatusConflict {
+		t.Errorf("status = %d, want 409", apperror.HTTPStatus(err))
+	}
+	if appErr.Fields["conflicts"] != "a.go, b.go" {
+		t.Errorf("fields = %v", appErr.Fields)
+	}
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// moveToFront moves ARGV[1] from whichever queue it waits in to the head of
+// the first (high priority) queue, the end workers pop from. KEYS are the
+// queues in drain order. It returns the entry's previous 0-based position
+// counted across all queues, or -1 when it is not queued. One script, so a
+// worker can never dequeue the entry in between and have it pushed back as a
+// duplicate.
+var moveToFront = redis.NewScript(`
+local ahead = 0
+for _, key in ipairs(KEYS) do
+	local pos = redis.call("LPOS", key, ARGV[1])
+	if pos then
+		redis.call("LREM", key, 0, ARGV[1])
+		redis.call("LPUSH", KEYS[1], ARGV[1])
+		return ahead + pos
+	end
+	ahead = ahead + redis.call("LLEN", key)
+end
+return -1`)
+
+// Prioritize moves a session waiting in the queue to the front of the high
+// priority queue, so the next free worker picks it up — for urgent work stuck
+// behind a batch. Its priority for later iterations is unchanged. Returns the
+// session's previous 1-based queue position.
+func (s *Service) Prioritize(ctx context.Context, sessionID string) (int, error) {
+	t, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	pos, err := moveToFront.Run(ctx, s.redis.Unwrap(), s.queueKeys(), sessionID).Int()
+	if err != nil {
+		return 0, fmt.Errorf("moving session to the front of the queue: %w", err)
+	}
+	if pos < 0 {
+		return 0, apperror.Conflict("session is %s and not waiting in the queue", t.Status)
+	}
+
+	slog.Info("session prioritized", "session_id", sessionID, "previous_position", pos+1)
+	return pos + 1, nil
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// Priority decides which queue a session waits in. Workers drain the high
+// queue first, then normal, then low, so batch work created as low never
+// holds up interactive sessions.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// Priorities lists the priorities in the order workers drain their queues.
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// validatePriority checks the priority of a create request; empty is normal.
+func validatePriority(p Priority) error {
+	switch p {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return nil
+	}
+	appErr := apperror.Validation("invalid priority: must be high, normal or low")
+	appErr.Fields = map[string]string{"priority": "must be high, normal or low"}
+	return appErr
+}
+
+// QueueName returns the queue list for priority p under the configured queue
+// name. Normal sessions keep the base name, so entries queued before there
+// were priorities are still picked up.
+func QueueName(base string, p Priority) string {
+	switch p {
+	case PriorityHigh, PriorityLow:
+		return base + ":" + string(p)
+	}
+	return base
+}
+
+// QueueNames returns the queue lists under base in drain order.
+func QueueNames(base string) []string {
+	names := make([]string, len(Priorities))
+	for i, p := range Priorities {
+		names[i] = QueueName(base, p)
+	}
+	return names
+}
+
+// queueKey returns the Redis key of the queue for priority p.
+func (s *Service) queueKey(p Priority) string {
+	return s.redis.Key(QueueName(s.queueName, p))
+}
+
+// queueKeys returns the Redis keys of all queues in drain order.
+func (s *Service) queueKeys() []string {
+	names := QueueNames(s.queueName)
+	for i, name := range names {
+		names[i] = s.redis.Key(name)
+	}
+	return names
+}
+
+// sessionQueueKey reads the priority of sessionID through rdb (a WATCH
+// transaction or the client) and returns its queue key.
+func (s *Service) sessionQueueKey(ctx context.Context, rdb redis.Cmdable, sessionID string) (string, error) {
+	p, err := rdb.HGet(ctx, s.redis.Key("session", sessionID, "state"), "priority").Result()
+	if err != nil && err != redis.Nil {
+		return "", fmt.Errorf("reading session priority: %w", err)
+	}
+	return s.queueKey(Priority(p)), nil
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePriority(t *testing.T) {
+	tests := []struct {
+		in      Priority
+		wantErr bool
+	}{
+		{"", false},
+		{PriorityHigh, false},
+		{PriorityNormal, false},
+		{PriorityLow, false},
+		{"HIGH", true},
+		{"urgent", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.in), func(t *testing.T) {
+			err := validatePriority(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueueNames(t *testing.T) {
+	tests := []struct {
+		priority Priority
+		want     string
+	}{
+		{PriorityHigh, "queue:sessions:high"},
+		{PriorityNormal, "queue:sessions"},
+		{"", "queue:sessions"},
+		{PriorityLow, "queue:sessions:low"},
+	}
+	for _, tt := range tests {
+		if got := QueueName("queue:sessions", tt.priority); got != tt.want {
+			t.Errorf("QueueName(%q) = %q, want %q", tt.priority, got, tt.want)
+		}
+	}
+
+	want := "queue:sessions:high,queue:sessions,queue:sessions:low"
+	if got := strings.Join(QueueNames("queue:sessions"), ","); got != want {
+		t.Errorf("QueueNames = %s, want %s", got, want)
+	}
+}
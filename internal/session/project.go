@@ -0,0 +1,143 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/redisclient"
+)
+
+// ProjectSettings are per-repository defaults merged under the config of every
+// session created for that repository. Fields left empty in a session request
+// are taken from here; anything the request sets wins.
+type ProjectSettings struct {
+	Repo           string      `json:"repo"` // lower-cased owner/repo, from the URL
+	CLI            string      `json:"cli,omitempty"`
+	AIModel        string      `json:"ai_model,omitempty"`
+	TimeoutSeconds int         `json:"timeout_seconds,omitempty" validate:"gte=0"`
+	MaxTurns       int         `json:"max_turns,omitempty" validate:"gte=0"`
+	MCPServers     []MCPServer `json:"mcp_servers,omitempty"`
+	CallbackURL    string      `json:"callback_url,omitempty" validate:"omitempty,url"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// ProjectStore keeps project settings in Redis, one JSON value per repository.
+type ProjectStore struct {
+	redis *redisclient.Client
+}
+
+// NewProjectStore creates a project settings store.
+func NewProjectStore(redis *redisclient.Client) *ProjectStore {
+	return &ProjectStore{redis: redis}
+}
+
+func (p *ProjectStore) key(repo string) string { return p.redis.Key("project", repo) }
+func (p *ProjectStore) indexKey() string       { return p.redis.Key("projects:index") }
+
+// Put creates or replaces the settings of ps.Repo.
+func (p *ProjectStore) Put(ctx context.Context, ps *ProjectSettings) error {
+	ps.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(ps)
+	if err != nil {
+		return fmt.Errorf("marshaling project settings: %w", err)
+	}
+	pipe := p.redis.Unwrap().TxPipeline()
+	pipe.Set(ctx, p.key(ps.Repo), data, 0)
+	pipe.SAdd(ctx, p.indexKey(), ps.Repo)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("saving project settings: %w", err)
+	}
+	return nil
+}
+
+// Get returns the settings of repo (lower-cased owner/repo).
+func (p *ProjectStore) Get(ctx context.Context, repo string) (*ProjectSettings, error) {
+	data, err := p.redis.Unwrap().Get(ctx, p.key(repo)).Bytes()
+	if err == redis.Nil {
+		return nil, apperror.NotFound("project %s has no settings", repo)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading project settings: %w", err)
+	}
+	var ps ProjectSettings
+	if err := json.Unmarshal(data, &ps); err != nil {
+		return nil, fmt.Errorf("decoding project settings: %w", err)
+	}
+	return &ps, nil
+}
+
+// List returns the settings of all projects, ordered by repository.
+func (p *ProjectStore) List(ctx context.Context) ([]ProjectSettings, error) {
+	repos, err := p.redis.Unwrap().SMembers(ctx, p.indexKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+	sort.Strings(repos)
+
+	out := make([]ProjectSettings, 0, len(repos))
+	for _, repo := range repos {
+		ps, err := p.Get(ctx, repo)
+		if err != nil {
+			continue // removed concurrently
+		}
+		out = append(out, *ps)
+	}
+	return out, nil
+}
+
+// Delete removes the settings of repo.
+func (p *ProjectStore) Delete(ctx context.Context, repo string) error {
+	pipe := p.redis.Unwrap().TxPipeline()
+	del := pipe.Del(ctx, p.key(repo))
+	pipe.SRem(ctx, p.indexKey(), repo)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("deleting project settings: %w", err)
+	}
+	if del.Val() == 0 {
+		return apperror.NotFound("project %s has no settings", repo)
+	}
+	return nil
+}
+
+// SetProjectStore enables per-repository defaults at session creation.
+func (s *Service) SetProjectStore(p *ProjectStore) {
+	s.projects = p
+}
+
+func (ps *ProjectSettings) applyTo(req *CreateSessionRequest) {
+	if req.CallbackURL == "" {
+		req.CallbackURL = ps.CallbackURL
+	}
+	if req.Config == nil {
+		req.Config = &Config{}
+	}
+	cfg := req.Config
+	if cfg.CLI == "" {
+		cfg.CLI = ps.CLI
+	}
+	if cfg.AIModel == "" {
+		cfg.AIModel = ps.AIModel
+	}
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = ps.TimeoutSeconds
+	}
+	if cfg.MaxTurns == 0 {
+		cfg.MaxTurns = ps.MaxTurns
+	}
+	// Project MCP servers join the session's; a same-named session server wins.
+	have := make(map[string]bool, len(cfg.MCPServers))
+	for _, srv := range cfg.MCPServers {
+		have[srv.Name] = true
+	}
+	for _, srv := range ps.MCPServers {
+		if !have[srv.Name] {
+			cfg.MCPServers = append(cfg.MCPServers, srv)
+		}
+	}
+}
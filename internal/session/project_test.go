@@ -0,0 +1,76 @@
+package session
+
+import "testing"
+
+func TestProjectSettings_ApplyTo(t *testing.T) {
+	ps := &ProjectSettings{
+		CLI:            "codex",
+		AIModel:        "gpt-5",
+		TimeoutSeconds: 900,
+		MaxTurns:       20,
+		MCPServers:     []MCPServer{{Name: "docs", Command: "docs-mcp"}, {Name: "db", Command: "db-mcp"}},
+		CallbackURL:    "https://hooks.example.com/project",
+	}
+
+	tests := []struct {
+		name     string
+		req      CreateSessionRequest
+		wantCLI  string
+		wantTurn int
+		wantCB   string
+		wantMCP  []string
+	}{
+		{
+			name:     "no config",
+			req:      CreateSessionRequest{},
+			wantCLI:  "codex",
+			wantTurn: 20,
+			wantCB:   "https://hooks.example.com/project",
+			wantMCP:  []string{"docs", "db"},
+		},
+		{
+			name: "request wins",
+			req: CreateSessionRequest{
+				CallbackURL: "https://hooks.example.com/mine",
+				Config: &Config{
+					CLI:        "claude-code",
+					MaxTurns:   5,
+					MCPServers: []MCPServer{{Name: "db", Command: "my-db"}},
+				},
+			},
+			wantCLI:  "claude-code",
+			wantTurn: 5,
+			wantCB:   "https://hooks.example.com/mine",
+			wantMCP:  []string{"db", "docs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			ps.applyTo(&req)
+			ps.applyTo(&req) // idempotent
+
+			if req.Config.CLI != tt.wantCLI || req.Config.MaxTurns != tt.wantTurn || req.CallbackURL != tt.wantCB {
+				t.Errorf("got cli=%q turns=%d callback=%q", req.Config.CLI, req.Config.MaxTurns, req.CallbackURL)
+			}
+			if req.Config.AIModel != "gpt-5" || req.Config.TimeoutSeconds != 900 {
+				t.Errorf("model/timeout not filled: %+v", req.Config)
+			}
+			var names []string
+			for _, s := range req.Config.MCPServers {
+				names = append(names, s.Name)
+			}
+			if len(names) != len(tt.wantMCP) {
+				t.Fatalf("mcp servers = %v, want %v", names, tt.wantMCP)
+			}
+			for i := range names {
+				if names[i] != tt.wantMCP[i] {
+					t.Errorf("mcp servers = %v, want %v", names, tt.wantMCP)
+				}
+			}
+			if tt.name == "request wins" && req.Config.MCPServers[0].Command != "my-db" {
+				t.Errorf("session MCP server was overridden: %+v", req.Config.MCPServers[0])
+			}
+		})
+	}
+}
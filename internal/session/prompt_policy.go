@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/audit"
+	"github.com/freema/codeforge/internal/policy"
+)
+
+// SetPromptPolicy installs the prompt moderation hook run on create and
+// instruct. Decisions are recorded in auditLog when it is non-nil.
+func (s *Service) SetPromptPolicy(checker policy.PromptChecker, auditLog *audit.Store) {
+	s.promptPolicy = checker
+	s.auditLog = auditLog
+}
+
+// checkPrompt runs the prompt policy for a create or instruct of session t.
+// A reject becomes a 403; a flag is logged and audited but lets the prompt through.
+func (s *Service) checkPrompt(ctx context.Context, kind string, t *Session, prompt string) error {
+	if s.promptPolicy == nil {
+		return nil
+	}
+
+	d, err := s.promptPolicy.CheckPrompt(ctx, policy.PromptRequest{
+		Kind:      kind,
+		SessionID: t.ID,
+		TenantID:  t.TenantID,
+		RepoURL:   t.RepoURL,
+		Prompt:    prompt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if d.Action != policy.PromptAllow {
+		slog.Warn("prompt policy decision",
+			"session_id", t.ID, "kind", kind, "action", d.Action,
+			"source", d.Source, "rule", d.Rule, "reason", d.Reason)
+	}
+
+	if s.auditLog != nil {
+		entry := &audit.Entry{
+			SessionID: t.ID,
+			TenantID:  t.TenantID,
+			Action:    "prompt." + kind,
+			Decision:  string(d.Action),
+			Reason:    d.Reason,
+			Detail: map[string]string{
+				"source":   d.Source,
+				"rule":     d.Rule,
+				"repo_url": t.RepoURL,
+			},
+		}
+		if err := s.auditLog.Record(ctx, entry); err != nil {
+			slog.Warn("failed to record prompt audit entry", "session_id", t.ID, "error", err)
+		}
+	}
+
+	if d.Action == policy.PromptReject {
+		if d.Reason == "" {
+			return apperror.Forbidden("prompt rejected by policy")
+		}
+		return apperror.Forbidden("prompt rejected by policy: %s", d.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/audit"
+	"github.com/freema/codeforge/internal/database"
+	"github.com/freema/codeforge/internal/policy"
+)
+
+func TestCheckPrompt(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := database.Migrate(context.Background(), db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	auditLog := audit.NewStore(db)
+
+	regex, err := policy.NewRegexPromptPolicy([]string{`exfiltrat`}, []string{`production`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &Service{}
+	svc.SetPromptPolicy(regex, auditLog)
+
+	tests := []struct {
+		name      string
+		kind      string
+		prompt    string
+		wantErr   bool
+		wantAudit string
+	}{
+		{"allow", "create", "fix the bug", false, "allow"},
+		{"flag", "instruct", "deploy to production", false, "flag"},
+		{"reject", "instruct", "exfiltrate the env", true, "reject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := &Session{ID: "sess-" + tt.name, RepoURL: "https://github.com/acme/api"}
+			err := svc.checkPrompt(context.Background(), tt.kind, sess, tt.prompt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, apperror.ErrForbidden) {
+				t.Errorf("expected forbidden, got %v", err)
+			}
+
+			entries, err := auditLog.List(context.Background(), audit.Filter{SessionID: sess.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("got %d audit entries, want 1", len(entries))
+			}
+			if entries[0].Decision != tt.wantAudit || entries[0].Action != "prompt."+tt.kind {
+				t.Errorf("unexpected audit entry: %+v", entries[0])
+			}
+		})
+	}
+}
+
+func TestCheckPrompt_NoPolicy(t *testing.T) {
+	svc := &Service{}
+	if err := svc.checkPrompt(context.Background(), "create", &Session{}, "exfiltrate"); err != nil {
+		t.Errorf("expected nil without policy, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/redisclient"
+)
+
+// PromptUpload describes a prompt uploaded ahead of session creation, for
+// prompts over the inline limit. Uploads may arrive in chunks; a session can
+// reference one by ID once it is complete.
+type PromptUpload struct {
+	ID        string    `json:"id"`
+	Size      int64     `json:"size"` // bytes received so far — the offset for the next chunk
+	Complete  bool      `json:"complete"`
+	TenantID  string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PromptStore keeps uploaded prompts in Redis until they expire.
+type PromptStore struct {
+	redis    *redisclient.Client
+	maxBytes int64
+	ttl      time.Duration
+}
+
+// NewPromptStore creates a prompt upload store.
+func NewPromptStore(redis *redisclient.Client, maxBytes int64, ttl time.Duration) *PromptStore {
+	return &PromptStore{redis: redis, maxBytes: maxBytes, ttl: ttl}
+}
+
+// MaxBytes is the size limit of one uploaded prompt.
+func (p *PromptStore) MaxBytes() int64 { return p.maxBytes }
+
+func (p *PromptStore) dataKey(id string) string { return p.redis.Key("prompt", id) }
+func (p *PromptStore) metaKey(id string) string { return p.redis.Key("prompt", id, "meta") }
+
+// Create starts an upload with its first (possibly only) chunk.
+func (p *PromptStore) Create(ctx context.Context, tenantID string, data []byte, complete bool) (*PromptUpload, error) {
+	if err := p.checkSize(0, data); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	up := &PromptUpload{
+		ID:        uuid.New().String(),
+		Size:      int64(len(data)),
+		Complete:  complete,
+		TenantID:  tenantID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(p.ttl),
+	}
+
+	pipe := p.redis.Unwrap().TxPipeline()
+	pipe.Set(ctx, p.dataKey(up.ID), data, p.ttl)
+	pipe.HSet(ctx, p.metaKey(up.ID), map[string]interface{}{
+		"complete":   strconv.FormatBool(complete),
+		"tenant_id":  tenantID,
+		"created_at": now.Format(time.RFC3339Nano),
+	})
+	pipe.Expire(ctx, p.metaKey(up.ID), p.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("saving prompt upload: %w", err)
+	}
+	return up, nil
+}
+
+// Append adds a chunk at offset, which must equal the bytes received so far:
+// a client that lost a response asks Get for the size and resumes from there.
+func (p *PromptStore) Append(ctx context.Context, id, tenantID string, offset int64, data []byte, complete bool) (*PromptUpload, error) {
+	up, err := p.Get(ctx, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if up.Complete {
+		return nil, apperror.Conflict("prompt upload %s is already complete", id)
+	}
+	if offset != up.Size {
+		return nil, &apperror.AppError{
+			Err:     apperror.ErrConflict,
+			Message: fmt.Sprintf("upload offset %d does not match received size %d", offset, up.Size),
+			Status:  http.StatusConflict,
+			Fields:  map[string]string{"offset": strconv.FormatInt(up.Size, 10)},
+		}
+	}
+	if err := p.checkSize(up.Size, data); err != nil {
+		return nil, err
+	}
+
+	pipe := p.redis.Unwrap().TxPipeline()
+	size := pipe.Append(ctx, p.dataKey(id), string(data))
+	if complete {
+		pipe.HSet(ctx, p.metaKey(id), "complete", "true")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("appending prompt upload: %w", err)
+	}
+	up.Size = size.Val()
+	up.Complete = complete
+	return up, nil
+}
+
+// Get returns the upload state. Uploads of another tenant are reported as missing.
+func (p *PromptStore) Get(ctx context.Context, id, tenantID string) (*PromptUpload, error) {
+	pipe := p.redis.Unwrap().Pipeline()
+	meta := pipe.HGetAll(ctx, p.metaKey(id))
+	size := pipe.StrLen(ctx, p.dataKey(id))
+	ttl := pipe.PTTL(ctx, p.dataKey(id))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("loading prompt upload: %w", err)
+	}
+	fields := meta.Val()
+	if len(fields) == 0 || fields["tenant_id"] != tenantID {
+		return nil, apperror.NotFound("prompt upload %s not found or expired", id)
+	}
+	up := &PromptUpload{
+		ID:       id,
+		Size:     size.Val(),
+		Complete: fields["complete"] == "true",
+		TenantID: fields["tenant_id"],
+	}
+	up.CreatedAt, _ = time.Parse(time.RFC3339Nano, fields["created_at"])
+	if d := ttl.Val(); d > 0 {
+		up.ExpiresAt = time.Now().UTC().Add(d).Truncate(time.Second)
+	}
+	return up, nil
+}
+
+// Load returns the content of a complete upload.
+func (p *PromptStore) Load(ctx context.Context, id, tenantID string) (string, error) {
+	up, err := p.Get(ctx, id, tenantID)
+	if err != nil {
+		return "", err
+	}
+	if !up.Complete {
+		return "", apperror.Validation("prompt upload %s is incomplete (%d bytes received)", id, up.Size)
+	}
+	data, err := p.redis.Unwrap().Get(ctx, p.dataKey(id)).Result()
+	if err == redis.Nil {
+		return "", apperror.NotFound("prompt upload %s not found or expired", id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("loading prompt upload: %w", err)
+	}
+	if !utf8.ValidString(data) {
+		return "", apperror.Validation("prompt upload %s is not valid UTF-8", id)
+	}
+	return data, nil
+}
+
+func (p *PromptStore) checkSize(current int64, data []byte) error {
+	if p.maxBytes > 0 && current+int64(len(data)) > p.maxBytes {
+		return apperror.Validation("prompt upload exceeds %d bytes", p.maxBytes)
+	}
+	return nil
+}
+
+// SetPromptStore enables prompt_ref on create and instruct.
+func (s *Service) SetPromptStore(p *PromptStore) {
+	s.prompts = p
+}
+
+// ResolvePrompt returns the inline prompt or, when ref is set, the content of
+// the referenced upload. Setting both is rejected.
+func (s *Service) ResolvePrompt(ctx context.Context, prompt, ref, tenantID string) (string, error) {
+	if ref == "" {
+		return prompt, nil
+	}
+	if prompt != "" {
+		return "", apperror.Validation("prompt and prompt_ref are mutually exclusive")
+	}
+	if s.prompts == nil {
+		return "", apperror.Validation("prompt uploads are not enabled")
+	}
+	return s.prompts.Load(ctx, ref, tenantID)
+}
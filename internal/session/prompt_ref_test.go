@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestResolvePrompt_WithoutStore(t *testing.T) {
+	svc := &Service{}
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		prompt     string
+		ref        string
+		want       string
+		wantStatus int
+	}{
+		{"inline", "fix it", "", "fix it", 0},
+		{"both", "fix it", "abc", "", http.StatusBadRequest},
+		{"uploads disabled", "", "abc", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ResolvePrompt(ctx, tt.prompt, tt.ref, "")
+			if tt.wantStatus != 0 {
+				if err == nil || apperror.HTTPStatus(err) != tt.wantStatus {
+					t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolvePrompt = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/policy"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+)
+
+// RepoFullName returns the lower-cased "owner/repo" a repository URL is
+// indexed under, or "" when the URL has no owner/repo path. Host, scheme,
+// casing and a ".git" suffix do not matter, so URL variants share one index.
+func RepoFullName(repoURL string) string {
+	info, err := gitpkg.ParseRepoURL(repoURL, nil)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(info.FullName())
+}
+
+// repoIndexKey is the sorted set of a repository's session IDs, scored by
+// creation time (unix seconds).
+func (s *Service) repoIndexKey(fullName string) string {
+	return s.redis.Key("repo", fullName, "sessions")
+}
+
+// ListByRepo returns the summaries of sessions created for owner/repo, newest
+// first. Status, TenantID, Limit and Offset in opts apply as in List. Like
+// List it reads SQLite when configured, so sessions whose Redis state has
+// expired are still listed; otherwise it pages through the Redis index.
+func (s *Service) ListByRepo(ctx context.Context, owner, repo string, opts ListOptions) ([]Summary, int, error) {
+	fullName := strings.ToLower(owner + "/" + repo)
+	if s.sqlite != nil {
+		return s.sqlite.ListByRepo(ctx, fullName, opts)
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	offset := max(opts.Offset, 0)
+
+	key := s.repoIndexKey(fullName)
+	if opts.Status == "" && opts.TenantID == "" {
+		return s.repoIndexPage(ctx, key, offset, limit)
+	}
+
+	// Filtered: walk the index in batches, reading only the fields the
+	// filters need, and load full state for the requested page alone.
+	const batch = 200
+	var page []string
+	total := 0
+	for start := int64(0); ; start += batch {
+		ids, err := s.redis.Unwrap().ZRevRange(ctx, key, start, start+batch-1).Result()
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading repo index: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		pipe := s.redis.Unwrap().Pipeline()
+		cmds := make([]*redis.SliceCmd, len(ids))
+		for i, id := range ids {
+			cmds[i] = pipe.HMGet(ctx, s.redis.Key("session", id, "state"), "status", "tenant_id", "deleted_at")
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, 0, fmt.Errorf("reading repo sessions: %w", err)
+		}
+		for i, cmd := range cmds {
+			vals := cmd.Val()
+			status, _ := vals[0].(string)
+			tenantID, _ := vals[1].(string)
+			deletedAt, _ := vals[2].(string)
+			if status == "" || deletedAt != "" {
+				continue
+			}
+			if opts.Status != "" && status != opts.Status {
+				continue
+			}
+			if opts.TenantID != "" && tenantID != opts.TenantID {
+				continue
+			}
+			if total >= offset && len(page) < limit {
+				page = append(page, ids[i])
+			}
+			total++
+		}
+		if len(ids) < batch {
+			break
+		}
+	}
+
+	sessions, _, err := s.repoSummaries(ctx, key, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// repoIndexPage returns one unfiltered page of the repo index at key.
+func (s *Service) repoIndexPage(ctx context.Context, key string, offset, limit int) ([]Summary, int, error) {
+	total, err := s.redis.Unwrap().ZCard(ctx, key).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading repo index: %w", err)
+	}
+	ids, err := s.redis.Unwrap().ZRevRange(ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading repo index: %w", err)
+	}
+	sessions, stale, err := s.repoSummaries(ctx, key, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	return sessions, int(total) - stale, nil
+}
+
+// repoSummaries loads the summaries of ids from the repo index at key, in
+// order. Entries whose session state has expired are pruned from the index
+// and counted in the second return value; deleted sessions are skipped.
+func (s *Service) repoSummaries(ctx context.Context, key string, ids []string) ([]Summary, int, error) {
+	sessions := []Summary{}
+	if len(ids) == 0 {
+		return sessions, 0, nil
+	}
+	pipe := s.redis.Unwrap().Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, s.redis.Key("session", id, "state"))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, fmt.Errorf("reading repo sessions: %w", err)
+	}
+
+	var stale []interface{}
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			stale = append(stale, ids[i])
+			continue
+		}
+		t := s.hashToSession(fields)
+		if t.DeletedAt != nil {
+			continue
+		}
+		sessions = append(sessions, toSummary(t))
+	}
+	if len(stale) > 0 {
+		s.redis.Unwrap().ZRem(ctx, key, stale...)
+	}
+	return sessions, len(stale), nil
+}
+
+// BackfillRepoNames sets the SQLite repo name of sessions stored before
+// ListByRepo read SQLite. A no-op without SQLite.
+func (s *Service) BackfillRepoNames(ctx context.Context) (int, error) {
+	if s.sqlite == nil {
+		return 0, nil
+	}
+	return s.sqlite.BackfillRepoNames(ctx)
+}
+
+// MigrateRepoIndex indexes sessions created before the per-repo index
+// existed. It runs once per Redis database, guarded by a marker key.
+func (s *Service) MigrateRepoIndex(ctx context.Context) (int, error) {
+	marker := s.redis.Key("repos:index:migrated")
+	first, err := s.redis.Unwrap().SetNX(ctx, marker, time.Now().UTC().Format(time.RFC3339), 0).Result()
+	if err != nil {
+		return 0, fmt.Errorf("checking repo index migration: %w", err)
+	}
+	if !first {
+		return 0, nil
+	}
+
+	ids, err := s.redis.Unwrap().SMembers(ctx, s.redis.Key("sessions:index")).Result()
+	if err != nil {
+		s.redis.Unwrap().Del(ctx, marker) // retry on next start
+		return 0, fmt.Errorf("listing session index: %w", err)
+	}
+
+	pipe := s.redis.Unwrap().Pipeline()
+	cmds := make([]*redis.SliceCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HMGet(ctx, s.redis.Key("session", id, "state"), "repo_url", "created_at")
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			s.redis.Unwrap().Del(ctx, marker)
+			return 0, fmt.Errorf("reading sessions: %w", err)
+		}
+	}
+
+	indexed := 0
+	write := s.redis.Unwrap().Pipeline()
+	for i, cmd := range cmds {
+		vals := cmd.Val()
+		repoURL, _ := vals[0].(string)
+		createdAt, _ := vals[1].(string)
+		name := RepoFullName(repoURL)
+		if name == "" {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339Nano, createdAt)
+		write.ZAdd(ctx, s.repoIndexKey(name), redis.Z{Score: float64(created.Unix()), Member: ids[i]})
+		indexed++
+	}
+	if indexed > 0 {
+		if _, err := write.Exec(ctx); err != nil {
+			s.redis.Unwrap().Del(ctx, marker)
+			return 0, fmt.Errorf("writing repo index: %w", err)
+		}
+	}
+	return indexed, nil
+}
+
+// MigrateRepoURLs normalizes the repo URLs of sessions stored before Create
+// normalized them, in SQLite and in live Redis state, so FindByPR and the
+// repo index match them again. Sessions whose URL was not indexed before
+// (scp-like git@host:owner/repo) are added to the repo index. It runs once
+// per Redis database; the marker is set only after every session was seen,
+// so an interrupted run starts over on the next start.
+func (s *Service) MigrateRepoURLs(ctx context.Context) (int, error) {
+	rdb := s.redis.Unwrap()
+	marker := s.redis.Key("repos:urls:normalized")
+	if n, err := rdb.Exists(ctx, marker).Result(); err != nil {
+		return 0, fmt.Errorf("checking repo URL migration: %w", err)
+	} else if n > 0 {
+		return 0, nil
+	}
+
+	// Normalize without the scheme restrictions: they apply to new sessions,
+	// and a stored URL is rewritten rather than judged.
+	var normalizer *policy.RepoURLPolicy
+	rewrite := func(repoURL string) string {
+		if normalized, err := normalizer.Normalize(repoURL); err == nil {
+			return normalized
+		}
+		return repoURL // e.g. embedded credentials; left as stored
+	}
+
+	rewritten := 0
+	if s.sqlite != nil {
+		changed, err := s.sqlite.RewriteRepoURLs(ctx, rewrite)
+		if err != nil {
+			return 0, err
+		}
+		rewritten = len(changed)
+	}
+
+	ids, err := rdb.SMembers(ctx, s.redis.Key("sessions:index")).Result()
+	if err != nil {
+		return 0, fmt.Errorf("listing session index: %w", err)
+	}
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HMGet(ctx, s.redis.Key("session", id, "state"), "repo_url", "created_at")
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return 0, fmt.Errorf("reading sessions: %w", err)
+		}
+	}
+
+	write := rdb.Pipeline()
+	updated := 0
+	for i, cmd := range cmds {
+		vals := cmd.Val()
+		repoURL, _ := vals[0].(string)
+		createdAt, _ := vals[1].(string)
+		to := rewrite(repoURL)
+		if repoURL == "" || to == repoURL {
+			continue
+		}
+		write.HSet(ctx, s.redis.Key("session", ids[i], "state"), "repo_url", to)
+		if RepoFullName(repoURL) == "" {
+			if name := RepoFullName(to); name != "" {
+				created, _ := time.Parse(time.RFC3339Nano, createdAt)
+				write.ZAdd(ctx, s.repoIndexKey(name), redis.Z{Score: float64(created.Unix()), Member: ids[i]})
+			}
+		}
+		updated++
+	}
+	if updated > 0 {
+		if _, err := write.Exec(ctx); err != nil {
+			return 0, fmt.Errorf("writing repo URLs: %w", err)
+		}
+	}
+
+	if err := rdb.Set(ctx, marker, time.Now().UTC().Format(time.RFC3339), 0).Err(); err != nil {
+		return 0, fmt.Errorf("marking repo URL migration: %w", err)
+	}
+	return max(rewritten, updated), nil
+}
@@ -0,0 +1,21 @@
+package session
+
+import "testing"
+
+func TestRepoFullName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/Acme/API.git", "acme/api"},
+		{"https://github.com/acme/api/", "acme/api"},
+		{"https://gitlab.example.com/group/sub/project", "group/sub/project"},
+		{"https://github.com/acme", ""},
+		{"::not a url", ""},
+	}
+	for _, tt := range tests {
+		if got := RepoFullName(tt.url); got != tt.want {
+			t.Errorf("RepoFullName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// SetMaxRetainTTL bounds how long Retain may keep a session (0 = unbounded).
+func (s *Service) SetMaxRetainTTL(ttl time.Duration) {
+	s.maxRetainTTL = ttl
+}
+
+// Retain extends the expiry of a session's state, result, history, iteration
+// and attachment keys to at least ttl from now, for sessions that must be kept
+// longer (under review, audit). Keys that never expire — an active session's
+// state — are left as they are, and TTLs are only ever extended. Returns the
+// resulting expiry of the state key (zero if it does not expire).
+func (s *Service) Retain(ctx context.Context, sessionID string, ttl time.Duration) (time.Time, error) {
+	if ttl <= 0 {
+		return time.Time{}, apperror.Validation("ttl must be positive")
+	}
+	if s.maxRetainTTL > 0 && ttl > s.maxRetainTTL {
+		return time.Time{}, apperror.Validation("ttl must not exceed %s", s.maxRetainTTL)
+	}
+
+	rdb := s.redis.Unwrap()
+	keys := []string{
+		s.redis.Key("session", sessionID, "state"),
+		s.redis.Key("session", sessionID, "result"),
+		s.redis.Key("session", sessionID, "history"),
+		s.redis.Key("session", sessionID, "iterations"),
+		s.redis.Key("session", sessionID, "attachments"),
+	}
+
+	pipe := rdb.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, k := range keys {
+		ttls[i] = pipe.TTL(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("reading session TTLs: %w", err)
+	}
+	if ttls[0].Val() == -2 {
+		return time.Time{}, apperror.NotFound("session %s has no live state to retain", sessionID)
+	}
+
+	pipe = rdb.Pipeline()
+	for i, k := range keys {
+		if cur := ttls[i].Val(); cur > 0 && cur < ttl {
+			pipe.Expire(ctx, k, ttl)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("extending session TTLs: %w", err)
+	}
+
+	state := ttls[0].Val()
+	if state < 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().UTC().Add(max(state, ttl)).Truncate(time.Second), nil
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// maxScheduleDelay is how far ahead a session may be scheduled.
+const maxScheduleDelay = 30 * 24 * time.Hour
+
+// scheduledKey is the sorted set of scheduled sessions, scored by the unix
+// time they are due.
+func (s *Service) scheduledKey() string {
+	return s.redis.Key("sessions", "scheduled")
+}
+
+// scheduleTime returns when a create request asks to run: schedule_at, or
+// delay_seconds from now. Zero means right away, as does a time already past.
+func scheduleTime(req *CreateSessionRequest, now time.Time) (time.Time, error) {
+	var at time.Time
+	switch {
+	case req.ScheduleAt != nil && req.DelaySeconds > 0:
+		appErr := apperror.Validation("set schedule_at or delay_seconds, not both")
+		appErr.Fields = map[string]string{"delay_seconds": "conflicts with schedule_at"}
+		return time.Time{}, appErr
+	case req.DelaySeconds < 0:
+		appErr := apperror.Validation("delay_seconds must not be negative")
+		appErr.Fields = map[string]string{"delay_seconds": "must not be negative"}
+		return time.Time{}, appErr
+	case req.ScheduleAt != nil:
+		at = req.ScheduleAt.UTC()
+	case req.DelaySeconds > 0:
+		at = now.Add(time.Duration(req.DelaySeconds) * time.Second)
+	}
+	if !at.After(now) {
+		return time.Time{}, nil
+	}
+	if at.Sub(now) > maxScheduleDelay {
+		field := "schedule_at"
+		if req.DelaySeconds > 0 {
+			field = "delay_seconds"
+		}
+		appErr := apperror.Validation("sessions can be scheduled at most %s ahead", maxScheduleDelay)
+		appErr.Fields = map[string]string{field: "too far in the future"}
+		return time.Time{}, appErr
+	}
+	return at, nil
+}
+
+// ScheduledDue returns up to limit scheduled sessions due at or before now,
+// earliest first.
+func (s *Service) ScheduledDue(ctx context.Context, now time.Time, limit int64) ([]string, error) {
+	ids, err := s.redis.Unwrap().ZRangeByScore(ctx, s.scheduledKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading scheduled sessions: %w", err)
+	}
+	return ids, nil
+}
+
+// PromoteScheduled moves a due scheduled session into its priority queue as
+// pending. An entry whose session is no longer scheduled (canceled, deleted,
+// expired) is dropped. Reports whether the session was enqueued; safe to run
+// on several nodes at once.
+func (s *Service) PromoteScheduled(ctx context.Context, sessionID string) (bool, error) {
+	rdb := s.redis.Unwrap()
+	stateKey := s.redis.Key("session", sessionID, "state")
+	now := time.Now().UTC()
+	promoted := false
+
+	err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+		status, err := tx.HGet(ctx, stateKey, "status").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("reading session status: %w", err)
+		}
+		if Status(status) != StatusScheduled {
+			return tx.ZRem(ctx, s.scheduledKey(), sessionID).Err()
+		}
+		queueKey, err := s.sessionQueueKey(ctx, tx, sessionID)
+		if err != nil {
+			return err
+		}
+		// MULTI/EXEC like Create: the status, the queue entry and the
+		// outbox entry change together.
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, stateKey, map[string]interface{}{
+				"status":     string(StatusPending),
+				"updated_at": now.Format(time.RFC3339Nano),
+			})
+			pipe.ZRem(ctx, s.scheduledKey(), sessionID)
+			pipe.RPush(ctx, queueKey, sessionID)
+			s.addOutbox(ctx, pipe, sessionID, now)
+			return nil
+		})
+		promoted = err == nil
+		return err
+	}, stateKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil // changed meanwhile (canceled, or another node promoted it)
+	}
+	if err != nil || !promoted {
+		return false, err
+	}
+
+	slog.Info("scheduled session enqueued", "session_id", sessionID)
+	s.persistToSQLite(sessionID, func() error {
+		return s.sqlite.UpdateStatus(ctx, sessionID, StatusPending, nil, nil)
+	})
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+func TestScheduleTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+	tests := []struct {
+		name    string
+		req     CreateSessionRequest
+		want    time.Time
+		wantErr string // field of the validation error
+	}{
+		{"not scheduled", CreateSessionRequest{}, time.Time{}, ""},
+		{"schedule_at", CreateSessionRequest{ScheduleAt: at(time.Hour)}, now.Add(time.Hour), ""},
+		{"delay_seconds", CreateSessionRequest{DelaySeconds: 90}, now.Add(90 * time.Second), ""},
+		{"schedule_at in the past runs now", CreateSessionRequest{ScheduleAt: at(-time.Minute)}, time.Time{}, ""},
+		{"both", CreateSessionRequest{ScheduleAt: at(time.Hour), DelaySeconds: 60}, time.Time{}, "delay_seconds"},
+		{"negative delay", CreateSessionRequest{DelaySeconds: -1}, time.Time{}, "delay_seconds"},
+		{"too far ahead", CreateSessionRequest{ScheduleAt: at(31 * 24 * time.Hour)}, time.Time{}, "schedule_at"},
+		{"delay too long", CreateSessionRequest{DelaySeconds: 31 * 24 * 3600}, time.Time{}, "delay_seconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scheduleTime(&tt.req, now)
+			if tt.wantErr != "" {
+				var appErr *apperror.AppError
+				if !errors.As(err, &appErr) || appErr.Status != 400 || appErr.Fields[tt.wantErr] == "" {
+					t.Fatalf("err = %v, want 400 on %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,14 +10,20 @@ import (
 	"strconv"
 	"time"
 
+	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/audit"
+	"github.com/freema/codeforge/internal/blobstore"
 	"github.com/freema/codeforge/internal/crypto"
+	"github.com/freema/codeforge/internal/policy"
 	"github.com/freema/codeforge/internal/redisclient"
 	"github.com/freema/codeforge/internal/review"
+	"github.com/freema/codeforge/internal/structured"
 	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/verify"
 )
 
 // Service manages session lifecycle and Redis persistence.
@@ -28,6 +34,32 @@ type Service struct {
 	queueName string
 	stateTTL  time.Duration
 	resultTTL time.Duration
+
+	maxIterations int           // server default for config.max_iterations (0 = unlimited)
+	transcriptTTL time.Duration // retention of full CLI transcripts (0 = no expiry)
+	maxRetainTTL  time.Duration // upper bound for Retain (0 = unbounded)
+	deleteGrace   time.Duration // how long a soft-deleted session stays restorable
+	awaitTTL      time.Duration // default hold of Await
+	maxAwaitTTL   time.Duration // upper bound for Await (0 = unbounded)
+
+	maxQueueDepth   int           // CheckQueue rejects new sessions at this depth (0 = unlimited)
+	queueRetryAfter time.Duration // Retry-After of queue_full rejections
+
+	blobs         blobstore.Store // optional offload target for large payloads
+	blobThreshold int             // payloads >= this many bytes are offloaded
+
+	repoPolicy     *policy.RepoPolicy     // optional repository allow/deny rules
+	repoURLPolicy  *policy.RepoURLPolicy  // repo_url scheme rules (nil = all known schemes)
+	callbackPolicy *policy.CallbackPolicy // optional callback_url destination guard
+	promptPolicy   policy.PromptChecker   // optional prompt moderation hook
+	auditLog       *audit.Store           // optional audit trail for policy decisions
+	projects       *ProjectStore          // optional per-repository defaults
+	prompts        *PromptStore           // optional uploaded prompts (prompt_ref)
+	defaults       Defaults               // server-wide config defaults
+
+	workspaceTemplates []string // names config.workspace_template may use
+
+	remoteMirror bool // runner agent: SQLite writes are forwarded to the server
 }
 
 // NewService creates a new session service.
@@ -45,10 +77,44 @@ func NewService(redis *redisclient.Client, cryptoSvc *crypto.Service, db *sql.DB
 	return svc
 }
 
+// SetMaxIterations sets the server-wide iteration cap enforced by Instruct.
+// Sessions may override it via config.max_iterations.
+func (s *Service) SetMaxIterations(n int) {
+	s.maxIterations = n
+}
+
+// SetRepoPolicy restricts which repositories new sessions may target.
+func (s *Service) SetRepoPolicy(p *policy.RepoPolicy) {
+	s.repoPolicy = p
+}
+
+// SetRepoURLPolicy sets which repo_url schemes new sessions may use.
+func (s *Service) SetRepoURLPolicy(p *policy.RepoURLPolicy) {
+	s.repoURLPolicy = p
+}
+
+// SetWorkspaceTemplates sets the workspace template names sessions may pick
+// via config.workspace_template.
+func (s *Service) SetWorkspaceTemplates(names []string) {
+	s.workspaceTemplates = names
+}
+
+// SetCallbackPolicy restricts which hosts callback_url may point at.
+func (s *Service) SetCallbackPolicy(p *policy.CallbackPolicy) {
+	s.callbackPolicy = p
+}
+
+// CallbackPolicy returns the callback guard, nil when unrestricted.
+func (s *Service) CallbackPolicy() *policy.CallbackPolicy {
+	return s.callbackPolicy
+}
+
 // persistToSQLite runs fn as a fire-and-forget SQLite write.
-// Errors are logged but never block the caller.
-func (s *Service) persistToSQLite(fn func() error) {
+// Errors are logged but never block the caller. Without a local database
+// (runner agents) the write is forwarded to the server instead.
+func (s *Service) persistToSQLite(sessionID string, fn func() error) {
 	if s.sqlite == nil {
+		s.forwardMirror(sessionID)
 		return
 	}
 	if err := fn(); err != nil {
@@ -58,6 +124,62 @@ func (s *Service) persistToSQLite(fn func() error) {
 
 // Create creates a new session in Redis and enqueues it for processing.
 func (s *Service) Create(ctx context.Context, req CreateSessionRequest) (*Session, error) {
+	// Enforced here so every entry point (API, webhooks, schedules, workflows) is covered.
+	// The normalized URL is what gets stored, so lookups by URL match every variant.
+	repoURL, err := s.repoURLPolicy.Normalize(req.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+	req.RepoURL = repoURL
+	if err := s.repoPolicy.Check(req.RepoURL); err != nil {
+		return nil, err
+	}
+
+	s.ApplyDefaults(ctx, &req)
+
+	if req.CallbackURL != "" {
+		if err := s.callbackPolicy.Check(ctx, req.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Config != nil && len(req.Config.ResultSchema) > 0 {
+		if err := structured.ValidateSchema(req.Config.ResultSchema); err != nil {
+			return nil, apperror.Validation("invalid result_schema: %v", err)
+		}
+	}
+	if req.Config != nil && req.Config.ForkURL != "" {
+		forkURL, err := s.checkForkURL(req.RepoURL, req.Config.ForkURL)
+		if err != nil {
+			return nil, err
+		}
+		req.Config.ForkURL = forkURL
+	}
+	if err := validateAnonymous(req); err != nil {
+		return nil, err
+	}
+	if err := validatePriority(req.Priority); err != nil {
+		return nil, err
+	}
+	if req.Priority == "" {
+		req.Priority = PriorityNormal
+	}
+	scheduleAt, err := scheduleTime(&req, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if req.Config != nil {
+		if err := validateWorkspaceTemplate(req.Config.WorkspaceTemplate, s.workspaceTemplates); err != nil {
+			return nil, err
+		}
+	}
+
+	prompt, err := s.ResolvePrompt(ctx, req.Prompt, req.PromptRef, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	req.Prompt = prompt
+
 	taskType := req.SessionType
 	if taskType == "" {
 		taskType = "code"
@@ -94,17 +216,34 @@ func (s *Service) Create(ctx context.Context, req CreateSessionRequest) (*Sessio
 		SessionType:   taskType,
 		CallbackURL:   req.CallbackURL,
 		Config:        req.Config,
+		Priority:      req.Priority,
 		WorkflowRunID: req.WorkflowRunID,
 		Metadata:      req.Metadata,
 		TenantID:      req.TenantID,
+		RequestID:     chimw.GetReqID(ctx),
 		Iteration:     1,
 		CreatedAt:     time.Now().UTC(),
 	}
 
+	if !scheduleAt.IsZero() {
+		t.Status = StatusScheduled
+		t.ScheduledAt = &scheduleAt
+	}
+
+	if err := s.checkPrompt(ctx, "create", t, t.Prompt); err != nil {
+		return nil, err
+	}
+
 	if req.Config != nil && req.Config.AIApiKey != "" {
 		t.Config.AIApiKey = req.Config.AIApiKey
 	}
 
+	attachments, payloads, err := s.prepareAttachments(ctx, t.ID, t.TenantID, req.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	t.Attachments = attachments
+
 	fields := s.sessionToHash(t)
 
 	// Encrypt sensitive fields
@@ -125,25 +264,36 @@ func (s *Service) Create(ctx context.Context, req CreateSessionRequest) (*Sessio
 
 	stateKey := s.redis.Key("session", t.ID, "state")
 
-	pipe := s.redis.Unwrap().Pipeline()
+	// MULTI/EXEC: the state, the queue entry and the outbox entry are
+	// written together or not at all.
+	pipe := s.redis.Unwrap().TxPipeline()
 	pipe.HSet(ctx, stateKey, fields)
-	pipe.RPush(ctx, s.redis.Key(s.queueName), t.ID)
+	if len(payloads) > 0 {
+		pipe.HSet(ctx, s.redis.Key("session", t.ID, "attachments"), payloads)
+	}
+	if t.ScheduledAt != nil {
+		pipe.ZAdd(ctx, s.scheduledKey(), redis.Z{Score: float64(t.ScheduledAt.Unix()), Member: t.ID})
+	} else {
+		pipe.RPush(ctx, s.queueKey(t.Priority), t.ID)
+		s.addOutbox(ctx, pipe, t.ID, t.CreatedAt)
+	}
 	pipe.SAdd(ctx, s.redis.Key("sessions:index"), t.ID) // track session ID for listing
+	if name := RepoFullName(t.RepoURL); name != "" {
+		pipe.ZAdd(ctx, s.repoIndexKey(name), redis.Z{Score: float64(t.CreatedAt.Unix()), Member: t.ID})
+	}
 	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("creating session in redis: %w", err)
 	}
 
-	slog.Info("session created", "session_id", t.ID, "repo_url", t.RepoURL)
+	slog.Info("session created", "session_id", t.ID, "repo_url", t.RepoURL, "status", t.Status, "request_id", t.RequestID)
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(t.ID, func() error {
 		return s.sqlite.Save(ctx, t)
 	})
 
 	return t, nil
 }
 
-// CountActiveByTenant returns the number of in-flight sessions owned by a tenant.
-// Returns 0 when SQLite is not configured.
 // ListStuck returns IDs of sessions that look actively processing but have
 // not been touched since `before` — candidates for the stuck sweeper.
 func (s *Service) ListStuck(ctx context.Context, before time.Time) ([]string, error) {
@@ -153,6 +303,8 @@ func (s *Service) ListStuck(ctx context.Context, before time.Time) ([]string, er
 	return s.sqlite.ListStuckSessions(ctx, before)
 }
 
+// CountActiveByTenant returns the number of in-flight sessions owned by a tenant.
+// Returns 0 when SQLite is not configured.
 func (s *Service) CountActiveByTenant(ctx context.Context, tenantID string) (int, error) {
 	if s.sqlite == nil {
 		return 0, nil
@@ -160,28 +312,120 @@ func (s *Service) CountActiveByTenant(ctx context.Context, tenantID string) (int
 	return s.sqlite.CountActiveByTenant(ctx, tenantID)
 }
 
-// Get retrieves a session from Redis by ID. Sensitive fields are decrypted in memory.
-func (s *Service) Get(ctx context.Context, sessionID string) (*Session, error) {
-	stateKey := s.redis.Key("session", sessionID, "state")
-	fields, err := s.redis.Unwrap().HGetAll(ctx, stateKey).Result()
+// GetOption tunes what Get loads alongside the session state.
+type GetOption func(*getOptions)
+
+type getOptions struct {
+	secrets        bool
+	iterations     bool
+	includeDeleted bool
+}
+
+// WithSecrets decrypts the access token and AI API key into the returned
+// session. Only code that talks to git providers or runs the CLI (executor,
+// PR service) should ask for them; read paths never pay for decryption.
+func WithSecrets() GetOption {
+	return func(o *getOptions) { o.secrets = true }
+}
+
+// WithIterations loads the iteration history in the same Redis round-trip as
+// the session state and result.
+func WithIterations() GetOption {
+	return func(o *getOptions) { o.iterations = true }
+}
+
+// IncludeDeleted returns soft-deleted sessions instead of a 410 error. Only
+// the delete/restore paths and ownership checks need it.
+func IncludeDeleted() GetOption {
+	return func(o *getOptions) { o.includeDeleted = true }
+}
+
+// Get retrieves a session from Redis by ID. Secrets stay encrypted unless
+// WithSecrets is passed.
+func (s *Service) Get(ctx context.Context, sessionID string, opts ...GetOption) (*Session, error) {
+	var o getOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	pipe := s.redis.Unwrap().Pipeline()
+	stateCmd := pipe.HGetAll(ctx, s.redis.Key("session", sessionID, "state"))
+	resultCmd := pipe.Get(ctx, s.redis.Key("session", sessionID, "result"))
+	queuedCmd := pipe.LRange(ctx, s.instructQueueKey(sessionID), 0, -1)
+	var iterCmd *redis.StringSliceCmd
+	if o.iterations {
+		iterCmd = pipe.LRange(ctx, s.redis.Key("session", sessionID, "iterations"), 0, -1)
+	}
+	_, _ = pipe.Exec(ctx) // per-command errors are checked below; a missing result is redis.Nil
+
+	fields, err := stateCmd.Result()
 	if err != nil {
 		return nil, fmt.Errorf("getting session from redis: %w", err)
 	}
 	if len(fields) == 0 {
 		// Fallback to SQLite for expired Redis keys
 		if s.sqlite != nil {
-			return s.sqlite.Get(ctx, sessionID)
+			t, err := s.sqlite.Get(ctx, sessionID)
+			if err != nil {
+				return nil, err
+			}
+			if t.DeletedAt != nil && !o.includeDeleted {
+				return nil, apperror.Gone("session %s was deleted", sessionID)
+			}
+			if o.iterations {
+				if t.Iterations, err = s.sqlite.GetIterations(ctx, sessionID); err != nil {
+					return nil, err
+				}
+			}
+			return t, nil
 		}
 		return nil, apperror.NotFound("session %s not found", sessionID)
 	}
 
 	t := s.hashToSession(fields)
+	if t.DeletedAt != nil && !o.includeDeleted {
+		return nil, apperror.Gone("session %s was deleted", sessionID)
+	}
+
+	if o.secrets {
+		s.decryptSecrets(t, fields)
+	}
+
+	// Load result if exists
+	if result, err := resultCmd.Bytes(); err == nil {
+		if resolved, err := s.resolveBlob(ctx, result); err != nil {
+			slog.Error("failed to load offloaded result", "session_id", sessionID, "error", err)
+		} else {
+			t.Result = string(resolved)
+		}
+	}
+
+	t.QueuedInstructions = decodeQueuedInstructions(queuedCmd.Val())
+
+	if iterCmd != nil {
+		items, err := iterCmd.Result()
+		if err != nil {
+			return nil, fmt.Errorf("loading iterations: %w", err)
+		}
+		if len(items) > 0 {
+			t.Iterations = decodeIterations(items)
+		} else if s.sqlite != nil {
+			if t.Iterations, err = s.sqlite.GetIterations(ctx, sessionID); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	// Decrypt sensitive fields
+	return t, nil
+}
+
+// decryptSecrets fills the session's access token and AI API key from their
+// encrypted hash fields.
+func (s *Service) decryptSecrets(t *Session, fields map[string]string) {
 	if enc := fields["encrypted_access_token"]; enc != "" {
 		token, err := s.crypto.Decrypt(enc)
 		if err != nil {
-			slog.Error("failed to decrypt access token", "session_id", sessionID, "error", err)
+			slog.Error("failed to decrypt access token", "session_id", t.ID, "error", err)
 		} else {
 			t.AccessToken = token
 		}
@@ -189,7 +433,7 @@ func (s *Service) Get(ctx context.Context, sessionID string) (*Session, error) {
 	if enc := fields["encrypted_ai_api_key"]; enc != "" {
 		key, err := s.crypto.Decrypt(enc)
 		if err != nil {
-			slog.Error("failed to decrypt ai api key", "session_id", sessionID, "error", err)
+			slog.Error("failed to decrypt ai api key", "session_id", t.ID, "error", err)
 		} else {
 			if t.Config == nil {
 				t.Config = &Config{}
@@ -197,22 +441,59 @@ func (s *Service) Get(ctx context.Context, sessionID string) (*Session, error) {
 			t.Config.AIApiKey = key
 		}
 	}
+}
 
-	// Load result if exists
-	resultKey := s.redis.Key("session", sessionID, "result")
-	result, err := s.redis.Unwrap().Get(ctx, resultKey).Result()
-	if err == nil {
-		t.Result = result
+// GetStatusBatch returns the status of every given session with one pipelined
+// HGET per session and no decryption, falling back to a single SQLite query for
+// sessions whose Redis state has expired. Unknown sessions are omitted.
+func (s *Service) GetStatusBatch(ctx context.Context, sessionIDs []string) (map[string]Status, error) {
+	out := make(map[string]Status, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return out, nil
 	}
 
-	return t, nil
+	pipe := s.redis.Unwrap().Pipeline()
+	cmds := make([]*redis.StringCmd, len(sessionIDs))
+	for i, id := range sessionIDs {
+		cmds[i] = pipe.HGet(ctx, s.redis.Key("session", id, "state"), "status")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("getting session statuses: %w", err)
+	}
+
+	var missing []string
+	for i, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil && v != "" {
+			out[sessionIDs[i]] = Status(v)
+		} else {
+			missing = append(missing, sessionIDs[i])
+		}
+	}
+
+	if len(missing) > 0 && s.sqlite != nil {
+		fallback, err := s.sqlite.GetStatuses(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for id, st := range fallback {
+			out[id] = st
+		}
+	}
+	return out, nil
 }
 
 // UpdateStatus transitions a session to a new status with state machine validation.
 func (s *Service) UpdateStatus(ctx context.Context, sessionID string, newStatus Status) error {
 	stateKey := s.redis.Key("session", sessionID, "state")
 
-	currentStatus, err := s.redis.Unwrap().HGet(ctx, stateKey, "status").Result()
+	// Status transitions must survive a short Redis blip: a lost terminal
+	// write would leave the session running forever.
+	var currentStatus string
+	err := redisclient.Retry(ctx, func() error {
+		var err error
+		currentStatus, err = s.redis.Unwrap().HGet(ctx, stateKey, "status").Result()
+		return err
+	})
 	if err == redis.Nil {
 		return apperror.NotFound("session %s not found", sessionID)
 	}
@@ -234,28 +515,29 @@ func (s *Service) UpdateStatus(ctx context.Context, sessionID string, newStatus
 	switch newStatus {
 	case StatusCloning, StatusRunning:
 		fields["started_at"] = now.Format(time.RFC3339Nano)
-	case StatusCompleted, StatusFailed, StatusPRCreated, StatusCanceled:
+	case StatusCompleted, StatusFailed, StatusPRCreated, StatusPRMerged, StatusCanceled:
 		fields["finished_at"] = now.Format(time.RFC3339Nano)
 	}
 
-	pipe := s.redis.Unwrap().Pipeline()
-	pipe.HSet(ctx, stateKey, fields)
-
 	// Set TTL only on truly terminal states (failed).
 	// Idle states (completed, pr_created) get a longer idle TTL
 	// that resets on each interaction.
-	if IsFinished(newStatus) {
-		pipe.Expire(ctx, stateKey, s.stateTTL)
-	} else if IsIdle(newStatus) {
-		// Idle sessions get 7x the normal TTL (e.g. 7 days if stateTTL=24h)
-		idleTTL := s.stateTTL * 7
-		if idleTTL < 24*time.Hour {
-			idleTTL = 7 * 24 * time.Hour // minimum 7 days
+	// Attachments live exactly as long as the state.
+	attachmentsKey := s.redis.Key("session", sessionID, "attachments")
+	err = redisclient.Retry(ctx, func() error {
+		pipe := s.redis.Unwrap().Pipeline()
+		pipe.HSet(ctx, stateKey, fields)
+		if IsFinished(newStatus) {
+			pipe.Expire(ctx, stateKey, s.stateTTL)
+			pipe.Expire(ctx, attachmentsKey, s.stateTTL)
+		} else if IsIdle(newStatus) {
+			pipe.Expire(ctx, stateKey, s.idleTTL())
+			pipe.Expire(ctx, attachmentsKey, s.idleTTL())
 		}
-		pipe.Expire(ctx, stateKey, idleTTL)
-	}
-
-	if _, err := pipe.Exec(ctx); err != nil {
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("updating session status: %w", err)
 	}
 
@@ -266,10 +548,10 @@ func (s *Service) UpdateStatus(ctx context.Context, sessionID string, newStatus
 	switch newStatus {
 	case StatusCloning, StatusRunning:
 		startedAt = &now
-	case StatusCompleted, StatusFailed, StatusPRCreated, StatusCanceled:
+	case StatusCompleted, StatusFailed, StatusPRCreated, StatusPRMerged, StatusCanceled:
 		finishedAt = &now
 	}
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(sessionID, func() error {
 		return s.sqlite.UpdateStatus(ctx, sessionID, newStatus, startedAt, finishedAt)
 	})
 
@@ -289,8 +571,13 @@ func (s *Service) SetResult(ctx context.Context, sessionID string, result string
 		fields["usage"] = MarshalUsageInfo(usage)
 	}
 
+	stored, err := s.offload(ctx, blobKey(sessionID, "result"), []byte(result))
+	if err != nil {
+		return err
+	}
+
 	pipe := s.redis.Unwrap().Pipeline()
-	pipe.Set(ctx, resultKey, result, s.resultTTL)
+	pipe.Set(ctx, resultKey, stored, s.resultTTL)
 	if len(fields) > 0 {
 		pipe.HSet(ctx, stateKey, fields)
 	}
@@ -298,25 +585,87 @@ func (s *Service) SetResult(ctx context.Context, sessionID string, result string
 		return fmt.Errorf("setting session result: %w", err)
 	}
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(sessionID, func() error {
 		return s.sqlite.UpdateResult(ctx, sessionID, result, changes, usage)
 	})
 
 	return nil
 }
 
-// Instruct submits a follow-up instruction for an existing session.
+// AddCost adds the reported spend of a CLI run to the session's cost_usd.
+func (s *Service) AddCost(ctx context.Context, sessionID string, usd float64) error {
+	if usd <= 0 {
+		return nil
+	}
+	stateKey := s.redis.Key("session", sessionID, "state")
+	if err := s.redis.Unwrap().HIncrByFloat(ctx, stateKey, "cost_usd", usd).Err(); err != nil {
+		return fmt.Errorf("adding session cost: %w", err)
+	}
+	s.persistToSQLite(sessionID, func() error {
+		return s.sqlite.AddCost(ctx, sessionID, usd)
+	})
+	return nil
+}
+
+// SetStructuredResult stores the JSON block extracted from the latest result
+// for config.result_schema, or the reason there is none (errMsg).
+func (s *Service) SetStructuredResult(ctx context.Context, sessionID string, data json.RawMessage, errMsg string) error {
+	stateKey := s.redis.Key("session", sessionID, "state")
+	if err := s.redis.Unwrap().HSet(ctx, stateKey, map[string]interface{}{
+		"result_structured":       string(data),
+		"result_structured_error": errMsg,
+	}).Err(); err != nil {
+		return fmt.Errorf("setting structured result: %w", err)
+	}
+	return nil
+}
+
+// Instruct submits a follow-up instruction for an existing session. While
+// another instruction (or the first run) is in flight the follow-up is queued
+// and Session.QueuePosition is set.
 func (s *Service) Instruct(ctx context.Context, sessionID string, prompt string) (*Session, error) {
+	return s.instruct(ctx, sessionID, prompt, instructOptions{})
+}
+
+// InstructWithConfig is Instruct with config overrides for the new iteration.
+func (s *Service) InstructWithConfig(ctx context.Context, sessionID, prompt string, cfg *IterationConfig) (*Session, error) {
+	return s.instruct(ctx, sessionID, prompt, instructOptions{overrides: cfg})
+}
+
+// InstructVerifyFix queues an automatic follow-up after failed verification;
+// attempt is its position in the current run of automatic fixes.
+func (s *Service) InstructVerifyFix(ctx context.Context, sessionID, prompt string, attempt int) (*Session, error) {
+	return s.instruct(ctx, sessionID, prompt, instructOptions{verifyAttempts: attempt})
+}
+
+// instructOptions tune a follow-up beyond its prompt.
+type instructOptions struct {
+	overrides      *IterationConfig
+	verifyAttempts int  // > 0 for automatic verification fixes
+	lockHeld       bool // the caller holds the instruct lock (draining the queue)
+}
+
+func (s *Service) instruct(ctx context.Context, sessionID, prompt string, o instructOptions) (*Session, error) {
 	t, err := s.Get(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Automatic fixes continue the run that planned them and take over its
+	// instruct lock; everyone else has to acquire it or queue.
+	owner := o.lockHeld || o.verifyAttempts > 0
+
 	// Validate state allows instruction
+	busy := false
 	switch t.Status {
 	case StatusCompleted, StatusAwaitingInstruction, StatusPRCreated:
 		// ok
-	case StatusRunning, StatusCloning, StatusCreatingPR:
+	case StatusPending, StatusCloning, StatusRunning, StatusReviewing:
+		if owner {
+			return nil, apperror.Conflict("session is currently %s, cannot instruct", t.Status)
+		}
+		busy = true
+	case StatusCreatingPR:
 		return nil, apperror.Conflict("session is currently %s, cannot instruct", t.Status)
 	case StatusFailed:
 		return nil, apperror.Validation("session has failed, create a new session instead")
@@ -324,6 +673,42 @@ func (s *Service) Instruct(ctx context.Context, sessionID string, prompt string)
 		return nil, apperror.Conflict("session in status %s cannot accept instructions", t.Status)
 	}
 
+	// Queued follow-ups count towards the iteration budget.
+	budget := *t
+	budget.Iteration += len(t.QueuedInstructions)
+	if err := CheckIterationLimit(&budget, s.maxIterations); err != nil {
+		return nil, err
+	}
+	if err := CheckBudget(t); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPrompt(ctx, "instruct", t, prompt); err != nil {
+		return nil, err
+	}
+
+	if busy {
+		return s.queueInstruction(ctx, t, prompt, o.overrides)
+	}
+	lockKey := s.instructLockKey(sessionID)
+	if !owner {
+		acquired, err := s.redis.Unwrap().SetNX(ctx, lockKey, instructLockDraining, instructLockTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("acquiring instruct lock: %w", err)
+		}
+		if !acquired {
+			return s.queueInstruction(ctx, t, prompt, o.overrides)
+		}
+	}
+	// A lock taken here is handed back on every error below; only the
+	// enqueued iteration keeps it.
+	release := !owner
+	defer func() {
+		if release {
+			s.redis.Unwrap().Del(context.WithoutCancel(ctx), lockKey)
+		}
+	}()
+
 	// Transition through AWAITING_INSTRUCTION if needed
 	if t.Status == StatusCompleted || t.Status == StatusPRCreated {
 		if err := ValidateTransition(t.Status, StatusAwaitingInstruction); err != nil {
@@ -335,41 +720,89 @@ func (s *Service) Instruct(ctx context.Context, sessionID string, prompt string)
 	newIteration := t.Iteration + 1
 
 	stateKey := s.redis.Key("session", sessionID, "state")
-	pipe := s.redis.Unwrap().Pipeline()
+	pipe := s.redis.Unwrap().TxPipeline()
 
 	// Update session state
-	pipe.HSet(ctx, stateKey, map[string]interface{}{
-		"status":         string(StatusAwaitingInstruction),
-		"current_prompt": prompt,
-		"iteration":      newIteration,
-		"updated_at":     now.Format(time.RFC3339Nano),
-		"error":          "", // clear previous error
-	})
+	update := map[string]interface{}{
+		"status":          string(StatusAwaitingInstruction),
+		"current_prompt":  prompt,
+		"iteration":       newIteration,
+		"verify_attempts": o.verifyAttempts,
+		"updated_at":      now.Format(time.RFC3339Nano),
+		"error":           "", // clear previous error
+	}
+	if reqID := chimw.GetReqID(ctx); reqID != "" {
+		update["request_id"] = reqID
+		t.RequestID = reqID
+	}
+	if t.CostUSD > 0 {
+		// Carries the spend over when t was read back from SQLite after
+		// the Redis state expired; otherwise the value is unchanged.
+		update["cost_usd"] = t.CostUSD
+	}
+	if t.Priority != "" {
+		// Same for the priority, which later requeues read from the hash.
+		update["priority"] = string(t.Priority)
+	}
+	overrides := o.overrides
+	if overrides.IsZero() {
+		overrides = nil
+		pipe.HDel(ctx, stateKey, "iteration_config")
+	} else {
+		update["iteration_config"] = marshalIterationConfig(overrides)
+	}
+	pipe.HSet(ctx, stateKey, update)
+	pipe.HDel(ctx, stateKey, "await_expires_at")
+	if o.verifyAttempts == 0 {
+		// A user instruction is a deliberate retry, not part of a loop.
+		pipe.HDel(ctx, stateKey, "failure_class", "failure_streak")
+	}
 
 	// Remove TTL (session is active again)
 	pipe.Persist(ctx, stateKey)
+	pipe.Persist(ctx, s.redis.Key("session", sessionID, "attachments"))
 
-	// Re-enqueue for worker processing
-	pipe.RPush(ctx, s.redis.Key(s.queueName), sessionID)
+	// Re-enqueue for worker processing; the lock now belongs to this iteration
+	// until the worker finishes it.
+	pipe.RPush(ctx, s.queueKey(t.Priority), sessionID)
+	s.addOutbox(ctx, pipe, sessionID, now)
+	pipe.Set(ctx, lockKey, strconv.Itoa(newIteration), instructLockTTL)
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("instructing session: %w", err)
 	}
+	release = false
 
 	t.Status = StatusAwaitingInstruction
 	t.CurrentPrompt = prompt
 	t.Iteration = newIteration
 	t.Error = ""
+	t.VerifyAttempts = o.verifyAttempts
+	if o.verifyAttempts == 0 {
+		t.FailureClass, t.FailureStreak = "", 0
+	}
+	t.AwaitExpiresAt = nil
+	t.IterationConfig = overrides
 
-	slog.Info("session instructed", "session_id", sessionID, "iteration", newIteration)
+	slog.Info("session instructed", "session_id", sessionID, "iteration", newIteration, "request_id", t.RequestID)
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(t.ID, func() error {
 		return s.sqlite.Save(ctx, t)
 	})
 
 	return t, nil
 }
 
+// idleTTL is how long idle sessions (completed, pr_created) are kept: 7x the
+// normal TTL (e.g. 7 days if stateTTL=24h), at least 7 days.
+func (s *Service) idleTTL() time.Duration {
+	ttl := s.stateTTL * 7
+	if ttl < 24*time.Hour {
+		ttl = 7 * 24 * time.Hour
+	}
+	return ttl
+}
+
 // SaveIteration appends a completed iteration record to the session's iteration history.
 func (s *Service) SaveIteration(ctx context.Context, sessionID string, iter Iteration) error {
 	iterKey := s.redis.Key("session", sessionID, "iterations")
@@ -382,13 +815,74 @@ func (s *Service) SaveIteration(ctx context.Context, sessionID string, iter Iter
 		return err
 	}
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(sessionID, func() error {
 		return s.sqlite.SaveIteration(ctx, sessionID, iter)
 	})
 
 	return nil
 }
 
+// RecordIteration appends an iteration that ran outside the worker (e.g. a
+// revert) to an idle session. The session must still be idle at iteration
+// expectIteration; the counter bump happens under WATCH so a concurrent
+// instruct cannot claim the same number.
+func (s *Service) RecordIteration(ctx context.Context, sessionID string, expectIteration int, iter Iteration) error {
+	stateKey := s.redis.Key("session", sessionID, "state")
+	now := time.Now().UTC()
+
+	err := s.redis.Unwrap().Watch(ctx, func(tx *redis.Tx) error {
+		vals, err := tx.HMGet(ctx, stateKey, "status", "iteration").Result()
+		if err != nil {
+			return fmt.Errorf("reading session state: %w", err)
+		}
+		if vals[0] == nil {
+			return apperror.NotFound("session %s not found", sessionID)
+		}
+		status := Status(fmt.Sprint(vals[0]))
+		if !IsIdle(status) {
+			return apperror.Conflict("session is %s, cannot record iteration", status)
+		}
+		if fmt.Sprint(vals[1]) != strconv.Itoa(expectIteration) {
+			return apperror.Conflict("session iteration changed concurrently, retry the request")
+		}
+
+		fields := map[string]interface{}{
+			"iteration":      iter.Number,
+			"current_prompt": iter.Prompt,
+			"updated_at":     now.Format(time.RFC3339Nano),
+		}
+		if iter.Changes != nil {
+			fields["changes_summary"] = MarshalChangesSummary(iter.Changes)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, stateKey, fields)
+			return nil
+		})
+		return err
+	}, stateKey)
+
+	if err != nil {
+		if errors.Is(err, redis.TxFailedErr) {
+			return apperror.Conflict("session state changed concurrently, retry the request")
+		}
+		return err
+	}
+
+	if err := s.SaveIteration(ctx, sessionID, iter); err != nil {
+		return err
+	}
+
+	s.persistToSQLite(sessionID, func() error {
+		t, err := s.Get(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		return s.sqlite.Save(ctx, t)
+	})
+
+	return nil
+}
+
 // GetIterations loads the full iteration history from Redis, falling back to SQLite.
 func (s *Service) GetIterations(ctx context.Context, sessionID string) ([]Iteration, error) {
 	iterKey := s.redis.Key("session", sessionID, "iterations")
@@ -400,16 +894,45 @@ func (s *Service) GetIterations(ctx context.Context, sessionID string) ([]Iterat
 	if len(items) == 0 && s.sqlite != nil {
 		return s.sqlite.GetIterations(ctx, sessionID)
 	}
+	return decodeIterations(items), nil
+}
+
+// GetIterationPage returns up to limit iteration records of a session
+// starting at offset, oldest first, and how many there are in total. Like
+// GetIterations it falls back to SQLite once the Redis list is gone.
+func (s *Service) GetIterationPage(ctx context.Context, sessionID string, limit, offset int) ([]Iteration, int, error) {
+	iterKey := s.redis.Key("session", sessionID, "iterations")
+	total, err := s.redis.Unwrap().LLen(ctx, iterKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting iterations: %w", err)
+	}
+	if total == 0 && s.sqlite != nil {
+		return s.sqlite.GetIterationPage(ctx, sessionID, limit, offset)
+	}
+	if int64(offset) >= total {
+		return []Iteration{}, int(total), nil
+	}
+
+	items, err := s.redis.Unwrap().LRange(ctx, iterKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("loading iterations: %w", err)
+	}
+	return decodeIterations(items), int(total), nil
+}
 
+// decodeIterations parses iteration records stored as JSON list items,
+// skipping (and logging) malformed entries.
+func decodeIterations(items []string) []Iteration {
 	iterations := make([]Iteration, 0, len(items))
 	for _, item := range items {
 		var iter Iteration
 		if err := json.Unmarshal([]byte(item), &iter); err != nil {
+			slog.Warn("skipping malformed iteration record", "error", err)
 			continue
 		}
 		iterations = append(iterations, iter)
 	}
-	return iterations, nil
+	return iterations
 }
 
 // Summary is a lightweight view of a session for listing.
@@ -491,26 +1014,14 @@ func (s *Service) List(ctx context.Context, opts ListOptions) ([]Summary, int, e
 		}
 
 		t := s.hashToSession(fields)
+		if t.DeletedAt != nil {
+			continue
+		}
 		if opts.Status != "" && string(t.Status) != opts.Status {
 			continue
 		}
 
-		sessions = append(sessions, Summary{
-			ID:             t.ID,
-			Status:         t.Status,
-			RepoURL:        t.RepoURL,
-			Prompt:         truncatePrompt(t.Prompt, 200),
-			SessionType:    t.SessionType,
-			Iteration:      t.Iteration,
-			Error:          t.Error,
-			Branch:         t.Branch,
-			PRURL:          t.PRURL,
-			WorkflowRunID:  t.WorkflowRunID,
-			ChangesSummary: t.ChangesSummary,
-			CreatedAt:      t.CreatedAt,
-			StartedAt:      t.StartedAt,
-			FinishedAt:     t.FinishedAt,
-		})
+		sessions = append(sessions, toSummary(t))
 	}
 
 	sortByCreatedDesc(sessions)
@@ -527,6 +1038,25 @@ func (s *Service) List(ctx context.Context, opts ListOptions) ([]Summary, int, e
 	return sessions, total, nil
 }
 
+func toSummary(t *Session) Summary {
+	return Summary{
+		ID:             t.ID,
+		Status:         t.Status,
+		RepoURL:        t.RepoURL,
+		Prompt:         truncatePrompt(t.Prompt, 200),
+		SessionType:    t.SessionType,
+		Iteration:      t.Iteration,
+		Error:          t.Error,
+		Branch:         t.Branch,
+		PRURL:          t.PRURL,
+		WorkflowRunID:  t.WorkflowRunID,
+		ChangesSummary: t.ChangesSummary,
+		CreatedAt:      t.CreatedAt,
+		StartedAt:      t.StartedAt,
+		FinishedAt:     t.FinishedAt,
+	}
+}
+
 func truncatePrompt(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -565,7 +1095,6 @@ func sortByCreatedDesc(sessions []Summary) {
 // Uses Redis WATCH for atomic check-and-set to prevent double-enqueue races.
 func (s *Service) StartReviewAsync(ctx context.Context, sessionID, cli, model string) (*Session, error) {
 	stateKey := s.redis.Key("session", sessionID, "state")
-	queueKey := s.redis.Key(s.queueName)
 	now := time.Now().UTC()
 
 	err := s.redis.Unwrap().Watch(ctx, func(tx *redis.Tx) error {
@@ -587,6 +1116,10 @@ func (s *Service) StartReviewAsync(ctx context.Context, sessionID, cli, model st
 		default:
 			return apperror.Conflict("session in status %s cannot be reviewed", Status(current))
 		}
+		queueKey, err := s.sessionQueueKey(ctx, tx, sessionID)
+		if err != nil {
+			return err
+		}
 
 		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
 			pipe.HSet(ctx, stateKey, map[string]interface{}{
@@ -598,6 +1131,7 @@ func (s *Service) StartReviewAsync(ctx context.Context, sessionID, cli, model st
 			})
 			pipe.Persist(ctx, stateKey)
 			pipe.RPush(ctx, queueKey, sessionID)
+			s.addOutbox(ctx, pipe, sessionID, now)
 			return nil
 		})
 		return err
@@ -619,13 +1153,68 @@ func (s *Service) StartReviewAsync(ctx context.Context, sessionID, cli, model st
 
 	slog.Info("review enqueued", "session_id", sessionID)
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(sessionID, func() error {
 		return s.sqlite.UpdateStatus(ctx, sessionID, StatusReviewing, nil, nil)
 	})
 
 	return t, nil
 }
 
+// CancelPending cancels a session that is still waiting in the queue, or
+// scheduled for later. The queue (or schedule) entry is removed and the
+// session moves to canceled in the same WATCH transaction, so a worker can
+// never dequeue it afterwards. The entry is removed from every priority
+// queue; a session sits in one only.
+func (s *Service) CancelPending(ctx context.Context, sessionID string) error {
+	stateKey := s.redis.Key("session", sessionID, "state")
+	now := time.Now().UTC()
+
+	err := s.redis.Unwrap().Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.HGet(ctx, stateKey, "status").Result()
+		if err == redis.Nil {
+			return apperror.NotFound("session %s not found", sessionID)
+		}
+		if err != nil {
+			return fmt.Errorf("reading session status: %w", err)
+		}
+		if Status(current) != StatusPending && Status(current) != StatusScheduled {
+			return apperror.Conflict("session is %s, only pending or scheduled sessions can be dequeued", Status(current))
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, queueKey := range s.queueKeys() {
+				pipe.LRem(ctx, queueKey, 0, sessionID)
+			}
+			pipe.ZRem(ctx, s.scheduledKey(), sessionID)
+			pipe.ZRem(ctx, s.outboxKey(), sessionID)
+			pipe.HSet(ctx, stateKey, map[string]interface{}{
+				"status":      string(StatusCanceled),
+				"updated_at":  now.Format(time.RFC3339Nano),
+				"finished_at": now.Format(time.RFC3339Nano),
+			})
+			pipe.Expire(ctx, stateKey, s.stateTTL)
+			pipe.Expire(ctx, s.redis.Key("session", sessionID, "attachments"), s.stateTTL)
+			return nil
+		})
+		return err
+	}, stateKey)
+
+	if err != nil {
+		if errors.Is(err, redis.TxFailedErr) {
+			return apperror.Conflict("session state changed concurrently, retry the request")
+		}
+		return err
+	}
+
+	slog.Info("pending session canceled", "session_id", sessionID)
+
+	s.persistToSQLite(sessionID, func() error {
+		return s.sqlite.UpdateStatus(ctx, sessionID, StatusCanceled, nil, &now)
+	})
+
+	return nil
+}
+
 // CompleteReview stores the review result and transitions the session back to completed.
 func (s *Service) CompleteReview(ctx context.Context, sessionID string, result *review.ReviewResult) error {
 	if err := s.SetReviewResult(ctx, sessionID, result); err != nil {
@@ -641,13 +1230,26 @@ func (s *Service) SetReviewResult(ctx context.Context, sessionID string, result
 		return fmt.Errorf("setting review result: %w", err)
 	}
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(sessionID, func() error {
 		return s.sqlite.UpdateReviewResult(ctx, sessionID, result)
 	})
 
 	return nil
 }
 
+// SetVerification stores the verification outcome of the latest iteration.
+func (s *Service) SetVerification(ctx context.Context, sessionID string, result *verify.Result) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshaling verification: %w", err)
+	}
+	stateKey := s.redis.Key("session", sessionID, "state")
+	if err := s.redis.Unwrap().HSet(ctx, stateKey, "verification", string(b)).Err(); err != nil {
+		return fmt.Errorf("setting verification: %w", err)
+	}
+	return nil
+}
+
 // UpdateConfig persists updated Config to Redis.
 func (s *Service) UpdateConfig(ctx context.Context, sessionID string, cfg *Config) error {
 	if cfg == nil {
@@ -667,7 +1269,7 @@ func (s *Service) SetError(ctx context.Context, sessionID string, errMsg string)
 		return err
 	}
 
-	s.persistToSQLite(func() error {
+	s.persistToSQLite(sessionID, func() error {
 		return s.sqlite.UpdateError(ctx, sessionID, errMsg)
 	})
 
@@ -696,6 +1298,12 @@ func (s *Service) sessionToHash(t *Session) map[string]interface{} {
 	if t.Config != nil {
 		fields["config"] = MarshalConfig(t.Config)
 	}
+	if t.Priority != "" {
+		fields["priority"] = string(t.Priority)
+	}
+	if t.ScheduledAt != nil {
+		fields["scheduled_at"] = t.ScheduledAt.Format(time.RFC3339Nano)
+	}
 	if t.WorkflowRunID != "" {
 		fields["workflow_run_id"] = t.WorkflowRunID
 	}
@@ -705,6 +1313,9 @@ func (s *Service) sessionToHash(t *Session) map[string]interface{} {
 	if t.TraceID != "" {
 		fields["trace_id"] = t.TraceID
 	}
+	if t.RequestID != "" {
+		fields["request_id"] = t.RequestID
+	}
 	if t.ReviewCLI != "" {
 		fields["review_cli"] = t.ReviewCLI
 	}
@@ -715,6 +1326,10 @@ func (s *Service) sessionToHash(t *Session) map[string]interface{} {
 		b, _ := json.Marshal(t.Metadata)
 		fields["metadata"] = string(b)
 	}
+	if len(t.Attachments) > 0 {
+		b, _ := json.Marshal(t.Attachments)
+		fields["attachments"] = string(b)
+	}
 
 	return fields
 }
@@ -732,10 +1347,13 @@ func (s *Service) hashToSession(fields map[string]string) *Session {
 		CurrentPrompt: fields["current_prompt"],
 		Branch:        fields["branch"],
 		PRURL:         fields["pr_url"],
+		AutoMerge:     fields["auto_merge"],
+		Priority:      Priority(fields["priority"]),
 		Error:         fields["error"],
 		WorkflowRunID: fields["workflow_run_id"],
 		TenantID:      fields["tenant_id"],
 		TraceID:       fields["trace_id"],
+		RequestID:     fields["request_id"],
 	}
 
 	if v := fields["iteration"]; v != "" {
@@ -748,6 +1366,14 @@ func (s *Service) hashToSession(fields map[string]string) *Session {
 	if v := fields["created_at"]; v != "" {
 		t.CreatedAt, _ = time.Parse(time.RFC3339Nano, v)
 	}
+	if v := fields["scheduled_at"]; v != "" {
+		ts, _ := time.Parse(time.RFC3339Nano, v)
+		t.ScheduledAt = &ts
+	}
+	if v := fields["enqueued_at"]; v != "" {
+		ts, _ := time.Parse(time.RFC3339Nano, v)
+		t.EnqueuedAt = &ts
+	}
 	if v := fields["started_at"]; v != "" {
 		ts, _ := time.Parse(time.RFC3339Nano, v)
 		t.StartedAt = &ts
@@ -756,8 +1382,17 @@ func (s *Service) hashToSession(fields map[string]string) *Session {
 		ts, _ := time.Parse(time.RFC3339Nano, v)
 		t.FinishedAt = &ts
 	}
+	if v := fields["deleted_at"]; v != "" {
+		ts, _ := time.Parse(time.RFC3339Nano, v)
+		t.DeletedAt = &ts
+	}
+	if v := fields["await_expires_at"]; v != "" {
+		ts, _ := time.Parse(time.RFC3339Nano, v)
+		t.AwaitExpiresAt = &ts
+	}
 
 	t.Config = UnmarshalConfig(fields["config"])
+	t.IterationConfig = unmarshalIterationConfig(fields["iteration_config"])
 	t.ChangesSummary = UnmarshalChangesSummary(fields["changes_summary"])
 	t.Usage = UnmarshalUsageInfo(fields["usage"])
 	t.ReviewResult = review.UnmarshalReviewResult(fields["review_result"])
@@ -767,6 +1402,27 @@ func (s *Service) hashToSession(fields map[string]string) *Session {
 	if v := fields["metadata"]; v != "" {
 		_ = json.Unmarshal([]byte(v), &t.Metadata)
 	}
+	if v := fields["attachments"]; v != "" {
+		_ = json.Unmarshal([]byte(v), &t.Attachments)
+	}
+	if v := fields["result_structured"]; v != "" {
+		t.ResultStructured = json.RawMessage(v)
+	}
+	t.ResultStructuredError = fields["result_structured_error"]
+	if v := fields["verification"]; v != "" {
+		_ = json.Unmarshal([]byte(v), &t.Verification)
+	}
+	if v := fields["verify_attempts"]; v != "" {
+		t.VerifyAttempts, _ = strconv.Atoi(v)
+	}
+	t.FailureClass = fields["failure_class"]
+	if v := fields["failure_streak"]; v != "" {
+		t.FailureStreak, _ = strconv.Atoi(v)
+	}
+	if v := fields["cost_usd"]; v != "" {
+		t.CostUSD, _ = strconv.ParseFloat(v, 64)
+	}
+	t.Timings = parseTimings(fields)
 
 	return t
 }
@@ -777,18 +1433,36 @@ type CreateSessionRequest struct {
 	ProviderKey   string            `json:"provider_key,omitempty"`
 	AccessToken   string            `json:"access_token,omitempty"`
 	Prompt        string            `json:"prompt" validate:"max=102400"`
+	PromptRef     string            `json:"prompt_ref,omitempty"` // ID of an uploaded prompt (POST /api/v1/prompts), instead of prompt
 	SessionType   string            `json:"session_type,omitempty"`
 	CallbackURL   string            `json:"callback_url,omitempty" validate:"omitempty,url"`
 	Config        *Config           `json:"config,omitempty"`
+	Priority      Priority          `json:"priority,omitempty"`      // high, normal (default) or low
+	ScheduleAt    *time.Time        `json:"schedule_at,omitempty"`   // queue the session at this time instead of now
+	DelaySeconds  int               `json:"delay_seconds,omitempty"` // or this many seconds from now
 	WorkflowRunID string            `json:"workflow_run_id,omitempty"`
 	Metadata      map[string]string `json:"metadata,omitempty"`
+	Attachments   []Attachment      `json:"attachments,omitempty" validate:"omitempty,max=20,dive"`
 	// TenantID is set server-side (never decoded from client JSON) by the session
 	// handler when the request is authenticated as a subscription tenant.
 	TenantID string `json:"-"`
 }
 
+// InstructRequest is the body of a follow-up instruction.
+type InstructRequest struct {
+	Prompt    string `json:"prompt" validate:"required_without=PromptRef,max=102400"`
+	PromptRef string `json:"prompt_ref,omitempty"` // ID of an uploaded prompt, instead of prompt
+	// Config overrides model, max_turns, max_budget_usd and timeout_seconds
+	// for this iteration only.
+	Config *IterationConfig `json:"config,omitempty"`
+}
+
 // FindByPR finds the most recent active session for a given repo + PR/MR number.
 func (s *Service) FindByPR(ctx context.Context, repoURL string, prNumber int) (*Session, error) {
+	// Sessions store the normalized URL; webhook payloads may not be.
+	if normalized, err := s.repoURLPolicy.Normalize(repoURL); err == nil {
+		repoURL = normalized
+	}
 	// Try SQLite first (indexed, fast)
 	if s.sqlite != nil {
 		t, err := s.sqlite.FindByPR(ctx, repoURL, prNumber)
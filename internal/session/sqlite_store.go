@@ -32,21 +32,22 @@ func (s *SQLiteStore) Save(ctx context.Context, t *Session) error {
 	usageJSON := marshalJSON(t.Usage)
 
 	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO sessions (id, status, repo_url, provider_key, prompt, session_type, callback_url, config_json,
+		`INSERT INTO sessions (id, status, repo_url, repo_name, provider_key, prompt, session_type, callback_url, config_json,
 			result, error, changes_json, usage_json,
 			iteration, current_prompt,
 			branch, pr_number, pr_url,
-			workflow_run_id, trace_id, tenant_id,
+			workflow_run_id, trace_id, tenant_id, request_id, cost_usd, priority,
 			created_at, started_at, finished_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?,
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?,
 			?, ?, ?, ?,
 			?, ?,
 			?, ?, ?,
-			?, ?, ?,
+			?, ?, ?, ?, ?, ?,
 			?, ?, ?, ?)
 		 ON CONFLICT(id) DO UPDATE SET
 			status = excluded.status,
 			repo_url = excluded.repo_url,
+			repo_name = excluded.repo_name,
 			provider_key = excluded.provider_key,
 			prompt = excluded.prompt,
 			session_type = excluded.session_type,
@@ -63,14 +64,17 @@ func (s *SQLiteStore) Save(ctx context.Context, t *Session) error {
 			pr_url = excluded.pr_url,
 			workflow_run_id = excluded.workflow_run_id,
 			trace_id = excluded.trace_id,
+			request_id = excluded.request_id,
+			cost_usd = MAX(cost_usd, excluded.cost_usd),
+			priority = excluded.priority,
 			started_at = excluded.started_at,
 			finished_at = excluded.finished_at,
 			updated_at = excluded.updated_at`,
-		t.ID, string(t.Status), t.RepoURL, t.ProviderKey, t.Prompt, t.SessionType, t.CallbackURL, configJSON,
+		t.ID, string(t.Status), t.RepoURL, RepoFullName(t.RepoURL), t.ProviderKey, t.Prompt, t.SessionType, t.CallbackURL, configJSON,
 		t.Result, t.Error, changesJSON, usageJSON,
 		t.Iteration, t.CurrentPrompt,
 		t.Branch, t.PRNumber, t.PRURL,
-		t.WorkflowRunID, t.TraceID, t.TenantID,
+		t.WorkflowRunID, t.TraceID, t.TenantID, t.RequestID, t.CostUSD, string(t.Priority),
 		t.CreatedAt.Format(time.RFC3339Nano), nullableTime(t.StartedAt), nullableTime(t.FinishedAt), now,
 	)
 	if err != nil {
@@ -79,6 +83,18 @@ func (s *SQLiteStore) Save(ctx context.Context, t *Session) error {
 	return nil
 }
 
+// AddCost adds the spend of a CLI run to the session's cost_usd.
+func (s *SQLiteStore) AddCost(ctx context.Context, sessionID string, usd float64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET cost_usd = cost_usd + ?, updated_at = ? WHERE id = ?`,
+		usd, time.Now().UTC().Format(time.RFC3339Nano), sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("adding session cost in sqlite: %w", err)
+	}
+	return nil
+}
+
 // UpdateStatus updates status and related timestamps in SQLite.
 func (s *SQLiteStore) UpdateStatus(ctx context.Context, sessionID string, status Status, startedAt, finishedAt *time.Time) error {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
@@ -156,6 +172,10 @@ func (s *SQLiteStore) UpdateError(ctx context.Context, sessionID string, errMsg
 func (s *SQLiteStore) SaveIteration(ctx context.Context, sessionID string, iter Iteration) error {
 	changesJSON := marshalJSON(iter.Changes)
 	usageJSON := marshalJSON(iter.Usage)
+	summaryJSON := ""
+	if iter.Summary != nil {
+		summaryJSON = marshalJSON(iter.Summary)
+	}
 	var endedAt *string
 	if iter.EndedAt != nil {
 		s := iter.EndedAt.Format(time.RFC3339Nano)
@@ -163,8 +183,8 @@ func (s *SQLiteStore) SaveIteration(ctx context.Context, sessionID string, iter
 	}
 
 	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO session_iterations (session_id, number, prompt, result, error, status, changes_json, usage_json, started_at, ended_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`INSERT INTO session_iterations (session_id, number, prompt, result, error, status, changes_json, usage_json, summary_json, started_at, ended_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		 ON CONFLICT(session_id, number) DO UPDATE SET
 			prompt = excluded.prompt,
 			result = excluded.result,
@@ -172,10 +192,11 @@ func (s *SQLiteStore) SaveIteration(ctx context.Context, sessionID string, iter
 			status = excluded.status,
 			changes_json = excluded.changes_json,
 			usage_json = excluded.usage_json,
+			summary_json = excluded.summary_json,
 			started_at = excluded.started_at,
 			ended_at = excluded.ended_at`,
 		sessionID, iter.Number, iter.Prompt, iter.Result, iter.Error,
-		string(iter.Status), changesJSON, usageJSON,
+		string(iter.Status), changesJSON, usageJSON, summaryJSON,
 		iter.StartedAt.Format(time.RFC3339Nano), endedAt,
 	)
 	if err != nil {
@@ -190,15 +211,15 @@ func (s *SQLiteStore) Get(ctx context.Context, sessionID string) (*Session, erro
 	var t Session
 	var statusStr, configJSON, changesJSON, usageJSON, createdAt, updatedAt string
 	var reviewJSON sql.NullString
-	var startedAt, finishedAt sql.NullString
+	var startedAt, finishedAt, deletedAt sql.NullString
 
 	err := s.db.QueryRowContext(ctx,
 		`SELECT id, status, repo_url, provider_key, prompt, session_type, callback_url, config_json,
 			result, error, changes_json, usage_json,
 			iteration, current_prompt,
 			branch, pr_number, pr_url,
-			workflow_run_id, trace_id, tenant_id, created_at, started_at, finished_at, updated_at,
-			review_result_json
+			workflow_run_id, trace_id, tenant_id, request_id, created_at, started_at, finished_at, updated_at,
+			review_result_json, deleted_at, cost_usd, priority
 		 FROM sessions WHERE id = ?`,
 		sessionID,
 	).Scan(
@@ -206,8 +227,8 @@ func (s *SQLiteStore) Get(ctx context.Context, sessionID string) (*Session, erro
 		&t.Result, &t.Error, &changesJSON, &usageJSON,
 		&t.Iteration, &t.CurrentPrompt,
 		&t.Branch, &t.PRNumber, &t.PRURL,
-		&t.WorkflowRunID, &t.TraceID, &t.TenantID, &createdAt, &startedAt, &finishedAt, &updatedAt,
-		&reviewJSON,
+		&t.WorkflowRunID, &t.TraceID, &t.TenantID, &t.RequestID, &createdAt, &startedAt, &finishedAt, &updatedAt,
+		&reviewJSON, &deletedAt, &t.CostUSD, &t.Priority,
 	)
 	if err == sql.ErrNoRows {
 		return nil, apperror.NotFound("session %s not found", sessionID)
@@ -232,6 +253,10 @@ func (s *SQLiteStore) Get(ctx context.Context, sessionID string) (*Session, erro
 		ts, _ := time.Parse(time.RFC3339Nano, finishedAt.String)
 		t.FinishedAt = &ts
 	}
+	if deletedAt.Valid {
+		ts, _ := time.Parse(time.RFC3339Nano, deletedAt.String)
+		t.DeletedAt = &ts
+	}
 
 	return &t, nil
 }
@@ -239,29 +264,64 @@ func (s *SQLiteStore) Get(ctx context.Context, sessionID string) (*Session, erro
 // GetIterations loads all iterations for a session from SQLite.
 func (s *SQLiteStore) GetIterations(ctx context.Context, sessionID string) ([]Iteration, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT number, prompt, result, error, status, changes_json, usage_json, started_at, ended_at
+		`SELECT number, prompt, result, error, status, changes_json, usage_json, summary_json, started_at, ended_at
 		 FROM session_iterations WHERE session_id = ? ORDER BY number`,
 		sessionID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("getting iterations from sqlite: %w", err)
 	}
+	return scanIterations(rows)
+}
+
+// GetIterationPage returns up to limit iterations of a session starting at
+// offset, ordered by number, and the total number of iterations.
+func (s *SQLiteStore) GetIterationPage(ctx context.Context, sessionID string, limit, offset int) ([]Iteration, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM session_iterations WHERE session_id = ?`, sessionID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting iterations: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT number, prompt, result, error, status, changes_json, usage_json, summary_json, started_at, ended_at
+		 FROM session_iterations WHERE session_id = ? ORDER BY number LIMIT ? OFFSET ?`,
+		sessionID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting iterations from sqlite: %w", err)
+	}
+	iterations, err := scanIterations(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return iterations, total, nil
+}
+
+func scanIterations(rows *sql.Rows) ([]Iteration, error) {
 	defer rows.Close()
 
 	iterations := make([]Iteration, 0)
 	for rows.Next() {
 		var iter Iteration
-		var statusStr, changesJSON, usageJSON, startedAt string
+		var statusStr, changesJSON, usageJSON, summaryJSON, startedAt string
 		var endedAt sql.NullString
 
 		if err := rows.Scan(&iter.Number, &iter.Prompt, &iter.Result, &iter.Error, &statusStr,
-			&changesJSON, &usageJSON, &startedAt, &endedAt); err != nil {
+			&changesJSON, &usageJSON, &summaryJSON, &startedAt, &endedAt); err != nil {
 			return nil, fmt.Errorf("scanning iteration: %w", err)
 		}
 
 		iter.Status = Status(statusStr)
 		iter.Changes = UnmarshalChangesSummary(changesJSON)
 		iter.Usage = UnmarshalUsageInfo(usageJSON)
+		if summaryJSON != "" {
+			var sum IterationSummary
+			if json.Unmarshal([]byte(summaryJSON), &sum) == nil {
+				iter.Summary = &sum
+			}
+		}
 		iter.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
 		if endedAt.Valid {
 			ts, _ := time.Parse(time.RFC3339Nano, endedAt.String)
@@ -275,6 +335,17 @@ func (s *SQLiteStore) GetIterations(ctx context.Context, sessionID string) ([]It
 
 // List returns session summaries from SQLite with filtering and pagination.
 func (s *SQLiteStore) List(ctx context.Context, opts ListOptions) ([]Summary, int, error) {
+	return s.list(ctx, "", opts)
+}
+
+// ListByRepo returns the summaries of the sessions of one repository, by its
+// lower-cased owner/repo (see RepoFullName), newest first.
+func (s *SQLiteStore) ListByRepo(ctx context.Context, fullName string, opts ListOptions) ([]Summary, int, error) {
+	return s.list(ctx, fullName, opts)
+}
+
+// list backs List and ListByRepo; an empty repoName lists every repository.
+func (s *SQLiteStore) list(ctx context.Context, repoName string, opts ListOptions) ([]Summary, int, error) {
 	limit := opts.Limit
 	if limit <= 0 {
 		limit = 50
@@ -283,8 +354,9 @@ func (s *SQLiteStore) List(ctx context.Context, opts ListOptions) ([]Summary, in
 		limit = 200
 	}
 
-	// Build optional filters (status, tenant ownership).
-	var where []string
+	// Build optional filters (status, tenant ownership). Soft-deleted sessions
+	// are never listed.
+	where := []string{"deleted_at IS NULL"}
 	var filterArgs []interface{}
 	if opts.Status != "" {
 		where = append(where, "status = ?")
@@ -294,10 +366,11 @@ func (s *SQLiteStore) List(ctx context.Context, opts ListOptions) ([]Summary, in
 		where = append(where, "tenant_id = ?")
 		filterArgs = append(filterArgs, opts.TenantID)
 	}
-	whereClause := ""
-	if len(where) > 0 {
-		whereClause = " WHERE " + strings.Join(where, " AND ")
+	if repoName != "" {
+		where = append(where, "repo_name = ?")
+		filterArgs = append(filterArgs, repoName)
 	}
+	whereClause := " WHERE " + strings.Join(where, " AND ")
 
 	var total int
 	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions"+whereClause, filterArgs...).Scan(&total); err != nil {
@@ -349,13 +422,111 @@ func (s *SQLiteStore) List(ctx context.Context, opts ListOptions) ([]Summary, in
 	return sessions, total, rows.Err()
 }
 
+// BackfillRepoNames sets repo_name on rows saved before the column existed.
+// Returns the number of rows updated.
+func (s *SQLiteStore) BackfillRepoNames(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, repo_url FROM sessions WHERE repo_name = ''`)
+	if err != nil {
+		return 0, fmt.Errorf("listing sessions without repo name: %w", err)
+	}
+	names := map[string]string{}
+	for rows.Next() {
+		var id, repoURL string
+		if err := rows.Scan(&id, &repoURL); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning session: %w", err)
+		}
+		if name := RepoFullName(repoURL); name != "" {
+			names[id] = name
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("listing sessions without repo name: %w", err)
+	}
+
+	for id, name := range names {
+		if _, err := s.db.ExecContext(ctx, `UPDATE sessions SET repo_name = ? WHERE id = ?`, name, id); err != nil {
+			return 0, fmt.Errorf("setting repo name of %s: %w", id, err)
+		}
+	}
+	return len(names), nil
+}
+
+// RewriteRepoURLs replaces every stored repo_url for which rewrite returns a
+// different value, keeping repo_name in step. Returns the rewritten session
+// IDs mapped to their new URL.
+func (s *SQLiteStore) RewriteRepoURLs(ctx context.Context, rewrite func(string) string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, repo_url FROM sessions`)
+	if err != nil {
+		return nil, fmt.Errorf("listing session repo URLs: %w", err)
+	}
+	changed := map[string]string{}
+	for rows.Next() {
+		var id, repoURL string
+		if err := rows.Scan(&id, &repoURL); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning session: %w", err)
+		}
+		if to := rewrite(repoURL); to != repoURL {
+			changed[id] = to
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing session repo URLs: %w", err)
+	}
+
+	for id, repoURL := range changed {
+		if _, err := s.db.ExecContext(ctx, `UPDATE sessions SET repo_url = ?, repo_name = ? WHERE id = ?`,
+			repoURL, RepoFullName(repoURL), id); err != nil {
+			return nil, fmt.Errorf("rewriting repo URL of %s: %w", id, err)
+		}
+	}
+	return changed, nil
+}
+
+// SetDeleted marks a session soft-deleted at the given time, or restores it
+// when deletedAt is nil.
+func (s *SQLiteStore) SetDeleted(ctx context.Context, sessionID string, deletedAt *time.Time) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET deleted_at = ?, updated_at = ? WHERE id = ?`,
+		nullableTime(deletedAt), now, sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating session deleted_at in sqlite: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a session and its iterations permanently.
+func (s *SQLiteStore) Delete(ctx context.Context, sessionID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning delete: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session_iterations WHERE session_id = ?`, sessionID); err != nil {
+		return fmt.Errorf("deleting iterations from sqlite: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, sessionID); err != nil {
+		return fmt.Errorf("deleting session from sqlite: %w", err)
+	}
+	return tx.Commit()
+}
+
 // CountActiveByTenant returns the number of in-flight (non-terminal) sessions
 // owned by a tenant — used to enforce the per-tier concurrency limit.
+// Scheduled sessions are not in flight until they are due: a tenant planning
+// runs for next week can still run something now.
 func (s *SQLiteStore) CountActiveByTenant(ctx context.Context, tenantID string) (int, error) {
 	var n int
 	err := s.db.QueryRowContext(ctx,
 		`SELECT COUNT(*) FROM sessions
-		 WHERE tenant_id = ? AND status NOT IN ('completed', 'failed', 'pr_created', 'canceled')`,
+		 WHERE tenant_id = ? AND deleted_at IS NULL
+		   AND status NOT IN ('scheduled', 'completed', 'failed', 'pr_created', 'pr_merged', 'canceled')`,
 		tenantID,
 	).Scan(&n)
 	if err != nil {
@@ -364,6 +535,35 @@ func (s *SQLiteStore) CountActiveByTenant(ctx context.Context, tenantID string)
 	return n, nil
 }
 
+// GetStatuses returns the status of each given session that exists in SQLite,
+// in a single query. Used as the fallback for listings when Redis state expired.
+func (s *SQLiteStore) GetStatuses(ctx context.Context, sessionIDs []string) (map[string]Status, error) {
+	out := make(map[string]Status, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return out, nil
+	}
+	args := make([]interface{}, len(sessionIDs))
+	for i, id := range sessionIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(sessionIDs)), ",")
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, status FROM sessions WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("getting session statuses: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id, status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, err
+		}
+		out[id] = Status(status)
+	}
+	return out, rows.Err()
+}
+
 // ListStuckSessions returns IDs of sessions that claim to be actively
 // processing (running/cloning) but have not been touched since `before` —
 // i.e. their worker is gone (crash, lost requeue). Used by the stuck sweeper.
@@ -389,6 +589,63 @@ func (s *SQLiteStore) ListStuckSessions(ctx context.Context, before time.Time) (
 	return ids, rows.Err()
 }
 
+// ListStaleSessions returns IDs of sessions waiting for a worker (pending) or
+// for an instruction (awaiting_instruction) whose row has not been updated
+// since before.
+func (s *SQLiteStore) ListStaleSessions(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id FROM sessions
+		 WHERE status IN ('pending', 'awaiting_instruction')
+		   AND deleted_at IS NULL AND updated_at < ?`,
+		before.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing stale sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListInFlightSessions returns sessions in a working state (cloning,
+// running, reviewing, creating a PR) whose row has not been updated since
+// `before`. pending is excluded — a long queue is not a stuck worker — as is
+// awaiting_instruction, which is idle by design. Used to report stuck sessions.
+func (s *SQLiteStore) ListInFlightSessions(ctx context.Context, before time.Time) ([]StuckSession, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, status, repo_url, updated_at FROM sessions
+		 WHERE status IN ('cloning', 'running', 'reviewing', 'creating_pr')
+		   AND deleted_at IS NULL AND updated_at < ?
+		 ORDER BY updated_at`,
+		before.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing in-flight sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []StuckSession
+	for rows.Next() {
+		var st StuckSession
+		var status, updatedAt string
+		if err := rows.Scan(&st.ID, &status, &st.RepoURL, &updatedAt); err != nil {
+			return nil, err
+		}
+		st.Status = Status(status)
+		st.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
 // FindByPR finds the most recent session for a given repo + PR/MR number.
 // Returns nil, nil if no session is found.
 func (s *SQLiteStore) FindByPR(ctx context.Context, repoURL string, prNumber int) (*Session, error) {
@@ -403,7 +660,7 @@ func (s *SQLiteStore) FindByPR(ctx context.Context, repoURL string, prNumber int
 			iteration, current_prompt,
 			branch, pr_number, pr_url,
 			workflow_run_id, trace_id, created_at, started_at, finished_at, updated_at,
-			review_result_json
+			review_result_json, cost_usd, priority
 		 FROM sessions
 		 WHERE repo_url = ? AND pr_number = ? AND status != 'failed'
 		 ORDER BY updated_at DESC LIMIT 1`,
@@ -414,7 +671,7 @@ func (s *SQLiteStore) FindByPR(ctx context.Context, repoURL string, prNumber int
 		&t.Iteration, &t.CurrentPrompt,
 		&t.Branch, &t.PRNumber, &t.PRURL,
 		&t.WorkflowRunID, &t.TraceID, &createdAt, &startedAt, &finishedAt, &updatedAt,
-		&reviewJSON,
+		&reviewJSON, &t.CostUSD, &t.Priority,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
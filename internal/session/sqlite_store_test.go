@@ -3,6 +3,10 @@ package session
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +30,7 @@ func openTestDB(t *testing.T) *sql.DB {
 			id              TEXT PRIMARY KEY,
 			status          TEXT NOT NULL DEFAULT 'pending',
 			repo_url        TEXT NOT NULL,
+			repo_name       TEXT NOT NULL DEFAULT '',
 			provider_key    TEXT NOT NULL DEFAULT '',
 			prompt          TEXT NOT NULL,
 			session_type    TEXT NOT NULL DEFAULT 'code',
@@ -43,11 +48,15 @@ func openTestDB(t *testing.T) *sql.DB {
 			workflow_run_id TEXT NOT NULL DEFAULT '',
 			trace_id        TEXT NOT NULL DEFAULT '',
 			tenant_id       TEXT NOT NULL DEFAULT '',
+			request_id      TEXT NOT NULL DEFAULT '',
 			created_at      TEXT NOT NULL,
 			started_at      TEXT,
 			finished_at     TEXT,
 			updated_at      TEXT NOT NULL,
-			review_result_json TEXT NOT NULL DEFAULT '{}'
+			review_result_json TEXT NOT NULL DEFAULT '{}',
+			deleted_at      TEXT,
+			cost_usd        REAL NOT NULL DEFAULT 0,
+			priority        TEXT NOT NULL DEFAULT ''
 		);
 		CREATE TABLE session_iterations (
 			id          INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -59,6 +68,7 @@ func openTestDB(t *testing.T) *sql.DB {
 			status      TEXT NOT NULL,
 			changes_json TEXT NOT NULL DEFAULT '{}',
 			usage_json  TEXT NOT NULL DEFAULT '{}',
+			summary_json TEXT NOT NULL DEFAULT '',
 			started_at  TEXT NOT NULL,
 			ended_at    TEXT,
 			FOREIGN KEY (session_id) REFERENCES sessions(id),
@@ -126,12 +136,152 @@ func TestSQLiteStore_ListByTenant(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_ListByRepo(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	urls := map[string]string{
+		"a":     "https://github.com/Acme/API.git",
+		"b":     "https://github.com/acme/api",
+		"c":     "https://gitlab.com/acme/api/",
+		"other": "https://github.com/acme/web",
+	}
+	for i, id := range []string{"a", "b", "c", "other"} {
+		s := makeSession(id)
+		s.RepoURL = urls[id]
+		s.CreatedAt = time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+	}
+
+	got, total, err := store.ListByRepo(ctx, "acme/api", ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListByRepo: %v", err)
+	}
+	if total != 3 || len(got) != 2 || got[0].ID != "c" || got[1].ID != "b" {
+		t.Errorf("got %+v (total %d), want c, b of 3", got, total)
+	}
+	if got, _, _ := store.ListByRepo(ctx, "acme/api", ListOptions{Limit: 2, Offset: 2}); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("page 2: got %+v, want a", got)
+	}
+
+	// Rows saved before repo_name existed are found after the backfill.
+	if _, err := db.ExecContext(ctx, `UPDATE sessions SET repo_name = ''`); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.BackfillRepoNames(ctx); err != nil || n != 4 {
+		t.Fatalf("BackfillRepoNames = %d, %v; want 4", n, err)
+	}
+	if _, total, _ := store.ListByRepo(ctx, "acme/api", ListOptions{}); total != 3 {
+		t.Errorf("total after backfill = %d, want 3", total)
+	}
+}
+
+func TestSQLiteStore_RewriteRepoURLs(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	for id, repoURL := range map[string]string{"old": "https://GitHub.com/acme/api.git/", "new": "https://github.com/acme/api"} {
+		s := makeSession(id)
+		s.RepoURL = repoURL
+		s.PRNumber = 7
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+	}
+
+	changed, err := store.RewriteRepoURLs(ctx, func(u string) string {
+		return strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git"))
+	})
+	if err != nil {
+		t.Fatalf("RewriteRepoURLs: %v", err)
+	}
+	if len(changed) != 1 || changed["old"] != "https://github.com/acme/api" {
+		t.Errorf("changed = %v, want only old", changed)
+	}
+	got, err := store.Get(ctx, "old")
+	if err != nil || got.RepoURL != "https://github.com/acme/api" {
+		t.Errorf("old repo_url = %q (err %v)", got.RepoURL, err)
+	}
+}
+
+func TestSQLiteStore_Cost(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	sess := makeSession("s1")
+	if err := store.Save(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+	for _, usd := range []float64{0.25, 0.5} {
+		if err := store.AddCost(ctx, "s1", usd); err != nil {
+			t.Fatalf("AddCost: %v", err)
+		}
+	}
+	// A later save from state that lost the spend must not reset it.
+	sess.CostUSD = 0.1
+	if err := store.Save(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "s1")
+	if err != nil || got.CostUSD != 0.75 {
+		t.Errorf("CostUSD = %v (err %v), want 0.75", got.CostUSD, err)
+	}
+}
+
+func TestSQLiteStore_Priority(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	sess := makeSession("s1")
+	sess.Priority = PriorityLow
+	if err := store.Save(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "s1")
+	if err != nil || got.Priority != PriorityLow {
+		t.Errorf("Priority = %q (err %v), want low", got.Priority, err)
+	}
+}
+
+func TestSQLiteStore_CountActiveByTenant(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	for i, st := range []Status{StatusPending, StatusRunning, StatusAwaitingInstruction, StatusScheduled, StatusCompleted, StatusPRMerged, StatusCanceled} {
+		s := makeSession(fmt.Sprintf("s%d", i))
+		s.TenantID = "tenant-1"
+		s.Status = st
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	other := makeSession("other")
+	other.TenantID = "tenant-2"
+	if err := store.Save(ctx, other); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// pending, running and awaiting_instruction; scheduled sessions wait
+	// for their time without taking a slot.
+	if n, err := store.CountActiveByTenant(ctx, "tenant-1"); err != nil || n != 3 {
+		t.Errorf("CountActiveByTenant = %d, %v; want 3", n, err)
+	}
+}
+
 func TestSQLiteStore_SaveAndGet(t *testing.T) {
 	db := openTestDB(t)
 	store := NewSQLiteStore(db)
 	ctx := context.Background()
 
 	sess := makeSession("task-1")
+	sess.RequestID = "req-1"
 	if err := store.Save(ctx, sess); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
@@ -156,6 +306,9 @@ func TestSQLiteStore_SaveAndGet(t *testing.T) {
 	if got.TraceID != sess.TraceID {
 		t.Errorf("TraceID: got %q, want %q", got.TraceID, sess.TraceID)
 	}
+	if got.RequestID != "req-1" {
+		t.Errorf("RequestID: got %q, want req-1", got.RequestID)
+	}
 }
 
 func TestSQLiteStore_SaveUpsert(t *testing.T) {
@@ -314,6 +467,7 @@ func TestSQLiteStore_SaveAndGetIterations(t *testing.T) {
 		Prompt:    "first prompt",
 		Result:    "first result",
 		Status:    StatusCompleted,
+		Summary:   &IterationSummary{Changed: "added login", Files: []string{"auth.go"}},
 		StartedAt: now,
 		EndedAt:   &ended,
 	}
@@ -348,6 +502,56 @@ func TestSQLiteStore_SaveAndGetIterations(t *testing.T) {
 	if iters[0].EndedAt == nil {
 		t.Error("iter[0].EndedAt should be set")
 	}
+	if s := iters[0].Summary; s == nil || s.Changed != "added login" || len(s.Files) != 1 {
+		t.Errorf("iter[0].Summary: %+v", s)
+	}
+	if iters[1].Summary != nil {
+		t.Errorf("iter[1].Summary: got %+v, want nil", iters[1].Summary)
+	}
+}
+
+func TestSQLiteStore_GetIterationPage(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	if err := store.Save(ctx, makeSession("task-iter-page")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	for n := 1; n <= 3; n++ {
+		iter := Iteration{Number: n, Prompt: "step", Status: StatusCompleted, StartedAt: time.Now().UTC()}
+		if err := store.SaveIteration(ctx, "task-iter-page", iter); err != nil {
+			t.Fatalf("SaveIteration: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		want          []int
+	}{
+		{"first page", 2, 0, []int{1, 2}},
+		{"second page", 2, 2, []int{3}},
+		{"past the end", 2, 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iters, total, err := store.GetIterationPage(ctx, "task-iter-page", tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("GetIterationPage: %v", err)
+			}
+			if total != 3 {
+				t.Errorf("total = %d, want 3", total)
+			}
+			var got []int
+			for _, it := range iters {
+				got = append(got, it.Number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("iterations = %v, want %v", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestSQLiteStore_SaveIterationUpsert(t *testing.T) {
@@ -487,3 +691,174 @@ func TestSQLiteStore_PromptTruncatedInList(t *testing.T) {
 		t.Errorf("prompt not truncated: len=%d", len(sessions[0].Prompt))
 	}
 }
+
+func TestSQLiteStore_GetStatuses(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	for id, st := range map[string]Status{"a": StatusCompleted, "b": StatusFailed, "c": StatusRunning} {
+		sess := makeSession(id)
+		sess.Status = st
+		if err := store.Save(ctx, sess); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+	}
+
+	got, err := store.GetStatuses(ctx, []string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatalf("GetStatuses: %v", err)
+	}
+	if len(got) != 2 || got["a"] != StatusCompleted || got["b"] != StatusFailed {
+		t.Errorf("got %v", got)
+	}
+
+	empty, err := store.GetStatuses(ctx, nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("empty input: got %v, %v", empty, err)
+	}
+}
+
+func TestSQLiteStore_SoftDelete(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	for _, id := range []string{"keep", "gone"} {
+		if err := store.Save(ctx, makeSession(id)); err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+	}
+	if err := store.SaveIteration(ctx, "gone", Iteration{Number: 1, Prompt: "p", Status: StatusCompleted, StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	if err := store.SetDeleted(ctx, "gone", &now); err != nil {
+		t.Fatalf("SetDeleted: %v", err)
+	}
+	got, total, _ := store.List(ctx, ListOptions{})
+	if total != 1 || got[0].ID != "keep" {
+		t.Errorf("list after delete: total=%d %+v", total, got)
+	}
+	if s, err := store.Get(ctx, "gone"); err != nil || s.DeletedAt == nil {
+		t.Errorf("Get deleted: DeletedAt=%v err=%v", s.DeletedAt, err)
+	}
+
+	if err := store.SetDeleted(ctx, "gone", nil); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, total, _ := store.List(ctx, ListOptions{}); total != 2 {
+		t.Errorf("list after restore: total=%d, want 2", total)
+	}
+
+	if err := store.Delete(ctx, "gone"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "gone"); err == nil {
+		t.Error("purged session still readable")
+	}
+	if iters, _ := store.GetIterations(ctx, "gone"); len(iters) != 0 {
+		t.Errorf("iterations left behind: %d", len(iters))
+	}
+}
+
+func TestSQLiteStore_ListInFlightSessions(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+	for _, tc := range []struct {
+		id     string
+		status Status
+		old    bool
+	}{
+		{"running-old", StatusRunning, true},
+		{"pending-old", StatusPending, true},
+		{"creating-pr-old", StatusCreatingPR, true},
+		{"running-recent", StatusRunning, false},
+		{"awaiting-old", StatusAwaitingInstruction, true},
+		{"completed-old", StatusCompleted, true},
+	} {
+		s := makeSession(tc.id)
+		s.Status = tc.status
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("save %s: %v", tc.id, err)
+		}
+		if tc.old {
+			if _, err := db.ExecContext(ctx, `UPDATE sessions SET updated_at = ? WHERE id = ?`, old, tc.id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	got, err := store.ListInFlightSessions(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ListInFlightSessions: %v", err)
+	}
+	ids := map[string]Status{}
+	for _, s := range got {
+		ids[s.ID] = s.Status
+		if s.UpdatedAt.IsZero() || s.RepoURL == "" {
+			t.Errorf("%s: missing updated_at or repo_url: %+v", s.ID, s)
+		}
+	}
+	// Queued sessions only wait for a worker; the stale expirer covers them.
+	want := map[string]Status{"running-old": StatusRunning, "creating-pr-old": StatusCreatingPR}
+	if len(ids) != len(want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	for id, st := range want {
+		if ids[id] != st {
+			t.Errorf("%s: status %q, want %q", id, ids[id], st)
+		}
+	}
+}
+
+func TestSQLiteStore_ListStaleSessions(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db)
+	ctx := context.Background()
+
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+	for _, tc := range []struct {
+		id     string
+		status Status
+		old    bool
+	}{
+		{"pending-old", StatusPending, true},
+		{"awaiting-old", StatusAwaitingInstruction, true},
+		{"awaiting-recent", StatusAwaitingInstruction, false},
+		{"running-old", StatusRunning, true},
+		{"completed-old", StatusCompleted, true},
+		{"pending-deleted", StatusPending, true},
+	} {
+		s := makeSession(tc.id)
+		s.Status = tc.status
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("save %s: %v", tc.id, err)
+		}
+		if tc.old {
+			if _, err := db.ExecContext(ctx, `UPDATE sessions SET updated_at = ? WHERE id = ?`, old, tc.id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	now := time.Now()
+	if err := store.SetDeleted(ctx, "pending-deleted", &now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE sessions SET updated_at = ? WHERE id = ?`, old, "pending-deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.ListStaleSessions(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ListStaleSessions: %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{"awaiting-old", "pending-old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
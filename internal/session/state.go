@@ -9,9 +9,15 @@ import (
 // validTransitions defines valid state machine transitions.
 // Session is a session — completed and pr_created are NOT terminal.
 // They allow review/fix/instruct loops.
+// creating_pr → completed reverts a PR attempt rejected before anything
+// was pushed (e.g. missing target branch).
 // cloning/running → pending happens when a shutdown interrupts an in-flight
 // session and it is requeued for the next server start.
+// awaiting_instruction → cloning re-clones a follow-up's workspace that went
+// missing or failed its integrity check.
+// scheduled → pending is the promotion into the queue once it is due.
 var validTransitions = map[Status][]Status{
+	StatusScheduled:           {StatusPending, StatusCanceled},
 	StatusPending:             {StatusCloning, StatusRunning, StatusFailed, StatusCanceled},
 	StatusCloning:             {StatusRunning, StatusFailed, StatusCanceled, StatusPending},
 	StatusRunning:             {StatusCompleted, StatusFailed, StatusCanceled, StatusPending},
@@ -19,9 +25,10 @@ var validTransitions = map[Status][]Status{
 	StatusCompleted:           {StatusAwaitingInstruction, StatusCreatingPR, StatusReviewing},
 	StatusFailed:              {}, // terminal
 	StatusCanceled:            {}, // terminal — user aborted
-	StatusAwaitingInstruction: {StatusRunning, StatusReviewing, StatusFailed, StatusCanceled},
-	StatusCreatingPR:          {StatusPRCreated, StatusFailed},
-	StatusPRCreated:           {StatusAwaitingInstruction, StatusReviewing, StatusCreatingPR, StatusCompleted},
+	StatusAwaitingInstruction: {StatusCloning, StatusRunning, StatusReviewing, StatusFailed, StatusCanceled},
+	StatusCreatingPR:          {StatusPRCreated, StatusFailed, StatusCompleted},
+	StatusPRCreated:           {StatusAwaitingInstruction, StatusReviewing, StatusCreatingPR, StatusCompleted, StatusPRMerged},
+	StatusPRMerged:            {}, // terminal — the change landed
 }
 
 // ValidateTransition checks if the transition from current to next status is valid.
@@ -48,11 +55,60 @@ func ValidateTransition(current, next Status) error {
 	}
 }
 
+// CheckIterationLimit rejects a follow-up once the session has used up its
+// iteration budget. The per-session config.max_iterations overrides the server
+// default; 0 means unlimited.
+func CheckIterationLimit(t *Session, serverMax int) error {
+	limit := serverMax
+	if t.Config != nil && t.Config.MaxIterations > 0 {
+		limit = t.Config.MaxIterations
+	}
+	if limit > 0 && t.Iteration >= limit {
+		return apperror.Conflict("session reached max_iterations (%d), create a new session to continue", limit)
+	}
+	return nil
+}
+
+// CheckBudget rejects an iteration once the session's spend reached its
+// config.max_budget_usd (0 = no budget). Only CLIs that report their cost
+// count towards it.
+func CheckBudget(t *Session) error {
+	if t.Config == nil || t.Config.MaxBudgetUSD <= 0 || t.CostUSD < t.Config.MaxBudgetUSD {
+		return nil
+	}
+	err := apperror.Conflict("session spent $%.2f of its $%.2f budget (max_budget_usd), create a new session to continue",
+		t.CostUSD, t.Config.MaxBudgetUSD)
+	err.Code = "budget_exceeded"
+	return err
+}
+
+// RemainingBudget is what is left of config.max_budget_usd for the next CLI
+// run; ok is false when the session has no budget.
+func RemainingBudget(t *Session) (usd float64, ok bool) {
+	if t.Config == nil || t.Config.MaxBudgetUSD <= 0 {
+		return 0, false
+	}
+	return max(t.Config.MaxBudgetUSD-t.CostUSD, 0), true
+}
+
+// CheckCrashLoop stops an automatic run once the session's automatic runs
+// failed threshold times in a row with the same error class — retrying a
+// hopeless loop only burns budget (0 = no limit).
+func CheckCrashLoop(t *Session, threshold int) error {
+	if threshold <= 0 || t.FailureStreak < threshold {
+		return nil
+	}
+	err := apperror.Conflict("session failed %d times in a row with the same error (%s), automatic retries stopped",
+		t.FailureStreak, t.FailureClass)
+	err.Code = "crash_loop"
+	return err
+}
+
 // IsFinished returns true if the session has reached a terminal state.
-// Only failed and canceled are truly terminal — completed and pr_created
-// allow further interaction.
+// Only failed, canceled and pr_merged are truly terminal — completed and
+// pr_created allow further interaction.
 func IsFinished(s Status) bool {
-	return s == StatusFailed || s == StatusCanceled
+	return s == StatusFailed || s == StatusCanceled || s == StatusPRMerged
 }
 
 // IsIdle returns true if the session is in a resting state (not actively processing)
@@ -23,15 +23,21 @@ func TestValidTransitions(t *testing.T) {
 		{StatusCompleted, StatusReviewing},
 		{StatusReviewing, StatusCompleted},
 		{StatusReviewing, StatusFailed},
+		{StatusAwaitingInstruction, StatusCloning},
 		{StatusAwaitingInstruction, StatusRunning},
 		{StatusAwaitingInstruction, StatusReviewing},
 		{StatusAwaitingInstruction, StatusFailed},
 		{StatusCreatingPR, StatusPRCreated},
 		{StatusCreatingPR, StatusFailed},
+		{StatusCreatingPR, StatusCompleted},
 		{StatusPRCreated, StatusAwaitingInstruction},
 		{StatusPRCreated, StatusReviewing},
 		{StatusPRCreated, StatusCreatingPR},
 		{StatusPRCreated, StatusCompleted},
+		{StatusPRCreated, StatusPRMerged},
+		// scheduled sessions
+		{StatusScheduled, StatusPending},
+		{StatusScheduled, StatusCanceled},
 		// user cancel
 		{StatusPending, StatusCanceled},
 		{StatusCloning, StatusCanceled},
@@ -66,6 +72,8 @@ func TestInvalidTransitions(t *testing.T) {
 		{StatusCompleted, StatusCanceled},
 		{StatusPRCreated, StatusRunning},
 		{StatusPRCreated, StatusCanceled},
+		{StatusPRMerged, StatusAwaitingInstruction},
+		{StatusPRMerged, StatusPRCreated},
 		{StatusCanceled, StatusPending},
 		{StatusCanceled, StatusRunning},
 		{StatusReviewing, StatusPending},
@@ -83,8 +91,8 @@ func TestInvalidTransitions(t *testing.T) {
 }
 
 func TestIsFinished(t *testing.T) {
-	// Only failed is truly terminal
-	finished := []Status{StatusFailed}
+	// Only failed, canceled and pr_merged are truly terminal
+	finished := []Status{StatusFailed, StatusCanceled, StatusPRMerged}
 	for _, s := range finished {
 		if !IsFinished(s) {
 			t.Errorf("%s should be finished", s)
@@ -114,3 +122,99 @@ func TestIsIdle(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckIterationLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		iteration int
+		cfg       *Config
+		serverMax int
+		wantErr   bool
+	}{
+		{"unlimited", 50, nil, 0, false},
+		{"below server cap", 2, nil, 3, false},
+		{"at server cap", 3, nil, 3, true},
+		{"session override raises cap", 3, &Config{MaxIterations: 5}, 3, false},
+		{"session override lowers cap", 2, &Config{MaxIterations: 2}, 10, true},
+		{"session override without server cap", 4, &Config{MaxIterations: 4}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckIterationLimit(&Session{Iteration: tt.iteration, Config: tt.cfg}, tt.serverMax)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && apperror.HTTPStatus(err) != 409 {
+				t.Errorf("status = %d, want 409", apperror.HTTPStatus(err))
+			}
+		})
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		cost          float64
+		cfg           *Config
+		wantErr       bool
+		wantRemaining float64
+		wantOK        bool
+	}{
+		{"no config", 12, nil, false, 0, false},
+		{"no budget", 12, &Config{}, false, 0, false},
+		{"within budget", 1.5, &Config{MaxBudgetUSD: 5}, false, 3.5, true},
+		{"budget spent", 5, &Config{MaxBudgetUSD: 5}, true, 0, true},
+		{"overspent by the last run", 6.2, &Config{MaxBudgetUSD: 5}, true, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{CostUSD: tt.cost, Config: tt.cfg}
+			err := CheckBudget(s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var appErr *apperror.AppError
+				if !errors.As(err, &appErr) || appErr.Status != 409 || appErr.Code != "budget_exceeded" {
+					t.Errorf("err = %#v, want 409 budget_exceeded", err)
+				}
+			}
+			remaining, ok := RemainingBudget(s)
+			if remaining != tt.wantRemaining || ok != tt.wantOK {
+				t.Errorf("RemainingBudget = %v, %v; want %v, %v", remaining, ok, tt.wantRemaining, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckCrashLoop(t *testing.T) {
+	tests := []struct {
+		name      string
+		streak    int
+		threshold int
+		wantErr   bool
+	}{
+		{"no failures", 0, 3, false},
+		{"below threshold", 2, 3, false},
+		{"threshold reached", 3, 3, true},
+		{"disabled", 10, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{FailureClass: "worker crashed mid-run", FailureStreak: tt.streak}
+			err := CheckCrashLoop(s, tt.threshold)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var appErr *apperror.AppError
+				if !errors.As(err, &appErr) || appErr.Code != "crash_loop" {
+					t.Errorf("err = %#v, want crash_loop", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// StuckSession is an in-flight session without recent activity: neither its
+// state nor its event history changed for at least the requested age.
+type StuckSession struct {
+	ID          string     `json:"id"`
+	Status      Status     `json:"status"`
+	RepoURL     string     `json:"repo_url"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastEvent   string     `json:"last_event,omitempty"`
+	LastEventAt *time.Time `json:"last_event_at,omitempty"`
+	IdleSeconds int64      `json:"idle_seconds"`
+	Failed      bool       `json:"failed,omitempty"`
+	FailError   string     `json:"fail_error,omitempty"` // why a force-fail was skipped
+}
+
+// historyEvent is the part of a stream event needed to date it.
+type historyEvent struct {
+	Event string `json:"event"`
+	TS    string `json:"ts"`
+}
+
+// FindStuck lists sessions in a working state whose state and event history
+// have been quiet for at least age. Candidates come from SQLite; the live
+// Redis status and the newest history event then rule out sessions that
+// moved on or are still streaming output. Returns nil when SQLite is not
+// configured.
+func (s *Service) FindStuck(ctx context.Context, age time.Duration) ([]StuckSession, error) {
+	if age <= 0 {
+		return nil, apperror.Validation("age must be positive")
+	}
+	if s.sqlite == nil {
+		return nil, nil
+	}
+	now := time.Now().UTC()
+	cutoff := now.Add(-age)
+
+	candidates, err := s.sqlite.ListInFlightSessions(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return []StuckSession{}, nil
+	}
+
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+	statuses, err := s.GetStatusBatch(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	stuck := []StuckSession{}
+	for _, c := range candidates {
+		status, ok := statuses[c.ID]
+		if !ok || status.IsTerminal() || status == StatusAwaitingInstruction || status == StatusPending {
+			continue
+		}
+		c.Status = status
+
+		lastActivity := c.UpdatedAt
+		if ev, ok := s.lastHistoryEvent(ctx, c.ID); ok {
+			c.LastEvent = ev.Event
+			if ts, err := time.Parse(time.RFC3339Nano, ev.TS); err == nil {
+				c.LastEventAt = &ts
+				if ts.After(lastActivity) {
+					lastActivity = ts
+				}
+			}
+		}
+		if lastActivity.After(cutoff) {
+			continue
+		}
+		c.IdleSeconds = int64(now.Sub(lastActivity).Seconds())
+		stuck = append(stuck, c)
+	}
+	return stuck, nil
+}
+
+// lastHistoryEvent returns the newest event in the session's stream history.
+func (s *Service) lastHistoryEvent(ctx context.Context, sessionID string) (historyEvent, bool) {
+	raw, err := s.redis.Unwrap().LIndex(ctx, s.redis.Key("session", sessionID, "history"), -1).Result()
+	if err != nil {
+		return historyEvent{}, false
+	}
+	var ev historyEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		return historyEvent{}, false
+	}
+	return ev, true
+}
+
+// FailStuck marks a session failed with the given reason. The transition is
+// validated against the live Redis state, so a session that moved on in the
+// meantime is left alone and an error is returned.
+func (s *Service) FailStuck(ctx context.Context, sessionID, reason string) error {
+	if err := s.UpdateStatus(ctx, sessionID, StatusFailed); err != nil {
+		return err
+	}
+	if err := s.SetError(ctx, sessionID, reason); err != nil {
+		slog.Warn("storing stuck session error failed", "session_id", sessionID, "error", err)
+	}
+	return nil
+}
+
+// StuckReason is the error stored on a session force-failed as stuck.
+func StuckReason(idle time.Duration) string {
+	return fmt.Sprintf("session stuck without activity for %s — marked failed by an operator", idle.Round(time.Second))
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/freema/codeforge/internal/metrics"
+)
+
+// timingField is the state hash field holding the running total of a phase.
+func timingField(phase string) string {
+	return "timing_" + phase
+}
+
+// AddTimings adds the phases of a run (or of create-pr) to the session's
+// totals and observes them in the phase duration histogram. Phases that did
+// not happen are skipped, so a reused workspace does not count as a clone.
+func (s *Service) AddTimings(ctx context.Context, sessionID string, timings *Timings) error {
+	if timings == nil {
+		return nil
+	}
+	stateKey := s.redis.Key("session", sessionID, "state")
+	pipe := s.redis.Unwrap().TxPipeline()
+	n := 0
+	for phase, seconds := range timings.Phases() {
+		if seconds <= 0 {
+			continue
+		}
+		pipe.HIncrByFloat(ctx, stateKey, timingField(phase), seconds)
+		metrics.PhaseDuration.WithLabelValues(phase).Observe(seconds)
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("adding session timings: %w", err)
+	}
+	return nil
+}
+
+// parseTimings reads the phase totals from a state hash, nil before the
+// first run finished.
+func parseTimings(fields map[string]string) *Timings {
+	var t *Timings
+	for phase := range (&Timings{}).Phases() {
+		v := fields[timingField(phase)]
+		if v == "" {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			continue
+		}
+		if t == nil {
+			t = &Timings{}
+		}
+		*t.phase(phase) = seconds
+	}
+	return t
+}
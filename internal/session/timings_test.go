@@ -0,0 +1,44 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTimings(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   *Timings
+	}{
+		{"no run finished yet", map[string]string{"status": "pending"}, nil},
+		{
+			"phase totals",
+			map[string]string{"timing_queue_wait": "1.5", "timing_clone": "12", "timing_run": "90.25", "timing_diff": "0.5", "timing_pr": "4"},
+			&Timings{QueueWait: 1.5, Clone: 12, Run: 90.25, Diff: 0.5, PR: 4},
+		},
+		{"garbage is skipped", map[string]string{"timing_run": "x", "timing_diff": "2"}, &Timings{Diff: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTimings(tt.fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTimings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimings_Add(t *testing.T) {
+	var tm Timings
+	tm.Add(PhaseRun, 2*time.Second)
+	tm.Add(PhaseRun, 500*time.Millisecond)
+	tm.Add(PhaseClone, time.Second)
+	tm.Add("unknown", time.Hour)
+	if want := (Timings{Clone: 1, Run: 2.5}); tm != want {
+		t.Errorf("Timings = %+v, want %+v", tm, want)
+	}
+	if got := tm.Phases(); len(got) != 5 || got[PhaseRun] != 2.5 {
+		t.Errorf("Phases() = %v", got)
+	}
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// Transcript is the complete raw CLI event stream of one iteration.
+// Unlike the SSE history it outlives the workspace and is kept under its own TTL.
+type Transcript struct {
+	Iteration int               `json:"iteration"`
+	Truncated bool              `json:"truncated"`
+	Events    []json.RawMessage `json:"events"`
+}
+
+// transcriptTruncatedSuffix marks a hash field recording that the iteration's
+// transcript hit the size cap.
+const transcriptTruncatedSuffix = ":truncated"
+
+// SetTranscriptTTL sets how long transcripts are retained (0 = no expiry).
+func (s *Service) SetTranscriptTTL(ttl time.Duration) {
+	s.transcriptTTL = ttl
+}
+
+// SaveTranscript stores the newline-delimited CLI events of an iteration,
+// gzip-compressed, in the session's transcript hash.
+func (s *Service) SaveTranscript(ctx context.Context, sessionID string, iteration int, jsonl []byte, truncated bool) error {
+	data, err := encodeTranscript(jsonl)
+	if err != nil {
+		return err
+	}
+	field := strconv.Itoa(iteration)
+	if data, err = s.offload(ctx, blobKey(sessionID, "transcript", field+".jsonl.gz"), data); err != nil {
+		return err
+	}
+
+	key := s.redis.Key("session", sessionID, "transcript")
+	pipe := s.redis.Unwrap().Pipeline()
+	pipe.HSet(ctx, key, field, data)
+	if truncated {
+		pipe.HSet(ctx, key, field+transcriptTruncatedSuffix, "1")
+	}
+	if s.transcriptTTL > 0 {
+		pipe.Expire(ctx, key, s.transcriptTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("saving transcript: %w", err)
+	}
+	return nil
+}
+
+// GetTranscripts returns the stored transcripts of a session, ordered by
+// iteration. iteration > 0 limits the result to that iteration.
+func (s *Service) GetTranscripts(ctx context.Context, sessionID string, iteration int) ([]Transcript, error) {
+	key := s.redis.Key("session", sessionID, "transcript")
+	fields, err := s.redis.Unwrap().HGetAll(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("loading transcript: %w", err)
+	}
+
+	transcripts := make([]Transcript, 0, len(fields))
+	for field, data := range fields {
+		if strings.HasSuffix(field, transcriptTruncatedSuffix) {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || (iteration > 0 && n != iteration) {
+			continue
+		}
+		compressed, err := s.resolveBlob(ctx, []byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("loading transcript for iteration %d: %w", n, err)
+		}
+		events, err := decodeTranscript(compressed)
+		if err != nil {
+			return nil, fmt.Errorf("decoding transcript for iteration %d: %w", n, err)
+		}
+		transcripts = append(transcripts, Transcript{
+			Iteration: n,
+			Truncated: fields[field+transcriptTruncatedSuffix] == "1",
+			Events:    events,
+		})
+	}
+
+	if len(transcripts) == 0 {
+		if iteration > 0 {
+			return nil, apperror.NotFound("no transcript for iteration %d", iteration)
+		}
+		return nil, apperror.NotFound("no transcript for session %s (not run yet or expired)", sessionID)
+	}
+
+	sort.Slice(transcripts, func(i, j int) bool { return transcripts[i].Iteration < transcripts[j].Iteration })
+	return transcripts, nil
+}
+
+func encodeTranscript(jsonl []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(jsonl); err != nil {
+		return nil, fmt.Errorf("compressing transcript: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compressing transcript: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeTranscript(data []byte) ([]json.RawMessage, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []json.RawMessage
+	for _, line := range bytes.Split(raw, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			events = append(events, json.RawMessage(line))
+		}
+	}
+	return events, nil
+}
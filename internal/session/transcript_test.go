@@ -0,0 +1,42 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	jsonl := []byte("{\"type\":\"system\"}\n\n{\"type\":\"assistant\",\"text\":\"hi\"}\n")
+
+	data, err := encodeTranscript(jsonl)
+	if err != nil {
+		t.Fatalf("encodeTranscript: %v", err)
+	}
+	events, err := decodeTranscript(data)
+	if err != nil {
+		t.Fatalf("decodeTranscript: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if string(events[1]) != `{"type":"assistant","text":"hi"}` {
+		t.Errorf("unexpected event: %s", events[1])
+	}
+}
+
+func TestTranscriptCompresses(t *testing.T) {
+	jsonl := []byte(strings.Repeat("{\"type\":\"assistant\",\"text\":\"same line\"}\n", 1000))
+	data, err := encodeTranscript(jsonl)
+	if err != nil {
+		t.Fatalf("encodeTranscript: %v", err)
+	}
+	if len(data) >= len(jsonl)/10 {
+		t.Errorf("expected strong compression, got %d of %d bytes", len(data), len(jsonl))
+	}
+}
+
+func TestDecodeTranscript_Invalid(t *testing.T) {
+	if _, err := decodeTranscript([]byte("not gzip")); err == nil {
+		t.Error("expected error for non-gzip data")
+	}
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// IsTerminal reports whether no further work will happen for the session
+// without a new instruction.
+func (s Status) IsTerminal() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusPRCreated, StatusPRMerged, StatusCanceled:
+		return true
+	}
+	return false
+}
+
+// WaitForTerminal returns the session once it reaches a terminal state or the
+// wait elapses, whichever comes first. It subscribes to the session's done
+// channel before checking the status so a completion in between is not missed.
+func (s *Service) WaitForTerminal(ctx context.Context, sessionID string, wait time.Duration) (*Session, error) {
+	pubsub := s.redis.Unwrap().Subscribe(ctx, s.redis.Key("session", sessionID, "done"))
+	defer pubsub.Close()
+
+	t, err := s.Get(ctx, sessionID)
+	if err != nil || t.Status.IsTerminal() || wait <= 0 {
+		return t, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-pubsub.Channel():
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.Get(ctx, sessionID)
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/runner"
+)
+
+// StartWorkBranch checks out the session branch in a freshly cloned workspace
+// of a config.work_on_branch session. The first call names and records the
+// branch; a re-cloned workspace gets the recorded branch back. create-pr later
+// pushes this branch instead of creating one from the final changes.
+func (s *PRService) StartWorkBranch(ctx context.Context, t *Session, workDir string) (string, error) {
+	remote, _, err := s.pushTarget(ctx, t, workDir, nil)
+	if err != nil {
+		return "", err
+	}
+
+	branch := t.Branch
+	if branch == "" {
+		branch = s.sessionBranchName(ctx, t, workDir, remote, nil, workBranchLockTTL)
+	}
+
+	if err := gitpkg.CheckoutWorkBranch(ctx, workDir, remote, branch, t.AccessToken); err != nil {
+		return "", fmt.Errorf("checking out work branch: %w", err)
+	}
+	if branch == t.Branch {
+		return branch, nil
+	}
+	if err := s.recordBranch(ctx, t, branch); err != nil {
+		return "", err
+	}
+	return branch, nil
+}
+
+// sessionBranchName picks a branch name for a session that has none yet,
+// reserved for ttl so no concurrent session picks it before it is pushed.
+func (s *PRService) sessionBranchName(ctx context.Context, t *Session, workDir, remote string, changedPaths []string, ttl time.Duration) string {
+	pushURL := t.RepoURL
+	if t.Config != nil && t.Config.ForkURL != "" {
+		pushURL = t.Config.ForkURL
+	}
+	return gitpkg.GenerateBranchName(ctx, gitpkg.BranchNameOptions{
+		WorkDir: workDir,
+		Prefix:  s.cfg.BranchPrefix,
+		Slug:    runner.BranchSlug(t.Prompt, t.ID, changedPaths),
+		Remote:  remote,
+		Token:   t.AccessToken,
+		Reserve: s.branchReserver(ctx, pushURL, t.ID, ttl),
+	})
+}
+
+// recordBranch stores the session branch before any PR exists.
+func (s *PRService) recordBranch(ctx context.Context, t *Session, branch string) error {
+	stateKey := s.sessionService.redis.Key("session", t.ID, "state")
+	if err := s.sessionService.redis.Unwrap().HSet(ctx, stateKey, "branch", branch).Err(); err != nil {
+		return fmt.Errorf("storing session branch: %w", err)
+	}
+	s.sessionService.persistToSQLite(t.ID, func() error {
+		return s.sessionService.sqlite.UpdatePR(ctx, t.ID, branch, "", 0)
+	})
+	t.Branch = branch
+	return nil
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/freema/codeforge/internal/apperror"
+)
+
+// validateWorkspaceTemplate checks that config.workspace_template names one of
+// the configured templates, so a typo fails at creation rather than leaving
+// the session without its caches.
+func validateWorkspaceTemplate(name string, available []string) error {
+	if name == "" || slices.Contains(available, strings.ToLower(name)) {
+		return nil
+	}
+	reason := "no workspace templates are configured"
+	if len(available) > 0 {
+		reason = "unknown workspace template (available: " + strings.Join(available, ", ") + ")"
+	}
+	appErr := apperror.Validation("invalid workspace_template: %s", reason)
+	appErr.Fields = map[string]string{"config.workspace_template": reason}
+	return appErr
+}
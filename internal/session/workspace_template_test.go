@@ -0,0 +1,26 @@
+package session
+
+import "testing"
+
+func TestValidateWorkspaceTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		available []string
+		wantErr   bool
+	}{
+		{"none requested", "", nil, false},
+		{"known", "node", []string{"default", "node"}, false},
+		{"case-insensitive", "Node", []string{"node"}, false},
+		{"unknown", "rust", []string{"node"}, true},
+		{"none configured", "node", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkspaceTemplate(tt.template, tt.available)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWorkspaceTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package stats maintains rolling session aggregates in Redis. Finished
+// sessions are folded into hourly buckets as they happen, so summaries are a
+// fixed number of small reads regardless of how many sessions exist.
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/redisclient"
+)
+
+const (
+	// bucketTTL keeps hourly buckets a day past the longest window.
+	bucketTTL = 8 * 24 * time.Hour
+	// topRepos is how many repositories a window lists.
+	topRepos = 10
+)
+
+// Event describes one finished session run.
+type Event struct {
+	RepoURL      string
+	Status       string
+	Duration     time.Duration
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+	At           time.Time
+}
+
+// RepoCount is a repository with its finished-session count.
+type RepoCount struct {
+	RepoURL  string `json:"repo_url"`
+	Sessions int64  `json:"sessions"`
+}
+
+// Window aggregates the sessions finished within a time window.
+type Window struct {
+	Total              int64            `json:"total"`
+	ByStatus           map[string]int64 `json:"by_status"`
+	AvgDurationSeconds float64          `json:"avg_duration_seconds"`
+	InputTokens        int64            `json:"input_tokens"`
+	OutputTokens       int64            `json:"output_tokens"`
+	CostUSD            float64          `json:"cost_usd"`
+	TopRepos           []RepoCount      `json:"top_repos"`
+}
+
+// Summary is the response of GET /api/v1/stats.
+type Summary struct {
+	Last24h     Window    `json:"last_24h"`
+	Last7d      Window    `json:"last_7d"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Recorder writes and reads the hourly buckets.
+type Recorder struct {
+	redis *redisclient.Client
+}
+
+// NewRecorder creates a stats recorder.
+func NewRecorder(redis *redisclient.Client) *Recorder {
+	return &Recorder{redis: redis}
+}
+
+func (r *Recorder) bucketKey(hour time.Time) string {
+	return r.redis.Key("stats", "h", hour.UTC().Format("2006010215"))
+}
+
+func (r *Recorder) reposKey(hour time.Time) string {
+	return r.redis.Key("stats", "repos", hour.UTC().Format("2006010215"))
+}
+
+// Record folds a finished run into its hour's bucket.
+func (r *Recorder) Record(ctx context.Context, ev Event) error {
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	bucket := r.bucketKey(ev.At)
+	repos := r.reposKey(ev.At)
+
+	pipe := r.redis.Unwrap().TxPipeline()
+	pipe.HIncrBy(ctx, bucket, "status:"+ev.Status, 1)
+	pipe.HIncrBy(ctx, bucket, "duration_ms", ev.Duration.Milliseconds())
+	pipe.HIncrBy(ctx, bucket, "input_tokens", int64(ev.InputTokens))
+	pipe.HIncrBy(ctx, bucket, "output_tokens", int64(ev.OutputTokens))
+	if ev.CostUSD > 0 {
+		pipe.HIncrByFloat(ctx, bucket, "cost_usd", ev.CostUSD)
+	}
+	pipe.Expire(ctx, bucket, bucketTTL)
+	if ev.RepoURL != "" {
+		pipe.ZIncrBy(ctx, repos, 1, ev.RepoURL)
+		pipe.Expire(ctx, repos, bucketTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("recording stats: %w", err)
+	}
+	return nil
+}
+
+// Summary aggregates the last 24 hours and 7 days ending at now. The current
+// hour is included, so windows are up to an hour wider than their name.
+func (r *Recorder) Summary(ctx context.Context, now time.Time) (*Summary, error) {
+	const hours = 7 * 24
+	current := now.UTC().Truncate(time.Hour)
+
+	pipe := r.redis.Unwrap().Pipeline()
+	buckets := make([]*redis.MapStringStringCmd, hours)
+	repos := make([]*redis.ZSliceCmd, hours)
+	for i := 0; i < hours; i++ {
+		hour := current.Add(-time.Duration(i) * time.Hour)
+		buckets[i] = pipe.HGetAll(ctx, r.bucketKey(hour))
+		repos[i] = pipe.ZRangeWithScores(ctx, r.reposKey(hour), 0, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("reading stats: %w", err)
+	}
+
+	day, week := newAccumulator(), newAccumulator()
+	for i := 0; i < hours; i++ {
+		fields := buckets[i].Val()
+		members := repos[i].Val()
+		if i < 24 {
+			day.add(fields, members)
+		}
+		week.add(fields, members)
+	}
+	return &Summary{
+		Last24h:     day.window(),
+		Last7d:      week.window(),
+		GeneratedAt: now.UTC(),
+	}, nil
+}
+
+type accumulator struct {
+	w          Window
+	durationMs int64
+	repos      map[string]int64
+}
+
+func newAccumulator() *accumulator {
+	return &accumulator{
+		w:     Window{ByStatus: map[string]int64{}},
+		repos: map[string]int64{},
+	}
+}
+
+func (a *accumulator) add(fields map[string]string, repos []redis.Z) {
+	for k, v := range fields {
+		if status, ok := strings.CutPrefix(k, "status:"); ok {
+			n, _ := strconv.ParseInt(v, 10, 64)
+			a.w.ByStatus[status] += n
+			a.w.Total += n
+			continue
+		}
+		switch k {
+		case "duration_ms":
+			n, _ := strconv.ParseInt(v, 10, 64)
+			a.durationMs += n
+		case "input_tokens":
+			n, _ := strconv.ParseInt(v, 10, 64)
+			a.w.InputTokens += n
+		case "output_tokens":
+			n, _ := strconv.ParseInt(v, 10, 64)
+			a.w.OutputTokens += n
+		case "cost_usd":
+			f, _ := strconv.ParseFloat(v, 64)
+			a.w.CostUSD += f
+		}
+	}
+	for _, z := range repos {
+		if repo, ok := z.Member.(string); ok {
+			a.repos[repo] += int64(z.Score)
+		}
+	}
+}
+
+func (a *accumulator) window() Window {
+	w := a.w
+	if w.Total > 0 {
+		w.AvgDurationSeconds = float64(a.durationMs) / 1000 / float64(w.Total)
+	}
+	w.TopRepos = make([]RepoCount, 0, len(a.repos))
+	for repo, n := range a.repos {
+		w.TopRepos = append(w.TopRepos, RepoCount{RepoURL: repo, Sessions: n})
+	}
+	sort.Slice(w.TopRepos, func(i, j int) bool {
+		if w.TopRepos[i].Sessions != w.TopRepos[j].Sessions {
+			return w.TopRepos[i].Sessions > w.TopRepos[j].Sessions
+		}
+		return w.TopRepos[i].RepoURL < w.TopRepos[j].RepoURL
+	})
+	if len(w.TopRepos) > topRepos {
+		w.TopRepos = w.TopRepos[:topRepos]
+	}
+	return w
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+package stats
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/redisclient"
+)
+
+func TestRecorder_Summary(t *testing.T) {
+	url := os.Getenv("CODEFORGE_REDIS__URL")
+	if url == "" {
+		url = "redis://localhost:6379"
+	}
+	rdb, err := redisclient.New(url, "test:stats:")
+	if err != nil {
+		t.Skipf("skipping: redis not available: %v", err)
+	}
+	ctx := context.Background()
+	if err := rdb.Ping(ctx); err != nil {
+		rdb.Close()
+		t.Skipf("skipping: redis not reachable: %v", err)
+	}
+	t.Cleanup(func() {
+		rdb.Unwrap().FlushDB(context.Background())
+		rdb.Close()
+	})
+
+	rec := NewRecorder(rdb)
+	now := time.Now()
+	events := []Event{
+		{RepoURL: "https://github.com/acme/api", Status: "completed", Duration: 60 * time.Second, InputTokens: 100, OutputTokens: 10, CostUSD: 0.5, At: now},
+		{RepoURL: "https://github.com/acme/api", Status: "failed", Duration: 20 * time.Second, At: now.Add(-2 * time.Hour)},
+		{RepoURL: "https://github.com/acme/web", Status: "completed", Duration: 40 * time.Second, InputTokens: 50, OutputTokens: 5, CostUSD: 0.25, At: now.Add(-3 * 24 * time.Hour)},
+	}
+	for _, ev := range events {
+		if err := rec.Record(ctx, ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	s, err := rec.Summary(ctx, now)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+
+	if s.Last24h.Total != 2 || s.Last24h.ByStatus["completed"] != 1 || s.Last24h.ByStatus["failed"] != 1 {
+		t.Errorf("last_24h counts = %d %v", s.Last24h.Total, s.Last24h.ByStatus)
+	}
+	if s.Last24h.AvgDurationSeconds != 40 {
+		t.Errorf("last_24h avg duration = %v, want 40", s.Last24h.AvgDurationSeconds)
+	}
+	if s.Last7d.Total != 3 || s.Last7d.InputTokens != 150 || s.Last7d.OutputTokens != 15 || s.Last7d.CostUSD != 0.75 {
+		t.Errorf("last_7d = %+v", s.Last7d)
+	}
+	if len(s.Last7d.TopRepos) != 2 || s.Last7d.TopRepos[0].RepoURL != "https://github.com/acme/api" || s.Last7d.TopRepos[0].Sessions != 2 {
+		t.Errorf("top repos = %+v", s.Last7d.TopRepos)
+	}
+}
@@ -0,0 +1,243 @@
+// Package structured extracts a JSON result from free-text CLI output and
+// validates it against a caller-supplied schema. The schema language is a
+// subset of JSON Schema: type, properties, required, additionalProperties
+// (boolean), items and enum, plus the annotations title, description,
+// examples, default and $schema.
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxSchemaBytes bounds the size of a result schema.
+const MaxSchemaBytes = 16 << 10
+
+// ErrNoJSON is returned by Extract when the output contains no JSON value.
+var ErrNoJSON = errors.New("no JSON block found in the result")
+
+var jsonBlock = regexp.MustCompile("(?s)```json\\s*\n(.*?)\n\\s*```")
+
+var knownKeywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "enum": true,
+	"title": true, "description": true, "examples": true, "default": true, "$schema": true,
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// schema is the parsed form of the supported keywords.
+type schema struct {
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []json.RawMessage  `json:"enum"`
+}
+
+// typeList accepts "type" as a single name or a list of names.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New(`"type" must be a string or an array of strings`)
+	}
+	*t = many
+	return nil
+}
+
+// ValidateSchema checks that raw is a schema this package can enforce.
+func ValidateSchema(raw json.RawMessage) error {
+	if len(raw) > MaxSchemaBytes {
+		return fmt.Errorf("result_schema exceeds %d bytes", MaxSchemaBytes)
+	}
+	_, err := parse(raw, "")
+	return err
+}
+
+func parse(raw json.RawMessage, path string) (*schema, error) {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keywords); err != nil || keywords == nil {
+		return nil, fmt.Errorf("schema%s must be a JSON object", at(path))
+	}
+	for k := range keywords {
+		if !knownKeywords[k] {
+			return nil, fmt.Errorf("schema%s: unsupported keyword %q", at(path), k)
+		}
+	}
+	var s schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("schema%s: %v", at(path), err)
+	}
+	for _, t := range s.Type {
+		if !knownTypes[t] {
+			return nil, fmt.Errorf("schema%s: unknown type %q", at(path), t)
+		}
+	}
+	if props, ok := keywords["properties"]; ok {
+		var sub map[string]json.RawMessage
+		_ = json.Unmarshal(props, &sub)
+		for name, p := range sub {
+			if _, err := parse(p, path+"."+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if items, ok := keywords["items"]; ok {
+		if _, err := parse(items, path+"[]"); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// Extract returns the JSON value the CLI was asked to end its output with:
+// the last ```json block, or the whole output when it is JSON itself.
+func Extract(output string) (json.RawMessage, error) {
+	if matches := jsonBlock.FindAllStringSubmatch(output, -1); len(matches) > 0 {
+		block := strings.TrimSpace(matches[len(matches)-1][1])
+		if !json.Valid([]byte(block)) {
+			return nil, errors.New("the final JSON block is not valid JSON")
+		}
+		return compact(block), nil
+	}
+	if trimmed := strings.TrimSpace(output); json.Valid([]byte(trimmed)) && trimmed != "" {
+		return compact(trimmed), nil
+	}
+	return nil, ErrNoJSON
+}
+
+func compact(s string) json.RawMessage {
+	var buf bytes.Buffer
+	_ = json.Compact(&buf, []byte(s))
+	return buf.Bytes()
+}
+
+// Validate checks value against a schema accepted by ValidateSchema and
+// reports the first violation with its JSON path.
+func Validate(rawSchema, value json.RawMessage) error {
+	s, err := parse(rawSchema, "")
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.validate(v, "$")
+}
+
+func (s *schema) validate(v interface{}, path string) error {
+	if len(s.Type) > 0 && !s.matchesType(v) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), typeOf(v))
+	}
+	if len(s.Enum) > 0 && !s.inEnum(v) {
+		return fmt.Errorf("%s: value is not one of the allowed enum values", path)
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+				continue
+			}
+			if err := prop.validate(val[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *schema) matchesType(v interface{}) bool {
+	actual := typeOf(v)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schema) inEnum(v interface{}) bool {
+	got, _ := json.Marshal(v)
+	for _, e := range s.Enum {
+		var want interface{}
+		dec := json.NewDecoder(bytes.NewReader(e))
+		dec.UseNumber()
+		if dec.Decode(&want) != nil {
+			continue
+		}
+		w, _ := json.Marshal(want)
+		if bytes.Equal(got, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func at(path string) string {
+	if path == "" {
+		return ""
+	}
+	return " at " + strings.TrimPrefix(path, ".")
+}
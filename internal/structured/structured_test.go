@@ -0,0 +1,112 @@
+package structured
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["status", "files"],
+	"additionalProperties": false,
+	"properties": {
+		"status": {"type": "string", "enum": ["fixed", "not_reproducible"]},
+		"files": {"type": "array", "items": {"type": "string"}},
+		"confidence": {"type": "number"},
+		"ticket": {"type": ["string", "null"]}
+	}
+}`
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"valid", testSchema, ""},
+		{"annotations", `{"$schema": "x", "title": "t", "description": "d", "type": "object"}`, ""},
+		{"not an object", `["type"]`, "must be a JSON object"},
+		{"unsupported keyword", `{"type": "string", "pattern": "^a"}`, `unsupported keyword "pattern"`},
+		{"nested unsupported keyword", `{"properties": {"a": {"minLength": 1}}}`, "at a: unsupported keyword"},
+		{"unknown type", `{"type": "text"}`, `unknown type "text"`},
+		{"null", `null`, "must be a JSON object"},
+		{"null property", `{"properties": {"a": null}}`, "schema at a must be a JSON object"},
+		{"null items", `{"items": null}`, "schema at [] must be a JSON object"},
+		{"too large", `{"description": "` + strings.Repeat("x", MaxSchemaBytes) + `"}`, "exceeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(json.RawMessage(tt.schema))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"final block wins", "Plan:\n```json\n{\"a\": 1}\n```\nDone.\n```json\n{\"b\": [1, 2]}\n```\n", `{"b":[1,2]}`, false},
+		{"bare json", "  {\"a\": true}\n", `{"a":true}`, false},
+		{"invalid block", "```json\n{broken\n```", "", true},
+		{"no json", "All done, tests pass.", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Extract = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, err := Extract("plain text"); !errors.Is(err, ErrNoJSON) {
+		t.Errorf("err = %v, want ErrNoJSON", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"valid", `{"status": "fixed", "files": ["a.go"], "confidence": 0.9, "ticket": null}`, ""},
+		{"integer is a number", `{"status": "fixed", "files": [], "confidence": 1}`, ""},
+		{"missing required", `{"status": "fixed"}`, `$: missing required property "files"`},
+		{"wrong type", `{"status": "fixed", "files": "a.go"}`, "$.files: expected array, got string"},
+		{"item type", `{"status": "fixed", "files": ["a.go", 3]}`, "$.files[1]: expected string, got integer"},
+		{"enum", `{"status": "done", "files": []}`, "$.status: value is not one of the allowed enum values"},
+		{"additional property", `{"status": "fixed", "files": [], "extra": 1}`, `unexpected property "extra"`},
+		{"not an object", `[1]`, "$: expected object, got array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(json.RawMessage(testSchema), json.RawMessage(tt.value))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package tenant
+
+// Quota is a limit with its current usage. Unlimited quotas report usage only.
+type Quota struct {
+	Limit     int  `json:"limit"`
+	Used      int  `json:"used"`
+	Remaining int  `json:"remaining"`
+	Unlimited bool `json:"unlimited,omitempty"`
+}
+
+// NewQuota reports used against limit, or usage only when unlimited.
+func NewQuota(limit, used int, unlimited bool) Quota {
+	if unlimited {
+		return Quota{Used: used, Unlimited: true}
+	}
+	return Quota{Limit: limit, Used: used, Remaining: max(limit-used, 0)}
+}
+
+// Budget is a spending cap in USD with the amount spent against it.
+// Unlimited budgets report the spend only.
+type Budget struct {
+	LimitUSD     float64 `json:"limit_usd"`
+	SpentUSD     float64 `json:"spent_usd"`
+	RemainingUSD float64 `json:"remaining_usd"`
+	Unlimited    bool    `json:"unlimited,omitempty"`
+}
+
+// NewBudget reports spent against limit; limit <= 0 is no cap.
+func NewBudget(limit, spent float64) Budget {
+	if limit <= 0 {
+		return Budget{SpentUSD: spent, Unlimited: true}
+	}
+	return Budget{LimitUSD: limit, SpentUSD: spent, RemainingUSD: max(limit-spent, 0)}
+}
+
+// Limits are the subscription tier limits of a tenant with current usage.
+type Limits struct {
+	Tier                   string  `json:"tier"`
+	SessionsPerDay         Quota   `json:"sessions_per_day"`
+	ConcurrentSessions     Quota   `json:"concurrent_sessions"`
+	MonthlyBudget          Budget  `json:"monthly_budget"`             // calendar month, UTC
+	MaxBudgetUSDPerSession float64 `json:"max_budget_usd_per_session"` // 0 = no cap
+}
@@ -19,6 +19,7 @@ type Tenant struct {
 	MaxSessionsPerDay      int       `json:"max_sessions_per_day"`
 	MaxConcurrentSessions  int       `json:"max_concurrent_sessions"`
 	MaxBudgetUSDPerSession float64   `json:"max_budget_usd_per_session"`
+	MaxBudgetUSDPerMonth   float64   `json:"max_budget_usd_per_month"` // 0 = no cap
 	AllowedCLIs            string    `json:"allowed_clis"`
 	AllowedModels          *string   `json:"allowed_models,omitempty"`
 	CreatedAt              time.Time `json:"created_at"`
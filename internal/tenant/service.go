@@ -6,7 +6,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"time"
 
+	"github.com/freema/codeforge/internal/apperror"
 	"github.com/freema/codeforge/internal/crypto"
 )
 
@@ -60,6 +62,29 @@ func (s *Service) CreateTenant(ctx context.Context, name, slug, tier string) (*C
 	return &CreateTenantResult{Tenant: t, PlainToken: token}, nil
 }
 
+// CheckBudget rejects another run once a tenant's spend this calendar month
+// (UTC) reached its max_budget_usd_per_month (0 = no cap) with a 409
+// budget_exceeded.
+func (s *Service) CheckBudget(ctx context.Context, tenantID string) error {
+	t, err := s.store.GetTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if t.MaxBudgetUSDPerMonth <= 0 {
+		return nil
+	}
+	spent, err := s.store.MonthlyCost(ctx, tenantID, time.Now())
+	if err != nil {
+		return err
+	}
+	if spent < t.MaxBudgetUSDPerMonth {
+		return nil
+	}
+	appErr := apperror.Conflict("tenant spent $%.2f of its $%.2f monthly budget (max_budget_usd_per_month)", spent, t.MaxBudgetUSDPerMonth)
+	appErr.Code = "budget_exceeded"
+	return appErr
+}
+
 // ResolveKeyFromPool returns a decrypted API key from the key pool for the given provider.
 func (s *Service) ResolveKeyFromPool(ctx context.Context, provider string) (string, error) {
 	entry, err := s.store.GetActiveKeyForProvider(ctx, provider)
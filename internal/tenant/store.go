@@ -22,10 +22,10 @@ func NewStore(db *sql.DB) *Store {
 // CreateTenant inserts a new tenant.
 func (s *Store) CreateTenant(ctx context.Context, t *Tenant) error {
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO tenants (id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, allowed_clis, allowed_models)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO tenants (id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, max_budget_usd_per_month, allowed_clis, allowed_models)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		t.ID, t.Name, t.Slug, t.Tier, t.APITokenHash,
-		t.MaxSessionsPerDay, t.MaxConcurrentSessions, t.MaxBudgetUSDPerSession,
+		t.MaxSessionsPerDay, t.MaxConcurrentSessions, t.MaxBudgetUSDPerSession, t.MaxBudgetUSDPerMonth,
 		t.AllowedCLIs, t.AllowedModels,
 	)
 	if err != nil {
@@ -37,21 +37,21 @@ func (s *Store) CreateTenant(ctx context.Context, t *Tenant) error {
 // GetTenant returns a tenant by ID.
 func (s *Store) GetTenant(ctx context.Context, id string) (*Tenant, error) {
 	return s.scanTenant(s.db.QueryRowContext(ctx, `
-		SELECT id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, allowed_clis, allowed_models, created_at, updated_at
+		SELECT id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, max_budget_usd_per_month, allowed_clis, allowed_models, created_at, updated_at
 		FROM tenants WHERE id = ?`, id))
 }
 
 // GetTenantByTokenHash returns a tenant by its API token hash.
 func (s *Store) GetTenantByTokenHash(ctx context.Context, hash string) (*Tenant, error) {
 	return s.scanTenant(s.db.QueryRowContext(ctx, `
-		SELECT id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, allowed_clis, allowed_models, created_at, updated_at
+		SELECT id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, max_budget_usd_per_month, allowed_clis, allowed_models, created_at, updated_at
 		FROM tenants WHERE api_token_hash = ?`, hash))
 }
 
 // ListTenants returns all tenants.
 func (s *Store) ListTenants(ctx context.Context) ([]*Tenant, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, allowed_clis, allowed_models, created_at, updated_at
+		SELECT id, name, slug, tier, api_token_hash, max_sessions_per_day, max_concurrent_sessions, max_budget_usd_per_session, max_budget_usd_per_month, allowed_clis, allowed_models, created_at, updated_at
 		FROM tenants ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, fmt.Errorf("listing tenants: %w", err)
@@ -72,9 +72,9 @@ func (s *Store) ListTenants(ctx context.Context) ([]*Tenant, error) {
 // UpdateTenant updates a tenant's mutable fields.
 func (s *Store) UpdateTenant(ctx context.Context, t *Tenant) error {
 	_, err := s.db.ExecContext(ctx, `
-		UPDATE tenants SET name = ?, tier = ?, max_sessions_per_day = ?, max_concurrent_sessions = ?, max_budget_usd_per_session = ?, allowed_clis = ?, allowed_models = ?, updated_at = ?
+		UPDATE tenants SET name = ?, tier = ?, max_sessions_per_day = ?, max_concurrent_sessions = ?, max_budget_usd_per_session = ?, max_budget_usd_per_month = ?, allowed_clis = ?, allowed_models = ?, updated_at = ?
 		WHERE id = ?`,
-		t.Name, t.Tier, t.MaxSessionsPerDay, t.MaxConcurrentSessions, t.MaxBudgetUSDPerSession,
+		t.Name, t.Tier, t.MaxSessionsPerDay, t.MaxConcurrentSessions, t.MaxBudgetUSDPerSession, t.MaxBudgetUSDPerMonth,
 		t.AllowedCLIs, t.AllowedModels, time.Now().UTC().Format("2006-01-02T15:04:05.000"), t.ID,
 	)
 	if err != nil {
@@ -123,6 +123,17 @@ func (s *Store) GetUsageSummary(ctx context.Context, tenantID string, since time
 	return &summary, nil
 }
 
+// MonthlyCost returns a tenant's estimated spend since the start of now's
+// calendar month (UTC).
+func (s *Store) MonthlyCost(ctx context.Context, tenantID string, now time.Time) (float64, error) {
+	now = now.UTC()
+	summary, err := s.GetUsageSummary(ctx, tenantID, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return 0, err
+	}
+	return summary.TotalCostUSD, nil
+}
+
 // CountDailySessions returns the number of DISTINCT sessions a tenant ran today.
 // Counting distinct session_id (not rows) means a multi-turn session — which logs
 // usage once per iteration — counts as a single session against the daily quota.
@@ -260,7 +271,7 @@ func (s *Store) scanTenant(row *sql.Row) (*Tenant, error) {
 	var t Tenant
 	var createdAt, updatedAt string
 	err := row.Scan(&t.ID, &t.Name, &t.Slug, &t.Tier, &t.APITokenHash,
-		&t.MaxSessionsPerDay, &t.MaxConcurrentSessions, &t.MaxBudgetUSDPerSession,
+		&t.MaxSessionsPerDay, &t.MaxConcurrentSessions, &t.MaxBudgetUSDPerSession, &t.MaxBudgetUSDPerMonth,
 		&t.AllowedCLIs, &t.AllowedModels, &createdAt, &updatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("scanning tenant: %w", err)
@@ -274,7 +285,7 @@ func (s *Store) scanTenantRow(rows *sql.Rows) (*Tenant, error) {
 	var t Tenant
 	var createdAt, updatedAt string
 	err := rows.Scan(&t.ID, &t.Name, &t.Slug, &t.Tier, &t.APITokenHash,
-		&t.MaxSessionsPerDay, &t.MaxConcurrentSessions, &t.MaxBudgetUSDPerSession,
+		&t.MaxSessionsPerDay, &t.MaxConcurrentSessions, &t.MaxBudgetUSDPerSession, &t.MaxBudgetUSDPerMonth,
 		&t.AllowedCLIs, &t.AllowedModels, &createdAt, &updatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("scanning tenant row: %w", err)
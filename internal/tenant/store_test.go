@@ -3,10 +3,12 @@ package tenant
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 
 	_ "modernc.org/sqlite"
 
+	"github.com/freema/codeforge/internal/apperror"
 	"github.com/freema/codeforge/internal/database"
 )
 
@@ -137,3 +139,35 @@ func TestCountDailySessions_DistinctSession(t *testing.T) {
 		t.Errorf("count = %d, want 2 distinct sessions today (multi-turn counts once, yesterday excluded)", count)
 	}
 }
+
+func TestService_CheckBudget(t *testing.T) {
+	s := newTestStore(t)
+	svc := NewService(s, nil)
+	ctx := context.Background()
+	tnt := seedTenant(t, s)
+
+	if err := svc.CheckBudget(ctx, tnt.ID); err != nil {
+		t.Fatalf("no cap: %v", err)
+	}
+
+	tnt.MaxBudgetUSDPerMonth = 5
+	if err := s.UpdateTenant(ctx, tnt); err != nil {
+		t.Fatal(err)
+	}
+	for _, usd := range []float64{2, 2.5} {
+		if err := s.LogUsage(ctx, &UsageLog{TenantID: tnt.ID, SessionID: "s1", CLI: "claude-code", EstimatedCostUSD: usd}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.CheckBudget(ctx, tnt.ID); err != nil {
+		t.Fatalf("under the cap: %v", err)
+	}
+
+	if err := s.LogUsage(ctx, &UsageLog{TenantID: tnt.ID, SessionID: "s2", CLI: "claude-code", EstimatedCostUSD: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	var appErr *apperror.AppError
+	if err := svc.CheckBudget(ctx, tnt.ID); !errors.As(err, &appErr) || appErr.Status != 409 || appErr.Code != "budget_exceeded" {
+		t.Errorf("at the cap: err = %v, want 409 budget_exceeded", err)
+	}
+}
@@ -0,0 +1,186 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Access is what a session needs to do in a repository with its token.
+type Access int
+
+const (
+	AccessRead        Access = iota // clone
+	AccessPush                      // push a branch
+	AccessPullRequest               // push a branch and open a PR/MR
+)
+
+// AccessError explains why a token cannot do what a session needs in a
+// repository, e.g. "token lacks write access to acme/api".
+type AccessError struct {
+	Repo   string
+	Reason string
+}
+
+func (e *AccessError) Error() string {
+	return e.Reason
+}
+
+// GitLab role levels (project_access / group_access access_level).
+const (
+	gitlabReporter  = 20
+	gitlabDeveloper = 30
+)
+
+// CheckAccess asks the provider, before anything is cloned or pushed, whether
+// token may do need in repo: that it is valid, sees the repository, has a
+// role that allows pushing and the scopes git and the PR API require. Only a
+// definite answer is an *AccessError — provider API failures, unsupported
+// providers and tokens whose rights the API does not report are logged or
+// skipped, never a rejection. An empty token is not checked.
+func CheckAccess(ctx context.Context, repo *RepoInfo, token string, need Access) error {
+	if token == "" {
+		return nil
+	}
+	var err error
+	switch repo.Provider {
+	case ProviderGitHub:
+		err = checkGitHubAccess(ctx, repo, token, need)
+	case ProviderGitLab:
+		err = checkGitLabAccess(ctx, repo, token, need)
+	default:
+		return nil
+	}
+	var ae *AccessError
+	if err != nil && !errors.As(err, &ae) {
+		slog.Warn("token access check skipped", "repo", repo.FullName(), "error", err)
+		return nil
+	}
+	return err
+}
+
+func checkGitHubAccess(ctx context.Context, repo *RepoInfo, token string, need Access) error {
+	var r struct {
+		Private     bool `json:"private"`
+		Permissions *struct {
+			Pull bool `json:"pull"`
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s", repo.APIURL(), repo.Owner, repo.Repo)
+	header, err := providerFetch(ctx, repo, token, endpoint, &r)
+	if err != nil {
+		return accessFetchError(repo, err)
+	}
+
+	if r.Permissions != nil {
+		if !r.Permissions.Pull {
+			return lacks(repo, "read access")
+		}
+		if need >= AccessPush && !r.Permissions.Push {
+			return lacks(repo, "write access")
+		}
+	}
+
+	// Classic tokens list their scopes; fine-grained and app tokens do not.
+	if need >= AccessPush && header.Get("X-OAuth-Scopes") != "" {
+		scopes := splitScopes(header.Get("X-OAuth-Scopes"))
+		if !slices.Contains(scopes, "repo") && (r.Private || !slices.Contains(scopes, "public_repo")) {
+			return lacks(repo, "the repo scope needed to push")
+		}
+	}
+	return nil
+}
+
+func checkGitLabAccess(ctx context.Context, repo *RepoInfo, token string, need Access) error {
+	type level struct {
+		AccessLevel int `json:"access_level"`
+	}
+	var p struct {
+		Permissions struct {
+			ProjectAccess *level `json:"project_access"`
+			GroupAccess   *level `json:"group_access"`
+		} `json:"permissions"`
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s", repo.APIURL(), url.PathEscape(repo.FullName()))
+	if _, err := providerFetch(ctx, repo, token, endpoint, &p); err != nil {
+		return accessFetchError(repo, err)
+	}
+
+	// No role means none is reported (admins, inherited memberships), not
+	// that there is none.
+	role := 0
+	for _, l := range []*level{p.Permissions.ProjectAccess, p.Permissions.GroupAccess} {
+		if l != nil {
+			role = max(role, l.AccessLevel)
+		}
+	}
+	if role > 0 && role < gitlabReporter {
+		return lacks(repo, "read access", "reporter role needed to clone")
+	}
+	if need >= AccessPush && role > 0 && role < gitlabDeveloper {
+		return lacks(repo, "write access", "developer role needed to push")
+	}
+
+	// Personal, project and group access tokens report their scopes.
+	var self struct {
+		Scopes []string `json:"scopes"`
+	}
+	if err := providerGet(ctx, repo, token, repo.APIURL()+"/api/v4/personal_access_tokens/self", &self); err != nil || len(self.Scopes) == 0 {
+		return nil
+	}
+	has := func(scopes ...string) bool {
+		return slices.ContainsFunc(scopes, func(s string) bool { return slices.Contains(self.Scopes, s) })
+	}
+	switch {
+	case !has("api", "read_repository", "write_repository"):
+		return lacks(repo, "the read_repository scope needed to clone")
+	case need >= AccessPush && !has("api", "write_repository"):
+		return lacks(repo, "the write_repository scope needed to push")
+	case need >= AccessPullRequest && !has("api"):
+		return lacks(repo, "the api scope needed to open merge requests")
+	}
+	return nil
+}
+
+// accessFetchError turns the answers that settle the check into an
+// *AccessError: the token is rejected (401), or the repository is invisible
+// to it (404 — providers hide private repositories rather than refuse).
+func accessFetchError(repo *RepoInfo, err error) error {
+	if errors.Is(err, ErrBranchNotFound) {
+		return lacks(repo, "read access", "repository not found or not visible to the token")
+	}
+	var se *providerStatusError
+	if errors.As(err, &se) && se.status == http.StatusUnauthorized {
+		return &AccessError{
+			Repo:   repo.FullName(),
+			Reason: fmt.Sprintf("token is invalid or expired for %s", repo.Host),
+		}
+	}
+	return err
+}
+
+// lacks builds "token lacks <what> to owner/repo (<why>)".
+func lacks(repo *RepoInfo, what string, why ...string) *AccessError {
+	reason := fmt.Sprintf("token lacks %s to %s", what, repo.FullName())
+	if len(why) > 0 {
+		reason += " (" + strings.Join(why, ", ") + ")"
+	}
+	return &AccessError{Repo: repo.FullName(), Reason: reason}
+}
+
+// splitScopes parses a comma-separated scope header.
+func splitScopes(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
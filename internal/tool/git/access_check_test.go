@@ -0,0 +1,156 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   Provider
+		need       Access
+		handler    func(w http.ResponseWriter, r *http.Request)
+		wantReason string // "" = no rejection
+	}{
+		{
+			name:     "github writable",
+			provider: ProviderGitHub,
+			need:     AccessPullRequest,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-OAuth-Scopes", "repo, workflow")
+				_, _ = w.Write([]byte(`{"private":true,"permissions":{"pull":true,"push":true}}`))
+			},
+		},
+		{
+			name:     "github read-only collaborator",
+			provider: ProviderGitHub,
+			need:     AccessPush,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"permissions":{"pull":true,"push":false}}`))
+			},
+			wantReason: "token lacks write access to acme/api",
+		},
+		{
+			name:     "github read-only is enough to clone",
+			provider: ProviderGitHub,
+			need:     AccessRead,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"permissions":{"pull":true,"push":false}}`))
+			},
+		},
+		{
+			name:     "github classic token without repo scope",
+			provider: ProviderGitHub,
+			need:     AccessPullRequest,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-OAuth-Scopes", "public_repo, read:org")
+				_, _ = w.Write([]byte(`{"private":true,"permissions":{"pull":true,"push":true}}`))
+			},
+			wantReason: "token lacks the repo scope needed to push to acme/api",
+		},
+		{
+			name:     "github public_repo scope on a public repo",
+			provider: ProviderGitHub,
+			need:     AccessPullRequest,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-OAuth-Scopes", "public_repo")
+				_, _ = w.Write([]byte(`{"private":false,"permissions":{"pull":true,"push":true}}`))
+			},
+		},
+		{
+			name:     "github repository invisible",
+			provider: ProviderGitHub,
+			need:     AccessRead,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wantReason: "token lacks read access to acme/api",
+		},
+		{
+			name:     "github token rejected",
+			provider: ProviderGitHub,
+			need:     AccessRead,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantReason: "token is invalid or expired",
+		},
+		{
+			name:     "gitlab reporter cannot push",
+			provider: ProviderGitLab,
+			need:     AccessPush,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"permissions":{"project_access":{"access_level":20},"group_access":null}}`))
+			},
+			wantReason: "token lacks write access to acme/api (developer role needed to push)",
+		},
+		{
+			name:     "gitlab group developer without api scope",
+			provider: ProviderGitLab,
+			need:     AccessPullRequest,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/personal_access_tokens/self") {
+					_, _ = w.Write([]byte(`{"scopes":["read_repository","write_repository"]}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"permissions":{"project_access":null,"group_access":{"access_level":30}}}`))
+			},
+			wantReason: "token lacks the api scope needed to open merge requests to acme/api",
+		},
+		{
+			name:     "gitlab role not reported",
+			provider: ProviderGitLab,
+			need:     AccessPullRequest,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/personal_access_tokens/self") {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = w.Write([]byte(`{"permissions":{"project_access":null,"group_access":null}}`))
+			},
+		},
+		{
+			name:     "provider error is not a rejection",
+			provider: ProviderGitLab,
+			need:     AccessPush,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(tt.handler))
+			defer srv.Close()
+			orig := branchCheckClient
+			branchCheckClient = srv.Client
+			defer func() { branchCheckClient = orig }()
+
+			repo := &RepoInfo{Provider: tt.provider, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+			err := CheckAccess(context.Background(), repo, "tok", tt.need)
+
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var ae *AccessError
+			if !errors.As(err, &ae) || !strings.HasPrefix(ae.Reason, tt.wantReason) {
+				t.Fatalf("got %v, want AccessError %q", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckAccess_NoToken(t *testing.T) {
+	repo := &RepoInfo{Provider: ProviderGitHub, Host: "unreachable.invalid", Owner: "acme", Repo: "api"}
+	if err := CheckAccess(context.Background(), repo, "", AccessPush); err != nil {
+		t.Fatalf("CheckAccess without a token = %v, want nil", err)
+	}
+}
@@ -0,0 +1,288 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Combined state of the CI checks on a PR/MR head commit.
+const (
+	ChecksPending = "pending"
+	ChecksSuccess = "success"
+	ChecksFailure = "failure"
+)
+
+// EnableAutoMerge asks the provider to merge the PR/MR once its required
+// checks pass (GitHub auto-merge, GitLab merge when pipeline succeeds).
+// Fails when the repository does not allow it; callers fall back to polling
+// ChecksState and calling MergePR.
+func EnableAutoMerge(ctx context.Context, repo *RepoInfo, token string, prNumber int) error {
+	switch repo.Provider {
+	case ProviderGitHub:
+		return NewGitHubPRCreator().EnableAutoMerge(ctx, repo, token, prNumber)
+	case ProviderGitLab:
+		return NewGitLabMRCreator().MergeWhenPipelineSucceeds(ctx, repo, token, prNumber)
+	default:
+		return fmt.Errorf("auto-merge not supported for provider: %s", repo.Provider)
+	}
+}
+
+// ChecksState returns the combined CI state of the PR/MR head commit.
+func ChecksState(ctx context.Context, repo *RepoInfo, token string, prNumber int) (string, error) {
+	switch repo.Provider {
+	case ProviderGitHub:
+		return NewGitHubPRCreator().ChecksState(ctx, repo, token, prNumber)
+	case ProviderGitLab:
+		return NewGitLabMRCreator().PipelineState(ctx, repo, token, prNumber)
+	default:
+		return "", fmt.Errorf("checks not supported for provider: %s", repo.Provider)
+	}
+}
+
+// MergePR merges the PR/MR now.
+func MergePR(ctx context.Context, repo *RepoInfo, token string, prNumber int) error {
+	switch repo.Provider {
+	case ProviderGitHub:
+		return NewGitHubPRCreator().Merge(ctx, repo, token, prNumber)
+	case ProviderGitLab:
+		return NewGitLabMRCreator().Merge(ctx, repo, token, prNumber, false)
+	default:
+		return fmt.Errorf("merge not supported for provider: %s", repo.Provider)
+	}
+}
+
+// graphQLURL returns the GitHub GraphQL endpoint for the host.
+func graphQLURL(repo *RepoInfo) string {
+	if repo.Host == "github.com" {
+		return "https://api.github.com/graphql"
+	}
+	return "https://" + repo.Host + "/api/graphql" // GitHub Enterprise
+}
+
+// EnableAutoMerge enables auto-merge on a pull request via GraphQL.
+func (c *GitHubPRCreator) EnableAutoMerge(ctx context.Context, repo *RepoInfo, token string, prNumber int) error {
+	var pr struct {
+		NodeID string `json:"node_id"`
+	}
+	if err := c.getJSON(ctx, repo, token, fmt.Sprintf("/repos/%s/%s/pulls/%d", repo.Owner, repo.Repo, prNumber), &pr); err != nil {
+		return err
+	}
+
+	query := map[string]interface{}{
+		"query":     `mutation($id: ID!) { enablePullRequestAutoMerge(input: {pullRequestId: $id}) { clientMutationId } }`,
+		"variables": map[string]string{"id": pr.NodeID},
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fmt.Errorf("marshaling graphql request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", graphQLURL(repo), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github graphql request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github graphql returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	// GraphQL reports failures (auto-merge disabled, no required checks) with 200.
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("parsing graphql response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("enabling auto-merge: %s", result.Errors[0].Message)
+	}
+	return nil
+}
+
+// ChecksState combines commit statuses and check runs on the PR head.
+func (c *GitHubPRCreator) ChecksState(ctx context.Context, repo *RepoInfo, token string, prNumber int) (string, error) {
+	var pr struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.getJSON(ctx, repo, token, fmt.Sprintf("/repos/%s/%s/pulls/%d", repo.Owner, repo.Repo, prNumber), &pr); err != nil {
+		return "", err
+	}
+
+	var status struct {
+		State    string            `json:"state"`
+		Statuses []json.RawMessage `json:"statuses"`
+	}
+	if err := c.getJSON(ctx, repo, token, fmt.Sprintf("/repos/%s/%s/commits/%s/status", repo.Owner, repo.Repo, pr.Head.SHA), &status); err != nil {
+		return "", err
+	}
+	var runs struct {
+		CheckRuns []struct {
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+		} `json:"check_runs"`
+	}
+	if err := c.getJSON(ctx, repo, token, fmt.Sprintf("/repos/%s/%s/commits/%s/check-runs", repo.Owner, repo.Repo, pr.Head.SHA), &runs); err != nil {
+		return "", err
+	}
+
+	state := ChecksSuccess
+	// The combined status is "pending" when a commit has no statuses at all.
+	if len(status.Statuses) > 0 {
+		switch status.State {
+		case "failure", "error":
+			return ChecksFailure, nil
+		case "pending":
+			state = ChecksPending
+		}
+	}
+	for _, run := range runs.CheckRuns {
+		if run.Status != "completed" {
+			state = ChecksPending
+			continue
+		}
+		switch run.Conclusion {
+		case "failure", "timed_out", "cancelled", "action_required":
+			return ChecksFailure, nil
+		}
+	}
+	return state, nil
+}
+
+// Merge merges a pull request with the repository's default method.
+func (c *GitHubPRCreator) Merge(ctx context.Context, repo *RepoInfo, token string, prNumber int) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/merge", repo.APIURL(), repo.Owner, repo.Repo, prNumber)
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	return nil
+}
+
+func (c *GitHubPRCreator) getJSON(ctx context.Context, repo *RepoInfo, token, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", repo.APIURL()+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github API returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
+
+// MergeWhenPipelineSucceeds sets a merge request to merge once its pipeline passes.
+func (c *GitLabMRCreator) MergeWhenPipelineSucceeds(ctx context.Context, repo *RepoInfo, token string, mrIID int) error {
+	return c.Merge(ctx, repo, token, mrIID, true)
+}
+
+// Merge merges a merge request, or with whenPipelineSucceeds schedules the
+// merge for when its pipeline passes.
+func (c *GitLabMRCreator) Merge(ctx context.Context, repo *RepoInfo, token string, mrIID int, whenPipelineSucceeds bool) error {
+	projectPath := url.PathEscape(repo.FullName())
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d/merge", repo.APIURL(), projectPath, mrIID)
+
+	body, err := json.Marshal(map[string]bool{"merge_when_pipeline_succeeds": whenPipelineSucceeds})
+	if err != nil {
+		return fmt.Errorf("marshaling merge request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitlab API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	return nil
+}
+
+// PipelineState maps the merge request's head pipeline to a checks state.
+func (c *GitLabMRCreator) PipelineState(ctx context.Context, repo *RepoInfo, token string, mrIID int) (string, error) {
+	projectPath := url.PathEscape(repo.FullName())
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d", repo.APIURL(), projectPath, mrIID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gitlab API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gitlab API returned %d", resp.StatusCode)
+	}
+	var mr struct {
+		HeadPipeline *struct {
+			Status string `json:"status"`
+		} `json:"head_pipeline"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&mr); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+	if mr.HeadPipeline == nil {
+		return ChecksSuccess, nil // no CI configured
+	}
+	switch mr.HeadPipeline.Status {
+	case "success", "skipped", "manual":
+		return ChecksSuccess, nil
+	case "failed", "canceled":
+		return ChecksFailure, nil
+	default:
+		return ChecksPending, nil
+	}
+}
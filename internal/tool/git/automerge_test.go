@@ -0,0 +1,94 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubChecksState(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		runs   string
+		want   string
+	}{
+		{"no checks", `{"state":"pending","statuses":[]}`, `{"check_runs":[]}`, ChecksSuccess},
+		{"all green", `{"state":"success","statuses":[{}]}`, `{"check_runs":[{"status":"completed","conclusion":"success"}]}`, ChecksSuccess},
+		{"run in progress", `{"state":"success","statuses":[{}]}`, `{"check_runs":[{"status":"in_progress"}]}`, ChecksPending},
+		{"status pending", `{"state":"pending","statuses":[{}]}`, `{"check_runs":[]}`, ChecksPending},
+		{"run failed", `{"state":"success","statuses":[{}]}`, `{"check_runs":[{"status":"in_progress"},{"status":"completed","conclusion":"failure"}]}`, ChecksFailure},
+		{"status error", `{"state":"error","statuses":[{}]}`, `{"check_runs":[]}`, ChecksFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/pulls/7"):
+					_, _ = w.Write([]byte(`{"head":{"sha":"abc"}}`))
+				case strings.HasSuffix(r.URL.Path, "/commits/abc/status"):
+					_, _ = w.Write([]byte(tt.status))
+				case strings.HasSuffix(r.URL.Path, "/commits/abc/check-runs"):
+					_, _ = w.Write([]byte(tt.runs))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			repo := &RepoInfo{Provider: ProviderGitHub, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+			got, err := (&GitHubPRCreator{client: srv.Client()}).ChecksState(context.Background(), repo, "tok", 7)
+			if err != nil {
+				t.Fatalf("ChecksState: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitLabPipelineState(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"head_pipeline":null}`, ChecksSuccess},
+		{`{"head_pipeline":{"status":"success"}}`, ChecksSuccess},
+		{`{"head_pipeline":{"status":"running"}}`, ChecksPending},
+		{`{"head_pipeline":{"status":"failed"}}`, ChecksFailure},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(tt.body))
+		}))
+		repo := &RepoInfo{Provider: ProviderGitLab, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+		got, err := (&GitLabMRCreator{client: srv.Client()}).PipelineState(context.Background(), repo, "tok", 3)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("PipelineState(%s): %v", tt.body, err)
+		}
+		if got != tt.want {
+			t.Errorf("PipelineState(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubEnableAutoMerge_GraphQLError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/graphql" {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Auto merge is not allowed for this repository"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"node_id":"PR_1"}`))
+	}))
+	defer srv.Close()
+
+	repo := &RepoInfo{Provider: ProviderGitHub, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+	err := (&GitHubPRCreator{client: srv.Client()}).EnableAutoMerge(context.Background(), repo, "tok", 1)
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected graphql error, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/freema/codeforge/internal/httpclient"
+)
+
+// azureAPIVersion is the Azure DevOps REST API version CodeForge speaks.
+const azureAPIVersion = "7.1"
+
+// azureMaxDescription is the longest PR description Azure DevOps accepts.
+const azureMaxDescription = 4000
+
+// AzureDevOpsPRCreator creates pull requests via the Azure DevOps REST API.
+type AzureDevOpsPRCreator struct {
+	client *http.Client
+}
+
+// NewAzureDevOpsPRCreator creates an Azure DevOps PR creator.
+func NewAzureDevOpsPRCreator() *AzureDevOpsPRCreator {
+	return &AzureDevOpsPRCreator{
+		client: httpclient.New(httpclient.Provider),
+	}
+}
+
+// parseAzureRepoPath maps the path of an Azure Repos URL to the repository:
+// "org/project/_git/repo" on dev.azure.com (or "collection/project/_git/repo"
+// on Azure DevOps Server), "project/_git/repo" on org.visualstudio.com and
+// "v3/org/project/repo" for SSH. Owner is "org/project" (the organization or
+// collection and the project), Host the API host.
+func parseAzureRepoPath(host, repoPath string) (*RepoInfo, error) {
+	parts := strings.Split(repoPath, "/")
+	switch {
+	case host == "ssh.dev.azure.com" || strings.HasPrefix(host, "vs-ssh."):
+		if len(parts) != 4 || parts[0] != "v3" {
+			return nil, fmt.Errorf("cannot extract organization/project/repo from Azure DevOps SSH path: %s", repoPath)
+		}
+		host = "dev.azure.com"
+		parts = []string{parts[1], parts[2], "_git", parts[3]}
+	case strings.HasSuffix(host, ".visualstudio.com"):
+		org := strings.TrimSuffix(host, ".visualstudio.com")
+		if len(parts) > 0 && strings.EqualFold(parts[0], "DefaultCollection") {
+			parts = parts[1:]
+		}
+		host = "dev.azure.com"
+		parts = append([]string{org}, parts...)
+	}
+
+	git := -1
+	for i, p := range parts {
+		if p == "_git" {
+			git = i
+			break
+		}
+	}
+	if git < 2 || git != len(parts)-2 {
+		return nil, fmt.Errorf("cannot extract organization/project/repo from Azure DevOps path: %s", repoPath)
+	}
+	return &RepoInfo{
+		Provider: ProviderAzureDevOps,
+		Host:     host,
+		Owner:    strings.Join(parts[:git], "/"),
+		Repo:     parts[git+1],
+	}, nil
+}
+
+// azureRepoEndpoint returns the API URL of the repository resource sub
+// (e.g. "pullrequests/42"), with api-version set.
+func azureRepoEndpoint(repo *RepoInfo, sub string) string {
+	var segs []string
+	for _, s := range strings.Split(repo.Owner, "/") {
+		segs = append(segs, url.PathEscape(s))
+	}
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s/%s?api-version=%s",
+		repo.APIURL(), strings.Join(segs, "/"), url.PathEscape(repo.Repo), sub, azureAPIVersion)
+}
+
+// azureAuthorization is the header value for a personal access token:
+// Basic auth with an empty user name.
+func azureAuthorization(token string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+token))
+}
+
+// do sends an API request with a JSON body (nil = none) and decodes a
+// response with status want into out (nil = discard). repo supplies the
+// error body limit.
+func (c *AzureDevOpsPRCreator) do(ctx context.Context, repo *RepoInfo, method, endpoint, token string, body interface{}, want int, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", azureAuthorization(token))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("azure devops API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("reading azure devops response: %w", err)
+	}
+	// Rejected credentials get the sign-in page instead of a 401.
+	if resp.StatusCode == http.StatusNonAuthoritativeInfo || resp.StatusCode == http.StatusFound {
+		return fmt.Errorf("azure devops API returned %d: token rejected", resp.StatusCode)
+	}
+	if resp.StatusCode != want {
+		return fmt.Errorf("azure devops API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parsing azure devops response: %w", err)
+	}
+	return nil
+}
+
+// azurePR is the part of a pull request resource CodeForge reads.
+type azurePR struct {
+	PullRequestID int    `json:"pullRequestId"`
+	Status        string `json:"status"` // active, completed, abandoned
+	Title         string `json:"title"`
+	ClosedBy      *struct {
+		DisplayName string `json:"displayName"`
+	} `json:"closedBy"`
+	Repository struct {
+		WebURL string `json:"webUrl"`
+	} `json:"repository"`
+}
+
+// CreatePR creates a pull request in an Azure Repos repository. Pull
+// requests from forks are not supported.
+func (c *AzureDevOpsPRCreator) CreatePR(ctx context.Context, repo *RepoInfo, token string, opts PRCreateOptions) (*PRResult, error) {
+	if opts.HeadRepo != nil {
+		return nil, fmt.Errorf("pull requests from forks are not supported for Azure DevOps")
+	}
+	body := map[string]interface{}{
+		"sourceRefName": "refs/heads/" + opts.Branch,
+		"targetRefName": "refs/heads/" + opts.BaseBranch,
+		"title":         opts.Title,
+		"description":   truncateDescription(opts.Description),
+	}
+
+	var pr azurePR
+	if err := c.do(ctx, repo, http.MethodPost, azureRepoEndpoint(repo, "pullrequests"), token, body, http.StatusCreated, &pr); err != nil {
+		return nil, err
+	}
+
+	// Try to add label (best effort)
+	_ = c.do(ctx, repo, http.MethodPost, azureRepoEndpoint(repo, fmt.Sprintf("pullrequests/%d/labels", pr.PullRequestID)), token,
+		map[string]string{"name": "codeforge"}, http.StatusOK, nil)
+
+	webURL := pr.Repository.WebURL
+	if webURL == "" {
+		webURL = fmt.Sprintf("https://%s/%s/_git/%s", repo.Host, repo.Owner, repo.Repo)
+	}
+	return &PRResult{
+		URL:    fmt.Sprintf("%s/pullrequest/%d", strings.TrimRight(webURL, "/"), pr.PullRequestID),
+		Number: pr.PullRequestID,
+	}, nil
+}
+
+// UpdateDescription replaces the description of a pull request.
+func (c *AzureDevOpsPRCreator) UpdateDescription(ctx context.Context, repo *RepoInfo, token string, prNumber int, description string) error {
+	return c.do(ctx, repo, http.MethodPatch, azureRepoEndpoint(repo, fmt.Sprintf("pullrequests/%d", prNumber)), token,
+		map[string]string{"description": truncateDescription(description)}, http.StatusOK, nil)
+}
+
+// GetPRStatus fetches the current status of a pull request. Completed pull
+// requests count as merged, abandoned ones as closed.
+func (c *AzureDevOpsPRCreator) GetPRStatus(ctx context.Context, repo *RepoInfo, token string, prNumber int) (*PRStatus, error) {
+	var pr azurePR
+	if err := c.do(ctx, repo, http.MethodGet, azureRepoEndpoint(repo, fmt.Sprintf("pullrequests/%d", prNumber)), token, nil, http.StatusOK, &pr); err != nil {
+		return nil, err
+	}
+
+	status := &PRStatus{Title: pr.Title}
+	switch pr.Status {
+	case "completed":
+		status.State = "merged"
+		status.Merged = true
+		if pr.ClosedBy != nil {
+			status.MergedBy = pr.ClosedBy.DisplayName
+		}
+	case "abandoned":
+		status.State = "closed"
+	default:
+		status.State = "open"
+	}
+	return status, nil
+}
+
+// truncateDescription cuts a description to the length Azure DevOps accepts.
+func truncateDescription(s string) string {
+	r := []rune(s)
+	if len(r) <= azureMaxDescription {
+		return s
+	}
+	return string(r[:azureMaxDescription-1]) + "…"
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAzureDevOpsPRCreator(t *testing.T) {
+	var created map[string]string
+	var labeled, patched bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(":pat")) {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("api-version") != azureAPIVersion {
+			t.Errorf("api-version = %q", r.URL.Query().Get("api-version"))
+		}
+		base := "/acme/Web%20Shop/_apis/git/repositories/api/pullrequests"
+		switch {
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == base:
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"pullRequestId":7,"repository":{"webUrl":"https://dev.azure.com/acme/Web%20Shop/_git/api"}}`))
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == base+"/7/labels":
+			labeled = true
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodPatch && r.URL.EscapedPath() == base+"/7":
+			patched = true
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == base+"/7":
+			_, _ = w.Write([]byte(`{"pullRequestId":7,"status":"completed","title":"Fix","closedBy":{"displayName":"Jo"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := &AzureDevOpsPRCreator{client: srv.Client()}
+	repo := &RepoInfo{Provider: ProviderAzureDevOps, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme/Web Shop", Repo: "api"}
+	ctx := context.Background()
+
+	res, err := c.CreatePR(ctx, repo, "pat", PRCreateOptions{Title: "Fix", Description: "body", Branch: "codeforge/fix", BaseBranch: "main"})
+	if err != nil {
+		t.Fatalf("CreatePR: %v", err)
+	}
+	if res.Number != 7 || res.URL != "https://dev.azure.com/acme/Web%20Shop/_git/api/pullrequest/7" {
+		t.Errorf("CreatePR = %+v", res)
+	}
+	if created["sourceRefName"] != "refs/heads/codeforge/fix" || created["targetRefName"] != "refs/heads/main" || created["title"] != "Fix" {
+		t.Errorf("request body = %v", created)
+	}
+	if !labeled {
+		t.Error("codeforge label not added")
+	}
+
+	if err := c.UpdateDescription(ctx, repo, "pat", 7, "new"); err != nil || !patched {
+		t.Errorf("UpdateDescription = %v (patched %v)", err, patched)
+	}
+
+	st, err := c.GetPRStatus(ctx, repo, "pat", 7)
+	if err != nil {
+		t.Fatalf("GetPRStatus: %v", err)
+	}
+	if *st != (PRStatus{State: "merged", Title: "Fix", Merged: true, MergedBy: "Jo"}) {
+		t.Errorf("GetPRStatus = %+v", st)
+	}
+
+	if _, err := c.CreatePR(ctx, repo, "pat", PRCreateOptions{Branch: "b", BaseBranch: "main", HeadRepo: &RepoInfo{Owner: "bot"}}); err == nil {
+		t.Error("CreatePR from a fork: expected error")
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	long := strings.Repeat("é", azureMaxDescription+10)
+	if got := []rune(truncateDescription(long)); len(got) != azureMaxDescription {
+		t.Errorf("len = %d, want %d", len(got), azureMaxDescription)
+	}
+	if got := truncateDescription("short"); got != "short" {
+		t.Errorf("truncateDescription(short) = %q", got)
+	}
+}
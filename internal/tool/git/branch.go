@@ -18,6 +18,8 @@ type BranchOptions struct {
 	AuthorName  string
 	AuthorEmail string
 	Token       string
+	IgnoreGlobs []string // paths never staged (see LoadIgnoreGlobs)
+	Remote      string   // push remote (default origin), e.g. ForkRemote
 }
 
 // CreateBranchAndPush creates a new branch, stages all changes, commits, and pushes.
@@ -25,44 +27,51 @@ type BranchOptions struct {
 func CreateBranchAndPush(ctx context.Context, opts BranchOptions) error {
 	workDir := opts.WorkDir
 
-	// Create and checkout branch from current HEAD.
+	// Create and checkout branch from current HEAD, unless the session has
+	// worked on it since the clone (CheckoutWorkBranch) or pushed it before.
 	// The branch is based on whatever was cloned — the MR/PR target branch
 	// is specified separately in the API call, not via git ancestry.
-	if err := gitCmd(ctx, workDir, nil, "checkout", "-b", opts.BranchName); err != nil {
-		return fmt.Errorf("creating branch: %w", err)
+	onBranch := CurrentBranch(ctx, workDir) == opts.BranchName
+	if !onBranch {
+		if err := gitCmd(ctx, workDir, nil, "checkout", "-b", opts.BranchName); err != nil {
+			return fmt.Errorf("creating branch: %w", err)
+		}
+		slog.Info("branch created", "branch", opts.BranchName)
 	}
-	slog.Info("branch created", "branch", opts.BranchName)
 
 	// Remove generated files that must not be committed
 	for _, f := range []string{".mcp.json"} {
 		os.Remove(workDir + "/" + f) // best-effort, ignore errors
 	}
 
-	// Stage all changes
-	if err := gitCmd(ctx, workDir, nil, "add", "-A"); err != nil {
-		return fmt.Errorf("staging changes: %w", err)
+	// Stage all changes except ignored paths
+	if err := stageChanges(ctx, workDir, opts.IgnoreGlobs); err != nil {
+		return err
 	}
 
 	// Check if there's anything to commit
-	statusOut, err := gitOutput(ctx, workDir, "status", "--porcelain")
+	staged, err := hasStagedChanges(ctx, workDir)
 	if err != nil {
 		return fmt.Errorf("checking status: %w", err)
 	}
-	if strings.TrimSpace(statusOut) == "" {
+	// A branch worked on since the clone may already hold every commit.
+	if !staged && !onBranch {
 		return fmt.Errorf("nothing to commit")
 	}
 
-	// Commit with author info
-	commitEnv := []string{
-		"GIT_AUTHOR_NAME=" + opts.AuthorName,
-		"GIT_AUTHOR_EMAIL=" + opts.AuthorEmail,
-		"GIT_COMMITTER_NAME=" + opts.AuthorName,
-		"GIT_COMMITTER_EMAIL=" + opts.AuthorEmail,
-	}
-	if err := gitCmd(ctx, workDir, commitEnv, "commit", "-m", opts.CommitMsg); err != nil {
-		return fmt.Errorf("committing changes: %w", err)
+	if staged {
+		// Commit with author info
+		commitEnv := []string{
+			"GIT_AUTHOR_NAME=" + opts.AuthorName,
+			"GIT_AUTHOR_EMAIL=" + opts.AuthorEmail,
+			"GIT_COMMITTER_NAME=" + opts.AuthorName,
+			"GIT_COMMITTER_EMAIL=" + opts.AuthorEmail,
+		}
+		if err := gitCmd(ctx, workDir, commitEnv, "commit", "-m", opts.CommitMsg); err != nil {
+			return fmt.Errorf("committing changes: %w", err)
+		}
+		slog.Info("changes committed", "branch", opts.BranchName)
 	}
-	slog.Info("changes committed", "branch", opts.BranchName)
 
 	// Push via GIT_ASKPASS
 	pushEnv, cleanup, err := AskPassEnv(opts.Token)
@@ -71,7 +80,7 @@ func CreateBranchAndPush(ctx context.Context, opts BranchOptions) error {
 	}
 	defer cleanup()
 
-	if err := gitCmd(ctx, workDir, pushEnv, "push", "-u", "origin", opts.BranchName); err != nil {
+	if err := pushBranch(ctx, workDir, pushEnv, remoteOrOrigin(opts.Remote), opts.BranchName, true); err != nil {
 		return fmt.Errorf("pushing branch: %w", err)
 	}
 	slog.Info("branch pushed", "branch", opts.BranchName)
@@ -79,6 +88,60 @@ func CreateBranchAndPush(ctx context.Context, opts BranchOptions) error {
 	return nil
 }
 
+// CheckoutWorkBranch switches the workspace to branch right after the clone,
+// so every iteration commits to it. A branch already on remote (the workspace
+// was re-cloned after the branch was pushed) is checked out from there;
+// otherwise the branch starts at the current HEAD.
+func CheckoutWorkBranch(ctx context.Context, workDir, remote, branch, token string) error {
+	env, cleanup, err := AskPassEnv(token)
+	if err != nil {
+		return fmt.Errorf("preparing fetch credentials: %w", err)
+	}
+	defer cleanup()
+
+	remote = remoteOrOrigin(remote)
+	tracking := "refs/remotes/" + remote + "/" + branch
+	if gitCmd(ctx, workDir, env, "fetch", "-q", remote, "+refs/heads/"+branch+":"+tracking) == nil {
+		if err := gitCmd(ctx, workDir, nil, "checkout", "-q", "-B", branch, tracking); err != nil {
+			return fmt.Errorf("checking out branch: %w", err)
+		}
+		return nil
+	}
+	if err := gitCmd(ctx, workDir, nil, "checkout", "-q", "-b", branch); err != nil {
+		return fmt.Errorf("creating branch: %w", err)
+	}
+	return nil
+}
+
+// CurrentBranch returns the branch checked out in workDir, "" on a detached
+// HEAD or error.
+func CurrentBranch(ctx context.Context, workDir string) string {
+	out, err := gitOutput(ctx, workDir, "symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// HasCommitsAhead reports whether HEAD holds commits that base on origin does
+// not, after fetching base. FETCH_HEAD is compared rather than origin/<base>,
+// which a single-branch clone of another branch never tracks.
+func HasCommitsAhead(ctx context.Context, workDir, base, token string) (bool, error) {
+	env, cleanup, err := AskPassEnv(token)
+	if err != nil {
+		return false, fmt.Errorf("preparing fetch credentials: %w", err)
+	}
+	defer cleanup()
+	if err := gitCmd(ctx, workDir, env, "fetch", "-q", "origin", base); err != nil {
+		return false, fmt.Errorf("fetching %s: %w", base, err)
+	}
+	out, err := gitOutput(ctx, workDir, "rev-list", "--count", "FETCH_HEAD..HEAD")
+	if err != nil {
+		return false, fmt.Errorf("counting commits ahead of %s: %w", base, err)
+	}
+	return strings.TrimSpace(out) != "0", nil
+}
+
 // AskPassEnv prepares GIT_ASKPASS environment for authenticated git operations.
 // Returns extra env vars and a cleanup function.
 func AskPassEnv(token string) ([]string, func(), error) {
@@ -128,14 +191,29 @@ func gitOutput(ctx context.Context, workDir string, args ...string) (string, err
 	return string(out), nil
 }
 
-// GenerateBranchName creates a branch name with prefix and slug, adding numeric suffix if needed.
-func GenerateBranchName(ctx context.Context, workDir, prefix, slug string) string {
-	base := prefix + slug
+// BranchNameOptions configures GenerateBranchName.
+type BranchNameOptions struct {
+	WorkDir string
+	Prefix  string
+	Slug    string
+	Remote  string // remote asked via ls-remote (default origin)
+	Token   string // for ls-remote on private repositories
+	// Reserve claims a free name so a concurrent session cannot pick it too;
+	// it returns false when the name is already claimed. Optional.
+	Reserve func(name string) bool
+}
+
+// GenerateBranchName creates a branch name with prefix and slug, adding a
+// numeric suffix if needed. A name is taken when it exists locally, on the
+// remote — asked via ls-remote, since the clone's refs may be stale or
+// shallow — or is reserved by another session.
+func GenerateBranchName(ctx context.Context, opts BranchNameOptions) string {
+	base := opts.Prefix + opts.Slug
+	remote := remoteBranchNames(ctx, opts, base)
 	name := base
 
-	// Check if branch exists locally or in remote refs
 	for i := 1; i <= 99; i++ {
-		if !branchExists(ctx, workDir, name) {
+		if !branchExists(ctx, opts.WorkDir, name) && !remote[name] && (opts.Reserve == nil || opts.Reserve(name)) {
 			return name
 		}
 		name = fmt.Sprintf("%s-%d", base, i)
@@ -144,9 +222,34 @@ func GenerateBranchName(ctx context.Context, workDir, prefix, slug string) strin
 	return name
 }
 
-// DefaultBranch detects the default branch of the cloned repository
-// by reading the symbolic-ref of origin/HEAD.
-func DefaultBranch(ctx context.Context, workDir string) (string, error) {
+// remoteBranchNames lists the remote branches named base or base-*. On
+// failure it returns nil and only local refs are consulted.
+func remoteBranchNames(ctx context.Context, opts BranchNameOptions, base string) map[string]bool {
+	env, cleanup, err := AskPassEnv(opts.Token)
+	if err != nil {
+		return nil
+	}
+	defer cleanup()
+
+	out, err := gitOutputEnv(ctx, opts.WorkDir, env, "ls-remote", "--heads", remoteOrOrigin(opts.Remote), "refs/heads/"+base, "refs/heads/"+base+"-*")
+	if err != nil {
+		slog.Warn("listing remote branches failed, using local refs", "error", err)
+		return nil
+	}
+	names := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if _, ref, ok := strings.Cut(line, "\t"); ok {
+			names[strings.TrimPrefix(ref, "refs/heads/")] = true
+		}
+	}
+	return names
+}
+
+// DefaultBranch detects the default branch of the cloned repository: the
+// symbolic-ref of origin/HEAD (set by clone), else the remote's HEAD asked via
+// ls-remote — shallow and --branch clones may not record origin/HEAD, and
+// the checked-out branch is no hint once the session branch exists.
+func DefaultBranch(ctx context.Context, workDir, token string) (string, error) {
 	// Try symbolic-ref first (set by clone)
 	out, err := gitOutput(ctx, workDir, "symbolic-ref", "refs/remotes/origin/HEAD")
 	if err == nil {
@@ -156,17 +259,44 @@ func DefaultBranch(ctx context.Context, workDir string) (string, error) {
 			return strings.TrimPrefix(ref, "refs/remotes/origin/"), nil
 		}
 	}
-	// Fallback: check current branch name (what was cloned)
-	out, err = gitOutput(ctx, workDir, "rev-parse", "--abbrev-ref", "HEAD")
+	// Fallback: ask the remote. Output: "ref: refs/heads/master\tHEAD"
+	env, cleanup, err := AskPassEnv(token)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	out, err = gitOutputEnv(ctx, workDir, env, "ls-remote", "--symref", "origin", "HEAD")
 	if err == nil {
-		branch := strings.TrimSpace(out)
-		if branch != "" && branch != "HEAD" {
-			return branch, nil
+		for _, line := range strings.Split(out, "\n") {
+			if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+				if branch, _, _ := strings.Cut(ref, "\t"); branch != "" {
+					return branch, nil
+				}
+			}
 		}
 	}
 	return "", fmt.Errorf("could not detect default branch")
 }
 
+// ResolveDefaultBranch returns the branch PRs and rebases target when no
+// target_branch is given: DefaultBranch of the workspace, else the provider
+// API (repo may be nil). "main" is only the last resort.
+func ResolveDefaultBranch(ctx context.Context, workDir string, repo *RepoInfo, token string) string {
+	if workDir != "" {
+		if branch, err := DefaultBranch(ctx, workDir, token); err == nil {
+			return branch
+		}
+	}
+	if repo != nil {
+		branch, err := GetDefaultBranch(ctx, repo, token)
+		if err == nil && branch != "" {
+			return branch
+		}
+		slog.Warn("default branch detection failed, assuming main", "repo", repo.FullName(), "error", err)
+	}
+	return "main"
+}
+
 func branchExists(ctx context.Context, workDir, name string) bool {
 	// Check local
 	err := gitCmd(ctx, workDir, nil, "rev-parse", "--verify", name)
@@ -178,9 +308,27 @@ func branchExists(ctx context.Context, workDir, name string) bool {
 	return err == nil
 }
 
-// GetUnstagedDiff returns the diff of all uncommitted changes in the workspace.
-func GetUnstagedDiff(ctx context.Context, workDir string) (string, error) {
-	return gitOutput(ctx, workDir, "diff", "HEAD")
+// GetUnstagedDiff returns the diff of all uncommitted changes in the
+// workspace, leaving out paths matching ignoreGlobs.
+func GetUnstagedDiff(ctx context.Context, workDir string, ignoreGlobs ...string) (string, error) {
+	return gitOutput(ctx, workDir, withPathspecs([]string{"diff", "HEAD"}, ignorePathspecs(ignoreGlobs))...)
+}
+
+// stageChanges stages every change in the workspace except ignored paths.
+func stageChanges(ctx context.Context, workDir string, ignoreGlobs []string) error {
+	if err := gitCmd(ctx, workDir, nil, withPathspecs([]string{"add", "-A"}, ignorePathspecs(ignoreGlobs))...); err != nil {
+		return fmt.Errorf("staging changes: %w", err)
+	}
+	return nil
+}
+
+// hasStagedChanges reports whether the index differs from HEAD.
+func hasStagedChanges(ctx context.Context, workDir string) (bool, error) {
+	out, err := gitOutput(ctx, workDir, "diff", "--cached", "--name-only")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
 }
 
 // PushExistingOptions configures pushing follow-up changes to an existing branch.
@@ -191,6 +339,8 @@ type PushExistingOptions struct {
 	AuthorName  string
 	AuthorEmail string
 	Token       string
+	IgnoreGlobs []string // paths never staged (see LoadIgnoreGlobs)
+	Remote      string   // push remote (default origin), e.g. ForkRemote
 }
 
 // CommitAndPushToExisting stages all changes, commits, and pushes to an existing branch.
@@ -198,17 +348,29 @@ type PushExistingOptions struct {
 func CommitAndPushToExisting(ctx context.Context, opts PushExistingOptions) error {
 	workDir := opts.WorkDir
 
-	// Stage all changes
-	if err := gitCmd(ctx, workDir, nil, "add", "-A"); err != nil {
-		return fmt.Errorf("staging changes: %w", err)
+	pushEnv, cleanup, err := AskPassEnv(opts.Token)
+	if err != nil {
+		return fmt.Errorf("preparing push credentials: %w", err)
+	}
+	defer cleanup()
+
+	// Refuse before committing when the remote branch moved, so a retry
+	// after resolving the divergence still has the changes to commit.
+	if _, err := checkRemoteBranch(ctx, workDir, pushEnv, remoteOrOrigin(opts.Remote), opts.BranchName); err != nil {
+		return err
+	}
+
+	// Stage all changes except ignored paths
+	if err := stageChanges(ctx, workDir, opts.IgnoreGlobs); err != nil {
+		return err
 	}
 
 	// Check if there are any changes to commit
-	statusOut, err := gitOutput(ctx, workDir, "status", "--porcelain")
+	staged, err := hasStagedChanges(ctx, workDir)
 	if err != nil {
 		return fmt.Errorf("checking status: %w", err)
 	}
-	if strings.TrimSpace(statusOut) == "" {
+	if !staged {
 		return fmt.Errorf("no new changes to push")
 	}
 
@@ -224,14 +386,7 @@ func CommitAndPushToExisting(ctx context.Context, opts PushExistingOptions) erro
 	}
 	slog.Info("follow-up changes committed", "branch", opts.BranchName)
 
-	// Push via GIT_ASKPASS
-	pushEnv, cleanup, err := AskPassEnv(opts.Token)
-	if err != nil {
-		return fmt.Errorf("preparing push credentials: %w", err)
-	}
-	defer cleanup()
-
-	if err := gitCmd(ctx, workDir, pushEnv, "push", "origin", opts.BranchName); err != nil {
+	if err := pushBranch(ctx, workDir, pushEnv, remoteOrOrigin(opts.Remote), opts.BranchName, false); err != nil {
 		return fmt.Errorf("pushing to branch: %w", err)
 	}
 	slog.Info("follow-up changes pushed", "branch", opts.BranchName)
@@ -0,0 +1,252 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/freema/codeforge/internal/httpclient"
+)
+
+// maxBranchSuggestions bounds the branch names offered when a base branch is missing.
+const maxBranchSuggestions = 10
+
+// branchCheckClient returns the client for branch lookups; replaced in tests.
+var branchCheckClient = func() *http.Client { return httpclient.New(httpclient.Provider) }
+
+// ErrBranchNotFound is returned by GetBranch for a branch the provider does not know.
+var ErrBranchNotFound = errors.New("branch not found")
+
+// BranchInfo describes a branch on the provider.
+type BranchInfo struct {
+	Name      string
+	Protected bool
+}
+
+// PushTargetError explains why a PR cannot be pushed to or opened against
+// the requested branches. Suggestions lists existing branches (the default
+// branch first) when the base branch is missing.
+type PushTargetError struct {
+	Field       string // "target_branch" or "branch"
+	Branch      string
+	Reason      string
+	Suggestions []string
+}
+
+func (e *PushTargetError) Error() string {
+	return fmt.Sprintf("%s %q: %s", e.Field, e.Branch, e.Reason)
+}
+
+// ValidatePushTarget checks, before anything is pushed, that base exists on
+// the provider and that no protection rule forbids creating head in headRepo
+// (the fork the branch goes to; nil = repo). Provider API failures are
+// logged and treated as "unknown", never as a rejection.
+func ValidatePushTarget(ctx context.Context, repo, headRepo *RepoInfo, token, base, head string) error {
+	if _, err := GetBranch(ctx, repo, token, base); err != nil {
+		if !errors.Is(err, ErrBranchNotFound) {
+			slog.Warn("base branch check skipped", "repo", repo.FullName(), "branch", base, "error", err)
+		} else {
+			return &PushTargetError{
+				Field:       "target_branch",
+				Branch:      base,
+				Reason:      "does not exist in " + repo.FullName(),
+				Suggestions: branchSuggestions(ctx, repo, token),
+			}
+		}
+	}
+
+	if headRepo == nil {
+		headRepo = repo
+	}
+	reason, err := creationRestriction(ctx, headRepo, token, head)
+	if err != nil {
+		slog.Warn("branch protection check skipped", "repo", headRepo.FullName(), "branch", head, "error", err)
+		return nil
+	}
+	if reason != "" {
+		return &PushTargetError{Field: "branch", Branch: head, Reason: reason}
+	}
+	return nil
+}
+
+// GetBranch looks up a branch on the provider.
+func GetBranch(ctx context.Context, repo *RepoInfo, token, name string) (*BranchInfo, error) {
+	var endpoint string
+	switch repo.Provider {
+	case ProviderGitHub:
+		endpoint = fmt.Sprintf("%s/repos/%s/%s/branches/%s", repo.APIURL(), repo.Owner, repo.Repo, url.PathEscape(name))
+	case ProviderGitLab:
+		endpoint = fmt.Sprintf("%s/api/v4/projects/%s/repository/branches/%s", repo.APIURL(), url.PathEscape(repo.FullName()), url.PathEscape(name))
+	default:
+		return nil, fmt.Errorf("branch lookup not supported for provider: %s", repo.Provider)
+	}
+
+	var b struct {
+		Name      string `json:"name"`
+		Protected bool   `json:"protected"`
+	}
+	if err := providerGet(ctx, repo, token, endpoint, &b); err != nil {
+		return nil, err
+	}
+	return &BranchInfo{Name: b.Name, Protected: b.Protected}, nil
+}
+
+// GetDefaultBranch asks the provider for the repository's default branch.
+func GetDefaultBranch(ctx context.Context, repo *RepoInfo, token string) (string, error) {
+	var endpoint string
+	switch repo.Provider {
+	case ProviderGitHub:
+		endpoint = fmt.Sprintf("%s/repos/%s/%s", repo.APIURL(), repo.Owner, repo.Repo)
+	case ProviderGitLab:
+		endpoint = fmt.Sprintf("%s/api/v4/projects/%s", repo.APIURL(), url.PathEscape(repo.FullName()))
+	default:
+		return "", fmt.Errorf("default branch lookup not supported for provider: %s", repo.Provider)
+	}
+
+	var r struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := providerGet(ctx, repo, token, endpoint, &r); err != nil {
+		return "", err
+	}
+	return r.DefaultBranch, nil
+}
+
+// creationRestriction returns why the provider would refuse a push creating
+// branch name, or "" when nothing forbids it.
+func creationRestriction(ctx context.Context, repo *RepoInfo, token, name string) (string, error) {
+	switch repo.Provider {
+	case ProviderGitHub:
+		// Rulesets that apply to the branch, whether or not it exists yet.
+		var rules []struct {
+			Type string `json:"type"`
+		}
+		endpoint := fmt.Sprintf("%s/repos/%s/%s/rules/branches/%s", repo.APIURL(), repo.Owner, repo.Repo, url.PathEscape(name))
+		if err := providerGet(ctx, repo, token, endpoint, &rules); err != nil {
+			if errors.Is(err, ErrBranchNotFound) {
+				return "", nil // rulesets unsupported (older GitHub Enterprise)
+			}
+			return "", err
+		}
+		for _, r := range rules {
+			if r.Type == "creation" {
+				return "a repository ruleset restricts creating this branch", nil
+			}
+		}
+		return "", nil
+	case ProviderGitLab:
+		var protected []struct {
+			Name             string `json:"name"`
+			PushAccessLevels []struct {
+				AccessLevel int `json:"access_level"`
+			} `json:"push_access_levels"`
+		}
+		endpoint := fmt.Sprintf("%s/api/v4/projects/%s/protected_branches?per_page=100", repo.APIURL(), url.PathEscape(repo.FullName()))
+		if err := providerGet(ctx, repo, token, endpoint, &protected); err != nil {
+			return "", err
+		}
+		for _, p := range protected {
+			if ok, _ := path.Match(p.Name, name); !ok {
+				continue
+			}
+			noOne := len(p.PushAccessLevels) > 0
+			for _, l := range p.PushAccessLevels {
+				if l.AccessLevel != 0 {
+					noOne = false
+				}
+			}
+			if noOne {
+				return fmt.Sprintf("protected branch rule %q allows no one to push", p.Name), nil
+			}
+		}
+		return "", nil
+	default:
+		return "", nil
+	}
+}
+
+// branchSuggestions lists existing branches, the default branch first.
+func branchSuggestions(ctx context.Context, repo *RepoInfo, token string) []string {
+	baseURL := ""
+	if repo.Host != "github.com" {
+		baseURL = "https://" + repo.Host
+	}
+	branches, err := ListBranches(ctx, repo.Provider, token, baseURL, repo.FullName(), repo.ErrorMaxBytes)
+	if err != nil {
+		slog.Warn("listing branches for suggestions failed", "repo", repo.FullName(), "error", err)
+		return nil
+	}
+
+	out := make([]string, 0, maxBranchSuggestions)
+	for _, b := range branches {
+		if b.Default {
+			out = append(out, b.Name)
+		}
+	}
+	for _, b := range branches {
+		if len(out) == maxBranchSuggestions {
+			break
+		}
+		if !b.Default {
+			out = append(out, b.Name)
+		}
+	}
+	return out
+}
+
+// providerStatusError is a provider API response other than 200 or 404.
+type providerStatusError struct {
+	provider Provider
+	status   int
+	body     []byte
+	maxBytes int // body kept in Error, see truncateBytes
+}
+
+func (e *providerStatusError) Error() string {
+	return fmt.Sprintf("%s API returned %d: %s", e.provider, e.status, truncateBytes(e.body, e.maxBytes))
+}
+
+// providerGet fetches a provider API resource into out; 404 maps to ErrBranchNotFound.
+func providerGet(ctx context.Context, repo *RepoInfo, token, endpoint string, out interface{}) error {
+	_, err := providerFetch(ctx, repo, token, endpoint, out)
+	return err
+}
+
+// providerFetch is providerGet that also returns the response headers.
+func providerFetch(ctx context.Context, repo *RepoInfo, token, endpoint string, out interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if repo.Provider == ProviderGitLab {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	}
+
+	resp, err := branchCheckClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s API request: %w", repo.Provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.Header, ErrBranchNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		return resp.Header, &providerStatusError{provider: repo.Provider, status: resp.StatusCode, body: body, maxBytes: repo.ErrorMaxBytes}
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return resp.Header, fmt.Errorf("parsing response: %w", err)
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatePushTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  Provider
+		handler   func(w http.ResponseWriter, r *http.Request)
+		wantField string
+	}{
+		{
+			name:     "github ok",
+			provider: ProviderGitHub,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/rules/branches/") {
+					_, _ = w.Write([]byte(`[{"type":"pull_request"}]`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"name":"main","protected":true}`))
+			},
+		},
+		{
+			name:     "github base missing",
+			provider: ProviderGitHub,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wantField: "target_branch",
+		},
+		{
+			name:     "github creation ruleset",
+			provider: ProviderGitHub,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/rules/branches/") {
+					_, _ = w.Write([]byte(`[{"type":"creation"}]`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"name":"main"}`))
+			},
+			wantField: "branch",
+		},
+		{
+			name:     "gitlab no one may push",
+			provider: ProviderGitLab,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/protected_branches") {
+					_, _ = w.Write([]byte(`[{"name":"codeforge/*","push_access_levels":[{"access_level":0}]}]`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"name":"main"}`))
+			},
+			wantField: "branch",
+		},
+		{
+			name:     "provider error is not a rejection",
+			provider: ProviderGitLab,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(tt.handler))
+			defer srv.Close()
+			orig := branchCheckClient
+			branchCheckClient = srv.Client
+			defer func() { branchCheckClient = orig }()
+
+			repo := &RepoInfo{Provider: tt.provider, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+			err := ValidatePushTarget(context.Background(), repo, nil, "tok", "main", "codeforge/fix-1")
+
+			var pte *PushTargetError
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &pte) || pte.Field != tt.wantField {
+				t.Fatalf("got %v, want PushTargetError on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestGetDefaultBranch(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		wantPath string
+	}{
+		{ProviderGitHub, "/api/v3/repos/acme/api"},
+		{ProviderGitLab, "/api/v4/projects/acme/api"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			var gotPath string
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				_, _ = w.Write([]byte(`{"default_branch":"master"}`))
+			}))
+			defer srv.Close()
+			orig := branchCheckClient
+			branchCheckClient = srv.Client
+			defer func() { branchCheckClient = orig }()
+
+			repo := &RepoInfo{Provider: tt.provider, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+			got, err := GetDefaultBranch(context.Background(), repo, "tok")
+			if err != nil {
+				t.Fatalf("GetDefaultBranch: %v", err)
+			}
+			if got != "master" || gotPath != tt.wantPath {
+				t.Errorf("got %q from %s, want master from %s", got, gotPath, tt.wantPath)
+			}
+		})
+	}
+}
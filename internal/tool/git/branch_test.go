@@ -0,0 +1,148 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateBranchName(t *testing.T) {
+	ctx := context.Background()
+	work, other := initRemoteClone(t)
+	// Pushed by someone else after the workspace was cloned: not in its refs.
+	other("push", "-q", "origin", "main:refs/heads/codeforge/fix", "main:refs/heads/codeforge/fix-1", "main:refs/heads/codeforge/fixes")
+
+	tests := []struct {
+		name     string
+		slug     string
+		reserved map[string]bool
+		want     string
+	}{
+		{"free", "new-thing", nil, "codeforge/new-thing"},
+		{"local branch", "feature", nil, "codeforge/feature-1"},
+		{"branches only on the remote", "fix", nil, "codeforge/fix-2"},
+		{"reserved by another session", "fix", map[string]bool{"codeforge/fix-2": true}, "codeforge/fix-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := BranchNameOptions{WorkDir: work, Prefix: "codeforge/", Slug: tt.slug}
+			if tt.reserved != nil {
+				opts.Reserve = func(name string) bool { return !tt.reserved[name] }
+			}
+			if got := GenerateBranchName(ctx, opts); got != tt.want {
+				t.Errorf("GenerateBranchName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	ctx := context.Background()
+	work, other := initRemoteClone(t)
+	origin := filepath.Join(filepath.Dir(work), "origin.git")
+	other("push", "-q", "origin", "main:refs/heads/master")
+	if err := gitCmd(ctx, origin, nil, "symbolic-ref", "HEAD", "refs/heads/master"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The clone recorded origin/HEAD as main.
+	if got, err := DefaultBranch(ctx, work, ""); err != nil || got != "main" {
+		t.Errorf("from origin/HEAD: got %q, %v", got, err)
+	}
+
+	// Without origin/HEAD (shallow or --branch clones) the remote is asked.
+	if err := gitCmd(ctx, work, nil, "remote", "set-head", "origin", "-d"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DefaultBranch(ctx, work, ""); err != nil || got != "master" {
+		t.Errorf("from ls-remote: got %q, %v", got, err)
+	}
+	if got := ResolveDefaultBranch(ctx, work, nil, ""); got != "master" {
+		t.Errorf("ResolveDefaultBranch = %q, want master", got)
+	}
+}
+
+func TestCheckoutWorkBranch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("new branch from HEAD", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		head, _ := revParse(ctx, work, "HEAD")
+		if err := CheckoutWorkBranch(ctx, work, "", "codeforge/new", ""); err != nil {
+			t.Fatalf("CheckoutWorkBranch: %v", err)
+		}
+		if got := CurrentBranch(ctx, work); got != "codeforge/new" {
+			t.Errorf("current branch = %q", got)
+		}
+		if got, _ := revParse(ctx, work, "HEAD"); got != head {
+			t.Errorf("HEAD moved to %s, want %s", got, head)
+		}
+	})
+
+	t.Run("pushed branch from the remote", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		other("push", "-q", "origin", "main:refs/heads/codeforge/pushed")
+		if err := CheckoutWorkBranch(ctx, work, "", "codeforge/pushed", ""); err != nil {
+			t.Fatalf("CheckoutWorkBranch: %v", err)
+		}
+		want, _ := revParse(ctx, work, "refs/remotes/origin/main")
+		if got, _ := revParse(ctx, work, "HEAD"); got != want {
+			t.Errorf("HEAD = %s, want the remote branch %s", got, want)
+		}
+	})
+
+	t.Run("CreateBranchAndPush keeps the work branch", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		if err := CheckoutWorkBranch(ctx, work, "", "codeforge/work", ""); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, work, "more.go", "package main\n")
+		err := CreateBranchAndPush(ctx, BranchOptions{WorkDir: work, BranchName: "codeforge/work", CommitMsg: "work", AuthorName: "cf", AuthorEmail: "cf@x"})
+		if err != nil {
+			t.Fatalf("CreateBranchAndPush: %v", err)
+		}
+		head, _ := revParse(ctx, work, "HEAD")
+		if got, _ := revParse(ctx, work, "refs/remotes/origin/codeforge/work"); got != head {
+			t.Errorf("remote branch %s, want %s", got, head)
+		}
+
+		// Everything already committed on the branch: push, don't fail.
+		writeFile(t, work, "later.go", "package main\n")
+		if err := gitCmd(ctx, work, nil, "add", "later.go"); err != nil {
+			t.Fatal(err)
+		}
+		if err := gitCmd(ctx, work, []string{"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t"}, "commit", "-q", "-m", "by the CLI"); err != nil {
+			t.Fatal(err)
+		}
+		err = CreateBranchAndPush(ctx, BranchOptions{WorkDir: work, BranchName: "codeforge/work", CommitMsg: "work", AuthorName: "cf", AuthorEmail: "cf@x"})
+		if err != nil {
+			t.Fatalf("CreateBranchAndPush without staged changes: %v", err)
+		}
+		head, _ = revParse(ctx, work, "HEAD")
+		if got, _ := revParse(ctx, work, "refs/remotes/origin/codeforge/work"); got != head {
+			t.Errorf("remote branch %s, want %s", got, head)
+		}
+	})
+}
+
+func TestHasCommitsAhead(t *testing.T) {
+	ctx := context.Background()
+	work, _ := initRemoteClone(t)
+
+	ahead, err := HasCommitsAhead(ctx, work, "main", "")
+	if err != nil || !ahead {
+		t.Fatalf("feature branch: ahead = %v, err = %v", ahead, err)
+	}
+
+	if err := gitCmd(ctx, work, nil, "checkout", "-q", "-b", "codeforge/empty", "origin/main"); err != nil {
+		t.Fatal(err)
+	}
+	ahead, err = HasCommitsAhead(ctx, work, "main", "")
+	if err != nil || ahead {
+		t.Errorf("branch at base: ahead = %v, err = %v", ahead, err)
+	}
+
+	if _, err := HasCommitsAhead(ctx, work, "no-such-branch", ""); err == nil {
+		t.Error("expected an error for a missing base")
+	}
+}
@@ -0,0 +1,143 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Checkpoint stages. Each iteration is bracketed by a "before" snapshot taken
+// right before the CLI runs and an "after" snapshot taken once it finishes.
+const (
+	CheckpointBefore = "before"
+	CheckpointAfter  = "after"
+)
+
+// checkpointExcludes are generated files kept out of snapshots (they may
+// carry credentials and never belong to the user's change).
+var checkpointExcludes = []string{":(exclude).mcp.json", ":(exclude).cursor/cli.json"}
+
+// CheckpointRef returns the private ref holding an iteration snapshot.
+// Refs live outside refs/heads, so they are never pushed with the branch.
+func CheckpointRef(iteration int, stage string) string {
+	return fmt.Sprintf("refs/codeforge/iterations/%d/%s", iteration, stage)
+}
+
+// Checkpoint snapshots the full working tree (tracked, modified and untracked
+// files, respecting .gitignore) into a commit stored under ref. It uses a
+// throwaway index, so HEAD, the real index and the working tree are untouched.
+func Checkpoint(ctx context.Context, workDir, ref string) (string, error) {
+	gitDir, err := gitOutput(ctx, workDir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("locating git dir: %w", err)
+	}
+	indexFile := filepath.Join(strings.TrimSpace(gitDir), "codeforge-checkpoint.index")
+	defer os.Remove(indexFile)
+
+	env := []string{
+		"GIT_INDEX_FILE=" + indexFile,
+		"GIT_AUTHOR_NAME=CodeForge",
+		"GIT_AUTHOR_EMAIL=codeforge@noreply",
+		"GIT_COMMITTER_NAME=CodeForge",
+		"GIT_COMMITTER_EMAIL=codeforge@noreply",
+	}
+
+	parent, headErr := gitOutput(ctx, workDir, "rev-parse", "--verify", "-q", "HEAD")
+	parent = strings.TrimSpace(parent)
+	if headErr == nil && parent != "" {
+		// Seed from HEAD so only the delta has to be hashed.
+		if err := gitCmd(ctx, workDir, env, "read-tree", parent); err != nil {
+			return "", fmt.Errorf("seeding checkpoint index: %w", err)
+		}
+	}
+
+	addArgs := append([]string{"add", "-A", "--", "."}, checkpointExcludes...)
+	if err := gitCmd(ctx, workDir, env, addArgs...); err != nil {
+		return "", fmt.Errorf("staging checkpoint: %w", err)
+	}
+	tree, err := gitOutputEnv(ctx, workDir, env, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("writing checkpoint tree: %w", err)
+	}
+
+	commitArgs := []string{"commit-tree", strings.TrimSpace(tree), "-m", "codeforge checkpoint " + ref}
+	if parent != "" {
+		commitArgs = append(commitArgs, "-p", parent)
+	}
+	sha, err := gitOutputEnv(ctx, workDir, env, commitArgs...)
+	if err != nil {
+		return "", fmt.Errorf("committing checkpoint: %w", err)
+	}
+	sha = strings.TrimSpace(sha)
+
+	if err := gitCmd(ctx, workDir, nil, "update-ref", ref, sha); err != nil {
+		return "", fmt.Errorf("storing checkpoint ref: %w", err)
+	}
+	return sha, nil
+}
+
+// CheckpointExists reports whether ref resolves to a commit in workDir.
+func CheckpointExists(ctx context.Context, workDir, ref string) bool {
+	return gitCmd(ctx, workDir, nil, "rev-parse", "--verify", "-q", ref+"^{commit}") == nil
+}
+
+// DeleteCheckpoint removes a checkpoint ref; a missing ref is not an error.
+func DeleteCheckpoint(ctx context.Context, workDir, ref string) error {
+	if !CheckpointExists(ctx, workDir, ref) {
+		return nil
+	}
+	if err := gitCmd(ctx, workDir, nil, "update-ref", "-d", ref); err != nil {
+		return fmt.Errorf("deleting checkpoint %s: %w", ref, err)
+	}
+	return nil
+}
+
+// DiffCheckpoints returns the unified diff between two checkpoint refs.
+func DiffCheckpoints(ctx context.Context, workDir, fromRef, toRef string) (string, error) {
+	out, err := gitOutput(ctx, workDir, "diff", "--no-color", "--no-ext-diff", fromRef, toRef)
+	if err != nil {
+		return "", fmt.Errorf("git diff %s..%s: %w", fromRef, toRef, err)
+	}
+	return out, nil
+}
+
+// RevertCheckpoint undoes the change between two checkpoints in the working
+// tree by applying the reverse of their diff. Only the working tree is touched;
+// the result shows up as ordinary uncommitted changes. It fails without
+// modifying anything when later edits overlap the reverted hunks.
+func RevertCheckpoint(ctx context.Context, workDir, fromRef, toRef string) error {
+	patch, err := gitOutput(ctx, workDir, "diff", "--binary", "--no-color", "--no-ext-diff", fromRef, toRef)
+	if err != nil {
+		return fmt.Errorf("git diff %s..%s: %w", fromRef, toRef, err)
+	}
+	if patch == "" {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "apply", "-R", "--whitespace=nowarn", "-")
+	cmd.Dir = workDir
+	cmd.Stdin = strings.NewReader(patch)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git apply -R: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// gitOutputEnv runs a git command with extra env vars and returns stdout.
+func gitOutputEnv(ctx context.Context, workDir string, extraEnv []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), extraEnv...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,186 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initTestRepo(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	writeFile(t, dir, "main.go", "package main\n")
+	run("add", "-A")
+	run("commit", "-q", "-m", "init")
+	return dir, run
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckpoint_DiffIsolatesIteration(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	ctx := context.Background()
+
+	// Iteration 1 modifies a tracked file and adds an untracked one.
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(1, CheckpointBefore)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "pkg/util.go", "package pkg\n")
+	writeFile(t, dir, ".mcp.json", `{"secret": "x"}`)
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(1, CheckpointAfter)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	// Iteration 2 touches only a new file.
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(2, CheckpointBefore)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	writeFile(t, dir, "README.md", "hello\n")
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(2, CheckpointAfter)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	diff1, err := DiffCheckpoints(ctx, dir, CheckpointRef(1, CheckpointBefore), CheckpointRef(1, CheckpointAfter))
+	if err != nil {
+		t.Fatalf("DiffCheckpoints: %v", err)
+	}
+	for _, want := range []string{"main.go", "pkg/util.go"} {
+		if !strings.Contains(diff1, want) {
+			t.Errorf("iteration 1 diff missing %s:\n%s", want, diff1)
+		}
+	}
+	if strings.Contains(diff1, "README.md") || strings.Contains(diff1, ".mcp.json") {
+		t.Errorf("iteration 1 diff leaks unrelated files:\n%s", diff1)
+	}
+
+	diff2, err := DiffCheckpoints(ctx, dir, CheckpointRef(2, CheckpointBefore), CheckpointRef(2, CheckpointAfter))
+	if err != nil {
+		t.Fatalf("DiffCheckpoints: %v", err)
+	}
+	if !strings.Contains(diff2, "README.md") || strings.Contains(diff2, "main.go") {
+		t.Errorf("iteration 2 diff should only contain README.md:\n%s", diff2)
+	}
+
+	// Snapshots must not disturb the user's index or HEAD.
+	status, _ := gitOutput(ctx, dir, "status", "--porcelain")
+	if !strings.Contains(status, " M main.go") || !strings.Contains(status, "?? README.md") {
+		t.Errorf("working tree state changed by checkpoint:\n%s", status)
+	}
+}
+
+func TestCheckpointExists(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	ctx := context.Background()
+
+	ref := CheckpointRef(3, CheckpointAfter)
+	if CheckpointExists(ctx, dir, ref) {
+		t.Fatal("checkpoint should not exist yet")
+	}
+	if _, err := Checkpoint(ctx, dir, ref); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if !CheckpointExists(ctx, dir, ref) {
+		t.Error("checkpoint should exist")
+	}
+}
+
+func TestDeleteCheckpoint(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	ctx := context.Background()
+
+	ref := CheckpointRef(2, CheckpointBefore)
+	if _, err := Checkpoint(ctx, dir, ref); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if err := DeleteCheckpoint(ctx, dir, ref); err != nil {
+		t.Fatalf("DeleteCheckpoint: %v", err)
+	}
+	if CheckpointExists(ctx, dir, ref) {
+		t.Error("checkpoint should be gone")
+	}
+	if err := DeleteCheckpoint(ctx, dir, ref); err != nil {
+		t.Errorf("deleting a missing checkpoint: %v", err)
+	}
+}
+
+func TestRevertCheckpoint(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	ctx := context.Background()
+
+	// Iteration 1 edits main.go and adds a file; iteration 2 adds README.md.
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(1, CheckpointBefore)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "pkg/util.go", "package pkg\n")
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(1, CheckpointAfter)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	writeFile(t, dir, "README.md", "hello\n")
+
+	if err := RevertCheckpoint(ctx, dir, CheckpointRef(1, CheckpointBefore), CheckpointRef(1, CheckpointAfter)); err != nil {
+		t.Fatalf("RevertCheckpoint: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if string(data) != "package main\n" {
+		t.Errorf("main.go not restored: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg/util.go")); !os.IsNotExist(err) {
+		t.Error("pkg/util.go should be removed by revert")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); err != nil {
+		t.Error("README.md from a later change should survive the revert")
+	}
+}
+
+func TestRevertCheckpoint_Conflict(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	ctx := context.Background()
+
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(1, CheckpointBefore)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	if _, err := Checkpoint(ctx, dir, CheckpointRef(1, CheckpointAfter)); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	// A later change rewrites the same lines.
+	writeFile(t, dir, "main.go", "package other\n")
+
+	if err := RevertCheckpoint(ctx, dir, CheckpointRef(1, CheckpointBefore), CheckpointRef(1, CheckpointAfter)); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if string(data) != "package other\n" {
+		t.Errorf("failed revert must leave the tree untouched, got %q", data)
+	}
+}
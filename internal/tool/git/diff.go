@@ -19,9 +19,12 @@ type ChangesSummary struct {
 
 // CalculateChanges computes a summary of workspace changes after CLI execution.
 // It runs git status and git diff --shortstat (both staged and unstaged).
-func CalculateChanges(ctx context.Context, workDir string) (*ChangesSummary, error) {
+// Paths matching ignoreGlobs are left out of the counts.
+func CalculateChanges(ctx context.Context, workDir string, ignoreGlobs ...string) (*ChangesSummary, error) {
+	specs := ignorePathspecs(ignoreGlobs)
+
 	// git status --porcelain for file counts
-	statusCmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
+	statusCmd := exec.CommandContext(ctx, "git", withPathspecs([]string{"status", "--porcelain"}, specs)...)
 	statusCmd.Dir = workDir
 	statusOut, err := statusCmd.Output()
 	if err != nil {
@@ -49,10 +52,10 @@ func CalculateChanges(ctx context.Context, workDir string) (*ChangesSummary, err
 	}
 
 	// git diff --shortstat for unstaged changes
-	unstagedIns, unstagedDel := shortStat(ctx, workDir, false)
+	unstagedIns, unstagedDel := shortStat(ctx, workDir, false, specs)
 
 	// git diff --cached --shortstat for staged changes
-	stagedIns, stagedDel := shortStat(ctx, workDir, true)
+	stagedIns, stagedDel := shortStat(ctx, workDir, true, specs)
 
 	diffStats := fmt.Sprintf("+%d -%d", unstagedIns+stagedIns, unstagedDel+stagedDel)
 
@@ -66,13 +69,13 @@ func CalculateChanges(ctx context.Context, workDir string) (*ChangesSummary, err
 
 var shortStatRegex = regexp.MustCompile(`(\d+) insertions?\(\+\).*?(\d+) deletions?\(-\)|(\d+) insertions?\(\+\)|(\d+) deletions?\(-\)`)
 
-func shortStat(ctx context.Context, workDir string, cached bool) (insertions, deletions int) {
+func shortStat(ctx context.Context, workDir string, cached bool, specs []string) (insertions, deletions int) {
 	args := []string{"diff", "--shortstat"}
 	if cached {
 		args = []string{"diff", "--cached", "--shortstat"}
 	}
 
-	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd := exec.CommandContext(ctx, "git", withPathspecs(args, specs)...)
 	cmd.Dir = workDir
 	out, err := cmd.Output()
 	if err != nil {
@@ -114,3 +117,37 @@ func parseShortStat(s string) (insertions, deletions int) {
 
 	return 0, 0
 }
+
+// ChangedFiles lists the paths touched in the workspace (staged, unstaged and
+// untracked), as reported by git status. Untracked directories are expanded to
+// their files; renames and copies report the new path. Paths matching
+// ignoreGlobs are skipped.
+func ChangedFiles(ctx context.Context, workDir string, ignoreGlobs ...string) ([]string, error) {
+	args := withPathspecs([]string{"status", "--porcelain", "-z", "--untracked-files=all"}, ignorePathspecs(ignoreGlobs))
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+	return parsePorcelainPaths(string(out)), nil
+}
+
+// parsePorcelainPaths extracts file paths from `git status --porcelain -z`
+// output. Records are NUL-separated and paths are not quoted; a rename or copy
+// entry is followed by a separate record holding the source path.
+func parsePorcelainPaths(s string) []string {
+	var paths []string
+	records := strings.Split(s, "\x00")
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		if len(rec) < 4 {
+			continue
+		}
+		paths = append(paths, rec[3:])
+		if rec[0] == 'R' || rec[0] == 'C' {
+			i++ // skip the source path record
+		}
+	}
+	return paths
+}
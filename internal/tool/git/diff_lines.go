@@ -10,7 +10,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
 )
 
 // DiffLineSet maps filename to the set of valid new-file line numbers in the PR diff.
@@ -33,9 +34,10 @@ type prFile struct {
 
 // FetchPRDiffLines fetches PR files from the GitHub API and returns
 // the set of valid new-file line numbers per file (lines in diff hunks).
-func FetchPRDiffLines(ctx context.Context, client *http.Client, apiURL, owner, repo, token string, prNumber int) (DiffLineSet, error) {
+// errMax caps the error body kept in errors, see APIConfig.ErrorMaxBytes.
+func FetchPRDiffLines(ctx context.Context, client *http.Client, apiURL, owner, repo, token string, prNumber, errMax int) (DiffLineSet, error) {
 	if client == nil {
-		client = &http.Client{Timeout: 30 * time.Second}
+		client = httpclient.New(httpclient.Review)
 	}
 
 	result := make(DiffLineSet)
@@ -65,7 +67,7 @@ func FetchPRDiffLines(ctx context.Context, client *http.Client, apiURL, owner, r
 		}
 
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("PR files API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+			return nil, fmt.Errorf("PR files API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 		}
 
 		var files []prFile
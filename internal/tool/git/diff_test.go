@@ -1,6 +1,14 @@
 package git
 
-import "testing"
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
 
 func TestParseShortStat(t *testing.T) {
 	tests := []struct {
@@ -23,3 +31,51 @@ func TestParseShortStat(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePorcelainPaths(t *testing.T) {
+	input := " M internal/server/server.go\x00?? docs/new.md\x00R  new/name -> x.go\x00old/name.go\x00D  with space.txt\x00?? docs/café.md\x00"
+	got := parsePorcelainPaths(input)
+	want := []string{"internal/server/server.go", "docs/new.md", "new/name -> x.go", "with space.txt", "docs/café.md"}
+
+	if len(got) != len(want) {
+		t.Fatalf("parsePorcelainPaths returned %d paths, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("path[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestChangedFiles_UntrackedDirAndNonASCII(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	if err := os.MkdirAll(filepath.Join(dir, "pkg", "new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pkg/new/a.go", "pkg/new/b.go", "café.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ChangedFiles(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	sort.Strings(got)
+	want := []string{"café.md", "pkg/new/a.go", "pkg/new/b.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ChangedFiles = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// DirectCommitPolicy bounds the changes that may be committed straight to the
+// target branch instead of going through a PR.
+type DirectCommitPolicy struct {
+	Paths    []string // globs any change may touch, matched like .codeforgeignore patterns
+	MaxLines int      // ceiling on added plus deleted lines (0 = no ceiling)
+}
+
+// CheckTrivialChange stages the workspace changes (except ignoreGlobs) and
+// returns why the difference to base — the target branch, so commits made in
+// the workspace count too — does not qualify for a direct commit under
+// policy, or "". A change qualifies when every file either matches
+// policy.Paths or changes whitespace only, and the whole diff stays within
+// policy.MaxLines.
+func CheckTrivialChange(ctx context.Context, workDir, base string, ignoreGlobs []string, policy DirectCommitPolicy) (string, error) {
+	if _, err := revParse(ctx, workDir, base); err != nil {
+		return fmt.Sprintf("the workspace is not based on %s", base), nil
+	}
+	if err := stageChanges(ctx, workDir, ignoreGlobs); err != nil {
+		return "", err
+	}
+
+	stats, err := gitOutput(ctx, workDir, "diff", "--cached", "--no-renames", "--numstat", base)
+	if err != nil {
+		return "", fmt.Errorf("reading staged diff: %w", err)
+	}
+	lines, files := 0, 0
+	for _, row := range strings.Split(strings.TrimSpace(stats), "\n") {
+		fields := strings.SplitN(row, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		files++
+		added, _ := strconv.Atoi(fields[0]) // "-" for binary files
+		deleted, _ := strconv.Atoi(fields[1])
+		lines += added + deleted
+	}
+	if files == 0 {
+		return "", fmt.Errorf("nothing to commit")
+	}
+	if policy.MaxLines > 0 && lines > policy.MaxLines {
+		return fmt.Sprintf("%d changed lines exceed the limit of %d", lines, policy.MaxLines), nil
+	}
+
+	// -w leaves out files whose changes are whitespace only (formatting).
+	args := withPathspecs([]string{"diff", "--cached", "--no-renames", "-w", "--numstat", base}, ignorePathspecs(policy.Paths))
+	substantive, err := gitOutput(ctx, workDir, args...)
+	if err != nil {
+		return "", fmt.Errorf("reading staged diff: %w", err)
+	}
+	for _, row := range strings.Split(strings.TrimSpace(substantive), "\n") {
+		if fields := strings.SplitN(row, "\t", 3); len(fields) == 3 {
+			return fmt.Sprintf("%s is outside the allowed paths and changes more than whitespace", fields[2]), nil
+		}
+	}
+	return "", nil
+}
+
+// CommitAndPushDirect commits the workspace changes and pushes them, with any
+// commits made in the workspace, to opts.BranchName — the target branch
+// itself — returning the pushed commit.
+// Unlike a session branch, the target is never force-pushed: when it moved
+// past the workspace a *DivergedError is returned before anything is committed.
+func CommitAndPushDirect(ctx context.Context, opts PushExistingOptions) (string, error) {
+	workDir := opts.WorkDir
+
+	pushEnv, cleanup, err := AskPassEnv(opts.Token)
+	if err != nil {
+		return "", fmt.Errorf("preparing push credentials: %w", err)
+	}
+	defer cleanup()
+
+	remote := remoteOrOrigin(opts.Remote)
+	lease, err := checkRemoteBranch(ctx, workDir, pushEnv, remote, opts.BranchName)
+	if err != nil {
+		return "", err
+	}
+	if lease != "" {
+		head, _ := revParse(ctx, workDir, "HEAD")
+		return "", &DivergedError{Branch: opts.BranchName, RemoteSHA: lease, LocalSHA: head}
+	}
+
+	if err := stageChanges(ctx, workDir, opts.IgnoreGlobs); err != nil {
+		return "", err
+	}
+	staged, err := hasStagedChanges(ctx, workDir)
+	if err != nil {
+		return "", fmt.Errorf("checking status: %w", err)
+	}
+	if staged {
+		commitEnv := []string{
+			"GIT_AUTHOR_NAME=" + opts.AuthorName,
+			"GIT_AUTHOR_EMAIL=" + opts.AuthorEmail,
+			"GIT_COMMITTER_NAME=" + opts.AuthorName,
+			"GIT_COMMITTER_EMAIL=" + opts.AuthorEmail,
+		}
+		if err := gitCmd(ctx, workDir, commitEnv, "commit", "-m", opts.CommitMsg); err != nil {
+			return "", fmt.Errorf("committing changes: %w", err)
+		}
+	}
+	sha, _ := revParse(ctx, workDir, "HEAD")
+	if tip, _ := revParse(ctx, workDir, "refs/remotes/"+remote+"/"+opts.BranchName); tip == sha {
+		return "", fmt.Errorf("nothing to commit")
+	}
+
+	if err := gitCmd(ctx, workDir, pushEnv, "push", remote, "HEAD:refs/heads/"+opts.BranchName); err != nil {
+		// Leave the workspace as it was so the changes can still go into a PR.
+		if staged {
+			_ = gitCmd(ctx, workDir, nil, "reset", "-q", "--soft", "HEAD~1")
+		}
+		switch {
+		case isRejectedPush(err):
+			return "", &DivergedError{Branch: opts.BranchName, LocalSHA: sha}
+		case strings.Contains(err.Error(), "protected"):
+			return "", &PushTargetError{Field: "branch", Branch: opts.BranchName, Reason: "protected; the provider refused the direct push"}
+		}
+		return "", fmt.Errorf("pushing to %s: %w", opts.BranchName, err)
+	}
+	_ = gitCmd(ctx, workDir, nil, "update-ref", "refs/remotes/"+remote+"/"+opts.BranchName, "HEAD")
+	slog.Info("changes committed directly", "branch", opts.BranchName, "commit", sha)
+	return sha, nil
+}
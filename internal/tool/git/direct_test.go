@@ -0,0 +1,93 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckTrivialChange(t *testing.T) {
+	ctx := context.Background()
+	policy := DirectCommitPolicy{Paths: []string{"*.md", "docs"}, MaxLines: 5}
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		reason string // substring of the reason, "" = trivial
+	}{
+		{"docs only", map[string]string{"README.md": "hi\n", "docs/guide/setup.txt": "steps\n"}, ""},
+		{"whitespace only", map[string]string{"main.go": "package main\n\nfunc main()  {}\n"}, ""},
+		{"code change", map[string]string{"README.md": "hi\n", "util.go": "package main\n\nfunc util() { panic(1) }\n"}, "util.go is outside the allowed paths"},
+		{"too large", map[string]string{"README.md": "1\n2\n3\n4\n5\n6\n"}, "exceed the limit of 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			work, _ := initRemoteClone(t)
+			for name, content := range tt.files {
+				writeFile(t, work, name, content)
+			}
+			reason, err := CheckTrivialChange(ctx, work, "HEAD", nil, policy)
+			if err != nil {
+				t.Fatalf("CheckTrivialChange: %v", err)
+			}
+			if tt.reason == "" && reason != "" || tt.reason != "" && !strings.Contains(reason, tt.reason) {
+				t.Errorf("reason = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCheckTrivialChange_CommittedChanges(t *testing.T) {
+	// The workspace commit on top of main changes main.go: not trivial
+	// against main, even with nothing left uncommitted.
+	work, _ := initRemoteClone(t)
+	writeFile(t, work, "README.md", "hi\n")
+	reason, err := CheckTrivialChange(context.Background(), work, "refs/remotes/origin/main", nil, DirectCommitPolicy{Paths: []string{"*.md"}})
+	if err != nil {
+		t.Fatalf("CheckTrivialChange: %v", err)
+	}
+	if !strings.Contains(reason, "main.go is outside the allowed paths") {
+		t.Errorf("reason = %q", reason)
+	}
+}
+
+func TestCommitAndPushDirect(t *testing.T) {
+	ctx := context.Background()
+	opts := func(dir string) PushExistingOptions {
+		return PushExistingOptions{WorkDir: dir, BranchName: "main", CommitMsg: "docs: fix typo", AuthorName: "cf", AuthorEmail: "cf@x"}
+	}
+
+	t.Run("fast-forward", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		writeFile(t, work, "README.md", "hi\n")
+		sha, err := CommitAndPushDirect(ctx, opts(work))
+		if err != nil {
+			t.Fatalf("CommitAndPushDirect: %v", err)
+		}
+		origin := filepath.Join(filepath.Dir(work), "origin.git")
+		if got, _ := revParse(ctx, origin, "refs/heads/main"); got != sha {
+			t.Errorf("origin main = %s, want %s", got, sha)
+		}
+	})
+
+	t.Run("target moved", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		writeFile(t, filepath.Join(filepath.Dir(work), "other"), "CHANGELOG.md", "v2\n")
+		other("add", "-A")
+		other("commit", "-q", "-m", "changelog")
+		other("push", "-q", "origin", "main")
+
+		before, _ := revParse(ctx, work, "HEAD")
+		writeFile(t, work, "README.md", "hi\n")
+		_, err := CommitAndPushDirect(ctx, opts(work))
+		var de *DivergedError
+		if !errors.As(err, &de) {
+			t.Fatalf("expected DivergedError, got %v", err)
+		}
+		if after, _ := revParse(ctx, work, "HEAD"); after != before {
+			t.Error("nothing should be committed when the target moved")
+		}
+	})
+}
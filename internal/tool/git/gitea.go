@@ -0,0 +1,176 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/freema/codeforge/internal/httpclient"
+)
+
+// giteaLabelColor is the color of the "codeforge" label when CodeForge has to
+// create it in a repository.
+const giteaLabelColor = "#7057ff"
+
+// GiteaPRCreator creates pull requests via the Gitea API. Forgejo (and
+// Codeberg) speak the same API.
+type GiteaPRCreator struct {
+	client *http.Client
+}
+
+// NewGiteaPRCreator creates a Gitea PR creator.
+func NewGiteaPRCreator() *GiteaPRCreator {
+	return &GiteaPRCreator{
+		client: httpclient.New(httpclient.Provider),
+	}
+}
+
+// giteaRepoEndpoint returns the API URL of the repository resource sub
+// (e.g. "pulls/42").
+func giteaRepoEndpoint(repo *RepoInfo, sub string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/%s", repo.APIURL(), url.PathEscape(repo.Owner), url.PathEscape(repo.Repo), sub)
+}
+
+// do sends an API request with a JSON body (nil = none) and decodes a 2xx
+// response into out (nil = discard). Gitea answers some updates with 201.
+// repo supplies the error body limit.
+func (c *GiteaPRCreator) do(ctx context.Context, repo *RepoInfo, method, endpoint, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitea API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("reading gitea response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("gitea API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parsing gitea response: %w", err)
+	}
+	return nil
+}
+
+// CreatePR creates a pull request on Gitea.
+func (c *GiteaPRCreator) CreatePR(ctx context.Context, repo *RepoInfo, token string, opts PRCreateOptions) (*PRResult, error) {
+	head := opts.Branch
+	if opts.HeadRepo != nil {
+		head = opts.HeadRepo.Owner + ":" + opts.Branch // cross-repository PR from a fork
+	}
+	body := map[string]interface{}{
+		"title": opts.Title,
+		"body":  opts.Description,
+		"head":  head,
+		"base":  opts.BaseBranch,
+	}
+
+	var result struct {
+		HTMLURL string `json:"html_url"`
+		Number  int    `json:"number"`
+	}
+	if err := c.do(ctx, repo, http.MethodPost, giteaRepoEndpoint(repo, "pulls"), token, body, &result); err != nil {
+		return nil, err
+	}
+
+	// Try to add label (best effort)
+	c.addLabel(ctx, repo, token, result.Number)
+
+	return &PRResult{
+		URL:    result.HTMLURL,
+		Number: result.Number,
+	}, nil
+}
+
+// addLabel puts the "codeforge" label on a pull request. Gitea labels issues
+// by ID, so the label is looked up and created in the repository if missing.
+func (c *GiteaPRCreator) addLabel(ctx context.Context, repo *RepoInfo, token string, prNumber int) {
+	type label struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	var labels []label
+	if err := c.do(ctx, repo, http.MethodGet, giteaRepoEndpoint(repo, "labels?limit=50"), token, nil, &labels); err != nil {
+		return
+	}
+	var id int64
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, "codeforge") {
+			id = l.ID
+			break
+		}
+	}
+	if id == 0 {
+		var created label
+		if err := c.do(ctx, repo, http.MethodPost, giteaRepoEndpoint(repo, "labels"), token,
+			map[string]string{"name": "codeforge", "color": giteaLabelColor}, &created); err != nil {
+			return
+		}
+		id = created.ID
+	}
+	_ = c.do(ctx, repo, http.MethodPost, giteaRepoEndpoint(repo, fmt.Sprintf("issues/%d/labels", prNumber)), token,
+		map[string][]int64{"labels": {id}}, nil)
+}
+
+// UpdateDescription replaces the body of a pull request.
+func (c *GiteaPRCreator) UpdateDescription(ctx context.Context, repo *RepoInfo, token string, prNumber int, description string) error {
+	return c.do(ctx, repo, http.MethodPatch, giteaRepoEndpoint(repo, fmt.Sprintf("pulls/%d", prNumber)), token,
+		map[string]string{"body": description}, nil)
+}
+
+// GetPRStatus fetches the current status of a pull request.
+func (c *GiteaPRCreator) GetPRStatus(ctx context.Context, repo *RepoInfo, token string, prNumber int) (*PRStatus, error) {
+	var pr struct {
+		State    string `json:"state"`
+		Title    string `json:"title"`
+		Merged   bool   `json:"merged"`
+		MergedBy *struct {
+			Login string `json:"login"`
+		} `json:"merged_by"`
+	}
+	if err := c.do(ctx, repo, http.MethodGet, giteaRepoEndpoint(repo, fmt.Sprintf("pulls/%d", prNumber)), token, nil, &pr); err != nil {
+		return nil, err
+	}
+
+	state := pr.State // "open" or "closed"
+	if pr.Merged {
+		state = "merged"
+	}
+	status := &PRStatus{
+		State:  state,
+		Title:  pr.Title,
+		Merged: pr.Merged,
+	}
+	if pr.MergedBy != nil {
+		status.MergedBy = pr.MergedBy.Login
+	}
+	return status, nil
+}
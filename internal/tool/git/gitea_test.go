@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGiteaPRCreator(t *testing.T) {
+	var created map[string]string
+	var labeled []int64
+	var createdLabel, patched bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token tok" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		base := "/api/v1/repos/acme/api"
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == base+"/pulls":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number":3,"html_url":"https://git.example.com/acme/api/pulls/3"}`))
+		case r.Method == http.MethodGet && r.URL.Path == base+"/labels":
+			_, _ = w.Write([]byte(`[{"id":1,"name":"bug"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == base+"/labels":
+			createdLabel = true
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":9,"name":"codeforge"}`))
+		case r.Method == http.MethodPost && r.URL.Path == base+"/issues/3/labels":
+			var body struct {
+				Labels []int64 `json:"labels"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			labeled = body.Labels
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == http.MethodPatch && r.URL.Path == base+"/pulls/3":
+			patched = true
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.Path == base+"/pulls/3":
+			_, _ = w.Write([]byte(`{"state":"closed","title":"Fix","merged":true,"merged_by":{"login":"jo"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := &GiteaPRCreator{client: srv.Client()}
+	repo := &RepoInfo{Provider: ProviderGitea, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+	ctx := context.Background()
+
+	res, err := c.CreatePR(ctx, repo, "tok", PRCreateOptions{
+		Title: "Fix", Description: "body", Branch: "codeforge/fix", BaseBranch: "main",
+		HeadRepo: &RepoInfo{Owner: "bot", Repo: "api"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePR: %v", err)
+	}
+	if res.Number != 3 || res.URL != "https://git.example.com/acme/api/pulls/3" {
+		t.Errorf("CreatePR = %+v", res)
+	}
+	if created["head"] != "bot:codeforge/fix" || created["base"] != "main" || created["body"] != "body" {
+		t.Errorf("request body = %v", created)
+	}
+	if !createdLabel || len(labeled) != 1 || labeled[0] != 9 {
+		t.Errorf("label: created %v, added %v", createdLabel, labeled)
+	}
+
+	if err := c.UpdateDescription(ctx, repo, "tok", 3, "new"); err != nil || !patched {
+		t.Errorf("UpdateDescription = %v (patched %v)", err, patched)
+	}
+
+	st, err := c.GetPRStatus(ctx, repo, "tok", 3)
+	if err != nil {
+		t.Fatalf("GetPRStatus: %v", err)
+	}
+	if *st != (PRStatus{State: "merged", Title: "Fix", Merged: true, MergedBy: "jo"}) {
+		t.Errorf("GetPRStatus = %+v", st)
+	}
+}
+
+func TestGiteaPRCreator_ErrorMaxBytes(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	c := &GiteaPRCreator{client: srv.Client()}
+	repo := &RepoInfo{Provider: ProviderGitea, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+	APIConfig{ErrorMaxBytes: 10}.Apply(repo, "")
+
+	err := c.UpdateDescription(context.Background(), repo, "tok", 3, "new")
+	if err == nil || !strings.HasSuffix(err.Error(), ": "+strings.Repeat("x", 10)+"...") {
+		t.Errorf("error = %v, want the body cut to 10 bytes", err)
+	}
+}
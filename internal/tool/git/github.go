@@ -7,13 +7,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
 )
 
 // PRResult holds the result of a PR/MR creation.
 type PRResult struct {
 	URL    string
 	Number int
+
+	// GitLab approval rules of the target project: approvals the MR needs
+	// in total and the names of the rules asking for them (Premium only).
+	ApprovalsRequired int
+	ApprovalRules     []string
 }
 
 // GitHubPRCreator creates pull requests via the GitHub REST API.
@@ -24,7 +30,7 @@ type GitHubPRCreator struct {
 // NewGitHubPRCreator creates a GitHub PR creator.
 func NewGitHubPRCreator() *GitHubPRCreator {
 	return &GitHubPRCreator{
-		client: &http.Client{Timeout: 15 * time.Second},
+		client: httpclient.New(httpclient.Provider),
 	}
 }
 
@@ -33,10 +39,14 @@ func (c *GitHubPRCreator) CreatePR(ctx context.Context, repo *RepoInfo, token st
 	apiURL := repo.APIURL()
 	url := fmt.Sprintf("%s/repos/%s/%s/pulls", apiURL, repo.Owner, repo.Repo)
 
+	head := opts.Branch
+	if opts.HeadRepo != nil {
+		head = opts.HeadRepo.Owner + ":" + opts.Branch // cross-repository PR from a fork
+	}
 	body := map[string]interface{}{
 		"title": opts.Title,
 		"body":  opts.Description,
-		"head":  opts.Branch,
+		"head":  head,
 		"base":  opts.BaseBranch,
 	}
 
@@ -66,7 +76,7 @@ func (c *GitHubPRCreator) CreatePR(ctx context.Context, repo *RepoInfo, token st
 	}
 
 	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(respBody, 500))
+		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
 	}
 
 	var result struct {
@@ -109,6 +119,36 @@ func (c *GitHubPRCreator) addLabel(ctx context.Context, repo *RepoInfo, token st
 	resp.Body.Close()
 }
 
+// UpdateDescription replaces the body of a pull request.
+func (c *GitHubPRCreator) UpdateDescription(ctx context.Context, repo *RepoInfo, token string, prNumber int, description string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", repo.APIURL(), repo.Owner, repo.Repo, prNumber)
+
+	body, err := json.Marshal(map[string]string{"body": description})
+	if err != nil {
+		return fmt.Errorf("marshaling PR update: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	return nil
+}
+
 // GetPRStatus fetches the current status of a pull request.
 func (c *GitHubPRCreator) GetPRStatus(ctx context.Context, repo *RepoInfo, token string, prNumber int) (*PRStatus, error) {
 	apiURL := repo.APIURL()
@@ -164,7 +204,15 @@ func (c *GitHubPRCreator) GetPRStatus(ctx context.Context, repo *RepoInfo, token
 	return status, nil
 }
 
+// defaultErrorMaxBytes is how much of a provider API error body is kept in
+// error messages when APIConfig.ErrorMaxBytes is unset.
+const defaultErrorMaxBytes = 500
+
+// truncateBytes cuts b to max bytes (max <= 0 = defaultErrorMaxBytes).
 func truncateBytes(b []byte, max int) string {
+	if max <= 0 {
+		max = defaultErrorMaxBytes
+	}
 	if len(b) <= max {
 		return string(b)
 	}
@@ -9,8 +9,8 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
-	"time"
 
+	"github.com/freema/codeforge/internal/httpclient"
 	"github.com/freema/codeforge/internal/review"
 )
 
@@ -22,7 +22,7 @@ type GitHubReviewPoster struct {
 // NewGitHubReviewPoster creates a new GitHub review poster.
 func NewGitHubReviewPoster() *GitHubReviewPoster {
 	return &GitHubReviewPoster{
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: httpclient.New(httpclient.Review),
 	}
 }
 
@@ -46,7 +46,7 @@ func (p *GitHubReviewPoster) PostPRReview(ctx context.Context, repo *RepoInfo, t
 	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", apiURL, repo.Owner, repo.Repo, prNumber)
 
 	// Fetch diff lines to validate inline comments against actual PR diff
-	diffLines, err := FetchPRDiffLines(ctx, p.client, apiURL, repo.Owner, repo.Repo, token, prNumber)
+	diffLines, err := FetchPRDiffLines(ctx, p.client, apiURL, repo.Owner, repo.Repo, token, prNumber, repo.ErrorMaxBytes)
 	if err != nil {
 		slog.Warn("failed to fetch PR diff lines, all inline comments will be in summary",
 			"error", err)
@@ -109,13 +109,13 @@ func (p *GitHubReviewPoster) PostPRReview(ctx context.Context, repo *RepoInfo, t
 		"comments": comments,
 	}
 
-	postResult, err := p.doPostReview(ctx, url, token, body)
+	postResult, err := p.doPostReview(ctx, url, token, body, repo.ErrorMaxBytes)
 	if err != nil && event != "COMMENT" && resp422(err) && strings.Contains(strings.ToLower(err.Error()), "own pull request") {
 		// GitHub forbids APPROVE/REQUEST_CHANGES when the token owner authored the PR
 		slog.Warn("cannot post verdict on own pull request, downgrading to COMMENT", "event", event)
 		event = "COMMENT"
 		body["event"] = event
-		postResult, err = p.doPostReview(ctx, url, token, body)
+		postResult, err = p.doPostReview(ctx, url, token, body, repo.ErrorMaxBytes)
 	}
 	if err != nil && len(comments) > 0 && resp422(err) {
 		// Line comments failed despite diff validation — retry without them,
@@ -128,7 +128,7 @@ func (p *GitHubReviewPoster) PostPRReview(ctx context.Context, repo *RepoInfo, t
 			"event":    event,
 			"comments": []githubReviewComment{},
 		}
-		postResult, err = p.doPostReview(ctx, url, token, body)
+		postResult, err = p.doPostReview(ctx, url, token, body, repo.ErrorMaxBytes)
 	}
 	if err != nil {
 		return nil, err
@@ -138,7 +138,7 @@ func (p *GitHubReviewPoster) PostPRReview(ctx context.Context, repo *RepoInfo, t
 }
 
 // doPostReview sends the review request to GitHub API.
-func (p *GitHubReviewPoster) doPostReview(ctx context.Context, url, token string, body map[string]interface{}) (*PostReviewResult, error) {
+func (p *GitHubReviewPoster) doPostReview(ctx context.Context, url, token string, body map[string]interface{}, errMax int) (*PostReviewResult, error) {
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("marshaling review request: %w", err)
@@ -165,7 +165,7 @@ func (p *GitHubReviewPoster) doPostReview(ctx context.Context, url, token string
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("github review API returned %d: %s", resp.StatusCode, truncateBytes(respBody, 500))
+		return nil, fmt.Errorf("github review API returned %d: %s", resp.StatusCode, truncateBytes(respBody, errMax))
 	}
 
 	var respData struct {
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
 )
 
 // GitLabMRCreator creates merge requests via the GitLab REST API.
@@ -19,15 +22,78 @@ type GitLabMRCreator struct {
 // NewGitLabMRCreator creates a GitLab MR creator.
 func NewGitLabMRCreator() *GitLabMRCreator {
 	return &GitLabMRCreator{
-		client: &http.Client{Timeout: 15 * time.Second},
+		client: httpclient.New(httpclient.Provider),
+	}
+}
+
+// GitLabMROptions are merge request settings applied when CodeForge opens
+// an MR, so it matches group policies without manual fixing. Unset fields
+// leave the project defaults in place.
+type GitLabMROptions struct {
+	Squash             *bool `json:"squash,omitempty"`
+	RemoveSourceBranch *bool `json:"remove_source_branch,omitempty"`
+	MilestoneID        int   `json:"milestone_id,omitempty"`
+	AssigneeIDs        []int `json:"assignee_ids,omitempty"`
+	ReviewerIDs        []int `json:"reviewer_ids,omitempty"`
+	// Request review from the eligible approvers of the project's approval
+	// rules when ReviewerIDs is empty.
+	ApprovalRuleReviewers bool `json:"approval_rule_reviewers,omitempty"`
+}
+
+// Merge returns o with every field set in override replacing its own.
+func (o GitLabMROptions) Merge(override *GitLabMROptions) GitLabMROptions {
+	if override == nil {
+		return o
+	}
+	if override.Squash != nil {
+		o.Squash = override.Squash
+	}
+	if override.RemoveSourceBranch != nil {
+		o.RemoveSourceBranch = override.RemoveSourceBranch
+	}
+	if override.MilestoneID != 0 {
+		o.MilestoneID = override.MilestoneID
+	}
+	if len(override.AssigneeIDs) > 0 {
+		o.AssigneeIDs = override.AssigneeIDs
+	}
+	if len(override.ReviewerIDs) > 0 {
+		o.ReviewerIDs = override.ReviewerIDs
+	}
+	if override.ApprovalRuleReviewers {
+		o.ApprovalRuleReviewers = true
 	}
+	return o
+}
+
+// gitlabApprovalRule is a project-level approval rule.
+type gitlabApprovalRule struct {
+	Name              string `json:"name"`
+	RuleType          string `json:"rule_type"`
+	ApprovalsRequired int    `json:"approvals_required"`
+	EligibleApprovers []struct {
+		ID int `json:"id"`
+	} `json:"eligible_approvers"`
+}
+
+// approvalRules lists the approval rules of the project. Approval rules are
+// a Premium feature: other instances answer 403/404, reported as no rules.
+func (c *GitLabMRCreator) approvalRules(ctx context.Context, repo *RepoInfo, token string) []gitlabApprovalRule {
+	var rules []gitlabApprovalRule
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/approval_rules", repo.APIURL(), url.PathEscape(repo.FullName()))
+	if err := providerGet(ctx, repo, token, endpoint, &rules); err != nil {
+		if !errors.Is(err, ErrBranchNotFound) {
+			slog.Debug("gitlab approval rules unavailable", "repo", repo.FullName(), "error", err)
+		}
+		return nil
+	}
+	return rules
 }
 
 // CreateMR creates a merge request on GitLab.
 func (c *GitLabMRCreator) CreateMR(ctx context.Context, repo *RepoInfo, token string, opts PRCreateOptions) (*PRResult, error) {
 	apiURL := repo.APIURL()
 	projectPath := url.PathEscape(repo.FullName())
-	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests", apiURL, projectPath)
 
 	body := map[string]interface{}{
 		"title":         opts.Title,
@@ -37,6 +103,42 @@ func (c *GitLabMRCreator) CreateMR(ctx context.Context, repo *RepoInfo, token st
 		"labels":        "codeforge",
 	}
 
+	rules := c.approvalRules(ctx, repo, token)
+	if mr := opts.GitLab; mr != nil {
+		if mr.Squash != nil {
+			body["squash"] = *mr.Squash
+		}
+		if mr.RemoveSourceBranch != nil {
+			body["remove_source_branch"] = *mr.RemoveSourceBranch
+		}
+		if mr.MilestoneID != 0 {
+			body["milestone_id"] = mr.MilestoneID
+		}
+		if len(mr.AssigneeIDs) > 0 {
+			body["assignee_ids"] = mr.AssigneeIDs
+		}
+		reviewers := mr.ReviewerIDs
+		if len(reviewers) == 0 && mr.ApprovalRuleReviewers {
+			reviewers = eligibleApprovers(rules)
+		}
+		if len(reviewers) > 0 {
+			body["reviewer_ids"] = reviewers
+		}
+	}
+	// A merge request from a fork is opened on the fork (the source project)
+	// and targets the original project by ID.
+	if opts.HeadRepo != nil {
+		var target struct {
+			ID int `json:"id"`
+		}
+		if err := providerGet(ctx, repo, token, fmt.Sprintf("%s/api/v4/projects/%s", apiURL, projectPath), &target); err != nil {
+			return nil, fmt.Errorf("looking up target project %s: %w", repo.FullName(), err)
+		}
+		body["target_project_id"] = target.ID
+		projectPath = url.PathEscape(opts.HeadRepo.FullName())
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests", apiURL, projectPath)
+
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("marshaling MR request: %w", err)
@@ -61,7 +163,7 @@ func (c *GitLabMRCreator) CreateMR(ctx context.Context, repo *RepoInfo, token st
 	}
 
 	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(respBody, 500))
+		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
 	}
 
 	var result struct {
@@ -72,10 +174,65 @@ func (c *GitLabMRCreator) CreateMR(ctx context.Context, repo *RepoInfo, token st
 		return nil, fmt.Errorf("parsing gitlab MR response: %w", err)
 	}
 
-	return &PRResult{
+	res := &PRResult{
 		URL:    result.WebURL,
 		Number: result.IID,
-	}, nil
+	}
+	for _, r := range rules {
+		if r.ApprovalsRequired > 0 {
+			res.ApprovalsRequired += r.ApprovalsRequired
+			res.ApprovalRules = append(res.ApprovalRules, r.Name)
+		}
+	}
+	return res, nil
+}
+
+// eligibleApprovers collects the approvers of the rules that require
+// approvals, without duplicates, in rule order.
+func eligibleApprovers(rules []gitlabApprovalRule) []int {
+	var ids []int
+	seen := make(map[int]bool)
+	for _, r := range rules {
+		if r.ApprovalsRequired == 0 {
+			continue
+		}
+		for _, a := range r.EligibleApprovers {
+			if !seen[a.ID] {
+				seen[a.ID] = true
+				ids = append(ids, a.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// UpdateDescription replaces the description of a merge request.
+func (c *GitLabMRCreator) UpdateDescription(ctx context.Context, repo *RepoInfo, token string, mrIID int, description string) error {
+	projectPath := url.PathEscape(repo.FullName())
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d", repo.APIURL(), projectPath, mrIID)
+
+	body, err := json.Marshal(map[string]string{"description": description})
+	if err != nil {
+		return fmt.Errorf("marshaling MR update: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitlab API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
+	}
+	return nil
 }
 
 // GetMRStatus fetches the current status of a merge request.
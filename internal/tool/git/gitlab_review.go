@@ -8,8 +8,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"time"
 
+	"github.com/freema/codeforge/internal/httpclient"
 	"github.com/freema/codeforge/internal/review"
 )
 
@@ -21,7 +21,7 @@ type GitLabReviewPoster struct {
 // NewGitLabReviewPoster creates a new GitLab review poster.
 func NewGitLabReviewPoster() *GitLabReviewPoster {
 	return &GitLabReviewPoster{
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: httpclient.New(httpclient.Review),
 	}
 }
 
@@ -67,7 +67,7 @@ func (p *GitLabReviewPoster) PostMRReview(ctx context.Context, repo *RepoInfo, t
 
 	// Post line-level discussions
 	for _, issue := range fileIssues {
-		err := p.postDiscussion(ctx, apiURL, projectPath, token, mrIID, version, issue, formatIssue)
+		err := p.postDiscussion(ctx, apiURL, projectPath, token, mrIID, repo.ErrorMaxBytes, version, issue, formatIssue)
 		if err != nil {
 			// Non-fatal: move to summary
 			nonFileIssues = append(nonFileIssues, issue)
@@ -96,7 +96,7 @@ func (p *GitLabReviewPoster) PostMRReview(ctx context.Context, repo *RepoInfo, t
 
 	if resp.StatusCode != http.StatusCreated {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("gitlab discussion API returned %d: %s", resp.StatusCode, truncateBytes(respBody, 500))
+		return nil, fmt.Errorf("gitlab discussion API returned %d: %s", resp.StatusCode, truncateBytes(respBody, repo.ErrorMaxBytes))
 	}
 
 	var respData struct {
@@ -142,7 +142,7 @@ func (p *GitLabReviewPoster) getMRVersion(ctx context.Context, apiURL, projectPa
 	return &versions[0], nil
 }
 
-func (p *GitLabReviewPoster) postDiscussion(ctx context.Context, apiURL, projectPath, token string, mrIID int, version *gitlabMRVersion, issue review.ReviewIssue, formatIssue func(review.ReviewIssue) string) error {
+func (p *GitLabReviewPoster) postDiscussion(ctx context.Context, apiURL, projectPath, token string, mrIID, errMax int, version *gitlabMRVersion, issue review.ReviewIssue, formatIssue func(review.ReviewIssue) string) error {
 	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d/discussions", apiURL, projectPath, mrIID)
 
 	body := map[string]interface{}{
@@ -177,7 +177,7 @@ func (p *GitLabReviewPoster) postDiscussion(ctx context.Context, apiURL, project
 
 	if resp.StatusCode != http.StatusCreated {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("gitlab discussion returned %d: %s", resp.StatusCode, truncateBytes(respBody, 300))
+		return fmt.Errorf("gitlab discussion returned %d: %s", resp.StatusCode, truncateBytes(respBody, errMax))
 	}
 
 	return nil
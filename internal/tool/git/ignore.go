@@ -0,0 +1,108 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile lists glob patterns, one per line, for files that never belong
+// in a PR (build output, dependency directories, lockfile churn).
+const IgnoreFile = ".codeforgeignore"
+
+// LoadIgnoreGlobs returns the patterns from the repository's .codeforgeignore
+// followed by the configured ones. Blank lines and # comments are skipped.
+func LoadIgnoreGlobs(workDir string, configured []string) []string {
+	var globs []string
+	if f, err := os.Open(filepath.Join(workDir, IgnoreFile)); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			globs = append(globs, line)
+		}
+		f.Close()
+	}
+	for _, g := range configured {
+		if g = strings.TrimSpace(g); g != "" {
+			globs = append(globs, g)
+		}
+	}
+	return globs
+}
+
+// ignorePathspecs turns ignore globs into a pathspec limiting a git command to
+// everything else. Like .gitignore, a pattern without a slash matches at any
+// depth (a leading slash anchors it to the root) and a pattern naming a
+// directory excludes its whole content.
+func ignorePathspecs(globs []string) []string {
+	if len(globs) == 0 {
+		return nil
+	}
+	specs := []string{"."}
+	for _, g := range globs {
+		anchored := strings.HasPrefix(g, "/")
+		g = strings.Trim(g, "/")
+		if g == "" {
+			continue
+		}
+		if !anchored && !strings.Contains(g, "/") {
+			g = "**/" + g
+		}
+		specs = append(specs, ":(exclude,glob)"+g, ":(exclude,glob)"+g+"/**")
+	}
+	return specs
+}
+
+// withPathspecs appends pathspecs after a "--" separator.
+func withPathspecs(args []string, specs []string) []string {
+	if len(specs) == 0 {
+		return args
+	}
+	return append(append(args, "--"), specs...)
+}
+
+// ExcludeLocally adds pattern to the repository's info/exclude file, keeping
+// matching files out of status, staging and commits without touching the
+// tracked .gitignore. Adding a pattern that is already listed is a no-op.
+func ExcludeLocally(ctx context.Context, workDir, pattern string) error {
+	out, err := gitOutput(ctx, workDir, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return fmt.Errorf("locating info/exclude: %w", err)
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+	prefix := ""
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		prefix = "\n"
+	}
+	if _, err := f.WriteString(prefix + pattern + "\n"); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
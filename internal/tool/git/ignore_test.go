@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnorePathspecs(t *testing.T) {
+	tests := []struct {
+		globs []string
+		want  []string
+	}{
+		{nil, nil},
+		{[]string{"node_modules"}, []string{".", ":(exclude,glob)**/node_modules", ":(exclude,glob)**/node_modules/**"}},
+		{[]string{"dist/"}, []string{".", ":(exclude,glob)**/dist", ":(exclude,glob)**/dist/**"}},
+		{[]string{"/build"}, []string{".", ":(exclude,glob)build", ":(exclude,glob)build/**"}},
+		{[]string{"web/*.map", "/"}, []string{".", ":(exclude,glob)web/*.map", ":(exclude,glob)web/*.map/**"}},
+	}
+	for _, tt := range tests {
+		got := ignorePathspecs(tt.globs)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ignorePathspecs(%v) = %v, want %v", tt.globs, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreGlobs_ChangesAndCommit(t *testing.T) {
+	dir, run := initTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, IgnoreFile, "# generated\nnode_modules\n\n/dist\n")
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "node_modules/left-pad/index.js", "module.exports = 1\n")
+	writeFile(t, dir, "web/node_modules/x/y.js", "1\n")
+	writeFile(t, dir, "dist/app.js", "bundle\n")
+	writeFile(t, dir, "package-lock.json", "{}\n")
+
+	globs := LoadIgnoreGlobs(dir, []string{"package-lock.json", " "})
+	if fmt.Sprint(globs) != "[node_modules /dist package-lock.json]" {
+		t.Fatalf("LoadIgnoreGlobs = %v", globs)
+	}
+
+	files, err := ChangedFiles(ctx, dir, globs...)
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	if fmt.Sprint(files) != "[main.go .codeforgeignore]" {
+		t.Errorf("ChangedFiles = %v", files)
+	}
+
+	summary, err := CalculateChanges(ctx, dir, globs...)
+	if err != nil {
+		t.Fatalf("CalculateChanges: %v", err)
+	}
+	if summary.FilesModified != 1 || summary.FilesCreated != 1 {
+		t.Errorf("summary = %+v, want 1 modified, 1 created", summary)
+	}
+
+	if err := stageChanges(ctx, dir, globs); err != nil {
+		t.Fatalf("stageChanges: %v", err)
+	}
+	run("commit", "-q", "-m", "change")
+	tracked, _ := gitOutput(ctx, dir, "ls-files")
+	if tracked != ".codeforgeignore\nmain.go\n" {
+		t.Errorf("committed files = %q", tracked)
+	}
+}
+
+func TestExcludeLocally(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := ExcludeLocally(ctx, dir, ".codeforge/attachments/"); err != nil {
+			t.Fatalf("ExcludeLocally: %v", err)
+		}
+	}
+	writeFile(t, dir, ".codeforge/attachments/spec.md", "# spec\n")
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+
+	files, err := ChangedFiles(ctx, dir)
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	if fmt.Sprint(files) != "[main.go]" {
+		t.Errorf("ChangedFiles = %v", files)
+	}
+
+	exclude, _ := gitOutput(ctx, dir, "rev-parse", "--git-path", "info/exclude")
+	data, err := os.ReadFile(filepath.Join(dir, strings.TrimSpace(exclude)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), ".codeforge/attachments/"); n != 1 {
+		t.Errorf("pattern listed %d times, want 1", n)
+	}
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// WorkspaceError reports why an existing workspace cannot be reused.
+type WorkspaceError struct {
+	WorkDir string
+	Reason  string
+}
+
+func (e *WorkspaceError) Error() string {
+	return fmt.Sprintf("workspace %s is not usable: %s", e.WorkDir, e.Reason)
+}
+
+// CheckWorkspace is a light fsck run before a workspace is reused for a
+// follow-up iteration. workDir must be the top level of a git work tree whose
+// origin points at remoteURL (skipped when empty), HEAD must resolve to a
+// commit and the index must be readable. Each ref in intact that still exists
+// must point at a commit present in the object store — a half-deleted .git
+// keeps its refs but loses the objects behind them. Returns a *WorkspaceError.
+func CheckWorkspace(ctx context.Context, workDir, remoteURL string, intact ...string) error {
+	bad := func(format string, args ...any) error {
+		return &WorkspaceError{WorkDir: workDir, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	top, err := gitOutput(ctx, workDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return bad("not a git work tree")
+	}
+	if top = strings.TrimSpace(top); !samePath(top, workDir) {
+		return bad("git top level is %s", top)
+	}
+
+	if remoteURL != "" {
+		origin, err := gitOutput(ctx, workDir, "remote", "get-url", "origin")
+		if err != nil {
+			return bad("origin remote is missing")
+		}
+		if origin = strings.TrimSpace(origin); normalizeRemote(origin) != normalizeRemote(remoteURL) {
+			return bad("origin points at %s", SanitizeURL(origin))
+		}
+	}
+
+	if _, err := revParse(ctx, workDir, "HEAD^{commit}"); err != nil {
+		return bad("HEAD does not resolve to a commit")
+	}
+	if err := gitCmd(ctx, workDir, nil, "status", "--porcelain", "--untracked-files=no"); err != nil {
+		return bad("reading the work tree failed: %v", err)
+	}
+
+	for _, ref := range intact {
+		if _, err := revParse(ctx, workDir, ref); err != nil {
+			continue // never written (e.g. checkpointing failed), nothing to verify
+		}
+		if gitCmd(ctx, workDir, nil, "cat-file", "-e", ref+"^{commit}") != nil {
+			return bad("%s points at a missing commit", ref)
+		}
+	}
+	return nil
+}
+
+// samePath reports whether a and b name the same directory, resolving
+// symlinks (temp dirs on macOS live behind /var → /private/var).
+func samePath(a, b string) bool {
+	resolve := func(p string) string {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if real, err := filepath.EvalSymlinks(p); err == nil {
+			p = real
+		}
+		return filepath.Clean(p)
+	}
+	return resolve(a) == resolve(b)
+}
+
+// normalizeRemote strips credentials, a trailing slash and ".git" so the
+// same repository compares equal however its URL was spelled.
+func normalizeRemote(url string) string {
+	url = SanitizeURL(strings.TrimSpace(url))
+	if i := strings.Index(url, "***@"); i != -1 {
+		url = url[:i] + url[i+len("***@"):]
+	}
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	return strings.ToLower(url)
+}
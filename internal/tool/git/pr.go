@@ -11,6 +11,9 @@ type PRCreateOptions struct {
 	Description string
 	Branch      string
 	BaseBranch  string
+	HeadRepo    *RepoInfo // fork holding Branch; nil = the repository itself
+	// Merge request settings, ignored by the other providers.
+	GitLab *GitLabMROptions
 }
 
 // CreatePR creates a PR/MR on the appropriate provider.
@@ -20,11 +23,31 @@ func CreatePR(ctx context.Context, repo *RepoInfo, token string, opts PRCreateOp
 		return NewGitHubPRCreator().CreatePR(ctx, repo, token, opts)
 	case ProviderGitLab:
 		return NewGitLabMRCreator().CreateMR(ctx, repo, token, opts)
+	case ProviderAzureDevOps:
+		return NewAzureDevOpsPRCreator().CreatePR(ctx, repo, token, opts)
+	case ProviderGitea:
+		return NewGiteaPRCreator().CreatePR(ctx, repo, token, opts)
 	default:
 		return nil, fmt.Errorf("PR creation not supported for provider: %s", repo.Provider)
 	}
 }
 
+// UpdatePRDescription replaces the body of an existing PR/MR.
+func UpdatePRDescription(ctx context.Context, repo *RepoInfo, token string, prNumber int, description string) error {
+	switch repo.Provider {
+	case ProviderGitHub:
+		return NewGitHubPRCreator().UpdateDescription(ctx, repo, token, prNumber, description)
+	case ProviderGitLab:
+		return NewGitLabMRCreator().UpdateDescription(ctx, repo, token, prNumber, description)
+	case ProviderAzureDevOps:
+		return NewAzureDevOpsPRCreator().UpdateDescription(ctx, repo, token, prNumber, description)
+	case ProviderGitea:
+		return NewGiteaPRCreator().UpdateDescription(ctx, repo, token, prNumber, description)
+	default:
+		return fmt.Errorf("PR update not supported for provider: %s", repo.Provider)
+	}
+}
+
 // PRStatus represents the state of a PR/MR on the provider.
 type PRStatus struct {
 	State    string `json:"state"` // "open", "merged", "closed"
@@ -40,6 +63,10 @@ func GetPRStatus(ctx context.Context, repo *RepoInfo, token string, prNumber int
 		return NewGitHubPRCreator().GetPRStatus(ctx, repo, token, prNumber)
 	case ProviderGitLab:
 		return NewGitLabMRCreator().GetMRStatus(ctx, repo, token, prNumber)
+	case ProviderAzureDevOps:
+		return NewAzureDevOpsPRCreator().GetPRStatus(ctx, repo, token, prNumber)
+	case ProviderGitea:
+		return NewGiteaPRCreator().GetPRStatus(ctx, repo, token, prNumber)
 	default:
 		return nil, fmt.Errorf("PR status not supported for provider: %s", repo.Provider)
 	}
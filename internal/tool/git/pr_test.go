@@ -0,0 +1,174 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdatePRDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		method   string
+		path     string
+		field    string
+	}{
+		{"github", ProviderGitHub, http.MethodPatch, "/api/v3/repos/acme/api/pulls/42", "body"},
+		{"gitlab", ProviderGitLab, http.MethodPut, "/api/v4/projects/acme%2Fapi/merge_requests/42", "description"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method || r.URL.EscapedPath() != tt.path {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+				}
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			repo := &RepoInfo{Provider: tt.provider, Host: strings.TrimPrefix(srv.URL, "https://"), Owner: "acme", Repo: "api"}
+			var err error
+			if tt.provider == ProviderGitHub {
+				err = (&GitHubPRCreator{client: srv.Client()}).UpdateDescription(context.Background(), repo, "tok", 42, "new body")
+			} else {
+				err = (&GitLabMRCreator{client: srv.Client()}).UpdateDescription(context.Background(), repo, "tok", 42, "new body")
+			}
+			if err != nil {
+				t.Fatalf("UpdateDescription: %v", err)
+			}
+			if got[tt.field] != "new body" {
+				t.Errorf("%s = %q, want %q", tt.field, got[tt.field], "new body")
+			}
+		})
+	}
+}
+
+func TestCreatePR_FromFork(t *testing.T) {
+	fork := &RepoInfo{Owner: "bot", Repo: "api"}
+
+	t.Run("github", func(t *testing.T) {
+		var got map[string]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/repos/acme/api/pulls" {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"html_url":"https://github.com/acme/api/pull/7","number":7}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer srv.Close()
+
+		repo := &RepoInfo{Provider: ProviderGitHub, Host: "github.com", Owner: "acme", Repo: "api", APIBaseURL: srv.URL}
+		res, err := NewGitHubPRCreator().CreatePR(context.Background(), repo, "tok", PRCreateOptions{Title: "t", Branch: "codeforge/fix", BaseBranch: "main", HeadRepo: fork})
+		if err != nil {
+			t.Fatalf("CreatePR: %v", err)
+		}
+		if got["head"] != "bot:codeforge/fix" || res.Number != 7 {
+			t.Errorf("head = %q, number = %d", got["head"], res.Number)
+		}
+	})
+
+	t.Run("gitlab", func(t *testing.T) {
+		var got map[string]interface{}
+		var createdOn string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.EscapedPath() == "/api/v4/projects/acme%2Fapi":
+				_, _ = w.Write([]byte(`{"id":42}`))
+			case r.Method == http.MethodGet && r.URL.EscapedPath() == "/api/v4/projects/acme%2Fapi/approval_rules":
+				w.WriteHeader(http.StatusNotFound) // GitLab Free
+			case r.Method == http.MethodPost:
+				createdOn = r.URL.EscapedPath()
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"web_url":"https://gitlab.com/acme/api/-/merge_requests/3","iid":3}`))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			}
+		}))
+		defer srv.Close()
+
+		repo := &RepoInfo{Provider: ProviderGitLab, Host: "gitlab.com", Owner: "acme", Repo: "api", APIBaseURL: srv.URL}
+		if _, err := NewGitLabMRCreator().CreateMR(context.Background(), repo, "tok", PRCreateOptions{Title: "t", Branch: "codeforge/fix", BaseBranch: "main", HeadRepo: fork}); err != nil {
+			t.Fatalf("CreateMR: %v", err)
+		}
+		if createdOn != "/api/v4/projects/bot%2Fapi/merge_requests" {
+			t.Errorf("MR created on %s, want the fork project", createdOn)
+		}
+		if got["target_project_id"] != float64(42) {
+			t.Errorf("target_project_id = %v, want 42", got["target_project_id"])
+		}
+	})
+}
+
+func TestCreateMR_Options(t *testing.T) {
+	yes := true
+	tests := []struct {
+		name          string
+		opts          *GitLabMROptions
+		wantReviewers []interface{}
+	}{
+		{"explicit reviewers", &GitLabMROptions{Squash: &yes, MilestoneID: 5, AssigneeIDs: []int{7}, ReviewerIDs: []int{9}, ApprovalRuleReviewers: true}, []interface{}{float64(9)}},
+		{"reviewers from approval rules", &GitLabMROptions{Squash: &yes, MilestoneID: 5, AssigneeIDs: []int{7}, ApprovalRuleReviewers: true}, []interface{}{float64(11), float64(12)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && strings.HasSuffix(r.URL.EscapedPath(), "/approval_rules"):
+					_, _ = w.Write([]byte(`[
+						{"name":"Backend","rule_type":"regular","approvals_required":2,"eligible_approvers":[{"id":11},{"id":12}]},
+						{"name":"Optional","rule_type":"any_approver","approvals_required":0,"eligible_approvers":[{"id":13}]},
+						{"name":"Security","rule_type":"regular","approvals_required":1,"eligible_approvers":[{"id":12}]}
+					]`))
+				case r.Method == http.MethodPost:
+					_ = json.NewDecoder(r.Body).Decode(&got)
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"web_url":"https://gitlab.com/acme/api/-/merge_requests/3","iid":3}`))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+				}
+			}))
+			defer srv.Close()
+
+			repo := &RepoInfo{Provider: ProviderGitLab, Host: "gitlab.com", Owner: "acme", Repo: "api", APIBaseURL: srv.URL}
+			res, err := NewGitLabMRCreator().CreateMR(context.Background(), repo, "tok", PRCreateOptions{Title: "t", Branch: "codeforge/fix", BaseBranch: "main", GitLab: tt.opts})
+			if err != nil {
+				t.Fatalf("CreateMR: %v", err)
+			}
+			if got["squash"] != true || got["milestone_id"] != float64(5) || fmt.Sprint(got["assignee_ids"]) != "[7]" {
+				t.Errorf("unexpected MR body %v", got)
+			}
+			if _, ok := got["remove_source_branch"]; ok {
+				t.Error("unset remove_source_branch must keep the project default")
+			}
+			if fmt.Sprint(got["reviewer_ids"]) != fmt.Sprint(tt.wantReviewers) {
+				t.Errorf("reviewer_ids = %v, want %v", got["reviewer_ids"], tt.wantReviewers)
+			}
+			if res.ApprovalsRequired != 3 || strings.Join(res.ApprovalRules, ",") != "Backend,Security" {
+				t.Errorf("approvals = %d %v", res.ApprovalsRequired, res.ApprovalRules)
+			}
+		})
+	}
+}
+
+func TestGitLabMROptions_Merge(t *testing.T) {
+	yes, no := true, false
+	base := GitLabMROptions{Squash: &yes, RemoveSourceBranch: &yes, ReviewerIDs: []int{1}}
+	got := base.Merge(&GitLabMROptions{Squash: &no, MilestoneID: 4})
+	if *got.Squash || !*got.RemoveSourceBranch || got.MilestoneID != 4 || len(got.ReviewerIDs) != 1 {
+		t.Errorf("Merge = %+v", got)
+	}
+	if got := base.Merge(nil); got.Squash != base.Squash {
+		t.Error("nil override must keep the defaults")
+	}
+}
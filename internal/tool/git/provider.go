@@ -10,17 +10,22 @@ import (
 type Provider string
 
 const (
-	ProviderGitHub  Provider = "github"
-	ProviderGitLab  Provider = "gitlab"
-	ProviderUnknown Provider = "unknown"
+	ProviderGitHub      Provider = "github"
+	ProviderGitLab      Provider = "gitlab"
+	ProviderAzureDevOps Provider = "azure_devops"
+	ProviderGitea       Provider = "gitea" // also Forgejo
+	ProviderUnknown     Provider = "unknown"
 )
 
 // RepoInfo holds parsed repository information.
 type RepoInfo struct {
-	Provider Provider
-	Host     string
-	Owner    string
-	Repo     string
+	Provider   Provider
+	Host       string
+	Owner      string
+	Repo       string
+	APIBaseURL string // explicit API base (a key's api_base_url), see APIBase
+	// ErrorMaxBytes caps the provider error body kept in errors (0 = default).
+	ErrorMaxBytes int
 }
 
 // FullName returns "owner/repo".
@@ -30,22 +35,93 @@ func (r RepoInfo) FullName() string {
 
 // APIURL returns the base API URL for the provider.
 func (r RepoInfo) APIURL() string {
-	switch r.Provider {
+	return APIBase(r.Provider, "https://"+r.Host, r.APIBaseURL)
+}
+
+// APIConfig is the operator configuration of provider API calls. Components
+// calling provider APIs get it with their config and apply it to the
+// RepoInfo they build.
+type APIConfig struct {
+	// BaseURLs maps provider hosts to explicit API base URLs
+	// (git.api_base_urls), for GitHub Enterprise / GitLab installs where the
+	// conventional location is wrong (e.g. behind an API proxy).
+	BaseURLs map[string]string
+	// ErrorMaxBytes caps how much of a provider API error body is kept in
+	// error messages (sessions.provider_error_max_bytes, 0 = 500).
+	ErrorMaxBytes int
+}
+
+// BaseURL returns the explicit API base for the instance at baseURL:
+// explicit (a key's api_base_url) when set, else the BaseURLs entry for the
+// host, else "" for the convention (see APIBase).
+func (c APIConfig) BaseURL(baseURL, explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	for host, configured := range c.BaseURLs {
+		if strings.EqualFold(host, u.Hostname()) {
+			return configured
+		}
+	}
+	return ""
+}
+
+// Apply sets the API base URL and error limit of repo, explicit being its
+// key's api_base_url.
+func (c APIConfig) Apply(repo *RepoInfo, explicit string) {
+	repo.APIBaseURL = c.BaseURL("https://"+repo.Host, explicit)
+	repo.ErrorMaxBytes = c.ErrorMaxBytes
+}
+
+// APIBase returns the URL provider API paths are appended to, for the
+// instance at baseURL ("" = github.com / gitlab.com / dev.azure.com /
+// gitea.com). explicit (see APIConfig.BaseURL) wins, then the convention:
+// api.github.com or <host>/api/v3 for GitHub, the instance root (before
+// /api/v4) for GitLab and Azure DevOps (before /<org>/<project>/_apis),
+// <host>/api/v1 for Gitea.
+func APIBase(provider Provider, baseURL, explicit string) string {
+	if explicit != "" {
+		return strings.TrimRight(explicit, "/")
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	var host string
+	if u, err := url.Parse(baseURL); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+	switch provider {
 	case ProviderGitHub:
-		if r.Host == "github.com" {
+		if baseURL == "" || host == "github.com" {
 			return "https://api.github.com"
 		}
-		return "https://" + r.Host + "/api/v3" // GitHub Enterprise
+		return baseURL + "/api/v3" // GitHub Enterprise
 	case ProviderGitLab:
-		return "https://" + r.Host
+		if baseURL == "" {
+			return "https://gitlab.com"
+		}
+		return baseURL
+	case ProviderAzureDevOps:
+		if baseURL == "" {
+			return "https://dev.azure.com"
+		}
+		return baseURL
+	case ProviderGitea:
+		if baseURL == "" {
+			return "https://gitea.com/api/v1"
+		}
+		return baseURL + "/api/v1"
 	default:
 		return ""
 	}
 }
 
 // ParseRepoURL extracts provider, owner, and repo from a git URL.
-// Supports HTTPS URLs like https://github.com/owner/repo.git
-// Custom domain mapping via providerDomains config.
+// Supports HTTPS URLs like https://github.com/owner/repo.git and Azure Repos
+// URLs (https://dev.azure.com/org/project/_git/repo), whose owner is
+// "org/project". Custom domain mapping via providerDomains config.
 func ParseRepoURL(repoURL string, providerDomains map[string]string) (*RepoInfo, error) {
 	u, err := url.Parse(repoURL)
 	if err != nil {
@@ -56,6 +132,11 @@ func ParseRepoURL(repoURL string, providerDomains map[string]string) (*RepoInfo,
 	path := strings.Trim(u.Path, "/")
 	path = strings.TrimSuffix(path, ".git")
 
+	provider := detectProvider(host, providerDomains)
+	if provider == ProviderAzureDevOps {
+		return parseAzureRepoPath(host, path)
+	}
+
 	parts := strings.SplitN(path, "/", 3)
 	if len(parts) < 2 {
 		return nil, fmt.Errorf("cannot extract owner/repo from URL: %s", repoURL)
@@ -69,8 +150,6 @@ func ParseRepoURL(repoURL string, providerDomains map[string]string) (*RepoInfo,
 		repo = parts[len(parts)-1]
 	}
 
-	provider := detectProvider(host, providerDomains)
-
 	return &RepoInfo{
 		Provider: provider,
 		Host:     host,
@@ -88,6 +167,10 @@ func detectProvider(host string, customDomains map[string]string) Provider {
 				return ProviderGitHub
 			case "gitlab":
 				return ProviderGitLab
+			case "azure_devops", "azure":
+				return ProviderAzureDevOps
+			case "gitea", "forgejo":
+				return ProviderGitea
 			}
 		}
 	}
@@ -98,6 +181,10 @@ func detectProvider(host string, customDomains map[string]string) Provider {
 		return ProviderGitHub
 	case host == "gitlab.com" || strings.HasSuffix(host, ".gitlab.com"):
 		return ProviderGitLab
+	case host == "dev.azure.com" || host == "ssh.dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com"):
+		return ProviderAzureDevOps
+	case host == "gitea.com" || host == "codeberg.org":
+		return ProviderGitea
 	default:
 		return ProviderUnknown
 	}
@@ -14,6 +14,11 @@ func TestParseRepoURL(t *testing.T) {
 		{"https://gitlab.com/group/project.git", ProviderGitLab, "group", "project"},
 		{"https://gitlab.com/group/subgroup/project.git", ProviderGitLab, "group/subgroup", "project"},
 		{"https://example.com/owner/repo.git", ProviderUnknown, "owner", "repo"},
+		{"https://dev.azure.com/acme/Web%20Shop/_git/api", ProviderAzureDevOps, "acme/Web Shop", "api"},
+		{"https://acme.visualstudio.com/DefaultCollection/shop/_git/api", ProviderAzureDevOps, "acme/shop", "api"},
+		{"https://acme.visualstudio.com/shop/_git/api", ProviderAzureDevOps, "acme/shop", "api"},
+		{"ssh://git@ssh.dev.azure.com/v3/acme/shop/api", ProviderAzureDevOps, "acme/shop", "api"},
+		{"https://codeberg.org/forgejo/forgejo.git", ProviderGitea, "forgejo", "forgejo"},
 	}
 
 	for _, tt := range tests {
@@ -45,12 +50,45 @@ func TestParseRepoURL_CustomDomains(t *testing.T) {
 	if info.Provider != ProviderGitLab {
 		t.Errorf("expected gitlab, got %q", info.Provider)
 	}
+
+	for _, name := range []string{"gitea", "Forgejo"} {
+		info, err = ParseRepoURL("https://git.home.lan/team/project.git", map[string]string{"git.home.lan": name})
+		if err != nil {
+			t.Fatalf("ParseRepoURL: %v", err)
+		}
+		if info.Provider != ProviderGitea {
+			t.Errorf("%s: expected gitea, got %q", name, info.Provider)
+		}
+		if got := info.APIURL(); got != "https://git.home.lan/api/v1" {
+			t.Errorf("%s: APIURL() = %q", name, got)
+		}
+	}
+}
+
+func TestParseRepoURL_AzureDevOpsServer(t *testing.T) {
+	info, err := ParseRepoURL("https://tfs.corp.com/tfs/Main/shop/_git/api", map[string]string{"tfs.corp.com": "azure_devops"})
+	if err != nil {
+		t.Fatalf("ParseRepoURL: %v", err)
+	}
+	want := RepoInfo{Provider: ProviderAzureDevOps, Host: "tfs.corp.com", Owner: "tfs/Main/shop", Repo: "api"}
+	if *info != want {
+		t.Errorf("ParseRepoURL = %+v, want %+v", *info, want)
+	}
+	if got := info.APIURL(); got != "https://tfs.corp.com" {
+		t.Errorf("APIURL() = %q", got)
+	}
 }
 
 func TestParseRepoURL_Invalid(t *testing.T) {
-	_, err := ParseRepoURL("https://github.com/onlyone", nil)
-	if err == nil {
-		t.Fatal("expected error for URL without repo")
+	for _, u := range []string{
+		"https://github.com/onlyone",
+		"https://dev.azure.com/acme/shop/api",       // no _git
+		"https://dev.azure.com/acme/_git/api",       // no project
+		"ssh://git@ssh.dev.azure.com/acme/shop/api", // no v3
+	} {
+		if _, err := ParseRepoURL(u, nil); err == nil {
+			t.Errorf("ParseRepoURL(%q): expected error", u)
+		}
 	}
 }
 
@@ -63,6 +101,9 @@ func TestRepoInfo_APIURL(t *testing.T) {
 		{RepoInfo{Provider: ProviderGitHub, Host: "github.company.com"}, "https://github.company.com/api/v3"},
 		{RepoInfo{Provider: ProviderGitLab, Host: "gitlab.com"}, "https://gitlab.com"},
 		{RepoInfo{Provider: ProviderGitLab, Host: "git.company.com"}, "https://git.company.com"},
+		{RepoInfo{Provider: ProviderAzureDevOps, Host: "dev.azure.com"}, "https://dev.azure.com"},
+		{RepoInfo{Provider: ProviderGitea, Host: "codeberg.org"}, "https://codeberg.org/api/v1"},
+		{RepoInfo{Provider: ProviderGitHub, Host: "ghe.company.com", APIBaseURL: "https://api.ghe.company.com/"}, "https://api.ghe.company.com"},
 	}
 
 	for _, tt := range tests {
@@ -72,3 +113,34 @@ func TestRepoInfo_APIURL(t *testing.T) {
 		}
 	}
 }
+
+func TestAPIBase(t *testing.T) {
+	cfg := APIConfig{BaseURLs: map[string]string{"GHE.proxy.com": "https://ghe-api.proxy.com/api/v3/"}}
+
+	tests := []struct {
+		provider Provider
+		baseURL  string
+		explicit string
+		want     string
+	}{
+		{ProviderGitHub, "", "", "https://api.github.com"},
+		{ProviderGitHub, "https://github.com", "", "https://api.github.com"},
+		{ProviderGitHub, "https://ghe.example.com/", "", "https://ghe.example.com/api/v3"},
+		{ProviderGitHub, "https://ghe.proxy.com", "", "https://ghe-api.proxy.com/api/v3"},
+		{ProviderGitHub, "https://ghe.proxy.com", "https://key.example.com/api", "https://key.example.com/api"},
+		{ProviderGitLab, "", "", "https://gitlab.com"},
+		{ProviderGitLab, "https://git.company.com", "", "https://git.company.com"},
+		{ProviderUnknown, "https://example.com", "", ""},
+	}
+	for _, tt := range tests {
+		if got := APIBase(tt.provider, tt.baseURL, cfg.BaseURL(tt.baseURL, tt.explicit)); got != tt.want {
+			t.Errorf("APIBase(%s, %q, %q) = %q, want %q", tt.provider, tt.baseURL, tt.explicit, got, tt.want)
+		}
+	}
+
+	info := RepoInfo{Provider: ProviderGitHub, Host: "ghe.proxy.com"}
+	cfg.Apply(&info, "")
+	if got := info.APIURL(); got != "https://ghe-api.proxy.com/api/v3" {
+		t.Errorf("APIURL() with configured host = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// ForkRemote is the remote a session branch is pushed to when it goes to a
+// fork instead of origin.
+const ForkRemote = "fork"
+
+// SetRemote points remote name at url, adding it when missing. Credentials
+// never go into the URL — pushes authenticate via GIT_ASKPASS.
+func SetRemote(ctx context.Context, workDir, name, url string) error {
+	if gitCmd(ctx, workDir, nil, "remote", "get-url", name) == nil {
+		return gitCmd(ctx, workDir, nil, "remote", "set-url", name, url)
+	}
+	return gitCmd(ctx, workDir, nil, "remote", "add", name, url)
+}
+
+// remoteOrOrigin returns remote, defaulting to origin.
+func remoteOrOrigin(remote string) string {
+	if remote == "" {
+		return "origin"
+	}
+	return remote
+}
+
+// DivergedError reports that the remote branch has commits the workspace
+// does not contain — someone pushed to it since CodeForge last did. Nothing
+// is pushed; overwriting those commits is never done implicitly.
+type DivergedError struct {
+	Branch    string
+	RemoteSHA string // remote tip, when known
+	LocalSHA  string // workspace HEAD
+}
+
+func (e *DivergedError) Error() string {
+	return fmt.Sprintf("branch %q has diverged: the remote has commits the workspace does not contain", e.Branch)
+}
+
+// checkRemoteBranch compares the remote tip of branch with the workspace
+// before a push. It returns the lease to force-push with when the workspace
+// history was rewritten (rebased) since the last push, "" when a plain push
+// fast-forwards (or creates) the branch, and a *DivergedError when the remote
+// moved past what CodeForge last pushed.
+func checkRemoteBranch(ctx context.Context, workDir string, env []string, remote, branch string) (string, error) {
+	out, err := gitOutputEnv(ctx, workDir, env, "ls-remote", remote, "refs/heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("reading remote branch %s: %w", branch, err)
+	}
+	tip, _, _ := strings.Cut(strings.TrimSpace(out), "\t")
+	if tip == "" {
+		return "", nil
+	}
+	if gitCmd(ctx, workDir, nil, "merge-base", "--is-ancestor", tip, "HEAD") == nil {
+		return "", nil
+	}
+	// The lease: what we last pushed, as recorded by the remote-tracking ref.
+	if last, _ := revParse(ctx, workDir, "refs/remotes/"+remote+"/"+branch); last == tip {
+		return tip, nil
+	}
+	head, _ := revParse(ctx, workDir, "HEAD")
+	return "", &DivergedError{Branch: branch, RemoteSHA: tip, LocalSHA: head}
+}
+
+// pushBranch pushes HEAD to <remote>/<branch>. A rewritten history is pushed
+// with --force-with-lease pinned to the last pushed tip; a remote that moved
+// in the meantime — detected up front or by git rejecting the push — yields
+// a *DivergedError instead of raw git stderr.
+func pushBranch(ctx context.Context, workDir string, env []string, remote, branch string, setUpstream bool) error {
+	lease, err := checkRemoteBranch(ctx, workDir, env, remote, branch)
+	if err != nil {
+		return err
+	}
+	args := []string{"push"}
+	if setUpstream {
+		args = append(args, "-u")
+	}
+	if lease != "" {
+		args = append(args, "--force-with-lease=refs/heads/"+branch+":"+lease)
+		slog.Info("force-pushing rewritten branch with lease", "branch", branch, "lease", lease)
+	}
+	args = append(args, remote, "HEAD:refs/heads/"+branch)
+	if err := gitCmd(ctx, workDir, env, args...); err != nil {
+		if isRejectedPush(err) {
+			head, _ := revParse(ctx, workDir, "HEAD")
+			return &DivergedError{Branch: branch, LocalSHA: head}
+		}
+		return err
+	}
+	// Record the pushed tip as the next lease. Shallow clones track only the
+	// cloned branch, so push does not update this ref on its own.
+	_ = gitCmd(ctx, workDir, nil, "update-ref", "refs/remotes/"+remote+"/"+branch, "HEAD")
+	return nil
+}
+
+// isRejectedPush reports whether git refused a push because the remote
+// branch moved (non-fast-forward or a stale lease).
+func isRejectedPush(err error) bool {
+	msg := err.Error()
+	for _, s := range []string{"non-fast-forward", "fetch first", "stale info"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
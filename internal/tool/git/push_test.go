@@ -0,0 +1,112 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitAndPushToExisting(t *testing.T) {
+	ctx := context.Background()
+	opts := func(dir string) PushExistingOptions {
+		return PushExistingOptions{WorkDir: dir, BranchName: "codeforge/feature", CommitMsg: "follow-up", AuthorName: "cf", AuthorEmail: "cf@x"}
+	}
+
+	t.Run("fast-forward", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		writeFile(t, work, "more.go", "package main\n")
+		if err := CommitAndPushToExisting(ctx, opts(work)); err != nil {
+			t.Fatalf("CommitAndPushToExisting: %v", err)
+		}
+		head, _ := revParse(ctx, work, "HEAD")
+		remote, _ := revParse(ctx, work, "refs/remotes/origin/codeforge/feature")
+		if remote != head {
+			t.Errorf("remote branch %s, want %s", remote, head)
+		}
+	})
+
+	t.Run("rewritten history uses the lease", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		writeFile(t, work, "main.go", "package main\n\nfunc main() { println() }\n")
+		if err := gitCmd(ctx, work, []string{"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t"}, "commit", "-q", "--amend", "-am", "feature, amended"); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, work, "more.go", "package main\n")
+		if err := CommitAndPushToExisting(ctx, opts(work)); err != nil {
+			t.Fatalf("CommitAndPushToExisting: %v", err)
+		}
+	})
+
+	t.Run("diverged", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		otherDir := filepath.Join(filepath.Dir(work), "other")
+		other("fetch", "-q", "origin")
+		other("checkout", "-q", "-b", "codeforge/feature", "origin/codeforge/feature")
+		writeFile(t, otherDir, "extra.go", "package main\n")
+		other("add", "-A")
+		other("commit", "-q", "-m", "reviewer fixup")
+		other("push", "-q", "origin", "codeforge/feature")
+
+		before, _ := revParse(ctx, work, "HEAD")
+		writeFile(t, work, "more.go", "package main\n")
+		err := CommitAndPushToExisting(ctx, opts(work))
+		var de *DivergedError
+		if !errors.As(err, &de) {
+			t.Fatalf("expected DivergedError, got %v", err)
+		}
+		if de.Branch != "codeforge/feature" || de.RemoteSHA == "" {
+			t.Errorf("unexpected error %+v", de)
+		}
+		if after, _ := revParse(ctx, work, "HEAD"); after != before {
+			t.Error("changes should stay uncommitted when the branch diverged")
+		}
+	})
+}
+
+func TestPushToFork(t *testing.T) {
+	ctx := context.Background()
+	work, _ := initRemoteClone(t)
+	fork := filepath.Join(filepath.Dir(work), "fork.git")
+	if err := gitCmd(ctx, filepath.Dir(work), nil, "init", "-q", "--bare", fork); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetRemote(ctx, work, ForkRemote, fork); err != nil {
+		t.Fatalf("SetRemote: %v", err)
+	}
+	if err := SetRemote(ctx, work, ForkRemote, fork); err != nil {
+		t.Fatalf("SetRemote again: %v", err)
+	}
+	originTip, _ := revParse(ctx, work, "refs/remotes/origin/codeforge/feature")
+
+	writeFile(t, work, "more.go", "package main\n")
+	opts := PushExistingOptions{WorkDir: work, BranchName: "codeforge/feature", CommitMsg: "follow-up", AuthorName: "cf", AuthorEmail: "cf@x", Remote: ForkRemote}
+	if err := CommitAndPushToExisting(ctx, opts); err != nil {
+		t.Fatalf("CommitAndPushToExisting: %v", err)
+	}
+
+	head, _ := revParse(ctx, work, "HEAD")
+	if got, _ := revParse(ctx, fork, "refs/heads/codeforge/feature"); got != head {
+		t.Errorf("fork branch %s, want %s", got, head)
+	}
+	if got, _ := revParse(ctx, work, "refs/remotes/origin/codeforge/feature"); got != originTip {
+		t.Error("origin must not be pushed to")
+	}
+}
+
+func TestIsRejectedPush(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"git push: ! [rejected] main -> main (non-fast-forward)", true},
+		{"git push: ! [rejected] main -> main (fetch first)", true},
+		{"git push: ! [rejected] main -> main (stale info)", true},
+		{"git push: fatal: Authentication failed", false},
+	}
+	for _, tt := range tests {
+		if got := isRejectedPush(errors.New(tt.msg)); got != tt.want {
+			t.Errorf("isRejectedPush(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Branch update strategies.
+const (
+	UpdateRebase = "rebase"
+	UpdateMerge  = "merge"
+)
+
+// UpdateBranchOptions configures bringing a pushed branch up to date with its base.
+type UpdateBranchOptions struct {
+	WorkDir     string
+	BranchName  string
+	BaseBranch  string
+	Strategy    string // UpdateRebase (default) or UpdateMerge
+	AuthorName  string
+	AuthorEmail string
+	Token       string
+	Remote      string // remote the branch is pushed to (default origin); the base always comes from origin
+
+	// KeepConflicts leaves a conflicting update in the workspace as an
+	// uncommitted merge with conflict markers, for the CLI to resolve,
+	// instead of restoring the previous state.
+	KeepConflicts bool
+}
+
+// UpdateBranchResult describes the outcome of UpdateBranch.
+type UpdateBranchResult struct {
+	BaseSHA  string // tip of origin/<base> the branch was updated onto
+	HeadSHA  string // branch tip after the update
+	UpToDate bool   // base was already contained in the branch; nothing pushed
+}
+
+// ConflictError reports that updating a branch stopped on conflicting files.
+// The workspace is restored to its state before the attempt unless Kept.
+type ConflictError struct {
+	Strategy string
+	Base     string
+	Files    []string
+	Kept     bool // conflicts left in the workspace as an in-progress merge
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s onto %s stopped on conflicts in %s", e.Strategy, e.Base, strings.Join(e.Files, ", "))
+}
+
+// UpdateBranch fetches the latest base branch, rebases (or merges) the
+// checked-out branch onto it and pushes the result. A rebase is pushed with
+// --force-with-lease pinned to the last pushed tip, so commits someone else
+// added to the branch in the meantime are never overwritten: such a branch
+// is reported as a *DivergedError before anything is rewritten.
+func UpdateBranch(ctx context.Context, opts UpdateBranchOptions) (*UpdateBranchResult, error) {
+	workDir := opts.WorkDir
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = UpdateRebase
+	}
+
+	status, err := uncommittedChanges(ctx, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("checking status: %w", err)
+	}
+	if strings.TrimSpace(status) != "" {
+		return nil, fmt.Errorf("workspace has uncommitted changes — push them first")
+	}
+
+	pushEnv, cleanup, err := AskPassEnv(opts.Token)
+	if err != nil {
+		return nil, fmt.Errorf("preparing push credentials: %w", err)
+	}
+	defer cleanup()
+
+	if err := gitCmd(ctx, workDir, pushEnv, "fetch", "origin", opts.BaseBranch); err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", opts.BaseBranch, err)
+	}
+	baseRef := "origin/" + opts.BaseBranch
+	baseSHA, err := revParse(ctx, workDir, baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", baseRef, err)
+	}
+	result := &UpdateBranchResult{BaseSHA: baseSHA}
+
+	// Already contains the base tip: nothing to do.
+	if gitCmd(ctx, workDir, nil, "merge-base", "--is-ancestor", baseRef, "HEAD") == nil {
+		result.HeadSHA, _ = revParse(ctx, workDir, "HEAD")
+		result.UpToDate = true
+		return result, nil
+	}
+
+	remote := remoteOrOrigin(opts.Remote)
+	if _, err := checkRemoteBranch(ctx, workDir, pushEnv, remote, opts.BranchName); err != nil {
+		return nil, err
+	}
+
+	commitEnv := []string{
+		"GIT_AUTHOR_NAME=" + opts.AuthorName,
+		"GIT_AUTHOR_EMAIL=" + opts.AuthorEmail,
+		"GIT_COMMITTER_NAME=" + opts.AuthorName,
+		"GIT_COMMITTER_EMAIL=" + opts.AuthorEmail,
+	}
+	var updateErr error
+	if strategy == UpdateMerge {
+		updateErr = gitCmd(ctx, workDir, commitEnv, "merge", "--no-edit", "--autostash", baseRef)
+	} else {
+		// Keep the original authors; only the committer is rewritten.
+		updateErr = gitCmd(ctx, workDir, commitEnv[2:], "rebase", "--autostash", baseRef)
+	}
+	if updateErr != nil {
+		files, _ := ConflictedFiles(ctx, workDir)
+		_ = gitCmd(ctx, workDir, nil, strategy, "--abort")
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s onto %s: %w", strategy, baseRef, updateErr)
+		}
+		ce := &ConflictError{Strategy: strategy, Base: opts.BaseBranch, Files: files}
+		if opts.KeepConflicts {
+			// A single merge leaves all conflicts in one working tree, unlike
+			// a rebase that stops commit by commit. Committing it later (push)
+			// records the merge; no force push is needed.
+			_ = gitCmd(ctx, workDir, commitEnv, "merge", "--no-commit", "--no-ff", baseRef)
+			if kept, _ := ConflictedFiles(ctx, workDir); len(kept) > 0 {
+				ce.Files, ce.Kept = kept, true
+			} else {
+				_ = gitCmd(ctx, workDir, nil, "merge", "--abort")
+			}
+		}
+		return nil, ce
+	}
+	slog.Info("branch updated", "branch", opts.BranchName, "base", opts.BaseBranch, "strategy", strategy)
+
+	if err := pushBranch(ctx, workDir, pushEnv, remote, opts.BranchName, false); err != nil {
+		return nil, fmt.Errorf("pushing updated branch: %w", err)
+	}
+	slog.Info("updated branch pushed", "branch", opts.BranchName)
+
+	result.HeadSHA, _ = revParse(ctx, workDir, "HEAD")
+	return result, nil
+}
+
+// uncommittedChanges returns `git status --porcelain` for tracked files,
+// leaving out files the repository ignores even though they are tracked
+// (build output committed by mistake): they do not block an update and are
+// carried across it with --autostash.
+func uncommittedChanges(ctx context.Context, workDir string) (string, error) {
+	ignored, err := gitOutput(ctx, workDir, "ls-files", "-z", "--cached", "--ignored", "--exclude-standard")
+	if err != nil {
+		return "", err
+	}
+	var specs []string
+	for _, path := range strings.Split(ignored, "\x00") {
+		if path != "" {
+			specs = append(specs, ":(exclude,literal)"+path)
+		}
+	}
+	if len(specs) > 0 {
+		specs = append([]string{"."}, specs...)
+	}
+	return gitOutput(ctx, workDir, withPathspecs([]string{"status", "--porcelain", "--untracked-files=no", "--ignored=no"}, specs)...)
+}
+
+// ConflictedFiles lists paths with unresolved merge conflicts.
+func ConflictedFiles(ctx context.Context, workDir string) ([]string, error) {
+	out, err := gitOutput(ctx, workDir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+func revParse(ctx context.Context, workDir, ref string) (string, error) {
+	out, err := gitOutput(ctx, workDir, "rev-parse", "--verify", "-q", ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
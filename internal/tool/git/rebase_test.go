@@ -0,0 +1,193 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initRemoteClone creates a bare origin with a pushed feature branch and
+// returns the clone plus a helper that runs git in a second clone, used to
+// move main (or the feature branch) ahead.
+func initRemoteClone(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	runIn := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	origin := filepath.Join(root, "origin.git")
+	runIn(root, "init", "-q", "--bare", "-b", "main", origin)
+
+	other := filepath.Join(root, "other")
+	runIn(root, "clone", "-q", origin, other)
+	runIn(other, "checkout", "-q", "-b", "main")
+	writeFile(t, other, "main.go", "package main\n")
+	writeFile(t, other, "util.go", "package main\n\nfunc util() {}\n")
+	runIn(other, "add", "-A")
+	runIn(other, "commit", "-q", "-m", "init")
+	runIn(other, "push", "-q", "origin", "main")
+
+	work := filepath.Join(root, "work")
+	runIn(root, "clone", "-q", origin, work)
+	runIn(work, "checkout", "-q", "-b", "codeforge/feature")
+	writeFile(t, work, "main.go", "package main\n\nfunc main() {}\n")
+	runIn(work, "commit", "-q", "-am", "feature")
+	runIn(work, "push", "-q", "-u", "origin", "codeforge/feature")
+
+	return work, func(args ...string) { runIn(other, args...) }
+}
+
+func TestUpdateBranch(t *testing.T) {
+	ctx := context.Background()
+	opts := func(dir, strategy string) UpdateBranchOptions {
+		return UpdateBranchOptions{WorkDir: dir, BranchName: "codeforge/feature", BaseBranch: "main", Strategy: strategy, AuthorName: "cf", AuthorEmail: "cf@x"}
+	}
+
+	t.Run("up to date", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		res, err := UpdateBranch(ctx, opts(work, ""))
+		if err != nil {
+			t.Fatalf("UpdateBranch: %v", err)
+		}
+		if !res.UpToDate {
+			t.Error("expected up to date")
+		}
+	})
+
+	for _, strategy := range []string{UpdateRebase, UpdateMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			work, other := initRemoteClone(t)
+			writeFile(t, filepath.Join(filepath.Dir(work), "other"), "README.md", "hi\n")
+			other("add", "-A")
+			other("commit", "-q", "-m", "docs")
+			other("push", "-q", "origin", "main")
+
+			res, err := UpdateBranch(ctx, opts(work, strategy))
+			if err != nil {
+				t.Fatalf("UpdateBranch: %v", err)
+			}
+			if res.UpToDate || res.HeadSHA == "" {
+				t.Fatalf("unexpected result %+v", res)
+			}
+			remote, _ := revParse(ctx, work, "refs/remotes/origin/codeforge/feature")
+			if remote != res.HeadSHA {
+				t.Errorf("remote branch %s, want pushed head %s", remote, res.HeadSHA)
+			}
+			if gitCmd(ctx, work, nil, "merge-base", "--is-ancestor", res.BaseSHA, "HEAD") != nil {
+				t.Error("base tip is not contained in the updated branch")
+			}
+		})
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		writeFile(t, filepath.Join(filepath.Dir(work), "other"), "main.go", "package main\n\nfunc init() {}\n")
+		other("commit", "-q", "-am", "conflicting")
+		other("push", "-q", "origin", "main")
+		before, _ := revParse(ctx, work, "HEAD")
+
+		_, err := UpdateBranch(ctx, opts(work, UpdateRebase))
+		var ce *ConflictError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected ConflictError, got %v", err)
+		}
+		if strings.Join(ce.Files, ",") != "main.go" {
+			t.Errorf("conflicts = %v", ce.Files)
+		}
+		after, _ := revParse(ctx, work, "HEAD")
+		if after != before {
+			t.Error("aborted rebase should leave HEAD untouched")
+		}
+	})
+
+	t.Run("keep conflicts", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		writeFile(t, filepath.Join(filepath.Dir(work), "other"), "main.go", "package main\n\nfunc init() {}\n")
+		other("commit", "-q", "-am", "conflicting")
+		other("push", "-q", "origin", "main")
+
+		o := opts(work, UpdateRebase)
+		o.KeepConflicts = true
+		_, err := UpdateBranch(ctx, o)
+		var ce *ConflictError
+		if !errors.As(err, &ce) || !ce.Kept {
+			t.Fatalf("expected kept ConflictError, got %v", err)
+		}
+		files, _ := ConflictedFiles(ctx, work)
+		if strings.Join(files, ",") != "main.go" {
+			t.Errorf("workspace conflicts = %v", files)
+		}
+		data, _ := os.ReadFile(filepath.Join(work, "main.go"))
+		if !strings.Contains(string(data), "<<<<<<<") {
+			t.Error("conflict markers should be left in main.go")
+		}
+	})
+
+	t.Run("lease protects foreign commits", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		otherDir := filepath.Join(filepath.Dir(work), "other")
+		other("fetch", "-q", "origin")
+		other("checkout", "-q", "-b", "codeforge/feature", "origin/codeforge/feature")
+		writeFile(t, otherDir, "extra.go", "package main\n")
+		other("add", "-A")
+		other("commit", "-q", "-m", "reviewer fixup")
+		other("push", "-q", "origin", "codeforge/feature")
+		other("checkout", "-q", "main")
+		writeFile(t, otherDir, "README.md", "hi\n")
+		other("add", "-A")
+		other("commit", "-q", "-m", "docs")
+		other("push", "-q", "origin", "main")
+
+		before, _ := revParse(ctx, work, "HEAD")
+		_, err := UpdateBranch(ctx, opts(work, UpdateRebase))
+		var de *DivergedError
+		if !errors.As(err, &de) {
+			t.Fatalf("expected DivergedError protecting the reviewer's commit, got %v", err)
+		}
+		if after, _ := revParse(ctx, work, "HEAD"); after != before {
+			t.Error("a diverged branch should not be rebased")
+		}
+	})
+
+	t.Run("ignored tracked file changed", func(t *testing.T) {
+		work, other := initRemoteClone(t)
+		writeFile(t, filepath.Join(filepath.Dir(work), "other"), "README.md", "hi\n")
+		other("add", "-A")
+		other("commit", "-q", "-m", "docs")
+		other("push", "-q", "origin", "main")
+		// util.go is tracked but ignored, e.g. generated code committed once.
+		writeFile(t, work, ".git/info/exclude", "util.go\n")
+		writeFile(t, work, "util.go", "package main // regenerated\n")
+
+		if _, err := UpdateBranch(ctx, opts(work, UpdateRebase)); err != nil {
+			t.Fatalf("UpdateBranch: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(work, "util.go")); string(data) != "package main // regenerated\n" {
+			t.Errorf("change to the ignored file not kept: %q", data)
+		}
+	})
+
+	t.Run("dirty workspace", func(t *testing.T) {
+		work, _ := initRemoteClone(t)
+		writeFile(t, work, "main.go", "package main // edited\n")
+		if _, err := UpdateBranch(ctx, opts(work, UpdateRebase)); err == nil || !strings.Contains(err.Error(), "uncommitted") {
+			t.Errorf("expected uncommitted changes error, got %v", err)
+		}
+	})
+}
@@ -7,8 +7,9 @@ import (
 	"io"
 	"net/http"
 	neturl "net/url"
-	"strings"
 	"time"
+
+	"github.com/freema/codeforge/internal/httpclient"
 )
 
 // Repository represents a git repository from a provider.
@@ -23,8 +24,9 @@ type Repository struct {
 }
 
 // ListRepos lists repositories from a provider using the given token.
-// baseURL is optional — when set, it overrides the default API URL (for self-hosted instances).
-func ListRepos(ctx context.Context, provider Provider, token, baseURL string, page, perPage int) ([]Repository, error) {
+// apiBase is the provider API base URL, see APIBase; errMax caps the error
+// body kept in errors, see APIConfig.ErrorMaxBytes.
+func ListRepos(ctx context.Context, provider Provider, token, apiBase string, page, perPage, errMax int) ([]Repository, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -34,19 +36,15 @@ func ListRepos(ctx context.Context, provider Provider, token, baseURL string, pa
 
 	switch provider {
 	case ProviderGitHub:
-		return listGitHubRepos(ctx, token, baseURL, page, perPage)
+		return listGitHubRepos(ctx, token, apiBase, page, perPage, errMax)
 	case ProviderGitLab:
-		return listGitLabRepos(ctx, token, baseURL, page, perPage)
+		return listGitLabRepos(ctx, token, apiBase, page, perPage, errMax)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 }
 
-func listGitHubRepos(ctx context.Context, token, baseURL string, page, perPage int) ([]Repository, error) {
-	apiBase := "https://api.github.com"
-	if baseURL != "" {
-		apiBase = strings.TrimRight(baseURL, "/") + "/api/v3"
-	}
+func listGitHubRepos(ctx context.Context, token, apiBase string, page, perPage, errMax int) ([]Repository, error) {
 	url := fmt.Sprintf("%s/user/repos?per_page=%d&page=%d&sort=updated&type=all", apiBase, perPage, page)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -57,7 +55,7 @@ func listGitHubRepos(ctx context.Context, token, baseURL string, page, perPage i
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(httpclient.Provider)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("github API request: %w", err)
@@ -70,7 +68,7 @@ func listGitHubRepos(ctx context.Context, token, baseURL string, page, perPage i
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 	}
 
 	var ghRepos []struct {
@@ -113,22 +111,18 @@ type Branch struct {
 }
 
 // ListBranches lists branches for a repository from a provider using the given token.
-func ListBranches(ctx context.Context, provider Provider, token, baseURL string, repoFullName string) ([]Branch, error) {
+func ListBranches(ctx context.Context, provider Provider, token, apiBase, repoFullName string, errMax int) ([]Branch, error) {
 	switch provider {
 	case ProviderGitHub:
-		return listGitHubBranches(ctx, token, baseURL, repoFullName)
+		return listGitHubBranches(ctx, token, apiBase, repoFullName, errMax)
 	case ProviderGitLab:
-		return listGitLabBranches(ctx, token, baseURL, repoFullName)
+		return listGitLabBranches(ctx, token, apiBase, repoFullName, errMax)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 }
 
-func listGitHubBranches(ctx context.Context, token, baseURL string, repoFullName string) ([]Branch, error) {
-	apiBase := "https://api.github.com"
-	if baseURL != "" {
-		apiBase = strings.TrimRight(baseURL, "/") + "/api/v3"
-	}
+func listGitHubBranches(ctx context.Context, token, apiBase, repoFullName string, errMax int) ([]Branch, error) {
 	url := fmt.Sprintf("%s/repos/%s/branches?per_page=100", apiBase, repoFullName)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -139,7 +133,7 @@ func listGitHubBranches(ctx context.Context, token, baseURL string, repoFullName
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(httpclient.Provider)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("github API request: %w", err)
@@ -152,7 +146,7 @@ func listGitHubBranches(ctx context.Context, token, baseURL string, repoFullName
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 	}
 
 	var ghBranches []struct {
@@ -169,11 +163,7 @@ func listGitHubBranches(ctx context.Context, token, baseURL string, repoFullName
 	return branches, nil
 }
 
-func listGitLabBranches(ctx context.Context, token, baseURL string, repoFullName string) ([]Branch, error) {
-	apiBase := "https://gitlab.com"
-	if baseURL != "" {
-		apiBase = strings.TrimRight(baseURL, "/")
-	}
+func listGitLabBranches(ctx context.Context, token, apiBase, repoFullName string, errMax int) ([]Branch, error) {
 	// GitLab uses URL-encoded project path (e.g. "user/repo" -> "user%2Frepo")
 	encoded := neturl.PathEscape(repoFullName)
 	url := fmt.Sprintf("%s/api/v4/projects/%s/repository/branches?per_page=100", apiBase, encoded)
@@ -184,7 +174,7 @@ func listGitLabBranches(ctx context.Context, token, baseURL string, repoFullName
 	}
 	req.Header.Set("PRIVATE-TOKEN", token)
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(httpclient.Provider)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("gitlab API request: %w", err)
@@ -197,7 +187,7 @@ func listGitLabBranches(ctx context.Context, token, baseURL string, repoFullName
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 	}
 
 	var glBranches []struct {
@@ -227,22 +217,18 @@ type PullRequest struct {
 }
 
 // ListPullRequests lists open pull requests / merge requests for a repository.
-func ListPullRequests(ctx context.Context, provider Provider, token, baseURL, repoFullName string) ([]PullRequest, error) {
+func ListPullRequests(ctx context.Context, provider Provider, token, apiBase, repoFullName string, errMax int) ([]PullRequest, error) {
 	switch provider {
 	case ProviderGitHub:
-		return listGitHubPullRequests(ctx, token, baseURL, repoFullName)
+		return listGitHubPullRequests(ctx, token, apiBase, repoFullName, errMax)
 	case ProviderGitLab:
-		return listGitLabMergeRequests(ctx, token, baseURL, repoFullName)
+		return listGitLabMergeRequests(ctx, token, apiBase, repoFullName, errMax)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 }
 
-func listGitHubPullRequests(ctx context.Context, token, baseURL, repoFullName string) ([]PullRequest, error) {
-	apiBase := "https://api.github.com"
-	if baseURL != "" {
-		apiBase = strings.TrimRight(baseURL, "/") + "/api/v3"
-	}
+func listGitHubPullRequests(ctx context.Context, token, apiBase, repoFullName string, errMax int) ([]PullRequest, error) {
 	url := fmt.Sprintf("%s/repos/%s/pulls?state=open&per_page=50&sort=updated&direction=desc", apiBase, repoFullName)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -253,7 +239,7 @@ func listGitHubPullRequests(ctx context.Context, token, baseURL, repoFullName st
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(httpclient.Provider)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("github API request: %w", err)
@@ -266,7 +252,7 @@ func listGitHubPullRequests(ctx context.Context, token, baseURL, repoFullName st
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+		return nil, fmt.Errorf("github API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 	}
 
 	var ghPRs []struct {
@@ -297,11 +283,7 @@ func listGitHubPullRequests(ctx context.Context, token, baseURL, repoFullName st
 	return prs, nil
 }
 
-func listGitLabMergeRequests(ctx context.Context, token, baseURL, repoFullName string) ([]PullRequest, error) {
-	apiBase := "https://gitlab.com"
-	if baseURL != "" {
-		apiBase = strings.TrimRight(baseURL, "/")
-	}
+func listGitLabMergeRequests(ctx context.Context, token, apiBase, repoFullName string, errMax int) ([]PullRequest, error) {
 	encoded := neturl.PathEscape(repoFullName)
 	url := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests?state=opened&per_page=50&order_by=updated_at&sort=desc", apiBase, encoded)
 
@@ -311,7 +293,7 @@ func listGitLabMergeRequests(ctx context.Context, token, baseURL, repoFullName s
 	}
 	req.Header.Set("PRIVATE-TOKEN", token)
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(httpclient.Provider)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("gitlab API request: %w", err)
@@ -324,7 +306,7 @@ func listGitLabMergeRequests(ctx context.Context, token, baseURL, repoFullName s
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 	}
 
 	var glMRs []struct {
@@ -355,11 +337,7 @@ func listGitLabMergeRequests(ctx context.Context, token, baseURL, repoFullName s
 	return prs, nil
 }
 
-func listGitLabRepos(ctx context.Context, token, baseURL string, page, perPage int) ([]Repository, error) {
-	apiBase := "https://gitlab.com"
-	if baseURL != "" {
-		apiBase = strings.TrimRight(baseURL, "/")
-	}
+func listGitLabRepos(ctx context.Context, token, apiBase string, page, perPage, errMax int) ([]Repository, error) {
 	url := fmt.Sprintf("%s/api/v4/projects?membership=true&per_page=%d&page=%d&order_by=last_activity_at", apiBase, perPage, page)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -368,7 +346,7 @@ func listGitLabRepos(ctx context.Context, token, baseURL string, page, perPage i
 	}
 	req.Header.Set("PRIVATE-TOKEN", token)
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(httpclient.Provider)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("gitlab API request: %w", err)
@@ -381,7 +359,7 @@ func listGitLabRepos(ctx context.Context, token, baseURL string, page, perPage i
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(body, 500))
+		return nil, fmt.Errorf("gitlab API returned %d: %s", resp.StatusCode, truncateBytes(body, errMax))
 	}
 
 	var glProjects []struct {
@@ -5,7 +5,6 @@ import (
 	"log/slog"
 
 	"github.com/freema/codeforge/internal/ai"
-	"github.com/freema/codeforge/internal/slug"
 )
 
 // AnalysisResult holds auto-generated PR metadata.
@@ -17,10 +16,10 @@ type AnalysisResult struct {
 
 // Analyzer generates PR metadata from a session prompt.
 type Analyzer struct {
-	ai ai.Client // optional, nil = fallback mode
+	ai ai.Client // optional, nil = offline heuristic mode
 }
 
-// NewAnalyzer creates a prompt analyzer. Pass nil for ai to use fallback mode.
+// NewAnalyzer creates a prompt analyzer. Pass nil for ai to use the offline heuristic mode.
 func NewAnalyzer(aiClient ...ai.Client) *Analyzer {
 	a := &Analyzer{}
 	if len(aiClient) > 0 {
@@ -31,39 +30,22 @@ func NewAnalyzer(aiClient ...ai.Client) *Analyzer {
 
 // Analyze generates branch slug, PR title, and description from session prompt.
 // If an AI client is available, it generates smart metadata.
-// Otherwise falls back to simple truncation.
-func (a *Analyzer) Analyze(ctx context.Context, prompt string, sessionID string) *AnalysisResult {
-	branchSlug := slug.Generate(prompt, sessionID)
-
+// Otherwise falls back to a local heuristic over the prompt's first line and
+// the top changed paths (see HeuristicMetadata).
+func (a *Analyzer) Analyze(ctx context.Context, prompt string, sessionID string, changedPaths []string) *AnalysisResult {
 	// Try AI generation
 	if a.ai != nil {
 		meta := ai.GeneratePRMetadata(ctx, a.ai, "", prompt)
 		if meta != nil {
 			slog.Info("AI-generated PR metadata", "title", meta.Title)
 			return &AnalysisResult{
-				BranchSlug:  branchSlug,
+				BranchSlug:  BranchSlug(prompt, sessionID, changedPaths),
 				PRTitle:     meta.Title,
 				Description: meta.Description,
 			}
 		}
 	}
 
-	// Fallback: truncate prompt
-	title := truncateStr(prompt, 60)
-	if len(prompt) > 60 {
-		title += "..."
-	}
-
-	return &AnalysisResult{
-		BranchSlug:  branchSlug,
-		PRTitle:     title,
-		Description: "Automated changes by CodeForge.",
-	}
-}
-
-func truncateStr(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen]
+	// Fallback: offline heuristic
+	return HeuristicMetadata(prompt, sessionID, changedPaths)
 }
@@ -0,0 +1,57 @@
+package runner
+
+// Claude Code model backends.
+const (
+	BackendAnthropic = "anthropic"
+	BackendBedrock   = "bedrock"
+	BackendVertex    = "vertex"
+)
+
+// ClaudeBackend describes how Claude Code reaches the model: the Anthropic
+// API directly, AWS Bedrock or Google Vertex AI. Credentials come from the
+// standard provider chains (AWS env/profile/instance role, Google ADC).
+type ClaudeBackend struct {
+	Name            string // anthropic (default), bedrock, vertex
+	AWSRegion       string // bedrock region, e.g. us-east-1
+	AWSProfile      string // optional AWS shared-config profile (bedrock)
+	VertexRegion    string // vertex region, e.g. us-east5
+	VertexProjectID string // GCP project (vertex)
+	CredentialsFile string // optional service-account JSON (vertex)
+}
+
+// ValidClaudeBackend reports whether name is a known backend ("" = default).
+func ValidClaudeBackend(name string) bool {
+	switch name {
+	case "", BackendAnthropic, BackendBedrock, BackendVertex:
+		return true
+	}
+	return false
+}
+
+// Direct reports whether the backend is the Anthropic API, which needs an
+// ANTHROPIC_API_KEY rather than cloud credentials.
+func (b ClaudeBackend) Direct() bool {
+	return b.Name == "" || b.Name == BackendAnthropic
+}
+
+// Env returns the environment variables that switch Claude Code to the backend.
+func (b ClaudeBackend) Env() []string {
+	var env []string
+	add := func(key, value string) {
+		if value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+	switch b.Name {
+	case BackendBedrock:
+		add("CLAUDE_CODE_USE_BEDROCK", "1")
+		add("AWS_REGION", b.AWSRegion)
+		add("AWS_PROFILE", b.AWSProfile)
+	case BackendVertex:
+		add("CLAUDE_CODE_USE_VERTEX", "1")
+		add("CLOUD_ML_REGION", b.VertexRegion)
+		add("ANTHROPIC_VERTEX_PROJECT_ID", b.VertexProjectID)
+		add("GOOGLE_APPLICATION_CREDENTIALS", b.CredentialsFile)
+	}
+	return env
+}
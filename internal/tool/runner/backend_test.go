@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClaudeBackendEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend ClaudeBackend
+		want    []string
+	}{
+		{"default", ClaudeBackend{}, nil},
+		{"anthropic", ClaudeBackend{Name: BackendAnthropic, AWSRegion: "ignored"}, nil},
+		{
+			"bedrock",
+			ClaudeBackend{Name: BackendBedrock, AWSRegion: "eu-central-1", VertexRegion: "ignored"},
+			[]string{"CLAUDE_CODE_USE_BEDROCK=1", "AWS_REGION=eu-central-1"},
+		},
+		{
+			"bedrock with profile",
+			ClaudeBackend{Name: BackendBedrock, AWSRegion: "us-east-1", AWSProfile: "ai"},
+			[]string{"CLAUDE_CODE_USE_BEDROCK=1", "AWS_REGION=us-east-1", "AWS_PROFILE=ai"},
+		},
+		{
+			"vertex",
+			ClaudeBackend{Name: BackendVertex, VertexRegion: "us-east5", VertexProjectID: "p", CredentialsFile: "/etc/gcp.json"},
+			[]string{"CLAUDE_CODE_USE_VERTEX=1", "CLOUD_ML_REGION=us-east5", "ANTHROPIC_VERTEX_PROJECT_ID=p", "GOOGLE_APPLICATION_CREDENTIALS=/etc/gcp.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backend.Env(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Env() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidClaudeBackend(t *testing.T) {
+	for _, name := range []string{"", "anthropic", "bedrock", "vertex"} {
+		if !ValidClaudeBackend(name) {
+			t.Errorf("%q should be valid", name)
+		}
+	}
+	if ValidClaudeBackend("azure") {
+		t.Error("azure should be invalid")
+	}
+}
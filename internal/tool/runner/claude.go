@@ -8,12 +8,12 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
+
+	"github.com/freema/codeforge/internal/runas"
 )
 
 // ClaudeRunner executes Claude Code CLI.
@@ -78,6 +78,23 @@ func (c *ClaudeRunner) buildArgs(opts RunOptions) []string {
 	return args
 }
 
+// effortThinkingTokens maps a reasoning effort level to a Claude Code
+// extended-thinking budget (31999 is the CLI's "ultrathink" maximum).
+var effortThinkingTokens = map[string]int{
+	"low":    4000,
+	"medium": 10000,
+	"high":   31999,
+}
+
+// thinkingTokens resolves the MAX_THINKING_TOKENS value for a run. An explicit
+// budget wins over the effort level; 0 leaves the CLI default.
+func thinkingTokens(opts RunOptions) int {
+	if opts.ThinkingBudgetTokens > 0 {
+		return opts.ThinkingBudgetTokens
+	}
+	return effortThinkingTokens[opts.ReasoningEffort]
+}
+
 // Run executes Claude Code with stream-json output, calling OnEvent for each line.
 func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, error) {
 	args := c.buildArgs(opts)
@@ -105,47 +122,12 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 	cmd := exec.CommandContext(ctx, binary, cmdArgs...)
 	cmd.Dir = opts.WorkDir
 
-	// Build environment. If running as root and a "codeforge" user exists,
-	// drop privileges and replace HOME/SHELL so Claude Code accepts bypassPermissions.
+	// Drop privileges and replace HOME/SHELL/USER so Claude Code accepts
+	// bypassPermissions (it refuses to run as root).
 	baseEnv := os.Environ()
-	if os.Getuid() == 0 {
-		if u, err := user.Lookup("codeforge"); err == nil {
-			uid, _ := strconv.ParseUint(u.Uid, 10, 32)
-			gid, _ := strconv.ParseUint(u.Gid, 10, 32)
-
-			// Use gosu for privilege dropping. Go's SysProcAttr.Credential
-			// can fail with ENOENT on Alpine + Docker (kernel-level exec issue
-			// with Setpgid + Credential combination).
-			if gosuPath, gosuErr := exec.LookPath("gosu"); gosuErr == nil {
-				gosuArgs := append([]string{u.Username, cmd.Path}, cmd.Args[1:]...)
-				cmd = exec.CommandContext(ctx, gosuPath, gosuArgs...)
-				cmd.Dir = opts.WorkDir
-				slog.Debug("dropping privileges for "+c.label+" CLI via gosu", "uid", uid, "gid", gid)
-			} else {
-				// Fallback: use SysProcAttr.Credential directly
-				cmd.SysProcAttr = &syscall.SysProcAttr{
-					Setpgid:    true,
-					Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)},
-				}
-				slog.Debug("dropping privileges for "+c.label+" CLI via credential", "uid", uid, "gid", gid)
-			}
-
-			// Filter out HOME/SHELL/USER from root env and replace them
-			filtered := make([]string, 0, len(baseEnv))
-			for _, e := range baseEnv {
-				if !strings.HasPrefix(e, "HOME=") &&
-					!strings.HasPrefix(e, "SHELL=") &&
-					!strings.HasPrefix(e, "USER=") {
-					filtered = append(filtered, e)
-				}
-			}
-			filtered = append(filtered,
-				"HOME="+u.HomeDir,
-				"SHELL=/bin/sh",
-				"USER=codeforge",
-			)
-			baseEnv = filtered
-		}
+	if opts.RunAs != nil {
+		cmd = dropPrivileges(ctx, c.label, cmd, opts.RunAs)
+		baseEnv = runas.Env(baseEnv, opts.RunAs)
 	}
 	configureGracefulKill(cmd)
 
@@ -156,6 +138,10 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 	} else {
 		cmd.Env = baseEnv
 	}
+	cmd.Env = append(cmd.Env, opts.Env...)
+	if tokens := thinkingTokens(opts); tokens > 0 {
+		cmd.Env = append(cmd.Env, "MAX_THINKING_TOKENS="+strconv.Itoa(tokens))
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -180,6 +166,7 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 	var resultText string        // from the "result" event (authoritative if present)
 	var lastAssistantText string // from the latest "assistant" text event (fallback)
 	var inputTokens, outputTokens int
+	var costUSD float64
 
 	for scanner.Scan() {
 		line := scanner.Bytes()
@@ -195,7 +182,7 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 		}
 
 		// Extract result text and usage from stream events
-		rText, aText, iTokens, oTokens := extractStreamData(line)
+		rText, aText, iTokens, oTokens, cost := extractStreamData(line)
 		if rText != "" {
 			resultText = rText
 		}
@@ -204,6 +191,7 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 		}
 		inputTokens += iTokens
 		outputTokens += oTokens
+		costUSD += cost
 	}
 
 	err = cmd.Wait()
@@ -222,6 +210,7 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 		Duration:     duration,
 		InputTokens:  inputTokens,
 		OutputTokens: outputTokens,
+		CostUSD:      costUSD,
 	}
 
 	if cmd.ProcessState != nil {
@@ -254,22 +243,24 @@ func (c *ClaudeRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 //   - resultText: from the final "result" event (authoritative when present)
 //   - assistantText: from "assistant" text events (fallback when result is empty)
 //   - inputTokens, outputTokens: from the "result" event usage
-func extractStreamData(line []byte) (resultText, assistantText string, inputTokens, outputTokens int) {
+//   - costUSD: the "result" event's total_cost_usd
+func extractStreamData(line []byte) (resultText, assistantText string, inputTokens, outputTokens int, costUSD float64) {
 	var event map[string]json.RawMessage
 	if err := json.Unmarshal(line, &event); err != nil {
-		return "", "", 0, 0
+		return "", "", 0, 0, 0
 	}
 
 	var eventType string
 	if err := json.Unmarshal(event["type"], &eventType); err != nil {
-		return "", "", 0, 0
+		return "", "", 0, 0, 0
 	}
 
 	switch eventType {
 	case "result":
 		var result struct {
-			Result string `json:"result"`
-			Usage  struct {
+			Result       string  `json:"result"`
+			TotalCostUSD float64 `json:"total_cost_usd"`
+			Usage        struct {
 				InputTokens  int `json:"input_tokens"`
 				OutputTokens int `json:"output_tokens"`
 			} `json:"usage"`
@@ -278,6 +269,7 @@ func extractStreamData(line []byte) (resultText, assistantText string, inputToke
 			resultText = result.Result
 			inputTokens = result.Usage.InputTokens
 			outputTokens = result.Usage.OutputTokens
+			costUSD = result.TotalCostUSD
 		}
 
 	case "assistant":
@@ -303,5 +295,5 @@ func extractStreamData(line []byte) (resultText, assistantText string, inputToke
 		}
 	}
 
-	return resultText, assistantText, inputTokens, outputTokens
+	return resultText, assistantText, inputTokens, outputTokens, costUSD
 }
@@ -42,3 +42,24 @@ func TestClaudeRunner_BuildArgsBaseFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestThinkingTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RunOptions
+		want int
+	}{
+		{"unset", RunOptions{}, 0},
+		{"effort high", RunOptions{ReasoningEffort: "high"}, 31999},
+		{"effort low", RunOptions{ReasoningEffort: "low"}, 4000},
+		{"explicit budget wins", RunOptions{ReasoningEffort: "high", ThinkingBudgetTokens: 8000}, 8000},
+		{"unknown effort", RunOptions{ReasoningEffort: "extreme"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thinkingTokens(tt.opts); got != tt.want {
+				t.Errorf("thinkingTokens = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
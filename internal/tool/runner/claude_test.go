@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"testing"
+)
+
+func TestExtractStreamData(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantResult    string
+		wantAssistant string
+		wantInTokens  int
+		wantOutTokens int
+		wantCost      float64
+	}{
+		{
+			name:          "result with usage and cost",
+			input:         `{"type":"result","subtype":"success","result":"Done.","total_cost_usd":0.0421,"usage":{"input_tokens":1200,"output_tokens":340}}`,
+			wantResult:    "Done.",
+			wantInTokens:  1200,
+			wantOutTokens: 340,
+			wantCost:      0.0421,
+		},
+		{
+			name:          "assistant text",
+			input:         `{"type":"assistant","message":{"content":[{"type":"text","text":"Looking"},{"type":"tool_use"},{"type":"text","text":" around"}]}}`,
+			wantAssistant: "Looking around",
+		},
+		{
+			name:  "system ignored",
+			input: `{"type":"system","subtype":"init"}`,
+		},
+		{
+			name:  "invalid JSON",
+			input: `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, assistant, in, out, cost := extractStreamData([]byte(tt.input))
+			if result != tt.wantResult || assistant != tt.wantAssistant {
+				t.Errorf("text = %q / %q, want %q / %q", result, assistant, tt.wantResult, tt.wantAssistant)
+			}
+			if in != tt.wantInTokens || out != tt.wantOutTokens {
+				t.Errorf("tokens = %d/%d, want %d/%d", in, out, tt.wantInTokens, tt.wantOutTokens)
+			}
+			if cost != tt.wantCost {
+				t.Errorf("cost = %v, want %v", cost, tt.wantCost)
+			}
+		})
+	}
+}
@@ -8,11 +8,11 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/freema/codeforge/internal/runas"
 )
 
 // CodexRunner executes OpenAI Codex CLI.
@@ -34,8 +34,8 @@ func NewCodexRunner(binaryPath string) *CodexRunner {
 	return &CodexRunner{binaryPath: binaryPath}
 }
 
-// Run executes Codex CLI with JSON output, calling OnEvent for each line.
-func (c *CodexRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, error) {
+// buildArgs assembles the codex exec command line.
+func (c *CodexRunner) buildArgs(opts RunOptions) []string {
 	// --full-auto = --sandbox workspace-write + auto-approve on-request.
 	// We use danger-full-access instead because Codex's Landlock sandbox
 	// does not work inside Docker (missing kernel support / capabilities).
@@ -53,7 +53,11 @@ func (c *CodexRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, err
 	if opts.Model != "" {
 		args = append(args, "-m", opts.Model)
 	}
-	// MaxTurns, MaxBudgetUSD, AllowedTools are silently ignored — Codex does not support them.
+	if opts.ReasoningEffort != "" {
+		args = append(args, "-c", "model_reasoning_effort="+opts.ReasoningEffort)
+	}
+	// MaxTurns, MaxBudgetUSD, AllowedTools and ThinkingBudgetTokens are silently
+	// ignored — Codex does not support them.
 
 	// If AppendSystemPrompt is set, prepend it to the prompt (Codex has no system prompt flag).
 	prompt := opts.Prompt
@@ -62,43 +66,21 @@ func (c *CodexRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, err
 	}
 
 	args = append(args, prompt)
+	return args
+}
+
+// Run executes Codex CLI with JSON output, calling OnEvent for each line.
+func (c *CodexRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, error) {
+	args := c.buildArgs(opts)
 
 	cmd := exec.CommandContext(ctx, c.binaryPath, args...)
 	cmd.Dir = opts.WorkDir
 
-	// Build environment. If running as root and a "codeforge" user exists,
-	// drop privileges via gosu.
+	// Drop to the session's CLI user, if the server switches users at all.
 	baseEnv := os.Environ()
-	if os.Getuid() == 0 {
-		if u, err := user.Lookup("codeforge"); err == nil {
-			uid, _ := strconv.ParseUint(u.Uid, 10, 32)
-			gid, _ := strconv.ParseUint(u.Gid, 10, 32)
-
-			if gosuPath, gosuErr := exec.LookPath("gosu"); gosuErr == nil {
-				gosuArgs := append([]string{u.Username, cmd.Path}, cmd.Args[1:]...)
-				cmd = exec.CommandContext(ctx, gosuPath, gosuArgs...)
-				cmd.Dir = opts.WorkDir
-				slog.Debug("dropping privileges for codex CLI via gosu", "uid", uid, "gid", gid)
-			} else {
-				slog.Debug("gosu not found, running codex CLI as root")
-			}
-
-			// Filter out HOME/SHELL/USER from root env and replace them
-			filtered := make([]string, 0, len(baseEnv))
-			for _, e := range baseEnv {
-				if !strings.HasPrefix(e, "HOME=") &&
-					!strings.HasPrefix(e, "SHELL=") &&
-					!strings.HasPrefix(e, "USER=") {
-					filtered = append(filtered, e)
-				}
-			}
-			filtered = append(filtered,
-				"HOME="+u.HomeDir,
-				"SHELL=/bin/sh",
-				"USER=codeforge",
-			)
-			baseEnv = filtered
-		}
+	if opts.RunAs != nil {
+		cmd = dropPrivileges(ctx, "codex", cmd, opts.RunAs)
+		baseEnv = runas.Env(baseEnv, opts.RunAs)
 	}
 
 	configureGracefulKill(cmd)
@@ -113,6 +95,7 @@ func (c *CodexRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, err
 	} else {
 		cmd.Env = baseEnv
 	}
+	cmd.Env = append(cmd.Env, opts.Env...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
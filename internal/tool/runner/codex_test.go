@@ -111,3 +111,20 @@ func TestNewCodexRunner_PathResolution(t *testing.T) {
 		})
 	}
 }
+
+func TestCodexRunner_BuildArgsReasoningEffort(t *testing.T) {
+	c := NewCodexRunner("codex")
+
+	args := c.buildArgs(RunOptions{Prompt: "p", ReasoningEffort: "high"})
+	if !containsArg(args, "model_reasoning_effort=high") {
+		t.Errorf("expected reasoning effort override in %v", args)
+	}
+	if args[len(args)-1] != "p" {
+		t.Errorf("prompt must stay the last argument, got %v", args)
+	}
+
+	args = c.buildArgs(RunOptions{Prompt: "p"})
+	if containsArg(args, "-c") {
+		t.Errorf("no config override expected without reasoning, got %v", args)
+	}
+}
@@ -8,11 +8,11 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/freema/codeforge/internal/runas"
 )
 
 // CursorRunner executes Cursor CLI (cursor-agent).
@@ -52,34 +52,11 @@ func (c *CursorRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 	cmd := exec.CommandContext(ctx, c.binaryPath, args...)
 	cmd.Dir = opts.WorkDir
 
+	// Drop to the session's CLI user, if the server switches users at all.
 	baseEnv := os.Environ()
-	if os.Getuid() == 0 {
-		if u, err := user.Lookup("codeforge"); err == nil {
-			uid, _ := strconv.ParseUint(u.Uid, 10, 32)
-			gid, _ := strconv.ParseUint(u.Gid, 10, 32)
-
-			if gosuPath, gosuErr := exec.LookPath("gosu"); gosuErr == nil {
-				gosuArgs := append([]string{u.Username, cmd.Path}, cmd.Args[1:]...)
-				cmd = exec.CommandContext(ctx, gosuPath, gosuArgs...)
-				cmd.Dir = opts.WorkDir
-				slog.Debug("dropping privileges for cursor CLI via gosu", "uid", uid, "gid", gid)
-			}
-
-			filtered := make([]string, 0, len(baseEnv))
-			for _, e := range baseEnv {
-				if !strings.HasPrefix(e, "HOME=") &&
-					!strings.HasPrefix(e, "SHELL=") &&
-					!strings.HasPrefix(e, "USER=") {
-					filtered = append(filtered, e)
-				}
-			}
-			filtered = append(filtered,
-				"HOME="+u.HomeDir,
-				"SHELL=/bin/sh",
-				"USER=codeforge",
-			)
-			baseEnv = filtered
-		}
+	if opts.RunAs != nil {
+		cmd = dropPrivileges(ctx, "cursor", cmd, opts.RunAs)
+		baseEnv = runas.Env(baseEnv, opts.RunAs)
 	}
 
 	configureGracefulKill(cmd)
@@ -89,6 +66,7 @@ func (c *CursorRunner) Run(ctx context.Context, opts RunOptions) (*RunResult, er
 	} else {
 		cmd.Env = baseEnv
 	}
+	cmd.Env = append(cmd.Env, opts.Env...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
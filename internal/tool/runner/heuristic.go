@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/freema/codeforge/internal/slug"
+)
+
+const (
+	heuristicTitleLen = 72
+	heuristicTopDirs  = 3
+)
+
+// markdownPrefix matches a leading markdown heading, bullet or quote marker.
+var markdownPrefix = regexp.MustCompile(`^(#{1,6}|[-*>])\s+`)
+
+// HeuristicMetadata derives PR metadata locally, without any LLM call.
+// The title comes from the prompt's first meaningful line; when the prompt
+// carries nothing usable, the most-touched directories name the change instead.
+func HeuristicMetadata(prompt, sessionID string, changedPaths []string) *AnalysisResult {
+	title := heuristicTitle(prompt, changedPaths)
+	if title == "" {
+		title = "Automated changes by CodeForge"
+	}
+
+	return &AnalysisResult{
+		BranchSlug:  BranchSlug(prompt, sessionID, changedPaths),
+		PRTitle:     title,
+		Description: heuristicDescription(firstLine(prompt), changedPaths, topChangedDirs(changedPaths, heuristicTopDirs)),
+	}
+}
+
+// BranchSlug is the single source of PR branch slugs, used whether the PR
+// metadata comes from the caller, the AI helper, or the heuristic: it slugs
+// the heuristic title, so the branch never depends on who wrote the title.
+func BranchSlug(prompt, sessionID string, changedPaths []string) string {
+	return slug.Generate(heuristicTitle(prompt, changedPaths), sessionID)
+}
+
+// heuristicTitle returns the capped title derived from the prompt's first
+// line, or from the top changed directories when the prompt has no usable
+// words. Empty when neither yields anything.
+func heuristicTitle(prompt string, changedPaths []string) string {
+	line := firstLine(prompt)
+	if slug.Slugify(line) == "" {
+		if top := topChangedDirs(changedPaths, heuristicTopDirs); len(top) > 0 {
+			line = "Update " + strings.Join(top, ", ")
+		}
+	}
+	return shortenTitle(line)
+}
+
+// firstLine returns the first non-empty line of the prompt with recognized
+// markdown prefixes (headings, bullets, quotes) stripped.
+func firstLine(prompt string) string {
+	for _, l := range strings.Split(prompt, "\n") {
+		l = strings.TrimSpace(l)
+		for markdownPrefix.MatchString(l) {
+			l = strings.TrimSpace(markdownPrefix.ReplaceAllString(l, ""))
+		}
+		if l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// shortenTitle capitalizes the title and caps it at heuristicTitleLen,
+// cutting on a word boundary where possible.
+func shortenTitle(s string) string {
+	s = strings.TrimRight(strings.TrimSpace(s), ".:;,")
+	if s == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	s = string(unicode.ToUpper(r)) + s[size:]
+
+	if len(s) <= heuristicTitleLen {
+		return s
+	}
+	cut := s[:heuristicTitleLen-3]
+	if idx := strings.LastIndexByte(cut, ' '); idx > heuristicTitleLen/2 {
+		cut = cut[:idx]
+	}
+	// Never split a multi-byte rune.
+	for !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return strings.TrimRight(cut, " .,;:") + "..."
+}
+
+// topChangedDirs groups changed files by parent directory and returns the n
+// most-touched directories. Files at the repository root are not listed —
+// directory granularity keeps the summary uniform.
+func topChangedDirs(paths []string, n int) []string {
+	counts := make(map[string]int)
+	for _, p := range paths {
+		dir := path.Dir(strings.TrimSpace(p))
+		if dir == "." || dir == "/" {
+			continue
+		}
+		counts[dir]++
+	}
+
+	dirs := make([]string, 0, len(counts))
+	for d := range counts {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if counts[dirs[i]] != counts[dirs[j]] {
+			return counts[dirs[i]] > counts[dirs[j]]
+		}
+		return dirs[i] < dirs[j]
+	})
+	if len(dirs) > n {
+		dirs = dirs[:n]
+	}
+	return dirs
+}
+
+func heuristicDescription(task string, changedPaths, top []string) string {
+	var b strings.Builder
+	b.WriteString("Automated changes by CodeForge.")
+	if task != "" {
+		b.WriteString("\n\n**Task:** " + task)
+	}
+	if len(changedPaths) > 0 {
+		fmt.Fprintf(&b, "\n\n**Changed files:** %d", len(changedPaths))
+		if len(top) > 0 {
+			b.WriteString(", mostly in:\n")
+			for _, d := range top {
+				b.WriteString("\n- `" + d + "/`")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHeuristicMetadata(t *testing.T) {
+	const sessionID = "550e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name      string
+		prompt    string
+		paths     []string
+		wantTitle string
+		wantSlug  string
+	}{
+		{
+			name:      "first line of prompt",
+			prompt:    "fix the failing auth tests.\n\nThey break on CI since the token refactor.",
+			paths:     []string{"internal/auth/token.go"},
+			wantTitle: "Fix the failing auth tests",
+			wantSlug:  "fix-the-failing-auth-tests-550e8400",
+		},
+		{
+			name:      "markdown heading stripped",
+			prompt:    "\n## Add rate limiting\n- per tenant",
+			wantTitle: "Add rate limiting",
+			wantSlug:  "add-rate-limiting-550e8400",
+		},
+		{
+			name:      "inline code at start is kept",
+			prompt:    "`foo` is nil after reload",
+			wantTitle: "`foo` is nil after reload",
+			wantSlug:  "foo-is-nil-after-reload-550e8400",
+		},
+		{
+			name:      "nested markdown prefixes",
+			prompt:    "> - Bump the Go toolchain",
+			wantTitle: "Bump the Go toolchain",
+			wantSlug:  "bump-the-go-toolchain-550e8400",
+		},
+		{
+			name:      "empty prompt falls back to changed directories",
+			prompt:    "",
+			paths:     []string{"internal/server/a.go", "internal/server/b.go", "docs/api.md", "README.md"},
+			wantTitle: "Update internal/server, docs",
+			wantSlug:  "update-internal-server-docs-550e8400",
+		},
+		{
+			name:      "nothing to go on",
+			prompt:    "   ",
+			wantTitle: "Automated changes by CodeForge",
+			wantSlug:  "session-550e8400",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HeuristicMetadata(tt.prompt, sessionID, tt.paths)
+			if got.PRTitle != tt.wantTitle {
+				t.Errorf("PRTitle = %q, want %q", got.PRTitle, tt.wantTitle)
+			}
+			if got.BranchSlug != tt.wantSlug {
+				t.Errorf("BranchSlug = %q, want %q", got.BranchSlug, tt.wantSlug)
+			}
+		})
+	}
+}
+
+func TestHeuristicMetadata_LongTitleCutOnWord(t *testing.T) {
+	prompt := "Refactor the session service so that every Redis write goes through a single pipeline helper with retries"
+	got := HeuristicMetadata(prompt, "abc", nil)
+
+	if len(got.PRTitle) > heuristicTitleLen {
+		t.Fatalf("title too long (%d): %q", len(got.PRTitle), got.PRTitle)
+	}
+	if !strings.HasSuffix(got.PRTitle, "...") {
+		t.Errorf("truncated title should end with ellipsis: %q", got.PRTitle)
+	}
+	if strings.Contains(got.PRTitle, " ...") {
+		t.Errorf("title should not end on a space: %q", got.PRTitle)
+	}
+}
+
+func TestHeuristicMetadata_DescriptionListsPaths(t *testing.T) {
+	got := HeuristicMetadata("Fix bug", "abc", []string{"pkg/a.go", "pkg/b.go", "cmd/main.go"})
+
+	if !strings.Contains(got.Description, "**Task:** Fix bug") {
+		t.Errorf("description missing task line: %q", got.Description)
+	}
+	if !strings.Contains(got.Description, "**Changed files:** 3") {
+		t.Errorf("description missing file count: %q", got.Description)
+	}
+	if strings.Index(got.Description, "`pkg/`") > strings.Index(got.Description, "`cmd/`") {
+		t.Errorf("most-touched path should be listed first: %q", got.Description)
+	}
+}
+
+func TestAnalyzer_NoAIUsesHeuristic(t *testing.T) {
+	a := NewAnalyzer()
+	got := a.Analyze(context.Background(), "Add health endpoint", "12345678abcd", []string{"internal/server/health.go"})
+
+	if got.PRTitle != "Add health endpoint" {
+		t.Errorf("PRTitle = %q", got.PRTitle)
+	}
+	if got.BranchSlug != "add-health-endpoint-12345678" {
+		t.Errorf("BranchSlug = %q", got.BranchSlug)
+	}
+}
+
+func TestBranchSlug_IndependentOfTitleSource(t *testing.T) {
+	prompt := "Add health endpoint\n\nIt should report Redis and SQLite status."
+	paths := []string{"internal/server/health.go"}
+
+	explicit := BranchSlug(prompt, "12345678abcd", paths)
+	heuristic := HeuristicMetadata(prompt, "12345678abcd", paths).BranchSlug
+	if explicit != heuristic {
+		t.Errorf("BranchSlug = %q, heuristic slug = %q; want identical", explicit, heuristic)
+	}
+	if explicit != "add-health-endpoint-12345678" {
+		t.Errorf("BranchSlug = %q", explicit)
+	}
+}
@@ -0,0 +1,15 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+// dropPrivileges rewrites cmd to run as id; label names the CLI in logs.
+func dropPrivileges(ctx context.Context, label string, cmd *exec.Cmd, id *runas.Identity) *exec.Cmd {
+	slog.Debug("dropping privileges for "+label+" CLI", "uid", id.UID, "gid", id.GID)
+	return runas.Command(ctx, cmd, id)
+}
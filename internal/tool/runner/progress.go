@@ -0,0 +1,253 @@
+package runner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress is the running tally of a CLI run, taken from its intermediate
+// stream events before the final result reports the authoritative usage.
+type Progress struct {
+	Turns        int `json:"turns"`
+	ToolCalls    int `json:"tool_calls"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// ToolsSummary is what the agent did during a run, aggregated from its tool
+// calls: how often each tool was used, which files it read and wrote, and the
+// commands it ran (in order, capped at maxSummaryCommands).
+type ToolsSummary struct {
+	Calls        map[string]int `json:"calls"`
+	FilesRead    []string       `json:"files_read,omitempty"`
+	FilesWritten []string       `json:"files_written,omitempty"`
+	Commands     []string       `json:"commands,omitempty"`
+}
+
+const (
+	maxSummaryCommands    = 50
+	maxSummaryCommandLen  = 200
+	maxSummaryFilesPerSet = 200
+)
+
+// ProgressTracker folds raw stream lines into a Progress and a ToolsSummary.
+// It understands Claude Code / Cursor "assistant" messages (one turn per
+// message ID, usage taken from the latest line of each message) and Codex
+// "turn.completed" events (usage per turn) with their tool call items. Safe
+// for concurrent use.
+type ProgressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+	messages map[string]tokenUsage // assistant message ID → latest usage
+	lastLine time.Time             // any stream line, parsed or not
+
+	calls    map[string]int
+	read     map[string]bool
+	written  map[string]bool
+	commands []string
+}
+
+type tokenUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// toolUseBlock is an assistant content block; only tool_use blocks carry a
+// name and input.
+type toolUseBlock struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Input struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Command      string `json:"command"`
+	} `json:"input"`
+}
+
+// NewProgressTracker creates an empty tracker.
+func NewProgressTracker() *ProgressTracker {
+	return &ProgressTracker{
+		messages: make(map[string]tokenUsage),
+		lastLine: time.Now(),
+		calls:    make(map[string]int),
+		read:     make(map[string]bool),
+		written:  make(map[string]bool),
+	}
+}
+
+// Observe folds one stream line into the tally and reports whether it changed.
+// Lines that are not assistant messages, tool items or completed turns are ignored.
+func (p *ProgressTracker) Observe(line []byte) bool {
+	p.mu.Lock()
+	p.lastLine = time.Now()
+	p.mu.Unlock()
+
+	var event struct {
+		Type    string `json:"type"`
+		Message struct {
+			ID      string         `json:"id"`
+			Usage   *tokenUsage    `json:"usage"`
+			Content []toolUseBlock `json:"content"`
+		} `json:"message"`
+		Item struct {
+			codexItem
+			Changes []struct {
+				Path string `json:"path"`
+			} `json:"changes"` // file_change
+		} `json:"item"`
+		Usage tokenUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event.Type {
+	case "assistant":
+		for _, block := range event.Message.Content {
+			if block.Type == "tool_use" {
+				p.recordClaudeTool(block)
+			}
+		}
+		// Claude Code streams one line per content block, all carrying the
+		// message's usage so far: count the message once, replace its usage.
+		prev, seen := p.messages[event.Message.ID]
+		if !seen || event.Message.ID == "" {
+			p.progress.Turns++
+		}
+		if event.Message.Usage != nil {
+			p.progress.InputTokens += event.Message.Usage.InputTokens - prev.InputTokens
+			p.progress.OutputTokens += event.Message.Usage.OutputTokens - prev.OutputTokens
+			prev = *event.Message.Usage
+		}
+		if event.Message.ID != "" {
+			p.messages[event.Message.ID] = prev
+		}
+		return true
+	case "item.completed":
+		switch event.Item.Type {
+		case "function_call":
+			p.recordCall(event.Item.Name)
+		case "command_execution":
+			p.recordCall("command_execution")
+			p.recordCommand(event.Item.Command)
+		case "file_change":
+			p.calls["file_change"]++
+			for _, c := range event.Item.Changes {
+				addFile(p.written, c.Path)
+			}
+		default:
+			return false
+		}
+		return true
+	case "turn.completed":
+		p.progress.Turns++
+		p.progress.InputTokens += event.Usage.InputTokens
+		p.progress.OutputTokens += event.Usage.OutputTokens
+		return true
+	}
+	return false
+}
+
+// recordClaudeTool counts a Claude Code tool_use block and picks the file or
+// command out of its input.
+func (p *ProgressTracker) recordClaudeTool(block toolUseBlock) {
+	p.recordCall(block.Name)
+	switch block.Name {
+	case "Read":
+		addFile(p.read, block.Input.FilePath)
+	case "Write", "Edit", "MultiEdit":
+		addFile(p.written, block.Input.FilePath)
+	case "NotebookEdit":
+		addFile(p.written, block.Input.NotebookPath)
+	case "Bash":
+		p.recordCommand(block.Input.Command)
+	}
+}
+
+func (p *ProgressTracker) recordCall(name string) {
+	p.progress.ToolCalls++
+	if name == "" {
+		name = "unknown"
+	}
+	p.calls[name]++
+}
+
+func (p *ProgressTracker) recordCommand(cmd string) {
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" || len(p.commands) >= maxSummaryCommands {
+		return
+	}
+	if len(cmd) > maxSummaryCommandLen {
+		cmd = strings.ToValidUTF8(cmd[:maxSummaryCommandLen], "") + "…"
+	}
+	p.commands = append(p.commands, cmd)
+}
+
+func addFile(set map[string]bool, path string) {
+	if path != "" && len(set) < maxSummaryFilesPerSet {
+		set[path] = true
+	}
+}
+
+// LastEvent is when the run last produced a stream line (the tracker's
+// creation before the first one).
+func (p *ProgressTracker) LastEvent() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastLine
+}
+
+// Snapshot returns the tally so far.
+func (p *ProgressTracker) Snapshot() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.progress
+}
+
+// Tools returns the tool activity so far, with file paths inside workDir made
+// relative to it. Nil when the run made no tool calls.
+func (p *ProgressTracker) Tools(workDir string) *ToolsSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.calls) == 0 {
+		return nil
+	}
+	calls := make(map[string]int, len(p.calls))
+	for name, n := range p.calls {
+		calls[name] = n
+	}
+	return &ToolsSummary{
+		Calls:        calls,
+		FilesRead:    sortedPaths(p.read, workDir),
+		FilesWritten: sortedPaths(p.written, workDir),
+		Commands:     append([]string(nil), p.commands...),
+	}
+}
+
+func sortedPaths(set map[string]bool, workDir string) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(set))
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		if workDir != "" && filepath.IsAbs(path) {
+			if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressTracker_Observe(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  Progress
+	}{
+		{
+			name: "claude message split across lines counts once",
+			lines: []string{
+				`{"type":"system","subtype":"init"}`,
+				`{"type":"assistant","message":{"id":"m1","usage":{"input_tokens":100,"output_tokens":5},"content":[{"type":"text","text":"Looking"}]}}`,
+				`{"type":"assistant","message":{"id":"m1","usage":{"input_tokens":100,"output_tokens":20},"content":[{"type":"tool_use","name":"Read"}]}}`,
+				`{"type":"user","message":{"content":[{"type":"tool_result"}]}}`,
+				`{"type":"assistant","message":{"id":"m2","usage":{"input_tokens":150,"output_tokens":8},"content":[{"type":"tool_use","name":"Edit"},{"type":"tool_use","name":"Bash"}]}}`,
+			},
+			want: Progress{Turns: 2, ToolCalls: 3, InputTokens: 250, OutputTokens: 28},
+		},
+		{
+			name: "assistant messages without id or usage",
+			lines: []string{
+				`{"type":"assistant","message":{"content":[{"type":"text","text":"a"}]}}`,
+				`{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read"}]}}`,
+			},
+			want: Progress{Turns: 2, ToolCalls: 1},
+		},
+		{
+			name: "codex turns and tool items",
+			lines: []string{
+				`{"type":"item.completed","item":{"type":"command_execution","command":"ls"}}`,
+				`{"type":"item.completed","item":{"type":"agent_message","text":"done"}}`,
+				`{"type":"turn.completed","usage":{"input_tokens":1000,"output_tokens":50}}`,
+				`{"type":"item.completed","item":{"type":"function_call","name":"shell"}}`,
+				`{"type":"turn.completed","usage":{"input_tokens":500,"output_tokens":10}}`,
+			},
+			want: Progress{Turns: 2, ToolCalls: 2, InputTokens: 1500, OutputTokens: 60},
+		},
+		{
+			name:  "garbage is ignored",
+			lines: []string{`not json`, `{"type":"result","usage":{"input_tokens":9}}`},
+			want:  Progress{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProgressTracker()
+			for _, line := range tt.lines {
+				p.Observe([]byte(line))
+			}
+			if got := p.Snapshot(); got != tt.want {
+				t.Errorf("Snapshot() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressTracker_Tools(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  *ToolsSummary
+	}{
+		{
+			name: "claude tool calls",
+			lines: []string{
+				`{"type":"assistant","message":{"id":"m1","content":[{"type":"tool_use","name":"Read","input":{"file_path":"/ws/s1/go.mod"}}]}}`,
+				`{"type":"assistant","message":{"id":"m1","content":[{"type":"tool_use","name":"Read","input":{"file_path":"/ws/s1/main.go"}}]}}`,
+				`{"type":"assistant","message":{"id":"m2","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"/ws/s1/main.go"}},{"type":"tool_use","name":"Read","input":{"file_path":"/ws/s1/go.mod"}}]}}`,
+				`{"type":"assistant","message":{"id":"m3","content":[{"type":"tool_use","name":"Bash","input":{"command":"go test ./..."}},{"type":"tool_use","name":"Grep","input":{"pattern":"x"}}]}}`,
+				`{"type":"assistant","message":{"id":"m4","content":[{"type":"tool_use","name":"Write","input":{"file_path":"/etc/outside"}}]}}`,
+			},
+			want: &ToolsSummary{
+				Calls:        map[string]int{"Read": 3, "Edit": 1, "Bash": 1, "Grep": 1, "Write": 1},
+				FilesRead:    []string{"go.mod", "main.go"},
+				FilesWritten: []string{"/etc/outside", "main.go"},
+				Commands:     []string{"go test ./..."},
+			},
+		},
+		{
+			name: "codex items",
+			lines: []string{
+				`{"type":"item.completed","item":{"type":"command_execution","command":"ls -la"}}`,
+				`{"type":"item.completed","item":{"type":"file_change","changes":[{"path":"/ws/s1/a.go","kind":"update"}]}}`,
+				`{"type":"item.completed","item":{"type":"function_call","name":"shell"}}`,
+			},
+			want: &ToolsSummary{
+				Calls:        map[string]int{"command_execution": 1, "file_change": 1, "shell": 1},
+				FilesWritten: []string{"a.go"},
+				Commands:     []string{"ls -la"},
+			},
+		},
+		{
+			name:  "no tool calls",
+			lines: []string{`{"type":"assistant","message":{"content":[{"type":"text","text":"hi"}]}}`},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProgressTracker()
+			for _, line := range tt.lines {
+				p.Observe([]byte(line))
+			}
+			if got := p.Tools("/ws/s1"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tools() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressTracker_CommandsCapped(t *testing.T) {
+	p := NewProgressTracker()
+	long := `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash","input":{"command":"` + strings.Repeat("x", 300) + `"}}]}}`
+	for i := 0; i < maxSummaryCommands+5; i++ {
+		p.Observe([]byte(long))
+	}
+	tools := p.Tools("")
+	if len(tools.Commands) != maxSummaryCommands {
+		t.Errorf("commands = %d, want %d", len(tools.Commands), maxSummaryCommands)
+	}
+	if tools.Calls["Bash"] != maxSummaryCommands+5 {
+		t.Errorf("Bash calls = %d, want %d", tools.Calls["Bash"], maxSummaryCommands+5)
+	}
+	if n := len([]rune(tools.Commands[0])); n != maxSummaryCommandLen+1 {
+		t.Errorf("command length = %d, want %d", n, maxSummaryCommandLen+1)
+	}
+}
+
+func TestProgressTracker_LastEvent(t *testing.T) {
+	p := NewProgressTracker()
+	created := p.LastEvent()
+	if created.IsZero() {
+		t.Fatal("LastEvent() is zero before the first line, want creation time")
+	}
+	time.Sleep(time.Millisecond)
+	p.Observe([]byte("not json"))
+	if !p.LastEvent().After(created) {
+		t.Errorf("LastEvent() = %v, want after %v for an unparsed line", p.LastEvent(), created)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"time"
+
+	"github.com/freema/codeforge/internal/runas"
 )
 
 // Runner is the interface for CLI tool execution.
@@ -13,16 +15,20 @@ type Runner interface {
 
 // RunOptions configures a CLI run.
 type RunOptions struct {
-	Prompt             string
-	WorkDir            string
-	Model              string
-	APIKey             string
-	MaxTurns           int
-	MaxBudgetUSD       float64
-	MCPConfigPath      string // path to .mcp.json (Claude Code --mcp-config)
-	AppendSystemPrompt string // extra context appended to system prompt (Claude Code --append-system-prompt)
-	AllowedTools       string // comma-separated tool allowlist (Claude Code --allowedTools)
-	OnEvent            func(event json.RawMessage)
+	Prompt               string
+	WorkDir              string
+	Model                string
+	APIKey               string
+	MaxTurns             int
+	MaxBudgetUSD         float64
+	MCPConfigPath        string          // path to .mcp.json (Claude Code --mcp-config)
+	AppendSystemPrompt   string          // extra context appended to system prompt (Claude Code --append-system-prompt)
+	AllowedTools         string          // comma-separated tool allowlist (Claude Code --allowedTools)
+	ReasoningEffort      string          // low, medium or high (Codex model_reasoning_effort; Claude Code thinking budget)
+	ThinkingBudgetTokens int             // explicit extended-thinking budget (Claude Code MAX_THINKING_TOKENS)
+	Env                  []string        // extra KEY=value environment for the CLI process
+	RunAs                *runas.Identity // user the CLI drops to when the server is root; nil = the server's user
+	OnEvent              func(event json.RawMessage)
 }
 
 // RunResult holds the output of a CLI run.
@@ -32,6 +38,9 @@ type RunResult struct {
 	Duration     time.Duration
 	InputTokens  int
 	OutputTokens int
+	CostUSD      float64 // reported by the CLI; 0 when it does not report spend
+	// Tools is the run's tool activity, filled by the executor from the stream.
+	Tools *ToolsSummary
 }
 
 // RunnerMeta holds CLI-specific metadata used by the executor to select
@@ -0,0 +1,109 @@
+// Package verify runs a session's verification commands (tests, linters) in
+// the workspace after the CLI finished.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+// maxOutputChars bounds the stored output of a failing command. The tail is
+// kept — test runners print failures and summaries last.
+const maxOutputChars = 8000
+
+// Result is the outcome of running the verification commands.
+type Result struct {
+	Passed          bool   `json:"passed"`
+	Command         string `json:"command,omitempty"` // first failing command
+	ExitCode        int    `json:"exit_code,omitempty"`
+	Output          string `json:"output,omitempty"` // tail of the failing command's output
+	TimedOut        bool   `json:"timed_out,omitempty"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Attempt         int    `json:"attempt,omitempty"` // automatic fix attempt that produced the verified changes (0 = none)
+}
+
+// Run executes commands one by one with sh -c in workDir and stops at the
+// first failure. The commands come from the API client, so they run as id,
+// the session's CLI user (nil = the server's user). timeout bounds all
+// commands together; env is added to the commands' environment.
+func Run(ctx context.Context, workDir string, commands []string, timeout time.Duration, id *runas.Identity, env ...string) *Result {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := &Result{Passed: true}
+	for _, c := range commands {
+		out, err := run(ctx, workDir, c, id, env)
+		if err == nil {
+			continue
+		}
+		res.Passed = false
+		res.Command = c
+		res.Output = tail(out, maxOutputChars)
+		var exitErr *exec.ExitError
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			res.TimedOut = true
+			res.ExitCode = -1
+		case errors.As(err, &exitErr):
+			res.ExitCode = exitErr.ExitCode()
+		default:
+			res.ExitCode = -1
+			res.Output = tail(out+"\n"+err.Error(), maxOutputChars)
+		}
+		break
+	}
+	res.DurationSeconds = int(time.Since(start).Seconds())
+	return res
+}
+
+func run(ctx context.Context, workDir, command string, id *runas.Identity, env []string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = workDir
+	cmd = runas.Command(ctx, cmd, id)
+	cmd.Env = append(runas.Env(environ(), id), env...)
+	// Kill the whole process group on timeout: test runners spawn children
+	// that would otherwise keep running and hold the output pipe open.
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	return buf.String(), err
+}
+
+// environ returns the server environment without CodeForge's own settings,
+// which hold secrets the verification commands have no business reading.
+func environ() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "CODEFORGE_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return "…" + s[len(s)-n:]
+}
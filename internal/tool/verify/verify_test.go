@@ -0,0 +1,82 @@
+package verify
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		commands []string
+		passed   bool
+		command  string
+		exitCode int
+		output   string
+	}{
+		{"all pass", []string{"true", "echo ok"}, true, "", 0, ""},
+		{"stops at first failure", []string{"echo building", "echo 'FAIL: TestX' >&2; exit 3", "echo unreachable > marker"}, false, "echo 'FAIL: TestX' >&2; exit 3", 3, "FAIL: TestX"},
+		{"runs in workdir", []string{"test -d ../" + dir[strings.LastIndex(dir, "/")+1:]}, true, "", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Run(context.Background(), dir, tt.commands, 10*time.Second, nil)
+			if res.Passed != tt.passed || res.Command != tt.command || res.ExitCode != tt.exitCode {
+				t.Errorf("Run = %+v", res)
+			}
+			if !strings.Contains(res.Output, tt.output) {
+				t.Errorf("output %q should contain %q", res.Output, tt.output)
+			}
+		})
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	res := Run(context.Background(), t.TempDir(), []string{"sleep 5"}, 100*time.Millisecond, nil)
+	if res.Passed || !res.TimedOut {
+		t.Errorf("Run = %+v, want timed out", res)
+	}
+}
+
+func TestRun_HidesServerConfig(t *testing.T) {
+	t.Setenv("CODEFORGE_ENCRYPTION_KEY", "secret")
+	res := Run(context.Background(), t.TempDir(), []string{`test -z "$CODEFORGE_ENCRYPTION_KEY"`}, 10*time.Second, nil)
+	if !res.Passed {
+		t.Errorf("server settings leaked into verification env: %+v", res)
+	}
+}
+
+func TestRun_AsIdentity(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("switching users needs root")
+	}
+	// t.TempDir's parent is private to root; the identity must reach dir.
+	dir, err := os.MkdirTemp("", "verify-runas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	id := &runas.Identity{UID: 64123, GID: 64123, HomeDir: os.TempDir()}
+	res := Run(context.Background(), dir, []string{`test "$(id -u)" = 64123 && test "$HOME" = ` + os.TempDir()}, 10*time.Second, id)
+	if !res.Passed {
+		t.Errorf("commands did not run as the identity: %+v", res)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("  short \n", 10); got != "short" {
+		t.Errorf("tail = %q", got)
+	}
+	if got := tail("0123456789", 4); got != "…6789" {
+		t.Errorf("tail = %q", got)
+	}
+}
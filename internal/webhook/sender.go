@@ -7,51 +7,142 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"math"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/freema/codeforge/internal/chaos"
+	"github.com/freema/codeforge/internal/httpclient"
 	"github.com/freema/codeforge/internal/metrics"
+	"github.com/freema/codeforge/internal/policy"
 	"github.com/freema/codeforge/internal/session"
 	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/runner"
 )
 
 // Payload is the webhook request body.
 type Payload struct {
-	TaskID         string                 `json:"task_id"`
-	Status         string                 `json:"status"`
-	Result         string                 `json:"result,omitempty"`
-	Error          string                 `json:"error,omitempty"`
-	ChangesSummary *gitpkg.ChangesSummary `json:"changes_summary,omitempty"`
-	Usage          *session.UsageInfo     `json:"usage,omitempty"`
-	TraceID        string                 `json:"trace_id,omitempty"`
-	FinishedAt     time.Time              `json:"finished_at"`
+	TaskID                string                 `json:"task_id"`
+	Status                string                 `json:"status"`
+	Result                string                 `json:"result,omitempty"`
+	ResultStructured      json.RawMessage        `json:"result_structured,omitempty"`
+	ResultStructuredError string                 `json:"result_structured_error,omitempty"`
+	Error                 string                 `json:"error,omitempty"`
+	ChangesSummary        *gitpkg.ChangesSummary `json:"changes_summary,omitempty"`
+	Usage                 *session.UsageInfo     `json:"usage,omitempty"`
+	ToolsSummary          *runner.ToolsSummary   `json:"tools_summary,omitempty"`
+	Iteration             int                    `json:"iteration,omitempty"`
+	SuggestedNext         *session.NextStep      `json:"suggested_next,omitempty"`
+	Branch                string                 `json:"branch,omitempty"`
+	PRURL                 string                 `json:"pr_url,omitempty"`
+	PRNumber              int                    `json:"pr_number,omitempty"`
+	Iterations            []session.Iteration    `json:"iterations,omitempty"` // with config.include_iterations
+	TraceID               string                 `json:"trace_id,omitempty"`
+	RequestID             string                 `json:"request_id,omitempty"`
+	FinishedAt            time.Time              `json:"finished_at"`
 }
 
+// NewPayload starts the terminal webhook of session t: identity, iteration,
+// branch and PR, correlation IDs and the finish time. Callers add the
+// outcome (result, error, changes, usage).
+func NewPayload(t *session.Session, status session.Status) Payload {
+	return Payload{
+		TaskID:     t.ID,
+		Status:     string(status),
+		Iteration:  t.Iteration,
+		Branch:     t.Branch,
+		PRURL:      t.PRURL,
+		PRNumber:   t.PRNumber,
+		TraceID:    t.TraceID,
+		RequestID:  t.RequestID,
+		FinishedAt: time.Now().UTC(),
+	}
+}
+
+// SignatureHeader carries the replay-protected signature:
+// "t=<unix seconds>,n=<delivery id>,v1=<hex HMAC-SHA256>", where v1 signs
+// "t=<unix seconds>,n=<delivery id>," followed by the raw body. The legacy
+// X-Signature-256 header (body only) is still sent alongside.
+const SignatureHeader = "X-CodeForge-Signature"
+
+// DeliveryHeader carries the delivery UUID. It stays the same across retries
+// of one delivery, so receivers can use it to drop duplicates.
+const DeliveryHeader = "X-CodeForge-Delivery"
+
+// DefaultTolerance is the recommended maximum age of a signed delivery.
+const DefaultTolerance = 5 * time.Minute
+
 // Sender delivers webhook callbacks with HMAC-SHA256 signatures.
 type Sender struct {
 	client     *http.Client
 	secret     string
 	maxRetries int
 	baseDelay  time.Duration
+	maxDelay   time.Duration          // cap for a single retry delay, incl. Retry-After
+	chaos      *chaos.Injector        // optional, nil = no fault injection
+	policy     *policy.CallbackPolicy // optional, nil = any destination
 }
 
 // NewSender creates a webhook sender.
 func NewSender(secret string, maxRetries int, baseDelay time.Duration) *Sender {
 	return &Sender{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:     httpclient.New(httpclient.Webhook),
 		secret:     secret,
 		maxRetries: maxRetries,
 		baseDelay:  baseDelay,
+		maxDelay:   DefaultMaxRetryDelay,
 	}
 }
 
-// Send delivers a webhook to the callback URL with retries and exponential backoff.
+// DefaultMaxRetryDelay caps a single retry delay unless SetMaxRetryDelay
+// changes it.
+const DefaultMaxRetryDelay = 5 * time.Minute
+
+// SetMaxRetryDelay caps a single retry delay, including one requested by a
+// receiver's Retry-After header. Zero keeps the default.
+func (s *Sender) SetMaxRetryDelay(d time.Duration) {
+	if d > 0 {
+		s.maxDelay = d
+	}
+}
+
+// SetChaos enables fault injection: delivery attempts are dropped at the
+// configured rate to exercise retries.
+func (s *Sender) SetChaos(c *chaos.Injector) {
+	s.chaos = c
+}
+
+// SetPolicy restricts the destinations callbacks may reach (SSRF guard).
+// Connections are checked at dial time, covering DNS rebinding and redirects.
+func (s *Sender) SetPolicy(p *policy.CallbackPolicy) {
+	s.policy = p
+	if p != nil {
+		s.client = httpclient.NewWithTransport(httpclient.Webhook, p.Transport())
+	}
+}
+
+// CheckURL reports whether callbackURL is an acceptable destination.
+func (s *Sender) CheckURL(ctx context.Context, callbackURL string) error {
+	return s.policy.Check(ctx, callbackURL)
+}
+
+// Send delivers a webhook to the callback URL. Failed attempts are retried
+// with capped exponential backoff and jitter, or after the delay a 429/503
+// answer asks for in Retry-After. Other 4xx answers are not retried.
 func (s *Sender) Send(ctx context.Context, callbackURL string, payload Payload) error {
+	if err := s.policy.Check(ctx, callbackURL); err != nil {
+		metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		return fmt.Errorf("webhook to %s blocked: %w", callbackURL, err)
+	}
+
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshaling webhook payload: %w", err)
@@ -59,29 +150,34 @@ func (s *Sender) Send(ctx context.Context, callbackURL string, payload Payload)
 
 	sig := s.sign(body)
 	eventType := "task." + payload.Status
+	delivery := uuid.NewString()
 
+	var retryAfter time.Duration // requested by the receiver's last answer
 	for attempt := 0; attempt <= s.maxRetries; attempt++ {
 		if attempt > 0 {
-			delay := time.Duration(math.Pow(5, float64(attempt-1))) * s.baseDelay
-			slog.Info("webhook retry", "attempt", attempt, "delay", delay, "url", callbackURL)
+			delay := s.backoff(attempt)
+			if retryAfter > 0 {
+				delay = min(retryAfter, s.maxDelay)
+			}
+			slog.Info("webhook retry", "attempt", attempt, "delay", delay, "url", callbackURL, "delivery", delivery)
 
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			case <-time.After(delay):
 			}
+			retryAfter = 0
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+		// Re-signed per attempt: the timestamp must be fresh for the receiver's
+		// tolerance window, the delivery ID stays.
+		req, err := s.newRequest(ctx, callbackURL, body, sig, s.signTimestamped(body, time.Now(), delivery), delivery, eventType, payload)
 		if err != nil {
-			return fmt.Errorf("creating webhook request: %w", err)
+			return err
 		}
 
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Signature-256", "sha256="+sig)
-		req.Header.Set("X-CodeForge-Event", eventType)
-		if payload.TraceID != "" {
-			req.Header.Set("X-Trace-ID", payload.TraceID)
+		if s.chaos.DropWebhook() {
+			continue
 		}
 
 		resp, err := s.client.Do(req)
@@ -98,14 +194,192 @@ func (s *Sender) Send(ctx context.Context, callbackURL string, payload Payload)
 		}
 
 		slog.Warn("webhook non-2xx response", "attempt", attempt, "status", resp.StatusCode, "url", callbackURL)
+		if !retryable(resp.StatusCode) {
+			metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+			return fmt.Errorf("webhook to %s rejected with %d, not retrying", callbackURL, resp.StatusCode)
+		}
+		retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 	}
 
 	metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
 	return fmt.Errorf("webhook delivery failed after %d attempts to %s", s.maxRetries+1, callbackURL)
 }
 
+func (s *Sender) newRequest(ctx context.Context, callbackURL string, body []byte, sig, signed, delivery, eventType string, payload Payload) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature-256", "sha256="+sig)
+	req.Header.Set(SignatureHeader, signed)
+	req.Header.Set(DeliveryHeader, delivery)
+	req.Header.Set("X-CodeForge-Event", eventType)
+	if payload.TraceID != "" {
+		req.Header.Set("X-Trace-ID", payload.TraceID)
+	}
+	if payload.RequestID != "" {
+		req.Header.Set("X-Request-ID", payload.RequestID)
+	}
+	return req, nil
+}
+
+// TestStatus is the payload status of test deliveries; receivers see the
+// event as "task.test" and can acknowledge it without acting on it.
+const TestStatus = "test"
+
+// maxTestResponseBody bounds how much of the receiver's reply is echoed back.
+const maxTestResponseBody = 4096
+
+// TestResult reports the outcome of a single test delivery.
+type TestResult struct {
+	URL          string          `json:"url"`
+	Delivered    bool            `json:"delivered"`
+	StatusCode   int             `json:"status_code,omitempty"`
+	LatencyMs    int64           `json:"latency_ms"`
+	Event        string          `json:"event"`
+	Signature    string          `json:"signature"`
+	SignatureV1  string          `json:"signature_v1"`
+	DeliveryID   string          `json:"delivery_id"`
+	Payload      json.RawMessage `json:"payload"`
+	ResponseBody string          `json:"response_body,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// Test sends a signed sample payload to callbackURL exactly once (no retries)
+// and reports what the receiver answered. The payload bytes and signature are
+// echoed so integrators can check their HMAC verification against them.
+// Transport failures and non-2xx answers are reported in the result, not as
+// an error.
+func (s *Sender) Test(ctx context.Context, callbackURL string) (*TestResult, error) {
+	payload := Payload{
+		TaskID:     "test-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Status:     TestStatus,
+		Result:     "This is a test delivery from CodeForge.",
+		FinishedAt: time.Now().UTC(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling webhook payload: %w", err)
+	}
+
+	sig := s.sign(body)
+	res := &TestResult{
+		URL:        callbackURL,
+		Event:      "task." + payload.Status,
+		Signature:  "sha256=" + sig,
+		DeliveryID: uuid.NewString(),
+		Payload:    body,
+	}
+	res.SignatureV1 = s.signTimestamped(body, time.Now(), res.DeliveryID)
+
+	req, err := s.newRequest(ctx, callbackURL, body, sig, res.SignatureV1, res.DeliveryID, res.Event, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		return res, nil
+	}
+	defer resp.Body.Close()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, maxTestResponseBody))
+	res.StatusCode = resp.StatusCode
+	res.ResponseBody = string(reply)
+	res.Delivered = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !res.Delivered {
+		res.Error = fmt.Sprintf("receiver answered %d", resp.StatusCode)
+	}
+	return res, nil
+}
+
 func (s *Sender) sign(body []byte) string {
-	mac := hmac.New(sha256.New, []byte(s.secret))
-	_, _ = mac.Write(body)
+	return hmacHex(s.secret, body)
+}
+
+// backoff returns the delay before retry attempt n (1-based): baseDelay
+// doubled per attempt, capped at maxDelay, with "equal jitter" — a random
+// point in the upper half — so receivers recovering from an outage are not
+// hit by every sender at the same moment.
+func (s *Sender) backoff(n int) time.Duration {
+	d := s.baseDelay << (n - 1)
+	if d <= 0 || d > s.maxDelay || n > 32 {
+		d = s.maxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+// retryable reports whether a non-2xx status is worth retrying: server
+// errors, timeouts and rate limiting. Other client errors will not change.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. It returns 0 when the header is missing or unusable.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// signTimestamped returns the SignatureHeader value for body sent at ts.
+func (s *Sender) signTimestamped(body []byte, ts time.Time, delivery string) string {
+	prefix := "t=" + strconv.FormatInt(ts.Unix(), 10) + ",n=" + delivery + ","
+	return prefix + "v1=" + hmacHex(s.secret, []byte(prefix), body)
+}
+
+func hmacHex(secret string, parts ...[]byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, p := range parts {
+		_, _ = mac.Write(p)
+	}
 	return hex.EncodeToString(mac.Sum(nil))
 }
+
+// VerifySignature checks a SignatureHeader value against body the way a
+// receiver should and returns the signed delivery ID. Deliveries signed more than tolerance away from now are
+// rejected as replays; the returned delivery ID should additionally be
+// remembered for at least tolerance to drop duplicates.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) (string, error) {
+	fields := map[string]string{}
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		fields[k] = v
+	}
+	ts, err := strconv.ParseInt(fields["t"], 10, 64)
+	if err != nil || fields["n"] == "" || fields["v1"] == "" {
+		return "", errors.New("malformed signature header")
+	}
+	prefix := "t=" + fields["t"] + ",n=" + fields["n"] + ","
+	if !hmac.Equal([]byte(fields["v1"]), []byte(hmacHex(secret, []byte(prefix), body))) {
+		return "", errors.New("signature mismatch")
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return "", fmt.Errorf("signature timestamp outside the %s tolerance", tolerance)
+	}
+	return fields["n"], nil
+}
@@ -9,14 +9,18 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/freema/codeforge/internal/policy"
+	"github.com/freema/codeforge/internal/session"
 )
 
 func TestSender_Send_Success(t *testing.T) {
 	var received atomic.Bool
-	var gotSig, gotEvent, gotTraceID string
+	var gotSig, gotEvent, gotTraceID, gotRequestID string
 	var gotBody []byte
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -24,6 +28,7 @@ func TestSender_Send_Success(t *testing.T) {
 		gotSig = r.Header.Get("X-Signature-256")
 		gotEvent = r.Header.Get("X-CodeForge-Event")
 		gotTraceID = r.Header.Get("X-Trace-ID")
+		gotRequestID = r.Header.Get("X-Request-ID")
 		gotBody, _ = io.ReadAll(r.Body)
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -31,9 +36,10 @@ func TestSender_Send_Success(t *testing.T) {
 
 	sender := NewSender("my-secret", 0, time.Millisecond)
 	err := sender.Send(context.Background(), srv.URL, Payload{
-		TaskID:  "task-1",
-		Status:  "completed",
-		TraceID: "trace-123",
+		TaskID:    "task-1",
+		Status:    "completed",
+		TraceID:   "trace-123",
+		RequestID: "req-abc",
 	})
 
 	if err != nil {
@@ -48,6 +54,9 @@ func TestSender_Send_Success(t *testing.T) {
 	if gotTraceID != "trace-123" {
 		t.Errorf("trace ID: got %q, want %q", gotTraceID, "trace-123")
 	}
+	if gotRequestID != "req-abc" {
+		t.Errorf("request ID: got %q, want %q", gotRequestID, "req-abc")
+	}
 
 	// Verify HMAC signature
 	mac := hmac.New(sha256.New, []byte("my-secret"))
@@ -171,3 +180,272 @@ func TestSender_PayloadJSON(t *testing.T) {
 		t.Errorf("result: got %q, want %q", payload.Result, "all good")
 	}
 }
+
+func TestSender_Test(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantDelivered bool
+	}{
+		{"accepted", http.StatusNoContent, true},
+		{"rejected", http.StatusUnauthorized, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			var gotSig, gotSignedV1, gotDelivery, gotEvent string
+			var gotBody []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				gotSig = r.Header.Get("X-Signature-256")
+				gotSignedV1 = r.Header.Get(SignatureHeader)
+				gotDelivery = r.Header.Get(DeliveryHeader)
+				gotEvent = r.Header.Get("X-CodeForge-Event")
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			res, err := NewSender("my-secret", 3, time.Millisecond).Test(context.Background(), srv.URL)
+			if err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			if calls.Load() != 1 {
+				t.Errorf("calls = %d, want 1 (no retries)", calls.Load())
+			}
+			if res.Delivered != tt.wantDelivered || res.StatusCode != tt.status {
+				t.Errorf("delivered = %v, status = %d", res.Delivered, res.StatusCode)
+			}
+			if gotEvent != "task.test" || res.Event != gotEvent {
+				t.Errorf("event = %q, reported %q", gotEvent, res.Event)
+			}
+			if res.Signature != gotSig || string(res.Payload) != string(gotBody) {
+				t.Error("reported signature/payload differ from what was sent")
+			}
+			if res.SignatureV1 != gotSignedV1 || res.DeliveryID != gotDelivery {
+				t.Error("reported signature_v1/delivery_id differ from what was sent")
+			}
+			mac := hmac.New(sha256.New, []byte("my-secret"))
+			mac.Write(gotBody)
+			if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); gotSig != want {
+				t.Errorf("signature = %q, want %q", gotSig, want)
+			}
+		})
+	}
+}
+
+func TestSender_Test_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	res, err := NewSender("s", 0, 0).Test(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if res.Delivered || res.Error == "" {
+		t.Errorf("expected a reported transport error, got %+v", res)
+	}
+}
+
+func TestSender_Send_DeliveryStableAcrossRetries(t *testing.T) {
+	var deliveries []string
+	var signed []string
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries = append(deliveries, r.Header.Get(DeliveryHeader))
+		signed = append(signed, r.Header.Get(SignatureHeader))
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, body)
+		if len(deliveries) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if err := NewSender("my-secret", 2, time.Millisecond).Send(context.Background(), srv.URL, Payload{TaskID: "task-1", Status: "completed"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0] == "" || deliveries[0] != deliveries[1] {
+		t.Fatalf("deliveries = %v, want one ID repeated", deliveries)
+	}
+	for i, header := range signed {
+		id, err := VerifySignature("my-secret", header, bodies[i], DefaultTolerance, time.Now())
+		if err != nil {
+			t.Errorf("attempt %d: %v", i, err)
+		}
+		if id != deliveries[i] {
+			t.Errorf("attempt %d: signed delivery %q, header %q", i, id, deliveries[i])
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	s := NewSender("my-secret", 0, 0)
+	body := []byte(`{"task_id":"task-1"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := s.signTimestamped(body, sentAt, "d-1")
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", "my-secret", header, body, sentAt.Add(time.Minute), false},
+		{"wrong secret", "other", header, body, sentAt, true},
+		{"tampered body", "my-secret", header, []byte(`{"task_id":"task-2"}`), sentAt, true},
+		{"replayed later", "my-secret", header, body, sentAt.Add(10 * time.Minute), true},
+		{"timestamp in the future", "my-secret", header, body, sentAt.Add(-10 * time.Minute), true},
+		{"swapped delivery", "my-secret", strings.Replace(header, "n=d-1", "n=d-2", 1), body, sentAt, true},
+		{"malformed", "my-secret", "v1=abc", body, sentAt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := VerifySignature(tt.secret, tt.header, tt.body, DefaultTolerance, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && id != "d-1" {
+				t.Errorf("delivery = %q, want d-1", id)
+			}
+		})
+	}
+}
+
+func TestSender_Send_BlockedByPolicy(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p, err := policy.NewCallbackPolicy(false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := NewSender("secret", 2, time.Millisecond)
+	sender.SetPolicy(p)
+	if err := sender.Send(context.Background(), srv.URL, Payload{TaskID: "task-1", Status: "completed"}); err == nil {
+		t.Fatal("expected a loopback callback to be blocked")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("receiver got %d requests, want 0", calls.Load())
+	}
+}
+
+func TestSender_Backoff(t *testing.T) {
+	s := NewSender("secret", 5, time.Second)
+	s.SetMaxRetryDelay(10 * time.Second)
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{5, 5 * time.Second, 10 * time.Second}, // 16s capped at 10s
+		{64, 5 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := s.backoff(tt.attempt); d < tt.min || d > tt.max {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestSender_Send_StatusHandling(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		retryAfter   string
+		wantAttempts int32
+	}{
+		{"client error is not retried", http.StatusUnauthorized, "", 1},
+		{"rate limited is retried", http.StatusTooManyRequests, "1", 3},
+		{"server error is retried", http.StatusBadGateway, "", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			s := NewSender("secret", 2, time.Millisecond)
+			s.SetMaxRetryDelay(5 * time.Millisecond) // caps Retry-After too
+			if err := s.Send(context.Background(), srv.URL, Payload{TaskID: "task-1", Status: "completed"}); err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestNewPayload(t *testing.T) {
+	s := &session.Session{
+		ID:        "task-1",
+		Iteration: 3,
+		Branch:    "codeforge/fix",
+		PRURL:     "https://github.com/o/r/pull/7",
+		PRNumber:  7,
+		TraceID:   "trace-1",
+		RequestID: "req-1",
+	}
+	p := NewPayload(s, session.StatusCompleted)
+	if p.TaskID != "task-1" || p.Status != "completed" || p.Iteration != 3 {
+		t.Errorf("identity = %q/%q/%d", p.TaskID, p.Status, p.Iteration)
+	}
+	if p.Branch != "codeforge/fix" || p.PRURL != s.PRURL || p.PRNumber != 7 {
+		t.Errorf("pr info = %q/%q/%d", p.Branch, p.PRURL, p.PRNumber)
+	}
+	if p.TraceID != "trace-1" || p.RequestID != "req-1" || p.FinishedAt.IsZero() {
+		t.Errorf("trace/request/finished = %q/%q/%v", p.TraceID, p.RequestID, p.FinishedAt)
+	}
+
+	body, err := json.Marshal(NewPayload(&session.Session{ID: "task-2"}, session.StatusFailed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"branch", "pr_url", "pr_number", "iterations"} {
+		if strings.Contains(string(body), `"`+key+`"`) {
+			t.Errorf("payload without a PR carries %q: %s", key, body)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// AutoMerger follows PRs created with auto_merge: it moves sessions to
+// pr_merged once the provider merges them and merges poll-mode PRs itself
+// when their checks pass.
+type AutoMerger struct {
+	prService *session.PRService
+	interval  time.Duration
+	maxAge    time.Duration
+}
+
+// NewAutoMerger creates an auto-merger. PRs still open after maxAge are no
+// longer followed.
+func NewAutoMerger(prService *session.PRService, interval, maxAge time.Duration) *AutoMerger {
+	return &AutoMerger{prService: prService, interval: interval, maxAge: maxAge}
+}
+
+// Start runs the sync loop until ctx is canceled. Call in a goroutine.
+func (m *AutoMerger) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sync(ctx)
+		}
+	}
+}
+
+func (m *AutoMerger) sync(ctx context.Context) {
+	merged, err := m.prService.SyncAutoMerges(ctx, time.Now(), m.maxAge)
+	if err != nil {
+		slog.Warn("auto-merger: sync failed", "error", err)
+		return
+	}
+	if merged > 0 {
+		slog.Info("auto-merger: PRs merged", "count", merged)
+	}
+}
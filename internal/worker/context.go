@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/freema/codeforge/internal/ai"
+	"github.com/freema/codeforge/internal/session"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/tool/verify"
+)
+
+const (
+	summaryVerbatim = 2                // newest iterations the summary strategy keeps word for word
+	summaryTimeout  = 30 * time.Second // AI helper call per iteration summary
+	maxSummaryChars = 1000             // per iteration summary text
+	maxEntryFiles   = 20               // files listed per iteration in follow-up prompts
+)
+
+// SetAIClient wires the AI helper used by the summary context strategy.
+// Optional — when unset, summary behaves like recent.
+func (e *Executor) SetAIClient(c ai.Client) {
+	e.aiClient = c
+}
+
+// iterationContext renders the earlier iterations for a follow-up prompt
+// according to the session's context strategy, within maxContextChars.
+// Returns empty string when nothing fits.
+func (e *Executor) iterationContext(ctx context.Context, t *session.Session, iterations []session.Iteration) string {
+	strategy := session.ContextFull
+	if t.Config != nil && t.Config.ContextStrategy != "" {
+		strategy = t.Config.ContextStrategy
+	}
+	budget := e.maxContextChars()
+
+	var b strings.Builder
+	b.WriteString("## Previous iterations on this codebase:\n\n")
+
+	switch strategy {
+	case session.ContextRecent:
+		writeRecent(&b, iterations, budget)
+	case session.ContextSummary:
+		older := iterations[:max(len(iterations)-summaryVerbatim, 0)]
+		summary := ""
+		if len(older) > 0 && e.aiClient != nil {
+			summary = ai.SummarizeIterations(ctx, e.aiClient, formatIterations(older))
+		}
+		if summary == "" {
+			writeRecent(&b, iterations, budget) // no AI helper or it failed
+			break
+		}
+		summary = truncate(summary, budget/3)
+		fmt.Fprintf(&b, "### Summary of iterations 1-%d\n%s\n\n", older[len(older)-1].Number, summary)
+		writeRecent(&b, iterations[len(older):], budget-len(summary))
+	default:
+		// Oldest first; the newest iterations are dropped once the cap is hit.
+		total := 0
+		for _, iter := range iterations {
+			entry := iterationEntry(iter)
+			if total+len(entry) > budget {
+				b.WriteString("(later iterations truncated for context limits)\n\n")
+				break
+			}
+			b.WriteString(entry)
+			total += len(entry)
+		}
+	}
+	return b.String()
+}
+
+// iterationEntry renders one iteration, preferring its stored summary over
+// the raw (truncated) result.
+func iterationEntry(iter session.Iteration) string {
+	s := iter.Summary
+	if s == nil {
+		return fmt.Sprintf("### Iteration %d\n**Prompt:** %s\n**Result summary:** %s\n**Status:** %s\n\n",
+			iter.Number, iter.Prompt, iter.Result, iter.Status)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Iteration %d\n**Prompt:** %s\n**Summary:** %s\n", iter.Number, iter.Prompt, s.Changed)
+	if len(s.Files) > 0 {
+		files := s.Files
+		more := ""
+		if len(files) > maxEntryFiles {
+			files, more = files[:maxEntryFiles], fmt.Sprintf(" (+%d more)", len(files)-maxEntryFiles)
+		}
+		fmt.Fprintf(&b, "**Files:** %s%s\n", strings.Join(files, ", "), more)
+	}
+	if len(s.OpenIssues) > 0 {
+		fmt.Fprintf(&b, "**Open issues:** %s\n", strings.Join(s.OpenIssues, "; "))
+	}
+	fmt.Fprintf(&b, "**Status:** %s\n\n", iter.Status)
+	return b.String()
+}
+
+// summarizeIteration builds the stored summary of a completed iteration:
+// the AI helper condenses the output when configured, otherwise the first
+// paragraph of the output stands in. Files come from the workspace, and a
+// failed verification is always listed as an open issue.
+func (e *Executor) summarizeIteration(ctx context.Context, t *session.Session, instruction, output, workDir string, verification *verify.Result, log *slog.Logger) *session.IterationSummary {
+	sum := &session.IterationSummary{}
+	if e.aiClient != nil {
+		aiCtx, cancel := context.WithTimeout(ctx, summaryTimeout)
+		res := ai.SummarizeIteration(aiCtx, e.aiClient, instruction, output)
+		cancel()
+		if res != nil {
+			sum.Changed = truncate(res.Changed, maxSummaryChars)
+			sum.OpenIssues = res.OpenIssues
+		}
+	}
+	if sum.Changed == "" {
+		sum.Changed = truncate(firstParagraph(output), maxSummaryChars)
+	}
+	files, err := gitpkg.ChangedFiles(ctx, workDir, t.IgnoreGlobs(workDir)...)
+	if err != nil {
+		log.Warn("listing changed files for iteration summary failed", "error", err)
+	}
+	sum.Files = files
+	if verification != nil && !verification.Passed {
+		sum.OpenIssues = append(sum.OpenIssues, fmt.Sprintf("verification failed: %s (exit code %d)", verification.Command, verification.ExitCode))
+	}
+	return sum
+}
+
+// firstParagraph returns the first non-empty paragraph of s.
+func firstParagraph(s string) string {
+	for _, p := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+func formatIterations(iterations []session.Iteration) string {
+	var b strings.Builder
+	for _, iter := range iterations {
+		b.WriteString(iterationEntry(iter))
+	}
+	return b.String()
+}
+
+// writeRecent writes the newest iterations that fit in budget, oldest first,
+// noting how many older ones were left out.
+func writeRecent(b *strings.Builder, iterations []session.Iteration, budget int) {
+	first, total := len(iterations), 0
+	for first > 0 {
+		n := len(iterationEntry(iterations[first-1]))
+		if total+n > budget {
+			break
+		}
+		total += n
+		first--
+	}
+	if first > 0 {
+		fmt.Fprintf(b, "(%d earlier iterations omitted for context limits)\n\n", first)
+	}
+	for _, iter := range iterations[first:] {
+		b.WriteString(iterationEntry(iter))
+	}
+}
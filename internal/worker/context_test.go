@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+type fakeAI struct {
+	out string
+	err error
+}
+
+func (f fakeAI) Generate(context.Context, string, string) (string, error) {
+	return f.out, f.err
+}
+
+func TestIterationEntry_PrefersSummary(t *testing.T) {
+	iter := session.Iteration{
+		Number: 2,
+		Prompt: "add logout",
+		Result: "raw CLI output",
+		Status: session.StatusCompleted,
+		Summary: &session.IterationSummary{
+			Changed:    "Added a logout handler.",
+			Files:      []string{"auth.go", "routes.go"},
+			OpenIssues: []string{"verification failed: go test ./... (exit code 1)"},
+		},
+	}
+	got := iterationEntry(iter)
+	for _, want := range []string{"**Summary:** Added a logout handler.", "**Files:** auth.go, routes.go", "**Open issues:** verification failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "raw CLI output") {
+		t.Errorf("raw result used despite summary:\n%s", got)
+	}
+}
+
+func TestFirstParagraph(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"one line", "one line"},
+		{"\n\n  Done: added X.\nMore detail.\n\nSecond paragraph", "Done: added X.\nMore detail."},
+	}
+	for _, tt := range tests {
+		if got := firstParagraph(tt.in); got != tt.want {
+			t.Errorf("firstParagraph(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIterationContext(t *testing.T) {
+	iterations := []session.Iteration{
+		{Number: 1, Prompt: "add login", Result: "login added", Status: session.StatusCompleted},
+		{Number: 2, Prompt: "add logout", Result: "logout added", Status: session.StatusCompleted},
+		{Number: 3, Prompt: "fix tests", Result: "tests fixed", Status: session.StatusCompleted},
+	}
+	two := len(iterationEntry(iterations[1])) + len(iterationEntry(iterations[2]))
+
+	tests := []struct {
+		name     string
+		strategy string
+		ai       *fakeAI
+		maxChars int
+		want     []string // substrings in order
+		wantNot  []string
+	}{
+		{"full keeps oldest", "", nil, two, []string{"Iteration 1", "Iteration 2", "later iterations truncated"}, []string{"Iteration 3"}},
+		{"recent keeps newest", session.ContextRecent, nil, two, []string{"1 earlier iterations omitted", "Iteration 2", "Iteration 3"}, []string{"Iteration 1\n"}},
+		{"summary", session.ContextSummary, &fakeAI{out: "login was added"}, 50000, []string{"Summary of iterations 1-1", "login was added", "Iteration 2", "Iteration 3"}, []string{"Iteration 1\n"}},
+		{"summary without ai falls back to recent", session.ContextSummary, nil, two, []string{"1 earlier iterations omitted", "Iteration 3"}, []string{"Summary"}},
+		{"summary ai failure falls back to recent", session.ContextSummary, &fakeAI{err: errors.New("down")}, 50000, []string{"Iteration 1", "Iteration 2", "Iteration 3"}, []string{"Summary"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Executor{cfg: ExecutorConfig{MaxContextChars: tt.maxChars}}
+			if tt.ai != nil {
+				e.SetAIClient(*tt.ai)
+			}
+			sess := &session.Session{Config: &session.Config{ContextStrategy: tt.strategy}}
+			got := e.iterationContext(context.Background(), sess, iterations)
+
+			pos := 0
+			for _, w := range tt.want {
+				i := strings.Index(got[pos:], w)
+				if i < 0 {
+					t.Fatalf("missing %q (in order) in:\n%s", w, got)
+				}
+				pos += i + len(w)
+			}
+			for _, w := range tt.wantNot {
+				if strings.Contains(got, w) {
+					t.Errorf("unexpected %q in:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/tool/verify"
+)
+
+// crashFailureClass is the failure class of a run that died with its worker
+// and was requeued by the queue recovery.
+const crashFailureClass = "worker crashed mid-run"
+
+// trackFailures extends the session's failure streak when verification failed
+// and ends it after a run that passed (or was not verified), so CheckCrashLoop
+// only ever sees failures in a row.
+func (e *Executor) trackFailures(ctx context.Context, t *session.Session, res *verify.Result, log *slog.Logger) {
+	if res == nil || res.Passed {
+		if t.FailureStreak == 0 {
+			return
+		}
+		if err := e.sessionService.ClearFailures(ctx, t.ID); err != nil {
+			log.Warn("failed to clear failure streak", "error", err)
+		}
+		t.FailureClass, t.FailureStreak = "", 0
+		return
+	}
+	class := verifyFailureClass(res)
+	streak, err := e.sessionService.RecordFailure(ctx, t.ID, class)
+	if err != nil {
+		log.Warn("failed to record failure", "error", err)
+		return
+	}
+	t.FailureClass, t.FailureStreak = class, streak
+}
+
+// verifyFailureClass tells failed verifications apart by the command that
+// failed and how, not by its output — test output changes between attempts
+// even when the agent makes no progress.
+func verifyFailureClass(res *verify.Result) string {
+	if res.TimedOut {
+		return fmt.Sprintf("verification: %s timed out", res.Command)
+	}
+	return fmt.Sprintf("verification: %s exit %d", res.Command, res.ExitCode)
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/freema/codeforge/internal/tool/verify"
+)
+
+func TestVerifyFailureClass(t *testing.T) {
+	tests := []struct {
+		name string
+		res  *verify.Result
+		want string
+	}{
+		{"exit code", &verify.Result{Command: "go test ./...", ExitCode: 1, Output: "FAIL x"}, "verification: go test ./... exit 1"},
+		{"timeout", &verify.Result{Command: "npm test", ExitCode: -1, TimedOut: true}, "verification: npm test timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyFailureClass(tt.res); got != tt.want {
+				t.Errorf("verifyFailureClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Output differs between attempts; the class must not.
+	a := &verify.Result{Command: "go test ./...", ExitCode: 1, Output: "FAIL TestA"}
+	b := &verify.Result{Command: "go test ./...", ExitCode: 1, Output: "FAIL TestB"}
+	if verifyFailureClass(a) != verifyFailureClass(b) {
+		t.Error("class depends on output")
+	}
+}
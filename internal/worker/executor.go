@@ -5,25 +5,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/fs"
 	"log/slog"
 	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 
+	"github.com/freema/codeforge/internal/ai"
+	"github.com/freema/codeforge/internal/apperror"
+	"github.com/freema/codeforge/internal/chaos"
+	"github.com/freema/codeforge/internal/hooks"
 	"github.com/freema/codeforge/internal/keys"
 	"github.com/freema/codeforge/internal/metrics"
 	"github.com/freema/codeforge/internal/notify"
 	"github.com/freema/codeforge/internal/prompt"
 	"github.com/freema/codeforge/internal/review"
+	"github.com/freema/codeforge/internal/runas"
 	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/stats"
 	"github.com/freema/codeforge/internal/tenant"
 	gitpkg "github.com/freema/codeforge/internal/tool/git"
 	"github.com/freema/codeforge/internal/tool/mcp"
@@ -36,6 +40,7 @@ import (
 
 const (
 	defaultMaxContextChars = 50000
+	defaultResultMaxChars  = 2000
 	defaultCLI             = "claude-code"
 )
 
@@ -44,8 +49,26 @@ type ExecutorConfig struct {
 	WorkspaceBase   string
 	DefaultTimeout  int
 	MaxTimeout      int
-	DefaultModels   map[string]string // CLI name → default model (e.g. "claude-code" → "claude-sonnet-4-...")
-	ProviderDomains map[string]string // custom domain → provider mappings
+	DefaultModels   map[string]string    // CLI name → default model (e.g. "claude-code" → "claude-sonnet-4-...")
+	ProviderDomains map[string]string    // custom domain → provider mappings
+	ProviderAPI     gitpkg.APIConfig     // provider API calls (git.api_base_urls)
+	ResultMaxChars  int                  // iteration/webhook result truncation (0 = default 2000)
+	MaxContextChars int                  // previous-iteration context budget for follow-up prompts (0 = default 50000)
+	ClaudeBackend   runner.ClaudeBackend // deployment-wide Claude Code backend (Anthropic API, Bedrock, Vertex)
+	// TranscriptMaxBytes caps the stored raw CLI transcript per iteration (0 = unlimited).
+	TranscriptMaxBytes int
+	// RunAs decides which user the CLI runs as and owns the workspace (nil = runas.Default).
+	RunAs runas.Strategy
+	// ProgressInterval is the period of cli_progress events during a CLI run (0 = none).
+	ProgressInterval time.Duration
+	// HeartbeatInterval is how long a CLI run may stay silent before a
+	// cli_heartbeat event, and the period of further ones (0 = none).
+	HeartbeatInterval time.Duration
+	// CrashLoopThreshold fails a session once this many automatic runs in a
+	// row failed with the same error class (0 = no limit).
+	CrashLoopThreshold int
+	// WorkspaceTemplates seeds fresh clones with dependency caches (nil = none).
+	WorkspaceTemplates *workspace.Templates
 }
 
 // PRCreator creates a PR/MR from a completed session's workspace.
@@ -55,18 +78,37 @@ type PRCreator interface {
 	CreatePR(ctx context.Context, sessionID string, req session.CreatePRRequest) (*session.CreatePRResponse, error)
 }
 
+// BranchStarter checks out the session branch right after the clone for
+// config.work_on_branch sessions. Implemented by *session.PRService; injected
+// via SetBranchStarter.
+type BranchStarter interface {
+	StartWorkBranch(ctx context.Context, t *session.Session, workDir string) (string, error)
+}
+
 // UsageLogger records per-tenant resource usage for subscription sessions.
 // Implemented by *tenant.Store; optional (nil = no per-tenant usage tracking).
 type UsageLogger interface {
 	LogUsage(ctx context.Context, log *tenant.UsageLog) error
 }
 
+// TenantBudget rejects runs of a tenant that spent its monthly budget.
+// Implemented by *tenant.Service; optional (nil = no tenant budgets).
+type TenantBudget interface {
+	CheckBudget(ctx context.Context, tenantID string) error
+}
+
 // SessionNotifier posts chat notifications for terminal session events.
 // Implemented by *notify.Notifier; optional (nil = notifications disabled).
 type SessionNotifier interface {
 	Notify(ctx context.Context, ev notify.Event)
 }
 
+// StatsRecorder folds finished sessions into rolling aggregates.
+// Implemented by *stats.Recorder; optional (nil = no stats).
+type StatsRecorder interface {
+	Record(ctx context.Context, ev stats.Event) error
+}
+
 // Executor orchestrates the full session lifecycle: clone → run CLI → diff → report.
 type Executor struct {
 	sessionService *session.Service
@@ -78,9 +120,21 @@ type Executor struct {
 	toolResolver   *tools.Resolver
 	workspaceMgr   *workspace.Manager
 	prCreator      PRCreator       // optional, nil = auto-PR disabled
+	branchStarter  BranchStarter   // optional, nil = work_on_branch is ignored
 	usageLogger    UsageLogger     // optional, nil = no per-tenant usage tracking
+	tenantBudget   TenantBudget    // optional, nil = no tenant budgets
 	notifier       SessionNotifier // optional, nil = notifications disabled
+	stats          StatsRecorder   // optional, nil = no stats
+	chaos          *chaos.Injector // optional, nil = no fault injection
+	aiClient       ai.Client       // optional, nil = summary context strategy falls back to recent
+	hooks          *hooks.Runner   // optional, nil = no executor hooks
 	cfg            ExecutorConfig
+
+	// leaseHeld is set by the pool; a run whose lease was reaped by another
+	// node must not write its result. Nil = always held.
+	leaseHeld func(ctx context.Context, sessionID string) bool
+
+	verifyFixes sync.Map // session ID → verifyFix, queued by the pool after the run
 }
 
 // SetPRCreator wires the PR creator used for auto-PR-enabled sessions (workflows).
@@ -89,18 +143,68 @@ func (e *Executor) SetPRCreator(pc PRCreator) {
 	e.prCreator = pc
 }
 
+// SetBranchStarter wires the work branch checkout for work_on_branch sessions.
+// Optional — when unset, sessions get their branch at create-pr as usual.
+func (e *Executor) SetBranchStarter(bs BranchStarter) {
+	e.branchStarter = bs
+}
+
 // SetUsageLogger wires per-tenant usage tracking. Optional — when unset,
 // subscription usage is not recorded.
 func (e *Executor) SetUsageLogger(ul UsageLogger) {
 	e.usageLogger = ul
 }
 
+// SetTenantBudget wires the monthly tenant budget check run before every
+// iteration. Optional — when unset, only session budgets apply.
+func (e *Executor) SetTenantBudget(tb TenantBudget) {
+	e.tenantBudget = tb
+}
+
 // SetNotifier wires chat notifications for terminal session events.
 // Optional — when unset, no notifications are sent.
 func (e *Executor) SetNotifier(n SessionNotifier) {
 	e.notifier = n
 }
 
+// SetStatsRecorder wires the rolling stats behind GET /api/v1/stats.
+// Optional — when unset, finished sessions are not aggregated.
+func (e *Executor) SetStatsRecorder(r StatsRecorder) {
+	e.stats = r
+}
+
+// SetChaos enables fault injection for clones and CLI runs (staging only).
+func (e *Executor) SetChaos(c *chaos.Injector) {
+	e.chaos = c
+}
+
+// SetHooks wires the pre/post clone and run hooks. Optional — when unset, no
+// hooks run.
+func (e *Executor) SetHooks(h *hooks.Runner) {
+	e.hooks = h
+}
+
+// recordStats folds a finished run into the rolling stats (best-effort).
+// usage may be nil for runs that ended without a result.
+func (e *Executor) recordStats(ctx context.Context, t *session.Session, status session.Status, startTime time.Time, usage *session.UsageInfo, costUSD float64, log *slog.Logger) {
+	if e.stats == nil {
+		return
+	}
+	ev := stats.Event{
+		RepoURL:  t.RepoURL,
+		Status:   string(status),
+		Duration: time.Since(startTime),
+		CostUSD:  costUSD,
+	}
+	if usage != nil {
+		ev.InputTokens = usage.InputTokens
+		ev.OutputTokens = usage.OutputTokens
+	}
+	if err := e.stats.Record(ctx, ev); err != nil {
+		log.Warn("failed to record stats", "error", err)
+	}
+}
+
 // maybeNotify fills session identity into the event and delivers it (best-effort).
 func (e *Executor) maybeNotify(ctx context.Context, t *session.Session, ev notify.Event) {
 	if e.notifier == nil {
@@ -124,6 +228,9 @@ func NewExecutor(
 	workspaceMgr *workspace.Manager,
 	cfg ExecutorConfig,
 ) *Executor {
+	if cfg.RunAs == nil {
+		cfg.RunAs = runas.Default()
+	}
 	return &Executor{
 		sessionService: sessionService,
 		cliRegistry:    cliRegistry,
@@ -162,8 +269,33 @@ func (e *Executor) Execute(ctx context.Context, t *session.Session) {
 		return
 	}
 
-	log := slog.With("session_id", t.ID, "iteration", t.Iteration, "trace_id", t.TraceID)
+	log := slog.With("session_id", t.ID, "iteration", t.Iteration, "trace_id", t.TraceID, "request_id", t.RequestID)
 	startTime := time.Now().UTC()
+	startTimings(t, startTime)
+
+	// Queued and automatic follow-ups were accepted before the iterations
+	// ahead of them had spent their share of the budget.
+	if err := session.CheckBudget(t); err != nil {
+		e.failSession(ctx, t, "budget_exceeded: "+err.Error(), startTime, log)
+		return
+	}
+	if e.tenantBudget != nil && t.TenantID != "" {
+		var appErr *apperror.AppError
+		if err := e.tenantBudget.CheckBudget(ctx, t.TenantID); errors.As(err, &appErr) && appErr.Code == "budget_exceeded" {
+			e.failSession(ctx, t, "budget_exceeded: "+err.Error(), startTime, log)
+			return
+		} else if err != nil {
+			// The API checked before accepting the work; a store hiccup
+			// here does not fail a session.
+			log.Warn("could not check tenant budget", "tenant_id", t.TenantID, "error", err)
+		}
+	}
+	// Automatic reruns (crash recovery, verification fixes) that keep failing
+	// the same way stop here; a user instruction resets the streak.
+	if err := session.CheckCrashLoop(t, e.cfg.CrashLoopThreshold); err != nil {
+		e.failSession(ctx, t, "crash_loop: "+err.Error(), startTime, log)
+		return
+	}
 
 	// Emit user instruction for follow-up iterations so the UI shows what the user asked
 	if t.Iteration > 1 && t.CurrentPrompt != "" {
@@ -185,6 +317,10 @@ func (e *Executor) Execute(ctx context.Context, t *session.Session) {
 
 	// Phase 1: resolve token + prepare workspace
 	e.resolveToken(sessionCtx, t, log)
+	e.streamer.RegisterSecrets(t.ID, t.AccessToken)
+	defer e.streamer.ForgetSecrets(t.ID)
+	var workDir string
+	defer func() { e.releaseCLIUser(t, workDir, log) }()
 	workDir, err := e.setupWorkspace(sessionCtx, ctx, t, startTime, log)
 	if err != nil {
 		return // failSession already called inside setupWorkspace
@@ -197,8 +333,15 @@ func (e *Executor) Execute(ctx context.Context, t *session.Session) {
 		return
 	}
 
-	// Phase 3: run CLI
+	// Phase 3: run CLI (bracketed by checkpoints for per-iteration diffs)
+	if err := e.runHooks(sessionCtx, t, hooks.PreRun, workDir, log); err != nil {
+		e.failSession(ctx, t, err.Error(), startTime, log)
+		return
+	}
+	e.checkpoint(ctx, t, workDir, gitpkg.CheckpointBefore, log)
+	runDone := timePhase(t, session.PhaseRun)
 	result, err := e.runStep(sessionCtx, t, workDir, mcpConfigPath, log)
+	runDone()
 	if err != nil {
 		// Timeout: complete gracefully with partial result instead of failing
 		if sessionCtx.Err() == context.DeadlineExceeded {
@@ -208,6 +351,11 @@ func (e *Executor) Execute(ctx context.Context, t *session.Session) {
 		e.handleRunError(ctx, t, err, startTime, log)
 		return
 	}
+	// Changes made by post-run hooks (formatters) are part of the iteration.
+	if err := e.runHooks(sessionCtx, t, hooks.PostRun, workDir, log); err != nil {
+		e.failSession(ctx, t, err.Error(), startTime, log)
+		return
+	}
 
 	// Phase 4: finalize
 	e.completeSession(ctx, t, result, workDir, startTime, false, log)
@@ -216,8 +364,8 @@ func (e *Executor) Execute(ctx context.Context, t *session.Session) {
 // resolveTimeout determines the effective session timeout in seconds.
 func (e *Executor) resolveTimeout(t *session.Session) int {
 	timeout := e.cfg.DefaultTimeout
-	if t.Config != nil && t.Config.TimeoutSeconds > 0 {
-		timeout = t.Config.TimeoutSeconds
+	if cfg := t.EffectiveConfig(); cfg != nil && cfg.TimeoutSeconds > 0 {
+		timeout = cfg.TimeoutSeconds
 	}
 	if timeout > e.cfg.MaxTimeout {
 		timeout = e.cfg.MaxTimeout
@@ -226,13 +374,19 @@ func (e *Executor) resolveTimeout(t *session.Session) int {
 }
 
 // resolveToken resolves the access token from the key registry if not already set.
+// Anonymous sessions get none: a server token is never sent to a repository
+// the session did not ask to authenticate against.
 func (e *Executor) resolveToken(ctx context.Context, t *session.Session, log *slog.Logger) {
 	if e.keyResolver == nil || t.AccessToken != "" {
 		return
 	}
+	if t.Config != nil && t.Config.Anonymous {
+		log.Info("anonymous session, cloning without credentials")
+		return
+	}
 	token, err := e.keyResolver.ResolveToken(ctx, t.RepoURL, t.AccessToken, t.ProviderKey)
 	if err != nil {
-		log.Warn("token resolution failed", "error", err)
+		log.Warn("token resolution failed, cloning without credentials", "error", err)
 		return
 	}
 	t.AccessToken = token
@@ -254,6 +408,7 @@ func (e *Executor) setupWorkspace(sessionCtx, parentCtx context.Context, t *sess
 			if _, statErr := os.Stat(refWs.Path); statErr == nil {
 				log.Info("reusing workspace from referenced session",
 					"ref_session_id", t.Config.WorkspaceSessionID, "work_dir", refWs.Path)
+				e.claimWorkspace(t, refWs.Path, log)
 				return refWs.Path, nil
 			}
 		}
@@ -271,26 +426,81 @@ func (e *Executor) setupWorkspace(sessionCtx, parentCtx context.Context, t *sess
 				workDir = ws.Path
 			}
 		}
+		e.startWorkBranch(sessionCtx, t, workDir, log)
 		return workDir, nil
 	}
 
 	// Follow-up iteration: reuse or re-clone
+	reclone := false
 	if _, err := os.Stat(workDir); os.IsNotExist(err) {
 		log.Warn("workspace missing for iteration, re-cloning", "work_dir", workDir)
-		if err := e.cloneStep(sessionCtx, t, workDir, log); err != nil {
-			e.terminateOnError(parentCtx, t, fmt.Sprintf("re-clone failed: %v", err), startTime, log)
-			return "", err
-		}
-	} else {
+		reclone = true
+	} else if err := gitpkg.CheckWorkspace(sessionCtx, workDir, t.RepoURL, gitpkg.CheckpointRef(t.Iteration-1, gitpkg.CheckpointAfter)); err != nil {
+		// A half-deleted workspace makes the CLI fail in confusing ways;
+		// start over from a fresh clone instead.
+		log.Warn("workspace corrupted, re-cloning", "work_dir", workDir, "error", err)
+		e.emitOrLog(e.streamer.EmitGit(sessionCtx, t.ID, "workspace_corrupted", map[string]string{
+			"reason": err.Error(),
+		}), log, "workspace_corrupted", t.ID)
+		e.removeWorkspace(parentCtx, t.ID, workDir, log)
+		reclone = true
+	}
+
+	if !reclone {
 		log.Info("reusing existing workspace", "work_dir", workDir)
-		if t.Branch != "" {
+		e.claimWorkspace(t, workDir, log)
+		if t.HasPR() {
 			e.pullBranch(sessionCtx, t, workDir, log)
 		}
+		return workDir, nil
 	}
 
+	if err := e.cloneStep(sessionCtx, t, workDir, log); err != nil {
+		e.terminateOnError(parentCtx, t, fmt.Sprintf("re-clone failed: %v", err), startTime, log)
+		return "", err
+	}
+	// The re-clone may land at a new slug-based path (the slug follows the
+	// current prompt).
+	if e.workspaceMgr != nil {
+		if ws := e.workspaceMgr.Get(parentCtx, t.ID); ws != nil && ws.Path != "" {
+			workDir = ws.Path
+		}
+	}
+	e.startWorkBranch(sessionCtx, t, workDir, log)
 	return workDir, nil
 }
 
+// startWorkBranch checks out the session branch in a fresh clone when the
+// session asked for config.work_on_branch. Best-effort: on failure the session
+// keeps working on the cloned branch and create-pr branches off at the end.
+func (e *Executor) startWorkBranch(ctx context.Context, t *session.Session, workDir string, log *slog.Logger) {
+	if e.branchStarter == nil || t.Config == nil || !t.Config.WorkOnBranch || t.SessionType == "pr_review" {
+		return
+	}
+	branch, err := e.branchStarter.StartWorkBranch(ctx, t, workDir)
+	if err != nil {
+		log.Warn("failed to check out work branch", "error", err)
+		return
+	}
+	log.Info("working on session branch", "branch", branch)
+	e.emitOrLog(e.streamer.EmitGit(ctx, t.ID, "work_branch_checked_out", map[string]string{
+		"branch": branch,
+	}), log, "work_branch_checked_out", t.ID)
+}
+
+// removeWorkspace deletes a workspace that is about to be re-cloned.
+func (e *Executor) removeWorkspace(ctx context.Context, sessionID, workDir string, log *slog.Logger) {
+	var err error
+	if e.workspaceMgr != nil {
+		err = e.workspaceMgr.Delete(ctx, sessionID)
+	} else {
+		err = os.RemoveAll(workDir)
+	}
+	if err != nil {
+		log.Warn("failed to remove corrupted workspace", "work_dir", workDir, "error", err)
+	}
+}
+
 // setupMCP resolves tool definitions and MCP server configs, writes .mcp.json.
 // Returns an error if the session explicitly requires tools/MCP and setup fails (fail-closed).
 func (e *Executor) setupMCP(ctx context.Context, t *session.Session, workDir string, log *slog.Logger) (string, error) {
@@ -379,9 +589,13 @@ func (e *Executor) handleTimeout(ctx context.Context, t *session.Session, result
 }
 
 // terminateOnError finishes a session whose step failed, routed by cause:
-// user cancel → canceled status, pool shutdown → requeue for the next start,
-// anything else → failed with errMsg.
+// lost lease → nothing (another node runs it), user cancel → canceled status,
+// pool shutdown → requeue for the next start, anything else → failed with errMsg.
 func (e *Executor) terminateOnError(ctx context.Context, t *session.Session, errMsg string, startTime time.Time, log *slog.Logger) {
+	if errors.Is(context.Cause(ctx), errLeaseLost) {
+		log.Warn("session lease lost, leaving the session to its new owner")
+		return
+	}
 	if errors.Is(context.Cause(ctx), errCanceledByUser) {
 		e.cancelSession(ctx, t, startTime, log)
 		return
@@ -401,9 +615,15 @@ func (e *Executor) cancelSession(ctx context.Context, t *session.Session, startT
 	finalCtx := context.WithoutCancel(ctx)
 
 	if err := e.sessionService.UpdateStatus(finalCtx, t.ID, session.StatusCanceled); err != nil {
+		if errors.Is(err, apperror.ErrInvalidTransition) {
+			// Already finished elsewhere, e.g. force-failed as stuck.
+			log.Info("canceled session already finished, leaving its status", "error", err)
+			return
+		}
 		log.Warn("failed to update session status to canceled", "error", err)
 	}
 	metrics.TasksTotal.WithLabelValues(string(session.StatusCanceled)).Inc()
+	e.recordStats(finalCtx, t, session.StatusCanceled, startTime, nil, 0, log)
 
 	now := time.Now().UTC()
 	prompt := t.CurrentPrompt
@@ -415,6 +635,8 @@ func (e *Executor) cancelSession(ctx context.Context, t *session.Session, startT
 		Prompt:    prompt,
 		Error:     "canceled by user",
 		Status:    session.StatusCanceled,
+		Config:    t.IterationConfig,
+		Timings:   e.recordTimings(finalCtx, t, log),
 		StartedAt: startTime,
 		EndedAt:   &now,
 	}); err != nil {
@@ -425,17 +647,23 @@ func (e *Executor) cancelSession(ctx context.Context, t *session.Session, startT
 	e.emitOrLog(e.streamer.EmitDone(finalCtx, t.ID, session.StatusCanceled, nil), log, "task_done_canceled", t.ID)
 
 	if t.CallbackURL != "" && e.webhook != nil {
-		if err := e.webhook.Send(finalCtx, t.CallbackURL, webhook.Payload{
-			TaskID:     t.ID,
-			Status:     string(session.StatusCanceled),
-			TraceID:    t.TraceID,
-			FinishedAt: time.Now().UTC(),
-		}); err != nil {
+		if err := e.webhook.Send(finalCtx, t.CallbackURL, e.webhookPayload(finalCtx, t, session.StatusCanceled, log)); err != nil {
 			log.Warn("failed to send cancellation webhook", "error", err)
 		}
 	}
 }
 
+// leaseLost reports whether this node lost the session's lease to a reaper,
+// checked before a run writes its outcome so a session requeued elsewhere is
+// not finished twice.
+func (e *Executor) leaseLost(ctx context.Context, t *session.Session, log *slog.Logger) bool {
+	if errors.Is(context.Cause(ctx), errLeaseLost) || (e.leaseHeld != nil && !e.leaseHeld(context.WithoutCancel(ctx), t.ID)) {
+		log.Warn("session lease lost, discarding the run's outcome")
+		return true
+	}
+	return false
+}
+
 // requeueForRestart puts a session interrupted by shutdown back into a
 // queueable state. The pool leaves its processing-list entry in place, so the
 // next server start requeues and re-runs it instead of losing the work.
@@ -464,21 +692,51 @@ func (e *Executor) handleRunError(ctx context.Context, t *session.Session, err e
 // completeSession handles post-CLI success: changes, result storage, status transition,
 // iteration record, events, pr_review handling, and webhook delivery.
 func (e *Executor) completeSession(ctx context.Context, t *session.Session, result *runner.RunResult, workDir string, startTime time.Time, timedOut bool, log *slog.Logger) {
-	changes, err := gitpkg.CalculateChanges(ctx, workDir)
+	if e.leaseLost(ctx, t, log) {
+		return
+	}
+	diffDone := timePhase(t, session.PhaseDiff)
+	e.checkpoint(ctx, t, workDir, gitpkg.CheckpointAfter, log)
+	diffDone()
+	if err := e.sessionService.ArchivePatch(ctx, t.ID, t.Iteration, workDir); err != nil {
+		log.Warn("archiving iteration patch failed", "error", err)
+	}
+
+	// CLI output can echo tokens or .env contents; mask before it is stored,
+	// streamed or sent to webhooks.
+	result.Output = e.streamer.Redact(t.ID, result.Output)
+	if result.Tools != nil {
+		for i, cmd := range result.Tools.Commands {
+			result.Tools.Commands[i] = e.streamer.Redact(t.ID, cmd)
+		}
+	}
+	e.extractStructured(ctx, t, result.Output, log)
+
+	diffDone = timePhase(t, session.PhaseDiff)
+	changes, err := gitpkg.CalculateChanges(ctx, workDir, t.IgnoreGlobs(workDir)...)
+	diffDone()
 	if err != nil {
 		log.Warn("failed to calculate changes", "error", err)
 	}
 
 	if e.workspaceMgr != nil {
-		if size, err := e.workspaceMgr.UpdateSize(ctx, t.ID); err == nil {
-			log.Info("workspace size updated", "size_bytes", size)
-		}
+		e.workspaceMgr.InvalidateSize(ctx, t.ID) // recomputed by the background sizer
 	}
 
+	verification := e.runVerification(ctx, t, workDir, timedOut, log)
+	e.trackFailures(ctx, t, verification, log)
+
+	instruction := t.CurrentPrompt
+	if instruction == "" {
+		instruction = t.Prompt
+	}
+	summary := e.summarizeIteration(ctx, t, instruction, result.Output, workDir, verification, log)
+
 	usage := &session.UsageInfo{
 		InputTokens:     result.InputTokens,
 		OutputTokens:    result.OutputTokens,
 		DurationSeconds: int(result.Duration.Seconds()),
+		CostUSD:         result.CostUSD,
 	}
 
 	if err := e.sessionService.SetResult(ctx, t.ID, result.Output, changes, usage); err != nil {
@@ -498,23 +756,32 @@ func (e *Executor) completeSession(ctx context.Context, t *session.Session, resu
 		prompt = t.Prompt
 	}
 	if err := e.sessionService.SaveIteration(ctx, t.ID, session.Iteration{
-		Number:    t.Iteration,
-		Prompt:    prompt,
-		Result:    truncate(result.Output, 2000),
-		Status:    session.StatusCompleted,
-		Changes:   changes,
-		Usage:     usage,
-		StartedAt: startTime,
-		EndedAt:   &now,
+		Number:       t.Iteration,
+		Prompt:       prompt,
+		Result:       truncate(result.Output, e.resultMaxChars()),
+		Structured:   t.ResultStructured,
+		Status:       session.StatusCompleted,
+		Changes:      changes,
+		Usage:        usage,
+		Verification: verification,
+		Config:       t.IterationConfig,
+		Summary:      summary,
+		Timings:      e.recordTimings(ctx, t, log),
+		ToolsSummary: result.Tools,
+		StartedAt:    startTime,
+		EndedAt:      &now,
 	}); err != nil {
 		log.Warn("failed to save iteration", "error", err)
 	}
 
 	e.emitOrLog(e.streamer.EmitResult(ctx, t.ID, "task_completed", map[string]interface{}{
-		"result":          truncate(result.Output, 2000),
-		"changes_summary": changes,
-		"usage":           usage,
-		"iteration":       t.Iteration,
+		"result":            truncate(result.Output, e.resultMaxChars()),
+		"changes_summary":   changes,
+		"usage":             usage,
+		"verification":      verification,
+		"iteration":         t.Iteration,
+		"result_structured": t.ResultStructured,
+		"tools_summary":     result.Tools,
 	}), log, "task_completed", t.ID)
 
 	// Review post-processing BEFORE done — client may close stream after done event
@@ -548,6 +815,8 @@ func (e *Executor) completeSession(ctx context.Context, t *session.Session, resu
 		finalStatus = session.StatusPRCreated
 	}
 
+	e.recordStats(ctx, t, finalStatus, startTime, usage, result.CostUSD, log)
+
 	e.emitOrLog(e.streamer.EmitDone(ctx, t.ID, finalStatus, changes), log, "task_done", t.ID)
 
 	evType := notify.EventSessionCompleted
@@ -562,9 +831,11 @@ func (e *Executor) completeSession(ctx context.Context, t *session.Session, resu
 	})
 
 	if t.CallbackURL != "" && e.webhook != nil {
-		e.sendWebhook(ctx, t, result.Output, changes, usage, log)
+		e.sendWebhook(ctx, t, finalStatus, result, changes, usage, log)
 	}
 
+	e.planVerifyFix(ctx, t, verification, log)
+
 	log.Info("session completed", "duration", result.Duration, "final_status", finalStatus)
 }
 
@@ -582,23 +853,24 @@ func (e *Executor) maybeLogUsage(ctx context.Context, t *session.Session, usage
 
 	cli := defaultCLI
 	model := ""
-	if t.Config != nil {
-		if t.Config.CLI != "" {
-			cli = t.Config.CLI
+	if cfg := t.EffectiveConfig(); cfg != nil {
+		if cfg.CLI != "" {
+			cli = cfg.CLI
 		}
-		model = t.Config.AIModel
+		model = cfg.AIModel
 	}
 	if model == "" {
 		model = e.cfg.DefaultModels[cli]
 	}
 
 	if err := e.usageLogger.LogUsage(ctx, &tenant.UsageLog{
-		TenantID:     tenantID,
-		SessionID:    t.ID,
-		CLI:          cli,
-		Model:        model,
-		InputTokens:  usage.InputTokens,
-		OutputTokens: usage.OutputTokens,
+		TenantID:         tenantID,
+		SessionID:        t.ID,
+		CLI:              cli,
+		Model:            model,
+		InputTokens:      usage.InputTokens,
+		OutputTokens:     usage.OutputTokens,
+		EstimatedCostUSD: usage.CostUSD,
 	}); err != nil {
 		log.Warn("failed to log tenant usage", "tenant_id", tenantID, "error", err)
 	}
@@ -623,10 +895,19 @@ func (e *Executor) maybeAutoCreatePR(ctx context.Context, t *session.Session, re
 		return false
 	}
 
+	// Failing tests or lint don't belong in a PR; a fix iteration may follow.
+	if t.Verification != nil && !t.Verification.Passed {
+		log.Info("auto-pr: verification failed, skipping PR creation")
+		e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "auto_pr_skipped", map[string]string{
+			"reason": "verification failed",
+		}), log, "auto_pr_skipped", t.ID)
+		return false
+	}
+
 	// Already has a PR (e.g. follow-up instruct iteration) — don't open a duplicate.
 	// The follow-up commits are already on the branch via the workspace; a human can
 	// push them with POST /sessions/:id/push.
-	if t.PRNumber != 0 || t.Branch != "" {
+	if t.HasPR() {
 		log.Info("auto-pr: session already has a PR, skipping duplicate", "pr_number", t.PRNumber, "branch", t.Branch)
 		e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "auto_pr_skipped", map[string]string{
 			"reason": "pr already exists",
@@ -648,6 +929,7 @@ func (e *Executor) maybeAutoCreatePR(ctx context.Context, t *session.Session, re
 		Title:        t.Config.PRTitle,
 		Description:  buildAutoPRDescription(result.Output),
 		TargetBranch: t.Config.TargetBranch,
+		AutoMerge:    t.Config.AutoMerge,
 	}
 
 	resp, err := e.prCreator.CreatePR(ctx, t.ID, req)
@@ -660,6 +942,7 @@ func (e *Executor) maybeAutoCreatePR(ctx context.Context, t *session.Session, re
 	}
 
 	log.Info("auto-pr: PR created", "pr_url", resp.PRURL, "branch", resp.Branch)
+	t.Branch, t.PRURL, t.PRNumber = resp.Branch, resp.PRURL, resp.PRNumber
 	e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "auto_pr_created", map[string]interface{}{
 		"pr_url":    resp.PRURL,
 		"pr_number": resp.PRNumber,
@@ -684,6 +967,15 @@ func buildAutoPRDescription(summary string) string {
 	return body + "\n\n---\n_Created automatically by a CodeForge workflow._"
 }
 
+// anonymousCloneError explains a clone that failed without credentials: the
+// repository is private (or does not exist), not merely unreachable.
+func anonymousCloneError(t *session.Session, err error) error {
+	if t.AccessToken != "" {
+		return err
+	}
+	return fmt.Errorf("%w (cloned without credentials: the repository must be public, otherwise set access_token or provider_key)", err)
+}
+
 // cloneWithRetry runs git clone with retries for transient failures (network
 // blips, provider hiccups). The destination is wiped between attempts because
 // git refuses to clone into a non-empty directory.
@@ -705,6 +997,9 @@ func (e *Executor) cloneWithRetry(ctx context.Context, sessionID string, opts gi
 				"attempt": fmt.Sprintf("%d", attempt+1),
 			}), log, "clone_retry", sessionID)
 		}
+		if err = e.chaos.CloneError(); err != nil {
+			continue
+		}
 		if err = gitpkg.Clone(ctx, opts); err == nil {
 			return nil
 		}
@@ -718,6 +1013,7 @@ func (e *Executor) cloneWithRetry(ctx context.Context, sessionID string, opts gi
 func (e *Executor) cloneStep(ctx context.Context, t *session.Session, workDir string, log *slog.Logger) error {
 	ctx, span := tracing.Tracer().Start(ctx, "task.clone")
 	defer span.End()
+	defer timePhase(t, session.PhaseClone)()
 
 	if err := e.sessionService.UpdateStatus(ctx, t.ID, session.StatusCloning); err != nil {
 		span.SetStatus(codes.Error, err.Error())
@@ -728,6 +1024,20 @@ func (e *Executor) cloneStep(ctx context.Context, t *session.Session, workDir st
 		"repo_url": gitpkg.SanitizeURL(t.RepoURL),
 	}), log, "clone_started", t.ID)
 
+	// A token that cannot see the repository fails here with a clear reason
+	// rather than as a git authentication error after three clone attempts.
+	if repo, err := e.parseRepo(ctx, t); err == nil {
+		if err := gitpkg.CheckAccess(ctx, repo, t.AccessToken, gitpkg.AccessRead); err != nil {
+			span.SetStatus(codes.Error, "token access check failed")
+			return err
+		}
+	}
+
+	if err := e.runHooks(ctx, t, hooks.PreClone, "", log); err != nil {
+		span.SetStatus(codes.Error, "pre_clone hook failed")
+		return err
+	}
+
 	// Create workspace via manager (or fallback to raw mkdir)
 	if e.workspaceMgr != nil {
 		prompt := t.Prompt
@@ -748,28 +1058,30 @@ func (e *Executor) cloneStep(ctx context.Context, t *session.Session, workDir st
 	// For pr_review tasks: clone the target branch (or default), then fetch the PR ref.
 	// This handles fork PRs where the source branch doesn't exist in the origin repo.
 	if t.SessionType == "pr_review" && t.Config != nil && t.Config.PRNumber > 0 {
-		targetBranch := t.Config.TargetBranch
-		if targetBranch == "" {
-			targetBranch = "main"
-		}
-
 		err := e.cloneWithRetry(ctx, t.ID, gitpkg.CloneOptions{
 			RepoURL: t.RepoURL,
 			DestDir: workDir,
 			Token:   t.AccessToken,
-			Branch:  targetBranch,
-			Shallow: false, // need full history for diff
+			Branch:  t.Config.TargetBranch, // empty = the repository's default branch
+			Shallow: false,                 // need full history for diff
 		}, log)
 		if err != nil {
 			span.SetStatus(codes.Error, "clone failed")
-			return err
+			return anonymousCloneError(t, err)
+		}
+		if t.Config.TargetBranch == "" {
+			// Store the detected base for the prompt template
+			t.Config.TargetBranch = gitpkg.ResolveDefaultBranch(ctx, workDir, nil, t.AccessToken)
 		}
 
-		// Determine the correct PR ref based on provider (GitHub vs GitLab)
+		// Determine the correct PR ref based on provider (GitLab and Azure DevOps
+		// differ; GitHub and Gitea publish pull/<n>/head)
 		repo, parseErr := gitpkg.ParseRepoURL(t.RepoURL, e.cfg.ProviderDomains)
 		var prRef string
 		if parseErr == nil && repo.Provider == gitpkg.ProviderGitLab {
 			prRef = fmt.Sprintf("merge-requests/%d/head", t.Config.PRNumber)
+		} else if parseErr == nil && repo.Provider == gitpkg.ProviderAzureDevOps {
+			prRef = fmt.Sprintf("pull/%d/merge", t.Config.PRNumber) // Azure Repos publishes no head ref
 		} else {
 			prRef = fmt.Sprintf("pull/%d/head", t.Config.PRNumber)
 		}
@@ -799,7 +1111,7 @@ func (e *Executor) cloneStep(ctx context.Context, t *session.Session, workDir st
 		}, log)
 		if err != nil {
 			span.SetStatus(codes.Error, "clone failed")
-			return err
+			return anonymousCloneError(t, err)
 		}
 	}
 
@@ -807,14 +1119,12 @@ func (e *Executor) cloneStep(ctx context.Context, t *session.Session, workDir st
 		"work_dir": workDir,
 	}), log, "clone_completed", t.ID)
 
-	// If running as root, chown workspace to "codeforge" user so the CLI
-	// (which drops privileges) can write to it.
-	if os.Getuid() == 0 {
-		if u, uErr := user.Lookup("codeforge"); uErr == nil {
-			uid, _ := strconv.Atoi(u.Uid)
-			gid, _ := strconv.Atoi(u.Gid)
-			_ = chownRecursive(workDir, uid, gid)
-		}
+	e.applyWorkspaceTemplate(ctx, t, workDir, log)
+	// Handed over first: post_clone hooks run as the CLI user.
+	e.chownToCLIUser(t, workDir, log)
+	if err := e.runHooks(ctx, t, hooks.PostClone, workDir, log); err != nil {
+		span.SetStatus(codes.Error, "post_clone hook failed")
+		return err
 	}
 
 	log.Info("repository cloned", "work_dir", workDir)
@@ -831,7 +1141,9 @@ func (e *Executor) pullBranch(ctx context.Context, t *session.Session, workDir s
 	}
 	defer cleanup()
 
-	cmd := exec.CommandContext(ctx, "git", "pull", "origin", t.Branch)
+	// Merge (never rebase) so conflicting commits pushed by others stay in the
+	// working tree as one merge for withConflictResolution to hand to the CLI.
+	cmd := exec.CommandContext(ctx, "git", "pull", "--no-rebase", "origin", t.Branch)
 	cmd.Dir = workDir
 	if len(askPassEnv) > 0 {
 		cmd.Env = append(os.Environ(), askPassEnv...)
@@ -842,6 +1154,68 @@ func (e *Executor) pullBranch(ctx context.Context, t *session.Session, workDir s
 	}
 }
 
+// withConflictResolution detects unresolved merge conflicts in the workspace
+// (left by a conflicting pull, or kept by a rebase with on_conflict=resolve),
+// emits a conflicts_detected event and prepends resolution steps to prompt.
+func (e *Executor) withConflictResolution(ctx context.Context, t *session.Session, workDir, instruction string, log *slog.Logger) string {
+	files, err := gitpkg.ConflictedFiles(ctx, workDir)
+	if err != nil || len(files) == 0 {
+		return instruction
+	}
+	log.Info("merge conflicts in workspace, resolving in this iteration", "files", files)
+	e.emitOrLog(e.streamer.EmitGit(ctx, t.ID, "conflicts_detected", map[string]interface{}{
+		"files":     files,
+		"iteration": t.Iteration,
+	}), log, "conflicts_detected", t.ID)
+
+	rendered, err := prompt.RenderResolveConflictsPrompt(prompt.ResolveConflictsData{Files: files, UserPrompt: instruction})
+	if err != nil {
+		log.Warn("failed to render conflict resolution prompt", "error", err)
+		return instruction
+	}
+	return rendered
+}
+
+// withAttachments writes the session's attachments into the workspace, keeps
+// them out of commits and lists them ahead of instruction.
+func (e *Executor) withAttachments(ctx context.Context, t *session.Session, workDir, instruction string, log *slog.Logger) (string, error) {
+	if len(t.Attachments) == 0 {
+		return instruction, nil
+	}
+	payloads, err := e.sessionService.LoadAttachments(ctx, t.ID)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(workDir, session.AttachmentsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating attachments dir: %w", err)
+	}
+	files := make([]prompt.AttachmentFile, 0, len(t.Attachments))
+	for _, a := range t.Attachments {
+		data, ok := payloads[a.Name]
+		if !ok {
+			return "", fmt.Errorf("attachment %s is missing (expired?)", a.Name)
+		}
+		if err := os.WriteFile(filepath.Join(dir, a.Name), data, 0o644); err != nil {
+			return "", fmt.Errorf("writing attachment %s: %w", a.Name, err)
+		}
+		files = append(files, prompt.AttachmentFile{Path: a.Path, ContentType: a.ContentType, Description: a.Description})
+	}
+	if err := gitpkg.ExcludeLocally(ctx, workDir, "/"+session.AttachmentsDir+"/"); err != nil {
+		return "", fmt.Errorf("excluding attachments from commits: %w", err)
+	}
+	e.chownToCLIUser(t, filepath.Dir(dir), log)
+	log.Info("attachments written to workspace", "count", len(files))
+
+	rendered, err := prompt.RenderAttachmentsPrompt(prompt.AttachmentsData{Files: files, UserPrompt: instruction})
+	if err != nil {
+		log.Warn("failed to render attachments prompt", "error", err)
+		return instruction, nil
+	}
+	return rendered, nil
+}
+
 func (e *Executor) runStep(ctx context.Context, t *session.Session, workDir string, mcpConfigPath string, log *slog.Logger) (*runner.RunResult, error) {
 	ctx, span := tracing.Tracer().Start(ctx, "task.run")
 	defer span.End()
@@ -886,37 +1260,83 @@ func (e *Executor) runStep(ctx context.Context, t *session.Session, workDir stri
 
 	// Build prompt with conversation context for iterations > 1
 	prompt := e.buildPrompt(ctx, t)
+	prompt, err = e.withAttachments(ctx, t, workDir, prompt, log)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	prompt = e.withConflictResolution(ctx, t, workDir, prompt, log)
+	prompt = e.withResultSchema(t, prompt, log)
 
 	model := e.cfg.DefaultModels[resolvedCLI]
 	apiKey := ""
 	var maxTurns int
 	var maxBudget float64
+	var reasoningEffort string
+	var thinkingBudget int
+	var allowedTools string
 
-	if t.Config != nil {
-		if t.Config.AIModel != "" {
-			model = t.Config.AIModel
+	if cfg := t.EffectiveConfig(); cfg != nil {
+		if cfg.AIModel != "" {
+			model = cfg.AIModel
+		}
+		apiKey = cfg.AIApiKey
+		maxTurns = cfg.MaxTurns
+		maxBudget = cfg.MaxBudgetUSD
+		allowedTools = cfg.AllowedTools
+		if cfg.Reasoning != nil {
+			reasoningEffort = cfg.Reasoning.Effort
+			thinkingBudget = cfg.Reasoning.BudgetTokens
 		}
-		apiKey = t.Config.AIApiKey
-		maxTurns = t.Config.MaxTurns
-		maxBudget = t.Config.MaxBudgetUSD
 	}
 
+	// The session budget spans all iterations: cap the run at what is left.
+	if remaining, ok := session.RemainingBudget(t); ok && (maxBudget == 0 || remaining < maxBudget) {
+		maxBudget = remaining
+	}
+
+	env, direct := e.backendEnv(t, cliMeta.AIProvider)
+	env = append(env, e.cacheEnv(t, workDir, log)...)
+
 	// If no per-session AI key, try to resolve from key registry.
-	if apiKey == "" && e.keyResolver != nil {
+	if apiKey == "" && direct && e.keyResolver != nil {
 		if resolved, err := e.keyResolver.ResolveAIKey(ctx, cliMeta.AIProvider); err == nil {
 			apiKey = resolved
 		}
 	}
 
-	result, err := cliRunner.Run(ctx, runner.RunOptions{
-		Prompt:        prompt,
-		WorkDir:       workDir,
-		Model:         model,
-		APIKey:        apiKey,
-		MaxTurns:      maxTurns,
-		MaxBudgetUSD:  maxBudget,
-		MCPConfigPath: mcpConfigPath,
+	e.streamer.RegisterSecrets(t.ID, apiKey)
+
+	transcript := newTranscriptRecorder(e.cfg.TranscriptMaxBytes)
+	defer e.saveTranscript(ctx, t, transcript, log)
+
+	runAs, err := e.cfg.RunAs.Identity(t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("resolving CLI user: %w", err)
+	}
+
+	runCtx, stopChaos := e.chaos.WithCLIKill(ctx)
+	defer stopChaos()
+
+	progress := runner.NewProgressTracker()
+	defer e.startProgress(ctx, t, progress, e.resolveTimeout(t), log)()
+
+	result, err := cliRunner.Run(runCtx, runner.RunOptions{
+		Prompt:               prompt,
+		WorkDir:              workDir,
+		Model:                model,
+		APIKey:               apiKey,
+		MaxTurns:             maxTurns,
+		MaxBudgetUSD:         maxBudget,
+		MCPConfigPath:        mcpConfigPath,
+		AllowedTools:         allowedTools,
+		ReasoningEffort:      reasoningEffort,
+		ThinkingBudgetTokens: thinkingBudget,
+		Env:                  env,
+		RunAs:                runAs,
 		OnEvent: func(event json.RawMessage) {
+			transcript.record(e.streamer.RedactJSON(t.ID, event))
+			progress.Observe(event)
 			if normalizer != nil {
 				if events := normalizer.Normalize(event); len(events) > 0 {
 					for _, normalized := range events {
@@ -929,6 +1349,12 @@ func (e *Executor) runStep(ctx context.Context, t *session.Session, workDir stri
 		},
 	})
 
+	recordUsageMetrics(resolvedCLI, model, result)
+	e.addCost(ctx, t, result, log)
+	if result != nil {
+		result.Tools = progress.Tools(workDir)
+	}
+
 	if err != nil {
 		return result, err
 	}
@@ -937,6 +1363,49 @@ func (e *Executor) runStep(ctx context.Context, t *session.Session, workDir stri
 	return result, nil
 }
 
+// recordUsageMetrics adds a run's token usage and reported spend to the
+// Prometheus counters. Failed and timed-out runs count too: the tokens were
+// consumed either way.
+func recordUsageMetrics(cli, model string, result *runner.RunResult) {
+	if result == nil {
+		return
+	}
+	if model == "" {
+		model = "default"
+	}
+	metrics.TokensTotal.WithLabelValues("input", model, cli).Add(float64(result.InputTokens))
+	metrics.TokensTotal.WithLabelValues("output", model, cli).Add(float64(result.OutputTokens))
+	if result.CostUSD > 0 {
+		metrics.CostUSDTotal.WithLabelValues(model, cli).Add(result.CostUSD)
+	}
+}
+
+// addCost books a run's reported spend on the session for the
+// config.max_budget_usd check. Failed and timed-out runs count too.
+func (e *Executor) addCost(ctx context.Context, t *session.Session, result *runner.RunResult, log *slog.Logger) {
+	if result == nil || result.CostUSD <= 0 {
+		return
+	}
+	t.CostUSD += result.CostUSD
+	if err := e.sessionService.AddCost(context.WithoutCancel(ctx), t.ID, result.CostUSD); err != nil {
+		log.Warn("failed to record session cost", "error", err)
+	}
+}
+
+// saveTranscript persists the raw CLI transcript of the current iteration.
+// It runs on a detached context so timed-out and canceled runs keep theirs.
+func (e *Executor) saveTranscript(ctx context.Context, t *session.Session, rec *transcriptRecorder, log *slog.Logger) {
+	if rec.buf.Len() == 0 {
+		return
+	}
+	if rec.truncated {
+		log.Warn("CLI transcript truncated", "max_bytes", e.cfg.TranscriptMaxBytes)
+	}
+	if err := e.sessionService.SaveTranscript(context.WithoutCancel(ctx), t.ID, t.Iteration, rec.buf.Bytes(), rec.truncated); err != nil {
+		log.Error("failed to store transcript", "error", err)
+	}
+}
+
 // buildPrompt constructs the prompt with conversation context for multi-turn iterations.
 func (e *Executor) buildPrompt(ctx context.Context, t *session.Session) string {
 	currentPrompt := t.CurrentPrompt
@@ -978,38 +1447,27 @@ func (e *Executor) buildPrompt(ctx context.Context, t *session.Session) string {
 		return currentPrompt
 	}
 
+	if t.Config != nil && t.Config.ContextStrategy == session.ContextNone {
+		return currentPrompt
+	}
+
 	// Load previous iterations for context
 	iterations, err := e.sessionService.GetIterations(ctx, t.ID)
 	if err != nil || len(iterations) == 0 {
 		return currentPrompt
 	}
 
-	var ctx2 strings.Builder
-	ctx2.WriteString("## Previous iterations on this codebase:\n\n")
-
-	totalChars := 0
-	// Build from oldest to newest, but we may need to truncate oldest first
-	for _, iter := range iterations {
-		entry := fmt.Sprintf("### Iteration %d\n**Prompt:** %s\n**Result summary:** %s\n**Status:** %s\n\n",
-			iter.Number, iter.Prompt, iter.Result, iter.Status)
-
-		if totalChars+len(entry) > defaultMaxContextChars {
-			// Truncate — drop this and older entries
-			ctx2.WriteString("(earlier iterations truncated for context limits)\n\n")
-			break
-		}
-
-		ctx2.WriteString(entry)
-		totalChars += len(entry)
+	history := e.iterationContext(ctx, t, iterations)
+	if history == "" {
+		return currentPrompt
 	}
-
-	ctx2.WriteString("## Current instruction:\n\n")
-	ctx2.WriteString(currentPrompt)
-
-	return ctx2.String()
+	return history + "## Current instruction:\n\n" + currentPrompt
 }
 
 func (e *Executor) failSession(ctx context.Context, t *session.Session, errMsg string, startTime time.Time, log *slog.Logger) {
+	if e.leaseLost(ctx, t, log) {
+		return
+	}
 	log.Error("session failed", "error", errMsg)
 
 	// Use a detached context for finalization — the original ctx may be canceled
@@ -1023,6 +1481,7 @@ func (e *Executor) failSession(ctx context.Context, t *session.Session, errMsg s
 		log.Warn("failed to update session status to failed", "error", err)
 	}
 	metrics.TasksTotal.WithLabelValues(string(session.StatusFailed)).Inc()
+	e.recordStats(finalCtx, t, session.StatusFailed, startTime, nil, 0, log)
 
 	// Save failed iteration record
 	now := time.Now().UTC()
@@ -1035,6 +1494,8 @@ func (e *Executor) failSession(ctx context.Context, t *session.Session, errMsg s
 		Prompt:    prompt,
 		Error:     errMsg,
 		Status:    session.StatusFailed,
+		Config:    t.IterationConfig,
+		Timings:   e.recordTimings(finalCtx, t, log),
 		StartedAt: startTime,
 		EndedAt:   &now,
 	}); err != nil {
@@ -1053,32 +1514,45 @@ func (e *Executor) failSession(ctx context.Context, t *session.Session, errMsg s
 	})
 
 	if t.CallbackURL != "" && e.webhook != nil {
-		if err := e.webhook.Send(finalCtx, t.CallbackURL, webhook.Payload{
-			TaskID:     t.ID,
-			Status:     string(session.StatusFailed),
-			Error:      errMsg,
-			TraceID:    t.TraceID,
-			FinishedAt: time.Now().UTC(),
-		}); err != nil {
+		payload := e.webhookPayload(finalCtx, t, session.StatusFailed, log)
+		payload.Error = errMsg
+		if err := e.webhook.Send(finalCtx, t.CallbackURL, payload); err != nil {
 			log.Warn("failed to send failure webhook", "error", err)
 		}
 	}
 }
 
-func (e *Executor) sendWebhook(ctx context.Context, t *session.Session, result string, changes *gitpkg.ChangesSummary, usage *session.UsageInfo, log *slog.Logger) {
-	if err := e.webhook.Send(ctx, t.CallbackURL, webhook.Payload{
-		TaskID:         t.ID,
-		Status:         string(session.StatusCompleted),
-		Result:         result,
-		ChangesSummary: changes,
-		Usage:          usage,
-		TraceID:        t.TraceID,
-		FinishedAt:     time.Now().UTC(),
-	}); err != nil {
+// sendWebhook reports a finished iteration. It is delivered after every
+// iteration, with a suggested next step for clients driving the session
+// from callbacks.
+func (e *Executor) sendWebhook(ctx context.Context, t *session.Session, status session.Status, result *runner.RunResult, changes *gitpkg.ChangesSummary, usage *session.UsageInfo, log *slog.Logger) {
+	payload := e.webhookPayload(ctx, t, session.StatusCompleted, log)
+	payload.Result = result.Output
+	payload.ToolsSummary = result.Tools
+	payload.ResultStructured = t.ResultStructured
+	payload.ResultStructuredError = t.ResultStructuredError
+	payload.ChangesSummary = changes
+	payload.Usage = usage
+	payload.SuggestedNext = e.sessionService.SuggestNext(t, status, changes)
+	if err := e.webhook.Send(ctx, t.CallbackURL, payload); err != nil {
 		log.Error("webhook delivery failed", "error", err)
 	}
 }
 
+// webhookPayload starts a terminal webhook for t, with the iteration history
+// when the session asked for it (config.include_iterations).
+func (e *Executor) webhookPayload(ctx context.Context, t *session.Session, status session.Status, log *slog.Logger) webhook.Payload {
+	payload := webhook.NewPayload(t, status)
+	if t.Config != nil && t.Config.IncludeIterations {
+		iterations, err := e.sessionService.GetIterations(ctx, t.ID)
+		if err != nil {
+			log.Warn("failed to load iterations for webhook", "error", err)
+		}
+		payload.Iterations = iterations
+	}
+	return payload
+}
+
 // fetchAndCheckoutPR fetches a PR ref from origin and checks out a local branch.
 // This handles both same-repo and fork PRs via the pull/{number}/head ref.
 func (e *Executor) fetchAndCheckoutPR(ctx context.Context, t *session.Session, workDir, prRef, localBranch string, log *slog.Logger) error {
@@ -1176,7 +1650,7 @@ func (e *Executor) handlePRReviewCompletion(ctx context.Context, t *session.Sess
 		token = resolved
 	}
 
-	repo, err := gitpkg.ParseRepoURL(t.RepoURL, e.cfg.ProviderDomains)
+	repo, err := e.parseRepo(ctx, t)
 	if err != nil {
 		log.Error("pr_review: failed to parse repo URL", "error", err)
 		return
@@ -1234,7 +1708,7 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 	)
 	defer span.End()
 
-	log := slog.With("session_id", t.ID, "trace_id", t.TraceID, "review", true)
+	log := slog.With("session_id", t.ID, "trace_id", t.TraceID, "request_id", t.RequestID, "review", true)
 	startTime := time.Now().UTC()
 
 	metrics.TasksInProgress.Inc()
@@ -1253,6 +1727,8 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 		e.failSession(ctx, t, "workspace not found for review — it may have been cleaned up", startTime, log)
 		return
 	}
+	e.claimWorkspace(t, workDir, log)
+	defer e.releaseCLIUser(t, workDir, log)
 
 	// Resolve CLI: review param → session config → default
 	cli := defaultCLI
@@ -1301,20 +1777,37 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 		apiKey = t.Config.AIApiKey
 	}
 
+	env, direct := e.backendEnv(t, cliMeta.AIProvider)
+
 	// If no per-session AI key, try to resolve from key registry.
-	if apiKey == "" && e.keyResolver != nil {
+	if apiKey == "" && direct && e.keyResolver != nil {
 		if resolved, resolveErr := e.keyResolver.ResolveAIKey(ctx, cliMeta.AIProvider); resolveErr == nil {
 			apiKey = resolved
 		}
 	}
 
+	e.streamer.RegisterSecrets(t.ID, t.AccessToken, apiKey)
+	defer e.streamer.ForgetSecrets(t.ID)
+
+	runAs, err := e.cfg.RunAs.Identity(t.ID)
+	if err != nil {
+		e.failSession(ctx, t, fmt.Sprintf("resolving CLI user: %v", err), startTime, log)
+		return
+	}
+
+	progress := runner.NewProgressTracker()
+	stopProgress := e.startProgress(sessionCtx, t, progress, timeout, log)
+
 	// Run CLI with streaming
 	result, err := cliRunner.Run(sessionCtx, runner.RunOptions{
 		Prompt:  reviewPrompt,
 		WorkDir: workDir,
 		Model:   model,
 		APIKey:  apiKey,
+		Env:     env,
+		RunAs:   runAs,
 		OnEvent: func(event json.RawMessage) {
+			progress.Observe(event)
 			if normalizer != nil {
 				if events := normalizer.Normalize(event); len(events) > 0 {
 					for _, normalized := range events {
@@ -1326,6 +1819,9 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 			e.emitOrLog(e.streamer.EmitCLIOutput(ctx, t.ID, event), log, "review_cli_output", t.ID)
 		},
 	})
+	stopProgress()
+	recordUsageMetrics(cli, model, result)
+	e.addCost(ctx, t, result, log)
 	if err != nil {
 		if sessionCtx.Err() == context.DeadlineExceeded {
 			e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "review_timeout", map[string]interface{}{
@@ -1340,6 +1836,8 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 		return
 	}
 
+	result.Output = e.streamer.Redact(t.ID, result.Output)
+
 	// Parse ReviewResult from CLI output
 	reviewResult, parseErr := review.ParseReviewOutput(result.Output)
 	if parseErr != nil {
@@ -1361,12 +1859,16 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 		reviewResult.ReviewedBy = cli
 	}
 	reviewResult.DurationSeconds = time.Since(startTime).Seconds()
+	if e.leaseLost(ctx, t, log) {
+		return
+	}
 
 	// Store raw result + usage
 	usage := &session.UsageInfo{
 		InputTokens:     result.InputTokens,
 		OutputTokens:    result.OutputTokens,
 		DurationSeconds: int(result.Duration.Seconds()),
+		CostUSD:         result.CostUSD,
 	}
 	if err := e.sessionService.SetResult(ctx, t.ID, result.Output, nil, usage); err != nil {
 		log.Error("failed to store review result", "error", err)
@@ -1396,6 +1898,8 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 		e.autoPostReviewToPR(ctx, t, t.Config.PRNumber, reviewResult, log)
 	}
 
+	e.recordStats(ctx, t, session.StatusCompleted, startTime, usage, result.CostUSD, log)
+
 	e.emitOrLog(e.streamer.EmitDone(ctx, t.ID, session.StatusCompleted, nil), log, "review_done", t.ID)
 
 	e.maybeNotify(ctx, t, notify.Event{
@@ -1407,14 +1911,11 @@ func (e *Executor) executeReview(ctx context.Context, t *session.Session) {
 	})
 
 	if t.CallbackURL != "" && e.webhook != nil {
-		if err := e.webhook.Send(ctx, t.CallbackURL, webhook.Payload{
-			TaskID:     t.ID,
-			Status:     string(session.StatusCompleted),
-			Result:     result.Output,
-			Usage:      usage,
-			TraceID:    t.TraceID,
-			FinishedAt: time.Now().UTC(),
-		}); err != nil {
+		payload := e.webhookPayload(ctx, t, session.StatusCompleted, log)
+		payload.Result = result.Output
+		payload.Usage = usage
+		payload.SuggestedNext = e.sessionService.SuggestAfterReview(t, len(reviewResult.Issues))
+		if err := e.webhook.Send(ctx, t.CallbackURL, payload); err != nil {
 			log.Warn("failed to send review completion webhook", "error", err)
 		}
 	}
@@ -1431,6 +1932,21 @@ func (e *Executor) autoPostReview(ctx context.Context, t *session.Session, revie
 	e.autoPostReviewToPR(ctx, t, t.PRNumber, reviewResult, log)
 }
 
+// parseRepo parses the session's repository URL for provider API calls,
+// applying the API base URL of its provider key when the key sets one.
+func (e *Executor) parseRepo(ctx context.Context, t *session.Session) (*gitpkg.RepoInfo, error) {
+	repo, err := gitpkg.ParseRepoURL(t.RepoURL, e.cfg.ProviderDomains)
+	if err != nil {
+		return nil, err
+	}
+	var explicit string
+	if e.keyResolver != nil {
+		explicit = e.keyResolver.APIBaseURL(ctx, t.ProviderKey)
+	}
+	e.cfg.ProviderAPI.Apply(repo, explicit)
+	return repo, nil
+}
+
 // autoPostReviewToPR posts review results to a specific PR number.
 func (e *Executor) autoPostReviewToPR(ctx context.Context, t *session.Session, prNumber int, reviewResult *review.ReviewResult, log *slog.Logger) {
 	token := t.AccessToken
@@ -1443,7 +1959,7 @@ func (e *Executor) autoPostReviewToPR(ctx context.Context, t *session.Session, p
 		token = resolved
 	}
 
-	repo, err := gitpkg.ParseRepoURL(t.RepoURL, e.cfg.ProviderDomains)
+	repo, err := e.parseRepo(ctx, t)
 	if err != nil {
 		log.Error("auto-post: failed to parse repo URL", "error", err)
 		return
@@ -1478,6 +1994,35 @@ func (e *Executor) autoPostReviewToPR(ctx context.Context, t *session.Session, p
 	)
 }
 
+// backendEnv returns the extra CLI environment selecting the model backend,
+// and whether the backend is the provider's direct API (which needs an API
+// key). Only Anthropic CLIs can be routed through Bedrock or Vertex; the
+// session's config.ai_backend overrides the deployment default.
+func (e *Executor) backendEnv(t *session.Session, aiProvider string) ([]string, bool) {
+	if aiProvider != "anthropic" {
+		return nil, true
+	}
+	backend := e.cfg.ClaudeBackend
+	if t.Config != nil && t.Config.AIBackend != "" {
+		backend.Name = t.Config.AIBackend
+	}
+	return backend.Env(), backend.Direct()
+}
+
+func (e *Executor) resultMaxChars() int {
+	if e.cfg.ResultMaxChars > 0 {
+		return e.cfg.ResultMaxChars
+	}
+	return defaultResultMaxChars
+}
+
+func (e *Executor) maxContextChars() int {
+	if e.cfg.MaxContextChars > 0 {
+		return e.cfg.MaxContextChars
+	}
+	return defaultMaxContextChars
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -1485,12 +2030,43 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// chownRecursive changes ownership of a directory tree.
-func chownRecursive(root string, uid, gid int) error {
-	return filepath.WalkDir(root, func(path string, _ fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		return os.Chown(path, uid, gid)
-	})
+// checkpoint snapshots the workspace for the current iteration (best-effort).
+// The before/after pair backs GET /sessions/{id}/iterations/{n}/diff.
+func (e *Executor) checkpoint(ctx context.Context, t *session.Session, workDir, stage string, log *slog.Logger) {
+	if _, err := gitpkg.Checkpoint(ctx, workDir, gitpkg.CheckpointRef(t.Iteration, stage)); err != nil {
+		log.Warn("workspace checkpoint failed", "stage", stage, "error", err)
+		return
+	}
+	// Objects written as root would lock the CLI user out of .git.
+	e.chownToCLIUser(t, filepath.Join(workDir, ".git"), log)
+}
+
+// claimWorkspace hands a reused workspace to the session's ephemeral CLI user;
+// it was taken back when the previous run released its user. Shared users
+// already own their workspaces.
+func (e *Executor) claimWorkspace(t *session.Session, workDir string, log *slog.Logger) {
+	if id, err := e.cfg.RunAs.Identity(t.ID); err == nil && id != nil && id.Ephemeral {
+		e.chownToCLIUser(t, workDir, log)
+	}
+}
+
+// releaseCLIUser ends the session's claim on its CLI user once the run is
+// over (ephemeral users are freed, the workspace taken back).
+func (e *Executor) releaseCLIUser(t *session.Session, workDir string, log *slog.Logger) {
+	if err := e.cfg.RunAs.Release(t.ID, workDir); err != nil {
+		log.Warn("releasing CLI user failed", "error", err)
+	}
+}
+
+// chownToCLIUser hands path over to the user the session's CLI runs as, so
+// it can write to it (best-effort; a no-op unless the server runs as root).
+func (e *Executor) chownToCLIUser(t *session.Session, path string, log *slog.Logger) {
+	id, err := e.cfg.RunAs.Identity(t.ID)
+	if err != nil {
+		log.Warn("resolving CLI user failed", "error", err)
+		return
+	}
+	if err := runas.Chown(path, id); err != nil {
+		log.Warn("handing workspace to CLI user failed", "path", path, "error", err)
+	}
 }
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/webhook"
+	"github.com/freema/codeforge/internal/workspace"
+)
+
+// StaleExpirer fails sessions left in pending or awaiting_instruction longer
+// than maxAge — abandoned conversations and queue entries nobody will pick up
+// — releases their workspaces and reports the failure to the callback URL, so
+// they do not pin disk forever.
+type StaleExpirer struct {
+	sessionService *session.Service
+	workspaceMgr   *workspace.Manager
+	webhook        *webhook.Sender
+	interval       time.Duration
+	maxAge         time.Duration
+}
+
+// NewStaleExpirer creates an expirer. workspaceMgr and sender may be nil.
+func NewStaleExpirer(sessionService *session.Service, workspaceMgr *workspace.Manager, sender *webhook.Sender, interval, maxAge time.Duration) *StaleExpirer {
+	return &StaleExpirer{
+		sessionService: sessionService,
+		workspaceMgr:   workspaceMgr,
+		webhook:        sender,
+		interval:       interval,
+		maxAge:         maxAge,
+	}
+}
+
+// Start runs the expiry loop until ctx is canceled. Call in a goroutine.
+func (x *StaleExpirer) Start(ctx context.Context) {
+	ticker := time.NewTicker(x.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			x.expire(ctx)
+		}
+	}
+}
+
+func (x *StaleExpirer) expire(ctx context.Context) {
+	ids, err := x.sessionService.ListStale(ctx, time.Now().Add(-x.maxAge))
+	if err != nil {
+		slog.Warn("stale expirer: listing failed", "error", err)
+		return
+	}
+
+	for _, id := range ids {
+		// ExpireStale re-checks the live state, so a session that moved on
+		// since the SQLite query is left alone.
+		t, err := x.sessionService.ExpireStale(ctx, id, x.maxAge)
+		if err != nil {
+			slog.Info("stale expirer: session skipped", "session_id", id, "error", err)
+			continue
+		}
+		slog.Warn("stale expirer: session expired", "session_id", id, "older_than", x.maxAge)
+
+		if x.workspaceMgr != nil {
+			if err := x.workspaceMgr.Delete(ctx, id); err != nil {
+				slog.Warn("stale expirer: workspace removal failed", "session_id", id, "error", err)
+			}
+		}
+		if t.CallbackURL != "" && x.webhook != nil {
+			payload := webhook.NewPayload(t, session.StatusFailed)
+			payload.Error = t.Error
+			if t.Config != nil && t.Config.IncludeIterations {
+				payload.Iterations, _ = x.sessionService.GetIterations(ctx, id)
+			}
+			if err := x.webhook.Send(ctx, t.CallbackURL, payload); err != nil {
+				slog.Warn("stale expirer: failure webhook not delivered", "session_id", id, "error", err)
+			}
+		}
+	}
+}
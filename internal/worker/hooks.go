@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/hooks"
+	"github.com/freema/codeforge/internal/session"
+)
+
+// runHooks runs the hooks of stage for the session and emits a hook_failed
+// event when a required one fails. Hooks in the workspace run as the
+// session's CLI user; pre_clone, without a workspace, as the server's.
+func (e *Executor) runHooks(ctx context.Context, t *session.Session, stage hooks.Stage, workDir string, log *slog.Logger) error {
+	ev := hooks.Event{
+		Stage:     stage,
+		SessionID: t.ID,
+		RepoURL:   t.RepoURL,
+		Iteration: t.Iteration,
+		WorkDir:   workDir,
+	}
+	if workDir != "" {
+		id, err := e.cfg.RunAs.Identity(t.ID)
+		if err != nil {
+			return fmt.Errorf("resolving hook user: %w", err)
+		}
+		ev.RunAs = id
+	}
+	err := e.hooks.Run(ctx, ev)
+	if err == nil {
+		return nil
+	}
+	log.Warn("hook failed", "stage", stage, "error", err)
+	var he *hooks.Error
+	if errors.As(err, &he) {
+		e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "hook_failed", map[string]interface{}{
+			"stage":     he.Stage,
+			"hook":      he.Hook,
+			"error":     e.streamer.Redact(t.ID, he.Err.Error()),
+			"iteration": t.Iteration,
+		}), log, "hook_failed", t.ID)
+	}
+	return err
+}
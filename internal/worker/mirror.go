@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// mirrorBatch caps the sessions copied per tick.
+const mirrorBatch = 100
+
+// MirrorSyncer copies sessions updated by runner agents into the server's
+// SQLite mirror, which agents cannot write themselves. Runs on the server.
+type MirrorSyncer struct {
+	sessionService *session.Service
+	interval       time.Duration
+}
+
+// NewMirrorSyncer creates a syncer polling every interval.
+func NewMirrorSyncer(sessionService *session.Service, interval time.Duration) *MirrorSyncer {
+	return &MirrorSyncer{sessionService: sessionService, interval: interval}
+}
+
+// Start runs the sync loop until ctx is canceled. Call in a goroutine.
+func (m *MirrorSyncer) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := m.sessionService.SyncMirror(ctx, mirrorBatch)
+			if err != nil {
+				slog.Warn("mirror sync failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Debug("mirrored sessions from runner agents", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Node roles.
+const (
+	RoleServer = "server" // API server, optionally running workers
+	RoleAgent  = "agent"  // runner agent started with `codeforge agent`: workers only
+)
+
+const (
+	nodeTTL       = 60 * time.Second // a node missing heartbeats this long is dead
+	nodeHeartbeat = 15 * time.Second
+	// nodeFence is how long a node may go without registering before it
+	// stops its runs: one heartbeat short of nodeTTL, so they stop before
+	// any other node can reap the leases.
+	nodeFence = nodeTTL - nodeHeartbeat
+)
+
+// Node is a process consuming the session queue, as registered in Redis.
+type Node struct {
+	ID          string    `json:"id"`
+	Role        string    `json:"role"`
+	Hostname    string    `json:"hostname"`
+	Version     string    `json:"version"`
+	Concurrency int       `json:"concurrency"`
+	Active      int       `json:"active"`
+	Paused      bool      `json:"paused"`
+	Processing  int64     `json:"processing"` // sessions leased by this node
+	StartedAt   time.Time `json:"started_at"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// DefaultNodeID identifies this process when workers.node_id is unset.
+func DefaultNodeID() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "local"
+}
+
+// SetNode sets the identity the pool registers under. Must be called before
+// Start; the default is the hostname with the server role. Two live
+// processes must never share a node ID — they would recover each other's
+// leases on restart.
+func (p *Pool) SetNode(id, role, version string) {
+	if id != "" {
+		p.nodeID = id
+	}
+	if role != "" {
+		p.role = role
+	}
+	p.version = version
+}
+
+// NodeID is the ID the pool registers and leases sessions under.
+func (p *Pool) NodeID() string {
+	return p.nodeID
+}
+
+func (p *Pool) nodesKey() string {
+	return p.redis.Key("nodes")
+}
+
+func (p *Pool) nodeKey(id string) string {
+	return p.redis.Key("node", id)
+}
+
+// register writes (or refreshes) this node's hash and keeps it in the node set.
+func (p *Pool) register(ctx context.Context) error {
+	hostname, _ := os.Hostname()
+	key := p.nodeKey(p.nodeID)
+	pipe := p.redis.Unwrap().TxPipeline()
+	pipe.HSet(ctx, key, map[string]any{
+		"role":        p.role,
+		"hostname":    hostname,
+		"version":     p.version,
+		"concurrency": p.concurrency,
+		"active":      p.ActiveCount(),
+		"paused":      strconv.FormatBool(p.Paused()),
+		"started_at":  p.startedAt.Format(time.RFC3339),
+		"last_seen":   time.Now().UTC().Format(time.RFC3339),
+	})
+	pipe.Expire(ctx, key, nodeTTL)
+	pipe.SAdd(ctx, p.nodesKey(), p.nodeID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	p.registeredAt.Store(time.Now().UnixNano())
+	return nil
+}
+
+// deregister removes the node hash on a clean stop. Set membership is kept so
+// a surviving node still reaps whatever was left in this node's lease list.
+func (p *Pool) deregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.redis.Unwrap().Del(ctx, p.nodeKey(p.nodeID)).Err(); err != nil {
+		slog.Warn("node deregistration failed", "node", p.nodeID, "error", err)
+	}
+}
+
+// heartbeat refreshes the registration and reaps dead nodes until ctx ends.
+func (p *Pool) heartbeat(ctx context.Context) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(nodeHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if p.degraded.Load() {
+				p.fenceStale()
+				continue // the circuit breaker re-registers on recovery
+			}
+			p.fenceLostLeases(ctx)
+			if err := p.register(ctx); err != nil {
+				slog.Warn("node heartbeat failed", "node", p.nodeID, "error", err)
+				continue
+			}
+			p.reapDeadNodes(ctx)
+		}
+	}
+}
+
+// fenceStale stops every local run once the node has gone nodeFence without
+// registering: cut off from Redis, its leases are about to be reaped and
+// requeued, and the sessions must not run on two nodes. Reports whether it
+// fenced.
+func (p *Pool) fenceStale() bool {
+	last := time.Unix(0, p.registeredAt.Load())
+	if last.Before(p.startedAt) {
+		last = p.startedAt // never registered: a previous run's leases may be reaped
+	}
+	if time.Since(last) < nodeFence {
+		return false
+	}
+	if n := p.cancelAll(errLeaseLost); n > 0 {
+		slog.Warn("node registration stale, stopped local runs", "node", p.nodeID, "sessions", n)
+	}
+	return true
+}
+
+// fenceLostLeases stops the local runs whose session is no longer in this
+// node's lease list — taken over by a reaper while the node was cut off.
+func (p *Pool) fenceLostLeases(ctx context.Context) {
+	if p.fenceStale() {
+		return
+	}
+	p.cancelsMu.RLock()
+	running := make(map[string]context.CancelCauseFunc, len(p.cancels))
+	for id, cancelFn := range p.cancels {
+		running[id] = cancelFn
+	}
+	p.cancelsMu.RUnlock()
+	for id, cancelFn := range running {
+		if !p.holdsLease(ctx, id) {
+			slog.Warn("session lease taken over by another node, stopping run", "node", p.nodeID, "session_id", id)
+			cancelFn(errLeaseLost)
+		}
+	}
+}
+
+// holdsLease reports whether sessionID is still in this node's lease list.
+// A failed lookup counts as held: fenceStale covers a node cut off from Redis.
+func (p *Pool) holdsLease(ctx context.Context, sessionID string) bool {
+	_, err := p.redis.Unwrap().LPos(ctx, p.processingKey(), sessionID, redis.LPosArgs{}).Result()
+	return !errors.Is(err, redis.Nil)
+}
+
+// reapDeadNodes takes over the leases of nodes whose registration expired:
+// each entry is moved into this node's lease list and recovered from there,
+// so a reaper crashing mid-way loses nothing.
+func (p *Pool) reapDeadNodes(ctx context.Context) {
+	// After a Redis outage every node's registration may have expired; give
+	// the others a full TTL to re-register before taking their leases.
+	if time.Since(time.Unix(0, p.resumedAt.Load())) < nodeTTL {
+		return
+	}
+	rdb := p.redis.Unwrap()
+	ids, err := rdb.SMembers(ctx, p.nodesKey()).Result()
+	if err != nil {
+		slog.Warn("node reaper: listing nodes failed", "error", err)
+		return
+	}
+	own := p.processingKey()
+	for _, id := range ids {
+		if id == p.nodeID {
+			continue
+		}
+		alive, err := rdb.Exists(ctx, p.nodeKey(id)).Result()
+		if err != nil || alive > 0 {
+			continue
+		}
+		dead := p.processingKeyFor(id)
+		reaped := 0
+		for {
+			sessionID, err := rdb.LMove(ctx, dead, own, "LEFT", "RIGHT").Result()
+			if errors.Is(err, redis.Nil) {
+				break
+			}
+			if err != nil {
+				slog.Warn("node reaper: moving lease failed", "node", id, "error", err)
+				return
+			}
+			p.recoverOne(ctx, own, sessionID)
+			reaped++
+		}
+		if err := rdb.SRem(ctx, p.nodesKey(), id).Err(); err != nil {
+			slog.Warn("node reaper: removing node failed", "node", id, "error", err)
+			continue
+		}
+		slog.Info("node reaper: removed dead node", "node", id, "requeued", reaped)
+	}
+}
+
+// Nodes lists the live nodes with the number of sessions each has leased.
+func (p *Pool) Nodes(ctx context.Context) ([]Node, error) {
+	rdb := p.redis.Unwrap()
+	ids, err := rdb.SMembers(ctx, p.nodesKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]Node, 0, len(ids))
+	for _, id := range ids {
+		h, err := rdb.HGetAll(ctx, p.nodeKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(h) == 0 {
+			continue // expired, waiting for the reaper
+		}
+		n := Node{ID: id, Role: h["role"], Hostname: h["hostname"], Version: h["version"]}
+		n.Concurrency, _ = strconv.Atoi(h["concurrency"])
+		n.Active, _ = strconv.Atoi(h["active"])
+		n.Paused, _ = strconv.ParseBool(h["paused"])
+		n.StartedAt, _ = time.Parse(time.RFC3339, h["started_at"])
+		n.LastSeen, _ = time.Parse(time.RFC3339, h["last_seen"])
+		n.Processing, _ = rdb.LLen(ctx, p.processingKeyFor(id)).Result()
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// leaseOwner returns the live node whose lease list holds sessionID, or "".
+func (p *Pool) leaseOwner(ctx context.Context, sessionID string) string {
+	rdb := p.redis.Unwrap()
+	ids, err := rdb.SMembers(ctx, p.nodesKey()).Result()
+	if err != nil {
+		return ""
+	}
+	for _, id := range ids {
+		if id == p.nodeID {
+			continue
+		}
+		if _, err := rdb.LPos(ctx, p.processingKeyFor(id), sessionID, redis.LPosArgs{}).Result(); err == nil {
+			return id
+		}
+	}
+	return ""
+}
+
+// InQueue reports whether sessionID waits in the queue or is leased by any
+// node. The queue is checked first: a dequeue moves the session from there
+// into a lease list, never the other way round.
+func (p *Pool) InQueue(ctx context.Context, sessionID string) (bool, error) {
+	rdb := p.redis.Unwrap()
+	ids, err := rdb.SMembers(ctx, p.nodesKey()).Result()
+	if err != nil {
+		return false, err
+	}
+	lists := append(p.queueKeys(), p.processingKey(), p.legacyProcessingKey())
+	for _, id := range ids {
+		if id != p.nodeID {
+			lists = append(lists, p.processingKeyFor(id))
+		}
+	}
+	for _, key := range lists {
+		_, err := rdb.LPos(ctx, key, sessionID, redis.LPosArgs{}).Result()
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (p *Pool) cancelChannel() string {
+	return p.redis.Key("pool", "cancel")
+}
+
+// listenCancels cancels sessions running here on request of other nodes —
+// the cancel API may be served by a node that is not running the session.
+func (p *Pool) listenCancels(ctx context.Context) {
+	defer p.wg.Done()
+	sub := p.redis.Unwrap().Subscribe(ctx, p.cancelChannel())
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			if p.cancelLocal(msg.Payload) {
+				slog.Info("session canceled on request of another node", "session_id", msg.Payload)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+//go:build integration
+
+package worker
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/crypto"
+	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/session"
+)
+
+func setupNodePool(t *testing.T) (*Pool, *redisclient.Client) {
+	t.Helper()
+	url := os.Getenv("CODEFORGE_REDIS__URL")
+	if url == "" {
+		url = "redis://localhost:6379"
+	}
+	rdb, err := redisclient.New(url, "test:nodes:")
+	if err != nil {
+		t.Skipf("skipping: redis not available: %v", err)
+	}
+	if err := rdb.Ping(context.Background()); err != nil {
+		rdb.Close()
+		t.Skipf("skipping: redis not reachable: %v", err)
+	}
+	t.Cleanup(func() {
+		rdb.Unwrap().FlushDB(context.Background())
+		rdb.Close()
+	})
+
+	cryptoSvc, err := crypto.NewService(base64.StdEncoding.EncodeToString([]byte("test-encryption-key-32-bytes!xxx")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := session.NewService(rdb, cryptoSvc, nil, "queue:test", time.Hour, time.Hour)
+	p := NewPool(rdb, nil, svc, "queue:test", 0)
+	p.SetNode("node-a", RoleServer, "test")
+	p.startedAt = time.Now().UTC()
+	return p, rdb
+}
+
+func TestPool_ReapDeadNode(t *testing.T) {
+	p, rdb := setupNodePool(t)
+	ctx := context.Background()
+	r := rdb.Unwrap()
+
+	sess, err := p.sessionService.Create(ctx, session.CreateSessionRequest{
+		RepoURL: "https://github.com/test/repo.git",
+		Prompt:  "test prompt",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// node-b dequeued the session, started it and died without deregistering.
+	r.LMove(ctx, p.queueKey(session.PriorityNormal), p.processingKeyFor("node-b"), "LEFT", "RIGHT")
+	for _, st := range []session.Status{session.StatusCloning, session.StatusRunning} {
+		if err := p.sessionService.UpdateStatus(ctx, sess.ID, st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.SAdd(ctx, p.nodesKey(), "node-b")
+
+	if err := p.register(ctx); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	p.reapDeadNodes(ctx)
+
+	if ids, _ := r.LRange(ctx, p.queueKey(session.PriorityNormal), 0, -1).Result(); len(ids) != 1 || ids[0] != sess.ID {
+		t.Errorf("queue = %v, want the reaped session", ids)
+	}
+	if n, _ := r.LLen(ctx, p.processingKeyFor("node-b")).Result(); n != 0 {
+		t.Errorf("dead node still holds %d leases", n)
+	}
+	if got, _ := p.sessionService.Get(ctx, sess.ID); got.Status != session.StatusPending {
+		t.Errorf("status = %s, want pending", got.Status)
+	}
+
+	nodes, err := p.Nodes(ctx)
+	if err != nil {
+		t.Fatalf("Nodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != "node-a" || nodes[0].Role != RoleServer || nodes[0].Version != "test" {
+		t.Errorf("nodes = %+v, want only node-a", nodes)
+	}
+}
+
+func TestPool_LeaseOwner(t *testing.T) {
+	p, rdb := setupNodePool(t)
+	ctx := context.Background()
+	r := rdb.Unwrap()
+
+	r.SAdd(ctx, p.nodesKey(), "node-a", "node-b")
+	r.RPush(ctx, p.processingKeyFor("node-b"), "sess-1")
+
+	if got := p.leaseOwner(ctx, "sess-1"); got != "node-b" {
+		t.Errorf("leaseOwner = %q, want node-b", got)
+	}
+	if got := p.leaseOwner(ctx, "sess-2"); got != "" {
+		t.Errorf("leaseOwner(unknown) = %q", got)
+	}
+	if err := p.Cancel("sess-2"); err == nil {
+		t.Error("canceling a session no node runs should fail")
+	}
+}
+
+func TestPool_FenceLostLeases(t *testing.T) {
+	p, rdb := setupNodePool(t)
+	ctx := context.Background()
+	if err := p.register(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	heldCtx, heldCancel := context.WithCancelCause(ctx)
+	defer heldCancel(nil)
+	lostCtx, lostCancel := context.WithCancelCause(ctx)
+	defer lostCancel(nil)
+	p.cancels["sess-held"] = heldCancel
+	p.cancels["sess-lost"] = lostCancel
+	// sess-lost was reaped by another node while this one was cut off.
+	rdb.Unwrap().RPush(ctx, p.processingKey(), "sess-held")
+
+	p.fenceLostLeases(ctx)
+	if heldCtx.Err() != nil {
+		t.Error("run with its lease in place was stopped")
+	}
+	if !errors.Is(context.Cause(lostCtx), errLeaseLost) {
+		t.Errorf("cause = %v, want errLeaseLost", context.Cause(lostCtx))
+	}
+}
@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/workspace"
+)
+
+// orphanSuffixes are the per-session keys reconciled against session state.
+// Transcripts are left alone: they deliberately outlive the session state.
+var orphanSuffixes = []string{"history", "result", "iterations"}
+
+// BlobDeleter deletes the blob-store objects that Redis values of a session
+// point to. Implemented by *session.Service.
+type BlobDeleter interface {
+	DeleteBlobs(ctx context.Context, sessionID string, values []string)
+}
+
+// OrphanSweeper reconciles per-session Redis keys against their session state.
+// A process dying at the wrong moment can leave history/result/iteration keys
+// without a state key (deleted, with the blobs they point to) or without a
+// TTL while the state expires (re-TTL'd to match), and workspace hashes
+// pointing at directories that no longer exist (dropped).
+type OrphanSweeper struct {
+	redis        *redisclient.Client
+	workspaceMgr *workspace.Manager
+	blobs        BlobDeleter // optional, nil = offloaded blobs are left alone
+	interval     time.Duration
+}
+
+// NewOrphanSweeper creates a sweeper. workspaceMgr may be nil.
+func NewOrphanSweeper(redis *redisclient.Client, workspaceMgr *workspace.Manager, interval time.Duration) *OrphanSweeper {
+	return &OrphanSweeper{redis: redis, workspaceMgr: workspaceMgr, interval: interval}
+}
+
+// SetBlobDeleter wires deletion of the blobs orphaned keys point to.
+// Optional — when unset, only the Redis keys are deleted.
+func (s *OrphanSweeper) SetBlobDeleter(d BlobDeleter) {
+	s.blobs = d
+}
+
+// Start runs the sweep loop until ctx is canceled. Call in a goroutine.
+func (s *OrphanSweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *OrphanSweeper) sweep(ctx context.Context) {
+	var deleted, rettled int
+	for _, suffix := range orphanSuffixes {
+		d, r, err := s.sweepSuffix(ctx, suffix)
+		deleted += d
+		rettled += r
+		if err != nil {
+			slog.Warn("orphan sweeper: scan failed", "suffix", suffix, "error", err)
+		}
+	}
+	dropped := s.sweepWorkspaces(ctx)
+
+	if deleted+rettled+dropped > 0 {
+		slog.Info("orphan sweeper: reconciled keys",
+			"deleted", deleted, "ttl_fixed", rettled, "workspaces_dropped", dropped)
+	}
+}
+
+// sweepSuffix reconciles every session:{id}:{suffix} key. Returns the number
+// of keys deleted and re-TTL'd.
+func (s *OrphanSweeper) sweepSuffix(ctx context.Context, suffix string) (int, int, error) {
+	rdb := s.redis.Unwrap()
+	prefix := s.redis.Key("session", "")
+	var deleted, rettled int
+
+	iter := rdb.Scan(ctx, 0, prefix+"*:"+suffix, 200).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		id := strings.TrimSuffix(strings.TrimPrefix(key, prefix), ":"+suffix)
+		if id == "" || strings.Contains(id, ":") {
+			continue
+		}
+
+		pipe := rdb.Pipeline()
+		stateTTL := pipe.TTL(ctx, s.redis.Key("session", id, "state"))
+		keyTTL := pipe.TTL(ctx, key)
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return deleted, rettled, err
+		}
+
+		switch st, kt := stateTTL.Val(), keyTTL.Val(); {
+		case st == -2: // state gone: the session lives on in SQLite only
+			values := s.blobValues(ctx, key, suffix)
+			if rdb.Del(ctx, key).Err() == nil {
+				deleted++
+				if s.blobs != nil && len(values) > 0 {
+					s.blobs.DeleteBlobs(ctx, id, values)
+				}
+			}
+		case st > 0 && kt == -1: // state expires but this key never would
+			if rdb.Expire(ctx, key, st).Err() == nil {
+				rettled++
+			}
+		}
+	}
+	return deleted, rettled, iter.Err()
+}
+
+// blobValues reads the values of an orphaned key that may point to offloaded
+// blobs: the result, and the archived chunks of the event history. SQLite
+// keeps the resolved result, so the blobs are not needed once the key goes.
+func (s *OrphanSweeper) blobValues(ctx context.Context, key, suffix string) []string {
+	if s.blobs == nil {
+		return nil
+	}
+	rdb := s.redis.Unwrap()
+	switch suffix {
+	case "result":
+		if v, err := rdb.Get(ctx, key).Result(); err == nil {
+			return []string{v}
+		}
+	case "history":
+		if v, err := rdb.LRange(ctx, key, 0, -1).Result(); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// sweepWorkspaces drops workspace metadata whose directory no longer exists.
+func (s *OrphanSweeper) sweepWorkspaces(ctx context.Context) int {
+	if s.workspaceMgr == nil {
+		return 0
+	}
+	workspaces, err := s.workspaceMgr.List(ctx)
+	if err != nil {
+		slog.Warn("orphan sweeper: listing workspaces failed", "error", err)
+		return 0
+	}
+
+	dropped := 0
+	for _, ws := range workspaces {
+		if ws.Path == "" {
+			continue
+		}
+		if _, err := os.Stat(ws.Path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := s.workspaceMgr.Delete(ctx, ws.TaskID); err != nil {
+			slog.Warn("orphan sweeper: dropping workspace failed", "session_id", ws.TaskID, "error", err)
+			continue
+		}
+		dropped++
+	}
+	return dropped
+}
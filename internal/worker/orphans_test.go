@@ -0,0 +1,86 @@
+//go:build integration
+
+package worker
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/workspace"
+)
+
+func TestOrphanSweeper(t *testing.T) {
+	url := os.Getenv("CODEFORGE_REDIS__URL")
+	if url == "" {
+		url = "redis://localhost:6379"
+	}
+	rdb, err := redisclient.New(url, "test:orphans:")
+	if err != nil {
+		t.Skipf("skipping: redis not available: %v", err)
+	}
+	ctx := context.Background()
+	if err := rdb.Ping(ctx); err != nil {
+		rdb.Close()
+		t.Skipf("skipping: redis not reachable: %v", err)
+	}
+	t.Cleanup(func() {
+		rdb.Unwrap().FlushDB(context.Background())
+		rdb.Close()
+	})
+	r := rdb.Unwrap()
+
+	// Orphan: history without state, with an archived chunk.
+	r.RPush(ctx, rdb.Key("session", "gone", "history"), "blob://sessions/gone/history/0.jsonl.gz", "{}")
+	// Expiring state with a result that never expires.
+	r.HSet(ctx, rdb.Key("session", "live", "state"), "status", "completed")
+	r.Expire(ctx, rdb.Key("session", "live", "state"), time.Hour)
+	r.Set(ctx, rdb.Key("session", "live", "result"), "done", 0)
+	// Running session: no TTLs anywhere, left alone.
+	r.HSet(ctx, rdb.Key("session", "run", "state"), "status", "running")
+	r.RPush(ctx, rdb.Key("session", "run", "history"), "{}")
+
+	mgr := workspace.NewManager(t.TempDir(), rdb, time.Hour)
+	ws, err := mgr.Create(ctx, "ws-gone", "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.RemoveAll(ws.Path)
+
+	blobs := &recordingBlobDeleter{}
+	sweeper := NewOrphanSweeper(rdb, mgr, time.Hour)
+	sweeper.SetBlobDeleter(blobs)
+	sweeper.sweep(ctx)
+
+	if n, _ := r.Exists(ctx, rdb.Key("session", "gone", "history")).Result(); n != 0 {
+		t.Error("orphaned history was not deleted")
+	}
+	if len(blobs.values["gone"]) != 2 || blobs.values["gone"][0] != "blob://sessions/gone/history/0.jsonl.gz" {
+		t.Errorf("blob deletion got %v, want the orphaned history entries", blobs.values)
+	}
+	if _, ok := blobs.values["live"]; ok {
+		t.Error("blobs of a live session were deleted")
+	}
+	if ttl, _ := r.TTL(ctx, rdb.Key("session", "live", "result")).Result(); ttl <= 0 {
+		t.Errorf("result TTL = %v, want state TTL", ttl)
+	}
+	if ttl, _ := r.TTL(ctx, rdb.Key("session", "run", "history")).Result(); ttl != -1 {
+		t.Errorf("running session history TTL = %v, want none", ttl)
+	}
+	if mgr.Get(ctx, "ws-gone") != nil {
+		t.Error("workspace metadata without a directory was not dropped")
+	}
+}
+
+type recordingBlobDeleter struct {
+	values map[string][]string
+}
+
+func (d *recordingBlobDeleter) DeleteBlobs(_ context.Context, sessionID string, values []string) {
+	if d.values == nil {
+		d.values = map[string][]string{}
+	}
+	d.values[sessionID] = append(d.values[sessionID], values...)
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// outboxBatch caps the outbox entries checked per tick.
+const outboxBatch = 100
+
+// OutboxReconciler re-enqueues sessions whose outbox entry was never acked
+// by a worker and which are in no queue or lease list — the state says they
+// wait for a worker, but none would ever pick them up.
+type OutboxReconciler struct {
+	sessionService *session.Service
+	pool           *Pool
+	interval       time.Duration
+	grace          time.Duration
+}
+
+// NewOutboxReconciler creates a reconciler. Entries younger than grace are
+// left alone: they are normally acked within seconds.
+func NewOutboxReconciler(sessionService *session.Service, pool *Pool, interval, grace time.Duration) *OutboxReconciler {
+	return &OutboxReconciler{
+		sessionService: sessionService,
+		pool:           pool,
+		interval:       interval,
+		grace:          grace,
+	}
+}
+
+// Start runs the reconcile loop until ctx is canceled. Call in a goroutine.
+func (o *OutboxReconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.reconcile(ctx)
+		}
+	}
+}
+
+func (o *OutboxReconciler) reconcile(ctx context.Context) {
+	ids, err := o.sessionService.OutboxDue(ctx, time.Now().Add(-o.grace), outboxBatch)
+	if err != nil {
+		slog.Warn("outbox reconciler: listing failed", "error", err)
+		return
+	}
+	for _, id := range ids {
+		requeued, err := o.sessionService.ReconcileOutbox(ctx, id, o.pool.InQueue)
+		if err != nil {
+			slog.Warn("outbox reconciler: session skipped", "session_id", id, "error", err)
+			continue
+		}
+		if requeued {
+			slog.Warn("outbox reconciler: orphaned session re-enqueued", "session_id", id)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,12 +22,23 @@ import (
 // pick between the canceled status (user intent) and a restart requeue.
 var errCanceledByUser = errors.New("canceled by user")
 
+// errLeaseLost marks a session context canceled because this node no longer
+// holds the session's lease: its registration expired and another node
+// requeued the session. The run stops without writing anything, the session
+// belongs to whoever runs it next.
+var errLeaseLost = errors.New("lease lost")
+
 // Pool is a worker pool that consumes sessions from a Redis queue.
 //
-// Reliability: sessions are moved atomically from the queue into a processing
-// list (BLMOVE) while being worked on and removed only after the executor
-// returns. Entries left behind by a crash or shutdown are recovered on the
-// next Start — non-terminal sessions are requeued, terminal ones dropped.
+// Reliability: sessions are moved atomically from the queue into this node's
+// processing list (BLMOVE) while being worked on and removed only after the
+// executor returns. Entries left behind by a crash or shutdown are recovered
+// on the next Start — non-terminal sessions are requeued, terminal ones
+// dropped. Several nodes (the server and runner agents) can share a queue:
+// each registers in Redis with a heartbeat, and the leases of a node whose
+// registration expired are recovered by the surviving nodes. A node that
+// cannot register, or finds a lease gone from its list, stops those runs
+// (errLeaseLost) before they write, so no session is finished twice.
 type Pool struct {
 	redis          *redisclient.Client
 	executor       *Executor
@@ -36,8 +48,45 @@ type Pool struct {
 	wg             sync.WaitGroup
 	cancel         context.CancelFunc
 	activeCount    atomic.Int32
+	paused         atomic.Bool
+	degraded       atomic.Bool  // Redis unreachable: workers wait, like paused
+	resumedAt      atomic.Int64 // unix nanos of the last Redis recovery
+	registeredAt   atomic.Int64 // unix nanos of the last successful registration
 	cancels        map[string]context.CancelCauseFunc
 	cancelsMu      sync.RWMutex
+	slots          []WorkerState // indexed by worker ID
+	slotsMu        sync.Mutex
+	nodeID         string
+	role           string
+	version        string
+	startedAt      time.Time
+}
+
+// WorkerState is what one worker goroutine is doing right now.
+type WorkerState struct {
+	ID        int        `json:"id"`
+	State     string     `json:"state"` // idle or busy
+	SessionID string     `json:"session_id,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// QueueStats are the Redis queue lengths shared by all nodes.
+type QueueStats struct {
+	Pending    int64 `json:"pending"`
+	Processing int64 `json:"processing"` // dequeued but not yet acknowledged, all nodes
+}
+
+// PoolSnapshot is a point-in-time view of the pool internals for operators.
+type PoolSnapshot struct {
+	Node           string        `json:"node"`
+	Paused         bool          `json:"paused"`
+	Degraded       bool          `json:"degraded"` // paused by the Redis circuit breaker
+	Concurrency    int           `json:"concurrency"`
+	ActiveSessions int           `json:"active_sessions"`
+	Workers        []WorkerState `json:"workers"`
+	Cancellable    []string      `json:"cancellable"` // sessions in the cancel map
+	Queue          *QueueStats   `json:"queue,omitempty"`
+	QueueError     string        `json:"queue_error,omitempty"`
 }
 
 // NewPool creates a new worker pool.
@@ -48,21 +97,57 @@ func NewPool(
 	queueName string,
 	concurrency int,
 ) *Pool {
-	return &Pool{
+	p := &Pool{
 		redis:          redis,
 		executor:       executor,
 		sessionService: sessionService,
 		queueName:      queueName,
 		concurrency:    concurrency,
 		cancels:        make(map[string]context.CancelCauseFunc),
+		slots:          newSlots(concurrency),
+		nodeID:         DefaultNodeID(),
+		role:           RoleServer,
+	}
+	if executor != nil {
+		executor.leaseHeld = p.holdsLease
+	}
+	return p
+}
+
+func newSlots(n int) []WorkerState {
+	slots := make([]WorkerState, n)
+	for i := range slots {
+		slots[i] = WorkerState{ID: i, State: "idle"}
 	}
+	return slots
+}
+
+// queueKey is the queue of sessions with priority pr.
+func (p *Pool) queueKey(pr session.Priority) string {
+	return p.redis.Key(session.QueueName(p.queueName, pr))
 }
 
-func (p *Pool) queueKey() string {
-	return p.redis.Key(p.queueName)
+// queueKeys are the priority queues in the order workers drain them.
+func (p *Pool) queueKeys() []string {
+	names := session.QueueNames(p.queueName)
+	for i, name := range names {
+		names[i] = p.redis.Key(name)
+	}
+	return names
 }
 
+// processingKey is this node's lease list.
 func (p *Pool) processingKey() string {
+	return p.processingKeyFor(p.nodeID)
+}
+
+func (p *Pool) processingKeyFor(nodeID string) string {
+	return p.redis.Key(p.queueName + ":processing:" + nodeID)
+}
+
+// legacyProcessingKey is the single processing list shared by all workers
+// before nodes had their own; still drained on Start after an upgrade.
+func (p *Pool) legacyProcessingKey() string {
 	return p.redis.Key(p.queueName + ":processing")
 }
 
@@ -70,12 +155,22 @@ func (p *Pool) processingKey() string {
 func (p *Pool) Start(ctx context.Context) {
 	ctx, p.cancel = context.WithCancel(ctx)
 
-	slog.Info("starting worker pool", "concurrency", p.concurrency, "queue", p.queueName)
+	slog.Info("starting worker pool", "concurrency", p.concurrency, "queue", p.queueName, "node", p.nodeID, "role", p.role)
 
-	p.recoverProcessing(ctx)
+	p.startedAt = time.Now().UTC()
+	if err := p.register(ctx); err != nil {
+		slog.Error("node registration failed", "node", p.nodeID, "error", err)
+	}
+	p.recoverProcessing(ctx, p.processingKey())
+	p.recoverProcessing(ctx, p.legacyProcessingKey())
+	p.reapDeadNodes(ctx)
 
 	metrics.WorkersTotal.Set(float64(p.concurrency))
 
+	p.wg.Add(2)
+	go p.heartbeat(ctx)
+	go p.listenCancels(ctx)
+
 	for i := 0; i < p.concurrency; i++ {
 		p.wg.Add(1)
 		go p.worker(ctx, i)
@@ -85,8 +180,8 @@ func (p *Pool) Start(ctx context.Context) {
 // recoverProcessing requeues sessions that were mid-flight when the previous
 // process died (crash or shutdown). Interrupted running/cloning sessions are
 // reset to pending; terminal or unknown entries are dropped from the list.
-func (p *Pool) recoverProcessing(ctx context.Context) {
-	ids, err := p.redis.Unwrap().LRange(ctx, p.processingKey(), 0, -1).Result()
+func (p *Pool) recoverProcessing(ctx context.Context, listKey string) {
+	ids, err := p.redis.Unwrap().LRange(ctx, listKey, 0, -1).Result()
 	if err != nil {
 		slog.Error("queue recovery: reading processing list failed", "error", err)
 		return
@@ -98,14 +193,14 @@ func (p *Pool) recoverProcessing(ctx context.Context) {
 	slog.Info("queue recovery: found in-flight sessions from previous run", "count", len(ids))
 
 	for _, id := range ids {
-		p.recoverOne(ctx, id)
+		p.recoverOne(ctx, listKey, id)
 	}
 }
 
-func (p *Pool) recoverOne(ctx context.Context, sessionID string) {
+func (p *Pool) recoverOne(ctx context.Context, listKey, sessionID string) {
 	log := slog.With("session_id", sessionID)
 	dropEntry := func() {
-		if err := p.redis.Unwrap().LRem(ctx, p.processingKey(), 1, sessionID).Err(); err != nil {
+		if err := p.redis.Unwrap().LRem(ctx, listKey, 1, sessionID).Err(); err != nil {
 			log.Warn("queue recovery: dropping processing entry failed", "error", err)
 		}
 	}
@@ -125,6 +220,11 @@ func (p *Pool) recoverOne(ctx context.Context, sessionID string) {
 			dropEntry()
 			return
 		}
+		// A session that takes its worker down with it every time must not
+		// be retried forever — the executor's crash-loop guard counts these.
+		if _, err := p.sessionService.RecordFailure(ctx, sessionID, crashFailureClass); err != nil {
+			log.Warn("queue recovery: recording crash failed", "error", err)
+		}
 	case session.StatusPending, session.StatusAwaitingInstruction, session.StatusReviewing:
 		// Dequeued but not started (or an interrupted review) — requeue as is.
 	default:
@@ -134,9 +234,10 @@ func (p *Pool) recoverOne(ctx context.Context, sessionID string) {
 	}
 
 	// Move back to the FRONT of the queue so interrupted work resumes first.
-	pipe := p.redis.Unwrap().Pipeline()
-	pipe.LRem(ctx, p.processingKey(), 1, sessionID)
-	pipe.LPush(ctx, p.queueKey(), sessionID)
+	// MULTI/EXEC so the outbox reconciler never sees it in neither list.
+	pipe := p.redis.Unwrap().TxPipeline()
+	pipe.LRem(ctx, listKey, 1, sessionID)
+	pipe.LPush(ctx, p.queueKey(t.Priority), sessionID)
 	if _, err := pipe.Exec(ctx); err != nil {
 		log.Error("queue recovery: requeue failed", "error", err)
 		return
@@ -153,19 +254,166 @@ func (p *Pool) Stop() {
 		p.cancel()
 	}
 	p.wg.Wait()
+	p.deregister()
 	slog.Info("worker pool stopped")
 }
 
-// Cancel cancels a running session by its ID (user-initiated).
+// Cancel cancels a running session by its ID (user-initiated). A session
+// leased by another node is canceled there via Redis pub/sub.
 func (p *Pool) Cancel(sessionID string) error {
+	if p.cancelLocal(sessionID) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	owner := p.leaseOwner(ctx, sessionID)
+	if owner == "" {
+		return fmt.Errorf("session %s is not currently running", sessionID)
+	}
+	if err := p.redis.Unwrap().Publish(ctx, p.cancelChannel(), sessionID).Err(); err != nil {
+		return fmt.Errorf("forwarding cancel to node %s: %w", owner, err)
+	}
+	return nil
+}
+
+// cancelLocal cancels sessionID if it runs on this node.
+func (p *Pool) cancelLocal(sessionID string) bool {
 	p.cancelsMu.RLock()
 	cancelFn, ok := p.cancels[sessionID]
 	p.cancelsMu.RUnlock()
-	if !ok {
-		return fmt.Errorf("session %s is not currently running", sessionID)
+	if ok {
+		cancelFn(errCanceledByUser)
 	}
-	cancelFn(errCanceledByUser)
-	return nil
+	return ok
+}
+
+// cancelAll cancels every session running on this node with cause and
+// returns how many there were.
+func (p *Pool) cancelAll(cause error) int {
+	p.cancelsMu.RLock()
+	defer p.cancelsMu.RUnlock()
+	for _, cancelFn := range p.cancels {
+		cancelFn(cause)
+	}
+	return len(p.cancels)
+}
+
+// Pause stops workers from dequeuing new sessions. In-flight sessions keep
+// running to completion and queued sessions stay in Redis untouched.
+func (p *Pool) Pause() {
+	if !p.paused.Swap(true) {
+		slog.Info("worker pool paused", "active", p.activeCount.Load())
+	}
+}
+
+// Resume lets workers dequeue again after Pause.
+func (p *Pool) Resume() {
+	if p.paused.Swap(false) {
+		slog.Info("worker pool resumed")
+	}
+}
+
+// Paused reports whether the pool is in maintenance mode.
+func (p *Pool) Paused() bool {
+	return p.paused.Load()
+}
+
+// SetRedisAvailable is the Redis circuit breaker callback: while Redis is
+// down workers stop dequeuing (in-flight sessions keep running), and they
+// resume when it is back. Independent of the operator's Pause.
+func (p *Pool) SetRedisAvailable(up bool) {
+	if !up {
+		if !p.degraded.Swap(true) {
+			slog.Warn("worker pool degraded: redis unavailable, dequeuing suspended", "active", p.activeCount.Load())
+		}
+		return
+	}
+	if p.degraded.Swap(false) {
+		p.resumedAt.Store(time.Now().UnixNano())
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		p.fenceLostLeases(ctx)
+		if err := p.register(ctx); err != nil {
+			slog.Warn("node re-registration failed", "node", p.nodeID, "error", err)
+		}
+		slog.Info("worker pool resumed after redis recovery")
+	}
+}
+
+// ActiveCount returns the number of sessions currently being executed.
+func (p *Pool) ActiveCount() int {
+	return int(p.activeCount.Load())
+}
+
+// Snapshot reports the state of every worker, the cancel map and the queue
+// lengths. A Redis failure only drops the queue stats.
+func (p *Pool) Snapshot(ctx context.Context) PoolSnapshot {
+	snap := PoolSnapshot{
+		Node:           p.nodeID,
+		Paused:         p.Paused(),
+		Degraded:       p.degraded.Load(),
+		Concurrency:    p.concurrency,
+		ActiveSessions: p.ActiveCount(),
+		Workers:        p.workerStates(),
+		Cancellable:    []string{},
+	}
+
+	p.cancelsMu.RLock()
+	for id := range p.cancels {
+		snap.Cancellable = append(snap.Cancellable, id)
+	}
+	p.cancelsMu.RUnlock()
+	sort.Strings(snap.Cancellable)
+
+	nodes, err := p.redis.Unwrap().SMembers(ctx, p.nodesKey()).Result()
+	if err != nil {
+		snap.QueueError = err.Error()
+		return snap
+	}
+	pipe := p.redis.Unwrap().Pipeline()
+	var pending []*redis.IntCmd
+	for _, key := range p.queueKeys() {
+		pending = append(pending, pipe.LLen(ctx, key))
+	}
+	leases := []*redis.IntCmd{pipe.LLen(ctx, p.legacyProcessingKey())}
+	for _, id := range nodes {
+		leases = append(leases, pipe.LLen(ctx, p.processingKeyFor(id)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		snap.QueueError = err.Error()
+		return snap
+	}
+	snap.Queue = &QueueStats{}
+	for _, n := range pending {
+		snap.Queue.Pending += n.Val()
+	}
+	for _, n := range leases {
+		snap.Queue.Processing += n.Val()
+	}
+	return snap
+}
+
+func (p *Pool) workerStates() []WorkerState {
+	p.slotsMu.Lock()
+	defer p.slotsMu.Unlock()
+	states := make([]WorkerState, len(p.slots))
+	copy(states, p.slots)
+	return states
+}
+
+// setSlot records what a worker is doing; an empty sessionID marks it idle.
+func (p *Pool) setSlot(id int, sessionID string) {
+	p.slotsMu.Lock()
+	defer p.slotsMu.Unlock()
+	if id >= len(p.slots) {
+		return
+	}
+	if sessionID == "" {
+		p.slots[id] = WorkerState{ID: id, State: "idle"}
+		return
+	}
+	now := time.Now().UTC()
+	p.slots[id] = WorkerState{ID: id, State: "busy", SessionID: sessionID, StartedAt: &now}
 }
 
 // shouldProcess returns true if a session status is actionable by the worker pool.
@@ -183,13 +431,23 @@ func (p *Pool) worker(ctx context.Context, id int) {
 	log := slog.With("worker", id)
 	log.Info("worker started")
 
-	queueKey := p.queueKey()
 	processingKey := p.processingKey()
 
 	for {
+		// Maintenance mode or Redis outage: leave the queue alone until resumed.
+		if p.paused.Load() || p.degraded.Load() {
+			select {
+			case <-ctx.Done():
+				log.Info("worker shutting down")
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
 		// Atomically move the next session into the processing list so it
 		// survives a crash between dequeue and completion.
-		sessionID, err := p.redis.Unwrap().BLMove(ctx, queueKey, processingKey, "LEFT", "RIGHT", 5*time.Second).Result()
+		sessionID, err := p.dequeue(ctx, processingKey)
 		if err != nil {
 			if errors.Is(err, redis.Nil) {
 				continue // timeout, try again
@@ -204,24 +462,55 @@ func (p *Pool) worker(ctx context.Context, id int) {
 		}
 
 		log.Info("picked up session", "session_id", sessionID)
+		p.sessionService.AckOutbox(ctx, sessionID)
 		p.activeCount.Add(1)
 		metrics.WorkersActive.Set(float64(p.activeCount.Load()))
 
 		// Update queue depth (approximate)
-		if qLen, err := p.redis.Unwrap().LLen(ctx, queueKey).Result(); err == nil {
+		if qLen, err := p.sessionService.QueueDepth(ctx); err == nil {
 			metrics.QueueDepth.Set(float64(qLen))
 		}
 
+		p.setSlot(id, sessionID)
 		p.processOne(ctx, sessionID, log)
+		p.setSlot(id, "")
 
 		p.activeCount.Add(-1)
 		metrics.WorkersActive.Set(float64(p.activeCount.Load()))
 	}
 }
 
+// dequeueFirst moves the head of the first non-empty queue in KEYS[1..n-1]
+// (drain order) to the tail of the lease list KEYS[n] and returns it.
+var dequeueFirst = redis.NewScript(`
+local lease = KEYS[#KEYS]
+for i = 1, #KEYS - 1 do
+	local id = redis.call("LMOVE", KEYS[i], lease, "LEFT", "RIGHT")
+	if id then
+		return id
+	end
+end
+return false`)
+
+// dequeueWait is how long an idle worker blocks on the normal queue before
+// it looks at all priorities again, the most a high or low priority session
+// waits with workers free.
+const dequeueWait = time.Second
+
+// dequeue moves the next session into processingKey, high priority first,
+// then normal, then low. With all queues empty it blocks on the normal queue
+// for up to dequeueWait and returns redis.Nil if nothing arrived.
+func (p *Pool) dequeue(ctx context.Context, processingKey string) (string, error) {
+	sessionID, err := dequeueFirst.Run(ctx, p.redis.Unwrap(), append(p.queueKeys(), processingKey)).Text()
+	if !errors.Is(err, redis.Nil) {
+		return sessionID, err
+	}
+	return p.redis.Unwrap().BLMove(ctx, p.queueKey(session.PriorityNormal), processingKey, "LEFT", "RIGHT", dequeueWait).Result()
+}
+
 func (p *Pool) processOne(ctx context.Context, sessionID string, log *slog.Logger) {
-	// Load session from Redis
-	t, err := p.sessionService.Get(ctx, sessionID)
+	// Load session from Redis, with credentials for the executor
+	t, err := p.sessionService.Get(ctx, sessionID, session.WithSecrets())
 	if err != nil {
 		log.Warn("failed to load session, skipping", "session_id", sessionID, "error", err)
 		p.finishProcessing(sessionID, log)
@@ -248,7 +537,15 @@ func (p *Pool) processOne(ctx context.Context, sessionID string, log *slog.Logge
 	p.cancelsMu.Lock()
 	delete(p.cancels, sessionID)
 	p.cancelsMu.Unlock()
+	leaseLost := errors.Is(context.Cause(sessionCtx), errLeaseLost)
 	sessionCancel(nil) // clean up context resources
+	if leaseLost {
+		// Another node owns the session now, with its lease and instruct lock.
+		p.executor.verifyFixes.Delete(sessionID)
+		log.Warn("session lease lost, run abandoned", "session_id", sessionID)
+		return
+	}
+	p.executor.queueVerifyFix(sessionID, log)
 
 	if ctx.Err() != nil {
 		// Shutdown interrupted this session: keep the processing-list entry so
@@ -257,14 +554,26 @@ func (p *Pool) processOne(ctx context.Context, sessionID string, log *slog.Logge
 		return
 	}
 	p.finishProcessing(sessionID, log)
+	p.finishIteration(sessionID, t.Iteration)
+}
+
+// finishIteration hands the session's instruct lock on and starts the next
+// queued follow-up. Detached context, like finishProcessing.
+func (p *Pool) finishIteration(sessionID string, iteration int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	p.sessionService.FinishIteration(ctx, sessionID, iteration)
 }
 
 // finishProcessing acknowledges a dequeued session by removing it from the
 // processing list. Uses a detached context — this must succeed even mid-shutdown.
 func (p *Pool) finishProcessing(sessionID string, log *slog.Logger) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
-	if err := p.redis.Unwrap().LRem(ctx, p.processingKey(), 1, sessionID).Err(); err != nil {
+	err := redisclient.Retry(ctx, func() error {
+		return p.redis.Unwrap().LRem(ctx, p.processingKey(), 1, sessionID).Err()
+	})
+	if err != nil {
 		log.Warn("failed to ack processing entry", "session_id", sessionID, "error", err)
 	}
 }
@@ -1,7 +1,10 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/freema/codeforge/internal/session"
 )
@@ -31,3 +34,46 @@ func TestShouldProcess(t *testing.T) {
 		})
 	}
 }
+
+func TestPool_WorkerSlots(t *testing.T) {
+	p := &Pool{slots: newSlots(2)}
+
+	p.setSlot(1, "sess-1")
+	p.setSlot(5, "out-of-range") // ignored
+
+	states := p.workerStates()
+	if len(states) != 2 {
+		t.Fatalf("len = %d, want 2", len(states))
+	}
+	if states[0].State != "idle" || states[0].SessionID != "" || states[0].StartedAt != nil {
+		t.Errorf("worker 0 = %+v, want idle", states[0])
+	}
+	if states[1].State != "busy" || states[1].SessionID != "sess-1" || states[1].StartedAt == nil {
+		t.Errorf("worker 1 = %+v, want busy on sess-1", states[1])
+	}
+
+	p.setSlot(1, "")
+	if st := p.workerStates()[1]; st.State != "idle" || st.SessionID != "" {
+		t.Errorf("worker 1 after finish = %+v, want idle", st)
+	}
+}
+
+func TestPool_FenceStale(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	p := &Pool{cancels: map[string]context.CancelCauseFunc{"sess-1": cancel}}
+	p.startedAt = time.Now().Add(-nodeTTL)
+
+	p.registeredAt.Store(time.Now().UnixNano())
+	if p.fenceStale() || ctx.Err() != nil {
+		t.Fatal("fenced a node that registered just now")
+	}
+
+	p.registeredAt.Store(time.Now().Add(-nodeFence).UnixNano())
+	if !p.fenceStale() {
+		t.Fatal("did not fence a node whose registration is about to expire")
+	}
+	if !errors.Is(context.Cause(ctx), errLeaseLost) {
+		t.Errorf("cause = %v, want errLeaseLost", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/tool/runner"
+)
+
+// progressEvent is the payload of a cli_progress system event.
+type progressEvent struct {
+	runner.Progress
+	ElapsedSeconds int `json:"elapsed_seconds"`
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// heartbeatEvent is the payload of a cli_heartbeat system event.
+type heartbeatEvent struct {
+	ElapsedSeconds   int `json:"elapsed_seconds"`
+	LastEventSeconds int `json:"last_event_seconds"`
+}
+
+// startProgress emits a cli_progress event every ProgressInterval while a CLI
+// run is in flight, with the tally tracker has taken from its stream events
+// and the time used against the session timeout. While the run stays silent
+// for HeartbeatInterval it also emits cli_heartbeat events, so clients can
+// tell a long quiet tool call from a dead session. The returned func stops
+// the reporter; zero intervals report nothing.
+func (e *Executor) startProgress(ctx context.Context, t *session.Session, tracker *runner.ProgressTracker, timeout int, log *slog.Logger) func() {
+	progressEvery, heartbeatEvery := e.cfg.ProgressInterval, e.cfg.HeartbeatInterval
+	if progressEvery <= 0 && heartbeatEvery <= 0 {
+		return func() {}
+	}
+	// ctx carries the session timeout: count elapsed time from its start, so
+	// elapsed and timeout compare even when the clone took a while.
+	started := time.Now()
+	if deadline, ok := ctx.Deadline(); ok {
+		started = deadline.Add(-time.Duration(timeout) * time.Second)
+	}
+	done := make(chan struct{})
+	go func() {
+		// A nil channel never fires: a disabled event simply has no ticker.
+		var progressC, heartbeatC <-chan time.Time
+		if progressEvery > 0 {
+			ticker := time.NewTicker(progressEvery)
+			defer ticker.Stop()
+			progressC = ticker.C
+		}
+		if heartbeatEvery > 0 {
+			ticker := time.NewTicker(heartbeatEvery)
+			defer ticker.Stop()
+			heartbeatC = ticker.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-progressC:
+				e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "cli_progress", progressEvent{
+					Progress:       tracker.Snapshot(),
+					ElapsedSeconds: int(time.Since(started).Seconds()),
+					TimeoutSeconds: timeout,
+				}), log, "cli_progress", t.ID)
+			case <-heartbeatC:
+				quiet := time.Since(tracker.LastEvent())
+				if quiet < heartbeatEvery {
+					continue
+				}
+				e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "cli_heartbeat", heartbeatEvent{
+					ElapsedSeconds:   int(time.Since(started).Seconds()),
+					LastEventSeconds: int(quiet.Seconds()),
+				}), log, "cli_heartbeat", t.ID)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
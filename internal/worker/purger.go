@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/workspace"
+)
+
+// DeletedPurger permanently removes soft-deleted sessions once their grace
+// period is over, together with their workspaces.
+type DeletedPurger struct {
+	sessionService *session.Service
+	workspaceMgr   *workspace.Manager
+	interval       time.Duration
+}
+
+// NewDeletedPurger creates a purger. workspaceMgr may be nil.
+func NewDeletedPurger(sessionService *session.Service, workspaceMgr *workspace.Manager, interval time.Duration) *DeletedPurger {
+	return &DeletedPurger{
+		sessionService: sessionService,
+		workspaceMgr:   workspaceMgr,
+		interval:       interval,
+	}
+}
+
+// Start runs the purge loop until ctx is canceled. Call in a goroutine.
+func (p *DeletedPurger) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge(ctx)
+		}
+	}
+}
+
+func (p *DeletedPurger) purge(ctx context.Context) {
+	ids, err := p.sessionService.PurgeDeleted(ctx, time.Now())
+	if err != nil {
+		slog.Warn("deleted purger: purge failed", "error", err)
+		return
+	}
+	for _, id := range ids {
+		if p.workspaceMgr == nil {
+			continue
+		}
+		if err := p.workspaceMgr.Delete(ctx, id); err != nil {
+			slog.Warn("deleted purger: workspace removal failed", "session_id", id, "error", err)
+		}
+	}
+	if len(ids) > 0 {
+		slog.Info("deleted purger: sessions purged", "count", len(ids))
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// scheduledBatch caps the scheduled sessions promoted per tick.
+const scheduledBatch = 100
+
+// ScheduledPromoter moves sessions created with schedule_at or delay_seconds
+// into the work queue once they are due.
+type ScheduledPromoter struct {
+	sessionService *session.Service
+	interval       time.Duration
+}
+
+// NewScheduledPromoter creates a promoter checking for due sessions every
+// interval — the most a scheduled session starts late.
+func NewScheduledPromoter(sessionService *session.Service, interval time.Duration) *ScheduledPromoter {
+	return &ScheduledPromoter{
+		sessionService: sessionService,
+		interval:       interval,
+	}
+}
+
+// Start runs the promote loop until ctx is canceled. Call in a goroutine.
+func (p *ScheduledPromoter) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.promote(ctx)
+		}
+	}
+}
+
+func (p *ScheduledPromoter) promote(ctx context.Context) {
+	ids, err := p.sessionService.ScheduledDue(ctx, time.Now(), scheduledBatch)
+	if err != nil {
+		slog.Warn("scheduled promoter: listing failed", "error", err)
+		return
+	}
+	for _, id := range ids {
+		if _, err := p.sessionService.PromoteScheduled(ctx, id); err != nil {
+			slog.Warn("scheduled promoter: session skipped", "session_id", id, "error", err)
+		}
+	}
+}
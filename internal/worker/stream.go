@@ -3,8 +3,11 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/freema/codeforge/internal/redact"
 	"github.com/freema/codeforge/internal/redisclient"
 	"github.com/freema/codeforge/internal/session"
 	gitpkg "github.com/freema/codeforge/internal/tool/git"
@@ -23,6 +26,15 @@ type StreamEvent struct {
 type Streamer struct {
 	redis      *redisclient.Client
 	historyTTL time.Duration
+
+	redactor *redact.Redactor // optional, nil = events are published verbatim
+	secrets  sync.Map         // sessionID → []string of per-session secret values
+	archiver HistoryArchiver  // optional, nil = history stays in Redis
+}
+
+// HistoryArchiver moves a finished run's event history out of Redis.
+type HistoryArchiver interface {
+	ArchiveHistory(ctx context.Context, sessionID string) error
 }
 
 // NewStreamer creates a new event streamer.
@@ -33,8 +45,62 @@ func NewStreamer(redis *redisclient.Client, historyTTL time.Duration) *Streamer
 	}
 }
 
+// SetRedactor enables secret redaction of every emitted event.
+func (s *Streamer) SetRedactor(r *redact.Redactor) {
+	s.redactor = r
+}
+
+// SetHistoryArchiver offloads each run's event history when it is done.
+func (s *Streamer) SetHistoryArchiver(a HistoryArchiver) {
+	s.archiver = a
+}
+
+// RegisterSecrets adds values (access token, AI key) that must never appear
+// in the session's events or stored results. A value registered again (every
+// follow-up registers the session's token) is kept once.
+func (s *Streamer) RegisterSecrets(sessionID string, values ...string) {
+	var secrets []string
+	seen := map[string]bool{}
+	if prev, ok := s.secrets.Load(sessionID); ok {
+		for _, v := range prev.([]string) {
+			seen[v] = true
+			secrets = append(secrets, v)
+		}
+	}
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			secrets = append(secrets, v)
+		}
+	}
+	s.secrets.Store(sessionID, secrets)
+}
+
+// ForgetSecrets drops the session's registered secrets once its run ends.
+func (s *Streamer) ForgetSecrets(sessionID string) {
+	s.secrets.Delete(sessionID)
+}
+
+// Redact masks secrets in text belonging to a session (e.g. the CLI result).
+func (s *Streamer) Redact(sessionID, text string) string {
+	return s.redactor.String(text, s.sessionSecrets(sessionID)...)
+}
+
+// RedactJSON masks secrets in a JSON payload belonging to a session.
+func (s *Streamer) RedactJSON(sessionID string, data json.RawMessage) json.RawMessage {
+	return s.redactor.JSON(data, s.sessionSecrets(sessionID)...)
+}
+
+func (s *Streamer) sessionSecrets(sessionID string) []string {
+	if v, ok := s.secrets.Load(sessionID); ok {
+		return v.([]string)
+	}
+	return nil
+}
+
 // Emit publishes an event to the session's stream channel and persists to history.
 func (s *Streamer) Emit(ctx context.Context, sessionID string, evt StreamEvent) error {
+	evt.Data = s.RedactJSON(sessionID, evt.Data)
 	evt.TS = time.Now().UTC().Format(time.RFC3339Nano)
 	data, err := json.Marshal(evt)
 	if err != nil {
@@ -86,7 +152,8 @@ func (s *Streamer) EmitResult(ctx context.Context, sessionID, event string, data
 	return s.emitTyped(ctx, sessionID, "result", event, data)
 }
 
-// EmitDone publishes completion signal on the done channel and sets history TTL.
+// EmitDone publishes completion signal on the done channel, archives the
+// history if an archiver is set and sets the history TTL.
 func (s *Streamer) EmitDone(ctx context.Context, sessionID string, status session.Status, summary *gitpkg.ChangesSummary) error {
 	data, _ := json.Marshal(map[string]interface{}{
 		"session_id":      sessionID,
@@ -97,6 +164,12 @@ func (s *Streamer) EmitDone(ctx context.Context, sessionID string, status sessio
 	doneKey := s.redis.Key("session", sessionID, "done")
 	historyKey := s.redis.Key("session", sessionID, "history")
 
+	if s.archiver != nil {
+		if err := s.archiver.ArchiveHistory(ctx, sessionID); err != nil {
+			slog.Warn("archiving event history failed", "session_id", sessionID, "error", err)
+		}
+	}
+
 	pipe := s.redis.Unwrap().Pipeline()
 	pipe.Publish(ctx, doneKey, string(data))
 	pipe.Expire(ctx, historyKey, s.historyTTL)
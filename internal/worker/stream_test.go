@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/freema/codeforge/internal/redact"
+)
+
+func TestStreamer_RedactsSessionSecrets(t *testing.T) {
+	r, err := redact.New(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStreamer(nil, 0)
+	s.SetRedactor(r)
+	s.RegisterSecrets("s1", "tok-1234567890", "")
+	s.RegisterSecrets("s1", "key-abcdefghij", "tok-1234567890")
+	if got := s.sessionSecrets("s1"); len(got) != 2 {
+		t.Errorf("secrets = %q, want each registered once", got)
+	}
+
+	got := s.Redact("s1", "cloned with tok-1234567890 using key-abcdefghij")
+	if strings.Contains(got, "tok-") || strings.Contains(got, "key-") {
+		t.Errorf("secrets not redacted: %q", got)
+	}
+
+	// Other sessions' secrets are not applied.
+	if got := s.Redact("s2", "tok-1234567890"); got != "tok-1234567890" {
+		t.Errorf("unexpected redaction for other session: %q", got)
+	}
+
+	data := s.RedactJSON("s1", json.RawMessage(`{"text":"tok-1234567890"}`))
+	if string(data) != `{"text":"[REDACTED]"}` {
+		t.Errorf("got %s", data)
+	}
+
+	s.ForgetSecrets("s1")
+	if got := s.Redact("s1", "tok-1234567890"); got != "tok-1234567890" {
+		t.Errorf("secrets kept after ForgetSecrets: %q", got)
+	}
+}
+
+func TestStreamer_NoRedactor(t *testing.T) {
+	s := NewStreamer(nil, 0)
+	s.RegisterSecrets("s1", "tok-1234567890")
+	if got := s.Redact("s1", "tok-1234567890"); got != "tok-1234567890" {
+		t.Errorf("redaction applied without redactor: %q", got)
+	}
+}
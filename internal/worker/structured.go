@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/freema/codeforge/internal/prompt"
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/structured"
+)
+
+// wantsStructured reports whether the session asked for a structured result.
+// Review sessions answer in their own JSON format and are left alone.
+func wantsStructured(t *session.Session) bool {
+	if t.Config == nil || len(t.Config.ResultSchema) == 0 {
+		return false
+	}
+	return t.SessionType != "review" && t.SessionType != "pr_review"
+}
+
+// withResultSchema appends the config.result_schema instructions to prompt.
+func (e *Executor) withResultSchema(t *session.Session, instruction string, log *slog.Logger) string {
+	if !wantsStructured(t) {
+		return instruction
+	}
+	var schema bytes.Buffer
+	if err := json.Indent(&schema, t.Config.ResultSchema, "", "  "); err != nil {
+		schema.Reset()
+		schema.Write(t.Config.ResultSchema)
+	}
+	rendered, err := prompt.RenderResultSchemaPrompt(prompt.ResultSchemaData{Schema: schema.String(), UserPrompt: instruction})
+	if err != nil {
+		log.Warn("failed to render result schema prompt", "error", err)
+		return instruction
+	}
+	return rendered
+}
+
+// extractStructured parses the final JSON block of output, validates it
+// against config.result_schema and stores it on the session. A missing or
+// invalid block is recorded as result_structured_error; the iteration
+// itself still completes.
+func (e *Executor) extractStructured(ctx context.Context, t *session.Session, output string, log *slog.Logger) {
+	if !wantsStructured(t) {
+		return
+	}
+	data, err := structured.Extract(output)
+	if err == nil {
+		err = structured.Validate(t.Config.ResultSchema, data)
+	}
+	t.ResultStructured, t.ResultStructuredError = data, ""
+	if err != nil {
+		log.Info("structured result rejected", "error", err)
+		t.ResultStructured, t.ResultStructuredError = nil, err.Error()
+	}
+	if err := e.sessionService.SetStructuredResult(ctx, t.ID, t.ResultStructured, t.ResultStructuredError); err != nil {
+		log.Warn("failed to store structured result", "error", err)
+	}
+}
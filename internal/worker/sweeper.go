@@ -57,13 +57,10 @@ func (s *StuckSweeper) sweep(ctx context.Context) {
 	for _, id := range ids {
 		// UpdateStatus validates the transition against the live Redis state,
 		// so a session that moved on since the SQLite query is left alone.
-		if err := s.sessionService.UpdateStatus(ctx, id, session.StatusFailed); err != nil {
+		if err := s.sessionService.FailStuck(ctx, id, "session stuck without a worker — marked failed by the stuck sweeper"); err != nil {
 			slog.Info("stuck sweeper: session skipped", "session_id", id, "error", err)
 			continue
 		}
-		if err := s.sessionService.SetError(ctx, id, "session stuck without a worker — marked failed by the stuck sweeper"); err != nil {
-			slog.Warn("stuck sweeper: storing error failed", "session_id", id, "error", err)
-		}
 		slog.Warn("stuck sweeper: session marked failed", "session_id", id, "older_than", s.maxAge)
 	}
 }
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/freema/codeforge/internal/session"
+	gitpkg "github.com/freema/codeforge/internal/tool/git"
+	"github.com/freema/codeforge/internal/workspace"
+)
+
+// applyWorkspaceTemplate copies the session's workspace template (or the
+// default one) into a fresh clone and keeps it out of commits. The caches only
+// save downloads, so a failure is logged and the session runs without them.
+func (e *Executor) applyWorkspaceTemplate(ctx context.Context, t *session.Session, workDir string, log *slog.Logger) {
+	name := ""
+	if t.Config != nil {
+		name = t.Config.WorkspaceTemplate
+	}
+	applied, err := e.cfg.WorkspaceTemplates.Apply(ctx, name, workDir)
+	if err != nil {
+		log.Warn("workspace template not applied", "template", name, "error", err)
+		return
+	}
+	if !applied {
+		return
+	}
+	if err := gitpkg.ExcludeLocally(ctx, workDir, "/"+workspace.CacheDir+"/"); err != nil {
+		// Better no caches than caches in the session's commits.
+		log.Warn("failed to exclude workspace caches from commits, removing them", "error", err)
+		_ = os.RemoveAll(filepath.Join(workDir, workspace.CacheDir))
+		return
+	}
+	if name == "" {
+		name = workspace.DefaultTemplate
+	}
+	log.Info("workspace template applied", "template", name)
+}
+
+// cacheEnv points the CLI and verify commands at the shared dependency cache
+// of the session's tenant and CLI user, and at the workspace's own template
+// copy, which wins where both exist. Without a usable shared cache the run
+// goes on with the template copy alone.
+func (e *Executor) cacheEnv(t *session.Session, workDir string, log *slog.Logger) []string {
+	var shared []string
+	if id, err := e.cfg.RunAs.Identity(t.ID); err != nil {
+		log.Warn("resolving CLI user for the shared cache failed", "error", err)
+	} else if shared, err = e.workspaceMgr.SharedCacheEnv(t.TenantID, id); err != nil {
+		log.Warn("shared dependency cache unavailable", "error", err)
+	}
+	return append(shared, workspace.CacheEnv(workDir)...)
+}
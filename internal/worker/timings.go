@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// startTimings begins the phase breakdown of a run picked up at startTime,
+// with the time it waited in the queue since it was last enqueued.
+func startTimings(t *session.Session, startTime time.Time) {
+	enqueued := t.CreatedAt
+	if t.EnqueuedAt != nil {
+		enqueued = *t.EnqueuedAt
+	}
+	t.RunTimings = &session.Timings{}
+	if wait := startTime.Sub(enqueued); wait > 0 && !enqueued.IsZero() {
+		t.RunTimings.Add(session.PhaseQueueWait, wait)
+	}
+}
+
+// timePhase starts timing phase of the run in flight; call the returned
+// func when the phase ends. A no-op for runs without a breakdown (reviews).
+func timePhase(t *session.Session, phase string) func() {
+	if t.RunTimings == nil {
+		return func() {}
+	}
+	started := time.Now()
+	return func() { t.RunTimings.Add(phase, time.Since(started)) }
+}
+
+// recordTimings adds the finished run's phases to the session totals and
+// returns them for its iteration record.
+func (e *Executor) recordTimings(ctx context.Context, t *session.Session, log *slog.Logger) *session.Timings {
+	if err := e.sessionService.AddTimings(ctx, t.ID, t.RunTimings); err != nil {
+		log.Warn("failed to record phase timings", "error", err)
+	}
+	return t.RunTimings
+}
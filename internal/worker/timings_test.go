@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+func TestStartTimings(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	instructed := start.Add(-3 * time.Second)
+	tests := []struct {
+		name string
+		sess *session.Session
+		want float64
+	}{
+		{"first run waits since creation", &session.Session{CreatedAt: start.Add(-10 * time.Second)}, 10},
+		{"follow-up waits since its enqueue", &session.Session{CreatedAt: start.Add(-time.Hour), EnqueuedAt: &instructed}, 3},
+		{"clock skew is no wait", &session.Session{CreatedAt: start.Add(time.Second)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startTimings(tt.sess, start)
+			if got := tt.sess.RunTimings.QueueWait; got != tt.want {
+				t.Errorf("QueueWait = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimePhase(t *testing.T) {
+	// Reviews run without a breakdown.
+	timePhase(&session.Session{}, session.PhaseRun)()
+
+	sess := &session.Session{RunTimings: &session.Timings{}}
+	done := timePhase(sess, session.PhaseClone)
+	time.Sleep(time.Millisecond)
+	done()
+	if sess.RunTimings.Clone <= 0 || sess.RunTimings.Run != 0 {
+		t.Errorf("RunTimings = %+v, want only clone timed", sess.RunTimings)
+	}
+}
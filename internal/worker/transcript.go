@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// transcriptRecorder accumulates raw CLI events as newline-delimited JSON,
+// dropping events once maxBytes is reached (0 = unlimited).
+type transcriptRecorder struct {
+	buf       bytes.Buffer
+	maxBytes  int
+	truncated bool
+}
+
+func newTranscriptRecorder(maxBytes int) *transcriptRecorder {
+	return &transcriptRecorder{maxBytes: maxBytes}
+}
+
+func (r *transcriptRecorder) record(event json.RawMessage) {
+	if r.truncated {
+		return
+	}
+	if r.maxBytes > 0 && r.buf.Len()+len(event)+1 > r.maxBytes {
+		r.truncated = true
+		return
+	}
+	r.buf.Write(event)
+	r.buf.WriteByte('\n')
+}
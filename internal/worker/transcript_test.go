@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTranscriptRecorder(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxBytes      int
+		events        []string
+		wantOutput    string
+		wantTruncated bool
+	}{
+		{
+			name:       "unlimited",
+			events:     []string{`{"a":1}`, `{"b":2}`},
+			wantOutput: "{\"a\":1}\n{\"b\":2}\n",
+		},
+		{
+			name:          "cap drops later events",
+			maxBytes:      10,
+			events:        []string{`{"a":1}`, `{"b":2}`, `{}`},
+			wantOutput:    "{\"a\":1}\n",
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTranscriptRecorder(tt.maxBytes)
+			for _, e := range tt.events {
+				r.record(json.RawMessage(e))
+			}
+			if got := r.buf.String(); got != tt.wantOutput {
+				t.Errorf("output = %q, want %q", got, tt.wantOutput)
+			}
+			if r.truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", r.truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
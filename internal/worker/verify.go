@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/freema/codeforge/internal/prompt"
+	"github.com/freema/codeforge/internal/runas"
+	"github.com/freema/codeforge/internal/session"
+	"github.com/freema/codeforge/internal/tool/verify"
+)
+
+const defaultVerifyTimeout = 300 * time.Second
+
+// verifyFix is an automatic follow-up decided during a run. The pool queues it
+// once the run has released the session, so the next iteration never
+// overlaps with the one that produced it.
+type verifyFix struct {
+	prompt  string
+	attempt int
+}
+
+// runVerification runs config.verify in the workspace after a code iteration,
+// as the session's CLI user, and stores the outcome. Returns nil when verification is not configured or
+// the run timed out (partial work is not worth verifying).
+func (e *Executor) runVerification(ctx context.Context, t *session.Session, workDir string, timedOut bool, log *slog.Logger) *verify.Result {
+	if t.Config == nil || t.Config.Verify == nil || len(t.Config.Verify.Commands) == 0 || timedOut {
+		return nil
+	}
+	if t.SessionType != "" && t.SessionType != "code" {
+		return nil
+	}
+	runAs, err := e.verifyUser(t)
+	if err != nil {
+		log.Warn("verification skipped", "error", err)
+		e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "verification_skipped", map[string]string{
+			"reason": err.Error(),
+		}), log, "verification_skipped", t.ID)
+		return nil
+	}
+	cfg := t.Config.Verify
+	timeout := defaultVerifyTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+
+	e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "verification_started", map[string]interface{}{
+		"commands":  cfg.Commands,
+		"iteration": t.Iteration,
+	}), log, "verification_started", t.ID)
+
+	res := verify.Run(ctx, workDir, cfg.Commands, timeout, runAs, e.cacheEnv(t, workDir, log)...)
+	res.Output = e.streamer.Redact(t.ID, res.Output)
+	res.Attempt = t.VerifyAttempts
+
+	event := "verification_passed"
+	if !res.Passed {
+		event = "verification_failed"
+		log.Info("verification failed", "command", res.Command, "exit_code", res.ExitCode, "timed_out", res.TimedOut)
+	}
+	e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, event, res), log, event, t.ID)
+
+	if err := e.sessionService.SetVerification(ctx, t.ID, res); err != nil {
+		log.Warn("failed to store verification result", "error", err)
+	}
+	t.Verification = res
+	return res
+}
+
+// verifyUser returns the user verification commands run as. The commands are
+// repository-controlled, so it fails closed: a root server without a user to
+// drop to refuses to run them, unless cli.run_as.mode is explicitly current.
+func (e *Executor) verifyUser(t *session.Session) (*runas.Identity, error) {
+	id, err := e.cfg.RunAs.Identity(t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("resolving CLI user: %w", err)
+	}
+	if id == nil && os.Getuid() == 0 && !runas.IsCurrent(e.cfg.RunAs) {
+		return nil, errors.New("refusing to run verification commands as root: no unprivileged run-as user (set cli.run_as.mode to current to allow)")
+	}
+	return id, nil
+}
+
+// planVerifyFix records an automatic follow-up for a failed verification
+// while attempts remain. Queued by the pool via queueVerifyFix.
+func (e *Executor) planVerifyFix(ctx context.Context, t *session.Session, res *verify.Result, log *slog.Logger) {
+	if res == nil || res.Passed || t.Config == nil || t.Config.Verify == nil {
+		return
+	}
+	maxAttempts := t.Config.Verify.MaxAttempts
+	attempt := t.VerifyAttempts + 1
+	if attempt > maxAttempts {
+		if maxAttempts > 0 {
+			log.Info("verification still failing, automatic fix attempts exhausted", "max_attempts", maxAttempts)
+		}
+		return
+	}
+
+	fixPrompt, err := prompt.RenderVerifyFixPrompt(prompt.VerifyFixData{
+		Command:     res.Command,
+		ExitCode:    res.ExitCode,
+		TimedOut:    res.TimedOut,
+		Output:      res.Output,
+		Attempt:     attempt,
+		MaxAttempts: maxAttempts,
+	})
+	if err != nil {
+		log.Warn("failed to render verification fix prompt", "error", err)
+		return
+	}
+	e.verifyFixes.Store(t.ID, verifyFix{prompt: fixPrompt, attempt: attempt})
+	e.emitOrLog(e.streamer.EmitSystem(ctx, t.ID, "verification_fix_planned", map[string]int{
+		"attempt":      attempt,
+		"max_attempts": maxAttempts,
+	}), log, "verification_fix_planned", t.ID)
+}
+
+// queueVerifyFix instructs the session with a follow-up planned by its last
+// run, if any. Uses a detached context — the fix must not be lost to a
+// shutdown that raced with the end of the run.
+func (e *Executor) queueVerifyFix(sessionID string, log *slog.Logger) {
+	v, ok := e.verifyFixes.LoadAndDelete(sessionID)
+	if !ok {
+		return
+	}
+	fix := v.(verifyFix)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := e.sessionService.InstructVerifyFix(ctx, sessionID, fix.prompt, fix.attempt); err != nil {
+		log.Warn("failed to queue verification fix", "session_id", sessionID, "attempt", fix.attempt, "error", err)
+		return
+	}
+	log.Info("verification fix queued", "session_id", sessionID, "attempt", fix.attempt)
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/freema/codeforge/internal/runas"
+	"github.com/freema/codeforge/internal/session"
+)
+
+// noUser is ModeAuto on a host without the codeforge user: nothing to drop to.
+type noUser struct{}
+
+func (noUser) Identity(string) (*runas.Identity, error) { return nil, nil }
+func (noUser) Release(string, ...string) error          { return nil }
+
+func TestVerifyUser(t *testing.T) {
+	current, err := runas.New(runas.Config{Mode: runas.ModeCurrent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed := &runas.Identity{UID: 64123, GID: 64123}
+	tests := []struct {
+		name     string
+		strategy runas.Strategy
+		want     *runas.Identity
+		wantErr  bool
+	}{
+		{"unprivileged user", staticUser{fixed}, fixed, false},
+		{"explicitly current", current, nil, false},
+		// As root, a missing user must not silently mean root.
+		{"no user to drop to", noUser{}, nil, os.Getuid() == 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Executor{cfg: ExecutorConfig{RunAs: tt.strategy}}
+			got, err := e.verifyUser(&session.Session{ID: "s1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("identity = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type staticUser struct{ id *runas.Identity }
+
+func (s staticUser) Identity(string) (*runas.Identity, error) { return s.id, nil }
+func (s staticUser) Release(string, ...string) error          { return nil }
@@ -0,0 +1,186 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+// SharedCache is a directory of per-language dependency caches (the layout of
+// a workspace template) shared between the sessions on the node. The CLI and
+// verify commands are pointed at it, so a module downloaded by one session is
+// there for the next. The cleaner keeps it under a size limit.
+//
+// Caches are scoped per tenant and CLI user: a session can only poison what
+// later sessions of its own tenant, running as the same user, build with.
+// Caches holding binaries or config (cargo, gradle) are never shared.
+type SharedCache struct {
+	dir      string
+	maxBytes int64 // 0 = unlimited
+}
+
+// NewSharedCache creates dir, private to the server's user; scopes are created
+// below it as sessions need them.
+func NewSharedCache(dir string, maxBytes int64) (*SharedCache, error) {
+	if err := os.MkdirAll(dir, 0o711); err != nil {
+		return nil, fmt.Errorf("creating shared cache: %w", err)
+	}
+	// Traversable, not listable: CLI users reach their own scope only.
+	if err := os.Chmod(dir, 0o711); err != nil {
+		return nil, fmt.Errorf("creating shared cache: %w", err)
+	}
+	return &SharedCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// scope names the cache directory of a tenant's sessions running as id.
+func scope(tenantID string, id *runas.Identity) string {
+	name := "default"
+	if tenantID != "" {
+		sum := sha256.Sum256([]byte(tenantID))
+		name = "tenant-" + hex.EncodeToString(sum[:8])
+	}
+	if id != nil {
+		name += "-uid" + strconv.Itoa(id.UID)
+	}
+	return name
+}
+
+// Env returns the environment that points the CLI's tools at the cache of the
+// tenant's sessions running as id (nil = the server's user), creating it on
+// first use. The scope belongs to id and is private to it.
+func (c *SharedCache) Env(tenantID string, id *runas.Identity) ([]string, error) {
+	root := filepath.Join(c.dir, scope(tenantID, id))
+	dirs := []string{root}
+	for _, e := range cacheEnv {
+		if !e.private {
+			dirs = append(dirs, filepath.Join(root, e.dir))
+		}
+	}
+	for _, d := range dirs {
+		if err := os.Mkdir(d, 0o700); err != nil {
+			if os.IsExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("creating shared cache: %w", err)
+		}
+		if id != nil && os.Getuid() == 0 {
+			if err := os.Lchown(d, id.UID, id.GID); err != nil {
+				return nil, fmt.Errorf("creating shared cache: %w", err)
+			}
+		}
+	}
+	return cacheEnvIn(root, true), nil
+}
+
+// cacheUsage is the size of one tool's cache and when it last got a file.
+type cacheUsage struct {
+	name    string
+	size    int64
+	written time.Time
+}
+
+// Evict brings the cache under its size limit by emptying whole tool caches
+// of a scope, least recently written first. Files of a removed cache stay
+// readable to runs that hold them open; the next run recreates the directory
+// and the tool downloads what it misses again. It returns the bytes freed.
+func (c *SharedCache) Evict() (int64, error) {
+	if c.maxBytes <= 0 {
+		return 0, nil
+	}
+	scopes, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("reading shared cache: %w", err)
+	}
+	var usage []cacheUsage
+	var total int64
+	for _, sc := range scopes {
+		if !sc.IsDir() || strings.HasPrefix(sc.Name(), ".") {
+			continue
+		}
+		for _, e := range cacheEnv {
+			name := filepath.Join(sc.Name(), e.dir)
+			u, err := measureCache(filepath.Join(c.dir, name))
+			if err != nil {
+				return 0, err
+			}
+			u.name = name
+			usage = append(usage, u)
+			total += u.size
+		}
+	}
+	if total <= c.maxBytes {
+		return 0, nil
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].written.Before(usage[j].written) })
+
+	var freed int64
+	for _, u := range usage {
+		if total <= c.maxBytes {
+			break
+		}
+		if u.size == 0 {
+			continue
+		}
+		// Rename first so no run writes into a half-deleted directory.
+		p := filepath.Join(c.dir, u.name)
+		evicted := filepath.Join(c.dir, fmt.Sprintf(".evicted-%s-%d", strings.ReplaceAll(u.name, string(filepath.Separator), "-"), time.Now().UnixNano()))
+		if err := os.Rename(p, evicted); err != nil {
+			return freed, fmt.Errorf("evicting shared cache %s: %w", u.name, err)
+		}
+		if err := removeAll(evicted); err != nil {
+			slog.Warn("failed to remove evicted shared cache", "path", evicted, "error", err)
+		}
+		slog.Info("shared dependency cache evicted", "cache", u.name, "size_mb", float64(u.size)/(1024*1024))
+		total -= u.size
+		freed += u.size
+	}
+	return freed, nil
+}
+
+func measureCache(path string) (cacheUsage, error) {
+	var u cacheUsage
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // skip files we can't stat
+		}
+		u.size += info.Size()
+		if info.ModTime().After(u.written) {
+			u.written = info.ModTime()
+		}
+		return nil
+	})
+	return u, err
+}
+
+// removeAll removes path after making its directories writable, which a Go
+// module cache written without -modcacherw is not. Only used on trees that
+// are about to go, so widening their modes does not matter.
+func removeAll(path string) error {
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(p, 0o700)
+		}
+		return nil
+	})
+	return os.RemoveAll(path)
+}
@@ -0,0 +1,130 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/runas"
+)
+
+func TestSharedCache_Env(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewSharedCache(dir, 0)
+	if err != nil {
+		t.Fatalf("NewSharedCache: %v", err)
+	}
+	env, err := c.Env("", nil)
+	if err != nil {
+		t.Fatalf("Env: %v", err)
+	}
+	root := filepath.Join(dir, "default")
+	for _, want := range []string{
+		"GOMODCACHE=" + filepath.Join(root, "gomod"),
+		"PIP_CACHE_DIR=" + filepath.Join(root, "pip"),
+		"GOFLAGS=-modcacherw",
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("Env() = %v, missing %s", env, want)
+		}
+	}
+	for _, e := range env {
+		if strings.HasPrefix(e, "CARGO_HOME=") || strings.HasPrefix(e, "GRADLE_USER_HOME=") {
+			t.Errorf("private cache shared: %s", e)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(root, "npm")); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("npm cache dir: %v %v", info, err)
+	}
+}
+
+func TestSharedCache_Scopes(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewSharedCache(dir, 0)
+	if err != nil {
+		t.Fatalf("NewSharedCache: %v", err)
+	}
+	gomod := func(tenant string, id *runas.Identity) string {
+		t.Helper()
+		env, err := c.Env(tenant, id)
+		if err != nil {
+			t.Fatalf("Env: %v", err)
+		}
+		for _, e := range env {
+			if v, ok := strings.CutPrefix(e, "GOMODCACHE="); ok {
+				return v
+			}
+		}
+		t.Fatalf("no GOMODCACHE in %v", env)
+		return ""
+	}
+	acme := gomod("acme", nil)
+	if acme == gomod("", nil) || acme == gomod("globex", nil) {
+		t.Error("tenants share a cache")
+	}
+	if acme == gomod("acme", &runas.Identity{UID: 12345, GID: 12345}) {
+		t.Error("CLI users share a cache")
+	}
+	if acme != gomod("acme", nil) {
+		t.Error("scope not stable")
+	}
+}
+
+func TestSharedCache_Evict(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewSharedCache(dir, 1500)
+	if err != nil {
+		t.Fatalf("NewSharedCache: %v", err)
+	}
+	if _, err := c.Env("", nil); err != nil {
+		t.Fatalf("Env: %v", err)
+	}
+	write := func(cache string, age time.Duration) {
+		t.Helper()
+		p := filepath.Join(dir, "default", cache, "pkg", "data")
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, 1000), 0o444); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		// Like a Go module cache written without -modcacherw.
+		if err := os.Chmod(filepath.Dir(p), 0o555); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("gomod", 48*time.Hour)
+	write("npm", time.Hour)
+
+	freed, err := c.Evict()
+	if err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+	if freed != 1000 {
+		t.Errorf("freed = %d, want 1000", freed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "default", "gomod")); !os.IsNotExist(err) {
+		t.Errorf("least recently written cache not evicted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "default", "npm", "pkg", "data")); err != nil {
+		t.Errorf("recent cache evicted: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".evicted-*")); len(matches) != 0 {
+		t.Errorf("evicted copies left behind: %v", matches)
+	}
+
+	if freed, err := c.Evict(); err != nil || freed != 0 {
+		t.Errorf("Evict under the limit = %d, %v", freed, err)
+	}
+	if _, err := c.Env("", nil); err != nil {
+		t.Errorf("evicted cache not recreated: %v", err)
+	}
+	_ = os.Chmod(filepath.Join(dir, "default", "npm", "pkg"), 0o755)
+}
@@ -65,7 +65,13 @@ func (c *Cleaner) cleanup(ctx context.Context) {
 	var reclaimedBytes int64
 
 	for _, ws := range workspaces {
-		if !ws.IsExpired() {
+		// Sessions parked by Await keep their workspace until the hold
+		// expires, and release it then even if the workspace TTL has not.
+		held, released := c.awaitHold(ctx, ws.TaskID)
+		if held && !released {
+			continue
+		}
+		if !released && !ws.IsExpired() {
 			continue
 		}
 
@@ -78,6 +84,7 @@ func (c *Cleaner) cleanup(ctx context.Context) {
 			"task_id", ws.TaskID,
 			"age", time.Since(ws.CreatedAt).Round(time.Second),
 			"size_bytes", ws.SizeBytes,
+			"await_expired", released,
 		)
 
 		if err := c.manager.Delete(ctx, ws.TaskID); err != nil {
@@ -98,6 +105,12 @@ func (c *Cleaner) cleanup(ctx context.Context) {
 
 	// Check disk thresholds
 	c.checkDiskUsage(ctx)
+
+	if c.manager.cache != nil {
+		if _, err := c.manager.cache.Evict(); err != nil {
+			slog.Error("shared cache eviction failed", "error", err)
+		}
+	}
 }
 
 func (c *Cleaner) checkDiskUsage(ctx context.Context) {
@@ -153,6 +166,16 @@ func (c *Cleaner) emergencyCleanup(ctx context.Context) {
 	}
 }
 
+// awaitHold reports whether the session is parked in awaiting_instruction by
+// Await, and whether that hold has expired.
+func (c *Cleaner) awaitHold(ctx context.Context, sessionID string) (held, expired bool) {
+	t, err := c.sessionService.Get(ctx, sessionID)
+	if err != nil || t.Status != session.StatusAwaitingInstruction || t.AwaitExpiresAt == nil {
+		return false, false
+	}
+	return true, session.AwaitExpired(t, time.Now())
+}
+
 func (c *Cleaner) isSessionRunning(ctx context.Context, sessionID string) bool {
 	t, err := c.sessionService.Get(ctx, sessionID)
 	if err != nil {
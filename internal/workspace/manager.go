@@ -11,7 +11,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
 	"github.com/freema/codeforge/internal/redisclient"
+	"github.com/freema/codeforge/internal/runas"
 	slugpkg "github.com/freema/codeforge/internal/slug"
 )
 
@@ -23,6 +26,7 @@ type Workspace struct {
 	CreatedAt time.Time `json:"created_at"`
 	TTL       int64     `json:"ttl"` // seconds
 	SizeBytes int64     `json:"size_bytes"`
+	SizedAt   time.Time `json:"sized_at,omitempty"` // zero = size not measured yet (see Sizer)
 }
 
 // IsExpired checks if the workspace TTL has elapsed.
@@ -40,6 +44,7 @@ type Manager struct {
 	basePath string
 	redis    *redisclient.Client
 	ttl      time.Duration
+	cache    *SharedCache // optional, nil = no shared dependency cache
 }
 
 // NewManager creates a new workspace manager.
@@ -51,6 +56,21 @@ func NewManager(basePath string, redis *redisclient.Client, ttl time.Duration) *
 	}
 }
 
+// SetSharedCache sets the dependency cache shared by all sessions, which the
+// cleaner keeps under its size limit.
+func (m *Manager) SetSharedCache(c *SharedCache) {
+	m.cache = c
+}
+
+// SharedCacheEnv returns the environment pointing the CLI at the tenant's
+// shared dependency cache for the CLI user id, nil without one.
+func (m *Manager) SharedCacheEnv(tenantID string, id *runas.Identity) ([]string, error) {
+	if m == nil || m.cache == nil {
+		return nil, nil
+	}
+	return m.cache.Env(tenantID, id)
+}
+
 // Create creates a workspace directory and registers it in Redis.
 // The prompt is used to generate a human-readable slug for the directory name.
 func (m *Manager) Create(ctx context.Context, sessionID, prompt string) (*Workspace, error) {
@@ -78,8 +98,10 @@ func (m *Manager) Create(ctx context.Context, sessionID, prompt string) (*Worksp
 		"size_bytes": 0,
 	}
 
-	redisKey := m.redisKey(sessionID)
-	if err := m.redis.Unwrap().HSet(ctx, redisKey, fields).Err(); err != nil {
+	pipe := m.redis.Unwrap().TxPipeline()
+	pipe.HSet(ctx, m.redisKey(sessionID), fields)
+	pipe.SAdd(ctx, m.indexKey(), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("registering workspace in redis: %w", err)
 	}
 
@@ -131,11 +153,21 @@ func (m *Manager) Delete(ctx context.Context, sessionID string) error {
 		slog.Warn("failed to remove workspace directory", "path", absPath, "error", err)
 	}
 
-	m.redis.Unwrap().Del(ctx, m.redisKey(sessionID))
+	pipe := m.redis.Unwrap().TxPipeline()
+	pipe.Del(ctx, m.redisKey(sessionID))
+	pipe.SRem(ctx, m.indexKey(), sessionID)
+	_, _ = pipe.Exec(ctx)
 	return nil
 }
 
-// UpdateSize calculates and stores the workspace size.
+// InvalidateSize marks the cached workspace size as stale so the background
+// Sizer recomputes it on its next pass. Cheap enough for the executor's hot path.
+func (m *Manager) InvalidateSize(ctx context.Context, sessionID string) {
+	m.redis.Unwrap().HDel(ctx, m.redisKey(sessionID), "sized_at")
+}
+
+// UpdateSize walks the workspace directory and stores its size. It blocks on
+// the filesystem; request paths should rely on the Sizer instead.
 func (m *Manager) UpdateSize(ctx context.Context, sessionID string) (int64, error) {
 	// Read path from Redis; fallback to legacy sessionID-based path
 	wsPath := filepath.Join(m.basePath, sessionID)
@@ -148,51 +180,90 @@ func (m *Manager) UpdateSize(ctx context.Context, sessionID string) (int64, erro
 		return 0, err
 	}
 
-	m.redis.Unwrap().HSet(ctx, m.redisKey(sessionID), "size_bytes", size)
+	m.redis.Unwrap().HSet(ctx, m.redisKey(sessionID),
+		"size_bytes", size,
+		"sized_at", time.Now().UTC().Format(time.RFC3339Nano),
+	)
 	return size, nil
 }
 
-// List returns all tracked workspaces.
+// List returns all tracked workspaces, read from the workspace index set.
+// Index entries whose metadata hash has disappeared are pruned.
 func (m *Manager) List(ctx context.Context) ([]Workspace, error) {
-	pattern := m.redis.Key("workspace", "*")
-	var workspaces []Workspace
+	indexKey := m.indexKey()
+	ids, err := m.redis.Unwrap().SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing workspace index: %w", err)
+	}
 
-	iter := m.redis.Unwrap().Scan(ctx, 0, pattern, 100).Iterator()
-	for iter.Next(ctx) {
-		redisKey := iter.Val()
-		// Skip index keys or other patterns
-		if strings.HasSuffix(redisKey, "_index") {
-			continue
+	pipe := m.redis.Unwrap().Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, m.redisKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("loading workspaces: %w", err)
 		}
+	}
 
-		fields, err := m.redis.Unwrap().HGetAll(ctx, redisKey).Result()
-		if err != nil || len(fields) == 0 {
+	workspaces := []Workspace{}
+	var stale []interface{}
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			stale = append(stale, ids[i])
 			continue
 		}
 
-		ws := hashToWorkspace(fields)
-		if ws != nil {
-			// Recalculate size if unknown and path exists
-			if ws.SizeBytes == 0 && ws.Path != "" {
-				if size, err := DirSize(ws.Path); err == nil && size > 0 {
-					ws.SizeBytes = size
-					m.redis.Unwrap().HSet(ctx, redisKey, "size_bytes", size)
-				}
-			}
-			workspaces = append(workspaces, *ws)
+		workspaces = append(workspaces, *hashToWorkspace(fields))
+	}
+	if len(stale) > 0 {
+		m.redis.Unwrap().SRem(ctx, indexKey, stale...)
+	}
+	return workspaces, nil
+}
+
+// MigrateIndex backfills the workspace index set from workspace:* keys
+// created before the index existed. It SCANs once per Redis database; a
+// marker key set after a complete scan makes later calls a no-op, so a scan
+// cut short is repeated on the next start. Nodes starting together may scan
+// concurrently, which is harmless as indexing is idempotent. Returns the
+// number of keys indexed.
+func (m *Manager) MigrateIndex(ctx context.Context) (int, error) {
+	marker := m.redis.Key("workspaces:index:migrated")
+	done, err := m.redis.Unwrap().Exists(ctx, marker).Result()
+	if err != nil {
+		return 0, fmt.Errorf("checking workspace index migration: %w", err)
+	}
+	if done > 0 {
+		return 0, nil
+	}
+
+	prefix := m.redis.Key("workspace", "")
+	indexed := 0
+	iter := m.redis.Unwrap().Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		id := strings.TrimPrefix(iter.Val(), prefix)
+		if id == "" || strings.Contains(id, ":") || strings.HasSuffix(id, "_index") {
+			continue
 		}
+		if err := m.redis.Unwrap().SAdd(ctx, m.indexKey(), id).Err(); err != nil {
+			return indexed, fmt.Errorf("indexing workspace %s: %w", id, err)
+		}
+		indexed++
 	}
 	if err := iter.Err(); err != nil {
-		return nil, fmt.Errorf("scanning workspaces: %w", err)
+		return indexed, fmt.Errorf("scanning workspaces: %w", err)
 	}
-
-	if workspaces == nil {
-		workspaces = []Workspace{}
+	if err := m.redis.Unwrap().Set(ctx, marker, time.Now().UTC().Format(time.RFC3339), 0).Err(); err != nil {
+		return indexed, fmt.Errorf("marking workspace index migration: %w", err)
 	}
-	return workspaces, nil
+	return indexed, nil
 }
 
-// TotalSizeBytes returns the sum of all tracked workspace sizes.
+// TotalSizeBytes returns the sum of all tracked workspace sizes as last
+// recorded by the Sizer.
 func (m *Manager) TotalSizeBytes(ctx context.Context) int64 {
 	workspaces, err := m.List(ctx)
 	if err != nil {
@@ -228,6 +299,10 @@ func (m *Manager) redisKey(sessionID string) string {
 	return m.redis.Key("workspace", sessionID)
 }
 
+func (m *Manager) indexKey() string {
+	return m.redis.Key("workspaces:index")
+}
+
 func hashToWorkspace(fields map[string]string) *Workspace {
 	ws := &Workspace{
 		TaskID: fields["task_id"],
@@ -243,5 +318,8 @@ func hashToWorkspace(fields map[string]string) *Workspace {
 	if v := fields["size_bytes"]; v != "" {
 		ws.SizeBytes, _ = strconv.ParseInt(v, 10, 64)
 	}
+	if v := fields["sized_at"]; v != "" {
+		ws.SizedAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
 	return ws
 }
@@ -0,0 +1,118 @@
+//go:build integration
+
+package workspace
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/freema/codeforge/internal/redisclient"
+)
+
+func setupTestManager(t *testing.T) (*Manager, *redisclient.Client) {
+	t.Helper()
+
+	url := os.Getenv("CODEFORGE_REDIS__URL")
+	if url == "" {
+		url = "redis://localhost:6379"
+	}
+	rdb, err := redisclient.New(url, "test:workspace:")
+	if err != nil {
+		t.Skipf("skipping: redis not available: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx); err != nil {
+		rdb.Close()
+		t.Skipf("skipping: redis not reachable: %v", err)
+	}
+
+	t.Cleanup(func() {
+		rdb.Unwrap().FlushDB(context.Background())
+		rdb.Close()
+	})
+	return NewManager(t.TempDir(), rdb, time.Hour), rdb
+}
+
+func TestManager_ListUsesIndex(t *testing.T) {
+	m, rdb := setupTestManager(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"s1", "s2"} {
+		if _, err := m.Create(ctx, id, "fix "+id); err != nil {
+			t.Fatalf("Create %s: %v", id, err)
+		}
+	}
+	if err := m.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// A stale index entry without metadata is pruned on List.
+	rdb.Unwrap().SAdd(ctx, m.indexKey(), "gone")
+
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 || list[0].TaskID != "s2" {
+		t.Errorf("List = %+v, want only s2", list)
+	}
+	if ok, _ := rdb.Unwrap().SIsMember(ctx, m.indexKey(), "gone").Result(); ok {
+		t.Error("stale index entry was not pruned")
+	}
+}
+
+func TestManager_MigrateIndex(t *testing.T) {
+	m, rdb := setupTestManager(t)
+	ctx := context.Background()
+
+	// Workspace registered before the index existed.
+	rdb.Unwrap().HSet(ctx, m.redisKey("legacy"), "task_id", "legacy", "path", "/tmp/legacy")
+
+	n, err := m.MigrateIndex(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("MigrateIndex = %d, %v; want 1", n, err)
+	}
+	if n, _ := m.MigrateIndex(ctx); n != 0 {
+		t.Errorf("second MigrateIndex indexed %d, want 0", n)
+	}
+
+	list, err := m.List(ctx)
+	if err != nil || len(list) != 1 || list[0].TaskID != "legacy" {
+		t.Errorf("List = %+v, %v", list, err)
+	}
+}
+
+func TestSizer_RefreshesStaleSizes(t *testing.T) {
+	m, _ := setupTestManager(t)
+	ctx := context.Background()
+
+	ws, err := m.Create(ctx, "s1", "size me")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := os.WriteFile(ws.Path+"/file.txt", make([]byte, 1024), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSizer(m, SizerConfig{Staleness: time.Hour})
+	s.refresh(ctx)
+	if got := m.Get(ctx, "s1"); got.SizeBytes != 1024 || got.SizedAt.IsZero() {
+		t.Fatalf("after refresh: size=%d sized_at=%v", got.SizeBytes, got.SizedAt)
+	}
+
+	// Fresh sizes are not recomputed until invalidated.
+	if err := os.WriteFile(ws.Path+"/more.txt", make([]byte, 1024), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.refresh(ctx)
+	if got := m.Get(ctx, "s1"); got.SizeBytes != 1024 {
+		t.Errorf("fresh size recomputed: %d", got.SizeBytes)
+	}
+	m.InvalidateSize(ctx, "s1")
+	s.refresh(ctx)
+	if got := m.Get(ctx, "s1"); got.SizeBytes != 2048 {
+		t.Errorf("invalidated size = %d, want 2048", got.SizeBytes)
+	}
+}
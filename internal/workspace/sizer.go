@@ -0,0 +1,72 @@
+package workspace
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SizerConfig holds background sizing configuration.
+type SizerConfig struct {
+	Interval  time.Duration // how often to look for stale sizes
+	Staleness time.Duration // recompute sizes older than this
+}
+
+// Sizer computes workspace sizes in the background and caches them in the
+// workspace hash, so listings, /health and the cleaner read sizes from Redis
+// instead of walking the filesystem on the request path.
+type Sizer struct {
+	manager *Manager
+	cfg     SizerConfig
+}
+
+// NewSizer creates a background workspace sizer.
+func NewSizer(manager *Manager, cfg SizerConfig) *Sizer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Staleness <= 0 {
+		cfg.Staleness = 15 * time.Minute
+	}
+	return &Sizer{manager: manager, cfg: cfg}
+}
+
+// Start runs the sizing loop until the context is canceled.
+func (s *Sizer) Start(ctx context.Context) {
+	slog.Info("workspace sizer started", "interval", s.cfg.Interval, "staleness", s.cfg.Staleness)
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	s.refresh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("workspace sizer stopped")
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// refresh recomputes every size that was never measured, was invalidated, or
+// is older than the staleness window.
+func (s *Sizer) refresh(ctx context.Context) {
+	workspaces, err := s.manager.List(ctx)
+	if err != nil {
+		slog.Warn("workspace sizer list failed", "error", err)
+		return
+	}
+	cutoff := time.Now().Add(-s.cfg.Staleness)
+	for _, ws := range workspaces {
+		if ctx.Err() != nil {
+			return
+		}
+		if !ws.SizedAt.IsZero() && ws.SizedAt.After(cutoff) {
+			continue
+		}
+		if _, err := s.manager.UpdateSize(ctx, ws.TaskID); err != nil {
+			slog.Debug("workspace sizing failed", "task_id", ws.TaskID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CacheDir is where a workspace template is copied in the workspace, relative
+// to the repository root. It is excluded from commits.
+const CacheDir = ".codeforge/cache"
+
+// DefaultTemplate is the template applied to sessions that name none.
+const DefaultTemplate = "default"
+
+// cacheEnv maps the top-level directories of a template (and of the shared
+// cache) to the variable that points the matching tool at it. Directories not
+// listed are copied but left for the CLI to find on its own.
+var cacheEnv = []struct {
+	dir string
+	env []string // NAME, or NAME=value for fixed values set alongside
+	// private caches are never shared between sessions: besides downloads
+	// they hold installed binaries, config and init scripts a build trusts.
+	private bool
+}{
+	{"npm", []string{"npm_config_cache"}, false},
+	{"yarn", []string{"YARN_CACHE_FOLDER"}, false},
+	{"pip", []string{"PIP_CACHE_DIR"}, false},
+	// The module cache is read-only by default, which would keep workspace
+	// cleanup and cache eviction from removing it.
+	{"gomod", []string{"GOMODCACHE", "GOFLAGS=-modcacherw"}, false},
+	{"gocache", []string{"GOCACHE"}, false},
+	{"cargo", []string{"CARGO_HOME"}, true},
+	{"gradle", []string{"GRADLE_USER_HOME"}, true},
+}
+
+// Templates are base directories of dependency caches (npm, Go modules, pip,
+// …) copied into fresh workspaces, so CLI runs on the same stack do not
+// download the same dependencies for every session. A nil *Templates has none.
+type Templates struct {
+	dirs map[string]string // name → absolute directory
+}
+
+// NewTemplates checks that every template directory exists. It returns nil
+// when no templates are configured.
+func NewTemplates(dirs map[string]string) (*Templates, error) {
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	t := &Templates{dirs: make(map[string]string, len(dirs))}
+	for name, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("workspace template %s: %w", name, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("workspace template %s: %w", name, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("workspace template %s: %s is not a directory", name, abs)
+		}
+		t.dirs[strings.ToLower(name)] = abs
+	}
+	return t, nil
+}
+
+// Names returns the configured template names, sorted.
+func (t *Templates) Names() []string {
+	if t == nil {
+		return nil
+	}
+	names := make([]string, 0, len(t.dirs))
+	for name := range t.dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the directory of the named template, or of the default
+// template when name is empty. ok is false when there is no such template.
+func (t *Templates) Resolve(name string) (dir string, ok bool) {
+	if t == nil {
+		return "", false
+	}
+	if name == "" {
+		name = DefaultTemplate
+	}
+	dir, ok = t.dirs[strings.ToLower(name)]
+	return dir, ok
+}
+
+// Apply copies the named template (the default one when name is empty) into
+// CacheDir of the workspace at workDir. On filesystems that support it the
+// copy is a reflink, so it costs no space until a file is changed. It reports
+// whether a template was applied.
+func (t *Templates) Apply(ctx context.Context, name, workDir string) (bool, error) {
+	src, ok := t.Resolve(name)
+	if !ok {
+		if name != "" {
+			return false, fmt.Errorf("unknown workspace template %q", name)
+		}
+		return false, nil
+	}
+
+	dst := filepath.Join(workDir, CacheDir)
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return false, fmt.Errorf("creating %s: %w", CacheDir, err)
+	}
+	out, err := exec.CommandContext(ctx, "cp", "-a", "--reflink=auto", src+"/.", dst).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("copying workspace template %s: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	// Read-only directories (a Go module cache) could not be removed with
+	// the workspace.
+	err = filepath.WalkDir(dst, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm()&0o200 == 0 {
+			return os.Chmod(p, info.Mode().Perm()|0o200)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("making workspace template writable: %w", err)
+	}
+	return true, nil
+}
+
+// CacheEnv returns the environment that points the CLI's tools at the caches
+// copied into the workspace at workDir, nil when it has none.
+func CacheEnv(workDir string) []string {
+	return cacheEnvIn(filepath.Join(workDir, CacheDir), false)
+}
+
+// cacheEnvIn returns the environment for the tool caches present in root,
+// leaving out private ones when shared is set.
+func cacheEnvIn(root string, shared bool) []string {
+	var env []string
+	for _, c := range cacheEnv {
+		if shared && c.private {
+			continue
+		}
+		dir := filepath.Join(root, c.dir)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		for _, e := range c.env {
+			if strings.Contains(e, "=") {
+				env = append(env, e)
+			} else {
+				env = append(env, e+"="+dir)
+			}
+		}
+	}
+	return env
+}
@@ -0,0 +1,84 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTemplates_Apply(t *testing.T) {
+	src := t.TempDir()
+	mod := filepath.Join(src, "gomod", "example.com", "lib@v1.0.0")
+	if err := os.MkdirAll(mod, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte("module example.com/lib\n"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(mod, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(mod, 0o755) })
+	if err := os.MkdirAll(filepath.Join(src, "npm"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := NewTemplates(map[string]string{"Default": src})
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	if got := templates.Names(); !slices.Equal(got, []string{"default"}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	workDir := t.TempDir()
+	applied, err := templates.Apply(context.Background(), "", workDir)
+	if err != nil || !applied {
+		t.Fatalf("Apply = %v, %v", applied, err)
+	}
+	copied := filepath.Join(workDir, CacheDir, "gomod", "example.com", "lib@v1.0.0")
+	if _, err := os.Stat(filepath.Join(copied, "go.mod")); err != nil {
+		t.Errorf("template not copied: %v", err)
+	}
+	if info, err := os.Stat(copied); err != nil || info.Mode().Perm()&0o200 == 0 {
+		t.Errorf("copied directory not writable: %v %v", info.Mode(), err)
+	}
+
+	env := CacheEnv(workDir)
+	cache := filepath.Join(workDir, CacheDir)
+	for _, want := range []string{
+		"npm_config_cache=" + filepath.Join(cache, "npm"),
+		"GOMODCACHE=" + filepath.Join(cache, "gomod"),
+		"GOFLAGS=-modcacherw",
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("CacheEnv() = %v, missing %s", env, want)
+		}
+	}
+	if len(env) != 3 {
+		t.Errorf("CacheEnv() = %v, want 3 entries", env)
+	}
+
+	if _, err := templates.Apply(context.Background(), "rust", t.TempDir()); err == nil {
+		t.Error("Apply(unknown): expected error")
+	}
+}
+
+func TestTemplates_None(t *testing.T) {
+	templates, err := NewTemplates(nil)
+	if err != nil || templates != nil {
+		t.Fatalf("NewTemplates(nil) = %v, %v", templates, err)
+	}
+	workDir := t.TempDir()
+	if applied, err := templates.Apply(context.Background(), "", workDir); applied || err != nil {
+		t.Errorf("Apply on nil templates = %v, %v", applied, err)
+	}
+	if env := CacheEnv(workDir); env != nil {
+		t.Errorf("CacheEnv() = %v, want nil", env)
+	}
+	if _, err := NewTemplates(map[string]string{"node": filepath.Join(workDir, "missing")}); err == nil {
+		t.Error("NewTemplates with a missing directory: expected error")
+	}
+}
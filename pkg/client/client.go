@@ -0,0 +1,236 @@
+// Package client is a typed Go client for the CodeForge HTTP API.
+//
+// Request and response types are aliases of the server's own types, so the
+// client stays in sync with the handlers without a separate schema:
+//
+//	c := client.New("https://codeforge.internal", token)
+//	sess, err := c.CreateSession(ctx, client.CreateSessionRequest{
+//		RepoURL: "https://github.com/acme/api",
+//		Prompt:  "Fix the flaky login test",
+//	})
+//	err = c.StreamEvents(ctx, sess.ID, func(ev client.Event) error {
+//		fmt.Println(ev.Type, ev.Event)
+//		return nil
+//	})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to a CodeForge server.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+
+	streamClient   *http.Client  // no overall timeout (SSE streams are long-lived)
+	reconnectDelay time.Duration // pause before an SSE reconnect
+	maxReconnects  int           // consecutive failed reconnects before giving up
+}
+
+// New creates a client for the server at baseURL (e.g. "http://localhost:8080")
+// authenticating with token (operator or tenant API token).
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		token:          token,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+		streamClient:   &http.Client{},
+		reconnectDelay: 2 * time.Second,
+		maxReconnects:  5,
+	}
+}
+
+// SetHTTPClient replaces the HTTP client used for regular (non-stream) calls.
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// SetStreamReconnect configures SSE reconnects: the delay between attempts and
+// how many consecutive failed attempts are tolerated.
+func (c *Client) SetStreamReconnect(delay time.Duration, maxAttempts int) {
+	c.reconnectDelay = delay
+	c.maxReconnects = maxAttempts
+}
+
+// APIError is a non-2xx response from the server.
+type APIError struct {
+	StatusCode int
+	Code       string            `json:"error"`
+	Message    string            `json:"message"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("codeforge: %d %s", e.StatusCode, e.Message)
+	}
+	if len(e.Fields) > 0 {
+		return fmt.Sprintf("codeforge: %d %s %v", e.StatusCode, e.Code, e.Fields)
+	}
+	return fmt.Sprintf("codeforge: %d %s", e.StatusCode, e.Code)
+}
+
+// CreateSession creates a session and queues it for execution.
+func (c *Client) CreateSession(ctx context.Context, req CreateSessionRequest) (*CreateSessionResponse, error) {
+	body, err := marshalCreateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var out CreateSessionResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Get returns a session. With includeIterations, the iteration history is loaded too.
+func (c *Client) Get(ctx context.Context, sessionID string, includeIterations bool) (*Session, error) {
+	path := "/api/v1/sessions/" + url.PathEscape(sessionID)
+	if includeIterations {
+		path += "?include=iterations"
+	}
+	var out Session
+	if err := c.do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Wait long-polls the session until it reaches a terminal state or wait
+// elapses (the server caps it at 55s), returning the latest session either way.
+func (c *Client) Wait(ctx context.Context, sessionID string, wait time.Duration) (*Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait+30*time.Second)
+	defer cancel()
+
+	path := "/api/v1/sessions/" + url.PathEscape(sessionID) + "?wait=" + url.QueryEscape(wait.String())
+	var out Session
+	// The stream client has no overall timeout; the context bounds the call.
+	if err := c.doWith(ctx, c.streamClient, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Instruct sends a follow-up prompt to a finished session, starting a new iteration.
+func (c *Client) Instruct(ctx context.Context, sessionID, prompt string) (*InstructResponse, error) {
+	body, err := json.Marshal(map[string]string{"prompt": prompt})
+	if err != nil {
+		return nil, err
+	}
+	var out InstructResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/instruct", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Cancel stops a running session or drops a queued one.
+func (c *Client) Cancel(ctx context.Context, sessionID string) (*CancelResponse, error) {
+	var out CancelResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/cancel", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePR commits the session's changes and opens a PR/MR.
+func (c *Client) CreatePR(ctx context.Context, sessionID string, req CreatePRRequest) (*CreatePRResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var out CreatePRResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/create-pr", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	return c.doWith(ctx, c.httpClient, method, path, body, out)
+}
+
+func (c *Client) doWith(ctx context.Context, hc *http.Client, method, path string, body []byte, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, apiErr); err != nil || (apiErr.Code == "" && apiErr.Message == "") {
+		apiErr.Code = http.StatusText(resp.StatusCode)
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
+
+// marshalCreateRequest encodes req including config.ai_api_key, which the
+// server's Config type accepts on input but never marshals.
+func marshalCreateRequest(req CreateSessionRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Config == nil || req.Config.AIApiKey == "" {
+		return body, nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(doc["config"], &cfg); err != nil {
+		return nil, err
+	}
+	cfg["ai_api_key"] = req.Config.AIApiKey
+	if doc["config"], err = json.Marshal(cfg); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
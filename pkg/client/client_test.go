@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateSession(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/sessions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("Authorization = %q", got)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"s1","status":"pending","created_at":"2026-01-01T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "tok")
+	resp, err := c.CreateSession(context.Background(), CreateSessionRequest{
+		RepoURL: "https://github.com/acme/api",
+		Prompt:  "fix it",
+		Config:  &Config{AIModel: "m", AIApiKey: "sk-secret"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if resp.ID != "s1" || resp.Status != StatusPending {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	cfg, _ := body["config"].(map[string]interface{})
+	if cfg["ai_api_key"] != "sk-secret" || cfg["ai_model"] != "m" {
+		t.Errorf("config not sent correctly: %v", body["config"])
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantMsg string
+	}{
+		{"app error", http.StatusConflict, `{"error":"Conflict","message":"session is currently running"}`, "session is currently running"},
+		{"validation", http.StatusBadRequest, `{"error":"validation_error","fields":{"repo_url":"field is required"}}`, ""},
+		{"plain text", http.StatusBadGateway, `upstream down`, "upstream down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := New(srv.URL, "tok").Instruct(context.Background(), "s1", "more")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMsg {
+				t.Errorf("got %+v", apiErr)
+			}
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("wait"); got != "30s" {
+			t.Errorf("wait = %q, want 30s", got)
+		}
+		_, _ = w.Write([]byte(`{"id":"s1","status":"completed","created_at":"2026-01-01T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(srv.URL, "tok").Wait(context.Background(), "s1", 30*time.Second)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if s.Status != StatusCompleted {
+		t.Errorf("status = %s", s.Status)
+	}
+}
+
+func TestStreamEvents_Reconnect(t *testing.T) {
+	history := []string{
+		`{"type":"system","event":"cli_started","data":{},"ts":"1"}`,
+		`{"type":"stream","event":"text","data":{},"ts":"2"}`,
+		`{"type":"result","event":"task_completed","data":{},"ts":"3"}`,
+	}
+	connects := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connects++
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: connected\ndata: {\"session_id\":\"s1\"}\n\n")
+		if connects == 1 {
+			// Drop the connection after two events.
+			for _, h := range history[:2] {
+				fmt.Fprintf(w, "data: %s\n\n", h)
+			}
+			return
+		}
+		for _, h := range history {
+			fmt.Fprintf(w, "data: %s\n\n", h)
+		}
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "event: done\ndata: {\"status\":\"completed\"}\n\n")
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "tok")
+	c.SetStreamReconnect(time.Millisecond, 3)
+
+	var names []string
+	err := c.StreamEvents(context.Background(), "s1", func(ev Event) error {
+		if ev.Name != "" {
+			names = append(names, ev.Name)
+		} else {
+			names = append(names, ev.Event)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents: %v", err)
+	}
+	want := []string{"connected", "cli_started", "text", "connected", "task_completed", "done"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+}
+
+func TestStreamEvents_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"Not Found","message":"session not found"}`))
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL, "tok").StreamEvents(context.Background(), "missing", func(Event) error { return nil })
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 APIError, got %v", err)
+	}
+}
+
+func TestStreamEvents_Stop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"type\":\"system\",\"event\":\"a\"}\n\ndata: {\"type\":\"system\",\"event\":\"b\"}\n\n")
+	}))
+	defer srv.Close()
+
+	calls := 0
+	err := New(srv.URL, "tok").StreamEvents(context.Background(), "s1", func(Event) error {
+		calls++
+		return ErrStopStream
+	})
+	if err != nil || calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, calls)
+	}
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrStopStream can be returned from an EventHandler to end StreamEvents
+// early without an error.
+var ErrStopStream = errors.New("stop stream")
+
+// Event is one SSE message from a session stream.
+//
+// Session events (Name == "") carry the worker's Type/Event/Data/TS fields.
+// Control events are named: "connected" (sent on every (re)connect),
+// "done" (terminal; Data holds session_id, status and changes_summary) and
+// "timeout" (server closed a long stream; the client reconnects).
+type Event struct {
+	Name  string          `json:"-"`
+	Type  string          `json:"type"`  // system, git, cli, stream, result
+	Event string          `json:"event"` // event name, e.g. cli_started, task_completed
+	Data  json.RawMessage `json:"data"`
+	TS    string          `json:"ts"`
+}
+
+// EventHandler receives stream events in order.
+type EventHandler func(Event) error
+
+// StreamEvents follows a session's event stream until the session finishes
+// (a "done" event), ctx is canceled, or fn returns an error.
+//
+// Dropped connections and server-side stream timeouts are retried
+// automatically. The server replays the session history on every connect;
+// events already delivered are skipped, so fn sees each session event once.
+func (c *Client) StreamEvents(ctx context.Context, sessionID string, fn EventHandler) error {
+	var (
+		delivered int // session events already passed to fn
+		failures  int // consecutive failed connects
+	)
+	for {
+		done, n, err := c.streamOnce(ctx, sessionID, delivered, fn)
+		if err == nil || n > 0 {
+			failures = 0 // clean close (server timeout) or progress made
+		}
+		delivered += n
+		switch {
+		case errors.Is(err, ErrStopStream):
+			return nil
+		case done:
+			return err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+			return err // 4xx: retrying won't help
+		}
+		if handlerErr, ok := err.(handlerError); ok {
+			return handlerErr.err
+		}
+
+		failures++
+		if failures > c.maxReconnects {
+			if err == nil {
+				err = errors.New("stream closed")
+			}
+			return fmt.Errorf("stream for session %s: giving up after %d reconnects: %w", sessionID, c.maxReconnects, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.reconnectDelay):
+		}
+	}
+}
+
+// handlerError marks an error returned by the caller's EventHandler.
+type handlerError struct{ err error }
+
+func (e handlerError) Error() string { return e.err.Error() }
+
+// streamOnce reads a single SSE connection. It skips the first `skip` session
+// events (history already delivered) and reports how many new session events
+// were delivered and whether the stream ended with "done".
+func (c *Client) streamOnce(ctx context.Context, sessionID string, skip int, fn EventHandler) (done bool, delivered int, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/stream", nil)
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return false, 0, decodeAPIError(resp)
+	}
+
+	seen := 0
+	emit := func(name string, data string) error {
+		ev := Event{Name: name}
+		if name == "" {
+			seen++
+			if seen <= skip {
+				return nil
+			}
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				ev.Data = json.RawMessage(data)
+			}
+		} else {
+			ev.Data = json.RawMessage(data)
+		}
+		if err := fn(ev); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				return err
+			}
+			return handlerError{err}
+		}
+		if name == "" {
+			delivered++
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	var (
+		name string
+		data []string
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				name = ""
+				continue
+			}
+			if err := emit(name, strings.Join(data, "\n")); err != nil {
+				return false, delivered, err
+			}
+			if name == "done" {
+				return true, delivered, nil
+			}
+			if name == "timeout" {
+				return false, delivered, nil
+			}
+			name, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// comment / keepalive
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return false, delivered, scanner.Err()
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"time"
+
+	"github.com/freema/codeforge/internal/session"
+)
+
+// Server types, aliased so request and response shapes follow the handlers.
+type (
+	Session              = session.Session
+	Status               = session.Status
+	Config               = session.Config
+	Reasoning            = session.Reasoning
+	MCPServer            = session.MCPServer
+	Iteration            = session.Iteration
+	CreateSessionRequest = session.CreateSessionRequest
+	CreatePRRequest      = session.CreatePRRequest
+	CreatePRResponse     = session.CreatePRResponse
+)
+
+// Session statuses.
+const (
+	StatusPending             = session.StatusPending
+	StatusCloning             = session.StatusCloning
+	StatusRunning             = session.StatusRunning
+	StatusCompleted           = session.StatusCompleted
+	StatusFailed              = session.StatusFailed
+	StatusAwaitingInstruction = session.StatusAwaitingInstruction
+	StatusReviewing           = session.StatusReviewing
+	StatusCreatingPR          = session.StatusCreatingPR
+	StatusPRCreated           = session.StatusPRCreated
+	StatusPRMerged            = session.StatusPRMerged
+	StatusCanceled            = session.StatusCanceled
+)
+
+// CreateSessionResponse is returned by CreateSession.
+type CreateSessionResponse struct {
+	ID        string    `json:"id"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InstructResponse is returned by Instruct.
+type InstructResponse struct {
+	ID        string `json:"id"`
+	Status    Status `json:"status"`
+	Iteration int    `json:"iteration"`
+}
+
+// CancelResponse is returned by Cancel.
+type CancelResponse struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"` // "canceled" for queued sessions, "canceling" for running ones
+	Message string `json:"message"`
+}
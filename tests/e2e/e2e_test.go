@@ -18,6 +18,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -209,6 +210,56 @@ func TestE2ESessionSuccess(t *testing.T) {
 	t.Log("SUCCESS: full session lifecycle completed")
 }
 
+// Scenarios are scripted mock CLI runs (tests/mockcli/scenarios).
+func TestE2EMockScenarios(t *testing.T) {
+	tests := []struct {
+		scenario     string
+		wantStatus   string
+		wantResult   string
+		wantInput    float64
+		wantOutput   float64
+		wantCreated  float64
+		wantErrorSub string
+	}{
+		{scenario: "streaming", wantStatus: "completed", wantResult: "Added scenario_output.txt.", wantInput: 1200, wantOutput: 340, wantCreated: 1},
+		{scenario: "noisy", wantStatus: "completed", wantResult: "Survived the noise.", wantInput: 80, wantOutput: 20},
+		{scenario: "crash", wantStatus: "failed", wantErrorSub: "137"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			repoDir := createTestRepo(t, "scenario-"+tt.scenario)
+			sessionID := createSession(t, map[string]interface{}{
+				"repo_url": "file://" + repoDir,
+				"prompt":   "SCENARIO:" + tt.scenario,
+			})
+
+			result := waitForTerminal(t, sessionID, 60*time.Second)
+			if result["status"] != tt.wantStatus {
+				t.Fatalf("status = %v, want %s (error: %v)", result["status"], tt.wantStatus, result["error"])
+			}
+			if tt.wantErrorSub != "" {
+				if errMsg, _ := result["error"].(string); !strings.Contains(errMsg, tt.wantErrorSub) {
+					t.Errorf("error = %q, want it to mention %q", errMsg, tt.wantErrorSub)
+				}
+				return
+			}
+			if result["result"] != tt.wantResult {
+				t.Errorf("result = %v, want %q", result["result"], tt.wantResult)
+			}
+			usage, _ := result["usage"].(map[string]interface{})
+			if usage["input_tokens"] != tt.wantInput || usage["output_tokens"] != tt.wantOutput {
+				t.Errorf("usage = %v, want %v/%v tokens", usage, tt.wantInput, tt.wantOutput)
+			}
+			if tt.wantCreated > 0 {
+				changes, _ := result["changes_summary"].(map[string]interface{})
+				if changes["files_created"] != tt.wantCreated {
+					t.Errorf("changes_summary = %v, want %v created", changes, tt.wantCreated)
+				}
+			}
+		})
+	}
+}
+
 func TestE2ESessionCLIFailure(t *testing.T) {
 	repoDir := createTestRepo(t, "fail")
 
@@ -18,8 +18,23 @@ func main() {
 	_ = flag.String("model", "", "model")
 	_ = flag.Int("max-turns", 0, "max turns")
 	_ = flag.String("max-budget-usd", "", "max budget")
+	_ = flag.String("mcp-config", "", "MCP config path")
+	_ = flag.String("append-system-prompt", "", "system prompt suffix")
+	_ = flag.String("allowedTools", "", "tool allowlist")
+	_ = flag.Bool("bare", false, "agent mode")
 	flag.Parse()
 
+	// A scenario (MOCKCLI_SCENARIO or SCENARIO:<name> in the prompt) scripts
+	// the whole run — see scenario.go.
+	sc, err := selectScenario(*prompt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mock CLI: %v\n", err)
+		os.Exit(2)
+	}
+	if sc != nil {
+		os.Exit(sc.Play(os.Stdout, os.Stderr))
+	}
+
 	// Check for special prompts that trigger different behaviors
 	switch {
 	case *prompt == "TIMEOUT":
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//go:embed scenarios/*.json
+var builtinScenarios embed.FS
+
+// Scenario scripts one mock CLI run. Steps play in order; Result and Usage,
+// when set, end the run with a result event before the process exits with
+// ExitCode.
+type Scenario struct {
+	Steps    []Step  `json:"steps"`
+	Result   string  `json:"result,omitempty"`
+	Usage    *Usage  `json:"usage,omitempty"`
+	CostUSD  float64 `json:"cost_usd,omitempty"`
+	IsError  bool    `json:"is_error,omitempty"` // result subtype "error_during_execution"
+	ExitCode int     `json:"exit_code,omitempty"`
+}
+
+// Usage is the token count reported in the result event.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Step is a single scripted action; exactly one field is expected to be set.
+type Step struct {
+	Event   json.RawMessage `json:"event,omitempty"`    // stream-json line, emitted verbatim
+	Text    string          `json:"text,omitempty"`     // shorthand for an assistant text event
+	ToolUse string          `json:"tool_use,omitempty"` // shorthand for an assistant tool_use event (tool name)
+	Raw     string          `json:"raw,omitempty"`      // written as is — malformed JSON, partial lines
+	Stderr  string          `json:"stderr,omitempty"`   // noise on stderr
+	DelayMS int             `json:"delay_ms,omitempty"` // pause before the next step
+	Write   *FileWrite      `json:"write,omitempty"`    // create or overwrite a workspace file
+}
+
+// FileWrite changes the workspace so the run produces a diff.
+type FileWrite struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// scenarioKeyword selects a scenario from the prompt, e.g. "SCENARIO:tool_noise".
+var scenarioKeyword = regexp.MustCompile(`SCENARIO:([A-Za-z0-9_./-]+)`)
+
+// selectScenario resolves the scenario for this run: MOCKCLI_SCENARIO (a file
+// path) wins, then a SCENARIO:<name> keyword in the prompt, looked up in
+// MOCKCLI_SCENARIO_DIR and then among the built-in scenarios. No scenario
+// returns nil.
+func selectScenario(prompt string) (*Scenario, error) {
+	if path := os.Getenv("MOCKCLI_SCENARIO"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseScenario(data)
+	}
+
+	m := scenarioKeyword.FindStringSubmatch(prompt)
+	if m == nil {
+		return nil, nil
+	}
+	name := strings.TrimSuffix(m[1], ".json") + ".json"
+	if dir := os.Getenv("MOCKCLI_SCENARIO_DIR"); dir != "" {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.Base(name))); err == nil {
+			return parseScenario(data)
+		}
+	}
+	data, err := builtinScenarios.ReadFile("scenarios/" + filepath.Base(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown scenario %q", m[1])
+	}
+	return parseScenario(data)
+}
+
+func parseScenario(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parsing scenario: %w", err)
+	}
+	return &sc, nil
+}
+
+// Play runs the scenario and returns the exit code.
+func (sc *Scenario) Play(stdout, stderr io.Writer) int {
+	enc := json.NewEncoder(stdout)
+	for _, step := range sc.Steps {
+		switch {
+		case len(step.Event) > 0:
+			var line bytes.Buffer
+			_ = json.Compact(&line, step.Event) // one event per line, however the file is formatted
+			fmt.Fprintf(stdout, "%s\n", line.Bytes())
+		case step.Text != "":
+			_ = enc.Encode(assistant(map[string]interface{}{"type": "text", "text": step.Text}))
+		case step.ToolUse != "":
+			_ = enc.Encode(assistant(map[string]interface{}{
+				"type": "tool_use", "id": "toolu_mock", "name": step.ToolUse, "input": map[string]interface{}{},
+			}))
+		case step.Raw != "":
+			fmt.Fprintln(stdout, step.Raw)
+		case step.Stderr != "":
+			fmt.Fprintln(stderr, step.Stderr)
+		case step.Write != nil:
+			if err := writeWorkspaceFile(step.Write); err != nil {
+				fmt.Fprintf(stderr, "mock CLI: %v\n", err)
+				return 1
+			}
+		}
+		if step.DelayMS > 0 {
+			time.Sleep(time.Duration(step.DelayMS) * time.Millisecond)
+		}
+	}
+
+	if sc.Result != "" || sc.Usage != nil {
+		subtype := "success"
+		if sc.IsError {
+			subtype = "error_during_execution"
+		}
+		result := map[string]interface{}{
+			"type":     "result",
+			"subtype":  subtype,
+			"is_error": sc.IsError,
+			"result":   sc.Result,
+		}
+		if sc.Usage != nil {
+			result["usage"] = sc.Usage
+		}
+		if sc.CostUSD > 0 {
+			result["total_cost_usd"] = sc.CostUSD
+		}
+		_ = enc.Encode(result)
+	}
+	return sc.ExitCode
+}
+
+func assistant(content map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "assistant",
+		"message": map[string]interface{}{"content": []map[string]interface{}{content}},
+	}
+}
+
+// writeWorkspaceFile writes relative to the working directory (the session
+// workspace); paths escaping it are rejected.
+func writeWorkspaceFile(w *FileWrite) error {
+	clean := filepath.Clean(w.Path)
+	if filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return fmt.Errorf("write outside workspace: %s", w.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(clean), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(clean, []byte(w.Content), 0644)
+}
@@ -51,6 +51,10 @@ const statusConfig: Record<
     label: "PR created",
     tone: "border-info/30 bg-info/10 text-info",
   },
+  pr_merged: {
+    label: "PR merged",
+    tone: "border-ok/30 bg-ok/10 text-ok",
+  },
   cancelling: {
     label: "Canceling",
     tone: "border-warn/30 bg-warn/10 text-warn",
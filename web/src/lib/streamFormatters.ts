@@ -30,6 +30,10 @@ export function formatSystemEvent(
       return `Cloning ${obj.repo_url ?? "repository"}...`;
     case "clone_completed":
       return `Clone complete → ${obj.work_dir ?? "workspace"}`;
+    case "conflicts_detected": {
+      const files = Array.isArray(obj.files) ? (obj.files as string[]) : [];
+      return `Merge conflicts in ${files.length} file(s): ${files.join(", ")}`;
+    }
     case "task_timeout":
       return `Session timed out after ${obj.timeout_seconds ?? "?"}s`;
     case "task_cancelled":
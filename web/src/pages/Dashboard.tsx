@@ -26,6 +26,7 @@ const STATUS_COLORS: Record<string, string> = {
   reviewing: "var(--th-info)",
   creating_pr: "var(--th-info)",
   pr_created: "var(--th-info)",
+  pr_merged: "var(--th-ok)",
   cancelling: "var(--th-warn)",
   canceled: "var(--th-fg-4)",
 };
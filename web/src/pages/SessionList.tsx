@@ -36,6 +36,7 @@ const STATUS_FILTERS: {
   { label: "Canceled", value: "canceled", icon: Ban },
   { label: "Awaiting", value: "awaiting_instruction", icon: MessageSquare },
   { label: "PR created", value: "pr_created", icon: GitMerge },
+  { label: "PR merged", value: "pr_merged", icon: GitMerge },
 ];
 
 export default function SessionList() {
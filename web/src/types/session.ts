@@ -8,6 +8,7 @@ export type SessionStatus =
   | "reviewing"
   | "creating_pr"
   | "pr_created"
+  | "pr_merged"
   | "cancelling"
   | "canceled";
 